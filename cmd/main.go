@@ -1,15 +1,24 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/mugiliam/common/logtrace"
+	"github.com/mugiliam/hatchcatalogsrv/internal/catalogmanager"
 	"github.com/mugiliam/hatchcatalogsrv/internal/common"
 	"github.com/mugiliam/hatchcatalogsrv/internal/config"
+	"github.com/mugiliam/hatchcatalogsrv/internal/db"
+	"github.com/mugiliam/hatchcatalogsrv/internal/db/blobstore"
 	"github.com/mugiliam/hatchcatalogsrv/internal/server"
+	"github.com/mugiliam/hatchcatalogsrv/internal/tracing"
+	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 )
 
@@ -37,12 +46,128 @@ func main() {
 		slog.Error().Msg("server port not defined")
 		os.Exit(1)
 	}
+	if err := catalogmanager.SetRootNamespaceFromConfig(); err != nil {
+		slog.Error().Err(err).Msg("invalid default namespace")
+		os.Exit(1)
+	}
+	setLogLevel(config.Config())
+	config.OnReload(setLogLevel)
+	shutdownTracing, err := tracing.Init(context.Background(), config.Config().Tracing)
+	if err != nil {
+		slog.Error().Err(err).Msg("unable to initialize tracing")
+		os.Exit(1)
+	}
+	if err := db.InitReadReplica(slog.WithContext(context.Background()), config.Config().ReadReplicaDSN); err != nil {
+		slog.Error().Err(err).Msg("unable to connect to read replica")
+		os.Exit(1)
+	}
+	if err := blobstore.Init(config.Config().BlobStore); err != nil {
+		slog.Error().Err(err).Msg("unable to initialize blob store")
+		os.Exit(1)
+	}
 	s, err := server.CreateNewServer()
 	if err != nil {
 		slog.Error().Err(err).Msg("Unable to create server")
+		os.Exit(1)
 	}
 	s.MountHandlers()
-	http.ListenAndServe(":"+config.Config().ServerPort, s.Router)
+
+	err = run(s, *opt.configFile)
+	flushTraces(slog, shutdownTracing)
+	if err != nil {
+		os.Exit(1)
+	}
+}
+
+// flushTraces exports the spans still buffered by the tracer
+func flushTraces(slog zerolog.Logger, shutdown func(context.Context) error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := shutdown(ctx); err != nil {
+		slog.Error().Err(err).Msg("failed to flush traces")
+	}
+}
+
+// run serves requests until the listener fails or a termination signal is received. On a signal,
+// new connections are refused and in-flight requests are drained up to the configured timeout
+// before the database pool is closed. SIGHUP reloads the config from configFile.
+func run(s *server.HatchCatalogServer, configFile string) error {
+	slog := log.With().Str("state", "run").Logger()
+	srv := &http.Server{
+		Addr:    ":" + config.Config().ServerPort,
+		Handler: s.Router,
+	}
+
+	serveErr := make(chan error, 1)
+	go func() {
+		slog.Info().Str("addr", srv.Addr).Msg("starting server")
+		serveErr <- srv.ListenAndServe()
+	}()
+
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(sig)
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+wait:
+	for {
+		select {
+		case err := <-serveErr:
+			slog.Error().Err(err).Msg("server stopped unexpectedly")
+			closeDb(slog)
+			return err
+		case <-hup:
+			reloadConfig(slog, configFile)
+		case sg := <-sig:
+			slog.Info().Str("signal", sg.String()).Msg("shutting down server")
+			break wait
+		}
+	}
+
+	timeout := config.Config().ShutdownTimeoutDuration()
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	shutdownErr := srv.Shutdown(ctx)
+	if shutdownErr != nil {
+		slog.Error().Err(shutdownErr).Dur("timeout", timeout).Msg("timed out draining in-flight requests")
+	} else {
+		slog.Info().Msg("in-flight requests drained")
+	}
+	closeDb(slog)
+	return shutdownErr
+}
+
+func reloadConfig(slog zerolog.Logger, configFile string) {
+	slog.Info().Str("config_file", configFile).Msg("reloading config file")
+	ignored, err := config.ReloadConfig(configFile)
+	if err != nil {
+		slog.Error().Str("config_file", configFile).Err(err).Msg("unable to reload config file, keeping current config")
+		return
+	}
+	for _, name := range ignored {
+		slog.Warn().Str("setting", name).Msg("setting cannot be changed without a restart, ignoring new value")
+	}
+}
+
+// setLogLevel sets the global log level from the config. An empty or unknown level leaves it unchanged.
+func setLogLevel(c *config.ConfigParam) {
+	if c.LogLevel == "" {
+		return
+	}
+	level, err := zerolog.ParseLevel(c.LogLevel)
+	if err != nil {
+		log.Warn().Str("log_level", c.LogLevel).Msg("unknown log level")
+		return
+	}
+	zerolog.SetGlobalLevel(level)
+}
+
+func closeDb(slog zerolog.Logger) {
+	if err := db.Close(); err != nil {
+		slog.Error().Err(err).Msg("failed to close database pool")
+	}
 }
 
 func parseFlags() cmdoptions {
@@ -0,0 +1,102 @@
+package apis
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/mugiliam/common/apperrors"
+	"github.com/mugiliam/common/httpx"
+	"github.com/mugiliam/hatchcatalogsrv/internal/catalogmanager"
+	"github.com/mugiliam/hatchcatalogsrv/internal/common"
+)
+
+type gcResponse struct {
+	Deleted int `json:"deleted"`
+}
+
+// collectUnreferencedObjects deletes the tenant's catalog objects that are no longer referenced
+func collectUnreferencedObjects(r *http.Request) (*httpx.Response, error) {
+	ctx := r.Context()
+
+	deleted, err := catalogmanager.GCUnreferencedObjects(ctx, common.TenantIdFromContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+
+	j, jsonErr := json.Marshal(gcResponse{Deleted: deleted})
+	if jsonErr != nil {
+		return nil, catalogmanager.ErrCatalogError.Msg("unable to marshal gc result")
+	}
+	return &httpx.Response{
+		StatusCode: http.StatusOK,
+		Response:   j,
+	}, nil
+}
+
+type migrateResponse struct {
+	Migrated int `json:"migrated"`
+}
+
+// migrateVariant rewrites the schemas of the variant in the catalog context in the current object format
+func migrateVariant(r *http.Request) (*httpx.Response, error) {
+	ctx := r.Context()
+
+	n, err := getResourceName(r)
+	if err != nil {
+		return nil, err
+	}
+
+	migrated, err := catalogmanager.MigrateVariant(ctx, n)
+	if err != nil {
+		return nil, err
+	}
+
+	j, jsonErr := json.Marshal(migrateResponse{Migrated: migrated})
+	if jsonErr != nil {
+		return nil, catalogmanager.ErrCatalogError.Msg("unable to marshal migration result")
+	}
+	return &httpx.Response{
+		StatusCode: http.StatusOK,
+		Response:   j,
+	}, nil
+}
+
+// verifyReferences reports the schemas in the catalog context whose references are out of sync with the
+// collection schemas
+func verifyReferences(r *http.Request) (*httpx.Response, error) {
+	return referencesResponse(r, catalogmanager.VerifyReferences)
+}
+
+// repairReferences rewrites the references of the schemas in the catalog context to those computed from the
+// collection schemas
+func repairReferences(r *http.Request) (*httpx.Response, error) {
+	return referencesResponse(r, catalogmanager.RepairReferences)
+}
+
+func referencesResponse(r *http.Request, check func(context.Context, catalogmanager.Directories) (*catalogmanager.ReferenceReport, apperrors.Error)) (*httpx.Response, error) {
+	ctx := r.Context()
+
+	n, err := getResourceName(r)
+	if err != nil {
+		return nil, err
+	}
+
+	dir, err := catalogmanager.ReferenceDirectories(ctx, n)
+	if err != nil {
+		return nil, err
+	}
+	report, err := check(ctx, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	j, jsonErr := json.Marshal(report)
+	if jsonErr != nil {
+		return nil, catalogmanager.ErrCatalogError.Msg("unable to marshal reference report")
+	}
+	return &httpx.Response{
+		StatusCode: http.StatusOK,
+		Response:   j,
+	}, nil
+}
@@ -0,0 +1,161 @@
+package apis
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/mugiliam/common/httpx"
+	"github.com/mugiliam/hatchcatalogsrv/internal/catalogmanager"
+	"github.com/rs/zerolog/log"
+)
+
+// exportCatalog streams a tarball of YAML manifests for the objects in a variant of the catalog, ending
+// with a catalogmanager.ExportTrailerName entry.
+// The variant is resolved as by getArchiveVariant. If a workspace is present in the context, the workspace is
+// exported instead.
+func exportCatalog(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	n, err := getArchiveVariant(r)
+	if err != nil {
+		sendError(w, err)
+		return
+	}
+	export, err := catalogmanager.PrepareExport(ctx, n)
+	if err != nil {
+		sendError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/gzip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", n.Catalog+"-"+n.Variant+".tar.gz"))
+	w.WriteHeader(http.StatusOK)
+
+	// the status has already been sent at this point, so a failure can only truncate the archive. A
+	// truncated archive has no trailer entry, which is how the client tells it apart from a complete one.
+	if err := export.Write(ctx, w); err != nil {
+		log.Ctx(ctx).Error().Err(err).Str("catalog", n.Catalog).Str("variant", n.Variant).Msg("failed to export catalog")
+	}
+}
+
+// importCatalog applies a tarball of manifests to a fresh workspace in a variant of the catalog.
+// Set continueOnError=true to apply the remaining manifests when one fails, and commit=true to commit
+// the workspace to the variant once all manifests have been applied.
+func importCatalog(r *http.Request) (*httpx.Response, error) {
+	ctx := r.Context()
+
+	if r.Body == nil {
+		return nil, httpx.ErrInvalidRequest()
+	}
+
+	n, err := getArchiveVariant(r)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := catalogmanager.ImportOptions{
+		ContinueOnError: n.QueryParams.Get("continueOnError") == "true",
+		Commit:          n.QueryParams.Get("commit") == "true",
+	}
+	summary, err := catalogmanager.ImportVariant(ctx, r.Body, n, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	j, jsonErr := json.Marshal(summary)
+	if jsonErr != nil {
+		return nil, catalogmanager.ErrCatalogError.Msg("unable to marshal import summary")
+	}
+	rsp := &httpx.Response{
+		StatusCode: http.StatusCreated,
+		Response:   j,
+	}
+	if summary.RolledBack {
+		rsp.StatusCode = http.StatusUnprocessableEntity
+	} else if summary.Workspace != "" {
+		rsp.Location = "/workspaces/" + summary.Workspace
+	}
+	return rsp, nil
+}
+
+// replaceNamespaceContents makes the contents of a namespace those of a tarball of manifests, in the format
+// taken by importCatalog, creating and updating objects to match. Objects of the namespace that are not in the
+// tarball are deleted unless prune=false is set. The changes are committed to the variant together.
+func replaceNamespaceContents(r *http.Request) (*httpx.Response, error) {
+	ctx := r.Context()
+
+	if r.Body == nil {
+		return nil, httpx.ErrInvalidRequest()
+	}
+
+	n, err := getArchiveVariant(r)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := catalogmanager.NamespaceContentsOptions{
+		Prune: n.QueryParams.Get("prune") != "false",
+	}
+	summary, err := catalogmanager.ReplaceNamespaceContents(ctx, r.Body, n, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	j, jsonErr := json.Marshal(summary)
+	if jsonErr != nil {
+		return nil, catalogmanager.ErrCatalogError.Msg("unable to marshal namespace contents summary")
+	}
+	rsp := &httpx.Response{
+		StatusCode: http.StatusOK,
+		Response:   j,
+	}
+	if summary.RolledBack {
+		rsp.StatusCode = http.StatusUnprocessableEntity
+	}
+	return rsp, nil
+}
+
+// getArchiveVariant returns the request context of r with the catalog and variant resolved. The variant is
+// taken from the catalog context or the variant (or v) query parameter and defaults to the default variant of
+// the catalog.
+func getArchiveVariant(r *http.Request) (catalogmanager.RequestContext, error) {
+	ctx := r.Context()
+
+	n, err := getResourceName(r)
+	if err != nil {
+		return n, err
+	}
+	if n.Variant == "" {
+		n.Variant = getUrlValue(r.URL.Query(), "variant")
+	}
+	cm, err := catalogmanager.LoadCatalogManagerByName(ctx, n.Catalog)
+	if err != nil {
+		return n, err
+	}
+	if n.Variant == "" && n.VariantID == uuid.Nil {
+		n.Variant = cm.DefaultVariant()
+	}
+	vm, err := catalogmanager.LoadVariantManager(ctx, cm.ID(), n.VariantID, n.Variant)
+	if err != nil {
+		return n, err
+	}
+	n.Catalog = cm.Name()
+	n.CatalogID = cm.ID()
+	n.Variant = vm.Name()
+	n.VariantID = vm.ID()
+	return n, nil
+}
+
+// sendError writes err to w using the status code carried by the error, if any.
+func sendError(w http.ResponseWriter, err error) {
+	if e, ok := ToHttpxError(err).(*httpx.Error); ok {
+		e.Send(w)
+		return
+	}
+	(&httpx.Error{
+		StatusCode:  http.StatusInternalServerError,
+		Description: err.Error(),
+	}).Send(w)
+}
@@ -0,0 +1,46 @@
+package apis
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/mugiliam/common/httpx"
+	"github.com/mugiliam/hatchcatalogsrv/internal/catalogmanager"
+)
+
+type renameCatalogRequest struct {
+	NewName string `json:"newName"`
+}
+
+// renameCatalog renames the catalog in the URL. Its variants and objects are kept under the new name, and a
+// name that is taken by another catalog is a conflict.
+func renameCatalog(r *http.Request) (*httpx.Response, error) {
+	ctx := r.Context()
+
+	if r.Body == nil {
+		return nil, httpx.ErrInvalidRequest()
+	}
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, httpx.ErrUnableToReadRequest()
+	}
+	req := renameCatalogRequest{}
+	if err := json.Unmarshal(body, &req); err != nil {
+		return nil, httpx.ErrInvalidRequest("unable to parse request")
+	}
+	if req.NewName == "" {
+		return nil, httpx.ErrInvalidRequest("newName is required")
+	}
+
+	rsrc, err := catalogmanager.RenameCatalog(ctx, chi.URLParam(r, "catalogName"), req.NewName)
+	if err != nil {
+		return nil, err
+	}
+	return &httpx.Response{
+		StatusCode: http.StatusOK,
+		Location:   "/catalogs/" + req.NewName,
+		Response:   rsrc,
+	}, nil
+}
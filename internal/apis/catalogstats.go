@@ -0,0 +1,31 @@
+package apis
+
+import (
+	"net/http"
+
+	"github.com/mugiliam/common/httpx"
+	"github.com/mugiliam/hatchcatalogsrv/internal/catalogmanager"
+)
+
+// getCatalogStats returns the number of objects in a variant of the catalog in the URL. The variant is
+// taken from the v query parameter, or from the catalog context.
+func getCatalogStats(r *http.Request) (*httpx.Response, error) {
+	ctx := r.Context()
+
+	n, err := getResourceName(r)
+	if err != nil {
+		return nil, err
+	}
+	if v := getUrlValue(r.URL.Query(), "variant"); v != "" {
+		n.Variant, n.VariantID = getUUIDOrName(v)
+	}
+
+	rsrc, err := catalogmanager.GetCatalogStats(ctx, n)
+	if err != nil {
+		return nil, err
+	}
+	return &httpx.Response{
+		StatusCode: http.StatusOK,
+		Response:   rsrc,
+	}, nil
+}
@@ -0,0 +1,44 @@
+package apis
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/mugiliam/common/httpx"
+	"github.com/mugiliam/hatchcatalogsrv/internal/catalogmanager"
+)
+
+// checkParameterSchemaCompat reports the collection schemas and collections that the candidate parameter schema
+// in the request would break if it replaced the parameter schema named in the URL. Nothing is saved.
+func checkParameterSchemaCompat(r *http.Request) (*httpx.Response, error) {
+	ctx := r.Context()
+
+	if r.Body == nil {
+		return nil, httpx.ErrInvalidRequest()
+	}
+	req, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, httpx.ErrUnableToReadRequest()
+	}
+
+	n, err := getResourceName(r)
+	if err != nil {
+		return nil, err
+	}
+
+	report, err := catalogmanager.CheckParameterSchemaCompat(ctx, n, chi.URLParam(r, "paramName"), req)
+	if err != nil {
+		return nil, err
+	}
+
+	rsrc, jsonErr := json.Marshal(report)
+	if jsonErr != nil {
+		return nil, catalogmanager.ErrCatalogError.Msg("unable to marshal response")
+	}
+	return &httpx.Response{
+		StatusCode: http.StatusOK,
+		Response:   rsrc,
+	}, nil
+}
@@ -1,14 +1,21 @@
 package apis
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"io"
 	"net/http"
 
 	"github.com/mugiliam/common/httpx"
 	"github.com/mugiliam/hatchcatalogsrv/internal/catalogmanager"
 	"github.com/mugiliam/hatchcatalogsrv/pkg/types"
+	"github.com/rs/zerolog/log"
 )
 
+// IdempotencyKeyHeader lets a client retry a create safely. A retry with the same key and request gets the
+// response of the original request instead of a conflict.
+const IdempotencyKeyHeader = "Idempotency-Key"
+
 // Create a new resource object
 func createObject(r *http.Request) (*httpx.Response, error) {
 	ctx := r.Context()
@@ -26,6 +33,9 @@ func createObject(r *http.Request) (*httpx.Response, error) {
 	if err != nil {
 		return nil, err
 	}
+	if err := checkDirectWrite(r); err != nil {
+		return nil, err
+	}
 
 	kind := getResourceKind(r)
 	if kind == types.InvalidKind {
@@ -35,6 +45,48 @@ func createObject(r *http.Request) (*httpx.Response, error) {
 	if err := validateRequest(req, kind); err != nil {
 		return nil, err
 	}
+	if err := validateRequestContext(req, kind, n); err != nil {
+		return nil, err
+	}
+
+	idempotencyKey := r.Header.Get(IdempotencyKeyHeader)
+	var reqHash string
+	if idempotencyKey != "" {
+		reqHash = requestHash(r, req)
+		prev, err := catalogmanager.ReserveIdempotencyKey(ctx, idempotencyKey, reqHash)
+		if err != nil {
+			return nil, err
+		}
+		if prev != nil {
+			return &httpx.Response{
+				StatusCode: prev.StatusCode,
+				Location:   prev.Location,
+				Response:   prev.Response,
+			}, nil
+		}
+	}
+
+	rsp, err := createResource(r, kind, n, req)
+	if err != nil {
+		if idempotencyKey != "" {
+			catalogmanager.ReleaseIdempotencyKey(ctx, idempotencyKey)
+		}
+		return nil, err
+	}
+	if idempotencyKey != "" {
+		catalogmanager.SaveIdempotencyKey(ctx, idempotencyKey, reqHash, catalogmanager.IdempotentResponse{
+			StatusCode: rsp.StatusCode,
+			Location:   rsp.Location,
+			Response:   rsp.Response,
+		})
+	}
+
+	return rsp, nil
+}
+
+// createResource creates the resource of kind in the request body req
+func createResource(r *http.Request, kind string, n catalogmanager.RequestContext, req []byte) (*httpx.Response, error) {
+	ctx := r.Context()
 
 	rm, err := catalogmanager.ResourceManagerForKind(ctx, kind, n)
 	if err != nil {
@@ -48,8 +100,24 @@ func createObject(r *http.Request) (*httpx.Response, error) {
 	rsp := &httpx.Response{
 		StatusCode: http.StatusCreated,
 		Location:   resourceLoc,
-		Response:   nil,
+		Response:   saveWarnings(rm),
+	}
+	if returnRepresentation(r) {
+		// the object is created, so a failure to load it back only costs the client the body
+		if rsrc, err := rm.Get(ctx); err == nil {
+			rsp.Response = rsrc
+		} else {
+			log.Ctx(ctx).Error().Err(err).Str("location", resourceLoc).Msg("failed to load created object")
+		}
 	}
-
 	return rsp, nil
 }
+
+// requestHash identifies a request by its method, target and body. Idempotency keys are scoped to the project,
+// so the same key used in another project is a different key.
+func requestHash(r *http.Request, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(r.Method + " " + r.URL.RequestURI() + "\n"))
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
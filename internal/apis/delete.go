@@ -1,6 +1,7 @@
 package apis
 
 import (
+	"encoding/json"
 	"net/http"
 
 	"github.com/mugiliam/common/httpx"
@@ -15,11 +16,33 @@ func deleteObject(r *http.Request) (*httpx.Response, error) {
 	if err != nil {
 		return nil, err
 	}
+	if err := checkDirectWrite(r); err != nil {
+		return nil, err
+	}
 	kind := getResourceKind(r)
 	if kind == types.InvalidKind {
 		return nil, httpx.ErrInvalidRequest()
 	}
 
+	// a forced delete of a parameter schema reports the collection schemas that no longer refer to it
+	if kind == types.ParameterSchemaKind && r.URL.Query().Get("force") == "true" {
+		modified, err := catalogmanager.ForceDeleteParameter(ctx, n)
+		if err != nil {
+			return nil, err
+		}
+		if modified == nil {
+			modified = []string{}
+		}
+		j, jsonErr := json.Marshal(map[string][]string{"modifiedCollectionSchemas": modified})
+		if jsonErr != nil {
+			return nil, catalogmanager.ErrCatalogError.Msg("unable to marshal delete result")
+		}
+		return &httpx.Response{
+			StatusCode: http.StatusOK,
+			Response:   j,
+		}, nil
+	}
+
 	rm, err := catalogmanager.ResourceManagerForKind(ctx, kind, n)
 	if err != nil {
 		return nil, err
@@ -36,3 +59,55 @@ func deleteObject(r *http.Request) (*httpx.Response, error) {
 	}
 	return rsp, nil
 }
+
+type batchDeleteRequest struct {
+	Items []catalogmanager.BatchDeleteItem `json:"items"`
+}
+
+type batchDeleteResponse struct {
+	DryRun bool                               `json:"dryRun"`
+	Items  []catalogmanager.BatchDeleteResult `json:"items"`
+}
+
+// batchDeleteObjects returns a handler that deletes the objects listed in the request body, of type t unless an
+// item names another kind. Objects that are not found or that others still depend on are reported per item
+// instead of failing the request. With ?dryRun=true nothing is deleted.
+func batchDeleteObjects(t types.CatalogObjectType) func(r *http.Request) (*httpx.Response, error) {
+	return func(r *http.Request) (*httpx.Response, error) {
+		ctx := r.Context()
+
+		if r.Body == nil {
+			return nil, httpx.ErrInvalidRequest()
+		}
+		var req batchDeleteRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			return nil, httpx.ErrInvalidRequest("unable to parse request")
+		}
+
+		n, err := getResourceName(r)
+		if err != nil {
+			return nil, err
+		}
+		if err := checkDirectWrite(r); err != nil {
+			return nil, err
+		}
+
+		dryRun := r.URL.Query().Get("dryRun") == "true"
+		results, err := catalogmanager.BatchDelete(ctx, t, n, req.Items, dryRun)
+		if err != nil {
+			return nil, err
+		}
+		if results == nil {
+			results = []catalogmanager.BatchDeleteResult{}
+		}
+
+		rsrc, jsonErr := json.Marshal(batchDeleteResponse{DryRun: dryRun, Items: results})
+		if jsonErr != nil {
+			return nil, catalogmanager.ErrCatalogError.Msg("unable to marshal response")
+		}
+		return &httpx.Response{
+			StatusCode: http.StatusOK,
+			Response:   rsrc,
+		}, nil
+	}
+}
@@ -1,22 +1,120 @@
 package apis
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/mugiliam/common/apperrors"
 	"github.com/mugiliam/common/httpx"
+	"github.com/mugiliam/hatchcatalogsrv/internal/catalogmanager"
+	v1errors "github.com/mugiliam/hatchcatalogsrv/internal/catalogmanager/v1/errors"
+	"github.com/mugiliam/hatchcatalogsrv/internal/catalogmanager/validationerrors"
 )
 
+// errorStatusCodes maps the kinds of errors that handlers return to the status code of the response, so that
+// the same kind of failure gets the same status from every handler: a missing object is a 404, a delete or
+// move blocked by references is a 409 and a request that fails validation is a 400. An error is mapped by the
+// first entry it matches, so errors derived from another error are listed before it. Errors that match no
+// entry keep the status code they carry.
+var errorStatusCodes = []struct {
+	err        apperrors.Error
+	statusCode int
+}{
+	// not found
+	{catalogmanager.ErrCatalogNotFound, http.StatusNotFound},
+	{catalogmanager.ErrProjectNotFound, http.StatusNotFound},
+	{catalogmanager.ErrVariantNotFound, http.StatusNotFound},
+	{catalogmanager.ErrNamespaceNotFound, http.StatusNotFound},
+	{catalogmanager.ErrWorkspaceNotFound, http.StatusNotFound},
+	{catalogmanager.ErrVersionNotFound, http.StatusNotFound},
+	{catalogmanager.ErrCollectionSchemaNotFound, http.StatusNotFound},
+	{catalogmanager.ErrParentCollectionSchemaNotFound, http.StatusNotFound},
+	{catalogmanager.ErrObjectNotFound, http.StatusNotFound},
+	{v1errors.ErrObjectNotFound, http.StatusNotFound},
+
+	// blocked by the current state of the catalog
+	{catalogmanager.ErrUnableToDeleteParameterWithReferences, http.StatusConflict},
+	{catalogmanager.ErrUnableToDeleteCollectionWithReferences, http.StatusConflict},
+	{catalogmanager.ErrUnableToDeleteCollectionWithChildren, http.StatusConflict},
+	{catalogmanager.ErrUnableToDeleteDefaultVariant, http.StatusConflict},
+	{catalogmanager.ErrNoAncestorReferencesFound, http.StatusConflict},
+	{catalogmanager.ErrUnableToMoveCollectionWithReferences, http.StatusConflict},
+	{catalogmanager.ErrUnableToMoveObject, http.StatusConflict},
+	{catalogmanager.ErrInvalidDefaultInReference, http.StatusBadRequest}, // derived from ErrSchemaConflict
+	{catalogmanager.ErrSchemaConflict, http.StatusConflict},
+	{catalogmanager.ErrAlreadyExists, http.StatusConflict},
+	{catalogmanager.ErrEqualToExistingObject, http.StatusConflict},
+	{catalogmanager.ErrVersionLabelExists, http.StatusConflict},
+	{catalogmanager.ErrCollectionSchemaChanged, http.StatusConflict},
+	{catalogmanager.ErrIdempotencyKeyInProgress, http.StatusConflict},
+	{catalogmanager.ErrJSONPatchConflict, http.StatusConflict},
+	{catalogmanager.ErrConcurrentModification, http.StatusPreconditionFailed},
+
+	// not allowed
+	{catalogmanager.ErrQuotaExceeded, http.StatusForbidden},
+	{catalogmanager.ErrDirectWritesDisabled, http.StatusForbidden},
+	{catalogmanager.ErrRevealNotAllowed, http.StatusForbidden},
+
+	// invalid requests
+	{catalogmanager.ErrImportTooLarge, http.StatusRequestEntityTooLarge},
+	{catalogmanager.ErrIdempotencyKeyReused, http.StatusUnprocessableEntity},
+	{catalogmanager.ErrContextMismatch, http.StatusBadRequest},
+	{catalogmanager.ErrInvalidJSONPatch, http.StatusBadRequest},
+	{catalogmanager.ErrInvalidRequest, http.StatusBadRequest},
+	{catalogmanager.ErrMissingRequiredValues, http.StatusBadRequest},
+	{catalogmanager.ErrUnknownValueKeys, http.StatusBadRequest},
+	{catalogmanager.ErrInvalidParameter, http.StatusBadRequest},
+	{catalogmanager.ErrInvalidCollectionValues, http.StatusBadRequest},
+	{catalogmanager.ErrInvalidCollection, http.StatusBadRequest},
+	{catalogmanager.ErrInvalidCollectionSchema, http.StatusBadRequest},
+	{catalogmanager.ErrSchemaOfCollectionNotMutable, http.StatusBadRequest},
+	{catalogmanager.ErrInvalidSchema, http.StatusBadRequest},
+	{catalogmanager.ErrEmptyMetadata, http.StatusBadRequest},
+	{catalogmanager.ErrInvalidProject, http.StatusBadRequest},
+	{catalogmanager.ErrInvalidCatalog, http.StatusBadRequest},
+	{catalogmanager.ErrInvalidVariant, http.StatusBadRequest},
+	{catalogmanager.ErrInvalidNamespace, http.StatusBadRequest},
+	{catalogmanager.ErrInvalidWorkspace, http.StatusBadRequest},
+	{catalogmanager.ErrInvalidWorkspaceOrVariant, http.StatusBadRequest},
+	{catalogmanager.ErrInvalidObject, http.StatusBadRequest},
+	{catalogmanager.ErrInvalidVersionLabel, http.StatusBadRequest},
+	{catalogmanager.ErrInvalidVersion, http.StatusBadRequest},
+	{catalogmanager.ErrInvalidVersionOrWorkspace, http.StatusBadRequest},
+	{catalogmanager.ErrInvalidUUID, http.StatusBadRequest},
+	{validationerrors.ErrSchemaValidation, http.StatusBadRequest},
+	{validationerrors.ErrValueValidation, http.StatusBadRequest},
+}
+
+// statusCodeOf returns the status code of the response to a request that failed with appErr
+func statusCodeOf(appErr apperrors.Error) int {
+	for _, e := range errorStatusCodes {
+		if errors.Is(appErr, e.err) {
+			return e.statusCode
+		}
+	}
+	if statusCode := appErr.StatusCode(); statusCode != 0 {
+		return statusCode
+	}
+	return http.StatusInternalServerError
+}
+
 func ToHttpxError(err error) error {
 	if appErr, ok := err.(apperrors.Error); ok {
-		statusCode := appErr.StatusCode()
-		if statusCode == 0 {
-			statusCode = http.StatusInternalServerError
-		}
 		return &httpx.Error{
-			StatusCode:  statusCode,
+			StatusCode:  statusCodeOf(appErr),
 			Description: appErr.ErrorAll(),
 		}
 	}
 	return err
 }
+
+// mapErrors returns handler with the errors it returns mapped to the status codes in errorStatusCodes
+func mapErrors(handler func(r *http.Request) (*httpx.Response, error)) func(r *http.Request) (*httpx.Response, error) {
+	return func(r *http.Request) (*httpx.Response, error) {
+		rsp, err := handler(r)
+		if err != nil {
+			return rsp, ToHttpxError(err)
+		}
+		return rsp, nil
+	}
+}
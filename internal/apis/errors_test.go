@@ -1,10 +1,12 @@
 package apis
 
 import (
+	"net/http"
 	"testing"
 
 	"github.com/mugiliam/common/apperrors"
 	"github.com/mugiliam/common/httpx"
+	"github.com/mugiliam/hatchcatalogsrv/internal/catalogmanager"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -17,3 +19,24 @@ func TestError(t *testing.T) {
 	assert.Equal(t, 500, herr.(*httpx.Error).StatusCode)
 	assert.Equal(t, "test error", herr.(*httpx.Error).Description)
 }
+
+func TestErrorStatusCodes(t *testing.T) {
+	for _, e := range errorStatusCodes {
+		t.Run(e.err.Error(), func(t *testing.T) {
+			for _, err := range []apperrors.Error{e.err, e.err.Msg("detail"), e.err.New("derived")} {
+				herr := ToHttpxError(err)
+				assert.Equal(t, e.statusCode, herr.(*httpx.Error).StatusCode, err.ErrorAll())
+			}
+		})
+	}
+
+	// errors outside the table keep their own status code, or 500 if they have none
+	assert.Equal(t, http.StatusTeapot, ToHttpxError(apperrors.New("teapot").SetStatusCode(http.StatusTeapot)).(*httpx.Error).StatusCode)
+	assert.Equal(t, http.StatusInternalServerError, ToHttpxError(apperrors.New("no status")).(*httpx.Error).StatusCode)
+
+	// derived errors listed before the error they derive from take their own status code
+	assert.Equal(t, http.StatusBadRequest, ToHttpxError(catalogmanager.ErrInvalidDefaultInReference.Msg("x")).(*httpx.Error).StatusCode)
+	assert.Equal(t, http.StatusConflict, ToHttpxError(catalogmanager.ErrSchemaConflict.Msg("x")).(*httpx.Error).StatusCode)
+	assert.Equal(t, http.StatusNotFound, ToHttpxError(catalogmanager.ErrObjectNotFound.Msg("x")).(*httpx.Error).StatusCode)
+	assert.Equal(t, http.StatusConflict, ToHttpxError(catalogmanager.ErrUnableToDeleteParameterWithReferences).(*httpx.Error).StatusCode)
+}
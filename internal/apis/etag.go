@@ -0,0 +1,76 @@
+package apis
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/mugiliam/hatchcatalogsrv/internal/catalogmanager"
+	"github.com/mugiliam/hatchcatalogsrv/pkg/types"
+)
+
+// conditionalGet sets the ETag of the object that a GET of a schema or collection returns, and answers with
+// 304 Not Modified if it matches the If-None-Match header of the request. The ETag comes from the directory
+// entry of the object, so an unchanged object is neither loaded nor sent. The JSON and YAML documents of an
+// object are different representations, so they get different ETags and the response varies by Accept.
+func conditionalGet(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		etag := objectETag(r)
+		if etag == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if acceptsYAML(r) {
+			etag = strings.TrimSuffix(etag, `"`) + `-yaml"`
+		}
+		w.Header().Add("Vary", "Accept")
+		w.Header().Set("ETag", etag)
+		if etagMatches(r.Header.Get("If-None-Match"), etag) {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// objectETag returns the ETag of the object that a GET of r returns, or "" if the response is more than the
+// stored object or the object cannot be found. Errors are left to the handler to report.
+func objectETag(r *http.Request) string {
+	if chi.URLParam(r, "objectType") == "" || r.URL.Query().Has("expand") {
+		return ""
+	}
+	kind := getResourceKind(r)
+	if kind != types.ParameterSchemaKind && kind != types.CollectionSchemaKind && kind != types.CollectionKind {
+		return ""
+	}
+	n, err := getResourceName(r)
+	if err != nil {
+		return ""
+	}
+	// the history and resolved values of a collection are not the collection itself
+	if kind == types.CollectionKind &&
+		(r.URL.Query().Get(collectionHistoryParam) == "true" || r.URL.Query().Get(collectionResolvedParam) == "true") {
+		return ""
+	}
+	etag, err := catalogmanager.ObjectETag(r.Context(), kind, n)
+	if err != nil {
+		return ""
+	}
+	return etag
+}
+
+// etagMatches reports whether the If-None-Match header value ifNoneMatch lists etag, using the weak comparison
+// that RFC 9110 requires for If-None-Match
+func etagMatches(ifNoneMatch, etag string) bool {
+	if ifNoneMatch == "" {
+		return false
+	}
+	etag = strings.TrimPrefix(etag, "W/")
+	for _, tag := range strings.Split(ifNoneMatch, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" || strings.TrimPrefix(tag, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
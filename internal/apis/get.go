@@ -1,13 +1,22 @@
 package apis
 
 import (
+	"encoding/json"
 	"net/http"
+	"strconv"
 
+	"github.com/go-chi/chi/v5"
 	"github.com/mugiliam/common/httpx"
 	"github.com/mugiliam/hatchcatalogsrv/internal/catalogmanager"
 	"github.com/mugiliam/hatchcatalogsrv/pkg/types"
 )
 
+// collectionHistoryParam is the query parameter that requests the history of a collection
+const collectionHistoryParam = "history"
+
+// collectionResolvedParam is the query parameter that requests the resolved values of a collection
+const collectionResolvedParam = "resolved"
+
 func getObject(r *http.Request) (*httpx.Response, error) {
 	ctx := r.Context()
 	var kind string
@@ -22,6 +31,30 @@ func getObject(r *http.Request) (*httpx.Response, error) {
 		return nil, httpx.ErrInvalidRequest()
 	}
 
+	// GET /collections/{path}?history=true lists the prior values of the collection at path
+	if kind == types.CollectionKind && r.URL.Query().Get(collectionHistoryParam) == "true" {
+		history, err := catalogmanager.GetCollectionHistory(ctx, n)
+		if err != nil {
+			return nil, err
+		}
+		return &httpx.Response{
+			StatusCode: http.StatusOK,
+			Response:   history,
+		}, nil
+	}
+
+	// GET /collections/{path}?resolved=true returns the effective values of the collection at path
+	if kind == types.CollectionKind && r.URL.Query().Get(collectionResolvedParam) == "true" {
+		resolved, err := catalogmanager.GetResolvedCollection(ctx, n)
+		if err != nil {
+			return nil, err
+		}
+		return &httpx.Response{
+			StatusCode: http.StatusOK,
+			Response:   resolved,
+		}, nil
+	}
+
 	rm, err := catalogmanager.ResourceManagerForKind(ctx, kind, n)
 	if err != nil {
 		return nil, err
@@ -38,3 +71,161 @@ func getObject(r *http.Request) (*httpx.Response, error) {
 	}
 	return rsp, nil
 }
+
+// listCollections returns the collections whose path is the pathPrefix query parameter or lies under it.
+// All collections in the namespace are returned if pathPrefix is not set. The list is ordered by path and can
+// be paged with the limit and offset query parameters.
+func listCollections(r *http.Request) (*httpx.Response, error) {
+	ctx := r.Context()
+
+	n, err := getResourceName(r)
+	if err != nil {
+		return nil, err
+	}
+	limit, offset, err := listPaging(r)
+	if err != nil {
+		return nil, err
+	}
+
+	rsrc, err := catalogmanager.ListCollectionsByPathPrefix(ctx, n, r.URL.Query().Get("pathPrefix"), limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	return &httpx.Response{
+		StatusCode: http.StatusOK,
+		Response:   rsrc,
+	}, nil
+}
+
+// listPaging returns the limit and offset query parameters of a list request
+func listPaging(r *http.Request) (limit, offset int, err error) {
+	if v := r.URL.Query().Get("limit"); v != "" {
+		if limit, err = strconv.Atoi(v); err != nil || limit < 0 {
+			return 0, 0, httpx.ErrInvalidRequest("limit must be a non-negative number")
+		}
+	}
+	if v := r.URL.Query().Get("offset"); v != "" {
+		if offset, err = strconv.Atoi(v); err != nil || offset < 0 {
+			return 0, 0, httpx.ErrInvalidRequest("offset must be a non-negative number")
+		}
+	}
+	return limit, offset, nil
+}
+
+// resolveParameterSchema returns the parameter schema that a collection at the path in the
+// "path" query parameter would bind to for the parameter name in the URL.
+func resolveParameterSchema(r *http.Request) (*httpx.Response, error) {
+	ctx := r.Context()
+
+	n, err := getResourceName(r)
+	if err != nil {
+		return nil, err
+	}
+
+	resolved, err := catalogmanager.ResolveParameterSchema(ctx, n, chi.URLParam(r, "paramName"), r.URL.Query().Get("path"))
+	if err != nil {
+		return nil, err
+	}
+
+	rsrc, jsonErr := json.Marshal(resolved)
+	if jsonErr != nil {
+		return nil, catalogmanager.ErrCatalogError.Msg("unable to marshal response")
+	}
+	rsp := &httpx.Response{
+		StatusCode: http.StatusOK,
+		Response:   rsrc,
+	}
+	return rsp, nil
+}
+
+// getParameterSchemaDefault returns the default of the parameter schema that a collection at the path in the
+// "path" query parameter would bind to for the parameter name in the URL.
+func getParameterSchemaDefault(r *http.Request) (*httpx.Response, error) {
+	ctx := r.Context()
+
+	n, err := getResourceName(r)
+	if err != nil {
+		return nil, err
+	}
+
+	def, err := catalogmanager.GetParameterSchemaDefault(ctx, n, chi.URLParam(r, "paramName"), r.URL.Query().Get("path"))
+	if err != nil {
+		return nil, err
+	}
+
+	rsrc, jsonErr := json.Marshal(def)
+	if jsonErr != nil {
+		return nil, catalogmanager.ErrCatalogError.Msg("unable to marshal response")
+	}
+	return &httpx.Response{
+		StatusCode: http.StatusOK,
+		Response:   rsrc,
+	}, nil
+}
+
+// getCollectionSchemaJSONSchema returns the JSON Schema that describes the values of collections based on the
+// collection schema in the URL.
+func getCollectionSchemaJSONSchema(r *http.Request) (*httpx.Response, error) {
+	ctx := r.Context()
+
+	n, err := getResourceName(r)
+	if err != nil {
+		return nil, err
+	}
+
+	rsrc, err := catalogmanager.GenerateJSONSchema(ctx, n, chi.URLParam(r, "collectionSchemaName"))
+	if err != nil {
+		return nil, err
+	}
+	rsp := &httpx.Response{
+		StatusCode: http.StatusOK,
+		Response:   rsrc,
+	}
+	return rsp, nil
+}
+
+type batchGetRequest struct {
+	Items []catalogmanager.BatchGetItem `json:"items"`
+}
+
+type batchGetResponse struct {
+	Items []catalogmanager.BatchGetResult `json:"items"`
+}
+
+// batchGetSchemas returns a handler that loads the schemas of type t listed in the request body in one
+// request. Objects that do not exist are marked as not found instead of failing the request.
+func batchGetSchemas(t types.CatalogObjectType) func(r *http.Request) (*httpx.Response, error) {
+	return func(r *http.Request) (*httpx.Response, error) {
+		ctx := r.Context()
+
+		if r.Body == nil {
+			return nil, httpx.ErrInvalidRequest()
+		}
+		var req batchGetRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			return nil, httpx.ErrInvalidRequest("unable to parse request")
+		}
+
+		n, err := getResourceName(r)
+		if err != nil {
+			return nil, err
+		}
+
+		results, err := catalogmanager.BatchGetSchemas(ctx, t, n, req.Items)
+		if err != nil {
+			return nil, err
+		}
+		if results == nil {
+			results = []catalogmanager.BatchGetResult{}
+		}
+
+		rsrc, jsonErr := json.Marshal(batchGetResponse{Items: results})
+		if jsonErr != nil {
+			return nil, catalogmanager.ErrCatalogError.Msg("unable to marshal response")
+		}
+		return &httpx.Response{
+			StatusCode: http.StatusOK,
+			Response:   rsrc,
+		}, nil
+	}
+}
@@ -0,0 +1,64 @@
+package apis
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"github.com/mugiliam/common/httpx"
+	"github.com/mugiliam/hatchcatalogsrv/internal/catalogmanager"
+	"github.com/tidwall/gjson"
+)
+
+type lintResponse struct {
+	Warnings []catalogmanager.LintWarning `json:"warnings"`
+}
+
+// saveWarnings returns the response body reporting the warnings rm raised while saving an object, or nil if
+// there were none
+func saveWarnings(rm any) []byte {
+	wr, ok := rm.(catalogmanager.WarningReporter)
+	if !ok || len(wr.Warnings()) == 0 {
+		return nil
+	}
+	j, err := json.Marshal(lintResponse{Warnings: wr.Warnings()})
+	if err != nil {
+		return nil
+	}
+	return j
+}
+
+// lintObject reports the lint warnings for the schema in the request without saving it
+func lintObject(r *http.Request) (*httpx.Response, error) {
+	ctx := r.Context()
+
+	if r.Body == nil {
+		return nil, httpx.ErrInvalidRequest()
+	}
+	req, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, httpx.ErrUnableToReadRequest()
+	}
+	if !gjson.ValidBytes(req) {
+		return nil, httpx.ErrInvalidRequest("unable to parse request")
+	}
+
+	n, err := getResourceName(r)
+	if err != nil {
+		return nil, err
+	}
+
+	warnings, err := catalogmanager.LintSchema(ctx, n, req)
+	if err != nil {
+		return nil, err
+	}
+
+	j, jsonErr := json.Marshal(lintResponse{Warnings: warnings})
+	if jsonErr != nil {
+		return nil, catalogmanager.ErrCatalogError.Msg("unable to marshal lint result")
+	}
+	return &httpx.Response{
+		StatusCode: http.StatusOK,
+		Response:   j,
+	}, nil
+}
@@ -2,11 +2,18 @@ package apis
 
 import (
 	"context"
+	"errors"
 	"net/url"
 
 	"github.com/google/uuid"
+	"github.com/mugiliam/common/apperrors"
+	"github.com/mugiliam/hatchcatalogsrv/internal/catalogmanager"
 	"github.com/mugiliam/hatchcatalogsrv/internal/common"
 	"github.com/mugiliam/hatchcatalogsrv/internal/db"
+	"github.com/mugiliam/hatchcatalogsrv/internal/db/dberror"
+	"github.com/mugiliam/hatchcatalogsrv/internal/db/models"
+	"github.com/mugiliam/hatchcatalogsrv/pkg/types"
+	"github.com/rs/zerolog/log"
 )
 
 func loadCatalogObject(ctx context.Context, c *common.CatalogContext, urlValues url.Values) (*common.CatalogContext, error) {
@@ -87,18 +94,16 @@ func loadWorkspaceObject(ctx context.Context, c *common.CatalogContext, urlValue
 				return nil, err
 			}
 		} else {
-			workspaceLabel := getUrlValue(urlValues, "workspace")
-			if workspaceLabel != "" {
-				c.WorkspaceLabel = workspaceLabel
-			}
+			// like a workspace in the path, the workspace can be given by label or by ID
+			c.WorkspaceLabel, c.WorkspaceId = getUUIDOrName(getUrlValue(urlValues, "workspace"))
 		}
 	}
 	if c.WorkspaceId != uuid.Nil {
+		workspace, err := workspaceOfVariant(ctx, c.WorkspaceId, c.CatalogId, c.VariantId)
+		if err != nil {
+			return nil, err
+		}
 		if c.WorkspaceLabel == "" {
-			workspace, err := db.DB(ctx).GetWorkspace(ctx, c.WorkspaceId)
-			if err != nil {
-				return nil, err
-			}
 			c.WorkspaceLabel = workspace.Label
 		}
 	} else if c.WorkspaceLabel != "" {
@@ -111,11 +116,45 @@ func loadWorkspaceObject(ctx context.Context, c *common.CatalogContext, urlValue
 	return c, nil
 }
 
+// workspaceOfVariant loads the workspace with workspaceID and checks that it is a workspace of the variant with
+// variantID, or of the catalog with catalogID if the variant is not known. A workspace given by label is looked
+// up in its variant, but one given by ID could be a workspace of any variant of the tenant, so a workspace of
+// another variant is not found.
+func workspaceOfVariant(ctx context.Context, workspaceID, catalogID, variantID uuid.UUID) (*models.Workspace, apperrors.Error) {
+	notFound := catalogmanager.ErrWorkspaceNotFound.Msg("workspace " + workspaceID.String() + " not found")
+	workspace, err := db.DB(ctx).GetWorkspace(ctx, workspaceID)
+	if err != nil {
+		if errors.Is(err, dberror.ErrNotFound) {
+			return nil, notFound
+		}
+		return nil, catalogmanager.ErrCatalogError.Err(err)
+	}
+	if variantID != uuid.Nil {
+		if workspace.VariantID != variantID {
+			log.Ctx(ctx).Warn().Str("workspace_id", workspaceID.String()).Str("variant_id", variantID.String()).Msg("workspace is not in the variant of the request")
+			return nil, notFound
+		}
+	} else if catalogID != uuid.Nil {
+		variant, err := db.DB(ctx).GetVariant(ctx, catalogID, workspace.VariantID, "")
+		if err != nil && !errors.Is(err, dberror.ErrNotFound) {
+			return nil, catalogmanager.ErrCatalogError.Err(err)
+		}
+		if err != nil || variant.CatalogID != catalogID {
+			log.Ctx(ctx).Warn().Str("workspace_id", workspaceID.String()).Str("catalog_id", catalogID.String()).Msg("workspace is not in the catalog of the request")
+			return nil, notFound
+		}
+	}
+	return workspace, nil
+}
+
 func loadNamespaceObject(ctx context.Context, c *common.CatalogContext, urlValues url.Values) (*common.CatalogContext, error) {
 	var _ = ctx
 	if c.Namespace == "" {
 		c.Namespace = getUrlValue(urlValues, "namespace")
 	}
+	if types.IsRootNamespace(c.Namespace) {
+		c.Namespace = "" // an explicit --root-- is the same as no namespace
+	}
 	return c, nil
 }
 
@@ -0,0 +1,103 @@
+package apis
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/mugiliam/hatchcatalogsrv/internal/catalogmanager"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	mediaTypeNDJSON = "application/x-ndjson"
+
+	// formatNDJSON is the value of the format query parameter that asks for a list as newline delimited JSON
+	formatNDJSON = "ndjson"
+
+	// ndjsonFlushInterval is the number of records written to a stream between flushes
+	ndjsonFlushInterval = 100
+)
+
+// ndjsonTrailer is the last record of an NDJSON stream, under the "trailer" key. It has the number of records
+// before it, and the error that ended the stream early if there was one. A stream without a trailer was cut
+// short.
+type ndjsonTrailer struct {
+	Count int    `json:"count"`
+	Error string `json:"error,omitempty"`
+}
+
+// streamCollections lists the same collections as listCollections, as one JSON object per line. Each
+// collection is written as it is read from the database, so the list is never held in memory.
+func streamCollections(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	n, err := getResourceName(r)
+	if err != nil {
+		sendError(w, err)
+		return
+	}
+	limit, offset, err := listPaging(r)
+	if err != nil {
+		sendError(w, err)
+		return
+	}
+	stream, err := catalogmanager.PrepareCollectionStream(ctx, n, r.URL.Query().Get("pathPrefix"), limit, offset)
+	if err != nil {
+		sendError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", mediaTypeNDJSON)
+	w.WriteHeader(http.StatusOK)
+	nw := newNDJSONWriter(w)
+
+	// the status has already been sent at this point, so a failure is reported in the trailer
+	var trailer ndjsonTrailer
+	if err := stream.Each(ctx, func(e catalogmanager.CollectionListEntry) error {
+		return nw.write(e)
+	}); err != nil {
+		log.Ctx(ctx).Error().Err(err).Str("namespace", stream.Namespace()).Msg("failed to stream collections")
+		trailer.Error = err.Error()
+	}
+	trailer.Count = nw.count
+	if err := nw.writeTrailer(trailer); err != nil {
+		log.Ctx(ctx).Error().Err(err).Msg("failed to write trailer of collection stream")
+	}
+}
+
+// ndjsonWriter writes records to a response as newline delimited JSON, flushing them to the client every
+// ndjsonFlushInterval records
+type ndjsonWriter struct {
+	w     http.ResponseWriter
+	enc   *json.Encoder
+	count int
+}
+
+func newNDJSONWriter(w http.ResponseWriter) *ndjsonWriter {
+	return &ndjsonWriter{w: w, enc: json.NewEncoder(w)}
+}
+
+func (nw *ndjsonWriter) write(v any) error {
+	// the encoder ends each record with a newline
+	if err := nw.enc.Encode(v); err != nil {
+		return err
+	}
+	nw.count++
+	if nw.count == 1 || nw.count%ndjsonFlushInterval == 0 {
+		nw.flush()
+	}
+	return nil
+}
+
+func (nw *ndjsonWriter) writeTrailer(t ndjsonTrailer) error {
+	defer nw.flush()
+	return nw.enc.Encode(struct {
+		Trailer ndjsonTrailer `json:"trailer"`
+	}{t})
+}
+
+func (nw *ndjsonWriter) flush() {
+	if f, ok := nw.w.(http.Flusher); ok {
+		f.Flush()
+	}
+}
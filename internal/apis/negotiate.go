@@ -0,0 +1,94 @@
+package apis
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	mediaTypeJSON = "application/json"
+	mediaTypeYAML = "application/yaml"
+)
+
+// yamlMediaTypes are the media types in an Accept header that ask for a YAML response
+var yamlMediaTypes = map[string]bool{
+	mediaTypeYAML:        true,
+	"application/x-yaml": true,
+	"text/yaml":          true,
+}
+
+// acceptsYAML reports whether the Accept header of r asks for YAML ahead of JSON. The types are taken in the
+// order they are listed; quality values are not considered.
+func acceptsYAML(r *http.Request) bool {
+	for _, v := range r.Header.Values("Accept") {
+		for _, part := range strings.Split(v, ",") {
+			mt, _, err := mime.ParseMediaType(strings.TrimSpace(part))
+			if err != nil {
+				continue
+			}
+			if yamlMediaTypes[mt] {
+				return true
+			}
+			if mt == mediaTypeJSON || mt == "*/*" {
+				return false
+			}
+		}
+	}
+	return false
+}
+
+// negotiateYAML serves the successful JSON responses of next as YAML when the request accepts YAML. Keys are
+// sorted in the output, so the same object always produces the same document, and the document converts back
+// to the JSON that was returned. Error responses are left as JSON.
+func negotiateYAML(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !acceptsYAML(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		bw := &bufferedResponseWriter{header: make(http.Header), status: http.StatusOK}
+		next.ServeHTTP(bw, r)
+
+		body := bw.body.Bytes()
+		if bw.status >= 200 && bw.status < 300 && len(body) > 0 && json.Valid(body) {
+			if y, err := yaml.JSONToYAML(body); err == nil {
+				body = y
+				bw.header.Set("Content-Type", mediaTypeYAML)
+				bw.header.Set("Content-Length", strconv.Itoa(len(body)))
+			} else {
+				log.Ctx(r.Context()).Error().Err(err).Msg("failed to convert response to yaml")
+			}
+		}
+		for k, v := range bw.header {
+			w.Header()[k] = v
+		}
+		w.WriteHeader(bw.status)
+		_, _ = w.Write(body)
+	})
+}
+
+// bufferedResponseWriter holds a response so that it can be rewritten before it is sent
+type bufferedResponseWriter struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (b *bufferedResponseWriter) Header() http.Header {
+	return b.header
+}
+
+func (b *bufferedResponseWriter) WriteHeader(status int) {
+	b.status = status
+}
+
+func (b *bufferedResponseWriter) Write(p []byte) (int, error) {
+	return b.body.Write(p)
+}
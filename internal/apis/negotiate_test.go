@@ -0,0 +1,56 @@
+package apis
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"sigs.k8s.io/yaml"
+)
+
+func TestNegotiateYAML(t *testing.T) {
+	body := `{"version":"v1","kind":"ParameterSchema","metadata":{"name":"p","catalog":"c"},"spec":{"dataType":"Integer","default":5}}`
+	h := negotiateYAML(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", mediaTypeJSON)
+		if r.URL.Path == "/missing" {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"error":"not found"}`))
+			return
+		}
+		_, _ = w.Write([]byte(body))
+	}))
+	get := func(path string, accept ...string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		for _, a := range accept {
+			req.Header.Add("Accept", a)
+		}
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+		return rr
+	}
+
+	// json is the default
+	for _, accept := range [][]string{nil, {"application/json"}, {"*/*"}, {"application/json, application/yaml"}} {
+		rr := get("/object", accept...)
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, mediaTypeJSON, rr.Header().Get("Content-Type"), accept)
+		assert.JSONEq(t, body, rr.Body.String())
+	}
+
+	// yaml converts back to the same json, with keys in a stable order
+	rr := get("/object", "application/yaml, application/json")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, mediaTypeYAML, rr.Header().Get("Content-Type"))
+	assert.Equal(t, "kind: ParameterSchema\nmetadata:\n  catalog: c\n  name: p\nspec:\n  dataType: Integer\n  default: 5\nversion: v1\n", rr.Body.String())
+	j, err := yaml.YAMLToJSON(rr.Body.Bytes())
+	assert.NoError(t, err)
+	assert.JSONEq(t, body, string(j))
+	assert.Equal(t, rr.Body.String(), get("/object", "application/x-yaml").Body.String())
+
+	// errors stay json
+	rr = get("/missing", "application/yaml")
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+	assert.Equal(t, mediaTypeJSON, rr.Header().Get("Content-Type"))
+	assert.JSONEq(t, `{"error":"not found"}`, rr.Body.String())
+}
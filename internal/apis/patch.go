@@ -0,0 +1,106 @@
+package apis
+
+import (
+	"io"
+	"mime"
+	"net/http"
+
+	"github.com/mugiliam/common/httpx"
+	"github.com/mugiliam/hatchcatalogsrv/internal/catalogmanager"
+	"github.com/mugiliam/hatchcatalogsrv/pkg/types"
+)
+
+// mediaTypeJSONPatch is the content type of a JSON Patch document, see RFC 6902
+const mediaTypeJSONPatch = "application/json-patch+json"
+
+// patchObject applies a JSON merge patch to the values of a collection, or a JSON Patch to a schema when the
+// request has the JSON Patch content type
+func patchObject(r *http.Request) (*httpx.Response, error) {
+	ctx := r.Context()
+
+	n, err := getResourceName(r)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkDirectWrite(r); err != nil {
+		return nil, err
+	}
+	if mt, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mt == mediaTypeJSONPatch {
+		return patchSchema(r, n)
+	}
+	if getResourceKind(r) != types.CollectionKind {
+		return nil, httpx.ErrInvalidRequest("patch is only supported for collections")
+	}
+
+	if r.Body == nil {
+		return nil, httpx.ErrInvalidRequest()
+	}
+	req, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, httpx.ErrUnableToReadRequest()
+	}
+	if err := validatePatchRequest(req, types.CollectionKind); err != nil {
+		return nil, err
+	}
+	if err := validateRequestContext(req, types.CollectionKind, n); err != nil {
+		return nil, err
+	}
+
+	err = catalogmanager.PatchCollection(ctx, n, req)
+	if err != nil {
+		return nil, err
+	}
+
+	rsp := &httpx.Response{
+		StatusCode: http.StatusOK,
+		Response:   nil,
+	}
+	return rsp, nil
+}
+
+// patchSchema applies the JSON Patch in the request to the stored json of the schema named by n and saves the
+// result as an update of the schema. A patch that cannot be applied is answered with a 409, and one that
+// produces an invalid schema with a 400.
+func patchSchema(r *http.Request, n catalogmanager.RequestContext) (*httpx.Response, error) {
+	ctx := r.Context()
+	kind := getResourceKind(r)
+	if kind != types.ParameterSchemaKind && kind != types.CollectionSchemaKind {
+		return nil, httpx.ErrInvalidRequest("json patch is only supported for schemas")
+	}
+
+	if r.Body == nil {
+		return nil, httpx.ErrInvalidRequest()
+	}
+	patch, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, httpx.ErrUnableToReadRequest()
+	}
+
+	rm, err := catalogmanager.ResourceManagerForKind(ctx, kind, n)
+	if err != nil {
+		return nil, err
+	}
+	current, err := rm.Get(ctx)
+	if err != nil {
+		return nil, err
+	}
+	req, err := catalogmanager.ApplyJSONPatch(current, patch)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateRequest(req, kind); err != nil {
+		return nil, err
+	}
+	if err := validateRequestContext(req, kind, n); err != nil {
+		return nil, err
+	}
+	if err := rm.Update(ctx, req); err != nil {
+		return nil, err
+	}
+
+	rsp := &httpx.Response{
+		StatusCode: http.StatusOK,
+		Response:   saveWarnings(rm),
+	}
+	return rsp, nil
+}
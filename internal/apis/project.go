@@ -0,0 +1,47 @@
+package apis
+
+import (
+	"io"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/mugiliam/common/httpx"
+	"github.com/mugiliam/hatchcatalogsrv/internal/catalogmanager"
+	"github.com/mugiliam/hatchcatalogsrv/pkg/types"
+)
+
+// listProjects returns the projects of the tenant
+func listProjects(r *http.Request) (*httpx.Response, error) {
+	ctx := r.Context()
+
+	projects, err := catalogmanager.ListProjects(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &httpx.Response{
+		StatusCode: http.StatusOK,
+		Response:   projects,
+	}, nil
+}
+
+// updateProject updates the description of the project in the URL
+func updateProject(r *http.Request) (*httpx.Response, error) {
+	ctx := r.Context()
+
+	if r.Body == nil {
+		return nil, httpx.ErrInvalidRequest()
+	}
+	req, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, httpx.ErrUnableToReadRequest()
+	}
+
+	err = catalogmanager.UpdateProject(ctx, types.ProjectId(chi.URLParam(r, "projectId")), req)
+	if err != nil {
+		return nil, err
+	}
+	return &httpx.Response{
+		StatusCode: http.StatusOK,
+		Response:   nil,
+	}, nil
+}
@@ -1,15 +1,36 @@
 package apis
 
 import (
+	"errors"
 	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/mugiliam/common/hatchrbac"
 	"github.com/mugiliam/common/httpx"
+	"github.com/mugiliam/hatchcatalogsrv/internal/catalogmanager"
 	"github.com/mugiliam/hatchcatalogsrv/internal/common"
+	"github.com/mugiliam/hatchcatalogsrv/internal/config"
+	"github.com/mugiliam/hatchcatalogsrv/internal/tracing"
+	"github.com/mugiliam/hatchcatalogsrv/pkg/types"
 )
 
 var resourceObjectHandlers = []httpx.RoleAuthorizedHandlerParam{
+	{
+		Method:  http.MethodGet,
+		Path:    "/projects",
+		Handler: listProjects,
+		Op:      hatchrbac.Read,
+	},
+	{
+		Method:  http.MethodPut,
+		Path:    "/projects/{projectId}",
+		Handler: updateProject,
+		Op:      hatchrbac.Update,
+	},
 	{
 		Method:  http.MethodPost,
 		Path:    "/catalogs",
@@ -34,6 +55,24 @@ var resourceObjectHandlers = []httpx.RoleAuthorizedHandlerParam{
 		Handler: deleteObject,
 		Op:      hatchrbac.Delete,
 	},
+	{
+		Method:  http.MethodPost,
+		Path:    "/catalogs/{catalogName}/rename",
+		Handler: renameCatalog,
+		Op:      hatchrbac.Update,
+	},
+	{
+		Method:  http.MethodGet,
+		Path:    "/catalogs/{catalogName}/stats",
+		Handler: getCatalogStats,
+		Op:      hatchrbac.Read,
+	},
+	{
+		Method:  http.MethodPost,
+		Path:    "/catalogs/{catalogName}/import",
+		Handler: importCatalog,
+		Op:      hatchrbac.Create,
+	},
 	{
 		Method:  http.MethodPost,
 		Path:    "/variants",
@@ -58,6 +97,24 @@ var resourceObjectHandlers = []httpx.RoleAuthorizedHandlerParam{
 		Handler: deleteObject,
 		Op:      hatchrbac.Delete,
 	},
+	{
+		Method:  http.MethodGet,
+		Path:    "/variants/{variantName}/versions",
+		Handler: listVersions,
+		Op:      hatchrbac.Read,
+	},
+	{
+		Method:  http.MethodGet,
+		Path:    "/variants/{variantName}/versions/{versionRef}",
+		Handler: getVersionByLabel,
+		Op:      hatchrbac.Read,
+	},
+	{
+		Method:  http.MethodPut,
+		Path:    "/variants/{variantName}/versions/{versionRef}/label",
+		Handler: setVersionLabel,
+		Op:      hatchrbac.Update,
+	},
 	{
 		Method:  http.MethodPost,
 		Path:    "/workspaces",
@@ -82,6 +139,18 @@ var resourceObjectHandlers = []httpx.RoleAuthorizedHandlerParam{
 		Handler: deleteObject,
 		Op:      hatchrbac.Delete,
 	},
+	{
+		Method:  http.MethodPost,
+		Path:    "/workspaces/{workspaceRef}/rebase",
+		Handler: rebaseWorkspace,
+		Op:      hatchrbac.Update,
+	},
+	{
+		Method:  http.MethodPost,
+		Path:    "/workspaces/{workspaceRef}/merge/{sourceRef}",
+		Handler: mergeWorkspaces,
+		Op:      hatchrbac.Update,
+	},
 	{
 		Method:  http.MethodPost,
 		Path:    "/namespaces",
@@ -106,6 +175,102 @@ var resourceObjectHandlers = []httpx.RoleAuthorizedHandlerParam{
 		Handler: deleteObject,
 		Op:      hatchrbac.Delete,
 	},
+	{
+		Method:  http.MethodPut,
+		Path:    "/namespaces/{namespaceName}/contents",
+		Handler: replaceNamespaceContents,
+		Op:      hatchrbac.Update,
+	},
+	{
+		Method:  http.MethodGet,
+		Path:    "/parameterschemas/{paramName}/resolve",
+		Handler: resolveParameterSchema,
+		Op:      hatchrbac.Read,
+	},
+	{
+		Method:  http.MethodGet,
+		Path:    "/parameterschemas/{paramName}/default",
+		Handler: getParameterSchemaDefault,
+		Op:      hatchrbac.Read,
+	},
+	{
+		Method:  http.MethodPost,
+		Path:    "/parameterschemas/{paramName}:checkCompat",
+		Handler: checkParameterSchemaCompat,
+		Op:      hatchrbac.Read,
+	},
+	{
+		Method:  http.MethodGet,
+		Path:    "/collectionschemas/{collectionSchemaName}/jsonschema",
+		Handler: getCollectionSchemaJSONSchema,
+		Op:      hatchrbac.Read,
+	},
+	{
+		Method:  http.MethodGet,
+		Path:    "/collections",
+		Handler: listCollections,
+		Op:      hatchrbac.Read,
+	},
+	{
+		Method:  http.MethodPost,
+		Path:    "/parameterschemas:batchGet",
+		Handler: batchGetSchemas(types.CatalogObjectTypeParameterSchema),
+		Op:      hatchrbac.Read,
+	},
+	{
+		Method:  http.MethodPost,
+		Path:    "/collectionschemas:batchGet",
+		Handler: batchGetSchemas(types.CatalogObjectTypeCollectionSchema),
+		Op:      hatchrbac.Read,
+	},
+	{
+		Method:  http.MethodPost,
+		Path:    "/parameterschemas:batchDelete",
+		Handler: batchDeleteObjects(types.CatalogObjectTypeParameterSchema),
+		Op:      hatchrbac.Delete,
+	},
+	{
+		Method:  http.MethodPost,
+		Path:    "/collectionschemas:batchDelete",
+		Handler: batchDeleteObjects(types.CatalogObjectTypeCollectionSchema),
+		Op:      hatchrbac.Delete,
+	},
+	{
+		Method:  http.MethodPost,
+		Path:    "/collections:batchDelete",
+		Handler: batchDeleteObjects(types.CatalogObjectTypeCatalogCollection),
+		Op:      hatchrbac.Delete,
+	},
+	{
+		Method:  http.MethodPost,
+		Path:    "/lint",
+		Handler: lintObject,
+		Op:      hatchrbac.Read,
+	},
+	{
+		Method:  http.MethodPost,
+		Path:    "/admin/gc",
+		Handler: collectUnreferencedObjects,
+		Op:      hatchrbac.Delete,
+	},
+	{
+		Method:  http.MethodPost,
+		Path:    "/admin/migrate",
+		Handler: migrateVariant,
+		Op:      hatchrbac.Update,
+	},
+	{
+		Method:  http.MethodGet,
+		Path:    "/admin/references:verify",
+		Handler: verifyReferences,
+		Op:      hatchrbac.Read,
+	},
+	{
+		Method:  http.MethodPost,
+		Path:    "/admin/references:repair",
+		Handler: repairReferences,
+		Op:      hatchrbac.Update,
+	},
 	{
 		Method:  http.MethodPost,
 		Path:    "/{objectType}",
@@ -130,6 +295,12 @@ var resourceObjectHandlers = []httpx.RoleAuthorizedHandlerParam{
 		Handler: updateObject,
 		Op:      hatchrbac.Update,
 	},
+	{
+		Method:  http.MethodPatch,
+		Path:    "/{objectType}/*",
+		Handler: patchObject,
+		Op:      hatchrbac.Update,
+	},
 	{
 		Method:  http.MethodDelete,
 		Path:    "/{objectType}/*",
@@ -138,11 +309,171 @@ var resourceObjectHandlers = []httpx.RoleAuthorizedHandlerParam{
 	},
 }
 
+// streamingHandlers write their response directly rather than returning an httpx.Response. A handler with a
+// Format serves only the requests whose format query parameter is Format, and the resource handler of the same
+// method and path serves the others.
+var streamingHandlers = []struct {
+	Method  string
+	Path    string
+	Format  string
+	Handler http.HandlerFunc
+}{
+	{
+		Method:  http.MethodGet,
+		Path:    "/catalogs/{catalogName}/export",
+		Handler: exportCatalog,
+	},
+	{
+		Method:  http.MethodGet,
+		Path:    "/collections",
+		Format:  formatNDJSON,
+		Handler: streamCollections,
+	},
+}
+
 func Router(r chi.Router) {
 	r.Use(LoadCatalogContext)
 	//TODO: Implement authentication
+	formatted := make(map[string]map[string]http.Handler)
+	for _, handler := range streamingHandlers {
+		h := withDeadline(handler.Handler, exportTimeout)
+		if handler.Format == "" {
+			r.Method(handler.Method, handler.Path, h)
+			continue
+		}
+		key := handler.Method + " " + handler.Path
+		if formatted[key] == nil {
+			formatted[key] = make(map[string]http.Handler)
+		}
+		formatted[key][handler.Format] = h
+	}
+	for _, handler := range resourceObjectHandlers {
+		var h http.Handler = httpx.WrapHttpRsp(mapErrors(handler.Handler))
+		if handler.Method == http.MethodGet {
+			h = conditionalGet(negotiateYAML(h))
+			r.Method(http.MethodHead, handler.Path, withTimeout(headOnly(h), requestTimeout))
+		}
+		h = withTimeout(h, requestTimeout)
+		if byFormat, ok := formatted[handler.Method+" "+handler.Path]; ok {
+			h = withFormats(h, byFormat)
+		}
+		r.Method(handler.Method, handler.Path, h)
+	}
+	for path, allowed := range allowedMethods() {
+		h := methodNotAllowed(allowed)
+		for _, m := range checkedMethods {
+			if !slices.Contains(allowed, m) {
+				r.Method(m, path, h)
+			}
+		}
+	}
+}
+
+func requestTimeout() time.Duration {
+	return config.Config().RequestTimeoutDuration()
+}
+
+func exportTimeout() time.Duration {
+	return config.Config().ExportTimeoutDuration()
+}
+
+// checkedMethods are the methods that resource paths are checked for. A request to a resource path with one of
+// them that the path does not support is answered with a 405, rather than falling through to the generic
+// object routes.
+var checkedMethods = []string{
+	http.MethodGet,
+	http.MethodHead,
+	http.MethodPost,
+	http.MethodPut,
+	http.MethodPatch,
+	http.MethodDelete,
+}
+
+// allowedMethods returns the methods supported by each resource path, in the order of checkedMethods. The
+// generic object routes accept any object type and are not included.
+func allowedMethods() map[string][]string {
+	methods := make(map[string][]string)
+	add := func(method, path string) {
+		if strings.HasPrefix(path, "/{objectType}") || slices.Contains(methods[path], method) {
+			return
+		}
+		methods[path] = append(methods[path], method)
+	}
+	for _, handler := range resourceObjectHandlers {
+		add(handler.Method, handler.Path)
+		if handler.Method == http.MethodGet {
+			add(http.MethodHead, handler.Path)
+		}
+	}
+	for _, handler := range streamingHandlers {
+		add(handler.Method, handler.Path)
+	}
+	// a resource path that names an object type takes the requests of the generic route for that type, so it
+	// allows the methods of that route too
 	for _, handler := range resourceObjectHandlers {
-		r.Method(handler.Method, handler.Path, httpx.WrapHttpRsp(handler.Handler))
+		if handler.Path != "/{objectType}" {
+			continue
+		}
+		for path := range methods {
+			if types.IsValidResourceNameAndMethod(strings.TrimPrefix(path, "/"), handler.Method) {
+				add(handler.Method, path)
+			}
+		}
+	}
+	for _, allowed := range methods {
+		slices.SortFunc(allowed, func(a, b string) int {
+			return slices.Index(checkedMethods, a) - slices.Index(checkedMethods, b)
+		})
+	}
+	return methods
+}
+
+// withFormats serves a request with the handler in byFormat for its format query parameter, and with next if
+// there is none
+func withFormats(next http.Handler, byFormat map[string]http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if h, ok := byFormat[r.URL.Query().Get("format")]; ok {
+			h.ServeHTTP(w, r)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// RouteAllowsMethod reports whether the route pattern path, as registered on the router, serves method. The
+// paths of resources are also registered with a 405 handler for the methods they do not serve, which this tells
+// apart from the handlers that serve them. Paths that are not resource paths serve any method they are
+// registered with.
+func RouteAllowsMethod(path, method string) bool {
+	allowed, ok := allowedMethods()[path]
+	return !ok || slices.Contains(allowed, method)
+}
+
+// headOnly answers a HEAD request with the status and headers that next sends for the GET of the same
+// resource, including the Content-Length of the body, but without the body itself
+func headOnly(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		bw := &bufferedResponseWriter{header: make(http.Header), status: http.StatusOK}
+		next.ServeHTTP(bw, r)
+		for k, v := range bw.header {
+			w.Header()[k] = v
+		}
+		if bw.body.Len() > 0 && w.Header().Get("Content-Length") == "" {
+			w.Header().Set("Content-Length", strconv.Itoa(bw.body.Len()))
+		}
+		w.WriteHeader(bw.status)
+	})
+}
+
+// methodNotAllowed responds with a 405 that lists the allowed methods in the Allow header
+func methodNotAllowed(allowed []string) http.HandlerFunc {
+	allow := strings.Join(allowed, ", ")
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Allow", allow)
+		(&httpx.Error{
+			StatusCode:  http.StatusMethodNotAllowed,
+			Description: "method " + r.Method + " is not allowed, use one of " + allow,
+		}).Send(w)
 	}
 }
 
@@ -178,6 +509,13 @@ func LoadCatalogContext(next http.Handler) http.Handler {
 		// Load Workspace
 		c, err = loadWorkspaceObject(ctx, c, urlValues)
 		if err != nil {
+			if errors.Is(err, catalogmanager.ErrWorkspaceNotFound) {
+				(&httpx.Error{
+					StatusCode:  http.StatusNotFound,
+					Description: err.Error(),
+				}).Send(w)
+				return
+			}
 			httpx.ErrInvalidWorkspace().Send(w)
 			return
 		}
@@ -190,6 +528,8 @@ func LoadCatalogContext(next http.Handler) http.Handler {
 		}
 
 		ctx = common.SetCatalogContext(ctx, c)
+		// the request span is tagged with the catalog and variant once they are loaded
+		tracing.Annotate(ctx)
 
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
@@ -0,0 +1,108 @@
+package apis
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/mugiliam/common/httpx"
+	"github.com/rs/zerolog/log"
+)
+
+// withTimeout cancels the context of a request to next once the duration returned by timeout has passed, which
+// also cancels the database work done for it. A request that has not completed by then is answered with a 504
+// once next has returned, and anything next writes afterwards is discarded. The response of next is buffered, so it must not be used
+// for streamed responses; see withDeadline. timeout is called for every request so that a config reload takes
+// effect.
+func withTimeout(next http.Handler, timeout func() time.Duration) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), timeout())
+		defer cancel()
+
+		tw := &timeoutWriter{buf: bufferedResponseWriter{header: make(http.Header), status: http.StatusOK}}
+		done := make(chan struct{})
+		panicked := make(chan any, 1)
+		go func() {
+			defer func() {
+				if p := recover(); p != nil {
+					panicked <- p
+				}
+			}()
+			next.ServeHTTP(tw, r.WithContext(ctx))
+			close(done)
+		}()
+
+		select {
+		case p := <-panicked:
+			panic(p)
+		case <-done:
+			tw.mu.Lock()
+			defer tw.mu.Unlock()
+			for k, v := range tw.buf.header {
+				w.Header()[k] = v
+			}
+			w.WriteHeader(tw.buf.status)
+			_, _ = w.Write(tw.buf.body.Bytes())
+		case <-ctx.Done():
+			tw.mu.Lock()
+			tw.timedOut = true
+			tw.mu.Unlock()
+			// next may still be using the database connection of the request, which is released once this
+			// returns. The context is cancelled, so it is expected to return promptly.
+			select {
+			case p := <-panicked:
+				panic(p)
+			case <-done:
+			}
+			if !errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				// the client went away, there is no one to respond to
+				return
+			}
+			log.Ctx(r.Context()).Warn().Str("method", r.Method).Str("path", r.URL.Path).Msg("request timed out")
+			(&httpx.Error{
+				StatusCode:  http.StatusGatewayTimeout,
+				Description: "request timed out",
+			}).Send(w)
+		}
+	})
+}
+
+// withDeadline sets a deadline on the context of a request to next, without buffering its response. It is used
+// for handlers that stream their response, which end the response early when their context is cancelled.
+func withDeadline(next http.Handler, timeout func() time.Duration) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), timeout())
+		defer cancel()
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// timeoutWriter buffers a response until the request completes. Writes fail once the request has timed out.
+type timeoutWriter struct {
+	mu       sync.Mutex
+	buf      bufferedResponseWriter
+	timedOut bool
+}
+
+func (tw *timeoutWriter) Header() http.Header {
+	return tw.buf.header
+}
+
+func (tw *timeoutWriter) WriteHeader(status int) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if !tw.timedOut {
+		tw.buf.WriteHeader(status)
+	}
+}
+
+func (tw *timeoutWriter) Write(p []byte) (int, error) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.timedOut {
+		return 0, http.ErrHandlerTimeout
+	}
+	return tw.buf.Write(p)
+}
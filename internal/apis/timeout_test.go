@@ -0,0 +1,68 @@
+package apis
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithTimeout(t *testing.T) {
+	cancelled := make(chan error, 1)
+	var returned atomic.Bool
+	slow := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer returned.Store(true)
+		select {
+		case <-time.After(5 * time.Second):
+			w.WriteHeader(http.StatusCreated)
+		case <-r.Context().Done():
+			cancelled <- r.Context().Err()
+			// the handler keeps running for a while after its context is cancelled
+			time.Sleep(20 * time.Millisecond)
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	})
+	fast := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", mediaTypeJSON)
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"ok":true}`))
+	})
+	timeout := func() time.Duration { return 50 * time.Millisecond }
+
+	// the slow handler is cut off with a 504 and its context is cancelled
+	rr := httptest.NewRecorder()
+	start := time.Now()
+	withTimeout(slow, timeout).ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/slow", nil))
+	assert.Less(t, time.Since(start), time.Second)
+	assert.Equal(t, http.StatusGatewayTimeout, rr.Code)
+	// the response is only sent once the handler has returned, so that it no longer uses the request's resources
+	assert.True(t, returned.Load())
+	select {
+	case err := <-cancelled:
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	case <-time.After(time.Second):
+		t.Fatal("handler context was not cancelled")
+	}
+
+	// a handler that completes in time is answered as usual
+	rr = httptest.NewRecorder()
+	withTimeout(fast, timeout).ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/fast", nil))
+	assert.Equal(t, http.StatusCreated, rr.Code)
+	assert.Equal(t, mediaTypeJSON, rr.Header().Get("Content-Type"))
+	assert.JSONEq(t, `{"ok":true}`, rr.Body.String())
+
+	// streamed responses get a deadline but are not buffered
+	var deadline time.Time
+	stream := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		deadline, _ = r.Context().Deadline()
+		_, _ = w.Write([]byte("streamed"))
+	})
+	rr = httptest.NewRecorder()
+	withDeadline(stream, func() time.Duration { return time.Hour }).ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/export", nil))
+	assert.Equal(t, "streamed", rr.Body.String())
+	assert.WithinDuration(t, time.Now().Add(time.Hour), deadline, time.Minute)
+}
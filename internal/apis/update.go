@@ -18,6 +18,9 @@ func updateObject(r *http.Request) (*httpx.Response, error) {
 	if err != nil {
 		return nil, err
 	}
+	if err := checkDirectWrite(r); err != nil {
+		return nil, err
+	}
 	kind = getResourceKind(r)
 	if kind == types.InvalidKind {
 		return nil, httpx.ErrInvalidRequest()
@@ -33,6 +36,9 @@ func updateObject(r *http.Request) (*httpx.Response, error) {
 	if err := validateRequest(req, kind); err != nil {
 		return nil, err
 	}
+	if err := validateRequestContext(req, kind, n); err != nil {
+		return nil, err
+	}
 
 	rm, err := catalogmanager.ResourceManagerForKind(ctx, kind, n)
 	if err != nil {
@@ -45,7 +51,7 @@ func updateObject(r *http.Request) (*httpx.Response, error) {
 
 	rsp := &httpx.Response{
 		StatusCode: http.StatusOK,
-		Response:   nil,
+		Response:   saveWarnings(rm),
 	}
 	return rsp, nil
 }
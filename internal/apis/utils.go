@@ -1,8 +1,10 @@
 package apis
 
 import (
+	"errors"
 	"net/http"
 	"path"
+	"strconv"
 	"strings"
 
 	"github.com/go-chi/chi/v5"
@@ -10,6 +12,7 @@ import (
 	"github.com/mugiliam/common/httpx"
 	"github.com/mugiliam/hatchcatalogsrv/internal/catalogmanager"
 	"github.com/mugiliam/hatchcatalogsrv/internal/common"
+	"github.com/mugiliam/hatchcatalogsrv/internal/config"
 	"github.com/mugiliam/hatchcatalogsrv/pkg/types"
 	"github.com/tidwall/gjson"
 )
@@ -24,10 +27,20 @@ func getResourceName(r *http.Request) (catalogmanager.RequestContext, error) {
 
 	n := catalogmanager.RequestContext{}
 	catalogContext := common.CatalogContextFromContext(ctx)
+	// a direct request is made to the variant, ignoring any workspace
+	n.Direct = isDirect(r)
+	if n.Direct {
+		workspace = ""
+	}
 	if workspace != "" {
 		n.Workspace = workspace
 		n.WorkspaceLabel, n.WorkspaceID = getUUIDOrName(workspace)
-	} else if catalogContext != nil {
+		if n.WorkspaceID != uuid.Nil && catalogContext != nil {
+			if _, err := workspaceOfVariant(ctx, n.WorkspaceID, catalogContext.CatalogId, catalogContext.VariantId); err != nil {
+				return n, err
+			}
+		}
+	} else if catalogContext != nil && !n.Direct {
 		n.WorkspaceLabel = catalogContext.WorkspaceLabel
 		n.WorkspaceID = catalogContext.WorkspaceId
 	}
@@ -49,6 +62,20 @@ func getResourceName(r *http.Request) (catalogmanager.RequestContext, error) {
 		n.Namespace = catalogContext.Namespace
 	}
 
+	// a request that names neither a variant nor a workspace is made to the default variant of the catalog.
+	// Catalogs, variants and workspaces name their variant themselves, if they need one.
+	if n.Variant == "" && n.VariantID == uuid.Nil && n.WorkspaceLabel == "" && n.WorkspaceID == uuid.Nil && (n.Catalog != "" || n.CatalogID != uuid.Nil) {
+		switch getResourceKind(r) {
+		case types.CatalogKind, types.VariantKind, types.WorkspaceKind:
+		default:
+			variant, variantID, err := catalogmanager.DefaultVariantOfCatalog(ctx, n.CatalogID, n.Catalog)
+			if err != nil && !errors.Is(err, catalogmanager.ErrCatalogNotFound) && !errors.Is(err, catalogmanager.ErrVariantNotFound) {
+				return n, err
+			}
+			n.Variant, n.VariantID = variant, variantID
+		}
+	}
+
 	// parse schema and collection objects
 	resourceName := chi.URLParam(r, "objectType")
 	resourceFqn := chi.URLParam(r, "*")
@@ -90,6 +117,37 @@ func getResourceName(r *http.Request) (catalogmanager.RequestContext, error) {
 	return n, nil
 }
 
+// isDirect reports whether r asks to operate on the variant directly, bypassing any workspace
+func isDirect(r *http.Request) bool {
+	return r.URL.Query().Get("direct") == "true"
+}
+
+// returnRepresentation reports whether r asks for the created object in the response body, either with the
+// return=representation query parameter or a Prefer: return=representation header (RFC 7240)
+func returnRepresentation(r *http.Request) bool {
+	if r.URL.Query().Get("return") == "representation" {
+		return true
+	}
+	for _, v := range r.Header.Values("Prefer") {
+		for _, pref := range strings.Split(v, ",") {
+			if strings.EqualFold(strings.TrimSpace(pref), "return=representation") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// checkDirectWrite returns an error if r writes to a variant directly and direct writes are not enabled.
+// Direct writes skip the review of changes in a workspace, so they are only allowed for admin tooling in
+// deployments that turn them on.
+func checkDirectWrite(r *http.Request) error {
+	if isDirect(r) && !config.Config().AllowDirectWrites {
+		return catalogmanager.ErrDirectWritesDisabled
+	}
+	return nil
+}
+
 func getResourceKind(r *http.Request) string {
 	// Trim leading and trailing slashes
 	path := strings.Trim(r.URL.Path, "/")
@@ -131,3 +189,31 @@ func validateRequest(reqJson []byte, kind string) error {
 	}
 	return nil
 }
+
+// validateRequestContext checks that the catalog and variant named in the metadata of reqJson, when present,
+// are those the request is made in, whether they come from the url, the query parameters or the catalog
+// context. The catalog of a Catalog and the variant of a Variant are the object itself and are not checked.
+func validateRequestContext(reqJson []byte, kind string, n catalogmanager.RequestContext) error {
+	if kind != types.CatalogKind {
+		if c := gjson.GetBytes(reqJson, "metadata.catalog").String(); c != "" && n.Catalog != "" && c != n.Catalog {
+			return catalogmanager.ErrContextMismatch.Msg("metadata.catalog " + strconv.Quote(c) + " does not match catalog " + strconv.Quote(n.Catalog) + " of the request")
+		}
+	}
+	if kind != types.CatalogKind && kind != types.VariantKind {
+		if v := gjson.GetBytes(reqJson, "metadata.variant").String(); v != "" && n.Variant != "" && v != n.Variant {
+			return catalogmanager.ErrContextMismatch.Msg("metadata.variant " + strconv.Quote(v) + " does not match variant " + strconv.Quote(n.Variant) + " of the request")
+		}
+	}
+	return nil
+}
+
+// validatePatchRequest checks a merge patch body. Unlike a full resource, kind is optional in a patch.
+func validatePatchRequest(reqJson []byte, kind string) error {
+	if !gjson.ValidBytes(reqJson) {
+		return httpx.ErrInvalidRequest("unable to parse request")
+	}
+	if result := gjson.GetBytes(reqJson, "kind"); result.Exists() && result.String() != kind {
+		return httpx.ErrInvalidRequest("invalid kind")
+	}
+	return nil
+}
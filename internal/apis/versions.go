@@ -0,0 +1,104 @@
+package apis
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/mugiliam/common/httpx"
+	"github.com/mugiliam/hatchcatalogsrv/internal/catalogmanager"
+)
+
+// listVersions returns the versions of a variant with the total number of versions. With named=true only
+// versions that have a label are listed.
+func listVersions(r *http.Request) (*httpx.Response, error) {
+	ctx := r.Context()
+
+	n, err := getResourceName(r)
+	if err != nil {
+		return nil, err
+	}
+
+	namedOnly := false
+	if v := r.URL.Query().Get("named"); v != "" {
+		if namedOnly, err = strconv.ParseBool(v); err != nil {
+			return nil, httpx.ErrInvalidRequest("named must be true or false")
+		}
+	}
+
+	rsrc, err := catalogmanager.ListVersions(ctx, n, namedOnly)
+	if err != nil {
+		return nil, err
+	}
+	return &httpx.Response{
+		StatusCode: http.StatusOK,
+		Response:   rsrc,
+	}, nil
+}
+
+// getVersionByLabel returns the version of a variant with the label in the URL
+func getVersionByLabel(r *http.Request) (*httpx.Response, error) {
+	ctx := r.Context()
+
+	n, err := getResourceName(r)
+	if err != nil {
+		return nil, err
+	}
+
+	rsrc, err := catalogmanager.GetVersionByLabel(ctx, n, chi.URLParam(r, "versionRef"))
+	if err != nil {
+		return nil, err
+	}
+	return &httpx.Response{
+		StatusCode: http.StatusOK,
+		Response:   rsrc,
+	}, nil
+}
+
+type setVersionLabelRequest struct {
+	Label string `json:"label"`
+}
+
+// setVersionLabel labels the version with the number in the URL. A label that is on another version is only
+// moved with force=true.
+func setVersionLabel(r *http.Request) (*httpx.Response, error) {
+	ctx := r.Context()
+
+	n, err := getResourceName(r)
+	if err != nil {
+		return nil, err
+	}
+	versionNum, err := strconv.Atoi(chi.URLParam(r, "versionRef"))
+	if err != nil || versionNum <= 0 {
+		return nil, httpx.ErrInvalidRequest("version must be a positive number")
+	}
+	force := false
+	if v := r.URL.Query().Get("force"); v != "" {
+		if force, err = strconv.ParseBool(v); err != nil {
+			return nil, httpx.ErrInvalidRequest("force must be true or false")
+		}
+	}
+
+	if r.Body == nil {
+		return nil, httpx.ErrInvalidRequest()
+	}
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, httpx.ErrUnableToReadRequest()
+	}
+	req := setVersionLabelRequest{}
+	if err := json.Unmarshal(body, &req); err != nil {
+		return nil, httpx.ErrInvalidRequest("unable to parse request")
+	}
+
+	rsrc, err := catalogmanager.SetVersionLabel(ctx, n, versionNum, req.Label, force)
+	if err != nil {
+		return nil, err
+	}
+	return &httpx.Response{
+		StatusCode: http.StatusOK,
+		Response:   rsrc,
+	}, nil
+}
@@ -0,0 +1,120 @@
+package apis
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/mugiliam/common/apperrors"
+	"github.com/mugiliam/common/httpx"
+	"github.com/mugiliam/hatchcatalogsrv/internal/catalogmanager"
+)
+
+type rebaseRequest struct {
+	BaseVersion int `json:"baseVersion"`
+}
+
+// rebaseWorkspace moves a workspace onto a newer base version. Conflicting changes are returned with
+// a 409 and the workspace is left unchanged.
+func rebaseWorkspace(r *http.Request) (*httpx.Response, error) {
+	ctx := r.Context()
+
+	n, err := getResourceName(r)
+	if err != nil {
+		return nil, err
+	}
+	n.WorkspaceID, err = resolveWorkspaceID(ctx, n.VariantID, n.WorkspaceLabel, n.WorkspaceID)
+	if err != nil {
+		return nil, err
+	}
+
+	if r.Body == nil {
+		return nil, httpx.ErrInvalidRequest()
+	}
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, httpx.ErrUnableToReadRequest()
+	}
+	req := rebaseRequest{}
+	if err := json.Unmarshal(body, &req); err != nil {
+		return nil, httpx.ErrInvalidRequest("unable to parse request")
+	}
+
+	result, err := catalogmanager.RebaseWorkspace(ctx, n.WorkspaceID, req.BaseVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	j, jsonErr := json.Marshal(result)
+	if jsonErr != nil {
+		return nil, catalogmanager.ErrCatalogError.Msg("unable to marshal rebase result")
+	}
+	rsp := &httpx.Response{
+		StatusCode: http.StatusOK,
+		Response:   j,
+	}
+	if len(result.Conflicts) > 0 {
+		rsp.StatusCode = http.StatusConflict
+	}
+	return rsp, nil
+}
+
+// mergeWorkspaces merges the source workspace into the target. Conflicting changes are returned with
+// a 409 and neither workspace is changed.
+func mergeWorkspaces(r *http.Request) (*httpx.Response, error) {
+	ctx := r.Context()
+
+	n, err := getResourceName(r)
+	if err != nil {
+		return nil, err
+	}
+	targetID, err := resolveWorkspaceID(ctx, n.VariantID, n.WorkspaceLabel, n.WorkspaceID)
+	if err != nil {
+		return nil, err
+	}
+	sourceLabel, sourceID := getUUIDOrName(chi.URLParam(r, "sourceRef"))
+	sourceID, err = resolveWorkspaceID(ctx, n.VariantID, sourceLabel, sourceID)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := catalogmanager.MergeWorkspaces(ctx, targetID, sourceID)
+	if err != nil {
+		return nil, err
+	}
+
+	j, jsonErr := json.Marshal(result)
+	if jsonErr != nil {
+		return nil, catalogmanager.ErrCatalogError.Msg("unable to marshal merge result")
+	}
+	rsp := &httpx.Response{
+		StatusCode: http.StatusOK,
+		Response:   j,
+	}
+	if len(result.Conflicts) > 0 {
+		rsp.StatusCode = http.StatusConflict
+	}
+	return rsp, nil
+}
+
+// resolveWorkspaceID checks that the workspace with id is in the variant, or looks up the workspace by label in
+// the variant if id is not set
+func resolveWorkspaceID(ctx context.Context, variantID uuid.UUID, label string, id uuid.UUID) (uuid.UUID, apperrors.Error) {
+	if id != uuid.Nil {
+		if _, err := workspaceOfVariant(ctx, id, uuid.Nil, variantID); err != nil {
+			return uuid.Nil, err
+		}
+		return id, nil
+	}
+	if label == "" {
+		return uuid.Nil, catalogmanager.ErrInvalidWorkspace
+	}
+	wm, err := catalogmanager.LoadWorkspaceManagerByLabel(ctx, variantID, label)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	return wm.ID(), nil
+}
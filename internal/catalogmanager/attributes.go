@@ -5,6 +5,8 @@ import (
 	"encoding/json"
 	"errors"
 	"path"
+	"sort"
+	"strings"
 
 	"github.com/google/uuid"
 	"github.com/mugiliam/common/apperrors"
@@ -42,6 +44,9 @@ func GetAttribute(ctx context.Context, m *schemamanager.SchemaMetadata, param st
 		log.Ctx(ctx).Error().Err(err).Msg("failed to load collection")
 		return nil, err
 	}
+	if !options.RevealSensitiveValues {
+		cm.RedactSensitiveValues()
+	}
 
 	return cm.GetValueJSON(ctx, param)
 }
@@ -71,6 +76,9 @@ func GetAllAttributes(ctx context.Context, m *schemamanager.SchemaMetadata, opts
 		log.Ctx(ctx).Error().Err(err).Msg("failed to load collection")
 		return nil, err
 	}
+	if !options.RevealSensitiveValues {
+		cm.RedactSensitiveValues()
+	}
 
 	return cm.GetAllValuesJSON(ctx)
 }
@@ -129,7 +137,7 @@ func DeleteAttribute(ctx context.Context, m *schemamanager.SchemaMetadata, param
 		}
 	}
 
-	schemaPath, _, err := setCollectionSchemaManager(ctx, cm, dir)
+	schemaPath, _, _, err := setCollectionSchemaManager(ctx, cm, dir)
 	if err != nil {
 		log.Ctx(ctx).Error().Err(err).Msg("failed to set collection schema manager")
 		return err
@@ -141,7 +149,10 @@ func DeleteAttribute(ctx context.Context, m *schemamanager.SchemaMetadata, param
 		return err
 	}
 
-	s := cm.StorageRepresentation()
+	s, err := collectionStorageRepresentation(cm)
+	if err != nil {
+		return err
+	}
 	data, err := s.Serialize()
 	if err != nil {
 		return err
@@ -162,7 +173,7 @@ func DeleteAttribute(ctx context.Context, m *schemamanager.SchemaMetadata, param
 		Data:    data,
 	}
 
-	return saveCollectionObject(ctx, m, &obj, dir, pathWithName, schemaPath)
+	return saveCollectionObject(ctx, m, &obj, dir, pathWithName, schemaPath, "")
 }
 
 type attributeValues map[string]types.NullableAny
@@ -221,7 +232,7 @@ func UpdateAttributes(ctx context.Context, m *schemamanager.SchemaMetadata, valu
 		}
 	}
 
-	schemaPath, schemaLoaders, err := setCollectionSchemaManager(ctx, cm, dir)
+	schemaPath, _, schemaLoaders, err := setCollectionSchemaManager(ctx, cm, dir)
 	if err != nil {
 		log.Ctx(ctx).Error().Err(err).Msg("failed to set collection schema manager")
 		return err
@@ -241,7 +252,10 @@ func UpdateAttributes(ctx context.Context, m *schemamanager.SchemaMetadata, valu
 		}
 	}
 
-	s := cm.StorageRepresentation()
+	s, err := collectionStorageRepresentation(cm)
+	if err != nil {
+		return err
+	}
 	data, err := s.Serialize()
 	if err != nil {
 		return err
@@ -262,7 +276,167 @@ func UpdateAttributes(ctx context.Context, m *schemamanager.SchemaMetadata, valu
 		Data:    data,
 	}
 
-	return saveCollectionObject(ctx, m, &obj, dir, pathWithName, schemaPath)
+	return saveCollectionObject(ctx, m, &obj, dir, pathWithName, schemaPath, "")
+}
+
+// PatchAttributes applies values to an existing collection as a JSON merge patch: each key replaces the value of
+// that parameter, and a null resets the parameter to its default. Parameters not in values keep their current
+// value. Every patched value is validated before anything is stored, and all failures are reported together.
+func PatchAttributes(ctx context.Context, m *schemamanager.SchemaMetadata, values attributeValues, opts ...ObjectStoreOption) apperrors.Error {
+	if m == nil || values == nil {
+		return validationerrors.ErrEmptySchema
+	}
+
+	options := storeOptions{}
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	var dir Directories
+	t := types.CatalogObjectTypeCatalogCollection
+	pathWithName := path.Clean(m.GetStoragePath(t) + "/" + m.Name)
+
+	// get the directory
+	if !options.Dir.IsNil() {
+		dir = options.Dir
+	} else if options.WorkspaceID != uuid.Nil {
+		var err apperrors.Error
+		dir, err = getDirectoriesForWorkspace(ctx, options.WorkspaceID)
+		if err != nil {
+			return err
+		}
+	} else if m.IDS.VariantID != uuid.Nil {
+		var err apperrors.Error
+		dir, err = getDirectoriesForVariant(ctx, m.IDS.VariantID)
+		if err != nil {
+			return err
+		}
+	} else {
+		return ErrInvalidVersionOrWorkspace
+	}
+
+	existingCollection, err := loadCollectionObjectByPath(ctx, m, opts...)
+	if err != nil {
+		if errors.Is(err, dberror.ErrNotFound) {
+			return ErrObjectNotFound.Msg("collection not found")
+		}
+		log.Ctx(ctx).Error().Err(err).Msg("failed to get existing collection")
+		return err
+	}
+	cm, err := collectionManagerFromObject(ctx, existingCollection, m)
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Msg("failed to load existing collection")
+		return err
+	}
+
+	schemaPath, _, schemaLoaders, err := setCollectionSchemaManager(ctx, cm, dir)
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Msg("failed to set collection schema manager")
+		return err
+	}
+
+	params := make([]string, 0, len(values))
+	for param := range values {
+		params = append(params, param)
+	}
+	sort.Strings(params)
+
+	var failures []string
+	for _, param := range params {
+		value := values[param]
+		if value.IsNil() {
+			err = cm.SetDefaultValues(param)
+		} else {
+			if v, verr := cm.GetValue(ctx, param); verr == nil && v.Equals(value) {
+				continue
+			}
+			err = cm.SetValue(ctx, schemaLoaders, param, value)
+		}
+		if err != nil {
+			failures = append(failures, param+": "+err.Error())
+		}
+	}
+	if len(failures) > 0 {
+		return ErrInvalidCollectionValues.Msg(strings.Join(failures, "; "))
+	}
+
+	s, err := collectionStorageRepresentation(cm)
+	if err != nil {
+		return err
+	}
+	data, err := s.Serialize()
+	if err != nil {
+		return err
+	}
+	newHash := s.GetHash()
+	if newHash == existingCollection.Hash {
+		if options.ErrorIfEqualToExisting {
+			return ErrEqualToExistingObject
+		}
+		return nil
+	}
+
+	// store this object and update the reference
+	obj := models.CatalogObject{
+		Type:    types.CatalogObjectTypeCatalogCollection,
+		Hash:    newHash,
+		Version: s.Version,
+		Data:    data,
+	}
+
+	return saveCollectionObject(ctx, m, &obj, dir, pathWithName, schemaPath, "")
+}
+
+// PatchCollection applies the JSON merge patch in patchJson to the values of the collection identified by reqCtx.
+// Only spec.values may be patched; the schema and metadata of a collection are changed with a PUT.
+func PatchCollection(ctx context.Context, reqCtx RequestContext, patchJson []byte) apperrors.Error {
+	if reqCtx.Catalog == "" || reqCtx.CatalogID == uuid.Nil {
+		return ErrInvalidCatalog
+	}
+	if reqCtx.Variant == "" || reqCtx.VariantID == uuid.Nil {
+		return ErrInvalidVariant
+	}
+
+	var patch map[string]json.RawMessage
+	if err := json.Unmarshal(patchJson, &patch); err != nil {
+		return validationerrors.ErrSchemaValidation.Msg("failed to parse request")
+	}
+	for k := range patch {
+		if k != "spec" && k != "kind" && k != "version" {
+			return validationerrors.ErrSchemaValidation.Msg("only spec.values can be patched")
+		}
+	}
+	var spec map[string]json.RawMessage
+	if err := json.Unmarshal(patch["spec"], &spec); err != nil || spec == nil {
+		return validationerrors.ErrSchemaValidation.Msg("missing spec.values")
+	}
+	for k := range spec {
+		if k != "values" {
+			return validationerrors.ErrSchemaValidation.Msg("only spec.values can be patched")
+		}
+	}
+	values := make(attributeValues)
+	if err := json.Unmarshal(spec["values"], &values); err != nil {
+		return validationerrors.ErrSchemaValidation.Msg("spec.values must be an object")
+	}
+	if len(values) == 0 {
+		return nil
+	}
+
+	m := &schemamanager.SchemaMetadata{
+		Catalog:   reqCtx.Catalog,
+		Variant:   types.NullableStringFrom(reqCtx.Variant),
+		Namespace: types.NullableStringFrom(reqCtx.Namespace),
+		Path:      reqCtx.ObjectPath,
+		Name:      reqCtx.ObjectName,
+	}
+	if ves := m.Validate(); ves != nil {
+		return validationerrors.ErrSchemaValidation.Msg(ves.Error())
+	}
+	m.IDS.CatalogID = reqCtx.CatalogID
+	m.IDS.VariantID = reqCtx.VariantID
+
+	return PatchAttributes(ctx, m, values, WithWorkspaceID(reqCtx.WorkspaceID))
 }
 
 type attributeResource struct {
@@ -312,9 +486,15 @@ func (ar *attributeResource) Get(ctx context.Context) ([]byte, apperrors.Error)
 	}
 	m.IDS.CatalogID = ar.reqCtx.CatalogID
 	m.IDS.VariantID = ar.reqCtx.VariantID
+	opts := []ObjectStoreOption{WithWorkspaceID(ar.reqCtx.WorkspaceID)}
+	if reveal, err := revealSensitiveValues(ar.reqCtx); err != nil {
+		return nil, err
+	} else if reveal {
+		opts = append(opts, RevealSensitiveValues())
+	}
 
 	if !returnCollection {
-		object, err := GetAttribute(ctx, m, ar.reqCtx.ObjectName, WithWorkspaceID(ar.reqCtx.WorkspaceID))
+		object, err := GetAttribute(ctx, m, ar.reqCtx.ObjectName, opts...)
 		if err != nil {
 			return nil, err
 		}
@@ -328,7 +508,7 @@ func (ar *attributeResource) Get(ctx context.Context) ([]byte, apperrors.Error)
 			return ret, nil
 		}
 	} else {
-		object, err := GetAllAttributes(ctx, m, WithWorkspaceID(ar.reqCtx.WorkspaceID))
+		object, err := GetAllAttributes(ctx, m, opts...)
 		if err != nil {
 			return nil, err
 		}
@@ -0,0 +1,257 @@
+package catalogmanager
+
+import (
+	"context"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/mugiliam/common/apperrors"
+	"github.com/mugiliam/hatchcatalogsrv/internal/catalogmanager/schemamanager"
+	"github.com/mugiliam/hatchcatalogsrv/internal/catalogmanager/validationerrors"
+	"github.com/mugiliam/hatchcatalogsrv/internal/db"
+	"github.com/mugiliam/hatchcatalogsrv/internal/db/models"
+	"github.com/mugiliam/hatchcatalogsrv/pkg/types"
+)
+
+// maxBatchDeleteItems is the largest number of objects that can be deleted in one batch delete
+const maxBatchDeleteItems = 500
+
+// Status of an item in a batch delete
+const (
+	BatchDeleteStatusDeleted     = "deleted"
+	BatchDeleteStatusWouldDelete = "wouldDelete" // the object would be deleted by a batch delete that is not a dry run
+	BatchDeleteStatusNotFound    = "notFound"
+	BatchDeleteStatusBlocked     = "blocked"
+)
+
+// BatchDeleteItem names an object to delete in a batch delete. Kind defaults to the kind of the endpoint, and the
+// namespace of the request is used if Namespace is empty.
+type BatchDeleteItem struct {
+	Kind      string `json:"kind,omitempty"`
+	Name      string `json:"name"`
+	Path      string `json:"path"`
+	Namespace string `json:"namespace,omitempty"`
+}
+
+// BatchDeleteResult is the outcome for an item of a batch delete. Reason says what keeps a blocked object from
+// being deleted.
+type BatchDeleteResult struct {
+	Kind      string `json:"kind"`
+	Name      string `json:"name"`
+	Path      string `json:"path"`
+	Namespace string `json:"namespace,omitempty"`
+	Status    string `json:"status"`
+	Reason    string `json:"reason,omitempty"`
+}
+
+// batchDeleteEntry is an object found for an item of a batch delete
+type batchDeleteEntry struct {
+	item        int
+	t           types.CatalogObjectType
+	storagePath string
+	m           schemamanager.SchemaMetadata
+}
+
+// BatchDelete deletes the objects named by items from the workspace or variant of reqCtx. Items of kind t are
+// expected, but any item may name a parameter schema, collection schema or collection. Objects are deleted in
+// an order that keeps the catalog consistent: collections first, then collection schemas with the deepest
+// first, then parameter schemas. An object that something outside of the batch still depends on is not
+// deleted and is reported as blocked, as is everything it in turn depends on, while the rest of the batch
+// proceeds. All deletes are made in one transaction. With dryRun, nothing is deleted and the results report
+// what would be. The results are in the order of items.
+func BatchDelete(ctx context.Context, t types.CatalogObjectType, reqCtx RequestContext, items []BatchDeleteItem, dryRun bool) ([]BatchDeleteResult, apperrors.Error) {
+	if reqCtx.Catalog == "" || reqCtx.CatalogID == uuid.Nil {
+		return nil, ErrInvalidCatalog
+	}
+	if reqCtx.Variant == "" || reqCtx.VariantID == uuid.Nil {
+		return nil, ErrInvalidVariant
+	}
+	if len(items) > maxBatchDeleteItems {
+		return nil, ErrInvalidRequest.Msg("a batch delete can delete at most " + strconv.Itoa(maxBatchDeleteItems) + " objects")
+	}
+
+	var dir Directories
+	var err apperrors.Error
+	if reqCtx.WorkspaceID != uuid.Nil {
+		dir, err = getDirectoriesForWorkspace(ctx, reqCtx.WorkspaceID)
+	} else {
+		dir, err = getDirectoriesForVariant(ctx, reqCtx.VariantID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	dirs := make(map[types.CatalogObjectType]models.Directory)
+	for _, dt := range []types.CatalogObjectType{
+		types.CatalogObjectTypeParameterSchema,
+		types.CatalogObjectTypeCollectionSchema,
+		types.CatalogObjectTypeCatalogCollection,
+	} {
+		if dirs[dt], err = loadDirectory(ctx, dt, dir.DirForType(dt)); err != nil {
+			return nil, err
+		}
+	}
+
+	results := make([]BatchDeleteResult, len(items))
+	entries := make(map[types.CatalogObjectType][]batchDeleteEntry)
+	first := make(map[string]int) // index of the first item for an object, keyed by type and storage path
+	duplicates := make(map[int]int)
+	for i, item := range items {
+		kind := item.Kind
+		if kind == "" {
+			kind = types.Kind(t)
+		}
+		it := types.CatalogObjectTypeFromKind(kind)
+		if _, ok := dirs[it]; !ok {
+			return nil, ErrInvalidRequest.Msg("item " + strconv.Itoa(i) + ": unsupported kind " + kind)
+		}
+		ns := item.Namespace
+		if ns == "" {
+			ns = reqCtx.Namespace
+		}
+		p := item.Path
+		if p == "" {
+			p = "/"
+		}
+		m := schemamanager.SchemaMetadata{
+			Catalog:   reqCtx.Catalog,
+			Variant:   types.NullableStringFrom(reqCtx.Variant),
+			Namespace: types.NullableStringFrom(ns),
+			Path:      path.Clean("/" + p),
+			Name:      item.Name,
+			IDS: schemamanager.IDS{
+				CatalogID: reqCtx.CatalogID,
+				VariantID: reqCtx.VariantID,
+			},
+		}
+		m.CanonicalizeNamespace()
+		if ves := m.Validate(); ves != nil {
+			return nil, validationerrors.ErrSchemaValidation.Msg("item " + strconv.Itoa(i) + ": " + ves.Error())
+		}
+		storagePath := path.Clean(m.GetStoragePath(it) + "/" + m.Name)
+		results[i] = BatchDeleteResult{
+			Kind:      kind,
+			Name:      m.Name,
+			Path:      m.Path,
+			Namespace: m.Namespace.String(),
+			Status:    BatchDeleteStatusNotFound,
+		}
+		key := string(it) + ":" + storagePath
+		if j, ok := first[key]; ok {
+			duplicates[i] = j
+			continue
+		}
+		first[key] = i
+		if _, ok := dirs[it][storagePath]; ok {
+			entries[it] = append(entries[it], batchDeleteEntry{item: i, t: it, storagePath: storagePath, m: m})
+		}
+	}
+
+	plan := planBatchDelete(dirs, entries, results)
+	for i := range plan {
+		if dryRun {
+			results[plan[i].item].Status = BatchDeleteStatusWouldDelete
+		} else {
+			results[plan[i].item].Status = BatchDeleteStatusDeleted
+		}
+	}
+	for i, j := range duplicates {
+		results[i].Status, results[i].Reason = results[j].Status, results[j].Reason
+	}
+	if dryRun || len(plan) == 0 {
+		return results, nil
+	}
+
+	err = db.WithTx(ctx, func(ctx context.Context) apperrors.Error {
+		for _, e := range plan {
+			var err apperrors.Error
+			if e.t == types.CatalogObjectTypeCatalogCollection {
+				err = DeleteCollection(ctx, &e.m, WithDirectories(dir))
+			} else {
+				err = DeleteSchema(ctx, e.t, &e.m, dir)
+			}
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// planBatchDelete returns the entries that can be deleted, in the order they must be deleted in, and marks the
+// results of the others as blocked. An object is blocked if an object that depends on it remains in dirs once
+// the batch is done.
+func planBatchDelete(dirs map[types.CatalogObjectType]models.Directory, entries map[types.CatalogObjectType][]batchDeleteEntry, results []BatchDeleteResult) []batchDeleteEntry {
+	var plan []batchDeleteEntry
+	deleted := make(map[types.CatalogObjectType]map[string]bool)
+	for t := range dirs {
+		deleted[t] = make(map[string]bool)
+	}
+	block := func(e batchDeleteEntry, reason string, paths []string) {
+		sort.Strings(paths)
+		results[e.item].Status = BatchDeleteStatusBlocked
+		results[e.item].Reason = reason + ": " + strings.Join(paths, ", ")
+	}
+
+	// nothing depends on a collection
+	for _, e := range entries[types.CatalogObjectTypeCatalogCollection] {
+		deleted[e.t][e.storagePath] = true
+		plan = append(plan, e)
+	}
+
+	// a collection schema is needed by the schemas below it and the collections based on it
+	schemas := entries[types.CatalogObjectTypeCollectionSchema]
+	sort.Slice(schemas, func(i, j int) bool {
+		di, dj := strings.Count(schemas[i].storagePath, "/"), strings.Count(schemas[j].storagePath, "/")
+		if di != dj {
+			return di > dj
+		}
+		return schemas[i].storagePath < schemas[j].storagePath
+	})
+	for _, e := range schemas {
+		var children, collections []string
+		for p := range dirs[types.CatalogObjectTypeCollectionSchema] {
+			if strings.HasPrefix(p, e.storagePath+"/") && !deleted[types.CatalogObjectTypeCollectionSchema][p] {
+				children = append(children, p)
+			}
+		}
+		for p, ref := range dirs[types.CatalogObjectTypeCatalogCollection] {
+			if ref.BaseSchema == e.storagePath && !deleted[types.CatalogObjectTypeCatalogCollection][p] {
+				collections = append(collections, p)
+			}
+		}
+		if len(children) > 0 {
+			block(e, "has child collection schemas", children)
+			continue
+		}
+		if len(collections) > 0 {
+			block(e, "has collections", collections)
+			continue
+		}
+		deleted[e.t][e.storagePath] = true
+		plan = append(plan, e)
+	}
+
+	// a parameter schema is needed by the collection schemas that refer to it
+	for _, e := range entries[types.CatalogObjectTypeParameterSchema] {
+		var referrers []string
+		for _, ref := range dirs[types.CatalogObjectTypeParameterSchema][e.storagePath].References {
+			if !deleted[types.CatalogObjectTypeCollectionSchema][ref.Name] {
+				referrers = append(referrers, ref.Name)
+			}
+		}
+		if len(referrers) > 0 {
+			block(e, "referenced by collection schemas", referrers)
+			continue
+		}
+		deleted[e.t][e.storagePath] = true
+		plan = append(plan, e)
+	}
+	return plan
+}
@@ -0,0 +1,133 @@
+package catalogmanager
+
+import (
+	"context"
+	"encoding/json"
+	"path"
+	"strconv"
+
+	"github.com/google/uuid"
+	"github.com/mugiliam/common/apperrors"
+	"github.com/mugiliam/hatchcatalogsrv/internal/catalogmanager/schemamanager"
+	"github.com/mugiliam/hatchcatalogsrv/internal/catalogmanager/validationerrors"
+	"github.com/mugiliam/hatchcatalogsrv/internal/db"
+	"github.com/mugiliam/hatchcatalogsrv/pkg/api/schemastore"
+	"github.com/mugiliam/hatchcatalogsrv/pkg/types"
+	"github.com/rs/zerolog/log"
+)
+
+// maxBatchGetItems is the largest number of objects that can be requested in one batch get
+const maxBatchGetItems = 500
+
+// BatchGetItem names an object to load in a batch get. The namespace of the request is used if Namespace is
+// empty.
+type BatchGetItem struct {
+	Name      string `json:"name"`
+	Path      string `json:"path"`
+	Namespace string `json:"namespace,omitempty"`
+}
+
+// BatchGetResult is the object loaded for an item of a batch get. Object is the same document a GET of the
+// object returns, and is omitted when the object is not found.
+type BatchGetResult struct {
+	Name      string          `json:"name"`
+	Path      string          `json:"path"`
+	Namespace string          `json:"namespace,omitempty"`
+	Found     bool            `json:"found"`
+	Object    json.RawMessage `json:"object,omitempty"`
+}
+
+// BatchGetSchemas loads the schemas of type t named by items from the workspace or variant of reqCtx with a
+// single directory lookup. The results are in the order of items; an object that does not exist is returned
+// with Found set to false rather than failing the request.
+func BatchGetSchemas(ctx context.Context, t types.CatalogObjectType, reqCtx RequestContext, items []BatchGetItem) ([]BatchGetResult, apperrors.Error) {
+	if t != types.CatalogObjectTypeCollectionSchema && t != types.CatalogObjectTypeParameterSchema {
+		return nil, ErrInvalidSchema
+	}
+	if reqCtx.Catalog == "" || reqCtx.CatalogID == uuid.Nil {
+		return nil, ErrInvalidCatalog
+	}
+	if reqCtx.Variant == "" || reqCtx.VariantID == uuid.Nil {
+		return nil, ErrInvalidVariant
+	}
+	if len(items) > maxBatchGetItems {
+		return nil, ErrInvalidRequest.Msg("a batch get can request at most " + strconv.Itoa(maxBatchGetItems) + " objects")
+	}
+
+	var dir Directories
+	var err apperrors.Error
+	if reqCtx.WorkspaceID != uuid.Nil {
+		dir, err = getDirectoriesForWorkspace(ctx, reqCtx.WorkspaceID)
+	} else {
+		dir, err = getDirectoriesForVariant(ctx, reqCtx.VariantID)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	metadata := make([]schemamanager.SchemaMetadata, len(items))
+	storagePaths := make([]string, len(items))
+	for i, item := range items {
+		ns := item.Namespace
+		if ns == "" {
+			ns = reqCtx.Namespace
+		}
+		p := item.Path
+		if p == "" {
+			p = "/"
+		}
+		m := schemamanager.SchemaMetadata{
+			Catalog:   reqCtx.Catalog,
+			Variant:   types.NullableStringFrom(reqCtx.Variant),
+			Namespace: types.NullableStringFrom(ns),
+			Path:      path.Clean("/" + p),
+			Name:      item.Name,
+			IDS: schemamanager.IDS{
+				CatalogID: reqCtx.CatalogID,
+				VariantID: reqCtx.VariantID,
+			},
+		}
+		if ves := m.Validate(); ves != nil {
+			return nil, validationerrors.ErrSchemaValidation.Msg("item " + strconv.Itoa(i) + ": " + ves.Error())
+		}
+		metadata[i] = m
+		storagePaths[i] = path.Clean(m.GetStoragePath(t) + "/" + m.Name)
+	}
+
+	objs, err := db.ReadDB(ctx).LoadObjectsByPaths(ctx, t, dir.DirForType(t), storagePaths)
+	if err != nil {
+		return nil, ErrCatalogError.Err(err)
+	}
+
+	results := make([]BatchGetResult, len(items))
+	for i := range items {
+		m := metadata[i]
+		results[i] = BatchGetResult{
+			Name:      m.Name,
+			Path:      m.Path,
+			Namespace: m.Namespace.String(),
+		}
+		obj, ok := objs[storagePaths[i]]
+		if !ok {
+			continue
+		}
+		s := &schemastore.SchemaStorageRepresentation{}
+		if err := json.Unmarshal(obj.Data, s); err != nil {
+			return nil, ErrUnableToLoadObject.Err(err).Msg("failed to de-serialize catalog object data")
+		}
+		if s.Type != obj.Type {
+			log.Ctx(ctx).Error().Str("Hash", obj.Hash).Msg("type mismatch when loading resource")
+		}
+		sm, err := loadSchemaManager(ctx, s, &m)
+		if err != nil {
+			return nil, err
+		}
+		j, err := sm.ToJson(ctx)
+		if err != nil {
+			return nil, err
+		}
+		results[i].Found = true
+		results[i].Object = j
+	}
+	return results, nil
+}
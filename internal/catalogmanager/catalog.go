@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"errors"
 	"reflect"
+	"time"
 
 	"github.com/go-playground/validator/v10"
 	"github.com/google/uuid"
@@ -28,8 +29,11 @@ type catalogSchema struct {
 }
 
 type catalogMetadata struct {
-	Name        string `json:"name" validate:"required,resourceNameValidator"`
-	Description string `json:"description"`
+	Name           string     `json:"name" validate:"required,resourceNameValidator"`
+	Description    string     `json:"description"`
+	DefaultVariant string     `json:"defaultVariant,omitempty" validate:"omitempty,resourceNameValidator"`
+	CreatedAt      *time.Time `json:"createdAt,omitempty"` // set by the server, ignored on input
+	UpdatedAt      *time.Time `json:"updatedAt,omitempty"` // set by the server, ignored on input
 }
 
 type catalogManager struct {
@@ -96,6 +100,11 @@ func NewCatalogManager(ctx context.Context, rsrcJson []byte, name string) (schem
 		return nil, ErrInvalidSchema.Err(ves)
 	}
 
+	// a new catalog only has the default variant
+	if cs.Metadata.DefaultVariant != "" && cs.Metadata.DefaultVariant != types.DefaultVariant {
+		return nil, ErrVariantNotFound.Msg("variant " + cs.Metadata.DefaultVariant + " does not exist in a new catalog")
+	}
+
 	c := models.Catalog{
 		Name:        cs.Metadata.Name,
 		Description: cs.Metadata.Description,
@@ -120,6 +129,10 @@ func (cm *catalogManager) Description() string {
 	return cm.c.Description
 }
 
+func (cm *catalogManager) DefaultVariant() string {
+	return cm.c.DefaultVariant()
+}
+
 func LoadCatalogManagerByName(ctx context.Context, name string) (schemamanager.CatalogManager, apperrors.Error) {
 	c, err := db.DB(ctx).GetCatalog(ctx, uuid.Nil, name)
 	if err != nil {
@@ -135,6 +148,9 @@ func LoadCatalogManagerByName(ctx context.Context, name string) (schemamanager.C
 }
 
 func (cm *catalogManager) Save(ctx context.Context) apperrors.Error {
+	if err := checkCatalogQuota(ctx); err != nil {
+		return err
+	}
 	err := db.DB(ctx).CreateCatalog(ctx, &cm.c)
 	if err != nil {
 		if errors.Is(err, dberror.ErrAlreadyExists) {
@@ -151,8 +167,11 @@ func (cm *catalogManager) ToJson(ctx context.Context) ([]byte, apperrors.Error)
 		Version: types.VersionV1,
 		Kind:    types.CatalogKind,
 		Metadata: catalogMetadata{
-			Name:        cm.c.Name,
-			Description: cm.c.Description,
+			Name:           cm.c.Name,
+			Description:    cm.c.Description,
+			DefaultVariant: cm.c.GetInfo().DefaultVariant,
+			CreatedAt:      &cm.c.CreatedAt,
+			UpdatedAt:      &cm.c.UpdatedAt,
 		},
 	}
 	j, err := json.Marshal(s)
@@ -163,6 +182,28 @@ func (cm *catalogManager) ToJson(ctx context.Context) ([]byte, apperrors.Error)
 	return j, nil
 }
 
+// DefaultVariantOfCatalog returns the name and ID of the variant that requests to a catalog use when they do not
+// name one. The catalog is looked up by catalogID, or by name if catalogID is nil.
+func DefaultVariantOfCatalog(ctx context.Context, catalogID uuid.UUID, name string) (string, uuid.UUID, apperrors.Error) {
+	c, err := db.DB(ctx).GetCatalog(ctx, catalogID, name)
+	if err != nil {
+		if errors.Is(err, dberror.ErrNotFound) {
+			return "", uuid.Nil, ErrCatalogNotFound
+		}
+		log.Ctx(ctx).Error().Err(err).Msg("failed to load catalog")
+		return "", uuid.Nil, err
+	}
+	v, err := db.DB(ctx).GetVariant(ctx, c.CatalogID, uuid.Nil, c.DefaultVariant())
+	if err != nil {
+		if errors.Is(err, dberror.ErrNotFound) {
+			return "", uuid.Nil, ErrVariantNotFound
+		}
+		log.Ctx(ctx).Error().Err(err).Msg("failed to load variant")
+		return "", uuid.Nil, err
+	}
+	return v.Name, v.VariantID, nil
+}
+
 func DeleteCatalogByName(ctx context.Context, name string) apperrors.Error {
 	err := db.DB(ctx).DeleteCatalog(ctx, uuid.Nil, name)
 	if err != nil {
@@ -175,6 +216,40 @@ func DeleteCatalogByName(ctx context.Context, name string) apperrors.Error {
 	return nil
 }
 
+// RenameCatalog renames the catalog called name to newName and returns the renamed catalog. The catalog keeps
+// its ID, so its variants, workspaces and objects stay with it. Objects are hashed with the name of their
+// catalog, so an unchanged object that is saved again after the rename is stored as a new object.
+func RenameCatalog(ctx context.Context, name, newName string) ([]byte, apperrors.Error) {
+	if err := schemavalidator.V().Var(newName, "required,resourceNameValidator"); err != nil {
+		return nil, ErrInvalidCatalog.Msg("invalid catalog name " + newName)
+	}
+	c, err := db.DB(ctx).GetCatalog(ctx, uuid.Nil, name)
+	if err != nil {
+		if errors.Is(err, dberror.ErrNotFound) {
+			return nil, ErrCatalogNotFound
+		}
+		log.Ctx(ctx).Error().Err(err).Msg("failed to load catalog")
+		return nil, err
+	}
+	if c.Name != newName {
+		if err := db.DB(ctx).RenameCatalog(ctx, c.CatalogID, newName); err != nil {
+			switch {
+			case errors.Is(err, dberror.ErrAlreadyExists):
+				return nil, ErrAlreadyExists.Msg("catalog " + newName + " already exists")
+			case errors.Is(err, dberror.ErrNotFound):
+				return nil, ErrCatalogNotFound
+			}
+			log.Ctx(ctx).Error().Err(err).Str("catalog", name).Msg("failed to rename catalog")
+			return nil, ErrUnableToUpdateObject.Msg("failed to rename catalog")
+		}
+	}
+	cm, err := LoadCatalogManagerByName(ctx, newName)
+	if err != nil {
+		return nil, err
+	}
+	return cm.ToJson(ctx)
+}
+
 type catalogResource struct {
 	name RequestContext
 	cm   schemamanager.CatalogManager
@@ -202,6 +277,7 @@ func (cr *catalogResource) Create(ctx context.Context, rsrcJson []byte) (string,
 		return "", err
 	}
 	cr.cm = catalog
+	cr.name.Catalog = catalog.Name()
 	return cr.Location(), nil
 }
 
@@ -242,6 +318,24 @@ func (cr *catalogResource) Update(ctx context.Context, rsrcJson []byte) apperror
 	}
 	c.Description = cs.Metadata.Description
 
+	// the default variant must exist when it is set. It is only checked when it changes, so that the catalog
+	// can be saved as it was read.
+	info := c.GetInfo()
+	if dv := cs.Metadata.DefaultVariant; dv != "" && dv != info.DefaultVariant {
+		if _, err := db.DB(ctx).GetVariant(ctx, c.CatalogID, uuid.Nil, dv); err != nil {
+			if errors.Is(err, dberror.ErrNotFound) {
+				return ErrVariantNotFound.Msg("variant " + dv + " not found")
+			}
+			log.Ctx(ctx).Error().Err(err).Msg("failed to load variant")
+			return err
+		}
+	}
+	info.DefaultVariant = cs.Metadata.DefaultVariant
+	if err := c.SetInfo(info); err != nil {
+		log.Ctx(ctx).Error().Err(err).Msg("failed to set catalog info")
+		return ErrUnableToUpdateObject.Msg("failed to update catalog")
+	}
+
 	err = db.DB(ctx).UpdateCatalog(ctx, c)
 	if err != nil {
 		log.Ctx(ctx).Error().Err(err).Msg("failed to update catalog")
@@ -0,0 +1,68 @@
+package catalogmanager
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	"github.com/mugiliam/common/apperrors"
+	"github.com/mugiliam/hatchcatalogsrv/internal/db"
+	"github.com/mugiliam/hatchcatalogsrv/internal/db/dberror"
+	"github.com/rs/zerolog/log"
+)
+
+// CatalogStats is the response of a request for the number of objects in a variant of a catalog. ObjectBytes
+// is the size of the data of the distinct catalog objects the variant refers to.
+type CatalogStats struct {
+	Catalog           string `json:"catalog"`
+	Variant           string `json:"variant"`
+	ParameterSchemas  int    `json:"parameterSchemas"`
+	CollectionSchemas int    `json:"collectionSchemas"`
+	Collections       int    `json:"collections"`
+	Namespaces        int    `json:"namespaces"`
+	Workspaces        int    `json:"workspaces"`
+	Versions          int    `json:"versions"`
+	ObjectBytes       int64  `json:"objectBytes"`
+}
+
+// GetCatalogStats returns the number of objects in the variant identified by reqCtx, in the catalog named
+// reqCtx.Catalog
+func GetCatalogStats(ctx context.Context, reqCtx RequestContext) ([]byte, apperrors.Error) {
+	if reqCtx.Catalog == "" {
+		return nil, ErrInvalidCatalog
+	}
+	catalogID, err := db.ReadDB(ctx).GetCatalogIDByName(ctx, reqCtx.Catalog)
+	if err != nil {
+		if errors.Is(err, dberror.ErrNotFound) {
+			return nil, ErrCatalogNotFound
+		}
+		return nil, ErrCatalogError.Err(err)
+	}
+	reqCtx.CatalogID = catalogID
+	v, err := variantForRequest(ctx, reqCtx)
+	if err != nil {
+		return nil, err
+	}
+
+	s, err := db.ReadDB(ctx).GetVariantStats(ctx, v.VariantID)
+	if err != nil {
+		return nil, ErrCatalogError.Err(err)
+	}
+	rsp := CatalogStats{
+		Catalog:           reqCtx.Catalog,
+		Variant:           v.Name,
+		ParameterSchemas:  s.ParameterSchemas,
+		CollectionSchemas: s.CollectionSchemas,
+		Collections:       s.Collections,
+		Namespaces:        s.Namespaces,
+		Workspaces:        s.Workspaces,
+		Versions:          s.Versions,
+		ObjectBytes:       s.ObjectBytes,
+	}
+	j, e := json.Marshal(rsp)
+	if e != nil {
+		log.Ctx(ctx).Error().Err(e).Msg("failed to marshal catalog stats")
+		return nil, ErrCatalogError
+	}
+	return j, nil
+}
@@ -4,8 +4,8 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
-	"net/url"
 	"path"
+	"strings"
 
 	"github.com/google/uuid"
 	"github.com/mugiliam/common/apperrors"
@@ -14,6 +14,8 @@ import (
 	"github.com/mugiliam/hatchcatalogsrv/internal/db"
 	"github.com/mugiliam/hatchcatalogsrv/internal/db/dberror"
 	"github.com/mugiliam/hatchcatalogsrv/internal/db/models"
+	"github.com/mugiliam/hatchcatalogsrv/internal/tracing"
+	"github.com/mugiliam/hatchcatalogsrv/internal/webhook"
 	"github.com/mugiliam/hatchcatalogsrv/pkg/api/schemastore"
 	"github.com/mugiliam/hatchcatalogsrv/pkg/types"
 	"github.com/rs/zerolog/log"
@@ -23,6 +25,9 @@ func NewCollectionManager(ctx context.Context, rsrcJson []byte, m *schemamanager
 	if len(rsrcJson) == 0 {
 		return nil, validationerrors.ErrEmptySchema
 	}
+	if err := checkDuplicateKeys(rsrcJson, "spec.values"); err != nil {
+		return nil, err
+	}
 
 	// get the metadata, replace fields in json from provided metadata. Set defaults.
 	rsrcJson, m, err := canonicalizeMetadata(rsrcJson, types.CollectionKind, m)
@@ -56,6 +61,8 @@ func SaveCollection(ctx context.Context, cm schemamanager.CollectionManager, opt
 	if cm == nil {
 		return validationerrors.ErrEmptySchema
 	}
+	ctx, span := tracing.Start(ctx, "catalogmanager.SaveCollection")
+	defer span.End()
 
 	options := storeOptions{}
 	for _, opt := range opts {
@@ -87,6 +94,13 @@ func SaveCollection(ctx context.Context, cm schemamanager.CollectionManager, opt
 		return ErrInvalidVersionOrWorkspace
 	}
 
+	// the namespace may have been deleted since the collection was validated
+	if m.IDS.VariantID != uuid.Nil {
+		if err := checkNamespaceExists(ctx, m.Namespace, m.IDS.VariantID); err != nil {
+			return err
+		}
+	}
+
 	existingCollection, err := loadCollectionObjectByPath(ctx, &m, opts...)
 	if err != nil {
 		if errors.Is(err, dberror.ErrNotFound) {
@@ -111,15 +125,43 @@ func SaveCollection(ctx context.Context, cm schemamanager.CollectionManager, opt
 		if cmCurrent.Schema() != cm.Schema() {
 			return ErrSchemaOfCollectionNotMutable
 		}
+		// A collection saved with the same spec keeps its current values, so re-applying an unchanged collection
+		// can be detected from the stored values without loading the collection schema and validating them.
+		// The defaults of the values come from the schema, so this only holds while the schema is the one the
+		// values were validated against.
+		candidate := cm.StorageRepresentation()
+		candidate.Values = cmCurrent.StorageRepresentation().Values
+		if err := sealSensitiveValues(candidate); err != nil {
+			return err
+		}
+		if candidate.GetHash() == existingCollection.Hash {
+			unchanged, err := collectionSchemaUnchanged(ctx, dir, pathWithName)
+			if err != nil {
+				return err
+			}
+			if unchanged {
+				if options.ErrorIfEqualToExisting {
+					return ErrEqualToExistingObject
+				}
+				return nil
+			}
+		}
 	}
 
-	schemaPath, schemaLoaders, err := setCollectionSchemaManager(ctx, cm, dir)
+	schemaPath, schemaHash, schemaLoaders, err := setCollectionSchemaManager(ctx, cm, dir)
 	if err != nil {
 		log.Ctx(ctx).Error().Err(err).Msg("failed to set collection schema manager")
 		return err
 	}
 	cm.SetCollectionSchemaPath(schemaPath)
 
+	if unknown := cm.UnknownValues(); len(unknown) > 0 {
+		if !options.AllowUnknownValues {
+			return ErrUnknownValueKeys.Msg("unknown keys: " + strings.Join(unknown, ", "))
+		}
+		log.Ctx(ctx).Debug().Strs("keys", unknown).Msg("ignoring unknown keys in collection values")
+	}
+
 	var cmCurrentValues schemamanager.ParamValues = nil
 	if cmCurrent != nil {
 		cmCurrentValues = cmCurrent.Values()
@@ -130,7 +172,10 @@ func SaveCollection(ctx context.Context, cm schemamanager.CollectionManager, opt
 		return err
 	}
 
-	s := cm.StorageRepresentation()
+	s, err := collectionStorageRepresentation(cm)
+	if err != nil {
+		return err
+	}
 
 	data, err := s.Serialize()
 	if err != nil {
@@ -143,6 +188,9 @@ func SaveCollection(ctx context.Context, cm schemamanager.CollectionManager, opt
 		}
 		return nil
 	}
+	if err := checkObjectQuota(ctx, m.IDS.VariantID, existingCollection == nil, len(data)); err != nil {
+		return err
+	}
 	// store this object and update the reference
 	obj := models.CatalogObject{
 		Type:    t,
@@ -151,10 +199,73 @@ func SaveCollection(ctx context.Context, cm schemamanager.CollectionManager, opt
 		Data:    data,
 	}
 
-	return saveCollectionObject(ctx, &m, &obj, dir, pathWithName, schemaPath)
+	// the values were validated against the schema at schemaHash, so the collection is only saved if the
+	// schema has not been deleted or replaced since
+	err = db.WithTx(ctx, func(ctx context.Context) apperrors.Error {
+		if err := checkCollectionSchema(ctx, dir, schemaPath, schemaHash); err != nil {
+			return err
+		}
+		return saveCollectionObject(ctx, &m, &obj, dir, pathWithName, schemaPath, schemaHash)
+	})
+	if err != nil {
+		return err
+	}
+
+	action := webhook.ActionCreate
+	if existingCollection != nil {
+		action = webhook.ActionUpdate
+	}
+	notifyObjectChange(ctx, action, t, pathWithName, &m, dir, newHash)
+	return nil
 }
 
-func setCollectionSchemaManager(ctx context.Context, cm schemamanager.CollectionManager, dir Directories) (string, schemamanager.SchemaLoaders, apperrors.Error) {
+// checkCollectionSchema is called through a variable so that tests can change the schema between validation
+// and save
+var checkCollectionSchema = checkCollectionSchemaUnchanged
+
+// checkCollectionSchemaUnchanged returns ErrCollectionSchemaChanged if the collection schema at schemaPath no
+// longer exists or no longer has the hash hash
+func checkCollectionSchemaUnchanged(ctx context.Context, dir Directories, schemaPath, hash string) apperrors.Error {
+	ref, err := db.DB(ctx).GetObjectRefByPath(ctx, types.CatalogObjectTypeCollectionSchema, dir.CollectionsDir, schemaPath)
+	if err != nil {
+		if errors.Is(err, dberror.ErrNotFound) {
+			return ErrCollectionSchemaChanged.Msg("collection schema " + schemaPath + " was deleted")
+		}
+		log.Ctx(ctx).Error().Err(err).Str("path", schemaPath).Msg("failed to get collection schema")
+		return ErrCatalogError
+	}
+	if ref == nil || ref.Hash != hash {
+		return ErrCollectionSchemaChanged.Msg("collection schema " + schemaPath + " was modified")
+	}
+	return nil
+}
+
+// collectionSchemaUnchanged reports whether the collection schema of the collection at pathWithName is the one
+// its values were validated against. That is not known for collections whose values were not all validated
+// when they were saved, so it is false for them.
+func collectionSchemaUnchanged(ctx context.Context, dir Directories, pathWithName string) (bool, apperrors.Error) {
+	c, err := db.DB(ctx).GetCollection(ctx, pathWithName, dir.ValuesDir)
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Str("path", pathWithName).Msg("failed to get collection")
+		return false, ErrCatalogError.Err(err)
+	}
+	if c.SchemaHash == "" {
+		return false, nil
+	}
+	ref, err := db.DB(ctx).GetObjectRefByPath(ctx, types.CatalogObjectTypeCollectionSchema, dir.CollectionsDir, c.CollectionSchema)
+	if err != nil {
+		if errors.Is(err, dberror.ErrNotFound) {
+			return false, nil
+		}
+		log.Ctx(ctx).Error().Err(err).Str("path", c.CollectionSchema).Msg("failed to get collection schema")
+		return false, ErrCatalogError.Err(err)
+	}
+	return ref.Hash == c.SchemaHash, nil
+}
+
+// setCollectionSchemaManager resolves the collection schema of cm and sets its manager on cm. It returns the
+// path and hash of the schema and the loaders to validate values against it.
+func setCollectionSchemaManager(ctx context.Context, cm schemamanager.CollectionManager, dir Directories) (string, string, schemamanager.SchemaLoaders, apperrors.Error) {
 	var schemaPath string
 	var schemaObj *models.ObjectRef
 	var err apperrors.Error
@@ -166,7 +277,7 @@ func setCollectionSchemaManager(ctx context.Context, cm schemamanager.Collection
 		schemaObj, err = db.DB(ctx).GetObjectRefByPath(ctx, types.CatalogObjectTypeCollectionSchema, dir.CollectionsDir, schemaPath)
 	} else {
 		m := cm.Metadata()
-		if !cm.Metadata().Namespace.IsNil() {
+		if !m.InRootNamespace() {
 			schemaPath = path.Clean(m.GetStoragePath(types.CatalogObjectTypeCollectionSchema) + "/" + cm.Schema())
 			schemaObj, err = db.DB(ctx).GetObjectRefByPath(ctx, types.CatalogObjectTypeCollectionSchema, dir.CollectionsDir, schemaPath)
 		}
@@ -177,14 +288,28 @@ func setCollectionSchemaManager(ctx context.Context, cm schemamanager.Collection
 		}
 	}
 
-	if err != nil || schemaObj == nil {
-		return schemaPath, schemaLoaders, ErrInvalidCollectionSchema
+	if err != nil && !errors.Is(err, dberror.ErrNotFound) {
+		log.Ctx(ctx).Error().Err(err).Str("path", schemaPath).Msg("failed to get collection schema")
+		return schemaPath, "", schemaLoaders, ErrCatalogError
+	}
+	if schemaObj == nil {
+		return schemaPath, "", schemaLoaders, ErrInvalidCollectionSchema.Msg("collection schema " + cm.Schema() + " does not exist")
 	}
 
 	if err := loadCollectionSchemaManager(ctx, schemaObj.Hash, cm, WithDirectories(dir)); err != nil {
 		log.Ctx(ctx).Error().Err(err).Msg("failed to load collection schema manager")
-		return schemaPath, schemaLoaders, err
+		if errors.Is(err, ErrObjectNotFound) {
+			// the directory entry was removed or replaced after it was read
+			return schemaPath, "", schemaLoaders, ErrCollectionSchemaChanged.Msg("collection schema " + schemaPath + " was modified")
+		}
+		return schemaPath, "", schemaLoaders, err
+	}
+
+	params := make([]string, 0, len(schemaObj.References))
+	for _, ref := range schemaObj.References {
+		params = append(params, ref.Name)
 	}
+	cm.SetCollectionSchemaManager(withValidationCache(cm.CollectionSchemaManager(), validationSchemaKey(ctx, dir, schemaObj.Hash, params)))
 
 	schemaLoaders = getSchemaLoaders(ctx, cm.Metadata(), WithDirectories(dir), SkipCanonicalizePaths())
 	schemaLoaders.ParameterRef = func(name string) string {
@@ -195,20 +320,17 @@ func setCollectionSchemaManager(ctx context.Context, cm schemamanager.Collection
 		}
 		return ""
 	}
-	return schemaPath, schemaLoaders, nil
+	return schemaPath, schemaObj.Hash, schemaLoaders, nil
 }
 
-func saveCollectionObject(ctx context.Context, m *schemamanager.SchemaMetadata, obj *models.CatalogObject, dir Directories, pathWithName, collectionSchema string) apperrors.Error {
-	dberr := db.DB(ctx).CreateCatalogObject(ctx, obj)
-	if dberr != nil {
-		if errors.Is(dberr, dberror.ErrAlreadyExists) {
-			log.Ctx(ctx).Debug().Str("hash", obj.Hash).Msg("catalog object already exists")
-			return nil // already exists, nothing to do
-		}
-		log.Ctx(ctx).Error().Err(dberr).Msg("failed to save catalog object")
-		return dberr
-	}
+// upsertCollection is called through this variable so that tests can inject failures
+var upsertCollection = func(ctx context.Context, c *models.Collection, dir uuid.UUID) apperrors.Error {
+	return db.DB(ctx).UpsertCollection(ctx, c, dir)
+}
 
+// saveCollectionObject saves the catalog object of a collection and points the collection at it. Both are
+// written in one transaction, so a failure does not leave an object that no collection points to.
+func saveCollectionObject(ctx context.Context, m *schemamanager.SchemaMetadata, obj *models.CatalogObject, dir Directories, pathWithName, collectionSchema, schemaHash string) apperrors.Error {
 	repoId := uuid.Nil
 	if dir.WorkspaceID != uuid.Nil {
 		repoId = dir.WorkspaceID
@@ -216,27 +338,34 @@ func saveCollectionObject(ctx context.Context, m *schemamanager.SchemaMetadata,
 		repoId = dir.VariantID
 	}
 
-	namespace := m.Namespace.String()
-	if namespace == "" {
-		namespace = types.DefaultNamespace
-	}
-
 	c := models.Collection{
 		Path:             pathWithName,
 		Hash:             obj.Hash,
 		CollectionSchema: collectionSchema,
+		SchemaHash:       schemaHash,
 		RepoID:           repoId,
 		VariantID:        m.IDS.VariantID,
 	}
 
-	if err := db.DB(ctx).UpsertCollection(ctx, &c, dir.ValuesDir); err != nil {
-		log.Ctx(ctx).Error().Err(err).Msg("failed to create collection in database")
-		// If the collection creation fails, we should also delete the catalog object
-		if _, delErr := db.DB(ctx).DeleteObjectByPath(ctx, types.CatalogObjectTypeCatalogCollection, dir.ValuesDir, pathWithName); delErr != nil {
-			log.Ctx(ctx).Error().Err(delErr).Msg("failed to delete catalog object after collection creation failure")
+	return db.WithTx(ctx, func(ctx context.Context) apperrors.Error {
+		dberr := db.DB(ctx).CreateCatalogObject(ctx, obj)
+		if dberr != nil {
+			if errors.Is(dberr, dberror.ErrAlreadyExists) {
+				// the object may be shared with another collection or an earlier version of this one, so the
+				// collection is still pointed at it
+				log.Ctx(ctx).Debug().Str("hash", obj.Hash).Msg("catalog object already exists")
+			} else {
+				log.Ctx(ctx).Error().Err(dberr).Msg("failed to save catalog object")
+				return dberr
+			}
+		}
+
+		if err := upsertCollection(ctx, &c, dir.ValuesDir); err != nil {
+			log.Ctx(ctx).Error().Err(err).Msg("failed to create collection in database")
+			return ErrCatalogError.Err(err)
 		}
-		return ErrCatalogError.Err(err)
-	}
+		return nil
+	})
 	/*
 		// the reference will point to the collection schema
 		var refModel models.References
@@ -253,7 +382,6 @@ func saveCollectionObject(ctx context.Context, m *schemamanager.SchemaMetadata,
 			return ErrCatalogError
 		}
 	*/
-	return nil
 }
 
 func DeleteCollection(ctx context.Context, m *schemamanager.SchemaMetadata, opts ...ObjectStoreOption) apperrors.Error {
@@ -290,24 +418,31 @@ func DeleteCollection(ctx context.Context, m *schemamanager.SchemaMetadata, opts
 		return ErrInvalidVersionOrWorkspace
 	}
 
-	hash, err := db.DB(ctx).DeleteCollection(ctx, pathWithName, dir.ValuesDir)
+	// the directory entry and the object it refers to are deleted in one transaction
+	err := db.WithTx(ctx, func(ctx context.Context) apperrors.Error {
+		hash, err := db.DB(ctx).DeleteCollection(ctx, pathWithName, dir.ValuesDir)
+		if err != nil {
+			log.Ctx(ctx).Error().Err(err).Msg("failed to delete collection")
+			return err
+		}
+		if hash == "" {
+			return nil
+		}
+		// the object is kept if another collection or a version still refers to it
+		if err := db.DB(ctx).DeleteCatalogObject(ctx, hash); err != nil && !errors.Is(err, dberror.ErrNotFound) {
+			log.Ctx(ctx).Error().Err(err).Str("hash", string(hash)).Msg("failed to delete object from database")
+			return ErrCatalogError.Err(err)
+		}
+		return nil
+	})
 	if err != nil {
-		log.Ctx(ctx).Error().Err(err).Msg("failed to delete collection")
 		if errors.Is(err, dberror.ErrNotFound) {
 			return nil // already deleted
 		}
 		return err
 	}
 
-	if hash != "" {
-		err = db.DB(ctx).DeleteCatalogObject(ctx, types.CatalogObjectTypeCatalogCollection, hash)
-		if !errors.Is(err, dberror.ErrNotFound) {
-			// we don't return an error since the object reference has already been removed and
-			// we cannot roll this back.
-			log.Ctx(ctx).Error().Err(err).Str("hash", string(hash)).Msg("failed to delete object from database")
-		}
-	}
-
+	notifyObjectChange(ctx, webhook.ActionDelete, t, pathWithName, m, dir, "")
 	return nil
 }
 
@@ -424,6 +559,11 @@ func collectionManagerFromObject(ctx context.Context, obj *models.CatalogObject,
 		return nil, ErrUnableToLoadObject
 	}
 
+	if err := unsealSensitiveValues(&s); err != nil {
+		log.Ctx(ctx).Error().Err(err).Str("hash", obj.Hash).Msg("failed to decrypt sensitive values of collection")
+		return nil, err
+	}
+
 	cm := &collectionManager{}
 	if err := json.Unmarshal(s.Schema, &cm.schema.Spec); err != nil {
 		log.Ctx(ctx).Error().Err(err).Msg("failed to unmarshal collection schema spec")
@@ -454,26 +594,23 @@ func (cr *collectionResource) Name() string {
 }
 
 func (cr *collectionResource) Location() string {
-	objName := types.ResourceNameFromObjectType(cr.reqCtx.ObjectType)
-	loc := path.Clean("/" + objName + cr.cm.FullyQualifiedName())
-	q := url.Values{}
-	if workspace := cr.reqCtx.WorkspaceLabel; workspace != "" {
-		q.Set("workspace", workspace)
-	}
-	if namespace := cr.cm.Metadata().Namespace.String(); namespace != "" {
-		q.Set("namespace", namespace)
-	}
-	qStr := q.Encode()
-	if qStr != "" {
-		loc += "?" + qStr
-	}
-	return loc
+	return objectLocation(types.CatalogObjectTypeCatalogCollection, cr.cm.FullyQualifiedName(), cr.cm.Metadata().Namespace.String(), cr.reqCtx)
 }
 
 func (cr *collectionResource) Manager() schemamanager.CollectionManager {
 	return cr.cm
 }
 
+// saveOptions returns the options to save a collection of the request with. The allowUnknown query parameter
+// lets the values have keys that are not parameters of the collection schema, which are then ignored.
+func (cr *collectionResource) saveOptions(opts ...ObjectStoreOption) []ObjectStoreOption {
+	opts = append(opts, WithWorkspaceID(cr.reqCtx.WorkspaceID))
+	if cr.reqCtx.QueryParams.Get("allowUnknown") == "true" {
+		opts = append(opts, AllowUnknownValues())
+	}
+	return opts
+}
+
 func (cr *collectionResource) Create(ctx context.Context, rsrcJson []byte) (string, apperrors.Error) {
 	m := &schemamanager.SchemaMetadata{
 		Catalog:   cr.reqCtx.Catalog,
@@ -485,7 +622,7 @@ func (cr *collectionResource) Create(ctx context.Context, rsrcJson []byte) (stri
 	if err != nil {
 		return "", err
 	}
-	err = SaveCollection(ctx, collection, WithWorkspaceID(cr.reqCtx.WorkspaceID), WithErrorIfExists())
+	err = SaveCollection(ctx, collection, cr.saveOptions(WithErrorIfExists())...)
 	if err != nil {
 		return "", err
 	}
@@ -523,11 +660,29 @@ func (cr *collectionResource) Get(ctx context.Context) ([]byte, apperrors.Error)
 	m.IDS.CatalogID = cr.reqCtx.CatalogID
 	m.IDS.VariantID = cr.reqCtx.VariantID
 
-	object, err := LoadCollectionByPath(ctx, m, WithWorkspaceID(cr.reqCtx.WorkspaceID))
+	dir, err := getDirectoriesForRead(ctx, cr.reqCtx)
 	if err != nil {
 		return nil, err
 	}
-	return object.ToJson(ctx)
+	reveal, err := revealSensitiveValues(cr.reqCtx)
+	if err != nil {
+		return nil, err
+	}
+	object, err := LoadCollectionByPath(ctx, m, WithDirectories(dir))
+	if err != nil {
+		return nil, err
+	}
+	if !reveal {
+		object.RedactSensitiveValues()
+	}
+	j, err := object.ToJson(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if j, err = addCollectionValueSources(ctx, j, m, object, dir); err != nil {
+		return nil, err
+	}
+	return addCollectionTimestamps(ctx, j, m, dir)
 }
 
 func (cr *collectionResource) Update(ctx context.Context, rsrcJson []byte) apperrors.Error {
@@ -573,7 +728,7 @@ func (cr *collectionResource) Update(ctx context.Context, rsrcJson []byte) apper
 	if err != nil {
 		return err
 	}
-	err = SaveCollection(ctx, collection, WithWorkspaceID(cr.reqCtx.WorkspaceID))
+	err = SaveCollection(ctx, collection, cr.saveOptions()...)
 	if err != nil {
 		return err
 	}
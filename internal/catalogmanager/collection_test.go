@@ -1,11 +1,16 @@
 package catalogmanager
 
 import (
+	"context"
+	"strings"
 	"testing"
 
+	"github.com/google/uuid"
 	"github.com/jackc/pgtype"
+	"github.com/mugiliam/common/apperrors"
 	"github.com/mugiliam/hatchcatalogsrv/internal/common"
 	"github.com/mugiliam/hatchcatalogsrv/internal/db"
+	"github.com/mugiliam/hatchcatalogsrv/internal/db/dberror"
 	"github.com/mugiliam/hatchcatalogsrv/internal/db/models"
 	"github.com/mugiliam/hatchcatalogsrv/pkg/types"
 	"github.com/stretchr/testify/assert"
@@ -296,9 +301,29 @@ func TestCollection(t *testing.T) {
 	require.NoError(t, err)
 	err = SaveCollection(ctx, collection, WithWorkspaceID(ws.WorkspaceID), WithErrorIfEqualToExisting())
 	require.ErrorIs(t, err, ErrEqualToExistingObject)
+	// an unchanged collection is detected before it is validated against the collection schema
+	assert.Nil(t, collection.CollectionSchemaManager())
 	err = SaveCollection(ctx, collection, WithWorkspaceID(ws.WorkspaceID), WithErrorIfExists())
 	require.ErrorIs(t, err, ErrAlreadyExists)
 
+	// the defaults of the values come from the collection schema, so once it changes an unchanged collection is
+	// validated again
+	jsonData, err = yaml.YAMLToJSON([]byte(strings.Replace(collectionYaml, "default: 1000", "default: 2000", 1)))
+	require.NoError(t, err)
+	changedSchema, err := NewSchema(ctx, jsonData, nil)
+	require.NoError(t, err)
+	require.NoError(t, SaveSchema(ctx, changedSchema, WithWorkspaceID(ws.WorkspaceID)))
+	jsonData, err = yaml.YAMLToJSON([]byte(validCollectionValueYaml))
+	require.NoError(t, err)
+	collection, err = NewCollectionManager(ctx, jsonData, nil)
+	require.NoError(t, err)
+	_ = SaveCollection(ctx, collection, WithWorkspaceID(ws.WorkspaceID))
+	assert.NotNil(t, collection.CollectionSchemaManager())
+	require.NoError(t, SaveSchema(ctx, collectionSchema, WithWorkspaceID(ws.WorkspaceID)))
+	collection, err = NewCollectionManager(ctx, jsonData, nil)
+	require.NoError(t, err)
+	require.NoError(t, SaveCollection(ctx, collection, WithWorkspaceID(ws.WorkspaceID)))
+
 	// check the collection
 	m := collection.Metadata()
 	validateMetadata(ctx, &m)
@@ -667,3 +692,324 @@ func TestCollectionWithNamespaces(t *testing.T) {
 	err = DeleteSchema(ctx, types.CatalogObjectTypeCollectionSchema, &m, dir)
 	require.NoError(t, err)
 }
+
+func TestDeleteCollectionSchemaRecursive(t *testing.T) {
+	collectionSchemaYaml := `
+		version: v1
+		kind: CollectionSchema
+		metadata:
+			name: example-collection-schema
+			catalog: example-catalog
+		spec:
+			parameters:
+				maxDelay:
+					dataType: Integer
+					default: 1000
+	`
+	collectionYaml := `
+		version: v1
+		kind: Collection
+		metadata:
+			name: my-collection
+			catalog: example-catalog
+			path: /some/random/path
+		spec:
+			schema: example-collection-schema
+	`
+	replaceTabsWithSpaces(&collectionSchemaYaml)
+	replaceTabsWithSpaces(&collectionYaml)
+
+	ctx := newDb()
+	t.Cleanup(func() {
+		db.DB(ctx).Close(ctx)
+	})
+
+	tenantID := types.TenantId("TABCDE")
+	projectID := types.ProjectId("PABCDE")
+	ctx = common.SetTenantIdInContext(ctx, tenantID)
+	ctx = common.SetProjectIdInContext(ctx, projectID)
+
+	err := db.DB(ctx).CreateTenant(ctx, tenantID)
+	assert.NoError(t, err)
+	t.Cleanup(func() {
+		_ = db.DB(ctx).DeleteTenant(ctx, tenantID)
+	})
+	err = db.DB(ctx).CreateProject(ctx, projectID)
+	assert.NoError(t, err)
+
+	cat := &models.Catalog{
+		Name:        "example-catalog",
+		Description: "An example catalog",
+		Info:        pgtype.JSONB{Status: pgtype.Null},
+		ProjectID:   projectID,
+	}
+	err = db.DB(ctx).CreateCatalog(ctx, cat)
+	assert.NoError(t, err)
+	varId, err := db.DB(ctx).GetVariantIDFromName(ctx, cat.CatalogID, types.DefaultVariant)
+	assert.NoError(t, err)
+	ws := &models.Workspace{
+		Info:        pgtype.JSONB{Status: pgtype.Null},
+		BaseVersion: 1,
+		VariantID:   varId,
+	}
+	err = db.DB(ctx).CreateWorkspace(ctx, ws)
+	assert.NoError(t, err)
+
+	jsonData, err := yaml.YAMLToJSON([]byte(collectionSchemaYaml))
+	require.NoError(t, err)
+	collectionSchema, err := NewSchema(ctx, jsonData, nil)
+	require.NoError(t, err)
+	require.NoError(t, SaveSchema(ctx, collectionSchema, WithWorkspaceID(ws.WorkspaceID)))
+
+	jsonData, err = yaml.YAMLToJSON([]byte(collectionYaml))
+	require.NoError(t, err)
+	collection, err := NewCollectionManager(ctx, jsonData, nil)
+	require.NoError(t, err)
+	require.NoError(t, SaveCollection(ctx, collection, WithWorkspaceID(ws.WorkspaceID)))
+
+	dir, err := getDirectoriesForWorkspace(ctx, ws.WorkspaceID)
+	require.NoError(t, err)
+	sm := collectionSchema.Metadata()
+	cm := collection.Metadata()
+	validateMetadata(ctx, &cm)
+
+	// the schema cannot be deleted while a collection is based on it
+	err = DeleteSchema(ctx, types.CatalogObjectTypeCollectionSchema, &sm, dir)
+	assert.ErrorIs(t, err, ErrUnableToDeleteCollectionWithReferences)
+	_, err = LoadCollectionByPath(ctx, &cm, WithWorkspaceID(ws.WorkspaceID), SkipCanonicalizePaths())
+	assert.NoError(t, err)
+
+	// a recursive delete removes the collection along with the schema
+	err = DeleteSchema(ctx, types.CatalogObjectTypeCollectionSchema, &sm, dir, WithRecursiveDelete())
+	require.NoError(t, err)
+	_, err = LoadSchemaByPath(ctx, types.CatalogObjectTypeCollectionSchema, &sm, WithWorkspaceID(ws.WorkspaceID))
+	assert.Error(t, err)
+	_, err = LoadCollectionByPath(ctx, &cm, WithWorkspaceID(ws.WorkspaceID), SkipCanonicalizePaths())
+	assert.Error(t, err)
+}
+
+func TestSaveCollectionSchemaChangedConcurrently(t *testing.T) {
+	collectionSchemaYaml := `
+		version: v1
+		kind: CollectionSchema
+		metadata:
+			name: example-collection-schema
+			catalog: example-catalog
+		spec:
+			parameters:
+				maxDelay:
+					dataType: Integer
+					default: 1000
+	`
+	collectionYaml := `
+		version: v1
+		kind: Collection
+		metadata:
+			name: my-collection
+			catalog: example-catalog
+			path: /some/path
+		spec:
+			schema: example-collection-schema
+	`
+	replaceTabsWithSpaces(&collectionSchemaYaml)
+	replaceTabsWithSpaces(&collectionYaml)
+
+	ctx := newDb()
+	t.Cleanup(func() {
+		db.DB(ctx).Close(ctx)
+	})
+
+	tenantID := types.TenantId("TABCDE")
+	projectID := types.ProjectId("PABCDE")
+	ctx = common.SetTenantIdInContext(ctx, tenantID)
+	ctx = common.SetProjectIdInContext(ctx, projectID)
+
+	err := db.DB(ctx).CreateTenant(ctx, tenantID)
+	assert.NoError(t, err)
+	t.Cleanup(func() {
+		_ = db.DB(ctx).DeleteTenant(ctx, tenantID)
+	})
+	err = db.DB(ctx).CreateProject(ctx, projectID)
+	assert.NoError(t, err)
+
+	cat := &models.Catalog{
+		Name:        "example-catalog",
+		Description: "An example catalog",
+		Info:        pgtype.JSONB{Status: pgtype.Null},
+		ProjectID:   projectID,
+	}
+	err = db.DB(ctx).CreateCatalog(ctx, cat)
+	assert.NoError(t, err)
+	varId, err := db.DB(ctx).GetVariantIDFromName(ctx, cat.CatalogID, types.DefaultVariant)
+	assert.NoError(t, err)
+	ws := &models.Workspace{
+		Info:        pgtype.JSONB{Status: pgtype.Null},
+		BaseVersion: 1,
+		VariantID:   varId,
+	}
+	err = db.DB(ctx).CreateWorkspace(ctx, ws)
+	assert.NoError(t, err)
+	dir, err := getDirectoriesForWorkspace(ctx, ws.WorkspaceID)
+	require.NoError(t, err)
+
+	collectionJson, err := yaml.YAMLToJSON([]byte(collectionYaml))
+	require.NoError(t, err)
+	saveCollection := func() apperrors.Error {
+		collection, err := NewCollectionManager(ctx, collectionJson, nil)
+		require.NoError(t, err)
+		return SaveCollection(ctx, collection, WithWorkspaceID(ws.WorkspaceID))
+	}
+
+	// a schema that never existed is invalid
+	err = saveCollection()
+	assert.ErrorIs(t, err, ErrInvalidCollectionSchema)
+
+	schemaJson, err := yaml.YAMLToJSON([]byte(collectionSchemaYaml))
+	require.NoError(t, err)
+	collectionSchema, err := NewSchema(ctx, schemaJson, nil)
+	require.NoError(t, err)
+	require.NoError(t, SaveSchema(ctx, collectionSchema, WithWorkspaceID(ws.WorkspaceID)))
+	sm := collectionSchema.Metadata()
+
+	t.Cleanup(func() {
+		checkCollectionSchema = checkCollectionSchemaUnchanged
+	})
+
+	// the schema is deleted after the values were validated against it
+	checkCollectionSchema = func(ctx context.Context, dir Directories, schemaPath, hash string) apperrors.Error {
+		require.NoError(t, DeleteSchema(ctx, types.CatalogObjectTypeCollectionSchema, &sm, dir))
+		return checkCollectionSchemaUnchanged(ctx, dir, schemaPath, hash)
+	}
+	err = saveCollection()
+	assert.ErrorIs(t, err, ErrCollectionSchemaChanged)
+	// the delete was rolled back along with the save
+	_, err = LoadSchemaByPath(ctx, types.CatalogObjectTypeCollectionSchema, &sm, WithDirectories(dir))
+	assert.NoError(t, err)
+
+	// the schema is replaced after the values were validated against it
+	b, _ := sjson.SetBytes(schemaJson, "spec.parameters.maxDelay.default", 2000)
+	changed, err := NewSchema(ctx, b, nil)
+	require.NoError(t, err)
+	checkCollectionSchema = func(ctx context.Context, dir Directories, schemaPath, hash string) apperrors.Error {
+		require.NoError(t, SaveSchema(ctx, changed, WithDirectories(dir)))
+		return checkCollectionSchemaUnchanged(ctx, dir, schemaPath, hash)
+	}
+	err = saveCollection()
+	assert.ErrorIs(t, err, ErrCollectionSchemaChanged)
+
+	// nothing was saved
+	collection, err := NewCollectionManager(ctx, collectionJson, nil)
+	require.NoError(t, err)
+	cm := collection.Metadata()
+	_, err = LoadCollectionByPath(ctx, &cm, WithWorkspaceID(ws.WorkspaceID))
+	assert.Error(t, err)
+
+	// an unchanged schema saves the collection
+	checkCollectionSchema = checkCollectionSchemaUnchanged
+	assert.NoError(t, saveCollection())
+}
+
+func TestSaveCollectionRollsBackObject(t *testing.T) {
+	collectionSchemaYaml := `
+		version: v1
+		kind: CollectionSchema
+		metadata:
+			name: example-collection-schema
+			catalog: example-catalog
+		spec:
+			parameters:
+				maxDelay:
+					dataType: Integer
+					default: 1000
+	`
+	collectionYaml := `
+		version: v1
+		kind: Collection
+		metadata:
+			name: my-collection
+			catalog: example-catalog
+			path: /some/path
+		spec:
+			schema: example-collection-schema
+			values:
+				maxDelay: 1234
+	`
+	replaceTabsWithSpaces(&collectionSchemaYaml)
+	replaceTabsWithSpaces(&collectionYaml)
+
+	ctx := newDb()
+	t.Cleanup(func() {
+		db.DB(ctx).Close(ctx)
+	})
+
+	tenantID := types.TenantId("TABCDE")
+	projectID := types.ProjectId("PABCDE")
+	ctx = common.SetTenantIdInContext(ctx, tenantID)
+	ctx = common.SetProjectIdInContext(ctx, projectID)
+
+	err := db.DB(ctx).CreateTenant(ctx, tenantID)
+	assert.NoError(t, err)
+	t.Cleanup(func() {
+		_ = db.DB(ctx).DeleteTenant(ctx, tenantID)
+	})
+	err = db.DB(ctx).CreateProject(ctx, projectID)
+	assert.NoError(t, err)
+
+	cat := &models.Catalog{
+		Name:        "example-catalog",
+		Description: "An example catalog",
+		Info:        pgtype.JSONB{Status: pgtype.Null},
+		ProjectID:   projectID,
+	}
+	err = db.DB(ctx).CreateCatalog(ctx, cat)
+	assert.NoError(t, err)
+	varId, err := db.DB(ctx).GetVariantIDFromName(ctx, cat.CatalogID, types.DefaultVariant)
+	assert.NoError(t, err)
+	ws := &models.Workspace{
+		Info:        pgtype.JSONB{Status: pgtype.Null},
+		BaseVersion: 1,
+		VariantID:   varId,
+	}
+	err = db.DB(ctx).CreateWorkspace(ctx, ws)
+	assert.NoError(t, err)
+
+	schemaJson, err := yaml.YAMLToJSON([]byte(collectionSchemaYaml))
+	require.NoError(t, err)
+	collectionSchema, err := NewSchema(ctx, schemaJson, nil)
+	require.NoError(t, err)
+	require.NoError(t, SaveSchema(ctx, collectionSchema, WithWorkspaceID(ws.WorkspaceID)))
+
+	collectionJson, err := yaml.YAMLToJSON([]byte(collectionYaml))
+	require.NoError(t, err)
+	saveCollection := func() apperrors.Error {
+		collection, err := NewCollectionManager(ctx, collectionJson, nil)
+		require.NoError(t, err)
+		return SaveCollection(ctx, collection, WithWorkspaceID(ws.WorkspaceID))
+	}
+
+	// fail the upsert after the catalog object has been written
+	injected := ErrCatalogError.Msg("injected failure")
+	var hash string
+	upsert := upsertCollection
+	t.Cleanup(func() {
+		upsertCollection = upsert
+	})
+	upsertCollection = func(ctx context.Context, c *models.Collection, dir uuid.UUID) apperrors.Error {
+		hash = c.Hash
+		return injected
+	}
+	err = saveCollection()
+	assert.ErrorIs(t, err, injected)
+	assert.False(t, db.DB(ctx).InTx())
+
+	// the object was rolled back along with the collection
+	require.NotEmpty(t, hash)
+	_, err = db.DB(ctx).GetCatalogObject(ctx, hash)
+	assert.ErrorIs(t, err, dberror.ErrNotFound)
+
+	// with the upsert restored the same save goes through
+	upsertCollection = upsert
+	require.NoError(t, saveCollection())
+	_, err = db.DB(ctx).GetCatalogObject(ctx, hash)
+	assert.NoError(t, err)
+}
@@ -0,0 +1,104 @@
+package catalogmanager
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"path"
+
+	"github.com/google/uuid"
+	"github.com/mugiliam/common/apperrors"
+	"github.com/mugiliam/hatchcatalogsrv/internal/catalogmanager/schemamanager"
+	"github.com/mugiliam/hatchcatalogsrv/internal/catalogmanager/validationerrors"
+	"github.com/mugiliam/hatchcatalogsrv/internal/db"
+	"github.com/mugiliam/hatchcatalogsrv/internal/db/dberror"
+	"github.com/mugiliam/hatchcatalogsrv/internal/db/models"
+	"github.com/mugiliam/hatchcatalogsrv/pkg/types"
+	"github.com/rs/zerolog/log"
+	"github.com/tidwall/sjson"
+)
+
+// CollectionHistory is the response of a collection history request
+type CollectionHistory struct {
+	Path    string                          `json:"path"`
+	History []models.CollectionHistoryEntry `json:"history"`
+}
+
+// GetCollectionHistory returns the ordered list of value hashes recorded for the collection identified by
+// reqCtx in the workspace, or in the variant if reqCtx has no workspace. A collection that exists but has
+// no recorded history returns an empty list.
+func GetCollectionHistory(ctx context.Context, reqCtx RequestContext) ([]byte, apperrors.Error) {
+	if reqCtx.WorkspaceID == uuid.Nil && reqCtx.VariantID == uuid.Nil {
+		return nil, ErrInvalidWorkspaceOrVariant
+	}
+	m := &schemamanager.SchemaMetadata{
+		Catalog:   reqCtx.Catalog,
+		Variant:   types.NullableStringFrom(reqCtx.Variant),
+		Namespace: types.NullableStringFrom(reqCtx.Namespace),
+		Path:      reqCtx.ObjectPath,
+		Name:      reqCtx.ObjectName,
+	}
+	if ves := m.Validate(); ves != nil {
+		return nil, validationerrors.ErrSchemaValidation.Msg(ves.Error())
+	}
+
+	var dir Directories
+	var err apperrors.Error
+	if reqCtx.WorkspaceID != uuid.Nil {
+		dir, err = getDirectoriesForWorkspace(ctx, reqCtx.WorkspaceID)
+	} else {
+		dir, err = getDirectoriesForVariant(ctx, reqCtx.VariantID)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	pathWithName := path.Clean(m.GetStoragePath(types.CatalogObjectTypeCatalogCollection) + "/" + m.Name)
+	history, err := db.ReadDB(ctx).ListCollectionHistory(ctx, pathWithName, dir.ValuesDir)
+	if err != nil {
+		return nil, ErrCatalogError.Err(err)
+	}
+	if len(history) == 0 {
+		// distinguish a collection without history from one that does not exist
+		if _, err := db.ReadDB(ctx).GetCollection(ctx, pathWithName, dir.ValuesDir); err != nil {
+			if errors.Is(err, dberror.ErrNotFound) {
+				return nil, ErrObjectNotFound
+			}
+			return nil, ErrCatalogError.Err(err)
+		}
+	}
+
+	j, jsonErr := json.Marshal(&CollectionHistory{
+		Path:    path.Clean(m.Path + "/" + m.Name),
+		History: history,
+	})
+	if jsonErr != nil {
+		return nil, ErrCatalogError.Msg("unable to marshal collection history")
+	}
+	return j, nil
+}
+
+// addCollectionTimestamps sets metadata.createdAt and metadata.updatedAt in the json of the collection
+// identified by m to the first and last entries of its history. The timestamps are not part of the stored
+// object, so they don't affect its hash, and a save that doesn't change the values isn't recorded in the
+// history, so it doesn't advance updatedAt. dir are the directories the collection was loaded from.
+func addCollectionTimestamps(ctx context.Context, j []byte, m *schemamanager.SchemaMetadata, dir Directories) ([]byte, apperrors.Error) {
+	pathWithName := path.Clean(m.GetStoragePath(types.CatalogObjectTypeCatalogCollection) + "/" + m.Name)
+	history, err := db.ReadDB(ctx).ListCollectionHistory(ctx, pathWithName, dir.ValuesDir)
+	if err != nil {
+		return nil, ErrCatalogError.Err(err)
+	}
+	if len(history) == 0 {
+		return j, nil
+	}
+
+	j, e := sjson.SetBytes(j, "metadata.createdAt", history[0].CreatedAt)
+	if e == nil {
+		j, e = sjson.SetBytes(j, "metadata.updatedAt", history[len(history)-1].CreatedAt)
+	}
+	if e != nil {
+		log.Ctx(ctx).Error().Err(e).Msg("failed to set collection timestamps")
+		return nil, ErrCatalogError.Msg("unable to load collection")
+	}
+	return j, nil
+}
@@ -0,0 +1,231 @@
+package catalogmanager
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"path"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/mugiliam/common/apperrors"
+	"github.com/mugiliam/hatchcatalogsrv/internal/catalogmanager/schema/schemavalidator"
+	"github.com/mugiliam/hatchcatalogsrv/internal/catalogmanager/schemamanager"
+	"github.com/mugiliam/hatchcatalogsrv/internal/db"
+	"github.com/mugiliam/hatchcatalogsrv/internal/db/dberror"
+	"github.com/mugiliam/hatchcatalogsrv/internal/db/models"
+	"github.com/mugiliam/hatchcatalogsrv/pkg/types"
+	"github.com/rs/zerolog/log"
+)
+
+// CollectionListEntry is a collection returned by a list of collections
+type CollectionListEntry struct {
+	Path   string `json:"path"`
+	Schema string `json:"schema"`
+}
+
+// CollectionList is the response of a request to list collections
+type CollectionList struct {
+	Namespace   string                `json:"namespace,omitempty"`
+	PathPrefix  string                `json:"pathPrefix"`
+	Limit       int                   `json:"limit,omitempty"`
+	Offset      int                   `json:"offset,omitempty"`
+	Collections []CollectionListEntry `json:"collections"`
+}
+
+// collectionLister lists the collections of a namespace under a path prefix
+type collectionLister struct {
+	namespace  string
+	pathPrefix string
+	valuesDir  uuid.UUID
+	nsRoot     string
+	// namespaces are the namespaces other than the root namespace when listing the root namespace. Their
+	// collections are stored under those of the root namespace and are left out of the list.
+	namespaces map[string]struct{}
+}
+
+// ListCollectionsByPathPrefix returns the collections in the namespace of reqCtx whose path is pathPrefix or
+// lies under it, from the workspace, or from the variant if reqCtx has no workspace. The prefix is matched on
+// path boundaries, and collections are ordered by path, so that pages of a list are stable. The first offset
+// collections are skipped, and at most limit are returned, or all of them if limit is 0.
+func ListCollectionsByPathPrefix(ctx context.Context, reqCtx RequestContext, pathPrefix string, limit, offset int) ([]byte, apperrors.Error) {
+	l, err := newCollectionLister(ctx, reqCtx, pathPrefix, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+
+	// collections of other namespaces are filtered out of the list, so it can only be paged in the database if
+	// there are none
+	dbLimit, dbOffset := limit, offset
+	if len(l.namespaces) > 0 {
+		dbLimit, dbOffset = 0, 0
+	}
+	collections, err := db.ReadDB(ctx).ListCollectionsByPathPrefix(ctx, path.Clean(l.nsRoot+l.pathPrefix), l.valuesDir, dbLimit, dbOffset)
+	if err != nil {
+		return nil, ErrCatalogError.Err(err)
+	}
+
+	list := CollectionList{
+		Namespace:   l.namespace,
+		PathPrefix:  l.pathPrefix,
+		Limit:       limit,
+		Offset:      offset,
+		Collections: make([]CollectionListEntry, 0, len(collections)),
+	}
+	for _, c := range collections {
+		if e, ok := l.entry(c); ok {
+			list.Collections = append(list.Collections, e)
+		}
+	}
+	if len(l.namespaces) > 0 {
+		list.Collections = list.Collections[min(offset, len(list.Collections)):]
+		if limit > 0 && limit < len(list.Collections) {
+			list.Collections = list.Collections[:limit]
+		}
+	}
+
+	j, jsonErr := json.Marshal(&list)
+	if jsonErr != nil {
+		return nil, ErrCatalogError.Msg("unable to marshal collections")
+	}
+	return j, nil
+}
+
+// CollectionStream lists the collections of a namespace one at a time, as they are read from the database
+type CollectionStream struct {
+	l      *collectionLister
+	limit  int
+	offset int
+}
+
+// PrepareCollectionStream checks a request to list the same collections as ListCollectionsByPathPrefix and
+// returns a stream of them. Errors in the request are returned here, before anything is streamed.
+func PrepareCollectionStream(ctx context.Context, reqCtx RequestContext, pathPrefix string, limit, offset int) (*CollectionStream, apperrors.Error) {
+	l, err := newCollectionLister(ctx, reqCtx, pathPrefix, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	return &CollectionStream{l: l, limit: limit, offset: offset}, nil
+}
+
+// Namespace is the namespace of the collections in the stream
+func (s *CollectionStream) Namespace() string {
+	return s.l.namespace
+}
+
+// Each calls fn for each collection of the stream in path order. It stops at the first error returned by fn,
+// and returns it. Collections that fn has been called for stay sent if the stream fails part way, so an error
+// can come after some of the collections.
+func (s *CollectionStream) Each(ctx context.Context, fn func(CollectionListEntry) error) apperrors.Error {
+	var fnErr error
+	skipped, sent := 0, 0
+	err := db.ReadDB(ctx).ForEachCollectionByPathPrefix(ctx, path.Clean(s.l.nsRoot+s.l.pathPrefix), s.l.valuesDir, func(c models.Collection) apperrors.Error {
+		e, ok := s.l.entry(c)
+		if !ok {
+			return nil
+		}
+		if skipped < s.offset {
+			skipped++
+			return nil
+		}
+		if fnErr = fn(e); fnErr != nil {
+			return errStopCollectionStream
+		}
+		sent++
+		if s.limit > 0 && sent == s.limit {
+			return errStopCollectionStream
+		}
+		return nil
+	})
+	if fnErr != nil {
+		if appErr, ok := fnErr.(apperrors.Error); ok {
+			return appErr
+		}
+		return ErrCatalogError.Err(fnErr)
+	}
+	if err != nil && !errors.Is(err, errStopCollectionStream) {
+		log.Ctx(ctx).Error().Err(err).Msg("failed to stream collections")
+		return ErrCatalogError.Err(err)
+	}
+	return nil
+}
+
+// errStopCollectionStream ends the rows of a collection stream early
+var errStopCollectionStream apperrors.Error = ErrCatalogError.New("collection stream stopped")
+
+func newCollectionLister(ctx context.Context, reqCtx RequestContext, pathPrefix string, limit, offset int) (*collectionLister, apperrors.Error) {
+	if reqCtx.WorkspaceID == uuid.Nil && reqCtx.VariantID == uuid.Nil {
+		return nil, ErrInvalidWorkspaceOrVariant
+	}
+	if limit < 0 || offset < 0 {
+		return nil, ErrInvalidRequest.Msg("limit and offset must not be negative")
+	}
+	pathPrefix = path.Clean("/" + pathPrefix)
+	if err := schemavalidator.V().Var(pathPrefix, "resourcePathValidator"); err != nil {
+		return nil, ErrInvalidRequest.Msg("invalid path prefix " + pathPrefix)
+	}
+	if err := schemavalidator.V().Var(reqCtx.Namespace, "omitempty,resourceNameValidator"); err != nil {
+		return nil, ErrInvalidNamespace
+	}
+	m := &schemamanager.SchemaMetadata{
+		Catalog:   reqCtx.Catalog,
+		Namespace: types.NullableStringFrom(reqCtx.Namespace),
+		Path:      "/",
+	}
+
+	var dir Directories
+	var err apperrors.Error
+	if reqCtx.WorkspaceID != uuid.Nil {
+		dir, err = getDirectoriesForWorkspace(ctx, reqCtx.WorkspaceID)
+	} else {
+		dir, err = getDirectoriesForVariant(ctx, reqCtx.VariantID)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// collections of the root namespace are stored above those of the other namespaces, so a root prefix
+	// would also match collections of other namespaces
+	namespaces := make(map[string]struct{})
+	if m.Namespace.IsNil() {
+		variantID := reqCtx.VariantID
+		if variantID == uuid.Nil {
+			variantID = dir.VariantID
+		}
+		nsList, err := db.ReadDB(ctx).ListNamespacesByVariant(ctx, variantID)
+		if err != nil && !errors.Is(err, dberror.ErrNotFound) {
+			log.Ctx(ctx).Error().Err(err).Msg("failed to list namespaces")
+			return nil, ErrCatalogError
+		}
+		for _, ns := range nsList {
+			if ns.Name != types.RootNamespace() {
+				namespaces[ns.Name] = struct{}{}
+			}
+		}
+	}
+
+	return &collectionLister{
+		namespace:  m.Namespace.String(),
+		pathPrefix: pathPrefix,
+		valuesDir:  dir.ValuesDir,
+		nsRoot:     m.GetStoragePath(types.CatalogObjectTypeCatalogCollection),
+		namespaces: namespaces,
+	}, nil
+}
+
+// entry returns the list entry of the collection c, and false if c is in another namespace
+func (l *collectionLister) entry(c models.Collection) (CollectionListEntry, bool) {
+	p := path.Clean("/" + strings.TrimPrefix(c.Path, l.nsRoot))
+	if len(l.namespaces) > 0 {
+		// a collection named after a namespace directly under the root is still in the root namespace
+		if first, _, nested := strings.Cut(strings.TrimPrefix(p, "/"), "/"); nested {
+			if _, ok := l.namespaces[first]; ok {
+				return CollectionListEntry{}, false
+			}
+		}
+	}
+	return CollectionListEntry{
+		Path:   p,
+		Schema: c.CollectionSchema,
+	}, true
+}
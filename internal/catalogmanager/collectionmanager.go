@@ -5,6 +5,8 @@ import (
 	"encoding/json"
 	"path"
 	"reflect"
+	"sort"
+	"strings"
 
 	"github.com/go-playground/validator/v10"
 	"github.com/mugiliam/common/apperrors"
@@ -136,6 +138,10 @@ func (cm *collectionManager) SetDefaultValues(param ...string) apperrors.Error {
 		} else {
 			return ErrInvalidParameter.Msg("invalid parameter: " + param[0])
 		}
+		// a default computed from other parameters is computed from their values in the collection
+		cm.setComputedDefaults(cm.csm.ComputeDefaults(cm.schema.Values, func(p string) bool {
+			return p == param[0]
+		}))
 	} else {
 		cm.schema.Values = values
 	}
@@ -200,20 +206,20 @@ func (cm *collectionManager) ValidateValues(ctx context.Context, schemaLoaders s
 	if cm.schema.Values == nil {
 		cm.schema.Values = make(schemamanager.ParamValues)
 	}
+	newValues := make(map[string]types.NullableAny)
 	for _, param := range cm.csm.ParameterNames() {
 		var currentValue schemamanager.ParamValue
 		if v, ok := currentValues[param]; ok {
 			currentValue = v
 		}
 		if v, ok := cm.schema.Spec.Values[param]; ok {
-			if currentValue.Value.Equals(v) {
+			// an explicit null is always validated, since only nullable parameters may have one
+			if !v.IsNil() && currentValue.Value.Equals(v) {
 				cm.schema.Values[param] = currentValue
 				continue
 			}
-			// if the user set any new value, we'll validate it and set it. If validation fails, we will return an error.
-			if err := cm.SetValue(ctx, schemaLoaders, param, v); err != nil {
-				return err
-			}
+			// if the user set any new value, we'll validate it and set it below
+			newValues[param] = v
 		} else if !currentValue.Value.IsNil() {
 			cm.schema.Values[param] = currentValue
 		} else {
@@ -221,9 +227,93 @@ func (cm *collectionManager) ValidateValues(ctx context.Context, schemaLoaders s
 			cm.schema.Values[param] = cm.csm.GetValue(ctx, param)
 		}
 	}
+	// all new values are validated together so that every invalid value is reported at once
+	if err := cm.csm.ValidateValues(ctx, schemaLoaders, newValues); err != nil {
+		return err
+	}
+	for param, value := range newValues {
+		v := cm.csm.GetValue(ctx, param)
+		v.Value = value
+		cm.schema.Values[param] = v
+	}
+	// whether a value is sensitive follows the schema, including for values kept from before the schema changed
+	for param, v := range cm.schema.Values {
+		v.Sensitive = cm.csm.GetValue(ctx, param).Sensitive
+		cm.schema.Values[param] = v
+	}
+
+	// defaults computed from other parameters are computed from the values in the collection, unless the
+	// collection sets a value
+	computed := cm.csm.ComputeDefaults(cm.schema.Values, func(param string) bool {
+		_, ok := cm.schema.Spec.Values[param]
+		return !ok
+	})
+	// a default that cannot be computed, because a parameter it refers to has no value, is left unset
+	toValidate := make(map[string]types.NullableAny, len(computed))
+	for param, v := range computed {
+		if !v.IsNil() {
+			toValidate[param] = v
+		}
+	}
+	if err := cm.csm.ValidateValues(ctx, schemaLoaders, toValidate); err != nil {
+		return err
+	}
+	cm.setComputedDefaults(computed)
+
+	var missing []string
+	for _, param := range cm.csm.RequiredParameters() {
+		if cm.schema.Values[param].Value.IsNil() {
+			missing = append(missing, param)
+		}
+	}
+	if len(missing) > 0 {
+		return ErrMissingRequiredValues.Msg("missing values: " + strings.Join(missing, ", "))
+	}
 	return nil
 }
 
+func (cm *collectionManager) UnknownValues() []string {
+	if cm.csm == nil {
+		return nil
+	}
+	return unknownValueKeys(cm.schema.Spec.Values, cm.csm.ParameterNames())
+}
+
+// SensitiveParameters returns the sorted names of the parameters whose values are sensitive
+func (cm *collectionManager) SensitiveParameters() []string {
+	var params []string
+	for param, v := range cm.schema.Values {
+		if v.Sensitive {
+			params = append(params, param)
+		}
+	}
+	sort.Strings(params)
+	return params
+}
+
+// RedactSensitiveValues replaces the values of sensitive parameters with RedactedValue. The collection must not
+// be saved afterwards.
+func (cm *collectionManager) RedactSensitiveValues() {
+	redacted, _ := types.NullableAnyFrom(RedactedValue)
+	for _, param := range cm.SensitiveParameters() {
+		if v := cm.schema.Values[param]; !v.Value.IsNil() {
+			v.Value = redacted
+			cm.schema.Values[param] = v
+		}
+		if v, ok := cm.schema.Spec.Values[param]; ok && !v.IsNil() {
+			cm.schema.Spec.Values[param] = redacted
+		}
+	}
+}
+
+func (cm *collectionManager) setComputedDefaults(computed map[string]types.NullableAny) {
+	for param, v := range computed {
+		pv := cm.schema.Values[param]
+		pv.Value = v
+		cm.schema.Values[param] = pv
+	}
+}
+
 func (cm *collectionManager) ToJson(ctx context.Context) ([]byte, apperrors.Error) {
 	j, err := json.Marshal(cm.schema)
 	if err != nil {
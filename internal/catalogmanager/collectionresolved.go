@@ -0,0 +1,165 @@
+package catalogmanager
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"path"
+
+	"github.com/google/uuid"
+	"github.com/mugiliam/common/apperrors"
+	"github.com/mugiliam/hatchcatalogsrv/internal/catalogmanager/schemamanager"
+	"github.com/mugiliam/hatchcatalogsrv/internal/catalogmanager/validationerrors"
+	"github.com/mugiliam/hatchcatalogsrv/internal/db"
+	"github.com/mugiliam/hatchcatalogsrv/internal/db/dberror"
+	"github.com/mugiliam/hatchcatalogsrv/pkg/types"
+	"github.com/rs/zerolog/log"
+	"github.com/tidwall/sjson"
+)
+
+// ResolvedCollection is the effective state of a collection: the value every parameter of its schema resolves
+// to, with the data type of the parameter and the layer the value was resolved from. A parameter with neither
+// a value nor a default resolves to null and has no source.
+type ResolvedCollection struct {
+	Path      string                 `json:"path"`
+	Namespace string                 `json:"namespace,omitempty"`
+	Schema    string                 `json:"schema"`
+	Values    valueSpec              `json:"values"`
+	DataTypes map[string]string      `json:"dataTypes"`
+	Sources   map[string]ValueSource `json:"sources"`
+}
+
+// GetResolvedCollection returns the resolved values of the collection identified by reqCtx in the workspace,
+// or in the variant if reqCtx has no workspace. Values are resolved as they are for GetValue, falling back from
+// the value set on the collection to the default of the collection schema and then of the parameter schema.
+// Values the collection schema takes from the values it extends take the place of its defaults.
+// Sensitive values are redacted unless reqCtx asks to reveal them.
+func GetResolvedCollection(ctx context.Context, reqCtx RequestContext) ([]byte, apperrors.Error) {
+	if reqCtx.WorkspaceID == uuid.Nil && reqCtx.VariantID == uuid.Nil {
+		return nil, ErrInvalidWorkspaceOrVariant
+	}
+	m := &schemamanager.SchemaMetadata{
+		Catalog:   reqCtx.Catalog,
+		Variant:   types.NullableStringFrom(reqCtx.Variant),
+		Namespace: types.NullableStringFrom(reqCtx.Namespace),
+		Path:      reqCtx.ObjectPath,
+		Name:      reqCtx.ObjectName,
+	}
+	if ves := m.Validate(); ves != nil {
+		return nil, validationerrors.ErrSchemaValidation.Msg(ves.Error())
+	}
+	m.IDS.CatalogID = reqCtx.CatalogID
+	m.IDS.VariantID = reqCtx.VariantID
+
+	var dir Directories
+	var err apperrors.Error
+	if reqCtx.WorkspaceID != uuid.Nil {
+		dir, err = getDirectoriesForWorkspace(ctx, reqCtx.WorkspaceID)
+	} else {
+		dir, err = getDirectoriesForVariant(ctx, reqCtx.VariantID)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	reveal, err := revealSensitiveValues(reqCtx)
+	if err != nil {
+		return nil, err
+	}
+	cm, err := LoadCollectionByPath(ctx, m, WithDirectories(dir))
+	if err != nil {
+		if errors.Is(err, dberror.ErrNotFound) {
+			return nil, ErrObjectNotFound
+		}
+		return nil, err
+	}
+	if !reveal {
+		cm.RedactSensitiveValues()
+	}
+	om, err := loadSchemaOfCollection(ctx, m, cm, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	values, sources, err := resolveValues(ctx, om, cm.Values(), dir)
+	if err != nil {
+		return nil, err
+	}
+	values, sources, err = mergeBaseValues(ctx, om, om.Metadata().ValuesExtends, values, sources, dir, nil)
+	if err != nil {
+		return nil, err
+	}
+	schemaValues := om.CollectionSchemaManager().GetDefaultValues()
+	dataTypes := make(map[string]string, len(values))
+	for param := range values {
+		dataTypes[param] = schemaValues[param].DataType.Type
+	}
+
+	j, jsonErr := json.Marshal(&ResolvedCollection{
+		Path:      path.Clean(m.Path + "/" + m.Name),
+		Namespace: m.Namespace.String(),
+		Schema:    cm.Schema(),
+		Values:    values,
+		DataTypes: dataTypes,
+		Sources:   sources,
+	})
+	if jsonErr != nil {
+		log.Ctx(ctx).Error().Err(jsonErr).Msg("failed to marshal resolved collection")
+		return nil, ErrCatalogError.Msg("unable to marshal resolved collection")
+	}
+	return j, nil
+}
+
+// loadSchemaOfCollection loads the collection schema of the collection cm with metadata m
+func loadSchemaOfCollection(ctx context.Context, m *schemamanager.SchemaMetadata, cm schemamanager.CollectionManager, dir Directories) (schemamanager.SchemaManager, apperrors.Error) {
+	schemaPath, schemaHash, _, err := setCollectionSchemaManager(ctx, cm, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	// the schema is in the namespace of the collection unless it was found in the root namespace
+	sm := &schemamanager.SchemaMetadata{
+		Catalog: m.Catalog,
+		Variant: m.Variant,
+		Name:    cm.Schema(),
+		IDS:     m.IDS,
+	}
+	if schemaPath == path.Clean(m.GetStoragePath(types.CatalogObjectTypeCollectionSchema)+"/"+cm.Schema()) {
+		sm.Namespace = m.Namespace
+	}
+	// the values the schema extends are kept with its directory entry
+	if ref, dbErr := db.ReadDB(ctx).GetObjectRefByPath(ctx, types.CatalogObjectTypeCollectionSchema, dir.CollectionsDir, schemaPath); dbErr == nil {
+		sm.ValuesExtends = ref.ValuesExtends
+	}
+	om, err := LoadSchemaByHash(ctx, schemaHash, sm, WithDirectories(dir))
+	if err != nil {
+		if errors.Is(err, ErrObjectNotFound) {
+			return nil, ErrCollectionSchemaChanged.Msg("collection schema " + schemaPath + " was modified")
+		}
+		return nil, err
+	}
+	return om, nil
+}
+
+// addCollectionValueSources sets metadata.valueSources in the collection json j to the layer that each value of
+// the collection cm with metadata m is resolved from, as GetResolvedCollection does. Like the timestamps it is
+// kept in the metadata, which is ignored when the collection is applied again.
+func addCollectionValueSources(ctx context.Context, j []byte, m *schemamanager.SchemaMetadata, cm schemamanager.CollectionManager, dir Directories) ([]byte, apperrors.Error) {
+	om, err := loadSchemaOfCollection(ctx, m, cm, dir)
+	if err != nil {
+		return nil, err
+	}
+	values, sources, err := resolveValues(ctx, om, cm.Values(), dir)
+	if err != nil {
+		return nil, err
+	}
+	if _, sources, err = mergeBaseValues(ctx, om, om.Metadata().ValuesExtends, values, sources, dir, nil); err != nil {
+		return nil, err
+	}
+	j, e := sjson.SetBytes(j, "metadata.valueSources", sources)
+	if e != nil {
+		log.Ctx(ctx).Error().Err(e).Msg("failed to set collection value sources")
+		return nil, ErrCatalogError.Msg("unable to load collection")
+	}
+	return j, nil
+}
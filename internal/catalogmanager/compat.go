@@ -0,0 +1,174 @@
+package catalogmanager
+
+import (
+	"context"
+	"errors"
+	"path"
+	"sort"
+
+	"github.com/google/uuid"
+	"github.com/mugiliam/common/apperrors"
+	"github.com/mugiliam/hatchcatalogsrv/internal/catalogmanager/schemamanager"
+	"github.com/mugiliam/hatchcatalogsrv/internal/db"
+	"github.com/mugiliam/hatchcatalogsrv/internal/db/dberror"
+	"github.com/mugiliam/hatchcatalogsrv/pkg/types"
+	"github.com/rs/zerolog/log"
+)
+
+// CompatViolation is a collection schema whose default, or a collection whose value, does not satisfy a
+// candidate parameter schema. Paths are storage paths, which start with the namespace.
+type CompatViolation struct {
+	Kind      string `json:"kind"`
+	Path      string `json:"path"`
+	Parameter string `json:"parameter,omitempty"`
+	Error     string `json:"error"`
+}
+
+// CompatReport lists what a candidate parameter schema would break. The change is safe if Incompatible is empty.
+type CompatReport struct {
+	Schema       string            `json:"schema"`
+	Incompatible []CompatViolation `json:"incompatible"`
+}
+
+// CheckParameterSchemaCompat reports the collection schemas and collections that refer to the parameter schema
+// named name and would become invalid if it were replaced by the candidate in rsrcJson. The defaults of the
+// collection schemas are revalidated as SaveSchema does, and the values of their collections are validated
+// against the candidate. Nothing is saved.
+func CheckParameterSchemaCompat(ctx context.Context, reqCtx RequestContext, name string, rsrcJson []byte) (*CompatReport, apperrors.Error) {
+	if reqCtx.Catalog == "" || reqCtx.CatalogID == uuid.Nil {
+		return nil, ErrInvalidCatalog
+	}
+	if reqCtx.Variant == "" || reqCtx.VariantID == uuid.Nil {
+		return nil, ErrInvalidVariant
+	}
+
+	m := &schemamanager.SchemaMetadata{
+		Catalog:   reqCtx.Catalog,
+		Variant:   types.NullableStringFrom(reqCtx.Variant),
+		Namespace: types.NullableStringFrom(reqCtx.Namespace),
+	}
+	om, err := NewSchema(ctx, rsrcJson, m)
+	if err != nil {
+		return nil, err
+	}
+	pm := om.ParameterSchemaManager()
+	if om.Type() != types.CatalogObjectTypeParameterSchema || pm == nil {
+		return nil, ErrInvalidSchema.Msg("candidate must be a parameter schema")
+	}
+	md := om.Metadata()
+	if md.Name != name {
+		return nil, ErrInvalidSchema.Msg("candidate is named " + md.Name + ", not " + name)
+	}
+	md.IDS.CatalogID = reqCtx.CatalogID
+	md.IDS.VariantID = reqCtx.VariantID
+
+	var dir Directories
+	if reqCtx.WorkspaceID != uuid.Nil {
+		dir, err = getDirectoriesForWorkspace(ctx, reqCtx.WorkspaceID)
+	} else {
+		dir, err = getDirectoriesForVariant(ctx, reqCtx.VariantID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	// a candidate that extends another parameter schema is checked with the base merged in, as it is saved
+	if err := resolveParameterSchemaExtends(ctx, om, dir); err != nil {
+		return nil, err
+	}
+
+	pathWithName := path.Clean(md.GetStoragePath(types.CatalogObjectTypeParameterSchema) + "/" + name)
+	r, dbErr := db.ReadDB(ctx).GetObjectRefByPath(ctx, types.CatalogObjectTypeParameterSchema, dir.ParametersDir, pathWithName)
+	if dbErr != nil {
+		if errors.Is(dbErr, dberror.ErrNotFound) {
+			return nil, ErrObjectNotFound.Msg("parameter schema " + pathWithName + " not found")
+		}
+		log.Ctx(ctx).Error().Err(dbErr).Str("path", pathWithName).Msg("failed to get parameter schema")
+		return nil, ErrCatalogError.Err(dbErr)
+	}
+
+	report := &CompatReport{
+		Schema:       pathWithName,
+		Incompatible: []CompatViolation{},
+	}
+	if len(r.References) == 0 {
+		return report, nil
+	}
+
+	schemas, err := loadDirectory(ctx, types.CatalogObjectTypeCollectionSchema, dir.CollectionsDir)
+	if err != nil {
+		return nil, err
+	}
+	collections, err := loadDirectory(ctx, types.CatalogObjectTypeCatalogCollection, dir.ValuesDir)
+	if err != nil {
+		return nil, err
+	}
+
+	// the references carry the storage paths of the collection schemas, so they are loaded by the exact path
+	loaders := getSchemaLoaders(ctx, md, WithDirectories(dir), SkipCanonicalizePaths())
+	for _, ref := range r.References {
+		if err := pm.ValidateDependencies(ctx, loaders, schemamanager.SchemaReferences{{Name: ref.Name}}); err != nil {
+			report.Incompatible = append(report.Incompatible, CompatViolation{
+				Kind:  types.CollectionSchemaKind,
+				Path:  ref.Name,
+				Error: err.Error(),
+			})
+		}
+
+		schemaRef, ok := schemas[ref.Name]
+		if !ok {
+			continue
+		}
+		sm, err := LoadSchemaByHash(ctx, schemaRef.Hash, &md)
+		if err != nil {
+			return nil, err
+		}
+		csm := sm.CollectionSchemaManager()
+		if csm == nil {
+			continue
+		}
+		var params []string
+		for _, p := range csm.ParametersWithSchema(name) {
+			params = append(params, p.Name)
+		}
+		sort.Strings(params)
+
+		var paths []string
+		for p, c := range collections {
+			if c.BaseSchema == ref.Name {
+				paths = append(paths, p)
+			}
+		}
+		sort.Strings(paths)
+		for _, p := range paths {
+			obj, dbErr := db.ReadDB(ctx).GetCatalogObject(ctx, collections[p].Hash)
+			if dbErr != nil {
+				log.Ctx(ctx).Error().Err(dbErr).Str("path", p).Msg("failed to load collection")
+				return nil, ErrCatalogError.Err(dbErr)
+			}
+			cm, err := collectionManagerFromObject(ctx, obj, &md)
+			if err != nil {
+				return nil, err
+			}
+			for _, param := range params {
+				v := cm.Values()[param].Value
+				if v.IsNil() {
+					continue
+				}
+				if err := pm.ValidateValue(v); err != nil {
+					msg := err.Error()
+					if cm.Values()[param].Sensitive {
+						// the error may quote the value
+						msg = "sensitive value is not valid"
+					}
+					report.Incompatible = append(report.Incompatible, CompatViolation{
+						Kind:      types.CollectionKind,
+						Path:      p,
+						Parameter: param,
+						Error:     msg,
+					})
+				}
+			}
+		}
+	}
+	return report, nil
+}
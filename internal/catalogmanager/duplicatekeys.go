@@ -0,0 +1,56 @@
+package catalogmanager
+
+import (
+	"bytes"
+	"encoding/json"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/mugiliam/common/apperrors"
+	"github.com/mugiliam/hatchcatalogsrv/internal/catalogmanager/validationerrors"
+	"github.com/tidwall/gjson"
+)
+
+// checkDuplicateKeys returns a validation error if the object at any of the given paths in rsrcJson repeats a
+// key. Decoding keeps only the last of the repeated keys, so without this check a duplicated parameter would
+// silently replace the first.
+func checkDuplicateKeys(rsrcJson []byte, paths ...string) apperrors.Error {
+	for _, p := range paths {
+		obj := gjson.GetBytes(rsrcJson, p)
+		if !obj.IsObject() {
+			continue
+		}
+		if dups := duplicateKeys([]byte(obj.Raw)); len(dups) > 0 {
+			return validationerrors.ErrSchemaValidation.Msg("duplicate keys in " + p + ": " + strings.Join(dups, ", "))
+		}
+	}
+	return nil
+}
+
+// duplicateKeys returns the sorted keys that appear more than once at the top level of the JSON object obj
+func duplicateKeys(obj []byte) []string {
+	d := json.NewDecoder(bytes.NewReader(obj))
+	if t, err := d.Token(); err != nil || t != json.Delim('{') {
+		return nil
+	}
+	seen := make(map[string]bool)
+	var dups []string
+	for d.More() {
+		t, err := d.Token()
+		if err != nil {
+			return dups
+		}
+		key, _ := t.(string)
+		if seen[key] && !slices.Contains(dups, key) {
+			dups = append(dups, key)
+		}
+		seen[key] = true
+		var skip json.RawMessage
+		if err := d.Decode(&skip); err != nil {
+			return dups
+		}
+	}
+	sort.Strings(dups)
+	return dups
+}
@@ -9,13 +9,16 @@ import (
 var (
 	ErrCatalogError                           apperrors.Error = apperrors.New("error in processing catalog").SetStatusCode(http.StatusInternalServerError)
 	ErrCatalogNotFound                        apperrors.Error = ErrCatalogError.New("catalog not found").SetExpandError(true).SetStatusCode(http.StatusNotFound)
+	ErrProjectNotFound                        apperrors.Error = ErrCatalogError.New("project not found").SetExpandError(true).SetStatusCode(http.StatusNotFound)
 	ErrObjectNotFound                         apperrors.Error = ErrCatalogError.New("object not found").SetStatusCode(http.StatusNotFound)
 	ErrParentCollectionSchemaNotFound         apperrors.Error = ErrCatalogError.New("collection schema not found").SetStatusCode(http.StatusNotFound)
+	ErrCollectionSchemaNotFound               apperrors.Error = ErrCatalogError.New("collection schema not found").SetExpandError(true).SetStatusCode(http.StatusNotFound)
 	ErrUnableToLoadObject                     apperrors.Error = ErrCatalogError.New("unable to load object").SetStatusCode(http.StatusInternalServerError)
 	ErrUnableToUpdateObject                   apperrors.Error = ErrCatalogError.New("unable to update object").SetExpandError(true).SetStatusCode(http.StatusInternalServerError)
 	ErrUnableToDeleteObject                   apperrors.Error = ErrCatalogError.New("unable to delete object").SetStatusCode(http.StatusInternalServerError)
 	ErrAlreadyExists                          apperrors.Error = ErrCatalogError.New("object already exists").SetStatusCode(http.StatusConflict)
 	ErrEqualToExistingObject                  apperrors.Error = ErrCatalogError.New("no change to existing object").SetStatusCode(http.StatusConflict)
+	ErrConcurrentModification                 apperrors.Error = ErrCatalogError.New("object was modified concurrently").SetStatusCode(http.StatusPreconditionFailed)
 	ErrInvalidSchema                          apperrors.Error = ErrCatalogError.New("invalid schema").SetExpandError(true).SetStatusCode(http.StatusBadRequest)
 	ErrEmptyMetadata                          apperrors.Error = ErrCatalogError.New("empty metadata").SetStatusCode(http.StatusBadRequest)
 	ErrInvalidProject                         apperrors.Error = ErrCatalogError.New("invalid project").SetStatusCode(http.StatusBadRequest)
@@ -29,16 +32,43 @@ var (
 	ErrVariantNotFound                        apperrors.Error = ErrCatalogError.New("variant not found").SetStatusCode(http.StatusNotFound)
 	ErrNamespaceNotFound                      apperrors.Error = ErrCatalogError.New("namespace not found").SetStatusCode(http.StatusNotFound)
 	ErrWorkspaceNotFound                      apperrors.Error = ErrCatalogError.New("workspace not found").SetStatusCode(http.StatusNotFound)
+	ErrVersionNotFound                        apperrors.Error = ErrCatalogError.New("version not found").SetStatusCode(http.StatusNotFound)
+	ErrInvalidVersionLabel                    apperrors.Error = ErrInvalidVersion.New("invalid version label").SetStatusCode(http.StatusBadRequest)
+	ErrVersionLabelExists                     apperrors.Error = ErrCatalogError.New("version label is already in use").SetStatusCode(http.StatusConflict)
 	ErrInvalidVersionOrWorkspace              apperrors.Error = ErrCatalogError.New("invalid version or workspace").SetStatusCode(http.StatusBadRequest)
 	ErrInvalidCollectionSchema                apperrors.Error = ErrCatalogError.New("invalid collection schema").SetStatusCode(http.StatusBadRequest)
+	ErrCollectionSchemaChanged                apperrors.Error = ErrCatalogError.New("collection schema changed while saving the collection").SetStatusCode(http.StatusConflict)
 	ErrInvalidCollection                      apperrors.Error = ErrCatalogError.New("invalid collection").SetStatusCode(http.StatusBadRequest)
+	ErrInvalidCollectionValues                apperrors.Error = ErrInvalidCollection.New("one or more values failed validation").SetExpandError(true).SetStatusCode(http.StatusBadRequest)
 	ErrSchemaOfCollectionNotMutable           apperrors.Error = ErrCatalogError.New("schema of a collection cannot be modified").SetStatusCode(http.StatusBadRequest)
 	ErrInvalidUUID                            apperrors.Error = ErrCatalogError.New("invalid uuid")
 	ErrNoAncestorReferencesFound              apperrors.Error = ErrUnableToDeleteObject.New("no ancestor references found").SetStatusCode(http.StatusConflict)
 	ErrUnableToDeleteParameterWithReferences  apperrors.Error = ErrUnableToDeleteObject.New("parameter has existing references in collections").SetStatusCode(http.StatusConflict)
 	ErrUnableToDeleteCollectionWithReferences apperrors.Error = ErrUnableToDeleteObject.New("collection has existing references in collections").SetStatusCode(http.StatusConflict)
+	ErrUnableToDeleteCollectionWithChildren   apperrors.Error = ErrUnableToDeleteObject.New("collection schema has child collection schemas").SetExpandError(true).SetStatusCode(http.StatusConflict)
+	ErrUnableToDeleteDefaultVariant           apperrors.Error = ErrUnableToDeleteObject.New("variant is the default variant of the catalog").SetStatusCode(http.StatusConflict)
+	ErrUnableToMoveObject                     apperrors.Error = ErrCatalogError.New("unable to move object").SetExpandError(true).SetStatusCode(http.StatusConflict)
+	ErrUnableToMoveCollectionWithReferences   apperrors.Error = ErrUnableToMoveObject.New("collection schema has existing collections").SetStatusCode(http.StatusConflict)
 	ErrInvalidParameter                       apperrors.Error = ErrCatalogError.New("invalid parameter").SetStatusCode(http.StatusBadRequest)
+	ErrMissingRequiredValues                  apperrors.Error = ErrInvalidParameter.New("collection has no value for required parameters").SetExpandError(true).SetStatusCode(http.StatusBadRequest)
+	ErrUnknownValueKeys                       apperrors.Error = ErrInvalidParameter.New("value has keys that are not parameters of the collection schema").SetExpandError(true).SetStatusCode(http.StatusBadRequest)
 	ErrUnableToSaveSchema                     apperrors.Error = ErrCatalogError.New("unable to save schema").SetStatusCode(http.StatusInternalServerError)
 	ErrSchemaConflict                         apperrors.Error = ErrCatalogError.New("schema conflicts with existing schema").SetStatusCode(http.StatusConflict) // when trying to save a schema that conflicts with an existing one
+	ErrInvalidDefaultInReference              apperrors.Error = ErrSchemaConflict.New("default value in referencing collection schema violates parameter schema").SetStatusCode(http.StatusBadRequest)
+	ErrInvalidExtends                         apperrors.Error = ErrInvalidSchema.New("unable to extend parameter schema").SetExpandError(true).SetStatusCode(http.StatusBadRequest)
+	ErrCyclicExtends                          apperrors.Error = ErrInvalidExtends.New("parameter schemas extend each other in a cycle").SetExpandError(true).SetStatusCode(http.StatusBadRequest)
+	ErrInvalidValueExtends                    apperrors.Error = ErrInvalidSchema.New("unable to extend value").SetExpandError(true).SetStatusCode(http.StatusBadRequest)
+	ErrCyclicValueExtends                     apperrors.Error = ErrInvalidValueExtends.New("values extend each other in a cycle").SetExpandError(true).SetStatusCode(http.StatusBadRequest)
 	ErrInvalidRequest                         apperrors.Error = ErrCatalogError.New("invalid request").SetStatusCode(http.StatusBadRequest)
+	ErrUnableToExport                         apperrors.Error = ErrCatalogError.New("unable to export catalog").SetStatusCode(http.StatusInternalServerError)
+	ErrImportTooLarge                         apperrors.Error = ErrInvalidRequest.New("import archive is too large").SetStatusCode(http.StatusRequestEntityTooLarge)
+	ErrContextMismatch                        apperrors.Error = ErrInvalidRequest.New("request body does not match the catalog or variant of the request").SetStatusCode(http.StatusBadRequest)
+	ErrIdempotencyKeyReused                   apperrors.Error = ErrInvalidRequest.New("idempotency key was used with a different request").SetStatusCode(http.StatusUnprocessableEntity)
+	ErrIdempotencyKeyInProgress               apperrors.Error = ErrCatalogError.New("a request with the same idempotency key is in progress").SetStatusCode(http.StatusConflict)
+	ErrQuotaExceeded                          apperrors.Error = ErrCatalogError.New("quota exceeded").SetStatusCode(http.StatusForbidden)
+	ErrDirectWritesDisabled                   apperrors.Error = ErrCatalogError.New("direct writes to a variant are not enabled").SetStatusCode(http.StatusForbidden)
+	ErrInvalidJSONPatch                       apperrors.Error = ErrInvalidRequest.New("invalid json patch").SetStatusCode(http.StatusBadRequest)
+	ErrJSONPatchConflict                      apperrors.Error = ErrCatalogError.New("unable to apply json patch").SetStatusCode(http.StatusConflict)
+	ErrSensitiveValue                         apperrors.Error = ErrCatalogError.New("unable to encrypt or decrypt sensitive value").SetStatusCode(http.StatusInternalServerError)
+	ErrRevealNotAllowed                       apperrors.Error = ErrCatalogError.New("revealing sensitive values is not enabled").SetStatusCode(http.StatusForbidden)
 )
@@ -0,0 +1,132 @@
+package catalogmanager
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/mugiliam/common/apperrors"
+	"github.com/mugiliam/hatchcatalogsrv/internal/catalogmanager/schemamanager"
+	"github.com/mugiliam/hatchcatalogsrv/internal/db"
+	"github.com/mugiliam/hatchcatalogsrv/internal/db/dberror"
+	"github.com/mugiliam/hatchcatalogsrv/internal/db/models"
+	"github.com/mugiliam/hatchcatalogsrv/pkg/types"
+)
+
+// ObjectETag returns the ETag of the parameter schema, collection schema or collection named by reqCtx, without
+// loading the object. It is derived from the hash of the stored object and the metadata kept in its directory
+// entry, so it changes whenever a GET of the object would return a different object. The tag is weak since the
+// same object may be served in more than one format.
+func ObjectETag(ctx context.Context, kind string, reqCtx RequestContext) (string, apperrors.Error) {
+	t := types.CatalogObjectTypeFromKind(kind)
+	switch t {
+	case types.CatalogObjectTypeParameterSchema, types.CatalogObjectTypeCollectionSchema, types.CatalogObjectTypeCatalogCollection:
+	default:
+		return "", ErrInvalidRequest.Msg("objects of kind " + kind + " do not have an ETag")
+	}
+	if reqCtx.WorkspaceID == uuid.Nil && reqCtx.VariantID == uuid.Nil {
+		return "", ErrInvalidWorkspaceOrVariant
+	}
+	m := schemamanager.SchemaMetadata{
+		Catalog:   reqCtx.Catalog,
+		Variant:   types.NullableStringFrom(reqCtx.Variant),
+		Namespace: types.NullableStringFrom(reqCtx.Namespace),
+		Path:      reqCtx.ObjectPath,
+		Name:      reqCtx.ObjectName,
+	}
+
+	dir, err := getDirectoriesForRead(ctx, reqCtx)
+	if err != nil {
+		return "", err
+	}
+	ref, err := db.ReadDB(ctx).GetObjectRefByPath(ctx, t, dir.DirForType(t), path.Clean(m.GetStoragePath(t)+"/"+m.Name))
+	if err != nil {
+		if errors.Is(err, dberror.ErrNotFound) {
+			return "", ErrObjectNotFound
+		}
+		return "", ErrCatalogError.Err(err)
+	}
+	if t != types.CatalogObjectTypeCatalogCollection {
+		return objectRefETag(ref), nil
+	}
+	deps, err := collectionDependencyHashes(ctx, dir, ref)
+	if err != nil {
+		return "", err
+	}
+	return objectRefETag(ref, deps...), nil
+}
+
+// collectionDependencyHashes returns the hashes of the collection schema of the collection in the directory entry
+// ref and of the parameter schemas the collection schema refers to. Where the values of a collection come from
+// depends on their defaults.
+func collectionDependencyHashes(ctx context.Context, dir Directories, ref *models.ObjectRef) ([]string, apperrors.Error) {
+	if ref.BaseSchema == "" {
+		return nil, nil
+	}
+	schemaRef, err := db.ReadDB(ctx).GetObjectRefByPath(ctx, types.CatalogObjectTypeCollectionSchema, dir.CollectionsDir, ref.BaseSchema)
+	if err != nil {
+		if errors.Is(err, dberror.ErrNotFound) {
+			return nil, nil
+		}
+		return nil, ErrCatalogError.Err(err)
+	}
+	params := make([]string, 0, len(schemaRef.References))
+	for _, r := range schemaRef.References {
+		params = append(params, r.Name)
+	}
+	hashes, err := parameterSchemaHashes(ctx, dir, params)
+	if err != nil {
+		return nil, err
+	}
+	return append([]string{schemaRef.Hash}, hashes...), nil
+}
+
+// parameterSchemaHashes returns the hashes of the parameter schemas at the paths params, in the same order.
+// The hash of a parameter schema that does not exist is "".
+func parameterSchemaHashes(ctx context.Context, dir Directories, params []string) ([]string, apperrors.Error) {
+	hashes := make([]string, len(params))
+	for i, p := range params {
+		paramRef, err := db.ReadDB(ctx).GetObjectRefByPath(ctx, types.CatalogObjectTypeParameterSchema, dir.ParametersDir, p)
+		if err != nil {
+			if errors.Is(err, dberror.ErrNotFound) {
+				continue
+			}
+			return nil, ErrCatalogError.Err(err)
+		}
+		hashes[i] = paramRef.Hash
+	}
+	return hashes, nil
+}
+
+// objectRefETag returns the ETag of the object in the directory entry ref, which also depends on the objects
+// with the hashes deps
+func objectRefETag(ref *models.ObjectRef, deps ...string) string {
+	tag := ref.Hash
+	if ref.Description != "" || ref.Deprecated || ref.DeprecationMessage != "" || len(ref.Units) > 0 || ref.ValuesExtends != "" {
+		meta := ref.Description + "\x00" + strconv.FormatBool(ref.Deprecated) + "\x00" + ref.DeprecationMessage
+		params := make([]string, 0, len(ref.Units))
+		for param := range ref.Units {
+			params = append(params, param)
+		}
+		sort.Strings(params)
+		for _, param := range params {
+			meta += "\x00" + param + "=" + ref.Units[param]
+		}
+		if ref.ValuesExtends != "" {
+			meta += "\x00\x00" + ref.ValuesExtends
+		}
+		h := sha256.Sum256([]byte(meta))
+		tag += "-" + hex.EncodeToString(h[:8])
+	}
+	if len(deps) > 0 {
+		h := sha256.Sum256([]byte(strings.Join(deps, "\x00")))
+		tag += "-" + hex.EncodeToString(h[:8])
+	}
+	return `W/"` + tag + `"`
+}
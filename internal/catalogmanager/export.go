@@ -0,0 +1,252 @@
+package catalogmanager
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"io"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mugiliam/common/apperrors"
+	"github.com/mugiliam/hatchcatalogsrv/internal/catalogmanager/schemamanager"
+	"github.com/mugiliam/hatchcatalogsrv/internal/db"
+	"github.com/mugiliam/hatchcatalogsrv/internal/db/models"
+	"github.com/mugiliam/hatchcatalogsrv/pkg/api/schemastore"
+	"github.com/mugiliam/hatchcatalogsrv/pkg/types"
+	"github.com/rs/zerolog/log"
+	"github.com/tidwall/sjson"
+	"sigs.k8s.io/yaml"
+)
+
+// number of catalog objects loaded from the database in a single round-trip during export
+const exportBatchSize = 100
+
+// objects are exported in dependency order so that an archive can be re-applied from start to end
+var exportObjectTypes = []types.CatalogObjectType{
+	types.CatalogObjectTypeParameterSchema,
+	types.CatalogObjectTypeCollectionSchema,
+	types.CatalogObjectTypeCatalogCollection,
+}
+
+// ExportTrailerName is the name of the last entry of an export archive. It records the number of
+// manifests in the archive, so a client can tell a complete archive from one that was cut short by
+// a failure after the response was sent. Import skips it as it isn't a manifest.
+const ExportTrailerName = ".export"
+
+// ExportTrailer is the content of the trailer entry of an export archive
+type ExportTrailer struct {
+	Objects int `json:"objects"`
+}
+
+type exportEntry struct {
+	objectType types.CatalogObjectType
+	path       string
+	hash       string
+}
+
+// VariantExport is the list of objects to export from a variant or workspace, as prepared by PrepareExport
+type VariantExport struct {
+	catalog    string
+	variant    string
+	namespaces map[string]struct{}
+	entries    []exportEntry
+}
+
+// PrepareExport lists every parameter schema, collection schema and collection in the variant, or in the
+// workspace if reqCtx carries one, in the order they are exported. The list is built before anything is
+// written, so failures to look up the directories can still be reported to the client.
+func PrepareExport(ctx context.Context, reqCtx RequestContext) (*VariantExport, apperrors.Error) {
+	var dir Directories
+	var err apperrors.Error
+	if reqCtx.WorkspaceID != uuid.Nil {
+		dir, err = getDirectoriesForWorkspace(ctx, reqCtx.WorkspaceID)
+	} else {
+		dir, err = getDirectoriesForVariant(ctx, reqCtx.VariantID)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	e := &VariantExport{
+		catalog:    reqCtx.Catalog,
+		variant:    reqCtx.Variant,
+		namespaces: make(map[string]struct{}),
+	}
+	nsList, err := db.ReadDB(ctx).ListNamespacesByVariant(ctx, reqCtx.VariantID)
+	if err != nil {
+		return nil, ErrUnableToExport.Err(err)
+	}
+	for _, ns := range nsList {
+		if ns.Name != types.RootNamespace() {
+			e.namespaces[ns.Name] = struct{}{}
+		}
+	}
+
+	for _, t := range exportObjectTypes {
+		dirJson, err := db.ReadDB(ctx).GetDirectory(ctx, t, dir.DirForType(t))
+		if err != nil {
+			return nil, ErrUnableToExport.Err(err)
+		}
+		directory, jsonErr := models.JSONToDirectory(dirJson)
+		if jsonErr != nil {
+			return nil, ErrUnableToExport.Err(jsonErr).Msg("failed to de-serialize directory")
+		}
+		paths := make([]string, 0, len(directory))
+		for p := range directory {
+			paths = append(paths, p)
+		}
+		sort.Strings(paths)
+		for _, p := range paths {
+			e.entries = append(e.entries, exportEntry{objectType: t, path: p, hash: directory[p].Hash})
+		}
+	}
+	return e, nil
+}
+
+// Write writes a gzip compressed tarball to w containing one YAML manifest for every object in the export,
+// laid out as <namespace>/<resource>/<path>/<name>.yaml, followed by the trailer entry. Objects are loaded
+// and written in batches so that the archive is streamed rather than buffered in memory. If an error is
+// returned, the archive is incomplete and has no trailer.
+func (e *VariantExport) Write(ctx context.Context, w io.Writer) apperrors.Error {
+	gw := gzip.NewWriter(w)
+	tw := tar.NewWriter(gw)
+	modTime := time.Now()
+
+	for start := 0; start < len(e.entries); start += exportBatchSize {
+		end := min(start+exportBatchSize, len(e.entries))
+		if err := e.writeBatch(ctx, tw, e.entries[start:end], modTime); err != nil {
+			return err
+		}
+	}
+
+	trailer, jsonErr := json.Marshal(&ExportTrailer{Objects: len(e.entries)})
+	if jsonErr != nil {
+		return ErrUnableToExport.Err(jsonErr)
+	}
+	if err := writeExportEntry(tw, ExportTrailerName, trailer, modTime); err != nil {
+		return err
+	}
+	if err := tw.Close(); err != nil {
+		return ErrUnableToExport.Err(err)
+	}
+	if err := gw.Close(); err != nil {
+		return ErrUnableToExport.Err(err)
+	}
+	return nil
+}
+
+func (e *VariantExport) writeBatch(ctx context.Context, tw *tar.Writer, batch []exportEntry, modTime time.Time) apperrors.Error {
+	hashes := make([]string, 0, len(batch))
+	for _, entry := range batch {
+		hashes = append(hashes, entry.hash)
+	}
+	objs, err := db.ReadDB(ctx).GetCatalogObjects(ctx, hashes)
+	if err != nil {
+		return ErrUnableToExport.Err(err)
+	}
+
+	for _, entry := range batch {
+		obj, ok := objs[entry.hash]
+		if !ok {
+			log.Ctx(ctx).Error().Str("path", entry.path).Str("hash", entry.hash).Msg("catalog object missing for directory entry")
+			return ErrUnableToExport.Msg("catalog object missing for " + entry.path)
+		}
+		m := exportMetadataFromPath(entry.path, e.namespaces)
+		m.Catalog = e.catalog
+		m.Variant = types.NullableStringFrom(e.variant)
+
+		manifest, err := exportManifest(ctx, entry.objectType, obj, &m)
+		if err != nil {
+			return err
+		}
+
+		ns := m.Namespace.String()
+		if ns == "" {
+			ns = types.RootNamespace()
+		}
+		name := path.Join(ns, types.ResourceNameFromObjectType(entry.objectType), m.Path, m.Name) + ".yaml"
+		if err := writeExportEntry(tw, name, manifest, modTime); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeExportEntry(tw *tar.Writer, name string, content []byte, modTime time.Time) apperrors.Error {
+	hdr := &tar.Header{
+		Name:    name,
+		Mode:    0644,
+		Size:    int64(len(content)),
+		ModTime: modTime,
+	}
+	if err := tw.WriteHeader(hdr); err != nil {
+		return ErrUnableToExport.Err(err)
+	}
+	if _, err := tw.Write(content); err != nil {
+		return ErrUnableToExport.Err(err)
+	}
+	return nil
+}
+
+// exportManifest converts a stored catalog object to its YAML manifest
+func exportManifest(ctx context.Context, t types.CatalogObjectType, obj *models.CatalogObject, m *schemamanager.SchemaMetadata) ([]byte, apperrors.Error) {
+	var j []byte
+	var err apperrors.Error
+	switch t {
+	case types.CatalogObjectTypeCatalogCollection:
+		var cm schemamanager.CollectionManager
+		if cm, err = collectionManagerFromObject(ctx, obj, m); err != nil {
+			return nil, err
+		}
+		if j, err = cm.ToJson(ctx); err != nil {
+			return nil, err
+		}
+		// sensitive values are left out rather than redacted, so that importing the export cannot overwrite
+		// them with a placeholder
+		for _, param := range cm.SensitiveParameters() {
+			var jsonErr error
+			if j, jsonErr = sjson.DeleteBytes(j, "spec.values."+param); jsonErr != nil {
+				return nil, ErrUnableToExport.Err(jsonErr)
+			}
+		}
+	default:
+		s := &schemastore.SchemaStorageRepresentation{}
+		if jsonErr := json.Unmarshal(obj.Data, s); jsonErr != nil {
+			return nil, ErrUnableToLoadObject.Err(jsonErr).Msg("failed to de-serialize catalog object data")
+		}
+		var sm schemamanager.SchemaManager
+		if sm, err = loadSchemaManager(ctx, s, m); err != nil {
+			return nil, err
+		}
+		if j, err = sm.ToJson(ctx); err != nil {
+			return nil, err
+		}
+	}
+	y, yamlErr := yaml.JSONToYAML(j)
+	if yamlErr != nil {
+		return nil, ErrUnableToExport.Err(yamlErr).Msg("failed to convert manifest to yaml")
+	}
+	return y, nil
+}
+
+// exportMetadataFromPath reconstructs the namespace, path and name of an object from its storage path.
+// The first path segment is treated as a namespace only if such a namespace exists in the variant.
+func exportMetadataFromPath(p string, namespaces map[string]struct{}) schemamanager.SchemaMetadata {
+	var m schemamanager.SchemaMetadata
+	rel := strings.Trim(strings.TrimPrefix(p, "/"+types.RootNamespace()), "/")
+	segments := strings.Split(rel, "/")
+	if len(segments) > 1 {
+		if _, ok := namespaces[segments[0]]; ok {
+			m.Namespace = types.NullableStringFrom(segments[0])
+			segments = segments[1:]
+		}
+	}
+	m.Name = segments[len(segments)-1]
+	m.Path = path.Clean("/" + strings.Join(segments[:len(segments)-1], "/"))
+	return m
+}
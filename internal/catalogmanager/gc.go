@@ -0,0 +1,61 @@
+package catalogmanager
+
+import (
+	"context"
+	"time"
+
+	"github.com/mugiliam/common/apperrors"
+	"github.com/mugiliam/hatchcatalogsrv/internal/common"
+	"github.com/mugiliam/hatchcatalogsrv/internal/db"
+	"github.com/mugiliam/hatchcatalogsrv/pkg/types"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// objectGCBatchSize bounds the number of catalog objects deleted in a single statement
+	objectGCBatchSize = 500
+)
+
+// objectGCGracePeriod protects recently created objects from collection. A writer stores the object before the
+// directory entry that references it, so a young unreferenced object may still be in use. Storing an object that
+// already exists resets its creation time, so an old object that is being reused is protected the same way.
+var objectGCGracePeriod = 10 * time.Minute
+
+// GCUnreferencedObjects deletes the tenant's catalog objects that are no longer referenced by any directory or
+// collection history entry. Objects are deleted in bounded batches until none are left, and objects younger
+// than the grace period are skipped so that it is safe to run alongside writes. It stops between batches once
+// ctx is cancelled. Blobs that no catalog object refers to, left behind by inserts that rolled back, are deleted
+// afterwards. It returns the number of objects deleted.
+func GCUnreferencedObjects(ctx context.Context, tenantID types.TenantId) (int, apperrors.Error) {
+	if tenantID == "" {
+		return 0, ErrCatalogError.Msg("tenant ID is required")
+	}
+	ctx = common.SetTenantIdInContext(ctx, tenantID)
+
+	total := 0
+	for {
+		// the collection may run for longer than the request allows, so stop between batches once it is cancelled
+		if ctx.Err() != nil {
+			log.Ctx(ctx).Warn().Err(ctx.Err()).Int("deleted", total).Msg("collection of unreferenced catalog objects was interrupted")
+			return total, ErrCatalogError.Msg("collection of unreferenced catalog objects was interrupted")
+		}
+		n, err := db.DB(ctx).DeleteUnreferencedCatalogObjects(ctx, objectGCGracePeriod, objectGCBatchSize)
+		if err != nil {
+			log.Ctx(ctx).Error().Err(err).Int("deleted", total).Msg("failed to delete unreferenced catalog objects")
+			return total, ErrCatalogError.Err(err)
+		}
+		total += n
+		if n < objectGCBatchSize {
+			break
+		}
+	}
+	log.Ctx(ctx).Info().Str("tenant", string(tenantID)).Int("deleted", total).Msg("collected unreferenced catalog objects")
+
+	blobs, err := db.DB(ctx).DeleteOrphanedBlobs(ctx, objectGCGracePeriod)
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Int("deleted", blobs).Msg("failed to delete orphaned blobs")
+		return total, ErrCatalogError.Err(err)
+	}
+	log.Ctx(ctx).Info().Str("tenant", string(tenantID)).Int("deleted", blobs).Msg("collected orphaned blobs")
+	return total, nil
+}
@@ -0,0 +1,124 @@
+package catalogmanager
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgtype"
+	"github.com/mugiliam/hatchcatalogsrv/internal/common"
+	"github.com/mugiliam/hatchcatalogsrv/internal/db"
+	"github.com/mugiliam/hatchcatalogsrv/internal/db/dberror"
+	"github.com/mugiliam/hatchcatalogsrv/internal/db/models"
+	"github.com/mugiliam/hatchcatalogsrv/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGCUnreferencedObjects(t *testing.T) {
+	ctx := newDb()
+	t.Cleanup(func() {
+		db.DB(ctx).Close(ctx)
+	})
+
+	tenantID := types.TenantId("TABCDE")
+	projectID := types.ProjectId("PDEFGH")
+	ctx = common.SetTenantIdInContext(ctx, tenantID)
+	ctx = common.SetProjectIdInContext(ctx, projectID)
+
+	var err error
+	err = db.DB(ctx).CreateTenant(ctx, tenantID)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		db.DB(ctx).DeleteTenant(ctx, tenantID)
+	})
+	err = db.DB(ctx).CreateProject(ctx, projectID)
+	require.NoError(t, err)
+
+	c := models.Catalog{
+		Name:      "gc-catalog",
+		ProjectID: projectID,
+		Info:      pgtype.JSONB{Status: pgtype.Null},
+	}
+	err = db.DB(ctx).CreateCatalog(ctx, &c)
+	require.NoError(t, err)
+	variantID, err := db.DB(ctx).GetVariantIDFromName(ctx, c.CatalogID, types.DefaultVariant)
+	require.NoError(t, err)
+	ws := newTestWorkspace(t, ctx, variantID)
+
+	kept := strings.Repeat("a", 128)
+	collected := strings.Repeat("b", 128)
+	for _, hash := range []string{kept, collected} {
+		err = db.DB(ctx).CreateCatalogObject(ctx, &models.CatalogObject{
+			Hash:    hash,
+			Type:    types.CatalogObjectTypeParameterSchema,
+			Version: "v1",
+			Data:    []byte(`{"hash":"` + hash + `"}`),
+		})
+		require.NoError(t, err)
+	}
+	err = db.DB(ctx).AddOrUpdateObjectByPath(ctx, types.CatalogObjectTypeParameterSchema, ws.ParametersDir, "/kept", testObjectRef(kept))
+	require.NoError(t, err)
+	err = db.DB(ctx).AddOrUpdateObjectByPath(ctx, types.CatalogObjectTypeParameterSchema, ws.ParametersDir, "/collected", testObjectRef(collected))
+	require.NoError(t, err)
+
+	// deleting the directory entry leaves the object behind
+	_, err = db.DB(ctx).DeleteObjectByPath(ctx, types.CatalogObjectTypeParameterSchema, ws.ParametersDir, "/collected")
+	require.NoError(t, err)
+	_, err = db.DB(ctx).GetCatalogObject(ctx, collected)
+	require.NoError(t, err)
+
+	// the object is younger than the grace period
+	deleted, err := GCUnreferencedObjects(ctx, tenantID)
+	require.NoError(t, err)
+	assert.Equal(t, 0, deleted)
+	_, err = db.DB(ctx).GetCatalogObject(ctx, collected)
+	require.NoError(t, err)
+
+	gracePeriod := objectGCGracePeriod
+	objectGCGracePeriod = 0
+	t.Cleanup(func() {
+		objectGCGracePeriod = gracePeriod
+	})
+
+	deleted, err = GCUnreferencedObjects(ctx, tenantID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, deleted)
+	_, err = db.DB(ctx).GetCatalogObject(ctx, collected)
+	assert.ErrorIs(t, err, dberror.ErrNotFound)
+	_, err = db.DB(ctx).GetCatalogObject(ctx, kept)
+	assert.NoError(t, err)
+
+	// nothing is left to collect
+	deleted, err = GCUnreferencedObjects(ctx, tenantID)
+	require.NoError(t, err)
+	assert.Equal(t, 0, deleted)
+
+	// storing an old unreferenced object again restarts its grace period
+	reused := strings.Repeat("c", 128)
+	obj := &models.CatalogObject{
+		Hash:    reused,
+		Type:    types.CatalogObjectTypeParameterSchema,
+		Version: "v1",
+		Data:    []byte(`{"hash":"` + reused + `"}`),
+	}
+	require.NoError(t, db.DB(ctx).CreateCatalogObject(ctx, obj))
+	objectGCGracePeriod = time.Second
+	time.Sleep(1100 * time.Millisecond)
+	assert.ErrorIs(t, db.DB(ctx).CreateCatalogObject(ctx, obj), dberror.ErrAlreadyExists)
+	deleted, err = GCUnreferencedObjects(ctx, tenantID)
+	require.NoError(t, err)
+	assert.Equal(t, 0, deleted)
+	_, err = db.DB(ctx).GetCatalogObject(ctx, reused)
+	assert.NoError(t, err)
+
+	// a cancelled collection stops before deleting anything
+	objectGCGracePeriod = 0
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	_, err = GCUnreferencedObjects(cancelled, tenantID)
+	assert.Error(t, err)
+	_, err = db.DB(ctx).GetCatalogObject(ctx, reused)
+	assert.NoError(t, err)
+}
@@ -2,10 +2,13 @@ package catalogmanager
 
 import (
 	"encoding/json"
+	"fmt"
+	"path"
 	"testing"
 
 	"github.com/jackc/pgtype"
 	"github.com/mugiliam/hatchcatalogsrv/internal/catalogmanager/schemamanager"
+	"github.com/mugiliam/hatchcatalogsrv/internal/catalogmanager/validationerrors"
 	"github.com/mugiliam/hatchcatalogsrv/internal/common"
 	"github.com/mugiliam/hatchcatalogsrv/internal/config"
 	"github.com/mugiliam/hatchcatalogsrv/internal/db"
@@ -13,6 +16,7 @@ import (
 	"github.com/mugiliam/hatchcatalogsrv/pkg/types"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/tidwall/sjson"
 	"sigs.k8s.io/yaml"
 )
 
@@ -618,12 +622,13 @@ func TestSaveHierarchicalValue(t *testing.T) {
 	require.NotNil(t, lr)
 	assert.NotEqual(t, collectionHash, lr.StorageRepresentation().GetHash())
 
-	// create a value with invalid data type
+	// create a value with invalid data type. The error names the parameter, its type and the value.
 	jsonData, err = yaml.YAMLToJSON([]byte(invalidDataTypeYaml))
 	require.NoError(t, err)
 	err = SaveValue(ctx, jsonData, nil, WithWorkspaceID(ws.WorkspaceID))
 	if assert.Error(t, err) {
-		t.Logf("Error: %v", err)
+		assert.ErrorIs(t, err, validationerrors.ErrSchemaValidation)
+		assert.Contains(t, err.Error(), `maxDelay: expects Integer, got "two_thousand"`)
 	}
 
 	// create a value with invalid parameter
@@ -638,8 +643,17 @@ func TestSaveHierarchicalValue(t *testing.T) {
 	jsonData, err = yaml.YAMLToJSON([]byte(invalidPathYaml))
 	require.NoError(t, err)
 	err = SaveValue(ctx, jsonData, nil, WithWorkspaceID(ws.WorkspaceID))
-	if assert.Error(t, err) {
-		t.Logf("Error: %v", err)
+	if assert.ErrorIs(t, err, ErrCollectionSchemaNotFound) {
+		assert.Contains(t, err.Error(), "/invalidpath/app-config-collection")
+		assert.Contains(t, err.Error(), "none of its parents exist")
+	}
+
+	// create a value whose path is missing only the leaf
+	leafMissing, err := sjson.SetBytes(jsonData, "metadata.collection", "/valid/path/missing-collection")
+	require.NoError(t, err)
+	err = SaveValue(ctx, leafMissing, nil, WithWorkspaceID(ws.WorkspaceID))
+	if assert.ErrorIs(t, err, ErrCollectionSchemaNotFound) {
+		assert.Contains(t, err.Error(), "parent /valid/path exists")
 	}
 }
 
@@ -1085,3 +1099,111 @@ func TestReferences(t *testing.T) {
 	}
 
 }
+
+func TestDeleteHierarchicalSchema(t *testing.T) {
+	if !config.HierarchicalSchemas {
+		t.Skip("Hierarchical schemas are not enabled")
+	}
+	collectionSchemaYaml := `
+	version: v1
+	kind: CollectionSchema
+	metadata:
+		name: %s
+		catalog: example-catalog
+		path: %s
+	`
+	replaceTabsWithSpaces(&collectionSchemaYaml)
+
+	ctx := newDb()
+	t.Cleanup(func() {
+		db.DB(ctx).Close(ctx)
+	})
+
+	tenantID := types.TenantId("TABCDE")
+	projectID := types.ProjectId("PABCDE")
+	ctx = common.SetTenantIdInContext(ctx, tenantID)
+	ctx = common.SetProjectIdInContext(ctx, projectID)
+
+	err := db.DB(ctx).CreateTenant(ctx, tenantID)
+	assert.NoError(t, err)
+	t.Cleanup(func() {
+		_ = db.DB(ctx).DeleteTenant(ctx, tenantID)
+	})
+	err = db.DB(ctx).CreateProject(ctx, projectID)
+	assert.NoError(t, err)
+
+	cat := &models.Catalog{
+		Name:        "example-catalog",
+		Description: "An example catalog",
+		Info:        pgtype.JSONB{Status: pgtype.Null},
+		ProjectID:   projectID,
+	}
+	err = db.DB(ctx).CreateCatalog(ctx, cat)
+	assert.NoError(t, err)
+	varId, err := db.DB(ctx).GetVariantIDFromName(ctx, cat.CatalogID, types.DefaultVariant)
+	assert.NoError(t, err)
+	ws := &models.Workspace{
+		Info:        pgtype.JSONB{Status: pgtype.Null},
+		BaseVersion: 1,
+		VariantID:   varId,
+	}
+	err = db.DB(ctx).CreateWorkspace(ctx, ws)
+	assert.NoError(t, err)
+
+	// /valid, /another, /another/path, /another/collection and /another/collection/path
+	schemas := make(map[string]schemamanager.SchemaMetadata)
+	for _, s := range []struct{ name, path string }{
+		{"valid", "/"},
+		{"another", "/"},
+		{"path", "/another"},
+		{"collection", "/another"},
+		{"path", "/another/collection"},
+	} {
+		jsonData, err := yaml.YAMLToJSON([]byte(fmt.Sprintf(collectionSchemaYaml, s.name, s.path)))
+		require.NoError(t, err)
+		collectionSchema, err := NewSchema(ctx, jsonData, nil)
+		require.NoError(t, err)
+		require.NoError(t, SaveSchema(ctx, collectionSchema, WithWorkspaceID(ws.WorkspaceID)))
+		schemas[path.Join(s.path, s.name)] = collectionSchema.Metadata()
+	}
+
+	dir, err := getDirectoriesForWorkspace(ctx, ws.WorkspaceID)
+	require.NoError(t, err)
+	exists := func(p string) bool {
+		m := schemas[p]
+		_, err := LoadSchemaByPath(ctx, types.CatalogObjectTypeCollectionSchema, &m, WithWorkspaceID(ws.WorkspaceID))
+		return err == nil
+	}
+
+	// deleting a schema with children is blocked and names the children
+	m := schemas["/another"]
+	err = DeleteSchema(ctx, types.CatalogObjectTypeCollectionSchema, &m, dir)
+	if assert.ErrorIs(t, err, ErrUnableToDeleteCollectionWithChildren) {
+		assert.Contains(t, err.Error(), "/another/path")
+		assert.Contains(t, err.Error(), "/another/collection/path")
+	}
+	for p := range schemas {
+		assert.True(t, exists(p), p)
+	}
+
+	// a recursive delete removes the schema and its children only
+	m = schemas["/another/collection"]
+	err = DeleteSchema(ctx, types.CatalogObjectTypeCollectionSchema, &m, dir, WithRecursiveDelete())
+	require.NoError(t, err)
+	assert.False(t, exists("/another/collection"))
+	assert.False(t, exists("/another/collection/path"))
+	assert.True(t, exists("/another/path"))
+
+	m = schemas["/another"]
+	err = DeleteSchema(ctx, types.CatalogObjectTypeCollectionSchema, &m, dir, WithRecursiveDelete())
+	require.NoError(t, err)
+	assert.False(t, exists("/another"))
+	assert.False(t, exists("/another/path"))
+	assert.True(t, exists("/valid"))
+
+	// a leaf schema is deleted without the recursive option
+	m = schemas["/valid"]
+	err = DeleteSchema(ctx, types.CatalogObjectTypeCollectionSchema, &m, dir)
+	require.NoError(t, err)
+	assert.False(t, exists("/valid"))
+}
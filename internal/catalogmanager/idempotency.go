@@ -0,0 +1,92 @@
+package catalogmanager
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/mugiliam/common/apperrors"
+	"github.com/mugiliam/hatchcatalogsrv/internal/db"
+	"github.com/mugiliam/hatchcatalogsrv/internal/db/dberror"
+	"github.com/mugiliam/hatchcatalogsrv/internal/db/models"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// idempotencyKeyTTL is how long the outcome of a request made with an idempotency key is kept
+	idempotencyKeyTTL = 24 * time.Hour
+	// maxIdempotencyKeyLength is the longest idempotency key accepted
+	maxIdempotencyKeyLength = 255
+)
+
+// IdempotentResponse is the outcome of an earlier request made with the same idempotency key
+type IdempotentResponse struct {
+	StatusCode int
+	Location   string
+	Response   []byte
+}
+
+// ReserveIdempotencyKey claims key for the request identified by requestHash before the request is carried out.
+// It returns nil if the key is now held by the request, or the outcome of the earlier request that holds the key.
+// A key held by a request that has not completed yet is ErrIdempotencyKeyInProgress, and reusing a key for a
+// different request is ErrIdempotencyKeyReused.
+func ReserveIdempotencyKey(ctx context.Context, key, requestHash string) (*IdempotentResponse, apperrors.Error) {
+	if len(key) > maxIdempotencyKeyLength {
+		return nil, ErrInvalidRequest.Msg("idempotency key is too long")
+	}
+	k := &models.IdempotencyKey{
+		Key:         key,
+		RequestHash: requestHash,
+	}
+	err := db.DB(ctx).CreateIdempotencyKey(ctx, k, idempotencyKeyTTL)
+	if err == nil {
+		return nil, nil
+	}
+	if !errors.Is(err, dberror.ErrAlreadyExists) {
+		return nil, ErrCatalogError.Err(err)
+	}
+
+	k, err = db.DB(ctx).GetIdempotencyKey(ctx, key)
+	if err != nil {
+		if errors.Is(err, dberror.ErrNotFound) {
+			// the key expired or its request failed since we tried to claim it
+			return nil, ErrIdempotencyKeyInProgress
+		}
+		return nil, ErrCatalogError.Err(err)
+	}
+	if k.StatusCode == 0 {
+		return nil, ErrIdempotencyKeyInProgress
+	}
+	if k.RequestHash != requestHash {
+		return nil, ErrIdempotencyKeyReused
+	}
+	return &IdempotentResponse{
+		StatusCode: k.StatusCode,
+		Location:   k.Location,
+		Response:   k.Response,
+	}, nil
+}
+
+// SaveIdempotencyKey records the outcome of the request identified by requestHash under key, which the request
+// reserved with ReserveIdempotencyKey. A failure to record is logged and not returned since the request itself
+// has already succeeded.
+func SaveIdempotencyKey(ctx context.Context, key, requestHash string, rsp IdempotentResponse) {
+	k := &models.IdempotencyKey{
+		Key:         key,
+		RequestHash: requestHash,
+		StatusCode:  rsp.StatusCode,
+		Location:    rsp.Location,
+		Response:    rsp.Response,
+	}
+	if err := db.DB(ctx).UpdateIdempotencyKey(ctx, k); err != nil {
+		log.Ctx(ctx).Error().Err(err).Str("key", key).Msg("failed to save idempotency key")
+	}
+}
+
+// ReleaseIdempotencyKey gives up key after the request that reserved it failed, so that the request can be
+// retried with the same key
+func ReleaseIdempotencyKey(ctx context.Context, key string) {
+	if err := db.DB(ctx).DeleteIdempotencyKey(ctx, key); err != nil {
+		log.Ctx(ctx).Error().Err(err).Str("key", key).Msg("failed to release idempotency key")
+	}
+}
@@ -0,0 +1,334 @@
+package catalogmanager
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"errors"
+	"io"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgtype"
+	"github.com/mugiliam/common/apperrors"
+	"github.com/mugiliam/hatchcatalogsrv/internal/config"
+	"github.com/mugiliam/hatchcatalogsrv/internal/db"
+	"github.com/mugiliam/hatchcatalogsrv/internal/db/models"
+	"github.com/mugiliam/hatchcatalogsrv/pkg/types"
+	"github.com/rs/zerolog/log"
+	"sigs.k8s.io/yaml"
+)
+
+// ImportOptions control how an archive is applied by ImportVariant
+type ImportOptions struct {
+	ContinueOnError bool // apply remaining manifests when one fails instead of rolling back
+	Commit          bool // commit the import workspace to the variant when all manifests are applied
+}
+
+// ImportResult is the outcome of applying a single manifest from the archive
+type ImportResult struct {
+	File     string `json:"file"`
+	Kind     string `json:"kind,omitempty"`
+	Location string `json:"location,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+// ImportSummary reports the outcome of an import
+type ImportSummary struct {
+	Workspace  string         `json:"workspace,omitempty"`
+	Committed  bool           `json:"committed"`
+	RolledBack bool           `json:"rolledBack"`
+	Results    []ImportResult `json:"results"`
+}
+
+// Failed returns true if any of the manifests failed to apply
+func (s *ImportSummary) Failed() bool {
+	for _, r := range s.Results {
+		if r.Error != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// manifests are applied in dependency order: parameters first, then collection schemas and collections
+var importKindOrder = map[string]int{
+	types.ParameterSchemaKind:  0,
+	types.CollectionSchemaKind: 1,
+	types.CollectionKind:       2,
+}
+
+type importManifest struct {
+	file string
+	kind string
+	json []byte
+}
+
+// ImportVariant applies a gzip compressed tarball of YAML or JSON manifests, as produced by VariantExport.Write,
+// to a fresh workspace in the variant identified by reqCtx. The catalog and variant in each manifest, and
+// the namespace if reqCtx carries one, are replaced by those in reqCtx. If a manifest fails to apply, the
+// workspace is deleted unless opts.ContinueOnError is set. The returned summary carries the per-file results.
+//
+// Manifests are applied concurrently, each in a transaction of its own, so the import is not one transaction.
+// It is all-or-nothing for the variant because the manifests are applied to the import workspace, which is
+// only committed once all of them are applied. If the workspace cannot be deleted after a failure, the
+// summary names it, and the results tell the manifests that were applied to it, which have a location,
+// from those that failed or were not applied.
+func ImportVariant(ctx context.Context, r io.Reader, reqCtx RequestContext, opts ImportOptions) (*ImportSummary, apperrors.Error) {
+	if reqCtx.Catalog == "" || reqCtx.CatalogID == uuid.Nil {
+		return nil, ErrInvalidCatalog
+	}
+	if reqCtx.Variant == "" || reqCtx.VariantID == uuid.Nil {
+		return nil, ErrInvalidVariant
+	}
+
+	summary := &ImportSummary{}
+	manifests, err := readImportArchive(r, summary)
+	if err != nil {
+		return nil, err
+	}
+	if summary.Failed() && !opts.ContinueOnError {
+		// nothing has been applied yet
+		summary.RolledBack = true
+		return summary, nil
+	}
+
+	wm := &workspaceManager{
+		w: models.Workspace{
+			Description: "import of " + reqCtx.Catalog + "/" + reqCtx.Variant,
+			Info:        pgtype.JSONB{Status: pgtype.Null},
+			VariantID:   reqCtx.VariantID,
+			BaseVersion: 1,
+		},
+	}
+	if err := wm.Save(ctx); err != nil {
+		return nil, err
+	}
+	summary.Workspace = wm.ID().String()
+
+	rc := reqCtx
+	rc.WorkspaceID = wm.ID()
+	rc.WorkspaceLabel = ""
+	workers := config.Config().ImportWorkers()
+	for _, stage := range importStages(manifests) {
+		if summary.Failed() && !opts.ContinueOnError {
+			// the later stages are not applied, but are reported so that every manifest has a result
+			for _, m := range stage {
+				summary.Results = append(summary.Results, importNotApplied(m))
+			}
+			continue
+		}
+		for i, result := range applyImportManifests(ctx, rc, stage, workers, opts.ContinueOnError) {
+			if result == nil {
+				summary.Results = append(summary.Results, importNotApplied(stage[i]))
+			} else {
+				summary.Results = append(summary.Results, *result)
+			}
+		}
+	}
+
+	if summary.Failed() && !opts.ContinueOnError {
+		if err := DeleteWorkspace(ctx, wm.ID()); err != nil {
+			log.Ctx(ctx).Error().Err(err).Str("workspace", summary.Workspace).Msg("failed to roll back import workspace")
+			return summary, err
+		}
+		summary.RolledBack = true
+		summary.Workspace = ""
+		return summary, nil
+	}
+
+	if opts.Commit && !summary.Failed() {
+		if err := db.DB(ctx).CommitWorkspace(ctx, &wm.w); err != nil {
+			log.Ctx(ctx).Error().Err(err).Str("workspace", summary.Workspace).Msg("failed to commit import workspace")
+			return summary, ErrCatalogError.Msg("unable to commit workspace")
+		}
+		summary.Committed = true
+	}
+
+	return summary, nil
+}
+
+// errImportSizeExceeded is returned by an importSizeLimiter once more than its limit has been read
+var errImportSizeExceeded = errors.New("import size exceeded")
+
+// importSizeLimiter fails reads of r once more than max bytes have been read from it
+type importSizeLimiter struct {
+	r    io.Reader
+	max  int64
+	read int64
+}
+
+func (l *importSizeLimiter) Read(p []byte) (int, error) {
+	n, err := l.r.Read(p)
+	l.read += int64(n)
+	if l.read > l.max {
+		return n, errImportSizeExceeded
+	}
+	return n, err
+}
+
+// readImportArchive reads all manifests from the archive and orders them for applying. Entries that
+// cannot be parsed, or are larger than the configured limit, are recorded as failed results in the summary.
+// An archive that is larger than the configured limit once decompressed is rejected.
+func readImportArchive(r io.Reader, summary *ImportSummary) ([]importManifest, apperrors.Error) {
+	maxObjectSize, maxArchiveSize := config.Config().ImportLimits()
+	gr, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, ErrInvalidRequest.Msg("archive is not gzip compressed")
+	}
+	defer gr.Close()
+
+	var manifests []importManifest
+	tr := tar.NewReader(&importSizeLimiter{r: gr, max: maxArchiveSize})
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if errors.Is(err, errImportSizeExceeded) {
+			return nil, ErrImportTooLarge.Msg("import archive is larger than " + strconv.FormatInt(maxArchiveSize, 10) + " bytes")
+		}
+		if err != nil {
+			return nil, ErrInvalidRequest.Msg("unable to read archive")
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		switch strings.ToLower(path.Ext(hdr.Name)) {
+		case ".yaml", ".yml", ".json":
+		default:
+			continue
+		}
+		tooLarge := ImportResult{File: hdr.Name, Error: "manifest is larger than " + strconv.FormatInt(maxObjectSize, 10) + " bytes"}
+		if hdr.Size > maxObjectSize {
+			summary.Results = append(summary.Results, tooLarge)
+			continue
+		}
+		b, err := io.ReadAll(io.LimitReader(tr, maxObjectSize+1))
+		if errors.Is(err, errImportSizeExceeded) {
+			return nil, ErrImportTooLarge.Msg("import archive is larger than " + strconv.FormatInt(maxArchiveSize, 10) + " bytes")
+		}
+		if err != nil {
+			return nil, ErrInvalidRequest.Msg("unable to read archive")
+		}
+		if int64(len(b)) > maxObjectSize {
+			summary.Results = append(summary.Results, tooLarge)
+			continue
+		}
+		// strict conversion rejects duplicate keys, which would otherwise be dropped before they can be checked
+		j, err := yaml.YAMLToJSONStrict(b)
+		if err != nil {
+			summary.Results = append(summary.Results, ImportResult{File: hdr.Name, Error: "invalid manifest format"})
+			continue
+		}
+		kind, apperr := RequestType(j)
+		if apperr != nil {
+			summary.Results = append(summary.Results, ImportResult{File: hdr.Name, Error: apperr.Error()})
+			continue
+		}
+		if _, ok := importKindOrder[kind]; !ok {
+			summary.Results = append(summary.Results, ImportResult{File: hdr.Name, Kind: kind, Error: "unsupported kind for import"})
+			continue
+		}
+		manifests = append(manifests, importManifest{file: hdr.Name, kind: kind, json: j})
+	}
+
+	sort.SliceStable(manifests, func(i, j int) bool {
+		return importKindOrder[manifests[i].kind] < importKindOrder[manifests[j].kind]
+	})
+	return manifests, nil
+}
+
+// importStages splits the ordered manifests into runs of the same kind. The manifests of a stage don't depend
+// on each other and can be applied in any order, but only once the stages before it are applied.
+func importStages(manifests []importManifest) [][]importManifest {
+	var stages [][]importManifest
+	for i := 0; i < len(manifests); {
+		j := i + 1
+		for j < len(manifests) && manifests[j].kind == manifests[i].kind {
+			j++
+		}
+		stages = append(stages, manifests[i:j])
+		i = j
+	}
+	return stages
+}
+
+// applyImportManifests applies the manifests of a stage with up to workers of them in flight, and returns their
+// results in the order of the manifests. Unless continueOnError is set, a failure cancels the manifests in
+// flight and no more are started; those that were not started have a nil result.
+func applyImportManifests(ctx context.Context, reqCtx RequestContext, manifests []importManifest, workers int, continueOnError bool) []*ImportResult {
+	results := make([]*ImportResult, len(manifests))
+	if workers > len(manifests) {
+		workers = len(manifests)
+	}
+	if workers <= 1 {
+		for i, m := range manifests {
+			results[i] = applyImportManifest(ctx, reqCtx, m)
+			if results[i].Error != "" && !continueOnError {
+				break
+			}
+		}
+		return results
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			// a connection runs one statement or transaction at a time, so each worker has its own
+			wctx := db.ConnCtx(ctx)
+			d := db.DB(wctx)
+			if d != nil {
+				defer d.Close(context.WithoutCancel(wctx))
+			}
+			for i := range next {
+				if ctx.Err() != nil {
+					continue
+				}
+				if d == nil {
+					results[i] = &ImportResult{File: manifests[i].file, Kind: manifests[i].kind, Error: "unable to get a database connection"}
+				} else {
+					results[i] = applyImportManifest(wctx, reqCtx, manifests[i])
+				}
+				if results[i].Error != "" && !continueOnError {
+					cancel()
+				}
+			}
+		}()
+	}
+	for i := range manifests {
+		if ctx.Err() != nil {
+			break
+		}
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+	return results
+}
+
+// importNotApplied is the result of a manifest that was not applied because the import stopped at a failure
+func importNotApplied(m importManifest) ImportResult {
+	return ImportResult{File: m.file, Kind: m.kind, Error: "not applied: the import stopped at an earlier failure"}
+}
+
+func applyImportManifest(ctx context.Context, reqCtx RequestContext, m importManifest) *ImportResult {
+	result := &ImportResult{File: m.file, Kind: m.kind}
+	rm, err := ResourceManagerForKind(ctx, m.kind, reqCtx)
+	if err == nil {
+		result.Location, err = rm.Create(ctx, m.json)
+	}
+	if err != nil {
+		result.Error = err.Error()
+	}
+	return result
+}
@@ -0,0 +1,97 @@
+package catalogmanager
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgtype"
+	"github.com/mugiliam/hatchcatalogsrv/internal/common"
+	"github.com/mugiliam/hatchcatalogsrv/internal/config"
+	"github.com/mugiliam/hatchcatalogsrv/internal/db"
+	"github.com/mugiliam/hatchcatalogsrv/internal/db/models"
+	"github.com/mugiliam/hatchcatalogsrv/pkg/types"
+	"github.com/stretchr/testify/require"
+)
+
+func BenchmarkImportVariant(b *testing.B) {
+	ctx := newDb()
+	b.Cleanup(func() {
+		db.DB(ctx).Close(ctx)
+	})
+
+	tenantID := types.TenantId("TIMPBM")
+	projectID := types.ProjectId("PIMPBM")
+	ctx = common.SetTenantIdInContext(ctx, tenantID)
+	ctx = common.SetProjectIdInContext(ctx, projectID)
+	require.NoError(b, db.DB(ctx).CreateTenant(ctx, tenantID))
+	b.Cleanup(func() {
+		_ = db.DB(ctx).DeleteTenant(ctx, tenantID)
+	})
+	require.NoError(b, db.DB(ctx).CreateProject(ctx, projectID))
+	c := models.Catalog{
+		Name:      "import-catalog",
+		ProjectID: projectID,
+		Info:      pgtype.JSONB{Status: pgtype.Null},
+	}
+	require.Nil(b, db.DB(ctx).CreateCatalog(ctx, &c))
+	variantID, err := db.DB(ctx).GetVariantIDFromName(ctx, c.CatalogID, types.DefaultVariant)
+	require.Nil(b, err)
+	reqCtx := RequestContext{
+		Catalog:   c.Name,
+		CatalogID: c.CatalogID,
+		Variant:   types.DefaultVariant,
+		VariantID: variantID,
+	}
+
+	// 500 objects: 400 parameter schemas, 50 collection schemas using them and 50 collections
+	var buf bytes.Buffer
+	gw := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gw)
+	add := func(name, content string) {
+		require.NoError(b, tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(content))}))
+		_, err := tw.Write([]byte(content))
+		require.NoError(b, err)
+	}
+	for i := 0; i < 400; i++ {
+		n := strconv.Itoa(i)
+		add("parameterschemas/param-"+n+".json",
+			`{"version": "v1", "kind": "ParameterSchema", "metadata": {"name": "param-`+n+`", "path": "/"}, "spec": {"dataType": "Integer", "default": `+n+`}}`)
+	}
+	for i := 0; i < 50; i++ {
+		n := strconv.Itoa(i)
+		add("collectionschemas/schema-"+n+".json",
+			`{"version": "v1", "kind": "CollectionSchema", "metadata": {"name": "schema-`+n+`", "path": "/"}, "spec": {"parameters": {"a": {"schema": "param-`+n+`"}, "b": {"schema": "param-`+strconv.Itoa(i+50)+`"}}}}`)
+		add("collections/collection-"+n+".json",
+			`{"version": "v1", "kind": "Collection", "metadata": {"name": "collection-`+n+`", "path": "/"}, "spec": {"schema": "schema-`+n+`", "values": {"a": `+n+`}}}`)
+	}
+	require.NoError(b, tw.Close())
+	require.NoError(b, gw.Close())
+	archive := buf.Bytes()
+
+	for _, workers := range []int{1, 4, 8} {
+		b.Run("workers="+strconv.Itoa(workers), func(b *testing.B) {
+			file := filepath.Join(b.TempDir(), "config.toml")
+			require.NoError(b, os.WriteFile(file, []byte("import_concurrency = "+strconv.Itoa(workers)+"\n"), 0o600))
+			require.NoError(b, config.LoadConfig(file))
+			b.Cleanup(func() {
+				_ = config.LoadConfig("")
+			})
+			for i := 0; i < b.N; i++ {
+				summary, err := ImportVariant(ctx, bytes.NewReader(archive), reqCtx, ImportOptions{})
+				if err != nil {
+					b.Fatal(err)
+				}
+				if summary.Failed() {
+					b.Fatalf("import failed: %+v", summary.Results)
+				}
+				_ = DeleteWorkspace(ctx, uuid.MustParse(summary.Workspace))
+			}
+		})
+	}
+}
@@ -0,0 +1,324 @@
+package catalogmanager
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/mugiliam/common/apperrors"
+)
+
+// jsonPatchOperation is an operation of a JSON Patch document, see RFC 6902
+type jsonPatchOperation struct {
+	Op    string          `json:"op"`
+	Path  *string         `json:"path"`
+	From  *string         `json:"from"`
+	Value json.RawMessage `json:"value"`
+}
+
+// ApplyJSONPatch applies the JSON Patch document patchJson (RFC 6902) to the JSON document doc and returns the
+// patched document. It returns ErrInvalidJSONPatch if the patch is malformed, and ErrJSONPatchConflict if an
+// operation cannot be applied to the document, including a failed test operation. The operations are applied
+// in order and the patch is applied as a whole or not at all.
+func ApplyJSONPatch(doc []byte, patchJson []byte) ([]byte, apperrors.Error) {
+	var ops []jsonPatchOperation
+	if err := json.Unmarshal(patchJson, &ops); err != nil {
+		return nil, ErrInvalidJSONPatch.Msg("patch must be an array of operations")
+	}
+	target, err := decodeJSONValue(doc)
+	if err != nil {
+		return nil, ErrCatalogError.Msg("unable to parse the document to patch")
+	}
+
+	for _, op := range ops {
+		var apperr apperrors.Error
+		if target, apperr = op.apply(target); apperr != nil {
+			return nil, apperr
+		}
+	}
+
+	j, err := json.Marshal(target)
+	if err != nil {
+		return nil, ErrCatalogError.Msg("unable to encode the patched document")
+	}
+	return j, nil
+}
+
+func (op jsonPatchOperation) apply(doc any) (any, apperrors.Error) {
+	if op.Path == nil {
+		return nil, ErrInvalidJSONPatch.Msg("missing path")
+	}
+	path, err := parseJSONPointer(*op.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch op.Op {
+	case "add", "replace", "test":
+		if op.Value == nil {
+			return nil, ErrInvalidJSONPatch.Msg("missing value")
+		}
+		value, e := decodeJSONValue(op.Value)
+		if e != nil {
+			return nil, ErrInvalidJSONPatch.Msg("invalid value")
+		}
+		switch op.Op {
+		case "add":
+			return addJSONValue(doc, path, value)
+		case "replace":
+			return replaceJSONValue(doc, path, value)
+		}
+		current, err := getJSONValue(doc, path)
+		if err != nil {
+			return nil, err
+		}
+		if !jsonValuesEqual(current, value) {
+			return nil, ErrJSONPatchConflict.Msg("value at " + *op.Path + " does not match")
+		}
+		return doc, nil
+	case "remove":
+		doc, _, err = removeJSONValue(doc, path)
+		return doc, err
+	case "move", "copy":
+		if op.From == nil {
+			return nil, ErrInvalidJSONPatch.Msg("missing from")
+		}
+		from, err := parseJSONPointer(*op.From)
+		if err != nil {
+			return nil, err
+		}
+		var value any
+		if op.Op == "move" {
+			if len(path) > len(from) && reflect.DeepEqual(path[:len(from)], from) {
+				return nil, ErrJSONPatchConflict.Msg("cannot move a value into itself")
+			}
+			doc, value, err = removeJSONValue(doc, from)
+		} else {
+			value, err = getJSONValue(doc, from)
+			if err == nil {
+				// the copy must not share containers with the original
+				value, err = copyJSONValue(value)
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+		return addJSONValue(doc, path, value)
+	default:
+		return nil, ErrInvalidJSONPatch.Msg("unsupported operation " + strconv.Quote(op.Op))
+	}
+}
+
+// parseJSONPointer splits the JSON Pointer p (RFC 6901) into its unescaped reference tokens
+func parseJSONPointer(p string) ([]string, apperrors.Error) {
+	if p == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(p, "/") {
+		return nil, ErrInvalidJSONPatch.Msg("invalid path " + strconv.Quote(p))
+	}
+	tokens := strings.Split(p[1:], "/")
+	for i, t := range tokens {
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(t, "~1", "/"), "~0", "~")
+	}
+	return tokens, nil
+}
+
+func getJSONValue(doc any, path []string) (any, apperrors.Error) {
+	for _, t := range path {
+		switch c := doc.(type) {
+		case map[string]any:
+			v, ok := c[t]
+			if !ok {
+				return nil, jsonPathNotFound(path)
+			}
+			doc = v
+		case []any:
+			i, err := jsonArrayIndex(t, len(c)-1)
+			if err != nil {
+				return nil, jsonPathNotFound(path)
+			}
+			doc = c[i]
+		default:
+			return nil, jsonPathNotFound(path)
+		}
+	}
+	return doc, nil
+}
+
+// updateJSONContainer calls f with the object or array that holds the last token of path and returns doc with
+// the container replaced by the one f returns
+func updateJSONContainer(doc any, path []string, f func(container any, key string) (any, apperrors.Error)) (any, apperrors.Error) {
+	if len(path) == 1 {
+		return f(doc, path[0])
+	}
+	switch c := doc.(type) {
+	case map[string]any:
+		child, ok := c[path[0]]
+		if !ok {
+			return nil, jsonPathNotFound(path)
+		}
+		child, err := updateJSONContainer(child, path[1:], f)
+		if err != nil {
+			return nil, err
+		}
+		c[path[0]] = child
+		return c, nil
+	case []any:
+		i, err := jsonArrayIndex(path[0], len(c)-1)
+		if err != nil {
+			return nil, jsonPathNotFound(path)
+		}
+		child, err := updateJSONContainer(c[i], path[1:], f)
+		if err != nil {
+			return nil, err
+		}
+		c[i] = child
+		return c, nil
+	default:
+		return nil, jsonPathNotFound(path)
+	}
+}
+
+func addJSONValue(doc any, path []string, value any) (any, apperrors.Error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+	return updateJSONContainer(doc, path, func(container any, key string) (any, apperrors.Error) {
+		switch c := container.(type) {
+		case map[string]any:
+			c[key] = value
+			return c, nil
+		case []any:
+			if key == "-" {
+				return append(c, value), nil
+			}
+			i, err := jsonArrayIndex(key, len(c))
+			if err != nil {
+				return nil, jsonPathNotFound(path)
+			}
+			c = append(c, nil)
+			copy(c[i+1:], c[i:])
+			c[i] = value
+			return c, nil
+		default:
+			return nil, jsonPathNotFound(path)
+		}
+	})
+}
+
+func replaceJSONValue(doc any, path []string, value any) (any, apperrors.Error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+	return updateJSONContainer(doc, path, func(container any, key string) (any, apperrors.Error) {
+		switch c := container.(type) {
+		case map[string]any:
+			if _, ok := c[key]; !ok {
+				return nil, jsonPathNotFound(path)
+			}
+			c[key] = value
+			return c, nil
+		case []any:
+			i, err := jsonArrayIndex(key, len(c)-1)
+			if err != nil {
+				return nil, jsonPathNotFound(path)
+			}
+			c[i] = value
+			return c, nil
+		default:
+			return nil, jsonPathNotFound(path)
+		}
+	})
+}
+
+// removeJSONValue returns doc without the value at path, along with the removed value
+func removeJSONValue(doc any, path []string) (any, any, apperrors.Error) {
+	if len(path) == 0 {
+		return nil, nil, ErrJSONPatchConflict.Msg("cannot remove the whole document")
+	}
+	var removed any
+	doc, err := updateJSONContainer(doc, path, func(container any, key string) (any, apperrors.Error) {
+		switch c := container.(type) {
+		case map[string]any:
+			v, ok := c[key]
+			if !ok {
+				return nil, jsonPathNotFound(path)
+			}
+			removed = v
+			delete(c, key)
+			return c, nil
+		case []any:
+			i, err := jsonArrayIndex(key, len(c)-1)
+			if err != nil {
+				return nil, jsonPathNotFound(path)
+			}
+			removed = c[i]
+			return append(c[:i], c[i+1:]...), nil
+		default:
+			return nil, jsonPathNotFound(path)
+		}
+	})
+	return doc, removed, err
+}
+
+// jsonArrayIndex parses the array index token t, which must be between 0 and last
+func jsonArrayIndex(t string, last int) (int, apperrors.Error) {
+	if t == "" || (len(t) > 1 && t[0] == '0') {
+		return 0, ErrJSONPatchConflict
+	}
+	i, err := strconv.Atoi(t)
+	if err != nil || i < 0 || i > last {
+		return 0, ErrJSONPatchConflict
+	}
+	return i, nil
+}
+
+func jsonPathNotFound(path []string) apperrors.Error {
+	escaped := make([]string, len(path))
+	for i, t := range path {
+		escaped[i] = strings.ReplaceAll(strings.ReplaceAll(t, "~", "~0"), "/", "~1")
+	}
+	return ErrJSONPatchConflict.Msg("path /" + strings.Join(escaped, "/") + " does not exist")
+}
+
+// decodeJSONValue decodes j keeping numbers as they are written, so values the patch does not touch are not
+// changed by a round trip through float64
+func decodeJSONValue(j []byte) (any, error) {
+	d := json.NewDecoder(bytes.NewReader(j))
+	d.UseNumber()
+	var v any
+	if err := d.Decode(&v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+func copyJSONValue(v any) (any, apperrors.Error) {
+	j, err := json.Marshal(v)
+	if err != nil {
+		return nil, ErrCatalogError.Msg("unable to copy value")
+	}
+	c, err := decodeJSONValue(j)
+	if err != nil {
+		return nil, ErrCatalogError.Msg("unable to copy value")
+	}
+	return c, nil
+}
+
+// jsonValuesEqual compares a and b as JSON values, so numbers are equal if they have the same value however
+// they are written
+func jsonValuesEqual(a, b any) bool {
+	var na, nb any
+	ja, errA := json.Marshal(a)
+	jb, errB := json.Marshal(b)
+	if errA != nil || errB != nil {
+		return false
+	}
+	if json.Unmarshal(ja, &na) != nil || json.Unmarshal(jb, &nb) != nil {
+		return false
+	}
+	return reflect.DeepEqual(na, nb)
+}
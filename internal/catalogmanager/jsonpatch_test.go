@@ -0,0 +1,50 @@
+package catalogmanager
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApplyJSONPatch(t *testing.T) {
+	doc := `{"a": {"b": [1, 2, 3], "c~d": 1.50, "e/f": "x"}}`
+	tests := []struct {
+		name  string
+		patch string
+		want  string
+		err   error
+	}{
+		{"add member", `[{"op": "add", "path": "/a/g", "value": {"h": null}}]`, `{"a": {"b": [1, 2, 3], "c~d": 1.50, "e/f": "x", "g": {"h": null}}}`, nil},
+		{"add to array", `[{"op": "add", "path": "/a/b/1", "value": 9}, {"op": "add", "path": "/a/b/-", "value": 10}]`, `{"a": {"b": [1, 9, 2, 3, 10], "c~d": 1.50, "e/f": "x"}}`, nil},
+		{"replace escaped", `[{"op": "replace", "path": "/a/c~0d", "value": 2}, {"op": "replace", "path": "/a/e~1f", "value": "y"}]`, `{"a": {"b": [1, 2, 3], "c~d": 2, "e/f": "y"}}`, nil},
+		{"remove from array", `[{"op": "remove", "path": "/a/b/0"}]`, `{"a": {"b": [2, 3], "c~d": 1.50, "e/f": "x"}}`, nil},
+		{"move", `[{"op": "move", "from": "/a/e~1f", "path": "/x"}]`, `{"a": {"b": [1, 2, 3], "c~d": 1.50}, "x": "x"}`, nil},
+		{"copy", `[{"op": "copy", "from": "/a/b", "path": "/b"}, {"op": "add", "path": "/b/-", "value": 4}]`, `{"a": {"b": [1, 2, 3], "c~d": 1.50, "e/f": "x"}, "b": [1, 2, 3, 4]}`, nil},
+		{"test compares values", `[{"op": "test", "path": "/a/c~0d", "value": 1.5}]`, doc, nil},
+		{"replace document", `[{"op": "replace", "path": "", "value": [1]}]`, `[1]`, nil},
+		{"failed test", `[{"op": "test", "path": "/a/b", "value": [1, 2]}]`, "", ErrJSONPatchConflict},
+		{"missing member", `[{"op": "replace", "path": "/a/z", "value": 1}]`, "", ErrJSONPatchConflict},
+		{"missing parent", `[{"op": "add", "path": "/z/y", "value": 1}]`, "", ErrJSONPatchConflict},
+		{"index out of range", `[{"op": "add", "path": "/a/b/4", "value": 1}]`, "", ErrJSONPatchConflict},
+		{"leading zero", `[{"op": "remove", "path": "/a/b/01"}]`, "", ErrJSONPatchConflict},
+		{"move into itself", `[{"op": "move", "from": "/a", "path": "/a/x"}]`, "", ErrJSONPatchConflict},
+		{"remove document", `[{"op": "remove", "path": ""}]`, "", ErrJSONPatchConflict},
+		{"not an array", `{"op": "remove", "path": "/a"}`, "", ErrInvalidJSONPatch},
+		{"unknown op", `[{"op": "rename", "path": "/a"}]`, "", ErrInvalidJSONPatch},
+		{"missing value", `[{"op": "add", "path": "/a/x"}]`, "", ErrInvalidJSONPatch},
+		{"missing from", `[{"op": "copy", "path": "/a/x"}]`, "", ErrInvalidJSONPatch},
+		{"relative path", `[{"op": "remove", "path": "a"}]`, "", ErrInvalidJSONPatch},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ApplyJSONPatch([]byte(doc), []byte(tt.patch))
+			if tt.err != nil {
+				assert.ErrorIs(t, err, tt.err)
+				return
+			}
+			if assert.Nil(t, err) {
+				assert.JSONEq(t, tt.want, string(got))
+			}
+		})
+	}
+}
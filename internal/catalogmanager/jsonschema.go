@@ -0,0 +1,191 @@
+package catalogmanager
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/google/uuid"
+	"github.com/mugiliam/common/apperrors"
+	"github.com/mugiliam/hatchcatalogsrv/internal/catalogmanager/schemamanager"
+	"github.com/mugiliam/hatchcatalogsrv/internal/catalogmanager/validationerrors"
+	"github.com/mugiliam/hatchcatalogsrv/pkg/types"
+	"github.com/rs/zerolog/log"
+	"github.com/tidwall/gjson"
+)
+
+// jsonSchemaDialect is the JSON Schema draft that generated schemas conform to
+const jsonSchemaDialect = "https://json-schema.org/draft/2020-12/schema"
+
+// jsonSchemaUnitKeyword is the annotation with the unit of a parameter. JSON Schema has no keyword for units,
+// and unknown keywords are collected as annotations by validators.
+const jsonSchemaUnitKeyword = "unit"
+
+// jsonSchemaForDataType translates the validation of a data type into JSON Schema keywords. Data types
+// without an entry produce an unconstrained schema.
+var jsonSchemaForDataType = map[string]func(validation gjson.Result) map[string]any{
+	"Integer": integerJSONSchema,
+	"String":  stringJSONSchema,
+}
+
+func integerJSONSchema(validation gjson.Result) map[string]any {
+	s := map[string]any{"type": "integer"}
+	min := validation.Get("minValue")
+	if min.Exists() && min.Type != gjson.Null {
+		s["minimum"] = min.Int()
+	}
+	if max := validation.Get("maxValue"); max.Exists() && max.Type != gjson.Null {
+		s["maximum"] = max.Int()
+	}
+	// steps are counted from minValue, which is only expressible as multipleOf when minValue is a multiple
+	if step := validation.Get("step").Int(); step > 0 && (!min.Exists() || min.Int()%step == 0) {
+		s["multipleOf"] = step
+	}
+	return s
+}
+
+func stringJSONSchema(validation gjson.Result) map[string]any {
+	s := map[string]any{"type": "string"}
+	if v := validation.Get("pattern"); v.Exists() {
+		s["pattern"] = v.String()
+	}
+	if v := validation.Get("minLength"); v.Exists() {
+		s["minLength"] = v.Int()
+	}
+	if v := validation.Get("maxLength"); v.Exists() {
+		s["maxLength"] = v.Int()
+	}
+	return s
+}
+
+// parameterJSONSchema returns the JSON Schema for a parameter with the given data type, validation and default
+func parameterJSONSchema(dataType string, validation, def gjson.Result) map[string]any {
+	s := map[string]any{}
+	if f, ok := jsonSchemaForDataType[dataType]; ok {
+		s = f(validation)
+	}
+	if enum := validation.Get("enum"); enum.IsArray() {
+		s["enum"] = json.RawMessage(enum.Raw)
+	}
+	if def.Exists() && def.Type != gjson.Null {
+		s["default"] = json.RawMessage(def.Raw)
+	}
+	return s
+}
+
+// GenerateJSONSchema translates the collection schema named name in reqCtx into a JSON Schema that describes
+// the values of a collection based on it. Parameters that refer to a parameter schema are resolved the same
+// way as when the collection schema is validated, and the data type and validation of the resolved schema is
+// translated into JSON Schema keywords.
+func GenerateJSONSchema(ctx context.Context, reqCtx RequestContext, name string) ([]byte, apperrors.Error) {
+	if reqCtx.Catalog == "" || reqCtx.CatalogID == uuid.Nil {
+		return nil, ErrInvalidCatalog
+	}
+	if reqCtx.Variant == "" || reqCtx.VariantID == uuid.Nil {
+		return nil, ErrInvalidVariant
+	}
+	if name == "" {
+		return nil, ErrInvalidRequest.Msg("collection schema name is required")
+	}
+
+	var dir Directories
+	var err apperrors.Error
+	if reqCtx.WorkspaceID != uuid.Nil {
+		dir, err = getDirectoriesForWorkspace(ctx, reqCtx.WorkspaceID)
+	} else {
+		dir, err = getDirectoriesForVariant(ctx, reqCtx.VariantID)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	m := schemamanager.SchemaMetadata{
+		Catalog:   reqCtx.Catalog,
+		Variant:   types.NullableStringFrom(reqCtx.Variant),
+		Namespace: types.NullableStringFrom(reqCtx.Namespace),
+		Name:      name,
+		Path:      "/",
+		IDS: schemamanager.IDS{
+			CatalogID: reqCtx.CatalogID,
+			VariantID: reqCtx.VariantID,
+		},
+	}
+	if ves := m.Validate(); ves != nil {
+		return nil, validationerrors.ErrSchemaValidation.Msg(ves.Error())
+	}
+	om, err := LoadSchemaByPath(ctx, types.CatalogObjectTypeCollectionSchema, &m, WithDirectories(dir))
+	if err != nil {
+		return nil, err
+	}
+	csJson, err := om.ToJson(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	loaders := getSchemaLoaders(ctx, om.Metadata(), WithDirectories(dir))
+	namespaces := make(map[string]struct{})
+	if reqCtx.Namespace != "" {
+		namespaces[reqCtx.Namespace] = struct{}{}
+	}
+	properties := make(map[string]any)
+	var rErr apperrors.Error
+	gjson.GetBytes(csJson, "spec.parameters").ForEach(func(param, p gjson.Result) bool {
+		schemaName := p.Get("schema").String()
+		if schemaName == "" {
+			s := parameterJSONSchema(p.Get("dataType").String(), gjson.Result{}, p.Get("default"))
+			if unit := p.Get("unit").String(); unit != "" {
+				s[jsonSchemaUnitKeyword] = unit
+			}
+			properties[param.String()] = s
+			return true
+		}
+		ps, _, err := loadParameterSchema(ctx, loaders, om.Metadata(), namespaces, param.String(), schemaName)
+		if err != nil {
+			rErr = err
+			return false
+		}
+		pj, err := ps.ToJson(ctx)
+		if err != nil {
+			rErr = err
+			return false
+		}
+		// the collection schema default takes precedence over the one in the parameter schema
+		def := p.Get("default")
+		if !def.Exists() || def.Type == gjson.Null {
+			def = gjson.GetBytes(pj, "spec.default")
+		}
+		s := parameterJSONSchema(gjson.GetBytes(pj, "spec.dataType").String(), gjson.GetBytes(pj, "spec.validation"), def)
+		if d := gjson.GetBytes(pj, "metadata.description").String(); d != "" {
+			s["description"] = d
+		}
+		// so does the unit
+		unit := p.Get("unit").String()
+		if unit == "" {
+			unit = gjson.GetBytes(pj, "spec.unit").String()
+		}
+		if unit != "" {
+			s[jsonSchemaUnitKeyword] = unit
+		}
+		properties[param.String()] = s
+		return true
+	})
+	if rErr != nil {
+		return nil, rErr
+	}
+
+	s := map[string]any{
+		"$schema":              jsonSchemaDialect,
+		"title":                om.Metadata().Name,
+		"type":                 "object",
+		"properties":           properties,
+		"additionalProperties": false,
+	}
+	if d := om.Metadata().Description; d != "" {
+		s["description"] = d
+	}
+	j, e := json.Marshal(s)
+	if e != nil {
+		log.Ctx(ctx).Error().Err(e).Msg("failed to marshal json schema")
+		return nil, ErrCatalogError.Msg("unable to generate json schema")
+	}
+	return j, nil
+}
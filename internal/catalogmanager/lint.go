@@ -0,0 +1,166 @@
+package catalogmanager
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/mugiliam/common/apperrors"
+	"github.com/mugiliam/hatchcatalogsrv/internal/catalogmanager/schemamanager"
+	"github.com/mugiliam/hatchcatalogsrv/internal/db"
+	"github.com/mugiliam/hatchcatalogsrv/pkg/types"
+	"github.com/tidwall/gjson"
+)
+
+// LintWarning is advice about a schema that does not prevent it from being saved
+type LintWarning struct {
+	Rule    string `json:"rule"`
+	Field   string `json:"field,omitempty"`
+	Message string `json:"message"`
+}
+
+// WarningReporter is implemented by resource managers that report warnings about the object they last saved
+type WarningReporter interface {
+	Warnings() []LintWarning
+}
+
+// lintRule inspects a schema that passed validation and returns the warnings it finds
+type lintRule func(ctx context.Context, om schemamanager.SchemaManager, schemaJson []byte) []LintWarning
+
+// lintRules are run on every linted schema. A new convention is enforced by adding a rule here.
+var lintRules = []lintRule{
+	lintMissingDescription,
+	lintEmptyCollectionSchema,
+	lintDefaultAtBoundary,
+}
+
+// LintSchema validates the schema in rsrcJson the same way as a create and runs the lint rules on it. Only
+// the schema itself is validated, so the warnings are returned whether or not the schema's references
+// resolve. An error is returned only if the schema is invalid.
+func LintSchema(ctx context.Context, reqCtx RequestContext, rsrcJson []byte) ([]LintWarning, apperrors.Error) {
+	kind := gjson.GetBytes(rsrcJson, "kind").String()
+	if kind != types.ParameterSchemaKind && kind != types.CollectionSchemaKind {
+		return nil, ErrInvalidSchema.Msg("only parameter and collection schemas can be linted")
+	}
+	m := &schemamanager.SchemaMetadata{
+		Catalog:   reqCtx.Catalog,
+		Variant:   types.NullableStringFrom(reqCtx.Variant),
+		Namespace: types.NullableStringFrom(reqCtx.Namespace),
+	}
+	om, err := NewSchema(ctx, rsrcJson, m)
+	if err != nil {
+		return nil, err
+	}
+	j, err := om.ToJson(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	warnings := []LintWarning{}
+	for _, rule := range lintRules {
+		warnings = append(warnings, rule(ctx, om, j)...)
+	}
+
+	// deprecated parameter schemas can only be found when the request names where the schema would be saved
+	var dir Directories
+	if reqCtx.WorkspaceID != uuid.Nil {
+		dir, err = getDirectoriesForWorkspace(ctx, reqCtx.WorkspaceID)
+	} else if reqCtx.VariantID != uuid.Nil {
+		dir, err = getDirectoriesForVariant(ctx, reqCtx.VariantID)
+	}
+	if err == nil && !dir.IsNil() {
+		warnings = append(warnings, deprecatedParameterWarnings(ctx, om, dir)...)
+	}
+	return warnings, nil
+}
+
+// deprecatedParameterWarnings warns about each parameter of a collection schema whose parameter schema is
+// deprecated. Parameter schemas that cannot be resolved are left to validation.
+func deprecatedParameterWarnings(ctx context.Context, om schemamanager.SchemaManager, dir Directories) []LintWarning {
+	if om.Type() != types.CatalogObjectTypeCollectionSchema {
+		return nil
+	}
+	j, err := om.ToJson(ctx)
+	if err != nil {
+		return nil
+	}
+	loaders := getSchemaLoaders(ctx, om.Metadata(), WithDirectories(dir))
+	if loaders.ClosestParent == nil {
+		return nil
+	}
+	var warnings []LintWarning
+	gjson.GetBytes(j, "spec.parameters").ForEach(func(name, param gjson.Result) bool {
+		schemaName := param.Get("schema").String()
+		if schemaName == "" {
+			return true
+		}
+		p, _, err := loaders.ClosestParent(ctx, types.CatalogObjectTypeParameterSchema, schemaName)
+		if err != nil {
+			return true
+		}
+		ref, err := db.DB(ctx).GetObjectRefByPath(ctx, types.CatalogObjectTypeParameterSchema, dir.ParametersDir, p)
+		if err != nil || !ref.Deprecated {
+			return true
+		}
+		msg := "parameter " + name.String() + " uses deprecated parameter schema " + schemaName
+		if ref.DeprecationMessage != "" {
+			msg += ": " + ref.DeprecationMessage
+		}
+		warnings = append(warnings, LintWarning{
+			Rule:    "deprecated-parameter",
+			Field:   "spec.parameters." + name.String() + ".schema",
+			Message: msg,
+		})
+		return true
+	})
+	return warnings
+}
+
+func lintMissingDescription(ctx context.Context, om schemamanager.SchemaManager, schemaJson []byte) []LintWarning {
+	if om.Metadata().Description != "" {
+		return nil
+	}
+	msg := "schema has no description"
+	if om.Type() == types.CatalogObjectTypeParameterSchema {
+		msg = "parameter schema has no description; collection schemas that use it will not describe the parameter"
+	}
+	return []LintWarning{{
+		Rule:    "missing-description",
+		Field:   "metadata.description",
+		Message: msg,
+	}}
+}
+
+func lintEmptyCollectionSchema(ctx context.Context, om schemamanager.SchemaManager, schemaJson []byte) []LintWarning {
+	if om.Type() != types.CatalogObjectTypeCollectionSchema {
+		return nil
+	}
+	if params := gjson.GetBytes(schemaJson, "spec.parameters"); params.IsObject() && len(params.Map()) > 0 {
+		return nil
+	}
+	return []LintWarning{{
+		Rule:    "empty-collection-schema",
+		Field:   "spec.parameters",
+		Message: "collection schema has no parameters",
+	}}
+}
+
+func lintDefaultAtBoundary(ctx context.Context, om schemamanager.SchemaManager, schemaJson []byte) []LintWarning {
+	if om.Type() != types.CatalogObjectTypeParameterSchema {
+		return nil
+	}
+	def := gjson.GetBytes(schemaJson, "spec.default")
+	if !def.Exists() || def.Type != gjson.Number {
+		return nil
+	}
+	var warnings []LintWarning
+	for _, bound := range []string{"minValue", "maxValue"} {
+		if b := gjson.GetBytes(schemaJson, "spec.validation."+bound); b.Type == gjson.Number && b.Num == def.Num {
+			warnings = append(warnings, LintWarning{
+				Rule:    "default-at-boundary",
+				Field:   "spec.default",
+				Message: "default is equal to " + bound,
+			})
+		}
+	}
+	return warnings
+}
@@ -0,0 +1,31 @@
+package catalogmanager
+
+import (
+	"net/url"
+	"path"
+
+	"github.com/google/uuid"
+	"github.com/mugiliam/hatchcatalogsrv/pkg/types"
+)
+
+// objectLocation returns the URL of the object of type t with the fully qualified name fqn in namespace. An
+// object in a workspace is located by the label of the workspace, or by its ID if it has no label; an object
+// outside a workspace is read from the variant and carries no workspace. Query parameters are sorted by key.
+func objectLocation(t types.CatalogObjectType, fqn, namespace string, reqCtx RequestContext) string {
+	loc := path.Clean("/" + types.ResourceNameFromObjectType(t) + "/" + fqn)
+	q := url.Values{}
+	if reqCtx.Direct {
+		q.Set("direct", "true")
+	} else if reqCtx.WorkspaceLabel != "" {
+		q.Set("workspace", reqCtx.WorkspaceLabel)
+	} else if reqCtx.WorkspaceID != uuid.Nil {
+		q.Set("workspace_id", reqCtx.WorkspaceID.String())
+	}
+	if namespace != "" {
+		q.Set("namespace", namespace)
+	}
+	if qStr := q.Encode(); qStr != "" {
+		loc += "?" + qStr
+	}
+	return loc
+}
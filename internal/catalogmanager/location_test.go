@@ -0,0 +1,104 @@
+package catalogmanager
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/mugiliam/hatchcatalogsrv/pkg/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestObjectLocation(t *testing.T) {
+	workspaceID := uuid.MustParse("0b6f7a52-1c1e-4d36-9d7e-3a4b5c6d7e8f")
+	tests := []struct {
+		name      string
+		t         types.CatalogObjectType
+		fqn       string
+		namespace string
+		reqCtx    RequestContext
+		want      string
+	}{
+		{
+			name: "collection schema at root",
+			t:    types.CatalogObjectTypeCollectionSchema,
+			fqn:  "/my-schema",
+			want: "/collectionschemas/my-schema",
+		},
+		{
+			name: "parameter schema at root",
+			t:    types.CatalogObjectTypeParameterSchema,
+			fqn:  "/my-param",
+			want: "/parameterschemas/my-param",
+		},
+		{
+			name: "collection at root",
+			t:    types.CatalogObjectTypeCatalogCollection,
+			fqn:  "/my-collection",
+			want: "/collections/my-collection",
+		},
+		{
+			name: "nested path is cleaned",
+			t:    types.CatalogObjectTypeCatalogCollection,
+			fqn:  "//a/b//my-collection",
+			want: "/collections/a/b/my-collection",
+		},
+		{
+			name:      "namespace",
+			t:         types.CatalogObjectTypeParameterSchema,
+			fqn:       "/a/my-param",
+			namespace: "my-namespace",
+			want:      "/parameterschemas/a/my-param?namespace=my-namespace",
+		},
+		{
+			name:   "workspace label",
+			t:      types.CatalogObjectTypeCollectionSchema,
+			fqn:    "/my-schema",
+			reqCtx: RequestContext{WorkspaceLabel: "my-workspace", WorkspaceID: workspaceID},
+			want:   "/collectionschemas/my-schema?workspace=my-workspace",
+		},
+		{
+			name:   "workspace without label",
+			t:      types.CatalogObjectTypeCatalogCollection,
+			fqn:    "/my-collection",
+			reqCtx: RequestContext{WorkspaceID: workspaceID},
+			want:   "/collections/my-collection?workspace_id=" + workspaceID.String(),
+		},
+		{
+			name:      "workspace label and namespace",
+			t:         types.CatalogObjectTypeParameterSchema,
+			fqn:       "/my-param",
+			namespace: "my-namespace",
+			reqCtx:    RequestContext{WorkspaceLabel: "my-workspace"},
+			want:      "/parameterschemas/my-param?namespace=my-namespace&workspace=my-workspace",
+		},
+		{
+			name:      "workspace without label and namespace",
+			t:         types.CatalogObjectTypeCatalogCollection,
+			fqn:       "/a/my-collection",
+			namespace: "my-namespace",
+			reqCtx:    RequestContext{WorkspaceID: workspaceID},
+			want:      "/collections/a/my-collection?namespace=my-namespace&workspace_id=" + workspaceID.String(),
+		},
+		{
+			name:      "direct",
+			t:         types.CatalogObjectTypeParameterSchema,
+			fqn:       "/my-param",
+			namespace: "my-namespace",
+			reqCtx:    RequestContext{Direct: true},
+			want:      "/parameterschemas/my-param?direct=true&namespace=my-namespace",
+		},
+		{
+			name:      "query values are escaped",
+			t:         types.CatalogObjectTypeCollectionSchema,
+			fqn:       "/my-schema",
+			namespace: "a b",
+			reqCtx:    RequestContext{WorkspaceLabel: "x&y"},
+			want:      "/collectionschemas/my-schema?namespace=a+b&workspace=x%26y",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, objectLocation(tt.t, tt.fqn, tt.namespace, tt.reqCtx))
+		})
+	}
+}
@@ -9,6 +9,8 @@ import (
 	"github.com/mugiliam/hatchcatalogsrv/internal/catalogmanager/validationerrors"
 	"github.com/mugiliam/hatchcatalogsrv/pkg/types"
 	"github.com/rs/zerolog/log"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
 )
 
 // preventing undefined use warnings
@@ -80,6 +82,37 @@ func canonicalizeMetadata(rsrcJson []byte, kind string, metadata *schemamanager.
 	if m.Variant.IsNil() {
 		m.Variant = types.NullableStringFrom(types.DefaultVariant) // set default variant if nil
 	}
+	m.CanonicalizeNamespace() // an empty or --root-- namespace is the root namespace
+
+	// a parameter schema may also be deprecated in its spec. The flag is moved to the metadata so that the spec,
+	// and with it the hash of the schema, does not depend on it.
+	if spec, ok := fullMap["spec"]; ok && kind == types.ParameterSchemaKind {
+		if d := gjson.GetBytes(spec, "deprecated"); d.Exists() {
+			if d.Type != gjson.True && d.Type != gjson.False {
+				return nil, nil, validationerrors.ErrSchemaValidation.Msg("spec.deprecated must be a boolean")
+			}
+			m.Deprecated = m.Deprecated || d.Bool()
+			if msg := gjson.GetBytes(spec, "deprecationMessage"); msg.Exists() && m.DeprecationMessage == "" {
+				m.DeprecationMessage = msg.String()
+			}
+			spec, err = sjson.DeleteBytes(spec, "deprecated")
+			if err == nil {
+				spec, err = sjson.DeleteBytes(spec, "deprecationMessage")
+			}
+			if err != nil {
+				return nil, nil, validationerrors.ErrSchemaValidation.Msg("failed to read deprecation from spec")
+			}
+			fullMap["spec"] = spec
+		}
+	}
+	// units of parameters are moved to the metadata for the same reason
+	if spec, ok := fullMap["spec"]; ok {
+		spec, ves := m.TakeUnits(kind, spec)
+		if ves != nil {
+			return nil, nil, validationerrors.ErrSchemaValidation.Msg(ves.Error())
+		}
+		fullMap["spec"] = spec
+	}
 
 	// marshal updated metadata back to json
 	j, err := json.Marshal(m)
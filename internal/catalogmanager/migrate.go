@@ -0,0 +1,150 @@
+package catalogmanager
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sort"
+
+	"github.com/google/uuid"
+	"github.com/mugiliam/common/apperrors"
+	"github.com/mugiliam/hatchcatalogsrv/internal/db"
+	"github.com/mugiliam/hatchcatalogsrv/internal/db/dberror"
+	"github.com/mugiliam/hatchcatalogsrv/internal/db/models"
+	"github.com/mugiliam/hatchcatalogsrv/pkg/api/schemastore"
+	"github.com/mugiliam/hatchcatalogsrv/pkg/types"
+	"github.com/rs/zerolog/log"
+)
+
+// migrateObjectTypes are the types of objects rewritten by MigrateVariant
+var migrateObjectTypes = []types.CatalogObjectType{
+	types.CatalogObjectTypeParameterSchema,
+	types.CatalogObjectTypeCollectionSchema,
+}
+
+// MigrateVariant rewrites the parameter and collection schemas of the variant identified by reqCtx in the
+// current canonical form. Schemas are loaded, which migrates older stored representations, and saved again
+// under the hash of their current storage representation. Schemas that are already current are left alone.
+// It returns the number of schemas rewritten.
+func MigrateVariant(ctx context.Context, reqCtx RequestContext) (int, apperrors.Error) {
+	v, err := variantForRequest(ctx, reqCtx)
+	if err != nil {
+		return 0, err
+	}
+	catalog := reqCtx.Catalog
+	if catalog == "" {
+		c, err := db.DB(ctx).GetCatalog(ctx, v.CatalogID, "")
+		if err != nil {
+			return 0, ErrCatalogError.Err(err)
+		}
+		catalog = c.Name
+	}
+	dir, err := getDirectoriesForVariant(ctx, v.VariantID)
+	if err != nil {
+		return 0, err
+	}
+
+	namespaces := make(map[string]struct{})
+	nsList, err := db.DB(ctx).ListNamespacesByVariant(ctx, v.VariantID)
+	if err != nil {
+		return 0, ErrCatalogError.Err(err)
+	}
+	for _, ns := range nsList {
+		if ns.Name != types.RootNamespace() {
+			namespaces[ns.Name] = struct{}{}
+		}
+	}
+
+	migrated := 0
+	err = db.WithTx(ctx, func(ctx context.Context) apperrors.Error {
+		for _, t := range migrateObjectTypes {
+			n, err := migrateDirectory(ctx, t, dir.DirForType(t), catalog, v.Name, namespaces)
+			if err != nil {
+				return err
+			}
+			migrated += n
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	log.Ctx(ctx).Info().Str("variant", v.Name).Int("migrated", migrated).Msg("migrated variant to the current object format")
+	return migrated, nil
+}
+
+func migrateDirectory(ctx context.Context, t types.CatalogObjectType, dirID uuid.UUID, catalog, variant string, namespaces map[string]struct{}) (int, apperrors.Error) {
+	dirJson, err := db.DB(ctx).GetDirectory(ctx, t, dirID)
+	if err != nil {
+		return 0, ErrCatalogError.Err(err)
+	}
+	directory, jsonErr := models.JSONToDirectory(dirJson)
+	if jsonErr != nil {
+		return 0, ErrCatalogError.Err(jsonErr).Msg("failed to de-serialize directory")
+	}
+	paths := make([]string, 0, len(directory))
+	for p := range directory {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+
+	migrated := 0
+	for _, p := range paths {
+		ref := directory[p]
+		obj, err := db.DB(ctx).GetCatalogObject(ctx, ref.Hash)
+		if err != nil {
+			log.Ctx(ctx).Error().Err(err).Str("path", p).Str("hash", ref.Hash).Msg("failed to load catalog object")
+			return migrated, ErrUnableToLoadObject.Err(err)
+		}
+		s := &schemastore.SchemaStorageRepresentation{}
+		if jsonErr := json.Unmarshal(obj.Data, s); jsonErr != nil {
+			return migrated, ErrUnableToLoadObject.Err(jsonErr).Msg("failed to de-serialize catalog object data")
+		}
+
+		m := exportMetadataFromPath(p, namespaces)
+		m.Catalog = catalog
+		m.Variant = types.NullableStringFrom(variant)
+		m.Description = ref.Description
+		m.Deprecated = ref.Deprecated
+		m.DeprecationMessage = ref.DeprecationMessage
+		m.Units = ref.Units
+		m.ValuesExtends = ref.ValuesExtends
+
+		sm, err := loadSchemaManager(ctx, s, &m)
+		if err != nil {
+			log.Ctx(ctx).Error().Err(err).Str("path", p).Msg("failed to load schema for migration")
+			return migrated, err
+		}
+		// a description migrated out of the object is moved to the directory entry
+		sMeta := sm.Metadata()
+		if _, err := saveEntryMetadata(ctx, t, dirID, p, &sMeta); err != nil {
+			return migrated, err
+		}
+
+		current := sm.StorageRepresentation()
+		hash := current.GetHash()
+		if hash == ref.Hash {
+			continue
+		}
+		data, err := current.Serialize()
+		if err != nil {
+			return migrated, err
+		}
+		newObj := models.CatalogObject{
+			Type:    current.Type,
+			Version: current.Version,
+			Data:    data,
+			Hash:    hash,
+		}
+		if err := db.DB(ctx).CreateCatalogObject(ctx, &newObj); err != nil && !errors.Is(err, dberror.ErrAlreadyExists) {
+			log.Ctx(ctx).Error().Err(err).Str("path", p).Msg("failed to save migrated catalog object")
+			return migrated, err
+		}
+		if err := db.DB(ctx).UpdateObjectHashForPath(ctx, t, dirID, p, hash); err != nil {
+			log.Ctx(ctx).Error().Err(err).Str("path", p).Msg("failed to update directory entry of migrated object")
+			return migrated, err
+		}
+		migrated++
+	}
+	return migrated, nil
+}
@@ -0,0 +1,106 @@
+package catalogmanager
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/jackc/pgtype"
+	"github.com/mugiliam/hatchcatalogsrv/internal/catalogmanager/schemamanager"
+	"github.com/mugiliam/hatchcatalogsrv/internal/common"
+	"github.com/mugiliam/hatchcatalogsrv/internal/db"
+	"github.com/mugiliam/hatchcatalogsrv/internal/db/models"
+	"github.com/mugiliam/hatchcatalogsrv/pkg/api/schemastore"
+	"github.com/mugiliam/hatchcatalogsrv/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMigrateVariant(t *testing.T) {
+	ctx := newDb()
+	t.Cleanup(func() {
+		db.DB(ctx).Close(ctx)
+	})
+
+	tenantID := types.TenantId("TABCDE")
+	projectID := types.ProjectId("PDEFGH")
+	ctx = common.SetTenantIdInContext(ctx, tenantID)
+	ctx = common.SetProjectIdInContext(ctx, projectID)
+
+	var err error
+	err = db.DB(ctx).CreateTenant(ctx, tenantID)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		db.DB(ctx).DeleteTenant(ctx, tenantID)
+	})
+	err = db.DB(ctx).CreateProject(ctx, projectID)
+	require.NoError(t, err)
+
+	c := models.Catalog{
+		Name:      "migrate-catalog",
+		ProjectID: projectID,
+		Info:      pgtype.JSONB{Status: pgtype.Null},
+	}
+	err = db.DB(ctx).CreateCatalog(ctx, &c)
+	require.NoError(t, err)
+	variantID, err := db.DB(ctx).GetVariantIDFromName(ctx, c.CatalogID, types.DefaultVariant)
+	require.NoError(t, err)
+	dir, err := getDirectoriesForVariant(ctx, variantID)
+	require.NoError(t, err)
+
+	// a parameter schema stored by an earlier release: the description is in the object and the now optional
+	// validation and default are missing
+	legacyHash := strings.Repeat("c", 128)
+	err = db.DB(ctx).CreateCatalogObject(ctx, &models.CatalogObject{
+		Hash:    legacyHash,
+		Type:    types.CatalogObjectTypeParameterSchema,
+		Version: "v1",
+		Data:    []byte(`{"version":"v1","type":"parameter_schema","description":"stored with the object","schema":{"dataType":"Integer"}}`),
+	})
+	require.NoError(t, err)
+	storagePath := "/" + types.DefaultNamespace + "/legacy-param"
+	err = db.DB(ctx).AddOrUpdateObjectByPath(ctx, types.CatalogObjectTypeParameterSchema, dir.ParametersDir, storagePath, testObjectRef(legacyHash))
+	require.NoError(t, err)
+
+	m := schemamanager.SchemaMetadata{
+		Name:    "legacy-param",
+		Catalog: "migrate-catalog",
+		Path:    "/",
+		IDS:     schemamanager.IDS{CatalogID: c.CatalogID, VariantID: variantID},
+	}
+	// the object loads without being rewritten
+	sm, err := LoadSchemaByPath(ctx, types.CatalogObjectTypeParameterSchema, &m)
+	require.NoError(t, err)
+	assert.Equal(t, "stored with the object", sm.Description())
+	ref, err := db.DB(ctx).GetObjectRefByPath(ctx, types.CatalogObjectTypeParameterSchema, dir.ParametersDir, storagePath)
+	require.NoError(t, err)
+	assert.Equal(t, legacyHash, ref.Hash)
+
+	reqCtx := RequestContext{Catalog: c.Name, CatalogID: c.CatalogID, VariantID: variantID}
+	migrated, err := MigrateVariant(ctx, reqCtx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, migrated)
+
+	// the object is stored in the canonical form under its recomputed hash, and the description is moved to
+	// the directory entry
+	ref, err = db.DB(ctx).GetObjectRefByPath(ctx, types.CatalogObjectTypeParameterSchema, dir.ParametersDir, storagePath)
+	require.NoError(t, err)
+	assert.NotEqual(t, legacyHash, ref.Hash)
+	assert.Equal(t, "stored with the object", ref.Description)
+	assert.Equal(t, sm.StorageRepresentation().GetHash(), ref.Hash)
+	obj, err := db.DB(ctx).GetCatalogObject(ctx, ref.Hash)
+	require.NoError(t, err)
+	s := schemastore.SchemaStorageRepresentation{}
+	require.NoError(t, json.Unmarshal(obj.Data, &s))
+	assert.Empty(t, s.Description)
+	assert.JSONEq(t, `{"dataType":"Integer","validation":null,"default":null}`, string(s.Schema))
+
+	sm, err = LoadSchemaByPath(ctx, types.CatalogObjectTypeParameterSchema, &m)
+	require.NoError(t, err)
+	assert.Equal(t, "stored with the object", sm.Description())
+
+	// a migrated variant is left alone
+	migrated, err = MigrateVariant(ctx, reqCtx)
+	require.NoError(t, err)
+	assert.Equal(t, 0, migrated)
+}
@@ -14,6 +14,7 @@ import (
 	"github.com/mugiliam/hatchcatalogsrv/internal/catalogmanager/schemamanager"
 	"github.com/mugiliam/hatchcatalogsrv/internal/catalogmanager/validationerrors"
 	"github.com/mugiliam/hatchcatalogsrv/internal/common"
+	"github.com/mugiliam/hatchcatalogsrv/internal/config"
 	"github.com/mugiliam/hatchcatalogsrv/internal/db"
 	"github.com/mugiliam/hatchcatalogsrv/internal/db/dberror"
 	"github.com/mugiliam/hatchcatalogsrv/internal/db/models"
@@ -40,6 +41,17 @@ type namespaceManager struct {
 
 // var _ schemamanager.VariantManager = (*variantManager)(nil)
 
+// SetRootNamespaceFromConfig names the root namespace after the configured default namespace. The name must
+// follow the rules for the names of namespaces. It is called once at startup, see types.SetRootNamespace.
+func SetRootNamespaceFromConfig() apperrors.Error {
+	name := config.Config().DefaultNamespace
+	if name != "" && name != types.DefaultNamespace && !schemavalidator.ValidateNamespaceName(name) {
+		return ErrInvalidNamespace.Msg("invalid default namespace name: " + name)
+	}
+	types.SetRootNamespace(name)
+	return nil
+}
+
 func NewNamespaceManager(ctx context.Context, rsrcJson []byte, catalog string, variant string) (schemamanager.NamespaceManager, apperrors.Error) {
 	projectID := common.ProjectIdFromContext(ctx)
 	if projectID == "" {
@@ -59,6 +71,9 @@ func NewNamespaceManager(ctx context.Context, rsrcJson []byte, catalog string, v
 	if ves != nil {
 		return nil, ErrInvalidSchema.Err(ves)
 	}
+	if types.IsRootNamespace(ns.Metadata.Name) {
+		return nil, ErrInvalidNamespace.Msg("the name of the root namespace is reserved")
+	}
 
 	if catalog != "" {
 		if !schemavalidator.ValidateSchemaName(catalog) {
@@ -183,6 +198,21 @@ func (nm *namespaceManager) GetNamespaceModel() *models.Namespace {
 	return &nm.n
 }
 
+// checkNamespaceExists returns ErrNamespaceNotFound if the namespace ns is set and is not a namespace of the
+// variant. Objects saved under a namespace that does not exist would not be reachable from any namespace.
+func checkNamespaceExists(ctx context.Context, ns types.NullableString, variantID uuid.UUID) apperrors.Error {
+	if types.IsRootNamespace(ns.String()) {
+		return nil
+	}
+	if _, err := db.DB(ctx).GetNamespace(ctx, ns.String(), variantID); err != nil {
+		if errors.Is(err, dberror.ErrNotFound) {
+			return ErrNamespaceNotFound.Msg("namespace " + ns.String() + " does not exist")
+		}
+		return ErrCatalogError.Err(err)
+	}
+	return nil
+}
+
 func LoadNamespaceManagerByName(ctx context.Context, variantID uuid.UUID, name string) (schemamanager.NamespaceManager, apperrors.Error) {
 	if variantID == uuid.Nil {
 		return nil, ErrInvalidVariant
@@ -0,0 +1,287 @@
+package catalogmanager
+
+import (
+	"context"
+	"io"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgtype"
+	"github.com/mugiliam/common/apperrors"
+	"github.com/mugiliam/hatchcatalogsrv/internal/catalogmanager/schemamanager"
+	"github.com/mugiliam/hatchcatalogsrv/internal/db"
+	"github.com/mugiliam/hatchcatalogsrv/internal/db/models"
+	"github.com/mugiliam/hatchcatalogsrv/pkg/types"
+	"github.com/rs/zerolog/log"
+	"github.com/tidwall/gjson"
+)
+
+// NamespaceContentsOptions control how ReplaceNamespaceContents converges a namespace
+type NamespaceContentsOptions struct {
+	Prune bool // delete the objects of the namespace that are not in the archive
+}
+
+// NamespaceChange is a change made to an object of a namespace. Path is the path of the object in the
+// namespace.
+type NamespaceChange struct {
+	Kind   string       `json:"kind"`
+	Path   string       `json:"path"`
+	Action ChangeAction `json:"action"`
+}
+
+// NamespaceContentsSummary reports the outcome of replacing the contents of a namespace. Changes is empty if
+// the namespace already had the contents of the archive. If a manifest could not be applied, nothing is
+// changed and Results has the manifests that failed.
+type NamespaceContentsSummary struct {
+	Namespace  string            `json:"namespace"`
+	RolledBack bool              `json:"rolledBack"`
+	Changes    []NamespaceChange `json:"changes"`
+	Results    []ImportResult    `json:"results,omitempty"`
+}
+
+// namespaceObject is an object of the namespace, either in the archive or in the variant
+type namespaceObject struct {
+	t        types.CatalogObjectType
+	name     string
+	path     string
+	manifest *importManifest
+}
+
+// ReplaceNamespaceContents makes the contents of the namespace in reqCtx those of a gzip compressed tarball of
+// manifests, in the format read by ImportVariant. Objects that are not in the namespace are created, and those
+// that are, are updated. With opts.Prune, the objects of the namespace that are not in the archive are deleted.
+// The changes are made in a fresh workspace that is committed to the variant once all of them are applied, so
+// either all of them are made or none are.
+func ReplaceNamespaceContents(ctx context.Context, r io.Reader, reqCtx RequestContext, opts NamespaceContentsOptions) (*NamespaceContentsSummary, apperrors.Error) {
+	if reqCtx.Catalog == "" || reqCtx.CatalogID == uuid.Nil {
+		return nil, ErrInvalidCatalog
+	}
+	if reqCtx.Variant == "" || reqCtx.VariantID == uuid.Nil {
+		return nil, ErrInvalidVariant
+	}
+	// the objects of the root namespace are stored above those of the other namespaces, so its contents
+	// cannot be told apart by path
+	if reqCtx.Namespace == "" || types.IsRootNamespace(reqCtx.Namespace) {
+		return nil, ErrInvalidNamespace.Msg("the contents of the root namespace cannot be replaced")
+	}
+	if err := checkNamespaceExists(ctx, types.NullableStringFrom(reqCtx.Namespace), reqCtx.VariantID); err != nil {
+		return nil, err
+	}
+
+	summary := &NamespaceContentsSummary{Namespace: reqCtx.Namespace, Changes: []NamespaceChange{}}
+	importSummary := &ImportSummary{}
+	manifests, err := readImportArchive(r, importSummary)
+	if err != nil {
+		return nil, err
+	}
+	wanted, failed := namespaceManifests(manifests, reqCtx.Namespace)
+	summary.Results = append(importSummary.Results, failed...)
+	if len(summary.Results) > 0 {
+		// nothing has been applied yet
+		summary.RolledBack = true
+		return summary, nil
+	}
+
+	wm := &workspaceManager{
+		w: models.Workspace{
+			Description: "contents of namespace " + reqCtx.Namespace,
+			Info:        pgtype.JSONB{Status: pgtype.Null},
+			VariantID:   reqCtx.VariantID,
+			BaseVersion: 1,
+		},
+	}
+	if err := wm.Save(ctx); err != nil {
+		return nil, err
+	}
+	rc := reqCtx
+	rc.WorkspaceID = wm.ID()
+	rc.WorkspaceLabel = ""
+
+	changes, result, err := applyNamespaceContents(ctx, rc, wanted, opts)
+	if err == nil && result == nil {
+		if dbErr := db.DB(ctx).CommitWorkspace(ctx, &wm.w); dbErr != nil {
+			log.Ctx(ctx).Error().Err(dbErr).Str("namespace", reqCtx.Namespace).Msg("failed to commit namespace contents")
+			err = ErrCatalogError.Msg("unable to commit workspace")
+		}
+	}
+	if err != nil || result != nil {
+		if delErr := DeleteWorkspace(ctx, wm.ID()); delErr != nil {
+			log.Ctx(ctx).Error().Err(delErr).Str("workspace", wm.ID().String()).Msg("failed to roll back namespace contents workspace")
+		}
+		if err != nil {
+			return nil, err
+		}
+		summary.RolledBack = true
+		summary.Results = append(summary.Results, *result)
+		return summary, nil
+	}
+	summary.Changes = changes
+	return summary, nil
+}
+
+// namespaceManifests returns the objects of the manifests by type and storage path in namespace ns, and
+// results for the manifests that name the same object as an earlier one
+func namespaceManifests(manifests []importManifest, ns string) (map[types.CatalogObjectType]map[string]namespaceObject, []ImportResult) {
+	objects := make(map[types.CatalogObjectType]map[string]namespaceObject, len(directoryTypes))
+	for _, t := range directoryTypes {
+		objects[t] = make(map[string]namespaceObject)
+	}
+	var failed []ImportResult
+	for i := range manifests {
+		mf := &manifests[i]
+		o := namespaceObject{
+			t:        types.CatalogObjectTypeFromKind(mf.kind),
+			name:     gjson.GetBytes(mf.json, "metadata.name").String(),
+			path:     path.Clean("/" + gjson.GetBytes(mf.json, "metadata.path").String()),
+			manifest: mf,
+		}
+		p := o.storagePath(ns)
+		if _, ok := objects[o.t][p]; ok {
+			failed = append(failed, ImportResult{File: mf.file, Kind: mf.kind, Error: "more than one manifest for " + path.Join(o.path, o.name)})
+			continue
+		}
+		objects[o.t][p] = o
+	}
+	return objects, failed
+}
+
+func (o namespaceObject) storagePath(ns string) string {
+	m := schemamanager.SchemaMetadata{
+		Namespace: types.NullableStringFrom(ns),
+		Path:      o.path,
+		Name:      o.name,
+	}
+	return path.Clean(m.GetStoragePath(o.t) + "/" + o.name)
+}
+
+// applyNamespaceContents creates or updates the objects in wanted in the workspace of reqCtx and, with
+// opts.Prune, deletes the other objects of the namespace. It returns the changes made to the namespace, or the
+// result of the manifest or object that could not be applied.
+func applyNamespaceContents(ctx context.Context, reqCtx RequestContext, wanted map[types.CatalogObjectType]map[string]namespaceObject, opts NamespaceContentsOptions) ([]NamespaceChange, *ImportResult, apperrors.Error) {
+	dir, err := getDirectoriesForWorkspace(ctx, reqCtx.WorkspaceID)
+	if err != nil {
+		return nil, nil, err
+	}
+	prefix := "/" + types.RootNamespace() + "/" + reqCtx.Namespace + "/"
+	before, err := loadNamespaceDirectories(ctx, dir, prefix)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// objects are created and updated in dependency order, and deleted in the reverse order
+	for _, t := range directoryTypes {
+		for _, p := range sortedObjectPaths(wanted[t]) {
+			o := wanted[t][p]
+			result := &ImportResult{File: o.manifest.file, Kind: o.manifest.kind}
+			rc := reqCtx
+			_, exists := before[t][p]
+			if exists {
+				rc.ObjectType, rc.ObjectPath, rc.ObjectName = t, o.path, o.name
+			}
+			rm, err := ResourceManagerForKind(ctx, o.manifest.kind, rc)
+			if err == nil {
+				if exists {
+					if err = rm.Update(ctx, o.manifest.json); err == nil {
+						result.Location = rm.Location()
+					}
+				} else {
+					result.Location, err = rm.Create(ctx, o.manifest.json)
+				}
+			}
+			if err != nil {
+				result.Error = err.Error()
+				return nil, result, nil
+			}
+		}
+	}
+
+	if opts.Prune {
+		for i := len(directoryTypes) - 1; i >= 0; i-- {
+			t := directoryTypes[i]
+			for _, p := range sortedPaths(before[t]) {
+				if _, ok := wanted[t][p]; ok {
+					continue
+				}
+				rc := reqCtx
+				rc.ObjectType = t
+				rc.ObjectPath, rc.ObjectName = path.Split(strings.TrimPrefix(p, strings.TrimSuffix(prefix, "/")))
+				rc.ObjectPath = path.Clean("/" + rc.ObjectPath)
+				rm, err := ResourceManagerForKind(ctx, types.Kind(t), rc)
+				if err == nil {
+					err = rm.Delete(ctx)
+				}
+				if err != nil {
+					return nil, &ImportResult{Kind: types.Kind(t), Error: "unable to delete " + path.Join(rc.ObjectPath, rc.ObjectName) + ": " + err.Error()}, nil
+				}
+			}
+		}
+	}
+
+	after, err := loadNamespaceDirectories(ctx, dir, prefix)
+	if err != nil {
+		return nil, nil, err
+	}
+	return namespaceChanges(before, after, prefix), nil, nil
+}
+
+// loadNamespaceDirectories returns the entries of the directories in dir whose path starts with prefix
+func loadNamespaceDirectories(ctx context.Context, dir Directories, prefix string) (map[types.CatalogObjectType]models.Directory, apperrors.Error) {
+	dirs := make(map[types.CatalogObjectType]models.Directory, len(directoryTypes))
+	for _, t := range directoryTypes {
+		d, err := loadDirectory(ctx, t, dir.DirForType(t))
+		if err != nil {
+			return nil, err
+		}
+		dirs[t] = models.Directory{}
+		for p, ref := range d {
+			if strings.HasPrefix(p, prefix) {
+				dirs[t][p] = ref
+			}
+		}
+	}
+	return dirs, nil
+}
+
+// namespaceChanges returns the objects that were added, modified or deleted between before and after, in the
+// order of directoryTypes and then of path. Changes to references alone are not changes to the object.
+func namespaceChanges(before, after map[types.CatalogObjectType]models.Directory, prefix string) []NamespaceChange {
+	changes := []NamespaceChange{}
+	for _, t := range directoryTypes {
+		all := models.Directory{}
+		for p, ref := range before[t] {
+			all[p] = ref
+		}
+		for p, ref := range after[t] {
+			all[p] = ref
+		}
+		for _, p := range sortedPaths(all) {
+			var b, a *models.ObjectRef
+			if ref, ok := before[t][p]; ok {
+				b = &ref
+			}
+			if ref, ok := after[t][p]; ok {
+				a = &ref
+			}
+			if sameContent(b, a) {
+				continue
+			}
+			changes = append(changes, NamespaceChange{
+				Kind:   types.Kind(t),
+				Path:   "/" + strings.TrimPrefix(p, prefix),
+				Action: pathChange{base: b, result: a}.action(),
+			})
+		}
+	}
+	return changes
+}
+
+func sortedObjectPaths(objects map[string]namespaceObject) []string {
+	paths := make([]string, 0, len(objects))
+	for p := range objects {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	return paths
+}
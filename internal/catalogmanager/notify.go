@@ -0,0 +1,29 @@
+package catalogmanager
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/mugiliam/hatchcatalogsrv/internal/catalogmanager/schemamanager"
+	"github.com/mugiliam/hatchcatalogsrv/internal/webhook"
+	"github.com/mugiliam/hatchcatalogsrv/pkg/types"
+)
+
+// notifyObjectChange emits a webhook event for a successfully stored or deleted object.
+// Delivery is asynchronous and never affects the outcome of the originating request.
+func notifyObjectChange(ctx context.Context, action string, t types.CatalogObjectType, pathWithName string,
+	m *schemamanager.SchemaMetadata, dir Directories, hash string) {
+
+	e := webhook.Event{
+		Action:     action,
+		ObjectType: string(t),
+		Path:       pathWithName,
+		Catalog:    m.Catalog,
+		Variant:    m.Variant.String(),
+		Hash:       hash,
+	}
+	if dir.WorkspaceID != uuid.Nil {
+		e.Workspace = dir.WorkspaceID.String()
+	}
+	webhook.Notify(ctx, e)
+}
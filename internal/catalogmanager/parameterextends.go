@@ -0,0 +1,98 @@
+package catalogmanager
+
+import (
+	"context"
+	"errors"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/mugiliam/common/apperrors"
+	"github.com/mugiliam/hatchcatalogsrv/internal/catalogmanager/schemamanager"
+	"github.com/mugiliam/hatchcatalogsrv/pkg/types"
+	"github.com/rs/zerolog/log"
+)
+
+// maxExtendsDepth is the longest chain of parameter schemas extending one another that is followed
+const maxExtendsDepth = 32
+
+// resolveParameterSchemaExtends merges the parameter schema that om extends, if it extends one, into om. The
+// base is found from the path of om the same way as the schema of a parameter of a collection schema. The
+// stored spec of the base already has its own base merged into it, so the merged spec of om is complete and
+// is what is hashed. The chain of bases is still followed, so that a base that extends om, directly or
+// through other schemas, is rejected.
+func resolveParameterSchemaExtends(ctx context.Context, om schemamanager.SchemaManager, dir Directories) apperrors.Error {
+	pm := om.ParameterSchemaManager()
+	if pm == nil || pm.Extends() == "" {
+		return nil
+	}
+	m := om.Metadata()
+	self := path.Clean(m.GetStoragePath(types.CatalogObjectTypeParameterSchema) + "/" + m.Name)
+
+	base, basePath, err := loadExtendedParameterSchema(ctx, m, dir, pm.Extends())
+	if err != nil {
+		return err
+	}
+	chain := []string{self}
+	next, nextPath := base, basePath
+	for {
+		cyclic := slices.Contains(chain, nextPath)
+		chain = append(chain, nextPath)
+		if cyclic {
+			log.Ctx(ctx).Info().Str("path", self).Strs("chain", chain).Msg("cyclic extends in parameter schema")
+			return ErrCyclicExtends.Msg("parameter schema " + m.Name + " extends itself through " + strings.Join(chain, " -> "))
+		}
+		if len(chain) > maxExtendsDepth {
+			return ErrInvalidExtends.Msg("parameter schema " + m.Name + " extends a chain of more than " + strconv.Itoa(maxExtendsDepth) + " parameter schemas")
+		}
+		e := next.ParameterSchemaManager().Extends()
+		if e == "" {
+			break
+		}
+		next, nextPath, err = loadExtendedParameterSchema(ctx, next.Metadata(), dir, e)
+		if errors.Is(err, ErrInvalidExtends) {
+			// a base further up the chain was deleted after it was merged, which ends the chain
+			break
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return pm.ExtendSpec(base.ParameterSchemaManager())
+}
+
+// loadExtendedParameterSchema loads the parameter schema named name that the parameter schema described by m
+// extends, along with its storage path
+func loadExtendedParameterSchema(ctx context.Context, m schemamanager.SchemaMetadata, dir Directories, name string) (schemamanager.SchemaManager, string, apperrors.Error) {
+	loaders := getSchemaLoaders(ctx, m, WithDirectories(dir))
+	schemaPath, hash, err := loaders.ClosestParent(ctx, types.CatalogObjectTypeParameterSchema, name)
+	if err != nil {
+		if errors.Is(err, ErrObjectNotFound) {
+			return nil, "", ErrInvalidExtends.Msg("parameter schema " + name + " extended by " + m.Name + " does not exist")
+		}
+		return nil, "", err
+	}
+
+	namespaces := make(map[string]struct{})
+	if !m.Namespace.IsNil() {
+		namespaces[m.Namespace.String()] = struct{}{}
+	}
+	if ns, _ := schemamanager.SplitSchemaRef(name); ns != "" {
+		namespaces = map[string]struct{}{ns: {}}
+	}
+	bm := exportMetadataFromPath(schemaPath, namespaces)
+	bm.Catalog = m.Catalog
+	bm.Variant = m.Variant
+	bm.IDS = m.IDS
+	sm, err := LoadSchemaByHash(ctx, hash, &bm)
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Str("path", schemaPath).Msg("failed to load extended parameter schema")
+		return nil, "", err
+	}
+	if sm.ParameterSchemaManager() == nil {
+		return nil, "", ErrInvalidExtends.Msg(name + " is not a parameter schema")
+	}
+	return sm, schemaPath, nil
+}
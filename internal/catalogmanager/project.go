@@ -0,0 +1,87 @@
+package catalogmanager
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"unicode/utf8"
+
+	"github.com/mugiliam/common/apperrors"
+	"github.com/mugiliam/hatchcatalogsrv/internal/common"
+	"github.com/mugiliam/hatchcatalogsrv/internal/db"
+	"github.com/mugiliam/hatchcatalogsrv/internal/db/dberror"
+	"github.com/mugiliam/hatchcatalogsrv/internal/db/models"
+	"github.com/mugiliam/hatchcatalogsrv/pkg/types"
+	"github.com/rs/zerolog/log"
+)
+
+// maxProjectDescriptionLength is the size of the description column of the projects table
+const maxProjectDescriptionLength = 1024
+
+// Project is the representation of a project in responses
+type Project struct {
+	ProjectID   types.ProjectId `json:"projectId"`
+	Description string          `json:"description,omitempty"`
+}
+
+// ProjectList is the response of a request to list the projects of a tenant
+type ProjectList struct {
+	Projects []Project `json:"projects"`
+}
+
+// projectUpdate is the body of a request to update a project
+type projectUpdate struct {
+	Description string `json:"description"`
+}
+
+// ListProjects returns the projects of the tenant in the context ordered by project ID
+func ListProjects(ctx context.Context) ([]byte, apperrors.Error) {
+	tenantID := common.TenantIdFromContext(ctx)
+	if tenantID == "" {
+		return nil, ErrInvalidProject.Msg("tenant is required")
+	}
+	projects, err := db.DB(ctx).ListProjects(ctx, tenantID)
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Str("tenant_id", string(tenantID)).Msg("failed to list projects")
+		return nil, ErrCatalogError.Msg("unable to list projects")
+	}
+
+	list := ProjectList{Projects: make([]Project, 0, len(projects))}
+	for _, p := range projects {
+		list.Projects = append(list.Projects, Project{
+			ProjectID:   p.ProjectID,
+			Description: p.Description,
+		})
+	}
+	j, jsonErr := json.Marshal(list)
+	if jsonErr != nil {
+		return nil, ErrCatalogError.Msg("unable to marshal projects")
+	}
+	return j, nil
+}
+
+// UpdateProject sets the description of the project of the tenant in the context from rsrcJson
+func UpdateProject(ctx context.Context, projectID types.ProjectId, rsrcJson []byte) apperrors.Error {
+	if projectID == "" {
+		return ErrInvalidProject
+	}
+	var u projectUpdate
+	if err := json.Unmarshal(rsrcJson, &u); err != nil {
+		return ErrInvalidRequest.Msg("unable to parse project")
+	}
+	if utf8.RuneCountInString(u.Description) > maxProjectDescriptionLength {
+		return ErrInvalidProject.Msg("description is too long")
+	}
+
+	if err := db.DB(ctx).UpdateProject(ctx, &models.Project{
+		ProjectID:   projectID,
+		Description: u.Description,
+	}); err != nil {
+		if errors.Is(err, dberror.ErrNotFound) {
+			return ErrProjectNotFound
+		}
+		log.Ctx(ctx).Error().Err(err).Str("project_id", string(projectID)).Msg("failed to update project")
+		return ErrUnableToUpdateObject.Msg("failed to update project")
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+package catalogmanager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/mugiliam/common/apperrors"
+	"github.com/mugiliam/hatchcatalogsrv/internal/config"
+	"github.com/mugiliam/hatchcatalogsrv/internal/db"
+	"github.com/mugiliam/hatchcatalogsrv/internal/db/dberror"
+	"github.com/rs/zerolog/log"
+)
+
+// tenantQuota returns the quotas of the tenant in the context: the configured ones, with the overrides of the
+// tenant applied
+func tenantQuota(ctx context.Context) (config.QuotaConfig, apperrors.Error) {
+	var q config.QuotaConfig
+	if cfg := config.Config(); cfg != nil {
+		q = cfg.Quotas
+	}
+	o, err := db.DB(ctx).GetTenantQuota(ctx)
+	if err != nil {
+		if errors.Is(err, dberror.ErrNotFound) {
+			return q, nil
+		}
+		log.Ctx(ctx).Error().Err(err).Msg("failed to load tenant quota")
+		return q, ErrCatalogError.Err(err)
+	}
+	if o.MaxCatalogsPerProject != nil {
+		q.MaxCatalogsPerProject = *o.MaxCatalogsPerProject
+	}
+	if o.MaxObjectsPerVariant != nil {
+		q.MaxObjectsPerVariant = *o.MaxObjectsPerVariant
+	}
+	if o.MaxBytesPerTenant != nil {
+		q.MaxBytesPerTenant = *o.MaxBytesPerTenant
+	}
+	return q, nil
+}
+
+func quotaExceeded(limit string, value int64) apperrors.Error {
+	return ErrQuotaExceeded.Msg(fmt.Sprintf("%s of %d exceeded", limit, value))
+}
+
+// checkCatalogQuota returns ErrQuotaExceeded if the project in the context cannot have another catalog
+func checkCatalogQuota(ctx context.Context) apperrors.Error {
+	q, err := tenantQuota(ctx)
+	if err != nil {
+		return err
+	}
+	if q.MaxCatalogsPerProject <= 0 {
+		return nil
+	}
+	n, err := db.DB(ctx).CountCatalogsInProject(ctx)
+	if err != nil {
+		return ErrCatalogError.Err(err)
+	}
+	if n >= q.MaxCatalogsPerProject {
+		return quotaExceeded("max_catalogs_per_project", int64(q.MaxCatalogsPerProject))
+	}
+	return nil
+}
+
+// checkObjectQuota returns ErrQuotaExceeded if an object of size bytes cannot be saved in the variant.
+// isNew is false when the object replaces one at the same path, which does not change the number of
+// objects in the variant.
+func checkObjectQuota(ctx context.Context, variantID uuid.UUID, isNew bool, size int) apperrors.Error {
+	q, err := tenantQuota(ctx)
+	if err != nil {
+		return err
+	}
+	if isNew && q.MaxObjectsPerVariant > 0 && variantID != uuid.Nil {
+		s, err := db.DB(ctx).GetVariantStats(ctx, variantID)
+		if err != nil {
+			return ErrCatalogError.Err(err)
+		}
+		if s.ParameterSchemas+s.CollectionSchemas+s.Collections >= q.MaxObjectsPerVariant {
+			return quotaExceeded("max_objects_per_variant", int64(q.MaxObjectsPerVariant))
+		}
+	}
+	if q.MaxBytesPerTenant > 0 {
+		n, err := db.DB(ctx).GetTenantObjectBytes(ctx)
+		if err != nil {
+			return ErrCatalogError.Err(err)
+		}
+		if n+int64(size) > q.MaxBytesPerTenant {
+			return quotaExceeded("max_bytes_per_tenant", q.MaxBytesPerTenant)
+		}
+	}
+	return nil
+}
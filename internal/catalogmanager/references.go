@@ -0,0 +1,228 @@
+package catalogmanager
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sort"
+
+	"github.com/google/uuid"
+	"github.com/mugiliam/common/apperrors"
+	"github.com/mugiliam/hatchcatalogsrv/internal/catalogmanager/schemamanager"
+	"github.com/mugiliam/hatchcatalogsrv/internal/db"
+	"github.com/mugiliam/hatchcatalogsrv/internal/db/dberror"
+	"github.com/mugiliam/hatchcatalogsrv/internal/db/models"
+	"github.com/mugiliam/hatchcatalogsrv/pkg/types"
+	"github.com/rs/zerolog/log"
+)
+
+// ReferenceMismatch is a directory entry whose stored references differ from the references computed from the
+// collection schemas
+type ReferenceMismatch struct {
+	Kind     string   `json:"kind"`
+	Path     string   `json:"path"`
+	Stored   []string `json:"stored"`
+	Expected []string `json:"expected"`
+}
+
+// ReferenceReport is the result of checking the references between the collection and parameter schemas of a
+// workspace or variant
+type ReferenceReport struct {
+	Mismatches []ReferenceMismatch `json:"mismatches"`
+	// Unresolved are the collection schemas whose parameter schemas could not be resolved. Their stored
+	// references to existing parameter schemas are taken as correct.
+	Unresolved []string `json:"unresolved,omitempty"`
+}
+
+// ReferenceDirectories returns the directories of the workspace, or else the variant, of reqCtx
+func ReferenceDirectories(ctx context.Context, reqCtx RequestContext) (Directories, apperrors.Error) {
+	if reqCtx.WorkspaceID != uuid.Nil {
+		wm, err := LoadWorkspaceManagerByID(ctx, reqCtx.WorkspaceID)
+		if err != nil {
+			return Directories{}, err
+		}
+		dir, err := getDirectoriesForWorkspace(ctx, reqCtx.WorkspaceID)
+		if err != nil {
+			return dir, err
+		}
+		// parameter schemas qualified by a namespace are resolved in the namespaces of the variant
+		dir.VariantID = wm.VariantID()
+		return dir, nil
+	}
+	v, err := variantForRequest(ctx, reqCtx)
+	if err != nil {
+		return Directories{}, err
+	}
+	return getDirectoriesForVariant(ctx, v.VariantID)
+}
+
+// VerifyReferences checks the references kept in the directory entries of the collection and parameter
+// schemas in dir. A collection schema references the parameter schemas its parameters resolve to, and a
+// parameter schema references the collection schemas that use it. The expected references are computed from
+// the specs of the collection schemas and every entry that differs is reported.
+func VerifyReferences(ctx context.Context, dir Directories) (*ReferenceReport, apperrors.Error) {
+	report, _, err := computeReferences(ctx, dir)
+	return report, err
+}
+
+// RepairReferences rewrites the references of the entries reported by VerifyReferences to the computed ones in
+// a single transaction. It returns the mismatches that were repaired.
+func RepairReferences(ctx context.Context, dir Directories) (*ReferenceReport, apperrors.Error) {
+	var report *ReferenceReport
+	err := db.WithTx(ctx, func(ctx context.Context) apperrors.Error {
+		var directories map[types.CatalogObjectType]models.Directory
+		var err apperrors.Error
+		if report, directories, err = computeReferences(ctx, dir); err != nil {
+			return err
+		}
+		for _, mm := range report.Mismatches {
+			t := types.CatalogObjectTypeCollectionSchema
+			if mm.Kind == types.ParameterSchemaKind {
+				t = types.CatalogObjectTypeParameterSchema
+			}
+			ref := directories[t][mm.Path]
+			ref.References = models.References{}
+			for _, name := range mm.Expected {
+				ref.References = append(ref.References, models.Reference{Name: name})
+			}
+			if err := db.DB(ctx).AddOrUpdateObjectByPath(ctx, t, dir.DirForType(t), mm.Path, ref); err != nil {
+				log.Ctx(ctx).Error().Err(err).Str("path", mm.Path).Msg("failed to repair references")
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(report.Mismatches) > 0 {
+		log.Ctx(ctx).Info().Int("repaired", len(report.Mismatches)).Msg("repaired schema references")
+	}
+	return report, nil
+}
+
+// computeReferences compares the stored references of the schemas in dir with the references computed from
+// the collection schemas. It also returns the directories it loaded.
+func computeReferences(ctx context.Context, dir Directories) (*ReferenceReport, map[types.CatalogObjectType]models.Directory, apperrors.Error) {
+	params, err := loadDirectory(ctx, types.CatalogObjectTypeParameterSchema, dir.ParametersDir)
+	if err != nil {
+		return nil, nil, err
+	}
+	collections, err := loadDirectory(ctx, types.CatalogObjectTypeCollectionSchema, dir.CollectionsDir)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	namespaces := make(map[string]struct{})
+	nsList, nsErr := db.DB(ctx).ListNamespacesByVariant(ctx, dir.VariantID)
+	if nsErr != nil && !errors.Is(nsErr, dberror.ErrNotFound) {
+		return nil, nil, ErrCatalogError.Err(nsErr)
+	}
+	for _, ns := range nsList {
+		if ns.Name != types.RootNamespace() {
+			namespaces[ns.Name] = struct{}{}
+		}
+	}
+
+	report := &ReferenceReport{Mismatches: []ReferenceMismatch{}}
+	expectedCollectionRefs := make(map[string][]string, len(collections))
+	expectedParamRefs := make(map[string][]string, len(params))
+	for _, p := range sortedPaths(collections) {
+		refs, resolved, err := collectionSchemaReferences(ctx, dir, p, collections[p], params, namespaces)
+		if err != nil {
+			return nil, nil, err
+		}
+		if !resolved {
+			report.Unresolved = append(report.Unresolved, p)
+		}
+		expectedCollectionRefs[p] = refs
+		for _, r := range refs {
+			expectedParamRefs[r] = append(expectedParamRefs[r], p)
+		}
+	}
+
+	compare := func(kind string, directory models.Directory, expected map[string][]string) {
+		for _, p := range sortedPaths(directory) {
+			stored := referenceNames(directory[p].References)
+			want := expected[p]
+			if want == nil {
+				want = []string{}
+			}
+			sort.Strings(want)
+			if !slices.Equal(stored, want) {
+				report.Mismatches = append(report.Mismatches, ReferenceMismatch{
+					Kind:     kind,
+					Path:     p,
+					Stored:   stored,
+					Expected: want,
+				})
+			}
+		}
+	}
+	compare(types.CollectionSchemaKind, collections, expectedCollectionRefs)
+	compare(types.ParameterSchemaKind, params, expectedParamRefs)
+
+	return report, map[types.CatalogObjectType]models.Directory{
+		types.CatalogObjectTypeParameterSchema:  params,
+		types.CatalogObjectTypeCollectionSchema: collections,
+	}, nil
+}
+
+// collectionSchemaReferences returns the paths of the parameter schemas that the parameters of the collection
+// schema at p resolve to, the same way they are resolved when the schema is saved. A parameter stays bound to
+// the parameter schema in its stored references as long as that schema exists. If the parameters cannot be
+// resolved, the stored references to existing parameter schemas are returned and resolved is false.
+func collectionSchemaReferences(ctx context.Context, dir Directories, p string, ref models.ObjectRef, params models.Directory, namespaces map[string]struct{}) (refs []string, resolved bool, err apperrors.Error) {
+	var existingRefs schemamanager.SchemaReferences
+	for _, r := range ref.References {
+		if _, ok := params[r.Name]; ok {
+			existingRefs = append(existingRefs, schemamanager.SchemaReference{Name: r.Name})
+		}
+	}
+
+	m := exportMetadataFromPath(p, namespaces)
+	m.IDS.VariantID = dir.VariantID
+	sm, err := LoadSchemaByHash(ctx, ref.Hash, &m)
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Str("path", p).Msg("failed to load collection schema")
+		return nil, false, err
+	}
+
+	var newRefs schemamanager.SchemaReferences
+	resolved = true
+	if cm := sm.CollectionSchemaManager(); cm != nil {
+		loaders := getSchemaLoaders(ctx, m, WithDirectories(dir), SkipCanonicalizePaths())
+		var verr apperrors.Error
+		if newRefs, verr = cm.ValidateDependencies(ctx, loaders, existingRefs); verr != nil {
+			log.Ctx(ctx).Warn().Err(verr).Str("path", p).Msg("unable to resolve parameter schemas of collection schema")
+			newRefs, resolved = existingRefs, false
+		}
+	}
+
+	refs = []string{}
+	for _, r := range newRefs {
+		if !slices.Contains(refs, r.Name) {
+			refs = append(refs, r.Name)
+		}
+	}
+	sort.Strings(refs)
+	return refs, resolved, nil
+}
+
+func referenceNames(refs models.References) []string {
+	names := make([]string, 0, len(refs))
+	for _, r := range refs {
+		names = append(names, r.Name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func sortedPaths(directory models.Directory) []string {
+	paths := make([]string, 0, len(directory))
+	for p := range directory {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	return paths
+}
@@ -0,0 +1,114 @@
+package catalogmanager
+
+import (
+	"testing"
+
+	"github.com/jackc/pgtype"
+	"github.com/mugiliam/hatchcatalogsrv/internal/common"
+	"github.com/mugiliam/hatchcatalogsrv/internal/db"
+	"github.com/mugiliam/hatchcatalogsrv/internal/db/models"
+	"github.com/mugiliam/hatchcatalogsrv/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerifyAndRepairReferences(t *testing.T) {
+	ctx := newDb()
+	t.Cleanup(func() {
+		db.DB(ctx).Close(ctx)
+	})
+
+	tenantID := types.TenantId("TABCDE")
+	projectID := types.ProjectId("PDEFGH")
+	ctx = common.SetTenantIdInContext(ctx, tenantID)
+	ctx = common.SetProjectIdInContext(ctx, projectID)
+
+	var err error
+	err = db.DB(ctx).CreateTenant(ctx, tenantID)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		db.DB(ctx).DeleteTenant(ctx, tenantID)
+	})
+	err = db.DB(ctx).CreateProject(ctx, projectID)
+	require.NoError(t, err)
+
+	c := models.Catalog{
+		Name:      "references-catalog",
+		ProjectID: projectID,
+		Info:      pgtype.JSONB{Status: pgtype.Null},
+	}
+	err = db.DB(ctx).CreateCatalog(ctx, &c)
+	require.NoError(t, err)
+	variantID, err := db.DB(ctx).GetVariantIDFromName(ctx, c.CatalogID, types.DefaultVariant)
+	require.NoError(t, err)
+	ws := newTestWorkspace(t, ctx, variantID)
+
+	save := func(j string) {
+		t.Helper()
+		sm, err := NewSchema(ctx, []byte(j), nil)
+		require.NoError(t, err)
+		require.NoError(t, SaveSchema(ctx, sm, WithWorkspaceID(ws.WorkspaceID)))
+	}
+	save(`{"version": "v1", "kind": "ParameterSchema", "metadata": {"name": "retries", "catalog": "references-catalog"},
+		"spec": {"dataType": "Integer"}}`)
+	save(`{"version": "v1", "kind": "ParameterSchema", "metadata": {"name": "delay", "catalog": "references-catalog"},
+		"spec": {"dataType": "Integer"}}`)
+	for _, name := range []string{"a", "b"} {
+		save(`{"version": "v1", "kind": "CollectionSchema", "metadata": {"name": "` + name + `", "catalog": "references-catalog"},
+			"spec": {"parameters": {"maxRetries": {"schema": "retries"}, "maxDelay": {"dataType": "Integer"}}}}`)
+	}
+
+	dir, err := ReferenceDirectories(ctx, RequestContext{CatalogID: c.CatalogID, WorkspaceID: ws.WorkspaceID})
+	require.NoError(t, err)
+	assert.Equal(t, variantID, dir.VariantID)
+
+	root := "/" + types.RootNamespace()
+	report, err := VerifyReferences(ctx, dir)
+	require.NoError(t, err)
+	assert.Empty(t, report.Mismatches)
+	assert.Empty(t, report.Unresolved)
+
+	// the parameter schema loses one of its collection schemas and gains one that does not use it, and a
+	// collection schema points to the wrong parameter schema
+	err = db.DB(ctx).DeleteReferenceFromObject(ctx, types.CatalogObjectTypeParameterSchema, dir.ParametersDir, root+"/retries", root+"/b")
+	require.NoError(t, err)
+	err = db.DB(ctx).AddReferencesToObject(ctx, types.CatalogObjectTypeParameterSchema, dir.ParametersDir, root+"/delay", models.References{{Name: root + "/a"}})
+	require.NoError(t, err)
+	ref, err := db.DB(ctx).GetObjectRefByPath(ctx, types.CatalogObjectTypeCollectionSchema, dir.CollectionsDir, root+"/a")
+	require.NoError(t, err)
+	ref.References = models.References{{Name: root + "/delay"}}
+	err = db.DB(ctx).AddOrUpdateObjectByPath(ctx, types.CatalogObjectTypeCollectionSchema, dir.CollectionsDir, root+"/a", *ref)
+	require.NoError(t, err)
+
+	expected := []ReferenceMismatch{
+		{Kind: types.CollectionSchemaKind, Path: root + "/a", Stored: []string{root + "/delay"}, Expected: []string{root + "/retries"}},
+		{Kind: types.ParameterSchemaKind, Path: root + "/delay", Stored: []string{root + "/a"}, Expected: []string{}},
+		{Kind: types.ParameterSchemaKind, Path: root + "/retries", Stored: []string{root + "/a"}, Expected: []string{root + "/a", root + "/b"}},
+	}
+	report, err = VerifyReferences(ctx, dir)
+	require.NoError(t, err)
+	assert.Equal(t, expected, report.Mismatches)
+
+	// repairing rewrites the references it reports
+	report, err = RepairReferences(ctx, dir)
+	require.NoError(t, err)
+	assert.Equal(t, expected, report.Mismatches)
+
+	refs, err := db.DB(ctx).GetAllReferences(ctx, types.CatalogObjectTypeParameterSchema, dir.ParametersDir, root+"/retries")
+	require.NoError(t, err)
+	assert.ElementsMatch(t, models.References{{Name: root + "/a"}, {Name: root + "/b"}}, refs)
+	refs, err = db.DB(ctx).GetAllReferences(ctx, types.CatalogObjectTypeParameterSchema, dir.ParametersDir, root+"/delay")
+	require.NoError(t, err)
+	assert.Empty(t, refs)
+	refs, err = db.DB(ctx).GetAllReferences(ctx, types.CatalogObjectTypeCollectionSchema, dir.CollectionsDir, root+"/a")
+	require.NoError(t, err)
+	assert.Equal(t, models.References{{Name: root + "/retries"}}, refs)
+
+	report, err = VerifyReferences(ctx, dir)
+	require.NoError(t, err)
+	assert.Empty(t, report.Mismatches)
+
+	// the directories of a variant that does not exist cannot be checked
+	_, err = ReferenceDirectories(ctx, RequestContext{CatalogID: c.CatalogID, Variant: "no-such-variant"})
+	assert.ErrorIs(t, err, ErrVariantNotFound)
+}
@@ -0,0 +1,138 @@
+package catalogmanager
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"path"
+
+	"github.com/google/uuid"
+	"github.com/mugiliam/common/apperrors"
+	"github.com/mugiliam/hatchcatalogsrv/internal/catalogmanager/schemamanager"
+	"github.com/mugiliam/hatchcatalogsrv/internal/db"
+	"github.com/mugiliam/hatchcatalogsrv/internal/db/dberror"
+	"github.com/mugiliam/hatchcatalogsrv/pkg/types"
+	"github.com/tidwall/gjson"
+)
+
+// ResolvedParameterSchema describes the parameter schema a collection at Path binds to for a parameter name
+type ResolvedParameterSchema struct {
+	Name       string          `json:"name"`
+	Path       string          `json:"path"`
+	Namespace  string          `json:"namespace,omitempty"`
+	Resolved   string          `json:"resolved"`
+	Validation json.RawMessage `json:"validation,omitempty"`
+	Schema     json.RawMessage `json:"schema"`
+}
+
+// ResolveParameterSchema returns the parameter schema named name that a collection at collectionPath in the
+// namespace of reqCtx would bind to. It uses the same closest-parent lookup as collection validation, so a
+// parameter defined closer to the collection, or in the collection's namespace, shadows one defined above it.
+func ResolveParameterSchema(ctx context.Context, reqCtx RequestContext, name, collectionPath string) (*ResolvedParameterSchema, apperrors.Error) {
+	if reqCtx.Catalog == "" || reqCtx.CatalogID == uuid.Nil {
+		return nil, ErrInvalidCatalog
+	}
+	if reqCtx.Variant == "" || reqCtx.VariantID == uuid.Nil {
+		return nil, ErrInvalidVariant
+	}
+	if name == "" {
+		return nil, ErrInvalidRequest.Msg("parameter name is required")
+	}
+	if collectionPath == "" {
+		collectionPath = "/"
+	}
+	collectionPath = path.Clean("/" + collectionPath)
+
+	var dir Directories
+	var err apperrors.Error
+	if reqCtx.WorkspaceID != uuid.Nil {
+		dir, err = getDirectoriesForWorkspace(ctx, reqCtx.WorkspaceID)
+	} else {
+		dir, err = getDirectoriesForVariant(ctx, reqCtx.VariantID)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	t := types.CatalogObjectTypeParameterSchema
+	at := schemamanager.SchemaMetadata{
+		Namespace: types.NullableStringFrom(reqCtx.Namespace),
+		Path:      collectionPath,
+	}
+	startPath := at.GetStoragePath(types.CatalogObjectTypeCatalogCollection)
+	resolvedPath, ref, err := db.ReadDB(ctx).FindClosestObject(ctx, t, dir.ParametersDir, name, startPath)
+	if err != nil {
+		if errors.Is(err, dberror.ErrNotFound) {
+			return nil, ErrObjectNotFound.Msg("no parameter schema " + name + " applies at " + collectionPath)
+		}
+		return nil, ErrCatalogError.Err(err)
+	}
+	if ref == nil {
+		return nil, ErrObjectNotFound.Msg("no parameter schema " + name + " applies at " + collectionPath)
+	}
+
+	namespaces := make(map[string]struct{})
+	if reqCtx.Namespace != "" {
+		namespaces[reqCtx.Namespace] = struct{}{}
+	}
+	m := exportMetadataFromPath(resolvedPath, namespaces)
+	m.Catalog = reqCtx.Catalog
+	m.Variant = types.NullableStringFrom(reqCtx.Variant)
+	m.IDS.CatalogID = reqCtx.CatalogID
+	m.IDS.VariantID = reqCtx.VariantID
+
+	sm, err := LoadSchemaByHash(ctx, ref.Hash, &m)
+	if err != nil {
+		return nil, err
+	}
+	j, err := sm.ToJson(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rsp := &ResolvedParameterSchema{
+		Name:      name,
+		Path:      collectionPath,
+		Namespace: m.Namespace.String(),
+		Resolved:  path.Clean(m.Path + "/" + m.Name),
+		Schema:    j,
+	}
+	if v := gjson.GetBytes(j, "spec.validation"); v.Exists() {
+		rsp.Validation = json.RawMessage(v.Raw)
+	}
+	return rsp, nil
+}
+
+// ParameterSchemaDefault is the default value of the parameter schema a collection at Path binds to for a
+// parameter name. It is the value a collection gets for the parameter when neither the collection nor its
+// collection schema sets one.
+type ParameterSchemaDefault struct {
+	Name      string          `json:"name"`
+	Path      string          `json:"path"`
+	Namespace string          `json:"namespace,omitempty"`
+	Resolved  string          `json:"resolved"`
+	DataType  string          `json:"dataType"`
+	Default   json.RawMessage `json:"default"`
+}
+
+// GetParameterSchemaDefault returns the default of the parameter schema named name that a collection at
+// collectionPath would bind to, looked up as ResolveParameterSchema does. A parameter schema without a default
+// has a null default.
+func GetParameterSchemaDefault(ctx context.Context, reqCtx RequestContext, name, collectionPath string) (*ParameterSchemaDefault, apperrors.Error) {
+	resolved, err := ResolveParameterSchema(ctx, reqCtx, name, collectionPath)
+	if err != nil {
+		return nil, err
+	}
+	rsp := &ParameterSchemaDefault{
+		Name:      resolved.Name,
+		Path:      resolved.Path,
+		Namespace: resolved.Namespace,
+		Resolved:  resolved.Resolved,
+		DataType:  gjson.GetBytes(resolved.Schema, "spec.dataType").String(),
+		Default:   json.RawMessage("null"),
+	}
+	if v := gjson.GetBytes(resolved.Schema, "spec.default"); v.Exists() {
+		rsp.Default = json.RawMessage(v.Raw)
+	}
+	return rsp, nil
+}
@@ -25,6 +25,7 @@ type RequestContext struct {
 	ObjectType     types.CatalogObjectType
 	ObjectPath     string
 	QueryParams    url.Values
+	Direct         bool // the request operates on the variant even if a workspace is set in the catalog context
 }
 
 func RequestType(rsrcJson []byte) (kind string, apperr apperrors.Error) {
@@ -4,23 +4,30 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
-	"net/url"
+	"maps"
 	"path"
+	"sort"
 	"strings"
 
 	"github.com/google/uuid"
 	"github.com/mugiliam/common/apperrors"
 	schemaerr "github.com/mugiliam/hatchcatalogsrv/internal/catalogmanager/schema/errors"
 	"github.com/mugiliam/hatchcatalogsrv/internal/catalogmanager/schemamanager"
-	v1Schema "github.com/mugiliam/hatchcatalogsrv/internal/catalogmanager/v1/schemaresource"
+	"github.com/mugiliam/hatchcatalogsrv/internal/catalogmanager/schemamanager/versionregistry"
+	_ "github.com/mugiliam/hatchcatalogsrv/internal/catalogmanager/v1/schemaresource" // registers the v1 schema managers
 	"github.com/mugiliam/hatchcatalogsrv/internal/catalogmanager/validationerrors"
 	"github.com/mugiliam/hatchcatalogsrv/internal/config"
 	"github.com/mugiliam/hatchcatalogsrv/internal/db"
 	"github.com/mugiliam/hatchcatalogsrv/internal/db/dberror"
 	"github.com/mugiliam/hatchcatalogsrv/internal/db/models"
+	"github.com/mugiliam/hatchcatalogsrv/internal/tracing"
+	"github.com/mugiliam/hatchcatalogsrv/internal/webhook"
 	"github.com/mugiliam/hatchcatalogsrv/pkg/api/schemastore"
 	"github.com/mugiliam/hatchcatalogsrv/pkg/types"
 	"github.com/rs/zerolog/log"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 type VersionHeader struct {
@@ -43,10 +50,23 @@ func NewSchema(ctx context.Context, rsrcJson []byte, m *schemamanager.SchemaMeta
 		return nil, validationerrors.ErrSchemaValidation.Msg(schemaerr.ErrMissingRequiredAttribute("version").Error())
 	}
 
-	// validate the version
-	if version.Version != "v1" {
+	if version.Kind == types.CollectionSchemaKind {
+		if err := checkDuplicateKeys(rsrcJson, "spec.parameters", "spec.values"); err != nil {
+			return nil, err
+		}
+	}
+
+	// validate the version and find the managers for it. Manifests using an alias are stored under the
+	// version the alias resolves to.
+	resolvedVersion, sv, ok := versionregistry.Resolve(version.Version)
+	if !ok {
 		return nil, validationerrors.ErrInvalidVersion
 	}
+	if resolvedVersion != version.Version {
+		if rsrcJson, err = sjson.SetBytes(rsrcJson, "version", resolvedVersion); err != nil {
+			return nil, validationerrors.ErrSchemaSerialization
+		}
+	}
 
 	// get the metadata, replace fields in json from provided metadata. Set defaults.
 	rsrcJson, m, err = canonicalizeMetadata(rsrcJson, version.Kind, m)
@@ -61,7 +81,7 @@ func NewSchema(ctx context.Context, rsrcJson []byte, m *schemamanager.SchemaMeta
 
 	var sm schemamanager.SchemaManager
 	var apperr apperrors.Error
-	if sm, apperr = v1Schema.NewV1SchemaManager(ctx, rsrcJson, schemamanager.WithValidation(), schemamanager.WithDefaultValues()); apperr != nil {
+	if sm, apperr = sv.New(ctx, rsrcJson, schemamanager.WithValidation(), schemamanager.WithDefaultValues()); apperr != nil {
 		return nil, apperr
 	} else {
 		sm.SetMetadata(m)
@@ -81,6 +101,9 @@ type storeOptions struct {
 	IgnoreSchemaSpecChange         bool
 	SkipRevalidationOnSchemaChange bool
 	VersionNum                     int
+	RecursiveDelete                bool
+	AllowUnknownValues             bool
+	RevealSensitiveValues          bool
 }
 
 type Directories struct {
@@ -140,6 +163,30 @@ func WithVersionNum(num int) ObjectStoreOption {
 	}
 }
 
+// WithRecursiveDelete deletes the child collection schemas of a collection schema, and the collections based
+// on them, along with it
+func WithRecursiveDelete() ObjectStoreOption {
+	return func(o *storeOptions) {
+		o.RecursiveDelete = true
+	}
+}
+
+// AllowUnknownValues makes SaveValue and SaveCollection ignore keys that are not parameters of the collection
+// schema instead of rejecting the values, so that values written for a newer collection schema can still be saved
+func AllowUnknownValues() ObjectStoreOption {
+	return func(o *storeOptions) {
+		o.AllowUnknownValues = true
+	}
+}
+
+// RevealSensitiveValues makes GetAttribute and GetAllAttributes return sensitive values in plaintext instead of
+// redacting them
+func RevealSensitiveValues() ObjectStoreOption {
+	return func(o *storeOptions) {
+		o.RevealSensitiveValues = true
+	}
+}
+
 func SkipValidationForUpdate() ObjectStoreOption {
 	return func(o *storeOptions) {
 		o.SkipValidationForUpdate = true
@@ -174,6 +221,11 @@ func SaveSchema(ctx context.Context, om schemamanager.SchemaManager, opts ...Obj
 	if om == nil {
 		return validationerrors.ErrEmptySchema
 	}
+	ctx, span := tracing.Start(ctx, "catalogmanager.SaveSchema",
+		attribute.String("kind", string(om.Type())),
+		attribute.String("name", om.Metadata().Name),
+	)
+	defer span.End()
 
 	m := om.Metadata()
 
@@ -217,11 +269,22 @@ func SaveSchema(ctx context.Context, om schemamanager.SchemaManager, opts ...Obj
 		return ErrInvalidVersionOrWorkspace
 	}
 
+	// the namespace may have been deleted since the schema was validated
+	if m.IDS.VariantID != uuid.Nil {
+		if err := checkNamespaceExists(ctx, m.Namespace, m.IDS.VariantID); err != nil {
+			return err
+		}
+	}
+
 	switch t {
 	case types.CatalogObjectTypeParameterSchema:
 		if options.SkipValidationForUpdate {
 			break
 		}
+		// the base is merged in before validation, since the merged spec is the one that is saved
+		if err := resolveParameterSchemaExtends(ctx, om, dir); err != nil {
+			return err
+		}
 		var err apperrors.Error
 		if existingObjHash, refs, existingParamPath, existingParamRef, err = validateParameterSchema(ctx, om, dir, options); err != nil {
 			return err
@@ -240,6 +303,15 @@ func SaveSchema(ctx context.Context, om schemamanager.SchemaManager, opts ...Obj
 
 	if om.Type() == types.CatalogObjectTypeCollectionSchema {
 		om.CollectionSchemaManager().SetDefaultValues(ctx)
+		// the base of the values is set by values and is not part of the manifest, so it is kept from the
+		// existing directory entry
+		ref, err := db.DB(ctx).GetObjectRefByPath(ctx, t, dir.DirForType(t), pathWithName)
+		if err == nil {
+			m.ValuesExtends = ref.ValuesExtends
+		} else if !errors.Is(err, dberror.ErrNotFound) {
+			log.Ctx(ctx).Error().Err(err).Msg("failed to get directory entry")
+			return ErrCatalogError.Err(err)
+		}
 	}
 
 	s := om.StorageRepresentation()
@@ -247,8 +319,22 @@ func SaveSchema(ctx context.Context, om schemamanager.SchemaManager, opts ...Obj
 		return validationerrors.ErrEmptySchema
 	}
 
+	if !m.Deprecated {
+		m.DeprecationMessage = ""
+	}
+
 	hash = s.GetHash()
 	if hash == existingObjHash {
+		// the description and deprecation are not part of the hash, so an otherwise identical schema may still
+		// change them
+		changed, err := saveEntryMetadata(ctx, t, dir.DirForType(t), pathWithName, &m)
+		if err != nil {
+			return err
+		}
+		if changed {
+			notifyObjectChange(ctx, webhook.ActionUpdate, t, pathWithName, &m, dir, hash)
+			return nil
+		}
 		if options.ErrorIfEqualToExisting {
 			return ErrEqualToExistingObject
 		}
@@ -262,6 +348,9 @@ func SaveSchema(ctx context.Context, om schemamanager.SchemaManager, opts ...Obj
 	if err != nil {
 		return validationerrors.ErrSchemaSerialization
 	}
+	if err := checkObjectQuota(ctx, m.IDS.VariantID, existingObjHash == "" && !options.SkipValidationForUpdate, len(data)); err != nil {
+		return err
+	}
 
 	obj := models.CatalogObject{
 		Type:    s.Type,
@@ -270,19 +359,6 @@ func SaveSchema(ctx context.Context, om schemamanager.SchemaManager, opts ...Obj
 		Hash:    hash,
 	}
 
-	// Save obj to the database
-	dberr := db.DB(ctx).CreateCatalogObject(ctx, &obj)
-	if dberr != nil {
-		if errors.Is(dberr, dberror.ErrAlreadyExists) {
-			log.Ctx(ctx).Debug().Str("hash", obj.Hash).Msg("catalog object already exists")
-			// in this case, we don't return. If we came here it means the object is not in the directory,
-			// so we'll keep chugging along and save the object to the directory
-		} else {
-			log.Ctx(ctx).Error().Err(dberr).Msg("failed to save catalog object")
-			return dberr
-		}
-	}
-
 	var refModel models.References
 	for _, ref := range refs {
 		refModel = append(refModel, models.Reference{
@@ -290,24 +366,93 @@ func SaveSchema(ctx context.Context, om schemamanager.SchemaManager, opts ...Obj
 		})
 	}
 
-	if err := db.DB(ctx).AddOrUpdateObjectByPath(ctx, t, dir.DirForType(t), pathWithName, models.ObjectRef{
-		Hash:       obj.Hash,
-		References: refModel,
-	}); err != nil {
-		log.Ctx(ctx).Error().Err(err).Msg("failed to save object to directory")
-		return ErrCatalogError
+	// the object, its directory entry and the references in other objects are saved in one transaction, so
+	// a failure part way does not leave the directory pointing to an object with stale references
+	err = db.WithTx(ctx, func(ctx context.Context) apperrors.Error {
+		// Save obj to the database
+		dberr := db.DB(ctx).CreateCatalogObject(ctx, &obj)
+		if dberr != nil {
+			if errors.Is(dberr, dberror.ErrAlreadyExists) {
+				log.Ctx(ctx).Debug().Str("hash", obj.Hash).Msg("catalog object already exists")
+				// in this case, we don't return. If we came here it means the object is not in the directory,
+				// so we'll keep chugging along and save the object to the directory
+			} else {
+				log.Ctx(ctx).Error().Err(dberr).Msg("failed to save catalog object")
+				return dberr
+			}
+		}
+
+		if err := db.DB(ctx).AddOrUpdateObjectByPath(ctx, t, dir.DirForType(t), pathWithName, schemaObjectRef(obj.Hash, refModel, &m)); err != nil {
+			log.Ctx(ctx).Error().Err(err).Msg("failed to save object to directory")
+			return ErrCatalogError
+		}
+
+		if t == types.CatalogObjectTypeCollectionSchema && !options.SkipValidationForUpdate {
+			return syncCollectionReferences(ctx, dir.ParametersDir, pathWithName, existingRefs, refs)
+		} else if t == types.CatalogObjectTypeParameterSchema && len(refs) > 0 {
+			return syncParameterReferences(ctx, dir, existingParamPath, pathWithName, existingParamRef, refs)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
 	}
 
-	if t == types.CatalogObjectTypeCollectionSchema && !options.SkipValidationForUpdate {
-		syncCollectionReferencesInParameters(ctx, dir.ParametersDir, pathWithName, existingRefs, refs)
-	} else if t == types.CatalogObjectTypeParameterSchema && len(refs) > 0 {
-		syncParameterReferencesInCollections(ctx, dir, existingParamPath, pathWithName, existingParamRef, refs)
+	action := webhook.ActionUpdate
+	if existingObjHash == "" {
+		action = webhook.ActionCreate
 	}
+	notifyObjectChange(ctx, action, t, pathWithName, &m, dir, obj.Hash)
 
 	return nil
 }
 
-func syncParameterReferencesInCollections(ctx context.Context, dir Directories, existingPath, newPath string, existingParamObjRef *models.ObjectRef, newCollectionRefs schemamanager.SchemaReferences) {
+// schemaObjectRef returns the directory entry of a schema with metadata m that is stored as the object hash
+func schemaObjectRef(hash string, refs models.References, m *schemamanager.SchemaMetadata) models.ObjectRef {
+	return models.ObjectRef{
+		Hash:               hash,
+		References:         refs,
+		Description:        m.Description,
+		Deprecated:         m.Deprecated,
+		DeprecationMessage: m.DeprecationMessage,
+		Units:              m.Units,
+		ValuesExtends:      m.ValuesExtends,
+	}
+}
+
+// saveEntryMetadata sets the metadata kept in the directory entry at path to that of m and reports whether it
+// changed
+func saveEntryMetadata(ctx context.Context, t types.CatalogObjectType, dirID uuid.UUID, path string, m *schemamanager.SchemaMetadata) (bool, apperrors.Error) {
+	ref, err := db.DB(ctx).GetObjectRefByPath(ctx, t, dirID, path)
+	if err != nil {
+		if errors.Is(err, dberror.ErrNotFound) {
+			return false, nil
+		}
+		return false, ErrCatalogError.Err(err)
+	}
+	if ref.Description == m.Description && ref.Deprecated == m.Deprecated && ref.DeprecationMessage == m.DeprecationMessage &&
+		maps.Equal(ref.Units, m.Units) && ref.ValuesExtends == m.ValuesExtends {
+		return false, nil
+	}
+	ref.Description = m.Description
+	ref.Deprecated = m.Deprecated
+	ref.DeprecationMessage = m.DeprecationMessage
+	ref.Units = m.Units
+	ref.ValuesExtends = m.ValuesExtends
+	if err := db.DB(ctx).AddOrUpdateObjectByPath(ctx, t, dirID, path, *ref); err != nil {
+		log.Ctx(ctx).Error().Err(err).Msg("failed to save schema metadata to directory")
+		return false, ErrCatalogError
+	}
+	return true, nil
+}
+
+// reference syncing is called through these variables so that tests can inject failures
+var (
+	syncCollectionReferences = syncCollectionReferencesInParameters
+	syncParameterReferences  = syncParameterReferencesInCollections
+)
+
+func syncParameterReferencesInCollections(ctx context.Context, dir Directories, existingPath, newPath string, existingParamObjRef *models.ObjectRef, newCollectionRefs schemamanager.SchemaReferences) apperrors.Error {
 	var newRefsForExistingParam models.References
 	if existingParamObjRef != nil {
 		for _, ref := range existingParamObjRef.References {
@@ -327,26 +472,32 @@ func syncParameterReferencesInCollections(ctx context.Context, dir Directories,
 		// save the updated references for the parameter
 		if err := db.DB(ctx).AddOrUpdateObjectByPath(ctx, types.CatalogObjectTypeParameterSchema, dir.ParametersDir, existingPath, *existingParamObjRef); err != nil {
 			log.Ctx(ctx).Error().Err(err).Msg("failed to update parameter references")
+			return err
 		}
 	}
 
 	// if there are no existing references, we don't need to do anything
 	if existingParamObjRef == nil {
-		return
+		return nil
 	}
 
 	// for all the collections that will now map to the new parameter, replace the old reference with the new one
+	collections := make([]string, 0, len(newCollectionRefs))
 	for _, newRef := range newCollectionRefs {
-		if err := db.DB(ctx).AddReferencesToObject(ctx, types.CatalogObjectTypeCollectionSchema, dir.CollectionsDir, newRef.Name, []models.Reference{{Name: newPath}}); err != nil {
-			log.Ctx(ctx).Error().Err(err).Msg("failed to add new param path to collection")
-		}
-		if err := db.DB(ctx).DeleteReferenceFromObject(ctx, types.CatalogObjectTypeCollectionSchema, dir.CollectionsDir, newRef.Name, existingPath); err != nil {
-			log.Ctx(ctx).Error().Err(err).Msg("failed to delete new param path from collection")
-		}
+		collections = append(collections, newRef.Name)
+	}
+	if err := db.DB(ctx).AddReferencesToObjects(ctx, types.CatalogObjectTypeCollectionSchema, dir.CollectionsDir, collections, []models.Reference{{Name: newPath}}); err != nil {
+		log.Ctx(ctx).Error().Err(err).Msg("failed to add new param path to collections")
+		return err
 	}
+	if err := db.DB(ctx).DeleteReferencesFromObjects(ctx, types.CatalogObjectTypeCollectionSchema, dir.CollectionsDir, collections, existingPath); err != nil {
+		log.Ctx(ctx).Error().Err(err).Msg("failed to delete existing param path from collections")
+		return err
+	}
+	return nil
 }
 
-func syncCollectionReferencesInParameters(ctx context.Context, paramDir uuid.UUID, collectionFqp string, existingParamRefs, newParamRefs schemamanager.SchemaReferences) {
+func syncCollectionReferencesInParameters(ctx context.Context, paramDir uuid.UUID, collectionFqp string, existingParamRefs, newParamRefs schemamanager.SchemaReferences) apperrors.Error {
 	type refAction string
 	const (
 		actionAdd    refAction = "add"
@@ -369,27 +520,38 @@ func syncCollectionReferencesInParameters(ctx context.Context, paramDir uuid.UUI
 		}
 	}
 
-	// Execute actions
+	var toAdd, toDelete []string
 	for param, action := range refActions {
 		switch action {
 		case actionAdd:
-			if err := db.DB(ctx).AddReferencesToObject(ctx, types.CatalogObjectTypeParameterSchema, paramDir, param, []models.Reference{{Name: collectionFqp}}); err != nil {
-				log.Ctx(ctx).Error().
-					Str("param", param).
-					Str("collectionschema", collectionFqp).
-					Err(err).
-					Msg("failed to add references to collection schema")
-			}
+			toAdd = append(toAdd, param)
 		case actionDelete:
-			if err := db.DB(ctx).DeleteReferenceFromObject(ctx, types.CatalogObjectTypeParameterSchema, paramDir, param, collectionFqp); err != nil {
-				log.Ctx(ctx).Error().
-					Str("param", param).
-					Str("collectionschema", collectionFqp).
-					Err(err).
-					Msg("failed to delete references from collection schema")
-			}
+			toDelete = append(toDelete, param)
+		}
+	}
+
+	// Execute actions
+	if len(toAdd) > 0 {
+		if err := db.DB(ctx).AddReferencesToObjects(ctx, types.CatalogObjectTypeParameterSchema, paramDir, toAdd, []models.Reference{{Name: collectionFqp}}); err != nil {
+			log.Ctx(ctx).Error().
+				Strs("params", toAdd).
+				Str("collectionschema", collectionFqp).
+				Err(err).
+				Msg("failed to add references to collection schema")
+			return err
 		}
 	}
+	if len(toDelete) > 0 {
+		if err := db.DB(ctx).DeleteReferencesFromObjects(ctx, types.CatalogObjectTypeParameterSchema, paramDir, toDelete, collectionFqp); err != nil {
+			log.Ctx(ctx).Error().
+				Strs("params", toDelete).
+				Str("collectionschema", collectionFqp).
+				Err(err).
+				Msg("failed to delete references from collection schema")
+			return err
+		}
+	}
+	return nil
 }
 
 func validateParameterSchema(ctx context.Context, om schemamanager.SchemaManager, dir Directories, options storeOptions) (
@@ -399,6 +561,7 @@ func validateParameterSchema(ctx context.Context, om schemamanager.SchemaManager
 	existingParamRef *models.ObjectRef,
 	err apperrors.Error) {
 
+	var newlyBound schemamanager.SchemaReferences
 	m := om.Metadata()
 	pathWithName := path.Clean(m.GetStoragePath(types.CatalogObjectTypeParameterSchema) + "/" + m.Name)
 
@@ -472,6 +635,7 @@ func validateParameterSchema(ctx context.Context, om schemamanager.SchemaManager
 			if len(refsToAdd) > 0 {
 				if config.RemapAttributeSchemaReferences {
 					newRefs = append(newRefs, refsToAdd...)
+					newlyBound = refsToAdd
 				} else {
 					err = ErrSchemaConflict.Msg("one or more collection schemas in this namespace refer to the same parameter schema in root namespace")
 					return
@@ -479,21 +643,41 @@ func validateParameterSchema(ctx context.Context, om schemamanager.SchemaManager
 			}
 		}
 	}
-	// if there are references to this object - either new or updated - we need to validate them
-	if options.IgnoreSchemaSpecChange {
-		if !options.SkipRevalidationOnSchemaChange && len(newRefs) > 0 {
-			loaders := getSchemaLoaders(ctx, om.Metadata(), WithDirectories(dir))
-			if pm := om.ParameterSchemaManager(); pm != nil {
-				if err = pm.ValidateDependencies(ctx, loaders, newRefs); err != nil {
-					return
-				}
-			}
+	// Defaults in referencing collection schemas must satisfy this schema. If the spec is allowed to change,
+	// all references are revalidated; otherwise only the collection schemas that newly bind to this
+	// parameter because it is now the closest one.
+	if !options.SkipRevalidationOnSchemaChange {
+		toValidate := newlyBound
+		if options.IgnoreSchemaSpecChange {
+			toValidate = newRefs
+		}
+		if err = validateReferencingDefaults(ctx, om, dir, toValidate); err != nil {
+			return
 		}
 	}
 
 	return
 }
 
+// validateReferencingDefaults validates the defaults of the parameters bound to om in each of the referencing
+// collection schemas. The error names the first collection schema whose default does not satisfy om.
+func validateReferencingDefaults(ctx context.Context, om schemamanager.SchemaManager, dir Directories, refs schemamanager.SchemaReferences) apperrors.Error {
+	pm := om.ParameterSchemaManager()
+	if pm == nil || len(refs) == 0 {
+		return nil
+	}
+	// references carry the storage path of the collection schema, which may be in a different namespace
+	// than the parameter, so load them by the exact path.
+	loaders := getSchemaLoaders(ctx, om.Metadata(), WithDirectories(dir), SkipCanonicalizePaths())
+	for _, ref := range refs {
+		if err := pm.ValidateDependencies(ctx, loaders, schemamanager.SchemaReferences{ref}); err != nil {
+			log.Ctx(ctx).Info().Str("collectionschema", ref.Name).Err(err).Msg("default in collection schema violates parameter schema")
+			return ErrInvalidDefaultInReference.Msg("default value in collection schema " + ref.Name + " does not satisfy parameter schema " + om.Metadata().Name + ": " + err.Error())
+		}
+	}
+	return nil
+}
+
 // isParentOrSame checks if p1 is a parent or the same as p2
 func isParentOrSame(p1, p2 string) bool {
 	// Clean paths to remove redundant elements
@@ -574,8 +758,7 @@ func validateCollectionSchema(ctx context.Context, om schemamanager.SchemaManage
 	return
 }
 
-func deleteCollectionSchema(ctx context.Context, t types.CatalogObjectType, m *schemamanager.SchemaMetadata, dir Directories) apperrors.Error {
-	// check if there are references to this schema
+func deleteCollectionSchema(ctx context.Context, t types.CatalogObjectType, m *schemamanager.SchemaMetadata, dir Directories, recursive bool) apperrors.Error {
 	pathWithName := path.Clean(m.GetStoragePath(t) + "/" + m.Name)
 	if m.IDS.VariantID == uuid.Nil {
 		err := validateMetadata(ctx, m)
@@ -584,6 +767,53 @@ func deleteCollectionSchema(ctx context.Context, t types.CatalogObjectType, m *s
 		}
 	}
 
+	children, err := childCollectionSchemas(ctx, dir.CollectionsDir, pathWithName)
+	if err != nil {
+		return err
+	}
+	if !recursive {
+		if len(children) > 0 {
+			log.Ctx(ctx).Info().Str("path", pathWithName).Strs("children", children).Msg("collection schema has children, cannot delete")
+			return ErrUnableToDeleteCollectionWithChildren.Msg(pathWithName + " has child collection schemas: " + strings.Join(children, ", "))
+		}
+		return db.WithTx(ctx, func(ctx context.Context) apperrors.Error {
+			return deleteCollectionSchemaAtPath(ctx, pathWithName, dir)
+		})
+	}
+
+	// delete the deepest schemas first so that a schema is never removed before its children. The deletes are
+	// notified once the transaction is committed.
+	var deletedCollections []string
+	err = db.WithTx(ctx, func(ctx context.Context) apperrors.Error {
+		deletedCollections = nil
+		for _, p := range append(children, pathWithName) {
+			collections, err := deleteCollectionsForSchema(ctx, p, dir)
+			if err != nil {
+				return err
+			}
+			deletedCollections = append(deletedCollections, collections...)
+			if err := deleteCollectionSchemaAtPath(ctx, p, dir); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for _, p := range deletedCollections {
+		notifyObjectChange(ctx, webhook.ActionDelete, types.CatalogObjectTypeCatalogCollection, p, m, dir, "")
+	}
+	for _, p := range children {
+		notifyObjectChange(ctx, webhook.ActionDelete, t, p, m, dir, "")
+	}
+	return nil
+}
+
+// deleteCollectionSchemaAtPath deletes the collection schema at pathWithName if no collections are based on it.
+// It is called in a transaction, so the directory entry and the object are deleted together.
+func deleteCollectionSchemaAtPath(ctx context.Context, pathWithName string, dir Directories) apperrors.Error {
+	// check if there are references to this schema
 	exists, err := db.DB(ctx).HasReferencesToCollectionSchema(ctx, pathWithName, dir.ValuesDir)
 	if err != nil {
 		log.Ctx(ctx).Error().Err(err).Str("path", pathWithName).Msg("failed to check if collection schema has references")
@@ -607,21 +837,75 @@ func deleteCollectionSchema(ctx context.Context, t types.CatalogObjectType, m *s
 	); err != nil {
 		return ErrCatalogError.Err(err).Msg("unable to delete collection schema from directory")
 	}
-	// delete the object from the database
-	if err := db.DB(ctx).DeleteCatalogObject(ctx, types.CatalogObjectTypeCollectionSchema, string(hash)); err != nil {
-		if !errors.Is(err, dberror.ErrNotFound) {
-			// we don't return an error since the object reference has already been removed and
-			// we cannot roll this back.
-			log.Ctx(ctx).Error().Err(err).Str("hash", string(hash)).Msg("failed to delete object from database")
-		}
+	// delete the object from the database; it is kept if another directory entry still refers to it
+	if err := db.DB(ctx).DeleteCatalogObject(ctx, string(hash)); err != nil && !errors.Is(err, dberror.ErrNotFound) {
+		log.Ctx(ctx).Error().Err(err).Str("hash", string(hash)).Msg("failed to delete object from database")
+		return ErrCatalogError.Err(err)
 	}
 	return nil
 }
 
+// childCollectionSchemas returns the paths of the collection schemas below pathWithName, deepest first
+func childCollectionSchemas(ctx context.Context, collectionsDir uuid.UUID, pathWithName string) ([]string, apperrors.Error) {
+	d, err := loadDirectory(ctx, types.CatalogObjectTypeCollectionSchema, collectionsDir)
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Str("path", pathWithName).Msg("failed to load collection schema directory")
+		return nil, err
+	}
+	var children []string
+	for p := range d {
+		if strings.HasPrefix(p, pathWithName+"/") {
+			children = append(children, p)
+		}
+	}
+	sort.Slice(children, func(i, j int) bool {
+		di, dj := strings.Count(children[i], "/"), strings.Count(children[j], "/")
+		if di != dj {
+			return di > dj
+		}
+		return children[i] < children[j]
+	})
+	return children, nil
+}
+
+// deleteCollectionsForSchema deletes the collections based on the collection schema at schemaPath and returns
+// the paths of the collections it deleted
+func deleteCollectionsForSchema(ctx context.Context, schemaPath string, dir Directories) ([]string, apperrors.Error) {
+	d, err := loadDirectory(ctx, types.CatalogObjectTypeCatalogCollection, dir.ValuesDir)
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Str("path", schemaPath).Msg("failed to load collection directory")
+		return nil, err
+	}
+	var paths []string
+	for p, ref := range d {
+		if ref.BaseSchema == schemaPath {
+			paths = append(paths, p)
+		}
+	}
+	sort.Strings(paths)
+	var deleted []string
+	for _, p := range paths {
+		hash, err := db.DB(ctx).DeleteCollection(ctx, p, dir.ValuesDir)
+		if err != nil {
+			if errors.Is(err, dberror.ErrNotFound) {
+				continue
+			}
+			log.Ctx(ctx).Error().Err(err).Str("path", p).Msg("failed to delete collection")
+			return nil, ErrCatalogError.Err(err).Msg("unable to delete collection " + p)
+		}
+		if err := db.DB(ctx).DeleteCatalogObject(ctx, hash); err != nil {
+			if !errors.Is(err, dberror.ErrNotFound) {
+				log.Ctx(ctx).Error().Err(err).Str("hash", hash).Msg("failed to delete object from database")
+			}
+		}
+		deleted = append(deleted, p)
+	}
+	return deleted, nil
+}
+
 func deleteParameterSchema(ctx context.Context, t types.CatalogObjectType, m *schemamanager.SchemaMetadata, dir Directories) apperrors.Error {
 	// check if there are references to this schema
 	pathWithName := path.Clean(m.GetStoragePath(t) + "/" + m.Name)
-	var hash types.Hash
 
 	// if there are references to this schema, don't delete it.
 	refs, err := db.DB(ctx).GetAllReferences(ctx, types.CatalogObjectTypeParameterSchema, dir.ParametersDir, pathWithName)
@@ -638,19 +922,136 @@ func deleteParameterSchema(ctx context.Context, t types.CatalogObjectType, m *sc
 		return ErrUnableToDeleteParameterWithReferences
 	}
 
-	// delete the object from the directory
-	if hash, err = db.DB(ctx).DeleteObjectByPath(ctx, types.CatalogObjectTypeParameterSchema, dir.ParametersDir, pathWithName); err != nil {
-		return ErrCatalogError.Err(err).Msg("unable to delete parameter schema from directory")
-	}
-	// delete the object from the database
-	if err := db.DB(ctx).DeleteCatalogObject(ctx, types.CatalogObjectTypeParameterSchema, string(hash)); err != nil {
-		if !errors.Is(err, dberror.ErrNotFound) {
-			// we don't return an error since the object reference has already been removed and
-			// we cannot roll this back.
+	// the directory entry and the object are deleted in one transaction
+	return db.WithTx(ctx, func(ctx context.Context) apperrors.Error {
+		hash, err := db.DB(ctx).DeleteObjectByPath(ctx, types.CatalogObjectTypeParameterSchema, dir.ParametersDir, pathWithName)
+		if err != nil {
+			return ErrCatalogError.Err(err).Msg("unable to delete parameter schema from directory")
+		}
+		// the object is kept if another directory entry still refers to it
+		if err := db.DB(ctx).DeleteCatalogObject(ctx, string(hash)); err != nil && !errors.Is(err, dberror.ErrNotFound) {
 			log.Ctx(ctx).Error().Err(err).Str("hash", string(hash)).Msg("failed to delete objects from database")
+			return ErrCatalogError.Err(err)
 		}
+		return nil
+	})
+}
+
+// ForceDeleteParameterSchema deletes the parameter schema described by m even if collection schemas refer to it.
+// The parameters of the referencing collection schemas that resolve to the parameter schema are reverted to the
+// data type of the parameter schema, keeping their defaults and annotations, and the parameter schema is then
+// deleted, all in one transaction. Returns the paths of the collection schemas that were modified.
+func ForceDeleteParameterSchema(ctx context.Context, m *schemamanager.SchemaMetadata, dir Directories) ([]string, apperrors.Error) {
+	if m == nil {
+		return nil, ErrEmptyMetadata
 	}
-	return nil
+	t := types.CatalogObjectTypeParameterSchema
+	pathWithName := path.Clean(m.GetStoragePath(t) + "/" + m.Name)
+
+	ps, err := LoadSchemaByPath(ctx, t, m, WithDirectories(dir))
+	if err != nil {
+		return nil, err
+	}
+	pj, err := ps.ToJson(ctx)
+	if err != nil {
+		return nil, err
+	}
+	dataType := gjson.GetBytes(pj, "spec.dataType").String()
+
+	variantID := m.IDS.VariantID
+	if variantID == uuid.Nil {
+		variantID = dir.VariantID
+	}
+	namespaces := make(map[string]struct{})
+	nsList, err := db.DB(ctx).ListNamespacesByVariant(ctx, variantID)
+	if err != nil && !errors.Is(err, dberror.ErrNotFound) {
+		log.Ctx(ctx).Error().Err(err).Msg("failed to list namespaces")
+		return nil, ErrCatalogError
+	}
+	for _, ns := range nsList {
+		if ns.Name != types.RootNamespace() {
+			namespaces[ns.Name] = struct{}{}
+		}
+	}
+
+	var modified []string
+	err = db.WithTx(ctx, func(ctx context.Context) apperrors.Error {
+		refs, err := db.DB(ctx).GetAllReferences(ctx, t, dir.ParametersDir, pathWithName)
+		if err != nil && !errors.Is(err, dberror.ErrNotFound) {
+			log.Ctx(ctx).Error().Err(err).Str("path", pathWithName).Msg("failed to get all references")
+			return ErrCatalogError.Err(err).Msg("unable to delete parameter schema")
+		}
+		for _, ref := range refs {
+			changed, err := removeParameterSchemaFromCollectionSchema(ctx, ref.Name, pathWithName, dataType, *m, namespaces, dir)
+			if err != nil {
+				return err
+			}
+			if changed {
+				modified = append(modified, ref.Name)
+			}
+		}
+		return deleteParameterSchema(ctx, t, m, dir)
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(modified)
+	notifyObjectChange(ctx, webhook.ActionDelete, t, pathWithName, m, dir, "")
+	return modified, nil
+}
+
+// removeParameterSchemaFromCollectionSchema rewrites the parameters of the collection schema at csPath that
+// resolve to the parameter schema at paramPath so that they only declare dataType, and saves the collection
+// schema. Returns false if no parameter of the collection schema resolves to the parameter schema.
+func removeParameterSchemaFromCollectionSchema(ctx context.Context, csPath, paramPath, dataType string, pm schemamanager.SchemaMetadata, namespaces map[string]struct{}, dir Directories) (bool, apperrors.Error) {
+	cm := exportMetadataFromPath(csPath, namespaces)
+	cm.Catalog = pm.Catalog
+	cm.Variant = pm.Variant
+	cm.IDS = pm.IDS
+
+	cs, err := LoadSchemaByPath(ctx, types.CatalogObjectTypeCollectionSchema, &cm, WithDirectories(dir))
+	if err != nil {
+		return false, err
+	}
+	j, err := cs.ToJson(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	loaders := getSchemaLoaders(ctx, cm, WithDirectories(dir))
+	if loaders.ClosestParent == nil {
+		return false, ErrCatalogError.Msg("unable to resolve parameter schemas")
+	}
+	changed := false
+	for _, p := range cs.CollectionSchemaManager().ParametersWithSchema(pm.Name) {
+		key := "spec.parameters." + p.Name
+		schemaName := gjson.GetBytes(j, key+".schema").String()
+		resolved, _, err := loaders.ClosestParent(ctx, types.CatalogObjectTypeParameterSchema, schemaName)
+		if err != nil || resolved != paramPath {
+			continue
+		}
+		var e error
+		if j, e = sjson.DeleteBytes(j, key+".schema"); e == nil {
+			j, e = sjson.SetBytes(j, key+".dataType", dataType)
+		}
+		if e != nil {
+			log.Ctx(ctx).Error().Err(e).Str("parameter", p.Name).Msg("failed to remove parameter schema")
+			return false, ErrCatalogError
+		}
+		changed = true
+	}
+	if !changed {
+		return false, nil
+	}
+
+	updated, err := NewSchema(ctx, j, &cm)
+	if err != nil {
+		return false, err
+	}
+	if err := SaveSchema(ctx, updated, WithDirectories(dir)); err != nil {
+		return false, err
+	}
+	return true, nil
 }
 
 var _ = collectionSchemaExists
@@ -706,7 +1107,7 @@ func LoadSchemaByPath(ctx context.Context, t types.CatalogObjectType, m *schemam
 	}
 	rsrcPath = path.Clean(rsrcPath)
 
-	obj, err := db.DB(ctx).LoadObjectByPath(ctx, t, dir, rsrcPath)
+	obj, err := db.ReadDB(ctx).LoadObjectByPath(ctx, t, dir, rsrcPath)
 	if err != nil {
 		if errors.Is(err, dberror.ErrNotFound) {
 			return nil, ErrObjectNotFound
@@ -729,21 +1130,191 @@ func LoadSchemaByPath(ctx context.Context, t types.CatalogObjectType, m *schemam
 		log.Ctx(ctx).Error().Str("Hash", obj.Hash).Msg("version mismatch when loading resource")
 	}
 
-	return v1Schema.LoadV1SchemaManager(ctx, s, m)
+	// the description, deprecation, units and base of the values are kept with the directory entry, not the
+	// object
+	loaded := *m
+	if ref, err := db.ReadDB(ctx).GetObjectRefByPath(ctx, t, dir, rsrcPath); err == nil {
+		loaded.Description = ref.Description
+		loaded.Deprecated = ref.Deprecated
+		loaded.DeprecationMessage = ref.DeprecationMessage
+		loaded.Units = ref.Units
+		loaded.ValuesExtends = ref.ValuesExtends
+	}
+
+	return loadSchemaManager(ctx, s, &loaded)
 }
 
-func DeleteSchema(ctx context.Context, t types.CatalogObjectType, m *schemamanager.SchemaMetadata, dir Directories) apperrors.Error {
+// DeleteSchema deletes the schema described by m. A collection schema with child collection schemas is only
+// deleted with WithRecursiveDelete, which also deletes the children and the collections based on them.
+func DeleteSchema(ctx context.Context, t types.CatalogObjectType, m *schemamanager.SchemaMetadata, dir Directories, opts ...ObjectStoreOption) apperrors.Error {
 	if m == nil {
 		return ErrEmptyMetadata
 	}
+	o := storeOptions{}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	var err apperrors.Error
 	switch t {
 	case types.CatalogObjectTypeCollectionSchema:
-		return deleteCollectionSchema(ctx, t, m, dir)
+		err = deleteCollectionSchema(ctx, t, m, dir, o.RecursiveDelete)
 	case types.CatalogObjectTypeParameterSchema:
-		return deleteParameterSchema(ctx, t, m, dir)
+		err = deleteParameterSchema(ctx, t, m, dir)
 	default:
 		return ErrInvalidSchema
 	}
+	if err != nil {
+		return err
+	}
+	notifyObjectChange(ctx, webhook.ActionDelete, t, path.Clean(m.GetStoragePath(t)+"/"+m.Name), m, dir, "")
+	return nil
+}
+
+// MoveSchema moves the collection schema described by from to the name and namespace described by to. The
+// parameter schemas that the collection schema refers to are resolved again from the new path, since a closer
+// parameter schema may exist there, and the move fails if any of them no longer resolves or if a value set on
+// the schema does not validate against the schema it now binds to. The directory entry and the references to
+// the collection schema held by parameter schemas are rewritten in one transaction.
+func MoveSchema(ctx context.Context, t types.CatalogObjectType, from, to *schemamanager.SchemaMetadata, dir Directories) apperrors.Error {
+	if t != types.CatalogObjectTypeCollectionSchema {
+		return ErrInvalidRequest.Msg("only collection schemas can be moved")
+	}
+	if from == nil || to == nil {
+		return ErrEmptyMetadata
+	}
+	if from.Catalog != to.Catalog || from.Variant.String() != to.Variant.String() {
+		return ErrInvalidRequest.Msg("a schema cannot be moved to another catalog or variant")
+	}
+	if err := validateMetadata(ctx, to); err != nil {
+		return err
+	}
+	oldPath := path.Clean(from.GetStoragePath(t) + "/" + from.Name)
+	newPath := path.Clean(to.GetStoragePath(t) + "/" + to.Name)
+	if oldPath == newPath {
+		return nil
+	}
+
+	om, err := LoadSchemaByPath(ctx, t, from, WithDirectories(dir))
+	if err != nil {
+		return err
+	}
+
+	// the destination must be free
+	if _, err := db.DB(ctx).GetObjectRefByPath(ctx, t, dir.CollectionsDir, newPath); err == nil {
+		return ErrAlreadyExists.Msg(newPath + " already exists")
+	} else if !errors.Is(err, dberror.ErrNotFound) {
+		log.Ctx(ctx).Error().Err(err).Str("path", newPath).Msg("failed to get object by path")
+		return ErrCatalogError
+	}
+
+	// collections and child schemas refer to the schema by its path
+	exists, err := db.DB(ctx).HasReferencesToCollectionSchema(ctx, oldPath, dir.ValuesDir)
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Str("path", oldPath).Msg("failed to check if collection schema has references")
+		return ErrCatalogError
+	}
+	if exists {
+		return ErrUnableToMoveCollectionWithReferences
+	}
+	children, err := childCollectionSchemas(ctx, dir.CollectionsDir, oldPath)
+	if err != nil {
+		return err
+	}
+	if len(children) > 0 {
+		return ErrUnableToMoveObject.Msg(oldPath + " has child collection schemas: " + strings.Join(children, ", "))
+	}
+
+	// resolve the parameter schemas from the new path and carry the values over
+	moved, err := loadSchemaManager(ctx, om.StorageRepresentation(), to)
+	if err != nil {
+		return err
+	}
+	cm := moved.CollectionSchemaManager()
+	if cm == nil {
+		log.Ctx(ctx).Error().Msg("collection manager is nil")
+		return ErrCatalogError
+	}
+	values := make(map[string]types.NullableAny)
+	for param, v := range cm.GetDefaultValues() {
+		values[param] = v.Value
+	}
+	loaders := getSchemaLoaders(ctx, *to, WithDirectories(dir), SkipCanonicalizePaths())
+	refs, err := cm.ValidateDependencies(ctx, loaders, nil)
+	if err != nil {
+		return err
+	}
+	cm.SetDefaultValues(ctx)
+	loaders.ParameterRef = getParameterRefForName(refs)
+	for param, v := range values {
+		if v.IsNil() {
+			delete(values, param)
+		}
+	}
+	if err := cm.ValidateValues(ctx, loaders, values); err != nil {
+		return err
+	}
+	for param, v := range values {
+		cm.SetValue(ctx, param, v)
+	}
+
+	s := moved.StorageRepresentation()
+	data, e := s.Serialize()
+	if e != nil {
+		return validationerrors.ErrSchemaSerialization
+	}
+	obj := models.CatalogObject{
+		Type:    s.Type,
+		Version: s.Version,
+		Data:    data,
+		Hash:    s.GetHash(),
+	}
+	var refModel models.References
+	for _, ref := range refs {
+		refModel = append(refModel, models.Reference{
+			Name: ref.Name,
+		})
+	}
+
+	err = db.WithTx(ctx, func(ctx context.Context) apperrors.Error {
+		if err := db.DB(ctx).CreateCatalogObject(ctx, &obj); err != nil && !errors.Is(err, dberror.ErrAlreadyExists) {
+			log.Ctx(ctx).Error().Err(err).Msg("failed to save catalog object")
+			return err
+		}
+		// removes the entry along with the references to it in parameter schemas
+		if _, err := db.DB(ctx).DeleteObjectWithReferences(ctx, t,
+			models.DirectoryIDs{
+				{ID: dir.CollectionsDir, Type: types.CatalogObjectTypeCollectionSchema},
+				{ID: dir.ParametersDir, Type: types.CatalogObjectTypeParameterSchema},
+			},
+			oldPath,
+			models.DeleteReferences(true),
+		); err != nil {
+			log.Ctx(ctx).Error().Err(err).Str("path", oldPath).Msg("failed to remove collection schema from directory")
+			return ErrCatalogError.Err(err)
+		}
+		mm := moved.Metadata()
+		if err := db.DB(ctx).AddOrUpdateObjectByPath(ctx, t, dir.CollectionsDir, newPath, schemaObjectRef(obj.Hash, refModel, &mm)); err != nil {
+			log.Ctx(ctx).Error().Err(err).Str("path", newPath).Msg("failed to save object to directory")
+			return ErrCatalogError
+		}
+		return syncCollectionReferences(ctx, dir.ParametersDir, newPath, nil, refs)
+	})
+	if err != nil {
+		return err
+	}
+
+	notifyObjectChange(ctx, webhook.ActionDelete, t, oldPath, from, dir, "")
+	notifyObjectChange(ctx, webhook.ActionCreate, t, newPath, to, dir, obj.Hash)
+	return nil
+}
+
+// loadSchemaManager builds the schema manager for s with the managers registered for its version
+func loadSchemaManager(ctx context.Context, s *schemastore.SchemaStorageRepresentation, m *schemamanager.SchemaMetadata) (schemamanager.SchemaManager, apperrors.Error) {
+	_, sv, ok := versionregistry.Resolve(s.Version)
+	if !ok {
+		return nil, ErrUnableToLoadObject.Msg("unsupported schema version " + s.Version)
+	}
+	return sv.Load(ctx, s, m)
 }
 
 func LoadSchemaByHash(ctx context.Context, hash string, m *schemamanager.SchemaMetadata, opts ...ObjectStoreOption) (schemamanager.SchemaManager, apperrors.Error) {
@@ -776,13 +1347,40 @@ func LoadSchemaByHash(ctx context.Context, hash string, m *schemamanager.SchemaM
 		log.Ctx(ctx).Error().Str("Hash", hash).Msg("version mismatch when loading resource")
 	}
 
-	return v1Schema.LoadV1SchemaManager(ctx, s, m)
+	return loadSchemaManager(ctx, s, m)
+}
+
+// LoadObjectsByHashes loads and de-serializes the catalog objects for the given hashes in a single
+// round-trip. Hashes that are not found are omitted from the returned map.
+func LoadObjectsByHashes(ctx context.Context, hashes []string) (map[string]*schemastore.SchemaStorageRepresentation, apperrors.Error) {
+	objs, err := db.DB(ctx).GetCatalogObjects(ctx, hashes)
+	if err != nil {
+		return nil, ErrUnableToLoadObject.Err(err)
+	}
+
+	reprs := make(map[string]*schemastore.SchemaStorageRepresentation, len(objs))
+	for hash, obj := range objs {
+		s := &schemastore.SchemaStorageRepresentation{}
+		if err := json.Unmarshal(obj.Data, s); err != nil {
+			log.Ctx(ctx).Error().Err(err).Str("Hash", hash).Msg("failed to de-serialize catalog object data")
+			return nil, ErrUnableToLoadObject.Err(err).Msg("failed to de-serialize catalog object data")
+		}
+		if s.Type != obj.Type {
+			log.Ctx(ctx).Error().Str("Hash", hash).Msg("type mismatch when loading resource")
+		}
+		if s.Version != obj.Version {
+			log.Ctx(ctx).Error().Str("Hash", hash).Msg("version mismatch when loading resource")
+		}
+		reprs[hash] = s
+	}
+	return reprs, nil
 }
 
 func validateMetadata(ctx context.Context, m *schemamanager.SchemaMetadata) apperrors.Error {
 	if m == nil {
 		return ErrEmptyMetadata
 	}
+	m.CanonicalizeNamespace()
 	ves := m.Validate()
 	if ves != nil {
 		return validationerrors.ErrSchemaValidation.Msg(ves.Error())
@@ -808,14 +1406,8 @@ func validateMetadata(ctx context.Context, m *schemamanager.SchemaMetadata) appe
 			variantId = v.VariantID
 		}
 	}
-	// check if the namespace exists
-	if !m.Namespace.IsNil() {
-		if _, err := db.DB(ctx).GetNamespace(ctx, m.Namespace.String(), variantId); err != nil {
-			if errors.Is(err, dberror.ErrNotFound) {
-				return ErrNamespaceNotFound.Prefix(m.Namespace.String())
-			}
-			return ErrCatalogError.Err(err)
-		}
+	if err := checkNamespaceExists(ctx, m.Namespace, variantId); err != nil {
+		return err
 	}
 	// we won't handle resource path here
 	m.IDS.CatalogID = catalogId
@@ -849,6 +1441,9 @@ func getClosestParentSchemaFinder(ctx context.Context, m schemamanager.SchemaMet
 	}
 
 	return func(ctx context.Context, t types.CatalogObjectType, targetName string) (path string, hash string, err apperrors.Error) {
+		if ns, name := schemamanager.SplitSchemaRef(targetName); ns != "" {
+			return findSchemaInNamespace(ctx, t, m, dir, ns, name)
+		}
 		startPath := m.GetStoragePath(t)
 		path, obj, err := db.DB(ctx).FindClosestObject(ctx, t, dir.DirForType(t), targetName, startPath)
 		if err != nil {
@@ -865,6 +1460,32 @@ func getClosestParentSchemaFinder(ctx context.Context, m schemamanager.SchemaMet
 	}
 }
 
+// findSchemaInNamespace finds the schema named name in namespace ns. Unlike unqualified names, a name qualified
+// by a namespace does not fall back to the root namespace.
+func findSchemaInNamespace(ctx context.Context, t types.CatalogObjectType, m schemamanager.SchemaMetadata, dir Directories, ns, name string) (string, string, apperrors.Error) {
+	if types.IsRootNamespace(ns) {
+		// the root namespace always exists and has no namespace record
+		m.Namespace = types.NullString()
+	} else {
+		if _, err := db.DB(ctx).GetNamespace(ctx, ns, m.IDS.VariantID); err != nil {
+			if errors.Is(err, dberror.ErrNotFound) {
+				return "", "", validationerrors.ErrNamespaceNotFound.Msg("namespace " + ns + " does not exist")
+			}
+			return "", "", ErrCatalogError.Err(err)
+		}
+		m.Namespace = types.NullableStringFrom(ns)
+	}
+	schemaPath := path.Clean(m.GetStoragePath(t) + "/" + name)
+	ref, err := db.DB(ctx).GetObjectRefByPath(ctx, t, dir.DirForType(t), schemaPath)
+	if err != nil {
+		if errors.Is(err, dberror.ErrNotFound) {
+			return "", "", ErrObjectNotFound
+		}
+		return "", "", ErrCatalogError.Err(err)
+	}
+	return schemaPath, ref.Hash, nil
+}
+
 func getSchemaLoaderByPath(ctx context.Context, m schemamanager.SchemaMetadata, opts ...ObjectStoreOption) schemamanager.SchemaLoaderByPath {
 	o := &storeOptions{}
 	for _, opt := range opts {
@@ -989,8 +1610,9 @@ func getSchemaReferences(ctx context.Context, t types.CatalogObjectType, dir uui
 }
 
 type objectResource struct {
-	name RequestContext
-	om   schemamanager.SchemaManager
+	name     RequestContext
+	om       schemamanager.SchemaManager
+	warnings []LintWarning
 }
 
 func (or *objectResource) Name() string {
@@ -998,20 +1620,11 @@ func (or *objectResource) Name() string {
 }
 
 func (or *objectResource) Location() string {
-	objName := types.ResourceNameFromObjectType(or.name.ObjectType)
-	loc := path.Clean("/" + objName + or.om.FullyQualifiedName())
-	q := url.Values{}
-	if workspace := or.name.WorkspaceLabel; workspace != "" {
-		q.Set("workspace", workspace)
-	}
-	if namespace := or.om.Metadata().Namespace.String(); namespace != "" {
-		q.Set("namespace", namespace)
-	}
-	qStr := q.Encode()
-	if qStr != "" {
-		loc += "?" + qStr
-	}
-	return loc
+	return objectLocation(or.name.ObjectType, or.om.FullyQualifiedName(), or.om.Metadata().Namespace.String(), or.name)
+}
+
+func (or *objectResource) Warnings() []LintWarning {
+	return or.warnings
 }
 
 func (or *objectResource) Manager() schemamanager.SchemaManager {
@@ -1033,6 +1646,9 @@ func (or *objectResource) Create(ctx context.Context, rsrcJson []byte) (string,
 	if err != nil {
 		return "", err
 	}
+	if dir, err := getDirectoriesForWorkspace(ctx, or.name.WorkspaceID); err == nil {
+		or.warnings = deprecatedParameterWarnings(ctx, object, dir)
+	}
 
 	or.name.ObjectName = object.Metadata().Name
 	or.name.ObjectPath = object.Metadata().Path
@@ -1066,11 +1682,103 @@ func (or *objectResource) Get(ctx context.Context) ([]byte, apperrors.Error) {
 	m.IDS.CatalogID = or.name.CatalogID
 	m.IDS.VariantID = or.name.VariantID
 
-	object, err := LoadSchemaByPath(ctx, or.name.ObjectType, m, WithWorkspaceID(or.name.WorkspaceID))
+	dir, err := getDirectoriesForRead(ctx, or.name)
+	if err != nil {
+		return nil, err
+	}
+	object, err := LoadSchemaByPath(ctx, or.name.ObjectType, m, WithDirectories(dir))
 	if err != nil {
 		return nil, err
 	}
-	return object.ToJson(ctx)
+	j, err := object.ToJson(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if or.name.QueryParams.Get("expand") == "parameters" && object.Type() == types.CatalogObjectTypeCollectionSchema {
+		return expandParameterSchemas(ctx, j, *m, WithDirectories(dir))
+	}
+	return j, nil
+}
+
+// resolvedParameter is the parameter schema that a collection schema parameter resolves to
+type resolvedParameter struct {
+	Schema     string          `json:"schema"`
+	Namespace  string          `json:"namespace,omitempty"`
+	DataType   string          `json:"dataType"`
+	Validation json.RawMessage `json:"validation,omitempty"`
+}
+
+// expandParameterSchemas adds a resolved block to the collection schema json with the parameter schema that each
+// parameter referring to a schema resolves to. The block is only part of the response and is not stored or hashed.
+func expandParameterSchemas(ctx context.Context, schemaJson []byte, m schemamanager.SchemaMetadata, opts ...ObjectStoreOption) ([]byte, apperrors.Error) {
+	loaders := getSchemaLoaders(ctx, m, opts...)
+	if loaders.ClosestParent == nil {
+		return nil, ErrCatalogError.Msg("unable to resolve parameter schemas")
+	}
+
+	namespaces := make(map[string]struct{})
+	if !m.Namespace.IsNil() {
+		namespaces[m.Namespace.String()] = struct{}{}
+	}
+	resolved := make(map[string]resolvedParameter)
+	var rErr apperrors.Error
+	gjson.GetBytes(schemaJson, "spec.parameters").ForEach(func(name, p gjson.Result) bool {
+		schemaName := p.Get("schema").String()
+		if schemaName == "" {
+			return true
+		}
+		ps, pm, err := loadParameterSchema(ctx, loaders, m, namespaces, name.String(), schemaName)
+		if err != nil {
+			rErr = err
+			return false
+		}
+		pj, err := ps.ToJson(ctx)
+		if err != nil {
+			rErr = err
+			return false
+		}
+		r := resolvedParameter{
+			Schema:    path.Clean(pm.Path + "/" + pm.Name),
+			Namespace: pm.Namespace.String(),
+			DataType:  gjson.GetBytes(pj, "spec.dataType").String(),
+		}
+		if v := gjson.GetBytes(pj, "spec.validation"); v.Exists() && v.Type != gjson.Null {
+			r.Validation = json.RawMessage(v.Raw)
+		}
+		resolved[name.String()] = r
+		return true
+	})
+	if rErr != nil {
+		return nil, rErr
+	}
+
+	j, err := sjson.SetBytes(schemaJson, "resolved.parameters", resolved)
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Msg("failed to add resolved parameters")
+		return nil, ErrCatalogError.Msg("unable to expand parameter schemas")
+	}
+	return j, nil
+}
+
+// loadParameterSchema loads the parameter schema that the parameter of the collection schema described by m
+// resolves to, along with the metadata of the parameter schema.
+func loadParameterSchema(ctx context.Context, loaders schemamanager.SchemaLoaders, m schemamanager.SchemaMetadata, namespaces map[string]struct{}, param, schemaName string) (schemamanager.SchemaManager, schemamanager.SchemaMetadata, apperrors.Error) {
+	schemaPath, hash, err := loaders.ClosestParent(ctx, types.CatalogObjectTypeParameterSchema, schemaName)
+	if err != nil {
+		return nil, schemamanager.SchemaMetadata{}, ErrObjectNotFound.Msg("unable to resolve parameter schema " + schemaName + " for parameter " + param)
+	}
+	if ns, _ := schemamanager.SplitSchemaRef(schemaName); ns != "" {
+		namespaces = map[string]struct{}{ns: {}}
+	}
+	pm := exportMetadataFromPath(schemaPath, namespaces)
+	pm.Catalog = m.Catalog
+	pm.Variant = m.Variant
+	pm.IDS = m.IDS
+	ps, err := LoadSchemaByHash(ctx, hash, &pm)
+	if err != nil {
+		return nil, pm, err
+	}
+	return ps, pm, nil
 }
 
 func (or *objectResource) Update(ctx context.Context, rsrcJson []byte) apperrors.Error {
@@ -1125,6 +1833,7 @@ func (or *objectResource) Update(ctx context.Context, rsrcJson []byte) apperrors
 		log.Ctx(ctx).Error().Err(err).Msg("failed to save object")
 		return err
 	}
+	or.warnings = deprecatedParameterWarnings(ctx, newSchema, dir)
 
 	return nil
 }
@@ -1152,7 +1861,11 @@ func (or *objectResource) Delete(ctx context.Context) apperrors.Error {
 		Namespace: types.NullableStringFrom(or.name.Namespace),
 	}
 	pathWithName := path.Clean(m.GetStoragePath(or.name.ObjectType) + "/" + or.name.ObjectName)
-	err = DeleteSchema(ctx, or.name.ObjectType, m, dir)
+	var opts []ObjectStoreOption
+	if or.name.QueryParams.Get("recursive") == "true" {
+		opts = append(opts, WithRecursiveDelete())
+	}
+	err = DeleteSchema(ctx, or.name.ObjectType, m, dir, opts...)
 	if err != nil {
 		log.Ctx(ctx).Error().Err(err).Str("path", pathWithName).Msg("failed to delete object")
 		return err
@@ -1160,6 +1873,35 @@ func (or *objectResource) Delete(ctx context.Context) apperrors.Error {
 	return nil
 }
 
+// ForceDeleteParameter deletes the parameter schema named by reqCtx with ForceDeleteParameterSchema and returns
+// the paths of the collection schemas that were modified
+func ForceDeleteParameter(ctx context.Context, reqCtx RequestContext) ([]string, apperrors.Error) {
+	if reqCtx.WorkspaceID == uuid.Nil && reqCtx.VariantID == uuid.Nil {
+		return nil, ErrInvalidWorkspace
+	}
+	var dir Directories
+	var err apperrors.Error
+	if reqCtx.WorkspaceID != uuid.Nil {
+		dir, err = getDirectoriesForWorkspace(ctx, reqCtx.WorkspaceID)
+	} else {
+		dir, err = getDirectoriesForVariant(ctx, reqCtx.VariantID)
+	}
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Str("workspace_id", reqCtx.WorkspaceID.String()).Str("variant_id", reqCtx.VariantID.String()).Msg("failed to get directories")
+		return nil, ErrInvalidWorkspace
+	}
+	m := &schemamanager.SchemaMetadata{
+		Catalog:   reqCtx.Catalog,
+		Variant:   types.NullableStringFrom(reqCtx.Variant),
+		Path:      reqCtx.ObjectPath,
+		Name:      reqCtx.ObjectName,
+		Namespace: types.NullableStringFrom(reqCtx.Namespace),
+	}
+	m.IDS.CatalogID = reqCtx.CatalogID
+	m.IDS.VariantID = reqCtx.VariantID
+	return ForceDeleteParameterSchema(ctx, m, dir)
+}
+
 func NewSchemaResource(ctx context.Context, name RequestContext) (schemamanager.ResourceManager, apperrors.Error) {
 	if name.Catalog == "" || name.CatalogID == uuid.Nil {
 		return nil, ErrInvalidCatalog
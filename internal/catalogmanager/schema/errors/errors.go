@@ -1,5 +1,7 @@
 package errors
 
+import "encoding/json"
+
 func ErrMissingRequiredAttribute(attr string, value ...any) ValidationError {
 	return ValidationError{
 		Field:  attr,
@@ -17,6 +19,14 @@ func ErrInvalidStepValue(attr string, value ...any) ValidationError {
 	}
 }
 
+func ErrInvalidMultipleOfValue(attr string, value ...any) ValidationError {
+	return ValidationError{
+		Field:  attr,
+		Value:  value,
+		ErrStr: "multipleOf value is invalid: must be greater than zero",
+	}
+}
+
 func ErrMissingSchemaOrType(attr string, value ...any) ValidationError {
 	return ValidationError{
 		Field:  attr,
@@ -36,7 +46,7 @@ func ErrMaxValueLessThanMinValue(attr string, value ...any) ValidationError {
 	return ValidationError{
 		Field:  attr,
 		Value:  value,
-		ErrStr: "maxValue must be greater than minValue",
+		ErrStr: "maxValue must be greater than or equal to minValue",
 	}
 }
 
@@ -78,6 +88,36 @@ func ErrInvalidObjectPath(attr string, value ...any) ValidationError {
 	}
 }
 
+// ErrInvalidResourceName explains the naming rule for an invalid name and suggests a valid alternative
+// if suggestion is not empty.
+func ErrInvalidResourceName(attr string, value string, suggestion string) ValidationError {
+	errStr := "invalid name " + InQuotes(value) + "; names may contain only lowercase letters, digits and '-', " +
+		"must start and end with a letter or digit, must not contain spaces or '/' and must be at most 63 characters"
+	if suggestion != "" && suggestion != value {
+		errStr += "; did you mean " + InQuotes(suggestion) + "?"
+	}
+	return ValidationError{
+		Field:  attr,
+		Value:  value,
+		ErrStr: errStr,
+	}
+}
+
+// ErrInvalidResourcePath explains the rule for an invalid path and suggests a valid alternative
+// if suggestion is not empty.
+func ErrInvalidResourcePath(attr string, value string, suggestion string) ValidationError {
+	errStr := "invalid path " + InQuotes(value) + "; paths must start with '/', each segment may contain only " +
+		"lowercase letters, digits and '-', and '.' or '..' segments are not allowed"
+	if suggestion != "" && suggestion != value {
+		errStr += "; did you mean " + InQuotes(suggestion) + "?"
+	}
+	return ValidationError{
+		Field:  attr,
+		Value:  value,
+		ErrStr: errStr,
+	}
+}
+
 func ErrInvalidCatalogVersion(attr string, value ...any) ValidationError {
 	return ValidationError{
 		Field:  attr,
@@ -154,6 +194,14 @@ func ErrParameterSchemaDoesNotExist(attr string, value ...any) ValidationError {
 	}
 }
 
+func ErrNamespaceDoesNotExist(attr string, value ...any) ValidationError {
+	return ValidationError{
+		Field:  attr,
+		Value:  value,
+		ErrStr: "namespace does not exist",
+	}
+}
+
 func ErrInvalidValue(attr string, value ...any) ValidationError {
 	errStr := "invalid value"
 	if len(value) > 0 {
@@ -175,3 +223,92 @@ func ErrInvalidParameter(attr string, value ...any) ValidationError {
 		ErrStr: "invalid parameter",
 	}
 }
+
+// ErrNullValue is the error for an explicit null value of parameter param, which is not nullable
+func ErrNullValue(param string) ValidationError {
+	return ValidationError{
+		Field:  param,
+		ErrStr: "is not nullable",
+	}
+}
+
+// ErrSensitiveDefault is the error for a default of a sensitive parameter, which would be stored unencrypted
+func ErrSensitiveDefault(attr string) ValidationError {
+	return ValidationError{
+		Field:  attr,
+		ErrStr: "sensitive parameters cannot have a default",
+	}
+}
+
+// ErrSensitiveNotEnabled is the error for a sensitive parameter when no key to encrypt its values is configured
+func ErrSensitiveNotEnabled(attr string) ValidationError {
+	return ValidationError{
+		Field:  attr,
+		ErrStr: "sensitive parameters are not enabled",
+	}
+}
+
+func ErrInvalidUnit(attr string) ValidationError {
+	return ValidationError{
+		Field:  attr,
+		ErrStr: "unit must be a string",
+	}
+}
+
+func ErrExtendedDataTypeMismatch(attr, dataType string) ValidationError {
+	return ValidationError{
+		Field:  attr,
+		Value:  dataType,
+		ErrStr: "must be " + dataType + ", the data type of the extended parameter schema",
+	}
+}
+
+func ErrDefaultAndDefaultExpr(attr string, value ...any) ValidationError {
+	return ValidationError{
+		Field:  attr,
+		Value:  value,
+		ErrStr: "default and defaultExpr cannot both be set",
+	}
+}
+
+func ErrInvalidDefaultExpr(attr string, value ...any) ValidationError {
+	errStr := "invalid default expression"
+	if len(value) > 0 {
+		if str, ok := value[0].(string); ok {
+			errStr += ": " + str
+		}
+	}
+	return ValidationError{
+		Field:  attr,
+		Value:  value,
+		ErrStr: errStr,
+	}
+}
+
+func ErrCyclicDefaultExpr(attr string, value ...any) ValidationError {
+	errStr := "default expressions refer to each other"
+	if len(value) > 0 {
+		if str, ok := value[0].(string); ok {
+			errStr += ": " + str
+		}
+	}
+	return ValidationError{
+		Field:  attr,
+		Value:  value,
+		ErrStr: errStr,
+	}
+}
+
+// ErrValueTypeMismatch is the error for a value of parameter param that is not of the data type dataType. Its
+// Value is a TypeMismatch.
+func ErrValueTypeMismatch(param string, dataType string, value any) ValidationError {
+	got, err := json.Marshal(value)
+	if err != nil {
+		got = []byte("an invalid value")
+	}
+	return ValidationError{
+		Field:  param,
+		Value:  TypeMismatch{DataType: dataType, Value: value},
+		ErrStr: "expects " + dataType + ", got " + string(got),
+	}
+}
@@ -22,6 +22,12 @@ func (ve ValidationError) Error() string {
 	}
 }
 
+// TypeMismatch is the Value of a ValidationError for a value that is not of the data type of its parameter
+type TypeMismatch struct {
+	DataType string // the data type of the parameter
+	Value    any    // the value that is not of that type
+}
+
 // ErrInvalidSchema is an error indicating that the schema is invalid.
 var ErrInvalidSchema = ValidationError{
 	Field:  "invalid input",
@@ -0,0 +1,41 @@
+package schemavalidator
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/mugiliam/hatchcatalogsrv/pkg/types"
+)
+
+var invalidNameChars = regexp.MustCompile(`[^a-z0-9]+`)
+
+// NormalizeResourceName returns the closest valid resource name to name: lower case, with every run of
+// characters outside [a-z0-9] replaced by a single '-', no leading or trailing '-', and at most 63
+// characters. It returns an empty string if nothing of name survives.
+func NormalizeResourceName(name string) string {
+	n := invalidNameChars.ReplaceAllString(strings.ToLower(name), "-")
+	n = strings.Trim(n, "-")
+	if len(n) > resourceNameMaxLength {
+		n = strings.TrimRight(n[:resourceNameMaxLength], "-")
+	}
+	return n
+}
+
+// NormalizeResourcePath returns the closest valid resource path to p. It is rooted at '/', drops empty,
+// '.' and '..' segments and normalizes every remaining segment as a resource name.
+func NormalizeResourcePath(p string) string {
+	var segments []string
+	for i, s := range strings.Split(p, "/") {
+		if s == "" || s == "." || s == ".." {
+			continue
+		}
+		if i <= 1 && s == types.RootNamespace() {
+			segments = append(segments, s)
+			continue
+		}
+		if n := NormalizeResourceName(s); n != "" {
+			segments = append(segments, n)
+		}
+	}
+	return "/" + strings.Join(segments, "/")
+}
@@ -50,6 +50,14 @@ func nameFormatValidator(fl validator.FieldLevel) bool {
 	return re.MatchString(str)
 }
 
+const schemaRefRegex = `^([A-Za-z0-9_-]+/)?[A-Za-z0-9_-]+$`
+
+// schemaRefValidator checks if the given schema reference is a name, optionally qualified by a namespace.
+func schemaRefValidator(fl validator.FieldLevel) bool {
+	re := regexp.MustCompile(schemaRefRegex)
+	return re.MatchString(fl.Field().String())
+}
+
 const resourceNameRegex = `^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`
 const resourceNameMaxLength = 63
 
@@ -74,6 +82,14 @@ func resourceNameValidator(fl validator.FieldLevel) bool {
 	return re.MatchString(str)
 }
 
+// namespaceValidator checks if the given namespace is a resource name or the name of the root namespace
+func namespaceValidator(fl validator.FieldLevel) bool {
+	if ns, ok := fl.Field().Interface().(types.NullableString); ok && types.IsRootNamespace(ns.String()) {
+		return true
+	}
+	return resourceNameValidator(fl)
+}
+
 // notNull checks if a nullable value is not null
 func notNull(fl validator.FieldLevel) bool {
 	nv, ok := fl.Field().Interface().(types.Nullable)
@@ -105,7 +121,7 @@ func resourcePathValidator(fl validator.FieldLevel) bool {
 		if collection == "" {
 			continue
 		}
-		if n == 0 && collection == types.DefaultNamespace {
+		if n == 0 && collection == types.RootNamespace() {
 			continue // Skip the first segment if it's the default namespace
 		}
 		// Validate each folder name using the regex
@@ -139,6 +155,15 @@ func ValidateSchemaName(name string) bool {
 	return re.MatchString(name)
 }
 
+// ValidateNamespaceName checks if name follows the rules for the name of a namespace
+func ValidateNamespaceName(name string) bool {
+	if len(name) > resourceNameMaxLength {
+		return false
+	}
+	re := regexp.MustCompile(resourceNameRegex)
+	return re.MatchString(name)
+}
+
 func ValidateSchemaKind(kind string) bool {
 	return slices.Contains(validKinds, kind)
 }
@@ -146,7 +171,9 @@ func ValidateSchemaKind(kind string) bool {
 func init() {
 	V().RegisterValidation("kindValidator", kindValidator)
 	V().RegisterValidation("resourceNameValidator", resourceNameValidator)
+	V().RegisterValidation("namespaceValidator", namespaceValidator)
 	V().RegisterValidation("nameFormatValidator", nameFormatValidator)
+	V().RegisterValidation("schemaRefValidator", schemaRefValidator)
 	V().RegisterValidation("noSpaces", noSpacesValidator)
 	V().RegisterValidation("resourcePathValidator", resourcePathValidator)
 	V().RegisterValidation("catalogVersionValidator", catalogVersionValidator)
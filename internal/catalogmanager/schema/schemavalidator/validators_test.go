@@ -1,9 +1,11 @@
 package schemavalidator
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/go-playground/validator/v10"
+	"github.com/mugiliam/hatchcatalogsrv/pkg/types"
 )
 
 func TestResourcePathValidator(t *testing.T) {
@@ -60,3 +62,58 @@ func TestNoSpacesValidator(t *testing.T) {
 		}
 	}
 }
+
+func TestNormalizeResourceName(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"valid-name", "valid-name"},
+		{"My Collection", "my-collection"},
+		{"  spaces  around  ", "spaces-around"},
+		{"/leading-slash", "leading-slash"},
+		{"../traversal", "traversal"},
+		{"snake_case_Name", "snake-case-name"},
+		{"!!!", ""},
+		{strings.Repeat("a", 62) + "-b", strings.Repeat("a", 62)},
+	}
+
+	for _, test := range tests {
+		got := NormalizeResourceName(test.input)
+		if got != test.expected {
+			t.Errorf("Expected '%s' for input '%s', got '%s'", test.expected, test.input, got)
+		}
+		if got != "" && !ValidateSchemaName(got) {
+			t.Errorf("Normalized name '%s' for input '%s' is not valid", got, test.input)
+		}
+	}
+}
+
+func TestNormalizeResourcePath(t *testing.T) {
+	validate := validator.New()
+	validate.RegisterValidation("resourcepath", resourcePathValidator)
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"/valid/path", "/valid/path"},
+		{"relative/path", "/relative/path"},
+		{"//double//slashes/", "/double/slashes"},
+		{"/a/../b", "/a/b"},
+		{"/../../etc", "/etc"},
+		{"/./My Folder", "/my-folder"},
+		{"/" + types.DefaultNamespace + "/Apps", "/" + types.DefaultNamespace + "/apps"},
+		{"", "/"},
+	}
+
+	for _, test := range tests {
+		got := NormalizeResourcePath(test.input)
+		if got != test.expected {
+			t.Errorf("Expected '%s' for input '%s', got '%s'", test.expected, test.input, got)
+		}
+		if err := validate.Var(got, "resourcepath"); err != nil {
+			t.Errorf("Normalized path '%s' for input '%s' is not valid", got, test.input)
+		}
+	}
+}
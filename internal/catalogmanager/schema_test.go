@@ -2,18 +2,30 @@ package catalogmanager
 
 import (
 	"context"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
 	"strings"
+	"sync"
 	"testing"
 
+	"github.com/google/uuid"
 	"github.com/jackc/pgtype"
+	"github.com/mugiliam/common/apperrors"
 	_ "github.com/mugiliam/hatchcatalogsrv/internal/catalogmanager/schema/schemavalidator"
+	"github.com/mugiliam/hatchcatalogsrv/internal/catalogmanager/schemamanager"
+	"github.com/mugiliam/hatchcatalogsrv/internal/catalogmanager/validationerrors"
 	"github.com/mugiliam/hatchcatalogsrv/internal/common"
+	"github.com/mugiliam/hatchcatalogsrv/internal/config"
 	"github.com/mugiliam/hatchcatalogsrv/internal/db"
+	"github.com/mugiliam/hatchcatalogsrv/internal/db/dberror"
 	"github.com/mugiliam/hatchcatalogsrv/internal/db/models"
 	"github.com/mugiliam/hatchcatalogsrv/pkg/types"
 	"github.com/rs/zerolog/log"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
 	"github.com/tidwall/sjson"
 	"sigs.k8s.io/yaml"
 )
@@ -1413,3 +1425,1931 @@ func newDb() context.Context {
 func replaceTabsWithSpaces(s *string) {
 	*s = strings.ReplaceAll(*s, "\t", "    ")
 }
+
+func TestParameterDefaultsRevalidatedAcrossNamespaces(t *testing.T) {
+	collectionYaml := `
+	version: v1
+	kind: CollectionSchema
+	metadata:
+		name: ns-collection
+		catalog: example-catalog
+		namespace: my-namespace
+	spec:
+		parameters:
+			maxRetries:
+				schema: integer-param-schema
+				default: 8
+	`
+	paramYaml := `
+				version: v1
+				kind: ParameterSchema
+				metadata:
+				  name: integer-param-schema
+				  catalog: example-catalog
+				spec:
+				  dataType: Integer
+				  validation:
+				    minValue: 1
+				    maxValue: 10
+	`
+	replaceTabsWithSpaces(&collectionYaml)
+	replaceTabsWithSpaces(&paramYaml)
+
+	ctx := newDb()
+	t.Cleanup(func() {
+		db.DB(ctx).Close(ctx)
+	})
+
+	tenantID := types.TenantId("TABCDE")
+	projectID := types.ProjectId("PABCDE")
+	ctx = common.SetTenantIdInContext(ctx, tenantID)
+	ctx = common.SetProjectIdInContext(ctx, projectID)
+
+	err := db.DB(ctx).CreateTenant(ctx, tenantID)
+	assert.NoError(t, err)
+	t.Cleanup(func() {
+		_ = db.DB(ctx).DeleteTenant(ctx, tenantID)
+	})
+	err = db.DB(ctx).CreateProject(ctx, projectID)
+	assert.NoError(t, err)
+
+	cat := &models.Catalog{
+		Name:        "example-catalog",
+		Description: "An example catalog",
+		Info:        pgtype.JSONB{Status: pgtype.Null},
+		ProjectID:   projectID,
+	}
+	err = db.DB(ctx).CreateCatalog(ctx, cat)
+	assert.NoError(t, err)
+	varId, err := db.DB(ctx).GetVariantIDFromName(ctx, cat.CatalogID, types.DefaultVariant)
+	assert.NoError(t, err)
+	err = db.DB(ctx).CreateNamespace(ctx, &models.Namespace{
+		Name:      "my-namespace",
+		VariantID: varId,
+	})
+	assert.NoError(t, err)
+	ws := &models.Workspace{
+		Info:        pgtype.JSONB{Status: pgtype.Null},
+		BaseVersion: 1,
+		VariantID:   varId,
+	}
+	err = db.DB(ctx).CreateWorkspace(ctx, ws)
+	assert.NoError(t, err)
+
+	saveParam := func(maxValue int, namespace string, opts ...ObjectStoreOption) error {
+		jsonData, err := yaml.YAMLToJSON([]byte(paramYaml))
+		require.NoError(t, err)
+		b, err := sjson.Set(string(jsonData), "spec.validation.maxValue", maxValue)
+		require.NoError(t, err)
+		if namespace != "" {
+			b, err = sjson.Set(b, "metadata.namespace", namespace)
+			require.NoError(t, err)
+		}
+		ps, err := NewSchema(ctx, []byte(b), nil)
+		require.NoError(t, err)
+		return SaveSchema(ctx, ps, append(opts, WithWorkspaceID(ws.WorkspaceID))...)
+	}
+
+	// parameter in the root namespace
+	require.NoError(t, saveParam(10, ""))
+
+	// collection schema in a namespace binds to the parameter in the root namespace
+	jsonData, err := yaml.YAMLToJSON([]byte(collectionYaml))
+	require.NoError(t, err)
+	cs, err := NewSchema(ctx, jsonData, nil)
+	require.NoError(t, err)
+	require.NoError(t, SaveSchema(ctx, cs, WithWorkspaceID(ws.WorkspaceID)))
+
+	// lowering the max value of the root parameter below the default in the namespace collection is rejected
+	// and the error names the collection schema
+	err = saveParam(5, "", IgnoreSchemaSpecChange())
+	if assert.Error(t, err) {
+		assert.ErrorIs(t, err, ErrInvalidDefaultInReference)
+		assert.Contains(t, err.Error(), "/--root--/my-namespace/ns-collection")
+	}
+
+	// a max value that still admits the default is accepted
+	assert.NoError(t, saveParam(9, "", IgnoreSchemaSpecChange()))
+
+	// a parameter in the namespace would shadow the root parameter for the collection, and its max value
+	// does not admit the default
+	err = saveParam(3, "my-namespace")
+	assert.ErrorIs(t, err, ErrSchemaConflict)
+
+	// skipping revalidation lets the root parameter through
+	assert.NoError(t, saveParam(5, "", IgnoreSchemaSpecChange(), SkipRevalidationOnSchemaChange()))
+}
+
+func TestSaveSchemaRollsBackOnReferenceSyncFailure(t *testing.T) {
+	collectionYaml := `
+	version: v1
+	kind: CollectionSchema
+	metadata:
+		name: tx-collection
+		catalog: example-catalog
+	spec:
+		parameters:
+			maxRetries:
+				schema: integer-param-schema
+				default: 8
+	`
+	paramYaml := `
+				version: v1
+				kind: ParameterSchema
+				metadata:
+				  name: integer-param-schema
+				  catalog: example-catalog
+				spec:
+				  dataType: Integer
+				  validation:
+				    minValue: 1
+				    maxValue: 10
+	`
+	replaceTabsWithSpaces(&collectionYaml)
+	replaceTabsWithSpaces(&paramYaml)
+
+	ctx := newDb()
+	t.Cleanup(func() {
+		db.DB(ctx).Close(ctx)
+	})
+
+	tenantID := types.TenantId("TABCDE")
+	projectID := types.ProjectId("PABCDE")
+	ctx = common.SetTenantIdInContext(ctx, tenantID)
+	ctx = common.SetProjectIdInContext(ctx, projectID)
+
+	err := db.DB(ctx).CreateTenant(ctx, tenantID)
+	assert.NoError(t, err)
+	t.Cleanup(func() {
+		_ = db.DB(ctx).DeleteTenant(ctx, tenantID)
+	})
+	err = db.DB(ctx).CreateProject(ctx, projectID)
+	assert.NoError(t, err)
+
+	cat := &models.Catalog{
+		Name:        "example-catalog",
+		Description: "An example catalog",
+		Info:        pgtype.JSONB{Status: pgtype.Null},
+		ProjectID:   projectID,
+	}
+	err = db.DB(ctx).CreateCatalog(ctx, cat)
+	assert.NoError(t, err)
+	varId, err := db.DB(ctx).GetVariantIDFromName(ctx, cat.CatalogID, types.DefaultVariant)
+	assert.NoError(t, err)
+	ws := &models.Workspace{
+		Info:        pgtype.JSONB{Status: pgtype.Null},
+		BaseVersion: 1,
+		VariantID:   varId,
+	}
+	err = db.DB(ctx).CreateWorkspace(ctx, ws)
+	assert.NoError(t, err)
+	dir, err := getDirectoriesForWorkspace(ctx, ws.WorkspaceID)
+	require.NoError(t, err)
+
+	jsonData, err := yaml.YAMLToJSON([]byte(paramYaml))
+	require.NoError(t, err)
+	ps, err := NewSchema(ctx, jsonData, nil)
+	require.NoError(t, err)
+	require.NoError(t, SaveSchema(ctx, ps, WithWorkspaceID(ws.WorkspaceID)))
+
+	// fail the reference sync after the object and directory entry have been written
+	injected := ErrCatalogError.Msg("injected failure")
+	syncCollectionReferences = func(context.Context, uuid.UUID, string, schemamanager.SchemaReferences, schemamanager.SchemaReferences) apperrors.Error {
+		return injected
+	}
+	t.Cleanup(func() {
+		syncCollectionReferences = syncCollectionReferencesInParameters
+	})
+
+	jsonData, err = yaml.YAMLToJSON([]byte(collectionYaml))
+	require.NoError(t, err)
+	cs, err := NewSchema(ctx, jsonData, nil)
+	require.NoError(t, err)
+	err = SaveSchema(ctx, cs, WithWorkspaceID(ws.WorkspaceID))
+	assert.ErrorIs(t, err, injected)
+	assert.False(t, db.DB(ctx).InTx())
+
+	// the directory entry was rolled back along with the object
+	_, err = db.DB(ctx).GetObjectRefByPath(ctx, types.CatalogObjectTypeCollectionSchema, dir.CollectionsDir, "/--root--/tx-collection")
+	assert.ErrorIs(t, err, dberror.ErrNotFound)
+	refs, err := db.DB(ctx).GetAllReferences(ctx, types.CatalogObjectTypeParameterSchema, dir.ParametersDir, "/--root--/integer-param-schema")
+	require.NoError(t, err)
+	assert.Empty(t, refs)
+
+	// with the sync restored the same save goes through
+	syncCollectionReferences = syncCollectionReferencesInParameters
+	cs, err = NewSchema(ctx, jsonData, nil)
+	require.NoError(t, err)
+	require.NoError(t, SaveSchema(ctx, cs, WithWorkspaceID(ws.WorkspaceID)))
+	_, err = db.DB(ctx).GetObjectRefByPath(ctx, types.CatalogObjectTypeCollectionSchema, dir.CollectionsDir, "/--root--/tx-collection")
+	assert.NoError(t, err)
+	refs, err = db.DB(ctx).GetAllReferences(ctx, types.CatalogObjectTypeParameterSchema, dir.ParametersDir, "/--root--/integer-param-schema")
+	require.NoError(t, err)
+	assert.Len(t, refs, 1)
+}
+
+func TestNewSchemaVersions(t *testing.T) {
+	paramYaml := `
+				version: v1beta1
+				kind: ParameterSchema
+				metadata:
+				  name: integer-param-schema
+				  catalog: example-catalog
+				spec:
+				  dataType: Integer
+				  validation:
+				    minValue: 1
+				    maxValue: 10
+	`
+	replaceTabsWithSpaces(&paramYaml)
+
+	ctx := newDb()
+	t.Cleanup(func() {
+		db.DB(ctx).Close(ctx)
+	})
+
+	tenantID := types.TenantId("TABCDE")
+	projectID := types.ProjectId("PABCDE")
+	ctx = common.SetTenantIdInContext(ctx, tenantID)
+	ctx = common.SetProjectIdInContext(ctx, projectID)
+
+	err := db.DB(ctx).CreateTenant(ctx, tenantID)
+	assert.NoError(t, err)
+	t.Cleanup(func() {
+		_ = db.DB(ctx).DeleteTenant(ctx, tenantID)
+	})
+	err = db.DB(ctx).CreateProject(ctx, projectID)
+	assert.NoError(t, err)
+
+	cat := &models.Catalog{
+		Name:        "example-catalog",
+		Description: "An example catalog",
+		Info:        pgtype.JSONB{Status: pgtype.Null},
+		ProjectID:   projectID,
+	}
+	err = db.DB(ctx).CreateCatalog(ctx, cat)
+	assert.NoError(t, err)
+
+	jsonData, err := yaml.YAMLToJSON([]byte(paramYaml))
+	require.NoError(t, err)
+
+	// v1beta1 is an alias of v1 and is stored as v1
+	ps, err := NewSchema(ctx, jsonData, nil)
+	require.NoError(t, err)
+	j, err := ps.ToJson(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, types.VersionV1, gjson.GetBytes(j, "version").String())
+	assert.Equal(t, types.VersionV1, ps.StorageRepresentation().Version)
+
+	// versions without registered managers are rejected
+	b, err := sjson.SetBytes(jsonData, "version", "v2")
+	require.NoError(t, err)
+	_, err = NewSchema(ctx, b, nil)
+	assert.ErrorIs(t, err, validationerrors.ErrInvalidVersion)
+}
+
+func TestSaveValueCollectionNotFound(t *testing.T) {
+	collectionYaml := `
+	version: v1
+	kind: CollectionSchema
+	metadata:
+		name: app-config-collection
+		catalog: example-catalog
+	spec:
+		parameters:
+			maxDelay:
+				dataType: Integer
+				default: 1000
+	`
+	valueYaml := `
+	version: v1
+	kind: Value
+	metadata:
+		catalog: example-catalog
+		variant: default
+		collection: /app-config-collection
+	spec:
+		maxDelay: 2000
+	`
+	replaceTabsWithSpaces(&collectionYaml)
+	replaceTabsWithSpaces(&valueYaml)
+
+	ctx := newDb()
+	t.Cleanup(func() {
+		db.DB(ctx).Close(ctx)
+	})
+
+	tenantID := types.TenantId("TABCDE")
+	projectID := types.ProjectId("PABCDE")
+	ctx = common.SetTenantIdInContext(ctx, tenantID)
+	ctx = common.SetProjectIdInContext(ctx, projectID)
+
+	err := db.DB(ctx).CreateTenant(ctx, tenantID)
+	assert.NoError(t, err)
+	t.Cleanup(func() {
+		_ = db.DB(ctx).DeleteTenant(ctx, tenantID)
+	})
+	err = db.DB(ctx).CreateProject(ctx, projectID)
+	assert.NoError(t, err)
+
+	cat := &models.Catalog{
+		Name:        "example-catalog",
+		Description: "An example catalog",
+		Info:        pgtype.JSONB{Status: pgtype.Null},
+		ProjectID:   projectID,
+	}
+	err = db.DB(ctx).CreateCatalog(ctx, cat)
+	assert.NoError(t, err)
+	varId, err := db.DB(ctx).GetVariantIDFromName(ctx, cat.CatalogID, types.DefaultVariant)
+	assert.NoError(t, err)
+	ws := &models.Workspace{
+		Info:        pgtype.JSONB{Status: pgtype.Null},
+		BaseVersion: 1,
+		VariantID:   varId,
+	}
+	err = db.DB(ctx).CreateWorkspace(ctx, ws)
+	assert.NoError(t, err)
+
+	jsonData, err := yaml.YAMLToJSON([]byte(collectionYaml))
+	require.NoError(t, err)
+	cs, err := NewSchema(ctx, jsonData, nil)
+	require.NoError(t, err)
+	require.NoError(t, SaveSchema(ctx, cs, WithWorkspaceID(ws.WorkspaceID)))
+
+	valueJson, err := yaml.YAMLToJSON([]byte(valueYaml))
+	require.NoError(t, err)
+	require.NoError(t, SaveValue(ctx, valueJson, nil, WithWorkspaceID(ws.WorkspaceID)))
+
+	tests := []struct {
+		name       string
+		collection string
+	}{
+		{name: "typo in the path", collection: "/app-config-colection"},
+		{name: "path missing the leaf", collection: "/apps"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, err := sjson.SetBytes(valueJson, "metadata.collection", tt.collection)
+			require.NoError(t, err)
+			saveErr := SaveValue(ctx, b, nil, WithWorkspaceID(ws.WorkspaceID))
+			if assert.ErrorIs(t, saveErr, ErrCollectionSchemaNotFound) {
+				assert.Equal(t, http.StatusNotFound, saveErr.StatusCode())
+				assert.Contains(t, saveErr.Error(), tt.collection)
+			}
+		})
+	}
+}
+
+func TestGetValueResolvesDefaults(t *testing.T) {
+	paramYaml := `
+	version: v1
+	kind: ParameterSchema
+	metadata:
+		name: integer-param-schema
+		catalog: example-catalog
+	spec:
+		dataType: Integer
+		validation:
+			minValue: 1
+			maxValue: 10
+		default: 5
+	`
+	collectionYaml := `
+	version: v1
+	kind: CollectionSchema
+	metadata:
+		name: app-config-collection
+		catalog: example-catalog
+	spec:
+		parameters:
+			maxRetries:
+				schema: integer-param-schema
+			maxAttempts:
+				schema: integer-param-schema
+				default: 8
+			maxDelay:
+				dataType: Integer
+				default: 1000
+			maxLength:
+				schema: integer-param-schema
+			minDelay:
+				dataType: Integer
+	`
+	valueYaml := `
+	version: v1
+	kind: Value
+	metadata:
+		catalog: example-catalog
+		variant: default
+		collection: /app-config-collection
+	spec:
+		maxLength: 3
+	`
+	replaceTabsWithSpaces(&paramYaml)
+	replaceTabsWithSpaces(&collectionYaml)
+	replaceTabsWithSpaces(&valueYaml)
+
+	ctx := newDb()
+	t.Cleanup(func() {
+		db.DB(ctx).Close(ctx)
+	})
+
+	tenantID := types.TenantId("TABCDE")
+	projectID := types.ProjectId("PABCDE")
+	ctx = common.SetTenantIdInContext(ctx, tenantID)
+	ctx = common.SetProjectIdInContext(ctx, projectID)
+
+	err := db.DB(ctx).CreateTenant(ctx, tenantID)
+	assert.NoError(t, err)
+	t.Cleanup(func() {
+		_ = db.DB(ctx).DeleteTenant(ctx, tenantID)
+	})
+	err = db.DB(ctx).CreateProject(ctx, projectID)
+	assert.NoError(t, err)
+
+	cat := &models.Catalog{
+		Name:        "example-catalog",
+		Description: "An example catalog",
+		Info:        pgtype.JSONB{Status: pgtype.Null},
+		ProjectID:   projectID,
+	}
+	err = db.DB(ctx).CreateCatalog(ctx, cat)
+	assert.NoError(t, err)
+	varId, err := db.DB(ctx).GetVariantIDFromName(ctx, cat.CatalogID, types.DefaultVariant)
+	assert.NoError(t, err)
+	ws := &models.Workspace{
+		Info:        pgtype.JSONB{Status: pgtype.Null},
+		BaseVersion: 1,
+		VariantID:   varId,
+	}
+	err = db.DB(ctx).CreateWorkspace(ctx, ws)
+	assert.NoError(t, err)
+
+	for _, y := range []string{paramYaml, collectionYaml} {
+		jsonData, err := yaml.YAMLToJSON([]byte(y))
+		require.NoError(t, err)
+		s, err := NewSchema(ctx, jsonData, nil)
+		require.NoError(t, err)
+		require.NoError(t, SaveSchema(ctx, s, WithWorkspaceID(ws.WorkspaceID)))
+	}
+
+	valueJson, err := yaml.YAMLToJSON([]byte(valueYaml))
+	require.NoError(t, err)
+	require.NoError(t, SaveValue(ctx, valueJson, nil, WithWorkspaceID(ws.WorkspaceID)))
+
+	dir, err := getDirectoriesForWorkspace(ctx, ws.WorkspaceID)
+	require.NoError(t, err)
+	vs, err := GetValue(ctx, &ValueMetadata{
+		Catalog:    "example-catalog",
+		Variant:    types.NullableStringFrom(types.DefaultVariant),
+		Collection: "/app-config-collection",
+	}, dir)
+	require.NoError(t, err)
+
+	tests := []struct {
+		param  string
+		value  any
+		source ValueSource
+	}{
+		{param: "maxLength", value: float64(3), source: ValueSourceExplicit},
+		{param: "maxAttempts", value: float64(8), source: ValueSourceCollectionDefault},
+		{param: "maxDelay", value: float64(1000), source: ValueSourceCollectionDefault},
+		{param: "maxRetries", value: float64(5), source: ValueSourceParameterDefault},
+	}
+	for _, tt := range tests {
+		t.Run(tt.param, func(t *testing.T) {
+			assert.Equal(t, tt.value, vs.Spec[tt.param].Get())
+			assert.Equal(t, tt.source, vs.Sources[tt.param])
+		})
+	}
+
+	// a parameter with neither a value nor a default has no source
+	assert.True(t, vs.Spec["minDelay"].IsNil())
+	assert.NotContains(t, vs.Sources, "minDelay")
+
+	// setting a value back to its default attributes it to the default
+	b, err := sjson.SetBytes(valueJson, "spec.maxLength", 5)
+	require.NoError(t, err)
+	require.NoError(t, SaveValue(ctx, b, nil, WithWorkspaceID(ws.WorkspaceID)))
+	vs, err = GetValue(ctx, &ValueMetadata{
+		Catalog:    "example-catalog",
+		Variant:    types.NullableStringFrom(types.DefaultVariant),
+		Collection: "/app-config-collection",
+	}, dir)
+	require.NoError(t, err)
+	assert.Equal(t, ValueSourceParameterDefault, vs.Sources["maxLength"])
+}
+
+func TestGetValueExtendsBase(t *testing.T) {
+	paramYaml := `
+	version: v1
+	kind: ParameterSchema
+	metadata:
+		name: integer-param-schema
+		catalog: example-catalog
+	spec:
+		dataType: Integer
+		validation:
+			minValue: 1
+			maxValue: 10
+		default: 5
+	`
+	collectionYaml := `
+	version: v1
+	kind: CollectionSchema
+	metadata:
+		name: base-config
+		catalog: example-catalog
+	spec:
+		parameters:
+			maxRetries:
+				schema: integer-param-schema
+			maxDelay:
+				dataType: Integer
+				default: 1000
+			maxLength:
+				schema: integer-param-schema
+	`
+	baseValueYaml := `
+	version: v1
+	kind: Value
+	metadata:
+		catalog: example-catalog
+		variant: default
+		collection: /base-config
+	spec:
+		maxRetries: 3
+		maxDelay: 2000
+	`
+	overlayValueYaml := `
+	version: v1
+	kind: Value
+	metadata:
+		catalog: example-catalog
+		variant: default
+		collection: /prod-config
+		extends: /base-config
+	spec:
+		maxRetries: 7
+	`
+	replaceTabsWithSpaces(&paramYaml)
+	replaceTabsWithSpaces(&collectionYaml)
+	replaceTabsWithSpaces(&baseValueYaml)
+	replaceTabsWithSpaces(&overlayValueYaml)
+
+	ctx := newDb()
+	t.Cleanup(func() {
+		db.DB(ctx).Close(ctx)
+	})
+
+	tenantID := types.TenantId("TABCDE")
+	projectID := types.ProjectId("PABCDE")
+	ctx = common.SetTenantIdInContext(ctx, tenantID)
+	ctx = common.SetProjectIdInContext(ctx, projectID)
+
+	err := db.DB(ctx).CreateTenant(ctx, tenantID)
+	assert.NoError(t, err)
+	t.Cleanup(func() {
+		_ = db.DB(ctx).DeleteTenant(ctx, tenantID)
+	})
+	err = db.DB(ctx).CreateProject(ctx, projectID)
+	assert.NoError(t, err)
+
+	cat := &models.Catalog{
+		Name:        "example-catalog",
+		Description: "An example catalog",
+		Info:        pgtype.JSONB{Status: pgtype.Null},
+		ProjectID:   projectID,
+	}
+	err = db.DB(ctx).CreateCatalog(ctx, cat)
+	assert.NoError(t, err)
+	varId, err := db.DB(ctx).GetVariantIDFromName(ctx, cat.CatalogID, types.DefaultVariant)
+	assert.NoError(t, err)
+	ws := &models.Workspace{
+		Info:        pgtype.JSONB{Status: pgtype.Null},
+		BaseVersion: 1,
+		VariantID:   varId,
+	}
+	err = db.DB(ctx).CreateWorkspace(ctx, ws)
+	assert.NoError(t, err)
+
+	// the base and the overlay are collection schemas with the same parameters
+	prodYaml := strings.Replace(collectionYaml, "name: base-config", "name: prod-config", 1)
+	for _, y := range []string{paramYaml, collectionYaml, prodYaml} {
+		jsonData, err := yaml.YAMLToJSON([]byte(y))
+		require.NoError(t, err)
+		s, err := NewSchema(ctx, jsonData, nil)
+		require.NoError(t, err)
+		require.NoError(t, SaveSchema(ctx, s, WithWorkspaceID(ws.WorkspaceID)))
+	}
+
+	baseJson, err := yaml.YAMLToJSON([]byte(baseValueYaml))
+	require.NoError(t, err)
+	require.NoError(t, SaveValue(ctx, baseJson, nil, WithWorkspaceID(ws.WorkspaceID)))
+	overlayJson, err := yaml.YAMLToJSON([]byte(overlayValueYaml))
+	require.NoError(t, err)
+	require.NoError(t, SaveValue(ctx, overlayJson, nil, WithWorkspaceID(ws.WorkspaceID)))
+
+	dir, err := getDirectoriesForWorkspace(ctx, ws.WorkspaceID)
+	require.NoError(t, err)
+	getProdValue := func() *valueSchema {
+		t.Helper()
+		vs, err := GetValue(ctx, &ValueMetadata{
+			Catalog:    "example-catalog",
+			Variant:    types.NullableStringFrom(types.DefaultVariant),
+			Collection: "/prod-config",
+		}, dir)
+		require.NoError(t, err)
+		return vs
+	}
+	vs := getProdValue()
+	assert.Equal(t, "/base-config", vs.Metadata.Extends)
+
+	tests := []struct {
+		param  string
+		value  any
+		source ValueSource
+	}{
+		{param: "maxRetries", value: float64(7), source: ValueSourceExplicit},
+		{param: "maxDelay", value: float64(2000), source: ValueSourceBase},
+		{param: "maxLength", value: float64(5), source: ValueSourceParameterDefault},
+	}
+	for _, tt := range tests {
+		t.Run(tt.param, func(t *testing.T) {
+			assert.Equal(t, tt.value, vs.Spec[tt.param].Get())
+			assert.Equal(t, tt.source, vs.Sources[tt.param])
+		})
+	}
+
+	// a base whose values extend the overlay is a cycle
+	b, err := sjson.SetBytes(baseJson, "metadata.extends", "/prod-config")
+	require.NoError(t, err)
+	err = SaveValue(ctx, b, nil, WithWorkspaceID(ws.WorkspaceID))
+	assert.ErrorIs(t, err, ErrCyclicValueExtends)
+
+	// the base must exist
+	b, err = sjson.SetBytes(overlayJson, "metadata.extends", "/no-such-config")
+	require.NoError(t, err)
+	err = SaveValue(ctx, b, nil, WithWorkspaceID(ws.WorkspaceID))
+	assert.ErrorIs(t, err, ErrInvalidValueExtends)
+
+	// re-applying the collection schema keeps the base of its values
+	prodJson, err := yaml.YAMLToJSON([]byte(prodYaml))
+	require.NoError(t, err)
+	s, err := NewSchema(ctx, prodJson, nil)
+	require.NoError(t, err)
+	err = SaveSchema(ctx, s, WithWorkspaceID(ws.WorkspaceID), WithErrorIfEqualToExisting())
+	assert.ErrorIs(t, err, ErrEqualToExistingObject)
+	vs = getProdValue()
+	assert.Equal(t, "/base-config", vs.Metadata.Extends)
+	assert.Equal(t, float64(2000), vs.Spec["maxDelay"].Get())
+
+	// a value that does not name a base keeps the one it has
+	b, err = sjson.DeleteBytes(overlayJson, "metadata.extends")
+	require.NoError(t, err)
+	b, err = sjson.SetBytes(b, "spec.maxRetries", 8)
+	require.NoError(t, err)
+	require.NoError(t, SaveValue(ctx, b, nil, WithWorkspaceID(ws.WorkspaceID)))
+	vs = getProdValue()
+	assert.Equal(t, "/base-config", vs.Metadata.Extends)
+	assert.Equal(t, float64(8), vs.Spec["maxRetries"].Get())
+	assert.Equal(t, float64(2000), vs.Spec["maxDelay"].Get())
+
+	// only the values of the overlay were stored, so without the base the defaults are back
+	b, err = sjson.SetRawBytes(overlayJson, "metadata.extends", []byte("null"))
+	require.NoError(t, err)
+	require.NoError(t, SaveValue(ctx, b, nil, WithWorkspaceID(ws.WorkspaceID)))
+	vs = getProdValue()
+	assert.Empty(t, vs.Metadata.Extends)
+	assert.Equal(t, float64(7), vs.Spec["maxRetries"].Get())
+	assert.Equal(t, float64(1000), vs.Spec["maxDelay"].Get())
+	assert.Equal(t, ValueSourceCollectionDefault, vs.Sources["maxDelay"])
+}
+
+func TestMoveCollectionSchema(t *testing.T) {
+	paramYaml := `
+	version: v1
+	kind: ParameterSchema
+	metadata:
+		name: integer-param-schema
+		catalog: example-catalog
+	spec:
+		dataType: Integer
+		validation:
+			minValue: 1
+			maxValue: 10
+		default: 5
+	`
+	namespaceParamYaml := `
+	version: v1
+	kind: ParameterSchema
+	metadata:
+		name: integer-param-schema
+		catalog: example-catalog
+		namespace: my-namespace
+	spec:
+		dataType: Integer
+		validation:
+			minValue: 1
+			maxValue: 8
+		default: 3
+	`
+	collectionYaml := `
+	version: v1
+	kind: CollectionSchema
+	metadata:
+		name: app-config
+		catalog: example-catalog
+	spec:
+		parameters:
+			maxRetries:
+				schema: integer-param-schema
+			maxDelay:
+				dataType: Integer
+				default: 1000
+	`
+	replaceTabsWithSpaces(&paramYaml)
+	replaceTabsWithSpaces(&namespaceParamYaml)
+	replaceTabsWithSpaces(&collectionYaml)
+
+	ctx := newDb()
+	t.Cleanup(func() {
+		db.DB(ctx).Close(ctx)
+	})
+
+	tenantID := types.TenantId("TABCDE")
+	projectID := types.ProjectId("PABCDE")
+	ctx = common.SetTenantIdInContext(ctx, tenantID)
+	ctx = common.SetProjectIdInContext(ctx, projectID)
+
+	err := db.DB(ctx).CreateTenant(ctx, tenantID)
+	assert.NoError(t, err)
+	t.Cleanup(func() {
+		_ = db.DB(ctx).DeleteTenant(ctx, tenantID)
+	})
+	err = db.DB(ctx).CreateProject(ctx, projectID)
+	assert.NoError(t, err)
+
+	cat := &models.Catalog{
+		Name:        "example-catalog",
+		Description: "An example catalog",
+		Info:        pgtype.JSONB{Status: pgtype.Null},
+		ProjectID:   projectID,
+	}
+	err = db.DB(ctx).CreateCatalog(ctx, cat)
+	assert.NoError(t, err)
+	varId, err := db.DB(ctx).GetVariantIDFromName(ctx, cat.CatalogID, types.DefaultVariant)
+	assert.NoError(t, err)
+	err = db.DB(ctx).CreateNamespace(ctx, &models.Namespace{
+		Name:      "my-namespace",
+		VariantID: varId,
+	})
+	assert.NoError(t, err)
+	ws := &models.Workspace{
+		Info:        pgtype.JSONB{Status: pgtype.Null},
+		BaseVersion: 1,
+		VariantID:   varId,
+	}
+	err = db.DB(ctx).CreateWorkspace(ctx, ws)
+	assert.NoError(t, err)
+	dir, err := getDirectoriesForWorkspace(ctx, ws.WorkspaceID)
+	require.NoError(t, err)
+
+	saveYaml := func(y string) schemamanager.SchemaManager {
+		jsonData, err := yaml.YAMLToJSON([]byte(y))
+		require.NoError(t, err)
+		s, err := NewSchema(ctx, jsonData, nil)
+		require.NoError(t, err)
+		require.NoError(t, SaveSchema(ctx, s, WithWorkspaceID(ws.WorkspaceID)))
+		return s
+	}
+	rootParam := saveYaml(paramYaml).Metadata()
+	nsParam := saveYaml(namespaceParamYaml).Metadata()
+	from := saveYaml(collectionYaml).Metadata()
+	rootParamPath := path.Clean(rootParam.GetStoragePath(types.CatalogObjectTypeParameterSchema) + "/" + rootParam.Name)
+	nsParamPath := path.Clean(nsParam.GetStoragePath(types.CatalogObjectTypeParameterSchema) + "/" + nsParam.Name)
+	fromPath := path.Clean(from.GetStoragePath(types.CatalogObjectTypeCollectionSchema) + "/" + from.Name)
+
+	setMaxRetries := func(collection string, v int) {
+		b, err := sjson.SetBytes([]byte(`{"version":"v1","kind":"Value","metadata":{"catalog":"example-catalog","variant":"default"}}`), "metadata.collection", collection)
+		require.NoError(t, err)
+		b, err = sjson.SetBytes(b, "spec.maxRetries", v)
+		require.NoError(t, err)
+		require.NoError(t, SaveValue(ctx, b, nil, WithWorkspaceID(ws.WorkspaceID)))
+	}
+	refNames := func(p string) []string {
+		r, err := db.DB(ctx).GetObjectRefByPath(ctx, types.CatalogObjectTypeParameterSchema, dir.ParametersDir, p)
+		require.NoError(t, err)
+		var names []string
+		for _, ref := range r.References {
+			names = append(names, ref.Name)
+		}
+		return names
+	}
+	assert.Contains(t, refNames(rootParamPath), fromPath)
+
+	// a value that is invalid for the parameter schema in the namespace blocks the move
+	setMaxRetries("/app-config", 9)
+	to := from
+	to.Namespace = types.NullableStringFrom("my-namespace")
+	to.Name = "moved-config"
+	err = MoveSchema(ctx, types.CatalogObjectTypeCollectionSchema, &from, &to, dir)
+	assert.Error(t, err)
+	_, err = LoadSchemaByPath(ctx, types.CatalogObjectTypeCollectionSchema, &from, WithDirectories(dir))
+	assert.NoError(t, err)
+
+	// the move rebinds maxRetries to the parameter schema in the namespace and keeps the values
+	setMaxRetries("/app-config", 7)
+	err = MoveSchema(ctx, types.CatalogObjectTypeCollectionSchema, &from, &to, dir)
+	require.NoError(t, err)
+	toPath := path.Clean(to.GetStoragePath(types.CatalogObjectTypeCollectionSchema) + "/" + to.Name)
+
+	_, err = LoadSchemaByPath(ctx, types.CatalogObjectTypeCollectionSchema, &from, WithDirectories(dir))
+	assert.ErrorIs(t, err, ErrObjectNotFound)
+	moved, err := LoadSchemaByPath(ctx, types.CatalogObjectTypeCollectionSchema, &to, WithDirectories(dir))
+	require.NoError(t, err)
+	assert.Equal(t, float64(7), moved.CollectionSchemaManager().GetValue(ctx, "maxRetries").Value.Get())
+	assert.Equal(t, float64(1000), moved.CollectionSchemaManager().GetValue(ctx, "maxDelay").Value.Get())
+
+	r, err := db.DB(ctx).GetObjectRefByPath(ctx, types.CatalogObjectTypeCollectionSchema, dir.CollectionsDir, toPath)
+	require.NoError(t, err)
+	if assert.Len(t, r.References, 1) {
+		assert.Equal(t, nsParamPath, r.References[0].Name)
+	}
+	assert.NotContains(t, refNames(rootParamPath), fromPath)
+	assert.Contains(t, refNames(nsParamPath), toPath)
+
+	// the parameter schema now bound cannot be deleted while the moved schema refers to it
+	err = DeleteSchema(ctx, types.CatalogObjectTypeParameterSchema, &nsParam, dir)
+	assert.Error(t, err)
+
+	// moving onto an existing schema fails
+	saveYaml(collectionYaml)
+	err = MoveSchema(ctx, types.CatalogObjectTypeCollectionSchema, &from, &to, dir)
+	assert.ErrorIs(t, err, ErrAlreadyExists)
+
+	// parameter schemas cannot be moved
+	err = MoveSchema(ctx, types.CatalogObjectTypeParameterSchema, &rootParam, &nsParam, dir)
+	assert.ErrorIs(t, err, ErrInvalidRequest)
+}
+
+func TestSaveValueUnknownKeys(t *testing.T) {
+	collectionYaml := `
+	version: v1
+	kind: CollectionSchema
+	metadata:
+		name: app-config-collection
+		catalog: example-catalog
+	spec:
+		parameters:
+			maxDelay:
+				dataType: Integer
+				default: 1000
+	`
+	valueYaml := `
+	version: v1
+	kind: Value
+	metadata:
+		catalog: example-catalog
+		variant: default
+		collection: /app-config-collection
+	spec:
+		maxDelay: 2000
+		unexpected: 1
+		another: 2
+	`
+	replaceTabsWithSpaces(&collectionYaml)
+	replaceTabsWithSpaces(&valueYaml)
+
+	ctx := newDb()
+	t.Cleanup(func() {
+		db.DB(ctx).Close(ctx)
+	})
+
+	tenantID := types.TenantId("TABCDE")
+	projectID := types.ProjectId("PABCDE")
+	ctx = common.SetTenantIdInContext(ctx, tenantID)
+	ctx = common.SetProjectIdInContext(ctx, projectID)
+
+	err := db.DB(ctx).CreateTenant(ctx, tenantID)
+	assert.NoError(t, err)
+	t.Cleanup(func() {
+		_ = db.DB(ctx).DeleteTenant(ctx, tenantID)
+	})
+	err = db.DB(ctx).CreateProject(ctx, projectID)
+	assert.NoError(t, err)
+
+	cat := &models.Catalog{
+		Name:        "example-catalog",
+		Description: "An example catalog",
+		Info:        pgtype.JSONB{Status: pgtype.Null},
+		ProjectID:   projectID,
+	}
+	err = db.DB(ctx).CreateCatalog(ctx, cat)
+	assert.NoError(t, err)
+	varId, err := db.DB(ctx).GetVariantIDFromName(ctx, cat.CatalogID, types.DefaultVariant)
+	assert.NoError(t, err)
+	ws := &models.Workspace{
+		Info:        pgtype.JSONB{Status: pgtype.Null},
+		BaseVersion: 1,
+		VariantID:   varId,
+	}
+	err = db.DB(ctx).CreateWorkspace(ctx, ws)
+	assert.NoError(t, err)
+
+	jsonData, err := yaml.YAMLToJSON([]byte(collectionYaml))
+	require.NoError(t, err)
+	cs, err := NewSchema(ctx, jsonData, nil)
+	require.NoError(t, err)
+	require.NoError(t, SaveSchema(ctx, cs, WithWorkspaceID(ws.WorkspaceID)))
+
+	valueJson, err := yaml.YAMLToJSON([]byte(valueYaml))
+	require.NoError(t, err)
+
+	// the unknown keys are named in the error and nothing is saved
+	err = SaveValue(ctx, valueJson, nil, WithWorkspaceID(ws.WorkspaceID))
+	if assert.ErrorIs(t, err, ErrUnknownValueKeys) {
+		assert.Contains(t, err.Error(), "another, unexpected")
+	}
+	m := cs.Metadata()
+	lr, err := LoadSchemaByPath(ctx, types.CatalogObjectTypeCollectionSchema, &m, WithWorkspaceID(ws.WorkspaceID))
+	require.NoError(t, err)
+	assert.Equal(t, float64(1000), lr.CollectionSchemaManager().GetValue(ctx, "maxDelay").Value.Get())
+
+	// unknown keys are ignored when allowed
+	err = SaveValue(ctx, valueJson, nil, WithWorkspaceID(ws.WorkspaceID), AllowUnknownValues())
+	require.NoError(t, err)
+	lr, err = LoadSchemaByPath(ctx, types.CatalogObjectTypeCollectionSchema, &m, WithWorkspaceID(ws.WorkspaceID))
+	require.NoError(t, err)
+	assert.Equal(t, float64(2000), lr.CollectionSchemaManager().GetValue(ctx, "maxDelay").Value.Get())
+	assert.Nil(t, lr.CollectionSchemaManager().GetValue(ctx, "unexpected").Value.Get())
+
+	// a value of the wrong type is reported with the parameter, its type and the value
+	b, err := sjson.SetBytes(valueJson, "spec", map[string]any{"maxDelay": "two_thousand"})
+	require.NoError(t, err)
+	err = SaveValue(ctx, b, nil, WithWorkspaceID(ws.WorkspaceID))
+	if assert.ErrorIs(t, err, validationerrors.ErrSchemaValidation) {
+		assert.Contains(t, err.Error(), `maxDelay: expects Integer, got "two_thousand"`)
+	}
+}
+
+func TestSaveValueNull(t *testing.T) {
+	collectionYaml := `
+	version: v1
+	kind: CollectionSchema
+	metadata:
+		name: app-config-collection
+		catalog: example-catalog
+	spec:
+		parameters:
+			proxy:
+				dataType: Integer
+				default: 8080
+				nullable: true
+			timeout:
+				dataType: Integer
+				default: 30
+	`
+	replaceTabsWithSpaces(&collectionYaml)
+
+	ctx := newDb()
+	t.Cleanup(func() {
+		db.DB(ctx).Close(ctx)
+	})
+
+	tenantID := types.TenantId("TABCDE")
+	projectID := types.ProjectId("PABCDE")
+	ctx = common.SetTenantIdInContext(ctx, tenantID)
+	ctx = common.SetProjectIdInContext(ctx, projectID)
+
+	err := db.DB(ctx).CreateTenant(ctx, tenantID)
+	assert.NoError(t, err)
+	t.Cleanup(func() {
+		_ = db.DB(ctx).DeleteTenant(ctx, tenantID)
+	})
+	err = db.DB(ctx).CreateProject(ctx, projectID)
+	assert.NoError(t, err)
+
+	cat := &models.Catalog{
+		Name:        "example-catalog",
+		Description: "An example catalog",
+		Info:        pgtype.JSONB{Status: pgtype.Null},
+		ProjectID:   projectID,
+	}
+	err = db.DB(ctx).CreateCatalog(ctx, cat)
+	assert.NoError(t, err)
+	varId, err := db.DB(ctx).GetVariantIDFromName(ctx, cat.CatalogID, types.DefaultVariant)
+	assert.NoError(t, err)
+	ws := &models.Workspace{
+		Info:        pgtype.JSONB{Status: pgtype.Null},
+		BaseVersion: 1,
+		VariantID:   varId,
+	}
+	err = db.DB(ctx).CreateWorkspace(ctx, ws)
+	assert.NoError(t, err)
+
+	jsonData, err := yaml.YAMLToJSON([]byte(collectionYaml))
+	require.NoError(t, err)
+	cs, err := NewSchema(ctx, jsonData, nil)
+	require.NoError(t, err)
+	require.NoError(t, SaveSchema(ctx, cs, WithWorkspaceID(ws.WorkspaceID)))
+	dir, err := getDirectoriesForWorkspace(ctx, ws.WorkspaceID)
+	require.NoError(t, err)
+
+	save := func(spec string) apperrors.Error {
+		return SaveValue(ctx, []byte(`{"version": "v1", "kind": "Value", "metadata": {"catalog": "example-catalog",
+			"variant": "default", "collection": "/app-config-collection"}, "spec": `+spec+`}`), nil, WithWorkspaceID(ws.WorkspaceID))
+	}
+	get := func() *valueSchema {
+		vs, err := GetValue(ctx, &ValueMetadata{
+			Catalog:    "example-catalog",
+			Variant:    types.NullableStringFrom(types.DefaultVariant),
+			Collection: "/app-config-collection",
+		}, dir)
+		require.NoError(t, err)
+		return vs
+	}
+
+	// a parameter left out of the value is unset and resolves to its default
+	require.NoError(t, save(`{"timeout": 60}`))
+	vs := get()
+	assert.Equal(t, float64(8080), vs.Spec["proxy"].Get())
+	assert.Equal(t, ValueSourceCollectionDefault, vs.Sources["proxy"])
+
+	// a nullable parameter can be cleared with null, which its default does not replace
+	require.NoError(t, save(`{"proxy": null}`))
+	vs = get()
+	assert.True(t, vs.Spec["proxy"].IsNil())
+	assert.Equal(t, ValueSourceExplicit, vs.Sources["proxy"])
+	assert.Equal(t, float64(60), vs.Spec["timeout"].Get())
+
+	// a parameter that is not nullable rejects null and keeps its value
+	err = save(`{"timeout": null}`)
+	if assert.ErrorIs(t, err, validationerrors.ErrSchemaValidation) {
+		assert.Contains(t, err.Error(), "timeout: is not nullable")
+	}
+	assert.Equal(t, float64(60), get().Spec["timeout"].Get())
+
+	// and a concrete value replaces the null
+	require.NoError(t, save(`{"proxy": 3128}`))
+	vs = get()
+	assert.Equal(t, float64(3128), vs.Spec["proxy"].Get())
+	assert.Equal(t, ValueSourceExplicit, vs.Sources["proxy"])
+}
+
+func TestQualifiedParameterSchemaReference(t *testing.T) {
+	paramYaml := `
+	version: v1
+	kind: ParameterSchema
+	metadata:
+		name: integer-param-schema
+		catalog: example-catalog
+	spec:
+		dataType: Integer
+		validation:
+			minValue: 1
+			maxValue: 10
+		default: 5
+	`
+	collectionYaml := `
+	version: v1
+	kind: CollectionSchema
+	metadata:
+		name: my-collection-schema
+		catalog: example-catalog
+		namespace: ns-a
+	spec:
+		parameters:
+			qualified:
+				schema: ns-b/integer-param-schema
+			unqualified:
+				schema: integer-param-schema
+	`
+	replaceTabsWithSpaces(&paramYaml)
+	replaceTabsWithSpaces(&collectionYaml)
+
+	ctx := newDb()
+	t.Cleanup(func() {
+		db.DB(ctx).Close(ctx)
+	})
+
+	tenantID := types.TenantId("TABCDE")
+	projectID := types.ProjectId("PABCDE")
+	ctx = common.SetTenantIdInContext(ctx, tenantID)
+	ctx = common.SetProjectIdInContext(ctx, projectID)
+
+	err := db.DB(ctx).CreateTenant(ctx, tenantID)
+	assert.NoError(t, err)
+	t.Cleanup(func() {
+		_ = db.DB(ctx).DeleteTenant(ctx, tenantID)
+	})
+	err = db.DB(ctx).CreateProject(ctx, projectID)
+	assert.NoError(t, err)
+
+	cat := &models.Catalog{
+		Name:        "example-catalog",
+		Description: "An example catalog",
+		Info:        pgtype.JSONB{Status: pgtype.Null},
+		ProjectID:   projectID,
+	}
+	err = db.DB(ctx).CreateCatalog(ctx, cat)
+	assert.NoError(t, err)
+
+	varId, err := db.DB(ctx).GetVariantIDFromName(ctx, cat.CatalogID, types.DefaultVariant)
+	assert.NoError(t, err)
+
+	for _, ns := range []string{"ns-a", "ns-b"} {
+		err = db.DB(ctx).CreateNamespace(ctx, &models.Namespace{
+			Name:      ns,
+			VariantID: varId,
+		})
+		require.NoError(t, err)
+	}
+
+	ws := &models.Workspace{
+		Info:        pgtype.JSONB{Status: pgtype.Null},
+		BaseVersion: 1,
+		VariantID:   varId,
+	}
+	err = db.DB(ctx).CreateWorkspace(ctx, ws)
+	assert.NoError(t, err)
+
+	// the parameter schema is in the root namespace with a default of 5 and in ns-b with a default of 7
+	jsonData, err := yaml.YAMLToJSON([]byte(paramYaml))
+	require.NoError(t, err)
+	ps, err := NewSchema(ctx, jsonData, nil)
+	require.NoError(t, err)
+	err = SaveSchema(ctx, ps, WithWorkspaceID(ws.WorkspaceID))
+	require.NoError(t, err)
+	b, _ := sjson.SetBytes(jsonData, "metadata.namespace", "ns-b")
+	b, _ = sjson.SetBytes(b, "spec.default", 7)
+	ps, err = NewSchema(ctx, b, nil)
+	require.NoError(t, err)
+	err = SaveSchema(ctx, ps, WithWorkspaceID(ws.WorkspaceID))
+	require.NoError(t, err)
+
+	// qualified names resolve in the named namespace, unqualified names fall back to the root
+	jsonData, err = yaml.YAMLToJSON([]byte(collectionYaml))
+	require.NoError(t, err)
+	cs, err := NewSchema(ctx, jsonData, nil)
+	require.NoError(t, err)
+	err = SaveSchema(ctx, cs, WithWorkspaceID(ws.WorkspaceID))
+	require.NoError(t, err)
+	m := cs.Metadata()
+	lr, err := LoadSchemaByPath(ctx, types.CatalogObjectTypeCollectionSchema, &m, WithWorkspaceID(ws.WorkspaceID))
+	require.NoError(t, err)
+	j, err := lr.ToJson(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(7), gjson.GetBytes(j, "spec.parameters.qualified.default").Int())
+	assert.Equal(t, int64(5), gjson.GetBytes(j, "spec.parameters.unqualified.default").Int())
+
+	// a qualified name does not fall back to the root namespace
+	b, _ = sjson.SetBytes(jsonData, "spec.parameters.qualified.schema", "ns-a/integer-param-schema")
+	cs, err = NewSchema(ctx, b, nil)
+	require.NoError(t, err)
+	err = SaveSchema(ctx, cs, WithWorkspaceID(ws.WorkspaceID))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "parameter schema does not exist")
+
+	// the namespace must exist
+	b, _ = sjson.SetBytes(jsonData, "spec.parameters.qualified.schema", "missing/integer-param-schema")
+	cs, err = NewSchema(ctx, b, nil)
+	require.NoError(t, err)
+	err = SaveSchema(ctx, cs, WithWorkspaceID(ws.WorkspaceID))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "namespace does not exist")
+
+	// only a single namespace can qualify a name
+	b, _ = sjson.SetBytes(jsonData, "spec.parameters.qualified.schema", "ns-a/ns-b/integer-param-schema")
+	_, err = NewSchema(ctx, b, nil)
+	require.Error(t, err)
+}
+
+func TestForceDeleteParameterSchema(t *testing.T) {
+	collectionYaml := `
+	version: v1
+	kind: CollectionSchema
+	metadata:
+		name: force-collection
+		catalog: example-catalog
+	spec:
+		parameters:
+			maxRetries:
+				schema: integer-param-schema
+				default: 8
+				annotations:
+					owner: ops
+			maxDelay:
+				dataType: Integer
+				default: 1000
+	`
+	paramYaml := `
+				version: v1
+				kind: ParameterSchema
+				metadata:
+				  name: integer-param-schema
+				  catalog: example-catalog
+				spec:
+				  dataType: Integer
+				  validation:
+				    minValue: 1
+				    maxValue: 10
+	`
+	replaceTabsWithSpaces(&collectionYaml)
+	replaceTabsWithSpaces(&paramYaml)
+
+	ctx := newDb()
+	t.Cleanup(func() {
+		db.DB(ctx).Close(ctx)
+	})
+
+	tenantID := types.TenantId("TABCDE")
+	projectID := types.ProjectId("PABCDE")
+	ctx = common.SetTenantIdInContext(ctx, tenantID)
+	ctx = common.SetProjectIdInContext(ctx, projectID)
+
+	err := db.DB(ctx).CreateTenant(ctx, tenantID)
+	assert.NoError(t, err)
+	t.Cleanup(func() {
+		_ = db.DB(ctx).DeleteTenant(ctx, tenantID)
+	})
+	err = db.DB(ctx).CreateProject(ctx, projectID)
+	assert.NoError(t, err)
+
+	cat := &models.Catalog{
+		Name:        "example-catalog",
+		Description: "An example catalog",
+		Info:        pgtype.JSONB{Status: pgtype.Null},
+		ProjectID:   projectID,
+	}
+	err = db.DB(ctx).CreateCatalog(ctx, cat)
+	require.NoError(t, err)
+	varId, err := db.DB(ctx).GetVariantIDFromName(ctx, cat.CatalogID, types.DefaultVariant)
+	require.NoError(t, err)
+
+	ws := &models.Workspace{
+		Info:        pgtype.JSONB{Status: pgtype.Null},
+		BaseVersion: 1,
+		VariantID:   varId,
+	}
+	err = db.DB(ctx).CreateWorkspace(ctx, ws)
+	require.NoError(t, err)
+
+	jsonData, err := yaml.YAMLToJSON([]byte(paramYaml))
+	require.NoError(t, err)
+	ps, err := NewSchema(ctx, jsonData, nil)
+	require.NoError(t, err)
+	err = SaveSchema(ctx, ps, WithWorkspaceID(ws.WorkspaceID))
+	require.NoError(t, err)
+
+	jsonData, err = yaml.YAMLToJSON([]byte(collectionYaml))
+	require.NoError(t, err)
+	cs, err := NewSchema(ctx, jsonData, nil)
+	require.NoError(t, err)
+	err = SaveSchema(ctx, cs, WithWorkspaceID(ws.WorkspaceID))
+	require.NoError(t, err)
+
+	dir, err := getDirectoriesForWorkspace(ctx, ws.WorkspaceID)
+	require.NoError(t, err)
+	md := ps.Metadata()
+	md.IDS.CatalogID = cat.CatalogID
+	md.IDS.VariantID = varId
+
+	// a plain delete fails while the collection schema refers to the parameter schema
+	err = DeleteSchema(ctx, types.CatalogObjectTypeParameterSchema, &md, dir)
+	require.ErrorIs(t, err, ErrUnableToDeleteParameterWithReferences)
+
+	// a forced delete reverts the parameter to its data type and deletes the parameter schema
+	modified, err := ForceDeleteParameterSchema(ctx, &md, dir)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"/default/force-collection"}, modified)
+
+	_, err = LoadSchemaByPath(ctx, types.CatalogObjectTypeParameterSchema, &md, WithDirectories(dir))
+	require.Error(t, err)
+
+	m := cs.Metadata()
+	lr, err := LoadSchemaByPath(ctx, types.CatalogObjectTypeCollectionSchema, &m, WithDirectories(dir))
+	require.NoError(t, err)
+	j, err := lr.ToJson(ctx)
+	require.NoError(t, err)
+	assert.False(t, gjson.GetBytes(j, "spec.parameters.maxRetries.schema").Exists())
+	assert.Equal(t, "Integer", gjson.GetBytes(j, "spec.parameters.maxRetries.dataType").String())
+	assert.Equal(t, int64(8), gjson.GetBytes(j, "spec.parameters.maxRetries.default").Int())
+	assert.Equal(t, "ops", gjson.GetBytes(j, "spec.parameters.maxRetries.annotations.owner").String())
+	assert.Equal(t, int64(1000), gjson.GetBytes(j, "spec.parameters.maxDelay.default").Int())
+
+	// the collection schema no longer refers to a parameter schema and can be saved with a value out of the
+	// old validation range
+	b, _ := sjson.SetBytes(j, "spec.parameters.maxRetries.default", 20)
+	cs, err = NewSchema(ctx, b, nil)
+	require.NoError(t, err)
+	err = SaveSchema(ctx, cs, WithDirectories(dir))
+	require.NoError(t, err)
+
+	// a forced delete of a missing parameter schema fails
+	_, err = ForceDeleteParameterSchema(ctx, &md, dir)
+	require.Error(t, err)
+}
+
+func TestSaveSchemaDescriptionOnly(t *testing.T) {
+	paramYaml := `
+				version: v1
+				kind: ParameterSchema
+				metadata:
+				  name: described-param-schema
+				  catalog: example-catalog
+				  description: the first description
+				spec:
+				  dataType: Integer
+				  default: 5
+	`
+	replaceTabsWithSpaces(&paramYaml)
+
+	ctx := newDb()
+	t.Cleanup(func() {
+		db.DB(ctx).Close(ctx)
+	})
+
+	tenantID := types.TenantId("TABCDE")
+	projectID := types.ProjectId("PABCDE")
+	ctx = common.SetTenantIdInContext(ctx, tenantID)
+	ctx = common.SetProjectIdInContext(ctx, projectID)
+
+	err := db.DB(ctx).CreateTenant(ctx, tenantID)
+	assert.NoError(t, err)
+	t.Cleanup(func() {
+		_ = db.DB(ctx).DeleteTenant(ctx, tenantID)
+	})
+	err = db.DB(ctx).CreateProject(ctx, projectID)
+	assert.NoError(t, err)
+
+	cat := &models.Catalog{
+		Name:        "example-catalog",
+		Description: "An example catalog",
+		Info:        pgtype.JSONB{Status: pgtype.Null},
+		ProjectID:   projectID,
+	}
+	err = db.DB(ctx).CreateCatalog(ctx, cat)
+	assert.NoError(t, err)
+	varId, err := db.DB(ctx).GetVariantIDFromName(ctx, cat.CatalogID, types.DefaultVariant)
+	assert.NoError(t, err)
+	ws := &models.Workspace{
+		Info:        pgtype.JSONB{Status: pgtype.Null},
+		BaseVersion: 1,
+		VariantID:   varId,
+	}
+	err = db.DB(ctx).CreateWorkspace(ctx, ws)
+	assert.NoError(t, err)
+	dir, err := getDirectoriesForWorkspace(ctx, ws.WorkspaceID)
+	require.NoError(t, err)
+
+	jsonData, err := yaml.YAMLToJSON([]byte(paramYaml))
+	require.NoError(t, err)
+	ps, err := NewSchema(ctx, jsonData, nil)
+	require.NoError(t, err)
+	require.NoError(t, SaveSchema(ctx, ps, WithWorkspaceID(ws.WorkspaceID)))
+	before, err := db.DB(ctx).GetObjectRefByPath(ctx, types.CatalogObjectTypeParameterSchema, dir.ParametersDir, "/--root--/described-param-schema")
+	require.NoError(t, err)
+
+	// only the description changes
+	jsonData, err = sjson.SetBytes(jsonData, "metadata.description", "the second description")
+	require.NoError(t, err)
+	ps, err = NewSchema(ctx, jsonData, nil)
+	require.NoError(t, err)
+	require.NoError(t, SaveSchema(ctx, ps, WithWorkspaceID(ws.WorkspaceID), WithErrorIfEqualToExisting()))
+
+	after, err := db.DB(ctx).GetObjectRefByPath(ctx, types.CatalogObjectTypeParameterSchema, dir.ParametersDir, "/--root--/described-param-schema")
+	require.NoError(t, err)
+	assert.Equal(t, before.Hash, after.Hash)
+	assert.Equal(t, "the second description", after.Description)
+
+	m := ps.Metadata()
+	m.Description = ""
+	loaded, err := LoadSchemaByPath(ctx, types.CatalogObjectTypeParameterSchema, &m, WithWorkspaceID(ws.WorkspaceID))
+	require.NoError(t, err)
+	assert.Equal(t, "the second description", loaded.Metadata().Description)
+
+	// saving it again is now a no-op
+	err = SaveSchema(ctx, ps, WithWorkspaceID(ws.WorkspaceID), WithErrorIfEqualToExisting())
+	assert.ErrorIs(t, err, ErrEqualToExistingObject)
+}
+
+func TestSaveSchemaUnits(t *testing.T) {
+	ctx := newDb()
+	t.Cleanup(func() {
+		db.DB(ctx).Close(ctx)
+	})
+
+	tenantID := types.TenantId("TABCDE")
+	projectID := types.ProjectId("PABCDE")
+	ctx = common.SetTenantIdInContext(ctx, tenantID)
+	ctx = common.SetProjectIdInContext(ctx, projectID)
+
+	err := db.DB(ctx).CreateTenant(ctx, tenantID)
+	assert.NoError(t, err)
+	t.Cleanup(func() {
+		_ = db.DB(ctx).DeleteTenant(ctx, tenantID)
+	})
+	err = db.DB(ctx).CreateProject(ctx, projectID)
+	assert.NoError(t, err)
+
+	cat := &models.Catalog{
+		Name:        "example-catalog",
+		Description: "An example catalog",
+		Info:        pgtype.JSONB{Status: pgtype.Null},
+		ProjectID:   projectID,
+	}
+	err = db.DB(ctx).CreateCatalog(ctx, cat)
+	assert.NoError(t, err)
+	varId, err := db.DB(ctx).GetVariantIDFromName(ctx, cat.CatalogID, types.DefaultVariant)
+	assert.NoError(t, err)
+	ws := &models.Workspace{
+		Info:        pgtype.JSONB{Status: pgtype.Null},
+		BaseVersion: 1,
+		VariantID:   varId,
+	}
+	err = db.DB(ctx).CreateWorkspace(ctx, ws)
+	assert.NoError(t, err)
+	dir, err := getDirectoriesForWorkspace(ctx, ws.WorkspaceID)
+	require.NoError(t, err)
+
+	param := []byte(`{"version": "v1", "kind": "ParameterSchema", "metadata": {"name": "timeout", "catalog": "example-catalog"},
+		"spec": {"dataType": "Integer", "default": 30, "unit": "seconds"}}`)
+	schema := []byte(`{"version": "v1", "kind": "CollectionSchema", "metadata": {"name": "server", "catalog": "example-catalog"},
+		"spec": {"parameters": {"readTimeout": {"schema": "timeout"}, "writeTimeout": {"schema": "timeout", "unit": "ms"},
+		"maxBody": {"dataType": "Integer", "unit": "bytes"}}}}`)
+
+	// the unit is not part of the hash
+	hashWithout := func(j []byte, paths ...string) string {
+		var err error
+		for _, p := range paths {
+			j, err = sjson.DeleteBytes(j, p)
+			require.NoError(t, err)
+		}
+		sm, err := NewSchema(ctx, j, nil)
+		require.NoError(t, err)
+		assert.Empty(t, sm.Metadata().Units)
+		return sm.StorageRepresentation().GetHash()
+	}
+	ps, err := NewSchema(ctx, param, nil)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"": "seconds"}, ps.Metadata().Units)
+	assert.Equal(t, hashWithout(param, "spec.unit"), ps.StorageRepresentation().GetHash())
+	cs, err := NewSchema(ctx, schema, nil)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"writeTimeout": "ms", "maxBody": "bytes"}, cs.Metadata().Units)
+	assert.Equal(t, hashWithout(schema, "spec.parameters.writeTimeout.unit", "spec.parameters.maxBody.unit"), cs.StorageRepresentation().GetHash())
+
+	// and round-trips through the directory entry
+	require.NoError(t, SaveSchema(ctx, ps, WithWorkspaceID(ws.WorkspaceID)))
+	require.NoError(t, SaveSchema(ctx, cs, WithWorkspaceID(ws.WorkspaceID)))
+	m := cs.Metadata()
+	loaded, err := LoadSchemaByPath(ctx, types.CatalogObjectTypeCollectionSchema, &m, WithWorkspaceID(ws.WorkspaceID))
+	require.NoError(t, err)
+	j, err := loaded.ToJson(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "ms", gjson.GetBytes(j, "spec.parameters.writeTimeout.unit").String())
+	assert.Equal(t, "bytes", gjson.GetBytes(j, "spec.parameters.maxBody.unit").String())
+	assert.False(t, gjson.GetBytes(j, "spec.parameters.readTimeout.unit").Exists())
+
+	// changing only the unit keeps the object
+	before, err := db.DB(ctx).GetObjectRefByPath(ctx, types.CatalogObjectTypeParameterSchema, dir.ParametersDir, "/--root--/timeout")
+	require.NoError(t, err)
+	param, err = sjson.SetBytes(param, "spec.unit", "milliseconds")
+	require.NoError(t, err)
+	ps, err = NewSchema(ctx, param, nil)
+	require.NoError(t, err)
+	require.NoError(t, SaveSchema(ctx, ps, WithWorkspaceID(ws.WorkspaceID), WithErrorIfEqualToExisting()))
+	after, err := db.DB(ctx).GetObjectRefByPath(ctx, types.CatalogObjectTypeParameterSchema, dir.ParametersDir, "/--root--/timeout")
+	require.NoError(t, err)
+	assert.Equal(t, before.Hash, after.Hash)
+	assert.Equal(t, map[string]string{"": "milliseconds"}, after.Units)
+
+	m = ps.Metadata()
+	m.Units = nil
+	loaded, err = LoadSchemaByPath(ctx, types.CatalogObjectTypeParameterSchema, &m, WithWorkspaceID(ws.WorkspaceID))
+	require.NoError(t, err)
+	j, err = loaded.ToJson(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "milliseconds", gjson.GetBytes(j, "spec.unit").String())
+
+	// a unit is a string
+	_, err = NewSchema(ctx, []byte(`{"version": "v1", "kind": "ParameterSchema", "metadata": {"name": "bad", "catalog": "example-catalog"},
+		"spec": {"dataType": "Integer", "unit": 5}}`), nil)
+	assert.Error(t, err)
+}
+
+func TestSaveSchemaExtends(t *testing.T) {
+	ctx := newDb()
+	t.Cleanup(func() {
+		db.DB(ctx).Close(ctx)
+	})
+
+	tenantID := types.TenantId("TABCDE")
+	projectID := types.ProjectId("PABCDE")
+	ctx = common.SetTenantIdInContext(ctx, tenantID)
+	ctx = common.SetProjectIdInContext(ctx, projectID)
+
+	err := db.DB(ctx).CreateTenant(ctx, tenantID)
+	assert.NoError(t, err)
+	t.Cleanup(func() {
+		_ = db.DB(ctx).DeleteTenant(ctx, tenantID)
+	})
+	err = db.DB(ctx).CreateProject(ctx, projectID)
+	assert.NoError(t, err)
+
+	cat := &models.Catalog{
+		Name:        "example-catalog",
+		Description: "An example catalog",
+		Info:        pgtype.JSONB{Status: pgtype.Null},
+		ProjectID:   projectID,
+	}
+	err = db.DB(ctx).CreateCatalog(ctx, cat)
+	assert.NoError(t, err)
+	varId, err := db.DB(ctx).GetVariantIDFromName(ctx, cat.CatalogID, types.DefaultVariant)
+	assert.NoError(t, err)
+	ws := &models.Workspace{
+		Info:        pgtype.JSONB{Status: pgtype.Null},
+		BaseVersion: 1,
+		VariantID:   varId,
+	}
+	err = db.DB(ctx).CreateWorkspace(ctx, ws)
+	assert.NoError(t, err)
+
+	save := func(name, spec string) error {
+		sm, err := NewSchema(ctx, []byte(`{"version": "v1", "kind": "ParameterSchema", "metadata": {"name": "`+name+`", "catalog": "example-catalog"},
+			"spec": `+spec+`}`), nil)
+		if err != nil {
+			return err
+		}
+		return SaveSchema(ctx, sm, WithWorkspaceID(ws.WorkspaceID))
+	}
+	load := func(name string) (schemamanager.SchemaManager, []byte) {
+		m := schemamanager.SchemaMetadata{
+			Catalog: "example-catalog",
+			Path:    "/",
+			Name:    name,
+			IDS:     schemamanager.IDS{CatalogID: cat.CatalogID, VariantID: varId},
+		}
+		sm, err := LoadSchemaByPath(ctx, types.CatalogObjectTypeParameterSchema, &m, WithWorkspaceID(ws.WorkspaceID))
+		require.NoError(t, err)
+		j, err := sm.ToJson(ctx)
+		require.NoError(t, err)
+		return sm, j
+	}
+
+	require.NoError(t, save("port", `{"dataType": "Integer", "validation": {"minValue": 1, "maxValue": 65535}, "default": 8080}`))
+
+	// the fields of the extending schema override those of the base, and its validation is merged key by key
+	require.NoError(t, save("http-port", `{"extends": "port", "validation": {"minValue": 1024}, "default": 8000}`))
+	sm, j := load("http-port")
+	assert.Equal(t, "port", gjson.GetBytes(j, "spec.extends").String())
+	assert.Equal(t, "Integer", gjson.GetBytes(j, "spec.dataType").String())
+	assert.Equal(t, int64(1024), gjson.GetBytes(j, "spec.validation.minValue").Int())
+	assert.Equal(t, int64(65535), gjson.GetBytes(j, "spec.validation.maxValue").Int())
+	assert.Equal(t, int64(8000), gjson.GetBytes(j, "spec.default").Int())
+	pm := sm.ParameterSchemaManager()
+	var v types.NullableAny
+	require.NoError(t, v.Set(80))
+	assert.Error(t, pm.ValidateValue(v))
+	require.NoError(t, v.Set(70000))
+	assert.Error(t, pm.ValidateValue(v))
+	require.NoError(t, v.Set(8443))
+	assert.NoError(t, pm.ValidateValue(v))
+
+	// what is not overridden comes from the base, which has its own base merged in
+	require.NoError(t, save("admin-port", `{"extends": "http-port"}`))
+	_, j = load("admin-port")
+	assert.Equal(t, int64(1024), gjson.GetBytes(j, "spec.validation.minValue").Int())
+	assert.Equal(t, int64(65535), gjson.GetBytes(j, "spec.validation.maxValue").Int())
+	assert.Equal(t, int64(8000), gjson.GetBytes(j, "spec.default").Int())
+
+	// the merged spec is validated
+	err = save("low-port", `{"extends": "port", "validation": {"maxValue": 1023}}`)
+	assert.Error(t, err)
+	err = save("bad-validation", `{"extends": "port", "validation": "not an object"}`)
+	assert.Error(t, err)
+
+	// the base must exist, and a schema that neither extends another nor has a data type is invalid
+	err = save("orphan", `{"extends": "no-such-schema"}`)
+	assert.ErrorIs(t, err, ErrInvalidExtends)
+	err = save("untyped", `{"validation": {"minValue": 1}}`)
+	assert.Error(t, err)
+
+	// cycles are rejected, directly or through other schemas
+	err = save("port", `{"extends": "port"}`)
+	assert.ErrorIs(t, err, ErrCyclicExtends)
+	err = save("port", `{"extends": "admin-port"}`)
+	assert.ErrorIs(t, err, ErrCyclicExtends)
+	_, j = load("port")
+	assert.False(t, gjson.GetBytes(j, "spec.extends").Exists())
+	assert.Equal(t, int64(1), gjson.GetBytes(j, "spec.validation.minValue").Int())
+}
+
+func TestSaveSchemaConcurrently(t *testing.T) {
+	ctx := newDb()
+	t.Cleanup(func() {
+		db.DB(ctx).Close(ctx)
+	})
+
+	tenantID := types.TenantId("TABCDE")
+	projectID := types.ProjectId("PABCDE")
+	ctx = common.SetTenantIdInContext(ctx, tenantID)
+	ctx = common.SetProjectIdInContext(ctx, projectID)
+
+	err := db.DB(ctx).CreateTenant(ctx, tenantID)
+	assert.NoError(t, err)
+	t.Cleanup(func() {
+		_ = db.DB(ctx).DeleteTenant(ctx, tenantID)
+	})
+	err = db.DB(ctx).CreateProject(ctx, projectID)
+	assert.NoError(t, err)
+
+	cat := &models.Catalog{
+		Name:        "example-catalog",
+		Description: "An example catalog",
+		Info:        pgtype.JSONB{Status: pgtype.Null},
+		ProjectID:   projectID,
+	}
+	err = db.DB(ctx).CreateCatalog(ctx, cat)
+	assert.NoError(t, err)
+	varId, err := db.DB(ctx).GetVariantIDFromName(ctx, cat.CatalogID, types.DefaultVariant)
+	assert.NoError(t, err)
+	ws := &models.Workspace{
+		Info:        pgtype.JSONB{Status: pgtype.Null},
+		BaseVersion: 1,
+		VariantID:   varId,
+	}
+	err = db.DB(ctx).CreateWorkspace(ctx, ws)
+	assert.NoError(t, err)
+
+	save := func(ctx context.Context, j string) error {
+		sm, err := NewSchema(ctx, []byte(j), nil)
+		if err != nil {
+			return err
+		}
+		return SaveSchema(ctx, sm, WithWorkspaceID(ws.WorkspaceID))
+	}
+	require.NoError(t, save(ctx, `{"version": "v1", "kind": "ParameterSchema", "metadata": {"name": "retries", "catalog": "example-catalog"},
+		"spec": {"dataType": "Integer"}}`))
+
+	// the same new collection schema is saved by several requests at once, each on its own connection
+	collectionJson := `{"version": "v1", "kind": "CollectionSchema", "metadata": {"name": "concurrent", "catalog": "example-catalog"},
+		"spec": {"parameters": {"maxRetries": {"schema": "retries"}}}}`
+	const n = 8
+	errs := make([]error, n)
+	var wg sync.WaitGroup
+	wg.Add(n)
+	for i := 0; i < n; i++ {
+		go func(i int) {
+			defer wg.Done()
+			ctx := newDb()
+			defer db.DB(ctx).Close(ctx)
+			ctx = common.SetTenantIdInContext(ctx, tenantID)
+			ctx = common.SetProjectIdInContext(ctx, projectID)
+			errs[i] = save(ctx, collectionJson)
+		}(i)
+	}
+	wg.Wait()
+	for _, err := range errs {
+		assert.NoError(t, err)
+	}
+
+	// they all wrote the same object, and the directories have a single entry and reference for it
+	dir, err := getDirectoriesForWorkspace(ctx, ws.WorkspaceID)
+	require.NoError(t, err)
+	root := "/" + types.RootNamespace()
+	collections, err := loadDirectory(ctx, types.CatalogObjectTypeCollectionSchema, dir.CollectionsDir)
+	require.NoError(t, err)
+	assert.Len(t, collections, 1)
+	ref, ok := collections[root+"/concurrent"]
+	require.True(t, ok)
+	obj, err := db.DB(ctx).GetCatalogObject(ctx, ref.Hash)
+	require.NoError(t, err)
+	assert.Equal(t, types.CatalogObjectTypeCollectionSchema, obj.Type)
+	refs, err := db.DB(ctx).GetAllReferences(ctx, types.CatalogObjectTypeParameterSchema, dir.ParametersDir, root+"/retries")
+	require.NoError(t, err)
+	assert.Equal(t, models.References{{Name: root + "/concurrent"}}, refs)
+
+	// saving it again is a no-op
+	require.NoError(t, save(ctx, collectionJson))
+	again, err := db.DB(ctx).GetObjectRefByPath(ctx, types.CatalogObjectTypeCollectionSchema, dir.CollectionsDir, root+"/concurrent")
+	require.NoError(t, err)
+	assert.Equal(t, ref.Hash, again.Hash)
+}
+
+func TestRootNamespaceIsNotDefaultNamespace(t *testing.T) {
+	paramYaml := `
+				version: v1
+				kind: ParameterSchema
+				metadata:
+				  name: root-param-schema
+				  catalog: example-catalog
+				spec:
+				  dataType: Integer
+				  default: 5
+	`
+	replaceTabsWithSpaces(&paramYaml)
+
+	ctx := newDb()
+	t.Cleanup(func() {
+		db.DB(ctx).Close(ctx)
+	})
+
+	tenantID := types.TenantId("TABCDE")
+	projectID := types.ProjectId("PABCDE")
+	ctx = common.SetTenantIdInContext(ctx, tenantID)
+	ctx = common.SetProjectIdInContext(ctx, projectID)
+
+	err := db.DB(ctx).CreateTenant(ctx, tenantID)
+	assert.NoError(t, err)
+	t.Cleanup(func() {
+		_ = db.DB(ctx).DeleteTenant(ctx, tenantID)
+	})
+	err = db.DB(ctx).CreateProject(ctx, projectID)
+	assert.NoError(t, err)
+
+	cat := &models.Catalog{
+		Name:        "example-catalog",
+		Description: "An example catalog",
+		Info:        pgtype.JSONB{Status: pgtype.Null},
+		ProjectID:   projectID,
+	}
+	err = db.DB(ctx).CreateCatalog(ctx, cat)
+	assert.NoError(t, err)
+	varId, err := db.DB(ctx).GetVariantIDFromName(ctx, cat.CatalogID, types.DefaultVariant)
+	assert.NoError(t, err)
+	ws := &models.Workspace{
+		Info:        pgtype.JSONB{Status: pgtype.Null},
+		BaseVersion: 1,
+		VariantID:   varId,
+	}
+	err = db.DB(ctx).CreateWorkspace(ctx, ws)
+	assert.NoError(t, err)
+	dir, err := getDirectoriesForWorkspace(ctx, ws.WorkspaceID)
+	require.NoError(t, err)
+
+	baseJson, err := yaml.YAMLToJSON([]byte(paramYaml))
+	require.NoError(t, err)
+	withNamespace := func(ns string) []byte {
+		j, err := sjson.SetBytes(baseJson, "metadata.namespace", ns)
+		require.NoError(t, err)
+		return j
+	}
+
+	// no namespace saves the schema in the root namespace
+	ps, err := NewSchema(ctx, baseJson, nil)
+	require.NoError(t, err)
+	require.NoError(t, SaveSchema(ctx, ps, WithWorkspaceID(ws.WorkspaceID)))
+	root, err := db.DB(ctx).GetObjectRefByPath(ctx, types.CatalogObjectTypeParameterSchema, dir.ParametersDir, "/--root--/root-param-schema")
+	require.NoError(t, err)
+
+	// an empty namespace and an explicit --root-- refer to the same object
+	for _, ns := range []string{"", types.DefaultNamespace} {
+		ps, err = NewSchema(ctx, withNamespace(ns), nil)
+		require.NoError(t, err, ns)
+		assert.True(t, ps.Metadata().Namespace.IsNil(), ns)
+		err = SaveSchema(ctx, ps, WithWorkspaceID(ws.WorkspaceID), WithErrorIfEqualToExisting())
+		assert.ErrorIs(t, err, ErrEqualToExistingObject, ns)
+	}
+	_, err = db.DB(ctx).GetObjectRefByPath(ctx, types.CatalogObjectTypeParameterSchema, dir.ParametersDir, "/--root--/--root--/root-param-schema")
+	assert.ErrorIs(t, err, dberror.ErrNotFound)
+
+	// "default" is an ordinary namespace and must exist
+	_, err = NewSchema(ctx, withNamespace("default"), nil)
+	assert.ErrorIs(t, err, ErrNamespaceNotFound)
+	err = db.DB(ctx).CreateNamespace(ctx, &models.Namespace{
+		Name:      "default",
+		VariantID: varId,
+	})
+	require.NoError(t, err)
+	ps, err = NewSchema(ctx, withNamespace("default"), nil)
+	require.NoError(t, err)
+	require.NoError(t, SaveSchema(ctx, ps, WithWorkspaceID(ws.WorkspaceID)))
+	inDefault, err := db.DB(ctx).GetObjectRefByPath(ctx, types.CatalogObjectTypeParameterSchema, dir.ParametersDir, "/--root--/default/root-param-schema")
+	require.NoError(t, err)
+	assert.Equal(t, root.Hash, inDefault.Hash)
+
+	// a qualified reference to --root-- resolves to the root namespace
+	loader := getSchemaLoaderByPath(ctx, ps.Metadata(), WithWorkspaceID(ws.WorkspaceID))
+	p, _, err := loader(ctx, types.CatalogObjectTypeParameterSchema, types.DefaultNamespace+"/root-param-schema")
+	require.NoError(t, err)
+	assert.Equal(t, "/--root--/root-param-schema", p)
+}
+
+func TestConfiguredRootNamespace(t *testing.T) {
+	setDefaultNamespace := func(name string) {
+		file := filepath.Join(t.TempDir(), "config.toml")
+		require.NoError(t, os.WriteFile(file, []byte(`default_namespace = "`+name+`"`+"\n"), 0o600))
+		require.NoError(t, config.LoadConfig(file))
+	}
+	t.Cleanup(func() {
+		_ = config.LoadConfig("")
+		types.SetRootNamespace("")
+	})
+
+	// the configured name follows the rules for namespace names
+	setDefaultNamespace("Not_A_Namespace")
+	assert.ErrorIs(t, SetRootNamespaceFromConfig(), ErrInvalidNamespace)
+	assert.Equal(t, types.DefaultNamespace, types.RootNamespace())
+
+	setDefaultNamespace("main")
+	require.NoError(t, SetRootNamespaceFromConfig())
+	assert.Equal(t, "main", types.RootNamespace())
+
+	paramYaml := `
+				version: v1
+				kind: ParameterSchema
+				metadata:
+				  name: root-param-schema
+				  catalog: example-catalog
+				spec:
+				  dataType: Integer
+				  default: 5
+	`
+	replaceTabsWithSpaces(&paramYaml)
+
+	ctx := newDb()
+	t.Cleanup(func() {
+		db.DB(ctx).Close(ctx)
+	})
+
+	tenantID := types.TenantId("TNSCFG")
+	projectID := types.ProjectId("PNSCFG")
+	ctx = common.SetTenantIdInContext(ctx, tenantID)
+	ctx = common.SetProjectIdInContext(ctx, projectID)
+
+	err := db.DB(ctx).CreateTenant(ctx, tenantID)
+	assert.NoError(t, err)
+	t.Cleanup(func() {
+		_ = db.DB(ctx).DeleteTenant(ctx, tenantID)
+	})
+	err = db.DB(ctx).CreateProject(ctx, projectID)
+	assert.NoError(t, err)
+
+	cat := &models.Catalog{
+		Name:        "example-catalog",
+		Description: "An example catalog",
+		Info:        pgtype.JSONB{Status: pgtype.Null},
+		ProjectID:   projectID,
+	}
+	err = db.DB(ctx).CreateCatalog(ctx, cat)
+	assert.NoError(t, err)
+	varId, err := db.DB(ctx).GetVariantIDFromName(ctx, cat.CatalogID, types.DefaultVariant)
+	assert.NoError(t, err)
+	ws := &models.Workspace{
+		Info:        pgtype.JSONB{Status: pgtype.Null},
+		BaseVersion: 1,
+		VariantID:   varId,
+	}
+	err = db.DB(ctx).CreateWorkspace(ctx, ws)
+	assert.NoError(t, err)
+	dir, err := getDirectoriesForWorkspace(ctx, ws.WorkspaceID)
+	require.NoError(t, err)
+
+	// the variant's root namespace has the configured name
+	_, err = db.DB(ctx).GetNamespace(ctx, "main", varId)
+	require.NoError(t, err)
+
+	// a schema saved without a namespace is stored under the configured name
+	baseJson, err := yaml.YAMLToJSON([]byte(paramYaml))
+	require.NoError(t, err)
+	ps, err := NewSchema(ctx, baseJson, nil)
+	require.NoError(t, err)
+	require.NoError(t, SaveSchema(ctx, ps, WithWorkspaceID(ws.WorkspaceID)))
+	root, err := db.DB(ctx).GetObjectRefByPath(ctx, types.CatalogObjectTypeParameterSchema, dir.ParametersDir, "/main/root-param-schema")
+	require.NoError(t, err)
+	_, err = db.DB(ctx).GetObjectRefByPath(ctx, types.CatalogObjectTypeParameterSchema, dir.ParametersDir, "/--root--/root-param-schema")
+	assert.ErrorIs(t, err, dberror.ErrNotFound)
+
+	// the configured name refers to the root namespace
+	j, err := sjson.SetBytes(baseJson, "metadata.namespace", "main")
+	require.NoError(t, err)
+	ps, err = NewSchema(ctx, j, nil)
+	require.NoError(t, err)
+	assert.True(t, ps.Metadata().Namespace.IsNil())
+	err = SaveSchema(ctx, ps, WithWorkspaceID(ws.WorkspaceID), WithErrorIfEqualToExisting())
+	assert.ErrorIs(t, err, ErrEqualToExistingObject)
+
+	// so does the default name of the root namespace
+	j, err = sjson.SetBytes(baseJson, "metadata.namespace", types.DefaultNamespace)
+	require.NoError(t, err)
+	ps, err = NewSchema(ctx, j, nil)
+	require.NoError(t, err)
+	assert.True(t, ps.Metadata().Namespace.IsNil())
+	err = SaveSchema(ctx, ps, WithWorkspaceID(ws.WorkspaceID), WithErrorIfEqualToExisting())
+	assert.ErrorIs(t, err, ErrEqualToExistingObject)
+
+	// and the schema loads from it
+	loaded, err := LoadSchemaByPath(ctx, types.CatalogObjectTypeParameterSchema, ps.Metadata(), WithWorkspaceID(ws.WorkspaceID))
+	require.NoError(t, err)
+	assert.Equal(t, root.Hash, loaded.StorageRepresentation().GetHash())
+	loader := getSchemaLoaderByPath(ctx, ps.Metadata(), WithWorkspaceID(ws.WorkspaceID))
+	p, _, err := loader(ctx, types.CatalogObjectTypeParameterSchema, "main/root-param-schema")
+	require.NoError(t, err)
+	assert.Equal(t, "/main/root-param-schema", p)
+
+	// no other namespace can take the name
+	_, err = NewNamespaceManager(ctx, []byte(`{"version":"v1","kind":"Namespace","metadata":{"name":"main","catalog":"example-catalog"}}`), "", "")
+	assert.ErrorIs(t, err, ErrInvalidNamespace)
+}
@@ -11,6 +11,7 @@ type CatalogManager interface {
 	ID() uuid.UUID
 	Name() string
 	Description() string
+	DefaultVariant() string
 	Save(context.Context) apperrors.Error
 	ToJson(context.Context) ([]byte, apperrors.Error)
 }
@@ -23,6 +23,12 @@ type CollectionManager interface {
 	GetAllValuesJSON(ctx context.Context) ([]byte, apperrors.Error)
 	SetValue(ctx context.Context, schemaLoaders SchemaLoaders, param string, value types.NullableAny) apperrors.Error
 	ValidateValues(ctx context.Context, schemaLoaders SchemaLoaders, currentValues ParamValues) apperrors.Error
+	// UnknownValues returns the keys of the values in the collection that are not parameters of its schema
+	UnknownValues() []string
+	// SensitiveParameters returns the names of the parameters whose values are sensitive
+	SensitiveParameters() []string
+	// RedactSensitiveValues replaces the values of sensitive parameters, so that the collection can be returned
+	RedactSensitiveValues()
 	Values() ParamValues
 	StorageRepresentation() *schemastore.SchemaStorageRepresentation
 	ToJson(ctx context.Context) ([]byte, apperrors.Error)
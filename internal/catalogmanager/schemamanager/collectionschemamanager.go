@@ -16,12 +16,19 @@ type ParameterSpec struct {
 
 type CollectionSchemaManager interface {
 	ParameterNames() []string
+	// RequiredParameters returns the names of the parameters that a collection must have a value for
+	RequiredParameters() []string
 	ParametersWithSchema(schemaName string) []ParameterSpec
 	ValidateDependencies(context.Context, SchemaLoaders, SchemaReferences) (SchemaReferences, apperrors.Error)
 	ValidateValue(ctx context.Context, loaders SchemaLoaders, param string, value types.NullableAny) apperrors.Error
+	// ValidateValues validates values, keyed by parameter name, and reports the errors of all of them together
+	ValidateValues(ctx context.Context, loaders SchemaLoaders, values map[string]types.NullableAny) apperrors.Error
 	SetValue(ctx context.Context, param string, value types.NullableAny) apperrors.Error
 	GetValue(ctx context.Context, param string) ParamValue
 	GetDefaultValues() map[string]ParamValue
 	StorageRepresentation() *schemastore.SchemaStorageRepresentation
 	SetDefaultValues(ctx context.Context)
+	// ComputeDefaults computes the defaults of the parameters that have a default expression from values. Only
+	// the parameters for which recompute returns true are computed, or all of them if recompute is nil.
+	ComputeDefaults(values ParamValues, recompute func(param string) bool) map[string]types.NullableAny
 }
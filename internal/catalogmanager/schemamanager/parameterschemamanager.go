@@ -22,6 +22,7 @@ type ParamValue struct {
 	Value       types.NullableAny `json:"value"`
 	DataType    ParamDataType     `json:"data_type"`
 	Annotations Annotations       `json:"annotations"`
+	Sensitive   bool              `json:"sensitive,omitempty"` // the value is stored encrypted
 }
 
 func (pv ParamValue) ToJson() ([]byte, error) {
@@ -62,4 +63,6 @@ type ParameterSchemaManager interface {
 	ValidateValue(types.NullableAny) apperrors.Error
 	ValidateDependencies(ctx context.Context, loaders SchemaLoaders, collectionRefs SchemaReferences) apperrors.Error
 	StorageRepresentation() *schemastore.SchemaStorageRepresentation
+	Extends() string
+	ExtendSpec(base ParameterSchemaManager) apperrors.Error
 }
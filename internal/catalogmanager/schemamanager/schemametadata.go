@@ -10,6 +10,8 @@ import (
 	schemaerr "github.com/mugiliam/hatchcatalogsrv/internal/catalogmanager/schema/errors"
 	"github.com/mugiliam/hatchcatalogsrv/internal/catalogmanager/schema/schemavalidator"
 	"github.com/mugiliam/hatchcatalogsrv/pkg/types"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
 )
 
 // Modifying this struct should also change the json
@@ -17,10 +19,21 @@ type SchemaMetadata struct {
 	Name        string               `json:"name" validate:"required,resourceNameValidator"`
 	Catalog     string               `json:"catalog" validate:"required,resourceNameValidator"`
 	Variant     types.NullableString `json:"variant,omitempty" validate:"resourceNameValidator"`
-	Namespace   types.NullableString `json:"namespace,omitempty" validate:"omitempty,resourceNameValidator"`
+	Namespace   types.NullableString `json:"namespace,omitempty" validate:"omitempty,namespaceValidator"`
 	Path        string               `json:"path,omitempty" validate:"omitempty,resourcePathValidator"`
 	Description string               `json:"description"`
-	IDS         IDS                  `json:"-"`
+	// Deprecated marks a schema that is being phased out. Like the description it is metadata; it is kept
+	// with the directory entry of the schema rather than in the stored object, so it does not change the hash.
+	Deprecated         bool   `json:"deprecated,omitempty"`
+	DeprecationMessage string `json:"deprecationMessage,omitempty"`
+	// Units are the units of the values of the parameters of a collection schema, keyed by parameter name, or
+	// of a parameter schema, keyed by the empty string. They only annotate values and are written in the spec,
+	// but are kept with the directory entry like the description so they do not change the hash.
+	Units map[string]string `json:"-"`
+	// ValuesExtends is the path of the collection schema whose values are the base of the values of a
+	// collection schema. It is set by saving a Value and is kept with the directory entry.
+	ValuesExtends string `json:"-"`
+	IDS           IDS    `json:"-"`
 }
 
 type IDS struct {
@@ -51,11 +64,12 @@ func (rs *SchemaMetadata) Validate() schemaerr.ValidationErrors {
 		switch e.Tag() {
 		case "required":
 			ves = append(ves, schemaerr.ErrMissingRequiredAttribute(jsonFieldName))
-		case "resourceNameValidator":
-			val, _ := e.Value().(string)
-			ves = append(ves, schemaerr.ErrInvalidNameFormat(jsonFieldName, val))
+		case "resourceNameValidator", "namespaceValidator":
+			val := fieldString(e.Value())
+			ves = append(ves, schemaerr.ErrInvalidResourceName(jsonFieldName, val, schemavalidator.NormalizeResourceName(val)))
 		case "resourcePathValidator":
-			ves = append(ves, schemaerr.ErrInvalidObjectPath(jsonFieldName))
+			val := fieldString(e.Value())
+			ves = append(ves, schemaerr.ErrInvalidResourcePath(jsonFieldName, val, schemavalidator.NormalizeResourcePath(val)))
 		case "catalogVersionValidator":
 			ves = append(ves, schemaerr.ErrInvalidCatalogVersion(jsonFieldName))
 		default:
@@ -65,6 +79,16 @@ func (rs *SchemaMetadata) Validate() schemaerr.ValidationErrors {
 	return ves
 }
 
+func fieldString(v any) string {
+	switch s := v.(type) {
+	case string:
+		return s
+	case types.NullableString:
+		return s.String()
+	}
+	return ""
+}
+
 func (s SchemaMetadata) MarshalJSON() ([]byte, error) {
 	m := make(map[string]interface{})
 
@@ -81,22 +105,41 @@ func (s SchemaMetadata) MarshalJSON() ([]byte, error) {
 	if s.Path != "" {
 		m["path"] = s.Path
 	}
+	if s.Deprecated {
+		m["deprecated"] = true
+		if s.DeprecationMessage != "" {
+			m["deprecationMessage"] = s.DeprecationMessage
+		}
+	}
 
 	return json.Marshal(m)
 }
 
+// InRootNamespace reports whether the object is in the root namespace. See types.RootNamespace.
+func (m SchemaMetadata) InRootNamespace() bool {
+	return types.IsRootNamespace(m.Namespace.String())
+}
+
+// CanonicalizeNamespace clears a namespace that refers to the root namespace, so that objects in the root
+// namespace are always saved and reported without a namespace
+func (m *SchemaMetadata) CanonicalizeNamespace() {
+	if m.InRootNamespace() {
+		m.Namespace = types.NullString()
+	}
+}
+
 func (m SchemaMetadata) GetStoragePath(t types.CatalogObjectType) string {
 	if t == types.CatalogObjectTypeCatalogCollection {
-		if m.Namespace.IsNil() {
-			return path.Clean("/" + types.DefaultNamespace + "/" + m.Path)
+		if m.InRootNamespace() {
+			return path.Clean("/" + types.RootNamespace() + "/" + m.Path)
 		} else {
-			return path.Clean("/" + types.DefaultNamespace + "/" + m.Namespace.String() + "/" + m.Path)
+			return path.Clean("/" + types.RootNamespace() + "/" + m.Namespace.String() + "/" + m.Path)
 		}
 	} else {
-		if m.Namespace.IsNil() {
-			return "/" + types.DefaultNamespace
+		if m.InRootNamespace() {
+			return "/" + types.RootNamespace()
 		} else {
-			return "/" + types.DefaultNamespace + "/" + m.Namespace.String()
+			return "/" + types.RootNamespace() + "/" + m.Namespace.String()
 		}
 	}
 }
@@ -105,3 +148,67 @@ func (m SchemaMetadata) GetEntropyBytes(t types.CatalogObjectType) []byte {
 	entropy := m.Catalog + ":" + string(t)
 	return []byte(entropy)
 }
+
+// unitPath returns the path of the unit of param in the spec of a schema of kind. A parameter schema has a
+// single unit, which is keyed by the empty string.
+func unitPath(kind, param string) string {
+	if kind == types.ParameterSchemaKind {
+		return "unit"
+	}
+	return "parameters." + param + ".unit"
+}
+
+// TakeUnits moves the units in spec, the spec of a schema of kind, to Units and returns spec without them
+func (m *SchemaMetadata) TakeUnits(kind string, spec []byte) ([]byte, schemaerr.ValidationErrors) {
+	var params []string
+	switch kind {
+	case types.ParameterSchemaKind:
+		params = []string{""}
+	case types.CollectionSchemaKind:
+		gjson.GetBytes(spec, "parameters").ForEach(func(param, _ gjson.Result) bool {
+			params = append(params, param.String())
+			return true
+		})
+	default:
+		return spec, nil
+	}
+
+	var ves schemaerr.ValidationErrors
+	m.Units = nil
+	for _, param := range params {
+		p := unitPath(kind, param)
+		u := gjson.GetBytes(spec, p)
+		if !u.Exists() {
+			continue
+		}
+		if u.Type != gjson.String {
+			ves = append(ves, schemaerr.ErrInvalidUnit("spec."+p))
+			continue
+		}
+		if u.String() != "" {
+			if m.Units == nil {
+				m.Units = make(map[string]string)
+			}
+			m.Units[param] = u.String()
+		}
+		var err error
+		if spec, err = sjson.DeleteBytes(spec, p); err != nil {
+			ves = append(ves, schemaerr.ErrValidationFailed("spec."+p))
+		}
+	}
+	return spec, ves
+}
+
+// SpecWithUnits returns spec, the spec of a schema of kind, with the units in Units written back to it
+func (m SchemaMetadata) SpecWithUnits(kind string, spec []byte) ([]byte, error) {
+	var err error
+	for param, unit := range m.Units {
+		if kind == types.CollectionSchemaKind && !gjson.GetBytes(spec, "parameters."+param).Exists() {
+			continue
+		}
+		if spec, err = sjson.SetBytes(spec, unitPath(kind, param), unit); err != nil {
+			return nil, err
+		}
+	}
+	return spec, nil
+}
@@ -3,6 +3,7 @@ package schemamanager
 import (
 	"encoding/json"
 	"path"
+	"strings"
 )
 
 // We'll keep this a struct, so this is extensible in the future
@@ -24,6 +25,15 @@ func (pr SchemaReference) Path() string {
 
 type SchemaReferences []SchemaReference
 
+// SplitSchemaRef splits a parameter schema reference of the form [namespace/]name. The namespace is empty if
+// the reference is not qualified.
+func SplitSchemaRef(ref string) (namespace, name string) {
+	if ns, n, ok := strings.Cut(ref, "/"); ok {
+		return ns, n
+	}
+	return "", ref
+}
+
 func (prs SchemaReferences) Serialize() ([]byte, error) {
 	s, err := json.Marshal(prs)
 	if err != nil {
@@ -0,0 +1,44 @@
+package versionregistry
+
+import (
+	"context"
+
+	"github.com/mugiliam/common/apperrors"
+	"github.com/mugiliam/hatchcatalogsrv/internal/catalogmanager/schemamanager"
+	"github.com/mugiliam/hatchcatalogsrv/pkg/api/schemastore"
+)
+
+// NewFunc builds a schema manager from a resource manifest
+type NewFunc func(ctx context.Context, rsrcJson []byte, options ...schemamanager.Options) (schemamanager.SchemaManager, apperrors.Error)
+
+// LoadFunc builds a schema manager from its storage representation
+type LoadFunc func(ctx context.Context, s *schemastore.SchemaStorageRepresentation, m *schemamanager.SchemaMetadata) (schemamanager.SchemaManager, apperrors.Error)
+
+// SchemaVersion holds the managers for one version of the schema format
+type SchemaVersion struct {
+	New  NewFunc
+	Load LoadFunc
+}
+
+var registry = make(map[string]SchemaVersion)
+var aliases = make(map[string]string)
+
+// RegisterSchemaVersion registers the managers for version. Versions are registered from package init functions.
+func RegisterSchemaVersion(version string, sv SchemaVersion) {
+	registry[version] = sv
+}
+
+// RegisterAlias makes alias resolve to the registered version. Manifests using an alias are stored
+// under the version it resolves to.
+func RegisterAlias(alias, version string) {
+	aliases[alias] = version
+}
+
+// Resolve returns the version that version resolves to, and its managers
+func Resolve(version string) (string, SchemaVersion, bool) {
+	if v, ok := aliases[version]; ok {
+		version = v
+	}
+	sv, ok := registry[version]
+	return version, sv, ok
+}
@@ -104,7 +104,115 @@ spec:
     maxValue: 10
   default: 5
 `,
-			expected: schemaerr.ErrInvalidNameFormat("metadata.name", "Invalid Name!").Error(),
+			expected: schemaerr.ErrInvalidResourceName("metadata.name", "Invalid Name!", "invalid-name").Error(),
+		},
+		{
+			name: "spaces in name",
+			yamlData: `
+version: v1
+kind: ParameterSchema
+metadata:
+  name: My Collection
+  catalog: example-catalog
+  path: /example
+spec:
+  dataType: Integer
+  validation:
+    minValue: 1
+    maxValue: 10
+  default: 5
+`,
+			expected: schemaerr.ErrInvalidResourceName("metadata.name", "My Collection", "my-collection").Error(),
+		},
+		{
+			name: "leading slash in name",
+			yamlData: `
+version: v1
+kind: ParameterSchema
+metadata:
+  name: /example
+  catalog: example-catalog
+  path: /example
+spec:
+  dataType: Integer
+  validation:
+    minValue: 1
+    maxValue: 10
+  default: 5
+`,
+			expected: schemaerr.ErrInvalidResourceName("metadata.name", "/example", "example").Error(),
+		},
+		{
+			name: "traversal in name",
+			yamlData: `
+version: v1
+kind: ParameterSchema
+metadata:
+  name: ../example
+  catalog: example-catalog
+  path: /example
+spec:
+  dataType: Integer
+  validation:
+    minValue: 1
+    maxValue: 10
+  default: 5
+`,
+			expected: schemaerr.ErrInvalidResourceName("metadata.name", "../example", "example").Error(),
+		},
+		{
+			name: "spaces in path",
+			yamlData: `
+version: v1
+kind: ParameterSchema
+metadata:
+  name: example
+  catalog: example-catalog
+  path: /my path/example
+spec:
+  dataType: Integer
+  validation:
+    minValue: 1
+    maxValue: 10
+  default: 5
+`,
+			expected: schemaerr.ErrInvalidResourcePath("metadata.path", "/my path/example", "/my-path/example").Error(),
+		},
+		{
+			name: "missing leading slash in path",
+			yamlData: `
+version: v1
+kind: ParameterSchema
+metadata:
+  name: example
+  catalog: example-catalog
+  path: example/path
+spec:
+  dataType: Integer
+  validation:
+    minValue: 1
+    maxValue: 10
+  default: 5
+`,
+			expected: schemaerr.ErrInvalidResourcePath("metadata.path", "example/path", "/example/path").Error(),
+		},
+		{
+			name: "traversal in path",
+			yamlData: `
+version: v1
+kind: ParameterSchema
+metadata:
+  name: example
+  catalog: example-catalog
+  path: /example/../../etc
+spec:
+  dataType: Integer
+  validation:
+    minValue: 1
+    maxValue: 10
+  default: 5
+`,
+			expected: schemaerr.ErrInvalidResourcePath("metadata.path", "/example/../../etc", "/example/etc").Error(),
 		},
 		{
 			name: "bad dataType",
@@ -163,6 +271,45 @@ spec:
 `,
 			expected: schemaerr.ErrMaxValueLessThanMinValue("validation.maxValue").Error(),
 		},
+		{
+			name: "inverted range with step",
+			yamlData: `
+version: v1
+kind: ParameterSchema
+metadata:
+  name: example
+  catalog: example-catalog
+  path: /example
+spec:
+  dataType: Integer
+  validation:
+    minValue: 10
+    maxValue: 1
+    step: 1
+`,
+			expected: schemaerr.ErrMaxValueLessThanMinValue("validation.maxValue").Error(),
+		},
+		{
+			name: "empty range with default off the bound",
+			yamlData: `
+version: v1
+kind: ParameterSchema
+metadata:
+  name: example
+  catalog: example-catalog
+  path: /example
+spec:
+  dataType: Integer
+  validation:
+    minValue: 5
+    maxValue: 5
+  default: 4
+`,
+			expected: schemaerr.ValidationError{
+				Field:  "default",
+				ErrStr: validationerrors.ErrValueBelowMin.Error(),
+			}.Error(),
+		},
 	}
 	// Run tests
 	ctx := newDb()
@@ -328,7 +475,7 @@ spec:
       schema: database-config-collection
 `,
 			expected: schemaerr.ValidationErrors{
-				schemaerr.ErrInvalidNameFormat("metadata.name", "Invalid Name!"),
+				schemaerr.ErrInvalidResourceName("metadata.name", "Invalid Name!", "invalid-name"),
 			}.Error(),
 		},
 	}
@@ -387,3 +534,74 @@ spec:
 		})
 	}
 }
+
+// YAML and JSON decoders keep the last of repeated keys, so duplicates are checked in the raw document
+func TestDuplicateParameterNames(t *testing.T) {
+	tests := []struct {
+		name     string
+		jsonData string
+		expected string
+	}{
+		{
+			name:     "duplicate parameter in collection schema",
+			jsonData: `{"version":"v1","kind":"CollectionSchema","metadata":{"name":"example","catalog":"example-catalog"},"spec":{"parameters":{"maxRetries":{"dataType":"Integer"},"maxDelay":{"dataType":"Integer"},"maxRetries":{"dataType":"Integer","default":5}}}}`,
+			expected: "duplicate keys in spec.parameters: maxRetries",
+		},
+		{
+			name:     "duplicate default in collection schema",
+			jsonData: `{"version":"v1","kind":"CollectionSchema","metadata":{"name":"example","catalog":"example-catalog"},"spec":{"parameters":{"maxRetries":{"dataType":"Integer"}},"values":{"maxRetries":1,"maxRetries":2}}}`,
+			expected: "duplicate keys in spec.values: maxRetries",
+		},
+		{
+			name:     "no duplicates",
+			jsonData: `{"version":"v1","kind":"CollectionSchema","metadata":{"name":"example","catalog":"example-catalog"},"spec":{"parameters":{"maxRetries":{"dataType":"Integer"},"maxDelay":{"dataType":"Integer"}}}}`,
+			expected: "",
+		},
+	}
+	ctx := newDb()
+	t.Cleanup(func() {
+		db.DB(ctx).Close(ctx)
+	})
+
+	tenantID := types.TenantId("TABCDE")
+	projectID := types.ProjectId("PABCDE")
+	ctx = common.SetTenantIdInContext(ctx, tenantID)
+	ctx = common.SetProjectIdInContext(ctx, projectID)
+
+	err := db.DB(ctx).CreateTenant(ctx, tenantID)
+	assert.NoError(t, err)
+	t.Cleanup(func() {
+		_ = db.DB(ctx).DeleteTenant(ctx, tenantID)
+	})
+	err = db.DB(ctx).CreateProject(ctx, projectID)
+	assert.NoError(t, err)
+	err = db.DB(ctx).CreateCatalog(ctx, &models.Catalog{
+		Name:        "example-catalog",
+		Description: "An example catalog",
+		Info:        pgtype.JSONB{Status: pgtype.Null},
+		ProjectID:   projectID,
+	})
+	assert.NoError(t, err)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewSchema(ctx, []byte(tt.jsonData), nil)
+			if tt.expected == "" {
+				assert.NoError(t, err)
+			} else if assert.Error(t, err) {
+				assert.ErrorIs(t, err, validationerrors.ErrSchemaValidation)
+				assert.Contains(t, err.Error(), tt.expected)
+			}
+		})
+	}
+
+	// values of a collection
+	_, err = NewCollectionManager(ctx, []byte(`{"version":"v1","kind":"Collection","metadata":{"name":"example","catalog":"example-catalog","path":"/"},"spec":{"schema":"example","values":{"maxRetries":1,"maxRetries":2}}}`), nil)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "duplicate keys in spec.values: maxRetries")
+	}
+
+	// duplicate keys in yaml are rejected before they reach the decoder
+	_, yamlErr := yaml.YAMLToJSONStrict([]byte("spec:\n  parameters:\n    maxRetries:\n      dataType: Integer\n    maxRetries:\n      dataType: Integer\n"))
+	assert.Error(t, yamlErr)
+}
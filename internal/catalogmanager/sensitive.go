@@ -0,0 +1,123 @@
+package catalogmanager
+
+import (
+	"encoding/json"
+
+	"github.com/mugiliam/common/apperrors"
+	"github.com/mugiliam/hatchcatalogsrv/internal/catalogmanager/schemamanager"
+	"github.com/mugiliam/hatchcatalogsrv/internal/config"
+	"github.com/mugiliam/hatchcatalogsrv/internal/secrets"
+	"github.com/mugiliam/hatchcatalogsrv/pkg/api/schemastore"
+	"github.com/mugiliam/hatchcatalogsrv/pkg/types"
+)
+
+// RedactedValue replaces the values of sensitive parameters in responses
+const RedactedValue = "[redacted]"
+
+// sealSensitiveValues encrypts the values of the sensitive parameters of s, the storage representation of a
+// collection. Values are sealed after they are validated and before the collection is hashed, so the hash is
+// that of the stored object.
+func sealSensitiveValues(s *schemastore.SchemaStorageRepresentation) apperrors.Error {
+	return mapSensitiveValues(s, func(v types.NullableAny) (types.NullableAny, error) {
+		raw, err := json.Marshal(v)
+		if err != nil {
+			return v, err
+		}
+		sealed, err := secrets.Encrypt(raw)
+		if err != nil {
+			return v, err
+		}
+		return types.NullableAnyFrom(sealed)
+	})
+}
+
+// unsealSensitiveValues decrypts the values sealed by sealSensitiveValues
+func unsealSensitiveValues(s *schemastore.SchemaStorageRepresentation) apperrors.Error {
+	return mapSensitiveValues(s, func(v types.NullableAny) (types.NullableAny, error) {
+		var sealed string
+		if err := v.GetAs(&sealed); err != nil {
+			return v, err
+		}
+		raw, err := secrets.Decrypt(sealed)
+		if err != nil {
+			return v, err
+		}
+		var plain types.NullableAny
+		err = plain.UnmarshalJSON(raw)
+		return plain, err
+	})
+}
+
+// mapSensitiveValues replaces each value of a sensitive parameter in the values and the spec of the collection
+// in s with the result of f. Values are marked sensitive in the values of the stored collection, so they can
+// be found without loading the collection schema.
+func mapSensitiveValues(s *schemastore.SchemaStorageRepresentation, f func(types.NullableAny) (types.NullableAny, error)) apperrors.Error {
+	if len(s.Values) == 0 {
+		return nil
+	}
+	var values schemamanager.ParamValues
+	if err := json.Unmarshal(s.Values, &values); err != nil {
+		return ErrUnableToLoadObject.Err(err)
+	}
+	var spec collectionSpec
+	if err := json.Unmarshal(s.Schema, &spec); err != nil {
+		return ErrUnableToLoadObject.Err(err)
+	}
+
+	changed := false
+	for param, pv := range values {
+		if !pv.Sensitive {
+			continue
+		}
+		if !pv.Value.IsNil() {
+			v, err := f(pv.Value)
+			if err != nil {
+				return ErrSensitiveValue.Err(err).Msg("parameter " + param)
+			}
+			pv.Value = v
+			values[param] = pv
+			changed = true
+		}
+		if sv, ok := spec.Values[param]; ok && !sv.IsNil() {
+			v, err := f(sv)
+			if err != nil {
+				return ErrSensitiveValue.Err(err).Msg("parameter " + param)
+			}
+			spec.Values[param] = v
+			changed = true
+		}
+	}
+	if !changed {
+		return nil
+	}
+
+	var err error
+	if s.Values, err = json.Marshal(values); err != nil {
+		return ErrSensitiveValue.Err(err)
+	}
+	if s.Schema, err = json.Marshal(spec); err != nil {
+		return ErrSensitiveValue.Err(err)
+	}
+	return nil
+}
+
+// collectionStorageRepresentation returns the storage representation of cm with its sensitive values sealed
+func collectionStorageRepresentation(cm schemamanager.CollectionManager) (*schemastore.SchemaStorageRepresentation, apperrors.Error) {
+	s := cm.StorageRepresentation()
+	if err := sealSensitiveValues(s); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// revealSensitiveValues reports whether the reveal query parameter of reqCtx asks for sensitive values in
+// plaintext, which is only allowed when allow_reveal is configured
+func revealSensitiveValues(reqCtx RequestContext) (bool, apperrors.Error) {
+	if reqCtx.QueryParams.Get("reveal") != "true" {
+		return false, nil
+	}
+	if !config.Config().AllowReveal {
+		return false, ErrRevealNotAllowed
+	}
+	return true, nil
+}
@@ -3,8 +3,10 @@ package collection
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"path"
 	"reflect"
+	"sort"
 
 	"github.com/go-playground/validator/v10"
 	"github.com/mugiliam/common/apperrors"
@@ -13,6 +15,8 @@ import (
 	"github.com/mugiliam/hatchcatalogsrv/internal/catalogmanager/schemamanager"
 	"github.com/mugiliam/hatchcatalogsrv/internal/catalogmanager/schemamanager/datatyperegistry"
 	"github.com/mugiliam/hatchcatalogsrv/internal/catalogmanager/v1/parameter"
+	"github.com/mugiliam/hatchcatalogsrv/internal/catalogmanager/validationerrors"
+	"github.com/mugiliam/hatchcatalogsrv/internal/secrets"
 	"github.com/mugiliam/hatchcatalogsrv/pkg/types"
 )
 
@@ -28,9 +32,13 @@ type CollectionSpec struct {
 }
 
 type Parameter struct {
-	Schema      string                    `json:"schema" validate:"required_without=DataType,omitempty,nameFormatValidator"`
+	Schema      string                    `json:"schema" validate:"required_without=DataType,omitempty,schemaRefValidator"` // [namespace/]name
 	DataType    string                    `json:"dataType" validate:"required_without=Schema,excluded_unless=Schema '',omitempty,nameFormatValidator"`
 	Default     types.NullableAny         `json:"default"`
+	DefaultExpr string                    `json:"defaultExpr,omitempty"` // computed from other parameters, see defaultexpr.go
+	Required    bool                      `json:"required,omitempty"`    // collections must have a value, set or defaulted
+	Nullable    bool                      `json:"nullable,omitempty"`    // values may be set to null explicitly, clearing the default
+	Sensitive   bool                      `json:"sensitive,omitempty"`   // values are encrypted at rest and redacted on reads
 	Annotations schemamanager.Annotations `json:"annotations" validate:"omitempty,dive,keys,noSpaces,endkeys"`
 }
 
@@ -44,7 +52,7 @@ func (cs *CollectionSchema) Validate() schemaerr.ValidationErrors {
 	// TODO: Add validation for dataType and default fields
 	err := schemavalidator.V().Struct(cs)
 	if err == nil {
-		return nil
+		return append(cs.validateDefaultExprs(), cs.validateSensitive()...)
 	}
 	ve, ok := err.(validator.ValidationErrors)
 	if !ok {
@@ -64,7 +72,7 @@ func (cs *CollectionSchema) Validate() schemaerr.ValidationErrors {
 			ves = append(ves, schemaerr.ErrMissingSchemaOrType(jsonFieldName))
 		case "excluded_unless":
 			ves = append(ves, schemaerr.ErrShouldContainSchemaOrType(jsonFieldName))
-		case "nameFormatValidator":
+		case "nameFormatValidator", "schemaRefValidator":
 			val, _ := e.Value().(string)
 			ves = append(ves, schemaerr.ErrInvalidNameFormat(jsonFieldName, val))
 		case "resourcePathValidator":
@@ -91,13 +99,21 @@ func (cs *CollectionSchema) ValidateDependencies(ctx context.Context, loaders sc
 		cs.Values = make(schemamanager.ParamValues)
 	}
 
-	for n, p := range cs.Spec.Parameters {
+	// all parameters are validated, in a stable order, so that every error is reported at once
+	schemaPaths := make(map[string]string)
+	names := cs.ParameterNames()
+	sort.Strings(names)
+	for _, n := range names {
+		p := cs.Spec.Parameters[n]
 		if p.Schema != "" {
 			var schemaPath string
-			for _, ref := range existingRefs {
-				if ref.SchemaName() == p.Schema {
-					schemaPath = ref.Name
-					break
+			// references qualified by a namespace are always resolved in that namespace
+			if ns, _ := schemamanager.SplitSchemaRef(p.Schema); ns == "" {
+				for _, ref := range existingRefs {
+					if ref.SchemaName() == p.Schema {
+						schemaPath = ref.Name
+						break
+					}
 				}
 			}
 			var ref schemamanager.SchemaReference
@@ -107,6 +123,7 @@ func (cs *CollectionSchema) ValidateDependencies(ctx context.Context, loaders sc
 				ves = append(ves, ve...)
 			} else {
 				refMap[ref.Name] = ref
+				schemaPaths[n] = ref.Name
 			}
 		} else if p.DataType != "" {
 			ves = append(ves, validateDataTypeDependency(n, &p, cs.Version)...)
@@ -115,23 +132,68 @@ func (cs *CollectionSchema) ValidateDependencies(ctx context.Context, loaders sc
 				Version: cs.Version,
 			}
 		}
+		if p.Sensitive && !secrets.Enabled() {
+			ves = append(ves, schemaerr.ErrSensitiveNotEnabled("spec.parameters."+n+".sensitive"))
+		}
 		if dataType.Type != "" {
 			cs.Values[n] = schemamanager.ParamValue{
 				DataType:    dataType,
 				Annotations: p.Annotations,
+				Sensitive:   p.Sensitive,
 			}
 		}
 		cs.Spec.Parameters[n] = p
 	}
+	if ves == nil {
+		ves = append(ves, cs.validateComputedDefaults(ctx, loaders, schemaPaths)...)
+	}
 	for _, ref := range refMap {
 		refs = append(refs, ref)
 	}
 	return refs, ves
 }
 
+// validateComputedDefaults checks that the defaults computed by default expressions from the defaults of the
+// other parameters are valid values of their parameters. schemaPaths has the path of the parameter schema of
+// each parameter that has one.
+func (cs *CollectionSchema) validateComputedDefaults(ctx context.Context, loaders schemamanager.SchemaLoaders, schemaPaths map[string]string) schemaerr.ValidationErrors {
+	var ves schemaerr.ValidationErrors
+	defaults := make(map[string]types.NullableAny)
+	for n, p := range cs.Spec.Parameters {
+		defaults[n] = p.Default
+	}
+	computed, errs := cs.evaluateDefaultExprs(defaults, nil)
+	names := cs.ParameterNames()
+	sort.Strings(names)
+	for _, n := range names {
+		if err, ok := errs[n]; ok {
+			ves = append(ves, schemaerr.ErrInvalidDefaultExpr("spec.parameters."+n+".defaultExpr", err.Error()))
+			continue
+		}
+		v, ok := computed[n]
+		if !ok || v.IsNil() {
+			continue
+		}
+		// validate the computed default as though it were the default
+		p := cs.Spec.Parameters[n]
+		p.Default = v
+		if p.Schema != "" {
+			_, _, ve := validateParameterSchemaDependency(ctx, loaders, n, schemaPaths[n], &p)
+			ves = append(ves, ve...)
+		} else if p.DataType != "" {
+			ves = append(ves, validateDataTypeDependency(n, &p, cs.Version)...)
+		}
+	}
+	return ves
+}
+
 func (cs *CollectionSchema) ValidateValue(ctx context.Context, loaders schemamanager.SchemaLoaders, param string, value types.NullableAny) schemaerr.ValidationErrors {
 	var ves schemaerr.ValidationErrors
 	if value.IsNil() {
+		// a parameter that is not nullable is unset by leaving out its value, not by setting it to null
+		if p, ok := cs.Spec.Parameters[param]; ok && !p.Nullable {
+			ves = append(ves, schemaerr.ErrNullValue(param))
+		}
 		return ves
 	}
 	if loaders.ClosestParent == nil || loaders.ByPath == nil || loaders.ParameterRef == nil {
@@ -158,6 +220,21 @@ func (cs *CollectionSchema) ValidateValue(ctx context.Context, loaders schemaman
 	return ves
 }
 
+// ValidateValues validates values, keyed by parameter name, with ValidateValue and returns the errors of all of
+// them, ordered by parameter name
+func (cs *CollectionSchema) ValidateValues(ctx context.Context, loaders schemamanager.SchemaLoaders, values map[string]types.NullableAny) schemaerr.ValidationErrors {
+	var ves schemaerr.ValidationErrors
+	names := make([]string, 0, len(values))
+	for n := range values {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	for _, n := range names {
+		ves = append(ves, cs.ValidateValue(ctx, loaders, n, values[n])...)
+	}
+	return ves
+}
+
 func (cs *CollectionSchema) GetValue(ctx context.Context, param string) schemamanager.ParamValue {
 	if cs.Values == nil {
 		return schemamanager.ParamValue{}
@@ -189,6 +266,119 @@ func (cs *CollectionSchema) SetDefaultValues(ctx context.Context) {
 		}
 		cs.SetValue(ctx, n, p.Default)
 	}
+	values := make(map[string]types.NullableAny)
+	for n, v := range cs.Values {
+		values[n] = v.Value
+	}
+	computed, _ := cs.evaluateDefaultExprs(values, nil)
+	for n, v := range computed {
+		cs.SetValue(ctx, n, v)
+	}
+}
+
+// validateDefaultExprs checks that each default expression parses, refers only to other parameters of the
+// schema and is not also given a default, and that the expressions do not refer to each other in a cycle
+func (cs *CollectionSchema) validateDefaultExprs() schemaerr.ValidationErrors {
+	var ves schemaerr.ValidationErrors
+	deps := make(map[string][]string)
+	names := cs.ParameterNames()
+	sort.Strings(names)
+	for _, n := range names {
+		p := cs.Spec.Parameters[n]
+		if p.DefaultExpr == "" {
+			continue
+		}
+		field := "spec.parameters." + n + ".defaultExpr"
+		if !p.Default.IsNil() {
+			ves = append(ves, schemaerr.ErrDefaultAndDefaultExpr(field))
+			continue
+		}
+		e, err := parseDefaultExpr(p.DefaultExpr)
+		if err != nil {
+			ves = append(ves, schemaerr.ErrInvalidDefaultExpr(field, err.Error()))
+			continue
+		}
+		refs := exprRefs(e)
+		for _, r := range refs {
+			if _, ok := cs.Spec.Parameters[r]; !ok {
+				ves = append(ves, schemaerr.ErrInvalidDefaultExpr(field, "unknown parameter "+r))
+			}
+		}
+		deps[n] = refs
+	}
+	if ves != nil {
+		return ves
+	}
+	if _, cycle := defaultExprOrder(deps); cycle != nil {
+		ves = append(ves, schemaerr.ErrCyclicDefaultExpr("spec.parameters", cycleString(cycle)))
+	}
+	return ves
+}
+
+// validateSensitive checks that sensitive parameters have no default. Defaults are part of the schema, which is
+// not encrypted, so a sensitive value can only be set on collections.
+func (cs *CollectionSchema) validateSensitive() schemaerr.ValidationErrors {
+	var ves schemaerr.ValidationErrors
+	names := cs.ParameterNames()
+	sort.Strings(names)
+	for _, n := range names {
+		p := cs.Spec.Parameters[n]
+		if p.Sensitive && (!p.Default.IsNil() || p.DefaultExpr != "") {
+			ves = append(ves, schemaerr.ErrSensitiveDefault("spec.parameters."+n+".default"))
+		}
+	}
+	return ves
+}
+
+// evaluateDefaultExprs computes the defaults of the parameters with a default expression from values, the
+// values of all parameters. Only parameters for which recompute returns true are computed, or all of them if
+// recompute is nil. Expressions are evaluated in dependency order, so a computed default is used by the
+// expressions that refer to it. A default that refers to a parameter without a value is computed as nil.
+// Expressions that fail to evaluate are returned in errs.
+func (cs *CollectionSchema) evaluateDefaultExprs(values map[string]types.NullableAny, recompute func(param string) bool) (computed map[string]types.NullableAny, errs map[string]error) {
+	deps := make(map[string][]string)
+	exprs := make(map[string]exprNode)
+	for n, p := range cs.Spec.Parameters {
+		if p.DefaultExpr == "" {
+			continue
+		}
+		e, err := parseDefaultExpr(p.DefaultExpr)
+		if err != nil {
+			continue
+		}
+		exprs[n] = e
+		deps[n] = exprRefs(e)
+	}
+	order, _ := defaultExprOrder(deps)
+	if len(order) == 0 {
+		return nil, nil
+	}
+
+	env := make(map[string]types.NullableAny, len(values))
+	for n, v := range values {
+		env[n] = v
+	}
+	computed = make(map[string]types.NullableAny)
+	for _, n := range order {
+		if recompute != nil && !recompute(n) {
+			continue
+		}
+		v, err := exprs[n].eval(env)
+		switch {
+		case err == nil:
+			env[n] = v.nullableAny()
+		case errors.Is(err, errUnsetParameter):
+			env[n] = types.NilAny()
+		default:
+			if errs == nil {
+				errs = make(map[string]error)
+			}
+			errs[n] = err
+			env[n] = types.NilAny()
+		}
+		computed[n] = env[n]
+	}
+	return computed, errs
 }
 
 func (cs *CollectionSchema) ParameterNames() []string {
@@ -199,10 +389,22 @@ func (cs *CollectionSchema) ParameterNames() []string {
 	return names
 }
 
+// RequiredParameters returns the names of the parameters that collections must have a value for, sorted
+func (cs *CollectionSchema) RequiredParameters() []string {
+	var names []string
+	for n, p := range cs.Spec.Parameters {
+		if p.Required {
+			names = append(names, n)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
 func (cs *CollectionSchema) ParametersWithSchema(schemaName string) []schemamanager.ParameterSpec {
 	var params []schemamanager.ParameterSpec
 	for n, p := range cs.Spec.Parameters {
-		if p.Schema == schemaName {
+		if _, name := schemamanager.SplitSchemaRef(p.Schema); name == schemaName {
 			ps := schemamanager.ParameterSpec{
 				Name:    n,
 				Default: p.Default,
@@ -230,7 +432,10 @@ func validateParameterSchemaDependency(ctx context.Context, loaders schemamanage
 		schemaPath, hash, err = loaders.ClosestParent(ctx, types.CatalogObjectTypeParameterSchema, p.Schema)
 	}
 
-	if err != nil || (schemaPath == "" && hash == "") {
+	if errors.Is(err, validationerrors.ErrNamespaceNotFound) {
+		ns, _ := schemamanager.SplitSchemaRef(p.Schema)
+		ves = append(ves, schemaerr.ErrNamespaceDoesNotExist(p.Schema, ns))
+	} else if err != nil || (schemaPath == "" && hash == "") {
 		ves = append(ves, schemaerr.ErrParameterSchemaDoesNotExist(p.Schema))
 	} else {
 		ref = schemamanager.SchemaReference{
@@ -260,9 +465,9 @@ func validateParameterSchemaDependency(ctx context.Context, loaders schemamanage
 		dataType = pm.DataType()
 		if !p.Default.IsNil() {
 			if err := pm.ValidateValue(p.Default); err != nil {
-				ves = append(ves, schemaerr.ErrInvalidValue(name, err.Error()))
+				ves = append(ves, invalidValueError(name, dataType.Type, p.Default, err))
 			}
-		} else {
+		} else if p.DefaultExpr == "" {
 			if pm.Default() != nil {
 				p.Default, _ = types.NullableAnyFrom(pm.Default())
 			}
@@ -271,6 +476,15 @@ func validateParameterSchemaDependency(ctx context.Context, loaders schemamanage
 	return dataType, ref, ves
 }
 
+// invalidValueError returns the validation error for value of parameter name of data type dataType, which failed
+// validation with err. A value of the wrong type is reported with the type it should have.
+func invalidValueError(name string, dataType string, value types.NullableAny, err error) schemaerr.ValidationError {
+	if errors.Is(err, validationerrors.ErrInvalidType) {
+		return schemaerr.ErrValueTypeMismatch(name, dataType, value.Get())
+	}
+	return schemaerr.ErrInvalidValue(name, err.Error())
+}
+
 func validateDataTypeDependency(name string, p *Parameter, version string) schemaerr.ValidationErrors {
 	var ves schemaerr.ValidationErrors
 
@@ -280,7 +494,7 @@ func validateDataTypeDependency(name string, p *Parameter, version string) schem
 		Version: version,
 	})
 	if loader == nil {
-		ves = append(ves, schemaerr.ErrUnsupportedDataType("spec.parameters.dataType", p.DataType))
+		ves = append(ves, schemaerr.ErrUnsupportedDataType("spec.parameters."+name+".dataType", p.DataType))
 		return ves
 	}
 	if !p.Default.IsNil() {
@@ -303,7 +517,7 @@ func validateDataTypeDependency(name string, p *Parameter, version string) schem
 			return ves
 		}
 		if err := parameter.ValidateValue(p.Default); err != nil {
-			ves = append(ves, schemaerr.ErrInvalidValue(name, err.Error()))
+			ves = append(ves, invalidValueError(name, p.DataType, p.Default, err))
 			return ves
 		}
 	}
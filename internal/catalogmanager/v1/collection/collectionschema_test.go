@@ -1,10 +1,15 @@
 package collection
 
 import (
+	"context"
 	"encoding/json"
 	"testing"
 
+	"github.com/mugiliam/common/apperrors"
 	schemaerr "github.com/mugiliam/hatchcatalogsrv/internal/catalogmanager/schema/errors"
+	"github.com/mugiliam/hatchcatalogsrv/internal/catalogmanager/schemamanager"
+	"github.com/mugiliam/hatchcatalogsrv/internal/catalogmanager/validationerrors"
+	"github.com/mugiliam/hatchcatalogsrv/pkg/types"
 	"github.com/stretchr/testify/assert"
 	"sigs.k8s.io/yaml"
 )
@@ -120,3 +125,226 @@ spec:
 		})
 	}
 }
+
+func TestDefaultExpr(t *testing.T) {
+	validate := func(yamlInput string) schemaerr.ValidationErrors {
+		jsonData, err := yaml.YAMLToJSON([]byte(yamlInput))
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		var cs CollectionSchema
+		if !assert.NoError(t, json.Unmarshal(jsonData, &cs)) {
+			t.FailNow()
+		}
+		return cs.Validate()
+	}
+
+	ves := validate(`
+version: v1
+spec:
+  parameters:
+    maxRetries:
+      dataType: Integer
+      default: 3
+    maxDelay:
+      dataType: Integer
+      defaultExpr: maxRetries * 1000
+`)
+	assert.Nil(t, ves)
+
+	ves = validate(`
+version: v1
+spec:
+  parameters:
+    maxDelay:
+      dataType: Integer
+      default: 5
+      defaultExpr: 1000
+`)
+	assert.Equal(t, schemaerr.ValidationErrors{schemaerr.ErrDefaultAndDefaultExpr("spec.parameters.maxDelay.defaultExpr")}, ves)
+
+	ves = validate(`
+version: v1
+spec:
+  parameters:
+    maxDelay:
+      dataType: Integer
+      defaultExpr: retries * 1000
+`)
+	assert.Equal(t, schemaerr.ValidationErrors{schemaerr.ErrInvalidDefaultExpr("spec.parameters.maxDelay.defaultExpr", "unknown parameter retries")}, ves)
+
+	ves = validate(`
+version: v1
+spec:
+  parameters:
+    maxDelay:
+      dataType: Integer
+      defaultExpr: (1000 +
+`)
+	if assert.Len(t, ves, 1) {
+		assert.Contains(t, ves[0].Error(), "invalid default expression")
+	}
+
+	ves = validate(`
+version: v1
+spec:
+  parameters:
+    a:
+      dataType: Integer
+      defaultExpr: b + 1
+    b:
+      dataType: Integer
+      defaultExpr: a + 1
+`)
+	assert.Equal(t, schemaerr.ValidationErrors{schemaerr.ErrCyclicDefaultExpr("spec.parameters", "a -> b -> a")}, ves)
+
+	ves = validate(`
+version: v1
+spec:
+  parameters:
+    a:
+      dataType: Integer
+      defaultExpr: a * 2
+`)
+	assert.Equal(t, schemaerr.ValidationErrors{schemaerr.ErrCyclicDefaultExpr("spec.parameters", "a -> a")}, ves)
+}
+
+func TestEvaluateDefaultExprs(t *testing.T) {
+	cs := CollectionSchema{
+		Spec: CollectionSpec{
+			Parameters: map[string]Parameter{
+				"max-retries": {DataType: "Integer"},
+				"maxDelay":    {DataType: "Integer", DefaultExpr: "max-retries * 1000"},
+				"totalDelay":  {DataType: "Integer", DefaultExpr: "(maxDelay - 500) / 2 + -1"},
+				"ratio":       {DataType: "Float", DefaultExpr: "max-retries / 2.0"},
+				"remainder":   {DataType: "Integer", DefaultExpr: "max-retries % 2"},
+			},
+		},
+	}
+	num := func(v any) types.NullableAny {
+		na, _ := types.NullableAnyFrom(v)
+		return na
+	}
+
+	computed, errs := cs.evaluateDefaultExprs(map[string]types.NullableAny{"max-retries": num(3)}, nil)
+	assert.Nil(t, errs)
+	assert.Equal(t, map[string]types.NullableAny{
+		"maxDelay":   num(3000),
+		"totalDelay": num(1249),
+		"ratio":      num(1.5),
+		"remainder":  num(1),
+	}, computed)
+
+	// only the selected defaults are computed, from the given values of the others
+	computed, _ = cs.evaluateDefaultExprs(map[string]types.NullableAny{"max-retries": num(3), "maxDelay": num(100)}, func(p string) bool {
+		return p == "totalDelay"
+	})
+	assert.Equal(t, map[string]types.NullableAny{"totalDelay": num(-201)}, computed)
+
+	// a default that refers to a parameter without a value has no value
+	computed, errs = cs.evaluateDefaultExprs(map[string]types.NullableAny{}, nil)
+	assert.Nil(t, errs)
+	assert.True(t, computed["maxDelay"].IsNil())
+	assert.True(t, computed["totalDelay"].IsNil())
+
+	// a default that cannot be evaluated is an error
+	computed, errs = cs.evaluateDefaultExprs(map[string]types.NullableAny{"max-retries": num(0)}, func(p string) bool {
+		return p == "remainder"
+	})
+	assert.Contains(t, errs["remainder"].Error(), "division by zero")
+	assert.True(t, computed["remainder"].IsNil())
+}
+
+func TestValidateDependenciesReportsAllErrors(t *testing.T) {
+	var cs CollectionSchema
+	err := yaml.Unmarshal([]byte(`
+version: v1
+spec:
+  parameters:
+    maxRetries:
+      dataType: Integer
+      default: many
+    maxDelay:
+      dataType: Integer
+      default: true
+    maxAttempts:
+      dataType: Integer
+      default: 3
+    timeout:
+      dataType: Integer
+      default: [1]
+`), &cs)
+	assert.NoError(t, err)
+	assert.Nil(t, cs.Validate())
+
+	// parameters with a data type don't load other schemas
+	loaders := schemamanager.SchemaLoaders{
+		ByPath: func(context.Context, types.CatalogObjectType, *schemamanager.SchemaMetadata) (schemamanager.SchemaManager, apperrors.Error) {
+			return nil, validationerrors.ErrSchemaValidation
+		},
+		ByHash: func(context.Context, types.CatalogObjectType, string, *schemamanager.SchemaMetadata) (schemamanager.SchemaManager, apperrors.Error) {
+			return nil, validationerrors.ErrSchemaValidation
+		},
+		ClosestParent: func(context.Context, types.CatalogObjectType, string) (string, string, apperrors.Error) {
+			return "", "", validationerrors.ErrSchemaValidation
+		},
+		ParameterRef: func(string) string {
+			return ""
+		},
+	}
+	_, ves := cs.ValidateDependencies(context.Background(), loaders, nil)
+	if assert.Len(t, ves, 3) {
+		// all of them, in the order of the parameter names
+		assert.Equal(t, "maxDelay", ves[0].Field)
+		assert.Equal(t, "maxRetries", ves[1].Field)
+		assert.Equal(t, "timeout", ves[2].Field)
+	}
+
+	// values are validated together in the same way
+	num := func(v any) types.NullableAny {
+		na, _ := types.NullableAnyFrom(v)
+		return na
+	}
+	ves = cs.ValidateValues(context.Background(), loaders, map[string]types.NullableAny{
+		"timeout":     num("soon"),
+		"maxAttempts": num(5),
+		"maxRetries":  num(false),
+	})
+	if assert.Len(t, ves, 2) {
+		assert.Equal(t, "maxRetries", ves[0].Field)
+		assert.Equal(t, "timeout", ves[1].Field)
+	}
+}
+
+func TestValidateValueTypeMismatch(t *testing.T) {
+	var cs CollectionSchema
+	err := yaml.Unmarshal([]byte(`
+version: v1
+spec:
+  parameters:
+    maxDelay:
+      dataType: Integer
+      default: 1000
+`), &cs)
+	assert.NoError(t, err)
+	assert.Nil(t, cs.Validate())
+
+	loaders := schemamanager.SchemaLoaders{
+		ByPath: func(context.Context, types.CatalogObjectType, *schemamanager.SchemaMetadata) (schemamanager.SchemaManager, apperrors.Error) {
+			return nil, validationerrors.ErrSchemaValidation
+		},
+		ClosestParent: func(context.Context, types.CatalogObjectType, string) (string, string, apperrors.Error) {
+			return "", "", validationerrors.ErrSchemaValidation
+		},
+		ParameterRef: func(string) string {
+			return ""
+		},
+	}
+	value, _ := types.NullableAnyFrom("two_thousand")
+	ves := cs.ValidateValue(context.Background(), loaders, "maxDelay", value)
+	if assert.Len(t, ves, 1) {
+		assert.Equal(t, "maxDelay", ves[0].Field)
+		assert.Equal(t, schemaerr.TypeMismatch{DataType: "Integer", Value: "two_thousand"}, ves[0].Value)
+		assert.Equal(t, `maxDelay: expects Integer, got "two_thousand"`, ves[0].Error())
+	}
+}
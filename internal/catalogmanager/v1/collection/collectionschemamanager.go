@@ -77,6 +77,10 @@ func (cm *V1CollectionSchemaManager) ParameterNames() []string {
 	return cm.collectionSchema.ParameterNames()
 }
 
+func (cm *V1CollectionSchemaManager) RequiredParameters() []string {
+	return cm.collectionSchema.RequiredParameters()
+}
+
 func (cm *V1CollectionSchemaManager) ParametersWithSchema(schemaName string) []schemamanager.ParameterSpec {
 	return cm.collectionSchema.ParametersWithSchema(schemaName)
 }
@@ -97,6 +101,14 @@ func (cm *V1CollectionSchemaManager) ValidateValue(ctx context.Context, loaders
 	return nil
 }
 
+func (cm *V1CollectionSchemaManager) ValidateValues(ctx context.Context, loaders schemamanager.SchemaLoaders, values map[string]types.NullableAny) apperrors.Error {
+	ves := cm.collectionSchema.ValidateValues(ctx, loaders, values)
+	if ves != nil {
+		return validationerrors.ErrSchemaValidation.Msg(ves.Error())
+	}
+	return nil
+}
+
 func (cm *V1CollectionSchemaManager) GetValue(ctx context.Context, param string) schemamanager.ParamValue {
 	return cm.collectionSchema.GetValue(ctx, param)
 }
@@ -113,6 +125,15 @@ func (cm *V1CollectionSchemaManager) SetValue(ctx context.Context, param string,
 	return nil
 }
 
+func (cm *V1CollectionSchemaManager) ComputeDefaults(values schemamanager.ParamValues, recompute func(param string) bool) map[string]types.NullableAny {
+	env := make(map[string]types.NullableAny, len(values))
+	for n, v := range values {
+		env[n] = v.Value
+	}
+	computed, _ := cm.collectionSchema.evaluateDefaultExprs(env, recompute)
+	return computed
+}
+
 func (cm *V1CollectionSchemaManager) SetDefaultValues(ctx context.Context) {
 	cm.collectionSchema.SetDefaultValues(ctx)
 }
@@ -0,0 +1,345 @@
+package collection
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/mugiliam/hatchcatalogsrv/pkg/types"
+)
+
+// Default expressions compute the default of a parameter from the values of other parameters in the same
+// collection schema, e.g. "maxRetries * 1000". They are arithmetic on numbers only: +, -, *, /, % and
+// parentheses over number literals and parameter names. Since parameter names may contain '-', a subtraction
+// that follows a parameter name must be separated from it by a space. Integers stay integers unless a
+// float is involved, and integer division truncates.
+
+// errUnsetParameter is returned when an expression refers to a parameter that has no value. The default is
+// left unset rather than failing.
+var errUnsetParameter = errors.New("parameter has no value")
+
+type exprNode interface {
+	eval(env map[string]types.NullableAny) (exprValue, error)
+	refs(add func(string))
+}
+
+type exprValue struct {
+	i       int64
+	f       float64
+	isFloat bool
+}
+
+func (v exprValue) float() float64 {
+	if v.isFloat {
+		return v.f
+	}
+	return float64(v.i)
+}
+
+func (v exprValue) nullableAny() types.NullableAny {
+	var na types.NullableAny
+	if v.isFloat {
+		na, _ = types.NullableAnyFrom(v.f)
+	} else {
+		na, _ = types.NullableAnyFrom(v.i)
+	}
+	return na
+}
+
+type numberNode exprValue
+
+func (n numberNode) eval(map[string]types.NullableAny) (exprValue, error) { return exprValue(n), nil }
+func (n numberNode) refs(func(string))                                    {}
+
+type paramNode string
+
+func (n paramNode) eval(env map[string]types.NullableAny) (exprValue, error) {
+	v, ok := env[string(n)]
+	if !ok || v.IsNil() {
+		return exprValue{}, errUnsetParameter
+	}
+	b, _ := v.MarshalJSON()
+	d := json.NewDecoder(bytes.NewReader(b))
+	d.UseNumber()
+	var num json.Number
+	if err := d.Decode(&num); err != nil {
+		return exprValue{}, fmt.Errorf("parameter %s is not a number", string(n))
+	}
+	return numberValue(string(num))
+}
+
+func (n paramNode) refs(add func(string)) { add(string(n)) }
+
+type negNode struct{ x exprNode }
+
+func (n negNode) eval(env map[string]types.NullableAny) (exprValue, error) {
+	v, err := n.x.eval(env)
+	if err != nil {
+		return v, err
+	}
+	if v.isFloat {
+		v.f = -v.f
+	} else {
+		v.i = -v.i
+	}
+	return v, nil
+}
+
+func (n negNode) refs(add func(string)) { n.x.refs(add) }
+
+type binaryNode struct {
+	op   byte
+	l, r exprNode
+}
+
+func (n binaryNode) eval(env map[string]types.NullableAny) (exprValue, error) {
+	l, err := n.l.eval(env)
+	if err != nil {
+		return l, err
+	}
+	r, err := n.r.eval(env)
+	if err != nil {
+		return r, err
+	}
+	if (n.op == '/' || n.op == '%') && r.float() == 0 {
+		return exprValue{}, errors.New("division by zero")
+	}
+	if l.isFloat || r.isFloat {
+		a, b := l.float(), r.float()
+		v := exprValue{isFloat: true}
+		switch n.op {
+		case '+':
+			v.f = a + b
+		case '-':
+			v.f = a - b
+		case '*':
+			v.f = a * b
+		case '/':
+			v.f = a / b
+		case '%':
+			return exprValue{}, errors.New("% requires integers")
+		}
+		return v, nil
+	}
+	var v exprValue
+	switch n.op {
+	case '+':
+		v.i = l.i + r.i
+	case '-':
+		v.i = l.i - r.i
+	case '*':
+		v.i = l.i * r.i
+	case '/':
+		v.i = l.i / r.i
+	case '%':
+		v.i = l.i % r.i
+	}
+	return v, nil
+}
+
+func (n binaryNode) refs(add func(string)) {
+	n.l.refs(add)
+	n.r.refs(add)
+}
+
+func numberValue(s string) (exprValue, error) {
+	if i, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return exprValue{i: i}, nil
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return exprValue{}, fmt.Errorf("invalid number %s", s)
+	}
+	return exprValue{f: f, isFloat: true}, nil
+}
+
+// exprParser is a recursive descent parser for default expressions
+type exprParser struct {
+	s   string
+	pos int
+}
+
+// parseDefaultExpr parses the default expression s
+func parseDefaultExpr(s string) (exprNode, error) {
+	p := &exprParser{s: s}
+	n, err := p.sum()
+	if err != nil {
+		return nil, err
+	}
+	if p.skipSpaces(); p.pos < len(p.s) {
+		return nil, fmt.Errorf("unexpected %q at position %d", p.s[p.pos], p.pos)
+	}
+	return n, nil
+}
+
+func (p *exprParser) skipSpaces() {
+	for p.pos < len(p.s) && (p.s[p.pos] == ' ' || p.s[p.pos] == '\t') {
+		p.pos++
+	}
+}
+
+// peek returns the next character that is not a space, or 0 at the end of the expression
+func (p *exprParser) peek() byte {
+	p.skipSpaces()
+	if p.pos < len(p.s) {
+		return p.s[p.pos]
+	}
+	return 0
+}
+
+func (p *exprParser) sum() (exprNode, error) {
+	l, err := p.product()
+	if err != nil {
+		return nil, err
+	}
+	for c := p.peek(); c == '+' || c == '-'; c = p.peek() {
+		p.pos++
+		r, err := p.product()
+		if err != nil {
+			return nil, err
+		}
+		l = binaryNode{op: c, l: l, r: r}
+	}
+	return l, nil
+}
+
+func (p *exprParser) product() (exprNode, error) {
+	l, err := p.unary()
+	if err != nil {
+		return nil, err
+	}
+	for c := p.peek(); c == '*' || c == '/' || c == '%'; c = p.peek() {
+		p.pos++
+		r, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		l = binaryNode{op: c, l: l, r: r}
+	}
+	return l, nil
+}
+
+func (p *exprParser) unary() (exprNode, error) {
+	if p.peek() == '-' {
+		p.pos++
+		x, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return negNode{x: x}, nil
+	}
+	return p.primary()
+}
+
+func (p *exprParser) primary() (exprNode, error) {
+	c := p.peek()
+	start := p.pos
+	switch {
+	case c == 0:
+		return nil, errors.New("unexpected end of expression")
+	case c == '(':
+		p.pos++
+		n, err := p.sum()
+		if err != nil {
+			return nil, err
+		}
+		if p.peek() != ')' {
+			return nil, fmt.Errorf("missing ) for ( at position %d", start)
+		}
+		p.pos++
+		return n, nil
+	case isDigit(c) || c == '.':
+		for p.pos < len(p.s) && (isDigit(p.s[p.pos]) || p.s[p.pos] == '.') {
+			p.pos++
+		}
+		v, err := numberValue(p.s[start:p.pos])
+		if err != nil {
+			return nil, err
+		}
+		return numberNode(v), nil
+	case isLetter(c) || c == '_':
+		for p.pos < len(p.s) && (isLetter(p.s[p.pos]) || isDigit(p.s[p.pos]) || p.s[p.pos] == '_' || p.s[p.pos] == '-') {
+			p.pos++
+		}
+		// a trailing '-' is the subtraction operator
+		for p.s[p.pos-1] == '-' {
+			p.pos--
+		}
+		return paramNode(p.s[start:p.pos]), nil
+	}
+	return nil, fmt.Errorf("unexpected %q at position %d", c, p.pos)
+}
+
+func isDigit(c byte) bool  { return c >= '0' && c <= '9' }
+func isLetter(c byte) bool { return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') }
+
+// exprRefs returns the sorted names of the parameters n refers to
+func exprRefs(n exprNode) []string {
+	seen := make(map[string]bool)
+	var names []string
+	n.refs(func(name string) {
+		if !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	})
+	sort.Strings(names)
+	return names
+}
+
+// defaultExprOrder returns the parameters with a default expression in an order in which each comes after
+// the parameters its expression refers to. deps maps each such parameter to the parameters it refers to. If
+// the expressions refer to each other in a cycle, the cycle is returned instead.
+func defaultExprOrder(deps map[string][]string) (order []string, cycle []string) {
+	const (
+		visiting = 1
+		done     = 2
+	)
+	state := make(map[string]int)
+	var stack []string
+	var visit func(n string) bool
+	visit = func(n string) bool {
+		switch state[n] {
+		case done:
+			return true
+		case visiting:
+			i := len(stack) - 1
+			for stack[i] != n {
+				i--
+			}
+			cycle = append(append(cycle, stack[i:]...), n)
+			return false
+		}
+		state[n] = visiting
+		stack = append(stack, n)
+		for _, d := range deps[n] {
+			if _, ok := deps[d]; ok && !visit(d) {
+				return false
+			}
+		}
+		stack = stack[:len(stack)-1]
+		state[n] = done
+		order = append(order, n)
+		return true
+	}
+	names := make([]string, 0, len(deps))
+	for n := range deps {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	for _, n := range names {
+		if !visit(n) {
+			return nil, cycle
+		}
+	}
+	return order, nil
+}
+
+// cycleString formats a cycle of parameters as "a -> b -> a"
+func cycleString(cycle []string) string {
+	return strings.Join(cycle, " -> ")
+}
@@ -57,6 +57,11 @@ func integerStepValidator(fl validator.FieldLevel) (ret bool) {
 		return false
 	}
 
+	// An inverted range is reported by integerBoundsValidator, so the step is not checked against it
+	if iv.MinValue != nil && iv.MaxValue != nil && *iv.MinValue > *iv.MaxValue {
+		return true
+	}
+
 	// Step should not be zero if it is present
 	if iv.Step != nil && *iv.Step == 0 {
 		return false
@@ -85,3 +90,26 @@ func integerStepValidator(fl validator.FieldLevel) (ret bool) {
 	// If all conditions pass, return true indicating Step is valid
 	return true
 }
+
+// integerMultipleOfValidator validates the MultipleOf field in Validation
+func integerMultipleOfValidator(fl validator.FieldLevel) (ret bool) {
+	var iv Validation
+	defer func() {
+		if r := recover(); r != nil {
+			log.Error().Any("int_multiple_of_validation", iv).Msg("panic occurred during validation")
+			ret = false
+			return
+		}
+	}()
+	// Retrieve the parent Validation struct
+	iv, ok := fl.Parent().Interface().(Validation)
+	if !ok {
+		return false
+	}
+
+	// A value can only be required to be a multiple of a positive base
+	if iv.MultipleOf != nil && *iv.MultipleOf <= 0 {
+		return false
+	}
+	return true
+}
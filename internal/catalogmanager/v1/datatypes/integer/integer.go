@@ -20,9 +20,10 @@ const (
 )
 
 type Validation struct {
-	MinValue *int `json:"minValue" validate:"omitnil"`
-	MaxValue *int `json:"maxValue" validate:"omitnil,integerBoundsValidator"`
-	Step     *int `json:"step" validate:"omitnil,stepValidator"`
+	MinValue   *int `json:"minValue" validate:"omitnil"`
+	MaxValue   *int `json:"maxValue" validate:"omitnil,integerBoundsValidator"`
+	Step       *int `json:"step" validate:"omitnil,stepValidator"`
+	MultipleOf *int `json:"multipleOf" validate:"omitnil,multipleOfValidator"`
 }
 
 type Spec struct {
@@ -68,6 +69,8 @@ func (is *Spec) ValidateSpec() schemaerr.ValidationErrors {
 			ves = append(ves, schemaerr.ErrMissingRequiredAttribute(jsonFieldName))
 		case "stepValidator":
 			ves = append(ves, schemaerr.ErrInvalidStepValue(jsonFieldName))
+		case "multipleOfValidator":
+			ves = append(ves, schemaerr.ErrInvalidMultipleOfValue(jsonFieldName))
 		case "integerBoundsValidator":
 			ves = append(ves, schemaerr.ErrMaxValueLessThanMinValue(jsonFieldName))
 		default:
@@ -101,6 +104,7 @@ func LoadIntegerSpec(data []byte) (schemamanager.Parameter, apperrors.Error) {
 func init() {
 	schemavalidator.V().RegisterValidation("stepValidator", integerStepValidator)
 	schemavalidator.V().RegisterValidation("integerBoundsValidator", integerBoundsValidator)
+	schemavalidator.V().RegisterValidation("multipleOfValidator", integerMultipleOfValidator)
 
 	datatyperegistry.RegisterDataType(schemamanager.ParamDataType{
 		Type:    dataType,
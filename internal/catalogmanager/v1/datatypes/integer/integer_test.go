@@ -185,6 +185,140 @@ func TestIntegerSpec(t *testing.T) {
 			}`,
 			expected: nil,
 		},
+		{
+			name: "minValue greater than maxValue with step and default",
+			jsonInput: `{
+				"dataType": "Integer",
+				"validation": {
+					"minValue": 10,
+					"maxValue": 1,
+					"step": 1
+				},
+				"default": 5
+			}`,
+			expected: schemaerr.ValidationErrors{
+				schemaerr.ErrMaxValueLessThanMinValue("validation.maxValue"),
+			},
+		},
+		{
+			name: "negative minValue greater than maxValue",
+			jsonInput: `{
+				"dataType": "Integer",
+				"validation": {
+					"minValue": -1,
+					"maxValue": -10
+				}
+			}`,
+			expected: schemaerr.ValidationErrors{
+				schemaerr.ErrMaxValueLessThanMinValue("validation.maxValue"),
+			},
+		},
+		{
+			name: "empty range with default at the bound",
+			jsonInput: `{
+				"dataType": "Integer",
+				"validation": {
+					"minValue": 5,
+					"maxValue": 5
+				},
+				"default": 5
+			}`,
+			expected: nil,
+		},
+		{
+			name: "empty range with default above the bound",
+			jsonInput: `{
+				"dataType": "Integer",
+				"validation": {
+					"minValue": 5,
+					"maxValue": 5
+				},
+				"default": 6
+			}`,
+			expected: schemaerr.ValidationErrors{
+				{Field: "default", ErrStr: validationerrors.ErrValueAboveMax.Error()},
+			},
+		},
+		{
+			name: "empty range with default below the bound",
+			jsonInput: `{
+				"dataType": "Integer",
+				"validation": {
+					"minValue": 5,
+					"maxValue": 5
+				},
+				"default": 4
+			}`,
+			expected: schemaerr.ValidationErrors{
+				{Field: "default", ErrStr: validationerrors.ErrValueBelowMin.Error()},
+			},
+		},
+		{
+			name: "valid multipleOf",
+			jsonInput: `{
+				"dataType": "Integer",
+				"validation": {
+					"minValue": 1024,
+					"maxValue": 65536,
+					"multipleOf": 1024
+				},
+				"default": 4096
+			}`,
+			expected: nil,
+		},
+		{
+			name: "multipleOf without bounds",
+			jsonInput: `{
+				"dataType": "Integer",
+				"validation": {
+					"multipleOf": 3
+				},
+				"default": -9
+			}`,
+			expected: nil,
+		},
+		{
+			name: "default value in range but not a multiple",
+			jsonInput: `{
+				"dataType": "Integer",
+				"validation": {
+					"minValue": 1024,
+					"maxValue": 65536,
+					"multipleOf": 1024
+				},
+				"default": 2000
+			}`,
+			expected: schemaerr.ValidationErrors{
+				{Field: "default", ErrStr: validationerrors.ErrValueNotMultipleOf.Msg("value 2000 is not a multiple of 1024").Error()},
+			},
+		},
+		{
+			name: "multipleOf is zero",
+			jsonInput: `{
+				"dataType": "Integer",
+				"validation": {
+					"multipleOf": 0
+				}
+			}`,
+			expected: schemaerr.ValidationErrors{
+				schemaerr.ErrInvalidMultipleOfValue("validation.multipleOf"),
+			},
+		},
+		{
+			name: "multipleOf is negative",
+			jsonInput: `{
+				"dataType": "Integer",
+				"validation": {
+					"minValue": 1,
+					"maxValue": 10,
+					"multipleOf": -2
+				},
+				"default": 4
+			}`,
+			expected: schemaerr.ValidationErrors{
+				schemaerr.ErrInvalidMultipleOfValue("validation.multipleOf"),
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -1,6 +1,8 @@
 package integer
 
 import (
+	"strconv"
+
 	"github.com/mugiliam/common/apperrors"
 	v1errors "github.com/mugiliam/hatchcatalogsrv/internal/catalogmanager/v1/errors"
 	"github.com/mugiliam/hatchcatalogsrv/internal/catalogmanager/validationerrors"
@@ -33,5 +35,9 @@ func (is *Spec) ValidateValue(v types.NullableAny) apperrors.Error {
 			return validationerrors.ErrValueNotInStep
 		}
 	}
+
+	if iv.MultipleOf != nil && *iv.MultipleOf > 0 && val%*iv.MultipleOf != 0 {
+		return validationerrors.ErrValueNotMultipleOf.Msg("value " + strconv.Itoa(val) + " is not a multiple of " + strconv.Itoa(*iv.MultipleOf))
+	}
 	return nil
 }
@@ -18,7 +18,10 @@ type ParameterSchema struct {
 }
 
 type ParameterSpec struct {
-	DataType   string            `json:"dataType" validate:"required"`
+	// Extends is the name of a parameter schema whose spec this one inherits. The fields of this spec override
+	// those of the base, and the keys of the validation override the keys of the validation of the base.
+	Extends    string            `json:"extends,omitempty" validate:"omitempty,schemaRefValidator"` // [namespace/]name
+	DataType   string            `json:"dataType" validate:"required_without=Extends"`
 	Validation json.RawMessage   `json:"validation"`
 	Default    types.NullableAny `json:"default"`
 }
@@ -41,9 +44,9 @@ func (ps *ParameterSchema) Validate() schemaerr.ValidationErrors {
 		jsonFieldName := schemavalidator.GetJSONFieldPath(value, typeOfCS, e.StructField())
 
 		switch e.Tag() {
-		case "required":
+		case "required", "required_without":
 			ves = append(ves, schemaerr.ErrMissingRequiredAttribute(jsonFieldName))
-		case "nameFormatValidator":
+		case "nameFormatValidator", "schemaRefValidator":
 			val, _ := e.Value().(string)
 			ves = append(ves, schemaerr.ErrInvalidNameFormat(jsonFieldName, val))
 		case "resourcePathValidator":
@@ -41,36 +41,112 @@ func NewV1ParameterSchemaManager(ctx context.Context, version string, rsrcJson [
 		}
 	}
 
-	// load the parameter spec
+	pm := &V1ParameterSchemaManager{
+		version:         version,
+		parameterSchema: *ps,
+	}
+	// the data type of a schema that extends another may come from the base, so its parameter is loaded
+	// once the base has been merged in
+	if ps.Spec.DataType == "" && ps.Spec.Extends != "" {
+		return pm, nil
+	}
+	if err := pm.loadParameter(o.Validate); err != nil {
+		return nil, err
+	}
+	return pm, nil
+}
+
+// loadParameter loads the parameter of the data type of the spec
+func (pm *V1ParameterSchemaManager) loadParameter(validate bool) apperrors.Error {
 	loader := datatyperegistry.GetLoader(schemamanager.ParamDataType{
-		Type:    ps.Spec.DataType,
-		Version: version,
+		Type:    pm.parameterSchema.Spec.DataType,
+		Version: pm.version,
 	})
 
 	if loader == nil {
-		return nil, validationerrors.ErrSchemaValidation.Msg(schemaerr.ErrUnsupportedDataType("spec.dataType", ps.Spec.DataType).Error())
+		return validationerrors.ErrSchemaValidation.Msg(schemaerr.ErrUnsupportedDataType("spec.dataType", pm.parameterSchema.Spec.DataType).Error())
 	}
 
-	js, err := json.Marshal(ps.Spec)
+	js, err := json.Marshal(pm.parameterSchema.Spec)
 	if err != nil {
-		return nil, validationerrors.ErrSchemaValidation.Msg("failed to read parameter spec")
+		return validationerrors.ErrSchemaValidation.Msg("failed to read parameter spec")
 	}
 	parameter, apperr := loader(js)
 	if apperr != nil {
-		return nil, apperr
+		return apperr
 	}
-	if o.Validate {
+	if validate {
 		ves := parameter.ValidateSpec()
 		if ves != nil {
-			return nil, validationerrors.ErrSchemaValidation.Msg(ves.Error())
+			return validationerrors.ErrSchemaValidation.Msg(ves.Error())
 		}
 	}
+	pm.parameter = parameter
+	return nil
+}
 
-	return &V1ParameterSchemaManager{
-		version:         version,
-		parameterSchema: *ps,
-		parameter:       parameter,
-	}, nil
+// Extends returns the name of the parameter schema that this one extends, or "" if it extends none
+func (pm *V1ParameterSchemaManager) Extends() string {
+	return pm.parameterSchema.Spec.Extends
+}
+
+// ExtendSpec merges the spec of base, the parameter schema that this one extends, into this spec. The fields of
+// this spec take precedence, and its validation is merged key by key over the validation of base. A data type
+// given by this spec must be that of base. The merged spec is validated.
+func (pm *V1ParameterSchemaManager) ExtendSpec(base schemamanager.ParameterSchemaManager) apperrors.Error {
+	var baseSpec ParameterSpec
+	if err := json.Unmarshal(base.StorageRepresentation().Schema, &baseSpec); err != nil {
+		return validationerrors.ErrSchemaValidation.Msg("failed to read spec of base parameter schema")
+	}
+	spec := pm.parameterSchema.Spec
+	if spec.DataType != "" && spec.DataType != baseSpec.DataType {
+		return validationerrors.ErrSchemaValidation.Msg(schemaerr.ErrExtendedDataTypeMismatch("spec.dataType", baseSpec.DataType).Error())
+	}
+	spec.DataType = baseSpec.DataType
+	validation, err := mergeValidation(baseSpec.Validation, spec.Validation)
+	if err != nil {
+		return validationerrors.ErrSchemaValidation.Msg(schemaerr.ErrInvalidFieldSchema("spec.validation").Error())
+	}
+	spec.Validation = validation
+	if spec.Default.IsNil() {
+		spec.Default = baseSpec.Default
+	}
+
+	extended := *pm
+	extended.parameterSchema.Spec = spec
+	if err := extended.loadParameter(true); err != nil {
+		return err
+	}
+	*pm = extended
+	return nil
+}
+
+// mergeValidation returns the keys of base with those of override on top of them
+func mergeValidation(base, override json.RawMessage) (json.RawMessage, error) {
+	if isNullJSON(override) {
+		return base, nil
+	}
+	if isNullJSON(base) {
+		return override, nil
+	}
+	var merged, over map[string]json.RawMessage
+	if err := json.Unmarshal(base, &merged); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(override, &over); err != nil {
+		return nil, err
+	}
+	if merged == nil {
+		merged = make(map[string]json.RawMessage, len(over))
+	}
+	for k, v := range over {
+		merged[k] = v
+	}
+	return json.Marshal(merged)
+}
+
+func isNullJSON(j json.RawMessage) bool {
+	return len(j) == 0 || string(j) == "null"
 }
 
 func (pm *V1ParameterSchemaManager) DataType() schemamanager.ParamDataType {
@@ -81,10 +157,16 @@ func (pm *V1ParameterSchemaManager) DataType() schemamanager.ParamDataType {
 }
 
 func (pm *V1ParameterSchemaManager) Default() interface{} {
+	if pm.parameter == nil {
+		return nil
+	}
 	return pm.parameter.DefaultValue()
 }
 
 func (pm *V1ParameterSchemaManager) ValidateValue(value types.NullableAny) apperrors.Error {
+	if pm.parameter == nil {
+		return validationerrors.ErrSchemaValidation.Msg("parameter schema " + pm.parameterSchema.Spec.Extends + " extended by the schema has not been resolved")
+	}
 	return pm.parameter.ValidateValue(value)
 }
 
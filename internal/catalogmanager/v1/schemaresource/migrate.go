@@ -0,0 +1,33 @@
+package schemaresource
+
+import (
+	"github.com/mugiliam/hatchcatalogsrv/internal/catalogmanager/schemamanager"
+	"github.com/mugiliam/hatchcatalogsrv/pkg/api/schemastore"
+)
+
+// A migration upgrades the stored representation of a v1 schema written by an earlier release. Migrations
+// run in order on every load, before the schema is validated, so that objects stored before a rule was
+// introduced still load. A migration must leave an object that is already current unchanged.
+type migration func(s *schemastore.SchemaStorageRepresentation, m *schemamanager.SchemaMetadata)
+
+var migrations = []migration{
+	migrateDescription,
+}
+
+// MigrateObject upgrades s, and the metadata m it is loaded with, to the current form. Storage is not
+// rewritten; the canonical form of the migrated object is the StorageRepresentation of the schema manager
+// loaded from it.
+func MigrateObject(s *schemastore.SchemaStorageRepresentation, m *schemamanager.SchemaMetadata) {
+	for _, migrate := range migrations {
+		migrate(s, m)
+	}
+}
+
+// migrateDescription moves the description of objects saved before descriptions were kept in the directory
+// to the metadata
+func migrateDescription(s *schemastore.SchemaStorageRepresentation, m *schemamanager.SchemaMetadata) {
+	if m.Description == "" {
+		m.Description = s.Description
+	}
+	s.Description = ""
+}
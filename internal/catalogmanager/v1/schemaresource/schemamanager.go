@@ -3,11 +3,13 @@ package schemaresource
 import (
 	"context"
 	"encoding/json"
+	"maps"
 
 	log "github.com/rs/zerolog/log"
 
 	"github.com/mugiliam/common/apperrors"
 	"github.com/mugiliam/hatchcatalogsrv/internal/catalogmanager/schemamanager"
+	"github.com/mugiliam/hatchcatalogsrv/internal/catalogmanager/schemamanager/versionregistry"
 	"github.com/mugiliam/hatchcatalogsrv/internal/catalogmanager/v1/collection"
 	"github.com/mugiliam/hatchcatalogsrv/internal/catalogmanager/v1/errors"
 	"github.com/mugiliam/hatchcatalogsrv/internal/catalogmanager/v1/parameter"
@@ -24,6 +26,27 @@ type V1SchemaManager struct {
 
 var _ schemamanager.SchemaManager = &V1SchemaManager{} // Ensure V1SchemaManager implements schemamanager.SchemaManager
 
+func init() {
+	versionregistry.RegisterSchemaVersion(types.VersionV1, versionregistry.SchemaVersion{
+		New: func(ctx context.Context, rsrcJson []byte, options ...schemamanager.Options) (schemamanager.SchemaManager, apperrors.Error) {
+			sm, err := NewV1SchemaManager(ctx, rsrcJson, options...)
+			if err != nil {
+				return nil, err
+			}
+			return sm, nil
+		},
+		Load: func(ctx context.Context, s *schemastore.SchemaStorageRepresentation, m *schemamanager.SchemaMetadata) (schemamanager.SchemaManager, apperrors.Error) {
+			sm, err := LoadV1SchemaManager(ctx, s, m)
+			if err != nil {
+				return nil, err
+			}
+			return sm, nil
+		},
+	})
+	// v1beta1 is used to tag experimental manifests that are otherwise v1
+	versionregistry.RegisterAlias(types.VersionV1Beta1, types.VersionV1)
+}
+
 func NewV1SchemaManager(ctx context.Context, rsrcJson []byte, options ...schemamanager.Options) (*V1SchemaManager, apperrors.Error) {
 	o := schemamanager.OptionsConfig{}
 	for _, option := range options {
@@ -35,7 +58,7 @@ func NewV1SchemaManager(ctx context.Context, rsrcJson []byte, options ...schemam
 		return nil, err
 	}
 
-	if rs.Version != "v1" {
+	if rs.Version != types.VersionV1 {
 		return nil, validationerrors.ErrInvalidVersion
 	}
 	if o.Validate {
@@ -47,8 +70,13 @@ func NewV1SchemaManager(ctx context.Context, rsrcJson []byte, options ...schemam
 	return buildSchemaManager(ctx, rs, rsrcJson, options...)
 }
 
+// LoadV1SchemaManager loads a stored schema. The stored representation is migrated to the current form first,
+// so s may be modified.
 func LoadV1SchemaManager(ctx context.Context, s *schemastore.SchemaStorageRepresentation, m *schemamanager.SchemaMetadata) (*V1SchemaManager, apperrors.Error) {
 	rs := &SchemaResource{}
+	rs.Metadata = *m
+	MigrateObject(s, &rs.Metadata)
+
 	rs.Version = s.Version
 	switch s.Type {
 	case types.CatalogObjectTypeParameterSchema:
@@ -56,7 +84,6 @@ func LoadV1SchemaManager(ctx context.Context, s *schemastore.SchemaStorageRepres
 	case types.CatalogObjectTypeCollectionSchema:
 		rs.Kind = "CollectionSchema"
 	}
-	rs.Metadata = *m
 	rs.Spec = s.Schema
 
 	ves := rs.Validate()
@@ -67,8 +94,6 @@ func LoadV1SchemaManager(ctx context.Context, s *schemastore.SchemaStorageRepres
 	if s.Values != nil && len(s.Values) > 0 && json.Valid(s.Values) {
 		opts = append(opts, schemamanager.WithParamValues(s.Values))
 	}
-
-	rs.Metadata.Description = s.Description
 	return buildSchemaManager(ctx, rs, nil, opts...)
 }
 
@@ -184,14 +209,27 @@ func (rm *V1SchemaManager) StorageRepresentation() *schemastore.SchemaStorageRep
 			s = rm.collectionSchemaManager.StorageRepresentation()
 		}
 	}
-	s.Description = rm.resourceSchema.Metadata.Description
+	// The description is metadata and is kept with the directory entry of the schema, so editing it does not
+	// change the stored object.
 	// We add entropy here because two schemas that have the same storage representation can be referred at multiple places
 	s.Entropy = rm.resourceSchema.Metadata.GetEntropyBytes(rm.Type())
 	return s
 }
 
 func (rm *V1SchemaManager) ToJson(ctx context.Context) ([]byte, apperrors.Error) {
-	j, err := json.Marshal(rm.resourceSchema)
+	// the units are kept in the metadata and are written back to the spec they were given in
+	rs := *rm.resourceSchema
+	if rm.parameterSchemaManager != nil && rm.parameterSchemaManager.Extends() != "" {
+		// the spec of the extended schema has been merged into the parameter schema
+		rs.Spec = rm.parameterSchemaManager.StorageRepresentation().Schema
+	}
+	spec, err := rs.Metadata.SpecWithUnits(rs.Kind, rs.Spec)
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Msg("failed to add units to object schema")
+		return nil, errors.ErrUnableToLoadObject
+	}
+	rs.Spec = spec
+	j, err := json.Marshal(rs)
 	if err != nil {
 		log.Ctx(ctx).Error().Err(err).Msg("failed to marshal object schema")
 		return j, errors.ErrUnableToLoadObject
@@ -202,10 +240,11 @@ func (rm *V1SchemaManager) ToJson(ctx context.Context) ([]byte, apperrors.Error)
 func (rm *V1SchemaManager) Compare(other schemamanager.SchemaManager, excludeMetadata bool) bool {
 	thisObj := rm.StorageRepresentation()
 	otherObj := other.StorageRepresentation()
-	// to exclude metadata, just exclude description. If there are other values in future, we need to do more here.
-	if excludeMetadata {
-		thisObj.Description = ""
-		otherObj.Description = ""
+	// the description and units are the only metadata compared. They are not part of the storage
+	// representation.
+	if !excludeMetadata && (rm.resourceSchema.Metadata.Description != other.Metadata().Description ||
+		!maps.Equal(rm.resourceSchema.Metadata.Units, other.Metadata().Units)) {
+		return false
 	}
 	return thisObj.GetHash() == otherObj.GetHash()
 }
@@ -1,12 +1,15 @@
 package schemaresource
 
 import (
+	"context"
 	"encoding/json"
 	"testing"
 
 	schemaerr "github.com/mugiliam/hatchcatalogsrv/internal/catalogmanager/schema/errors"
 	"github.com/mugiliam/hatchcatalogsrv/internal/catalogmanager/schema/schemavalidator"
+	"github.com/mugiliam/hatchcatalogsrv/internal/catalogmanager/schemamanager"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"sigs.k8s.io/yaml"
 )
 
@@ -243,3 +246,62 @@ func TestValidateJsonSchema(t *testing.T) {
 		})
 	}
 }
+
+func TestStorageRepresentationHashIsOrderIndependent(t *testing.T) {
+	yaml1 := `
+version: v1
+kind: CollectionSchema
+metadata:
+  name: valid
+  catalog: valid-catalog
+spec:
+  parameters:
+    maxRetries:
+      dataType: Integer
+      default: 5
+    maxDelay:
+      default: 1000
+      dataType: Integer
+    name:
+      dataType: Integer
+`
+	yaml2 := `
+kind: CollectionSchema
+version: v1
+spec:
+  parameters:
+    name:
+      dataType: Integer
+    maxDelay:
+      dataType: Integer
+      default: 1000
+    maxRetries:
+      default: 5
+      dataType: Integer
+metadata:
+  catalog: valid-catalog
+  name: valid
+`
+	ctx := context.Background()
+	hashAndData := func(y string) (string, []byte) {
+		j, err := yaml.YAMLToJSON([]byte(y))
+		require.NoError(t, err)
+		sm, apperr := NewV1SchemaManager(ctx, j, schemamanager.WithValidation(), schemamanager.WithDefaultValues())
+		require.NoError(t, apperr)
+		s := sm.StorageRepresentation()
+		data, apperr := s.Serialize()
+		require.NoError(t, apperr)
+		return s.GetHash(), data
+	}
+	h1, d1 := hashAndData(yaml1)
+	h2, d2 := hashAndData(yaml2)
+	assert.Equal(t, h1, h2)
+	assert.Equal(t, string(d1), string(d2))
+
+	// serialization is stable across repeated calls
+	for i := 0; i < 10; i++ {
+		h, d := hashAndData(yaml1)
+		assert.Equal(t, h1, h)
+		assert.Equal(t, string(d1), string(d))
+	}
+}
@@ -0,0 +1,141 @@
+package catalogmanager
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+	"sync"
+
+	"github.com/mugiliam/common/apperrors"
+	"github.com/mugiliam/hatchcatalogsrv/internal/catalogmanager/schemamanager"
+	"github.com/mugiliam/hatchcatalogsrv/internal/config"
+	"github.com/mugiliam/hatchcatalogsrv/pkg/types"
+	"github.com/rs/zerolog/log"
+)
+
+// validationCacheKey identifies values validated against a collection schema. schema is the hash of the
+// collection schema and of the parameter schemas it refers to, and values is the hash of the values.
+type validationCacheKey struct {
+	schema string
+	values string
+}
+
+// validationCache is a bounded LRU set of values that passed validation. Objects are identified by their
+// hashes, so an entry stays correct for as long as it is kept, and only successful validations are added.
+type validationCache struct {
+	mu      sync.Mutex
+	size    int
+	order   *list.List // most recently used first
+	entries map[validationCacheKey]*list.Element
+}
+
+func newValidationCache() *validationCache {
+	return &validationCache{
+		order:   list.New(),
+		entries: make(map[validationCacheKey]*list.Element),
+	}
+}
+
+// valueValidations holds the validations of values of all tenants. The key is derived from the content of
+// the schemas and values, so entries can be shared.
+var valueValidations = newValidationCache()
+
+// resize sets the number of entries kept to size, dropping the least recently used entries if there are
+// more. A size of 0 turns the cache off.
+func (c *validationCache) resize(size int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.size = size
+	for c.order.Len() > c.size {
+		delete(c.entries, c.order.Remove(c.order.Back()).(validationCacheKey))
+	}
+}
+
+func (c *validationCache) contains(k validationCacheKey) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.entries[k]
+	if ok {
+		c.order.MoveToFront(e)
+	}
+	return ok
+}
+
+func (c *validationCache) add(k validationCacheKey) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.size <= 0 {
+		return
+	}
+	if e, ok := c.entries[k]; ok {
+		c.order.MoveToFront(e)
+		return
+	}
+	c.entries[k] = c.order.PushFront(k)
+	if c.order.Len() > c.size {
+		delete(c.entries, c.order.Remove(c.order.Back()).(validationCacheKey))
+	}
+}
+
+// cachedValidations skips the validation of values that were validated successfully against the same
+// schema before. schemaKey identifies the schema, see validationSchemaKey; values are not cached if it is empty.
+type cachedValidations struct {
+	schemamanager.CollectionSchemaManager
+	schemaKey string
+}
+
+// withValidationCache returns csm with its ValidateValues consulting the cache of successful validations
+func withValidationCache(csm schemamanager.CollectionSchemaManager, schemaKey string) schemamanager.CollectionSchemaManager {
+	if csm == nil {
+		return nil
+	}
+	if c, ok := csm.(*cachedValidations); ok {
+		csm = c.CollectionSchemaManager
+	}
+	return &cachedValidations{CollectionSchemaManager: csm, schemaKey: schemaKey}
+}
+
+func (c *cachedValidations) ValidateValues(ctx context.Context, loaders schemamanager.SchemaLoaders, values map[string]types.NullableAny) apperrors.Error {
+	valueValidations.resize(config.Config().ValidationCacheEntries())
+	if c.schemaKey == "" || len(values) == 0 {
+		return c.CollectionSchemaManager.ValidateValues(ctx, loaders, values)
+	}
+	// maps are marshaled with sorted keys, so equal values have the same hash
+	j, err := json.Marshal(values)
+	if err != nil {
+		return c.CollectionSchemaManager.ValidateValues(ctx, loaders, values)
+	}
+	h := sha256.Sum256(j)
+	k := validationCacheKey{schema: c.schemaKey, values: hex.EncodeToString(h[:])}
+	if valueValidations.contains(k) {
+		return nil
+	}
+	if err := c.CollectionSchemaManager.ValidateValues(ctx, loaders, values); err != nil {
+		return err
+	}
+	valueValidations.add(k)
+	return nil
+}
+
+// validationSchemaKey returns the key that identifies what values are validated against: the collection
+// schema with the hash schemaHash and the parameter schemas at the paths params that it refers to. It
+// returns "" if the parameter schemas cannot be looked up, and values are then validated without the cache.
+func validationSchemaKey(ctx context.Context, dir Directories, schemaHash string, params []string) string {
+	if config.Config().ValidationCacheEntries() == 0 {
+		return ""
+	}
+	hashes, err := parameterSchemaHashes(ctx, dir, params)
+	if err != nil {
+		log.Ctx(ctx).Debug().Err(err).Msg("validating values without the cache")
+		return ""
+	}
+	parts := []string{schemaHash}
+	for i, p := range params {
+		parts = append(parts, p, hashes[i])
+	}
+	h := sha256.Sum256([]byte(strings.Join(parts, "\x00")))
+	return hex.EncodeToString(h[:])
+}
@@ -0,0 +1,138 @@
+package catalogmanager
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mugiliam/common/apperrors"
+	"github.com/mugiliam/hatchcatalogsrv/internal/catalogmanager/schemamanager"
+	"github.com/mugiliam/hatchcatalogsrv/internal/config"
+	"github.com/mugiliam/hatchcatalogsrv/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"sigs.k8s.io/yaml"
+)
+
+// countingValidator counts the validations that reach the collection schema manager
+type countingValidator struct {
+	schemamanager.CollectionSchemaManager
+	calls int
+	err   apperrors.Error
+}
+
+func (v *countingValidator) ValidateValues(ctx context.Context, loaders schemamanager.SchemaLoaders, values map[string]types.NullableAny) apperrors.Error {
+	v.calls++
+	return v.err
+}
+
+func setValidationCacheSize(t *testing.T, size string) {
+	file := filepath.Join(t.TempDir(), "config.toml")
+	require.NoError(t, os.WriteFile(file, []byte("validation_cache_size = "+size+"\n"), 0o600))
+	require.NoError(t, config.LoadConfig(file))
+	t.Cleanup(func() {
+		_ = config.LoadConfig("")
+		valueValidations.resize(0)
+	})
+}
+
+func TestValidationCache(t *testing.T) {
+	setValidationCacheSize(t, "2")
+	ctx := context.Background()
+	values := func(n int) map[string]types.NullableAny {
+		v, err := types.NullableAnyFrom(n)
+		require.NoError(t, err)
+		return map[string]types.NullableAny{"maxRetries": v}
+	}
+
+	// a successful validation is cached
+	v := &countingValidator{}
+	csm := withValidationCache(v, "schema-1")
+	require.Nil(t, csm.ValidateValues(ctx, schemamanager.SchemaLoaders{}, values(1)))
+	require.Nil(t, csm.ValidateValues(ctx, schemamanager.SchemaLoaders{}, values(1)))
+	assert.Equal(t, 1, v.calls)
+
+	// different values or a different schema are validated
+	require.Nil(t, csm.ValidateValues(ctx, schemamanager.SchemaLoaders{}, values(2)))
+	require.Nil(t, withValidationCache(v, "schema-2").ValidateValues(ctx, schemamanager.SchemaLoaders{}, values(1)))
+	assert.Equal(t, 3, v.calls)
+
+	// the least recently used entry was evicted
+	require.Nil(t, csm.ValidateValues(ctx, schemamanager.SchemaLoaders{}, values(1)))
+	assert.Equal(t, 4, v.calls)
+
+	// failures are not cached
+	failing := &countingValidator{err: ErrInvalidRequest}
+	csm = withValidationCache(failing, "schema-1")
+	require.NotNil(t, csm.ValidateValues(ctx, schemamanager.SchemaLoaders{}, values(3)))
+	require.NotNil(t, csm.ValidateValues(ctx, schemamanager.SchemaLoaders{}, values(3)))
+	assert.Equal(t, 2, failing.calls)
+
+	// a size of 0 turns the cache off
+	setValidationCacheSize(t, "0")
+	v = &countingValidator{}
+	csm = withValidationCache(v, "schema-1")
+	require.Nil(t, csm.ValidateValues(ctx, schemamanager.SchemaLoaders{}, values(1)))
+	require.Nil(t, csm.ValidateValues(ctx, schemamanager.SchemaLoaders{}, values(1)))
+	assert.Equal(t, 2, v.calls)
+}
+
+func BenchmarkValidateValues(b *testing.B) {
+	collectionYaml := `
+		version: v1
+		kind: CollectionSchema
+		metadata:
+			name: benchmark-collection-schema
+			catalog: example-catalog
+		spec:
+			parameters:
+				maxDelay:
+					dataType: Integer
+					validation:
+						minValue: 1
+						maxValue: 100000
+				maxRetries:
+					dataType: Integer
+					validation:
+						minValue: 0
+						maxValue: 10
+	`
+	replaceTabsWithSpaces(&collectionYaml)
+	j, err := yaml.YAMLToJSON([]byte(collectionYaml))
+	require.NoError(b, err)
+	ctx := context.Background()
+	sm, apperr := NewSchema(ctx, j, nil)
+	require.Nil(b, apperr)
+	csm := sm.CollectionSchemaManager()
+	require.NotNil(b, csm)
+
+	loaders := schemamanager.SchemaLoaders{
+		ByPath: func(context.Context, types.CatalogObjectType, *schemamanager.SchemaMetadata) (schemamanager.SchemaManager, apperrors.Error) {
+			return nil, ErrObjectNotFound
+		},
+		ClosestParent: func(context.Context, types.CatalogObjectType, string) (string, string, apperrors.Error) {
+			return "", "", ErrObjectNotFound
+		},
+		ParameterRef: func(string) string { return "" },
+	}
+	maxDelay, _ := types.NullableAnyFrom(5000)
+	maxRetries, _ := types.NullableAnyFrom(3)
+	values := map[string]types.NullableAny{"maxDelay": maxDelay, "maxRetries": maxRetries}
+
+	b.Run("uncached", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if err := csm.ValidateValues(ctx, loaders, values); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("cached", func(b *testing.B) {
+		cached := withValidationCache(csm, "benchmark-schema")
+		for i := 0; i < b.N; i++ {
+			if err := cached.ValidateValues(ctx, loaders, values); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
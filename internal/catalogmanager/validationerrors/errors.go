@@ -13,13 +13,15 @@ var (
 	ErrSchemaSerialization apperrors.Error = ErrSchemaValidation.New("error serializing schema")
 	ErrInvalidSchema       apperrors.Error = ErrSchemaValidation.New("invalid schema")
 	ErrInvalidNameFormat   apperrors.Error = ErrSchemaValidation.New("invalid name format")
+	ErrNamespaceNotFound   apperrors.Error = ErrSchemaValidation.New("namespace does not exist")
 
-	ErrValueValidation apperrors.Error = apperrors.New("error validating value").SetStatusCode(http.StatusBadRequest)
-	ErrInvalidType     apperrors.Error = ErrValueValidation.New("invalid type")
-	ErrInvalidKind     apperrors.Error = ErrValueValidation.New("unsupported kind")
-	ErrInvalidDataType apperrors.Error = ErrValueValidation.New("unsupported data type")
-	ErrValueBelowMin   apperrors.Error = ErrValueValidation.New("value is below minimum")
-	ErrValueAboveMax   apperrors.Error = ErrValueValidation.New("value is above maximum")
-	ErrValueInvalid    apperrors.Error = ErrValueValidation.New("value failed validation")
-	ErrValueNotInStep  apperrors.Error = ErrValueValidation.New("value not in step with min and max values")
+	ErrValueValidation    apperrors.Error = apperrors.New("error validating value").SetStatusCode(http.StatusBadRequest)
+	ErrInvalidType        apperrors.Error = ErrValueValidation.New("invalid type")
+	ErrInvalidKind        apperrors.Error = ErrValueValidation.New("unsupported kind")
+	ErrInvalidDataType    apperrors.Error = ErrValueValidation.New("unsupported data type")
+	ErrValueBelowMin      apperrors.Error = ErrValueValidation.New("value is below minimum")
+	ErrValueAboveMax      apperrors.Error = ErrValueValidation.New("value is above maximum")
+	ErrValueInvalid       apperrors.Error = ErrValueValidation.New("value failed validation")
+	ErrValueNotInStep     apperrors.Error = ErrValueValidation.New("value not in step with min and max values")
+	ErrValueNotMultipleOf apperrors.Error = ErrValueValidation.New("value is not a multiple of multipleOf")
 )
@@ -6,6 +6,10 @@ import (
 	"errors"
 	"path"
 	"reflect"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
 
 	"github.com/go-playground/validator/v10"
 	"github.com/google/uuid"
@@ -14,11 +18,14 @@ import (
 	"github.com/mugiliam/hatchcatalogsrv/internal/catalogmanager/schema/schemavalidator"
 	"github.com/mugiliam/hatchcatalogsrv/internal/catalogmanager/schemamanager"
 	"github.com/mugiliam/hatchcatalogsrv/internal/catalogmanager/validationerrors"
+	"github.com/mugiliam/hatchcatalogsrv/internal/config"
 	"github.com/mugiliam/hatchcatalogsrv/internal/db"
 	"github.com/mugiliam/hatchcatalogsrv/internal/db/dberror"
 	"github.com/mugiliam/hatchcatalogsrv/internal/db/models"
+	"github.com/mugiliam/hatchcatalogsrv/internal/tracing"
 	"github.com/mugiliam/hatchcatalogsrv/pkg/types"
 	"github.com/rs/zerolog/log"
+	"github.com/tidwall/gjson"
 )
 
 type valueSchema struct {
@@ -26,16 +33,34 @@ type valueSchema struct {
 	Kind     string        `json:"kind" validate:"required,kindValidator"`
 	Metadata ValueMetadata `json:"metadata" validate:"required"`
 	Spec     valueSpec     `json:"spec" validate:"required"`
+	Sources  valueSources  `json:"sources,omitempty"`
 }
 
 type ValueMetadata struct {
 	Catalog    string               `json:"catalog" validate:"required,resourceNameValidator"`
 	Variant    types.NullableString `json:"variant" validate:"required,resourceNameValidator"`
 	Collection string               `json:"collection" validate:"required,resourcePathValidator"`
+	// Extends is the path of the collection schema whose values are the base of this value. The value only
+	// stores what it sets, and the parameters it does not set take the values set in the base when it is read.
+	Extends string `json:"extends,omitempty" validate:"omitempty,resourcePathValidator"`
 }
 
 type valueSpec map[string]types.NullableAny
 
+// ValueSource identifies the layer a value was resolved from
+type ValueSource string
+
+const (
+	ValueSourceExplicit          ValueSource = "explicit"
+	ValueSourceCollectionDefault ValueSource = "collectionDefault"
+	ValueSourceParameterDefault  ValueSource = "parameterDefault"
+	ValueSourceBase              ValueSource = "base" // set in the value that the value extends
+)
+
+// valueSources maps each parameter with a resolved value to the source of the value. It is only set on values
+// returned by GetValue and is ignored on save.
+type valueSources map[string]ValueSource
+
 func (vs *valueSchema) Validate() schemaerr.ValidationErrors {
 	var ves schemaerr.ValidationErrors
 	err := schemavalidator.V().Struct(vs)
@@ -67,7 +92,15 @@ func (vs *valueSchema) Validate() schemaerr.ValidationErrors {
 	return ves
 }
 
+// GetValue returns the values of the collection schema in m, merged over the values of its base if it extends
+// one
 func GetValue(ctx context.Context, m *ValueMetadata, dir Directories) (*valueSchema, apperrors.Error) {
+	return getValue(ctx, m, dir, nil)
+}
+
+// getValue returns the values of the collection schema in m. chain are the collection schemas whose values
+// extend them, from the first.
+func getValue(ctx context.Context, m *ValueMetadata, dir Directories, chain []string) (*valueSchema, apperrors.Error) {
 	// load the object manager
 	om, err := LoadSchemaByPath(ctx,
 		types.CatalogObjectTypeCollectionSchema,
@@ -80,18 +113,21 @@ func GetValue(ctx context.Context, m *ValueMetadata, dir Directories) (*valueSch
 		WithDirectories(dir))
 
 	if err != nil {
-		log.Ctx(ctx).Error().Err(err).Msg("failed to load object manager")
 		if errors.Is(err, ErrObjectNotFound) {
-			return nil, ErrInvalidCollection.Msg("invalid collection " + m.Collection)
+			return nil, collectionSchemaNotFound(ctx, dir.CollectionsDir, m.Collection)
 		}
+		log.Ctx(ctx).Error().Err(err).Msg("failed to load object manager")
 		return nil, ErrCatalogError.Err(err)
 	}
 
-	// get the values
-	valuesParam := om.CollectionSchemaManager().GetDefaultValues()
-	values := make(valueSpec)
-	for param, value := range valuesParam {
-		values[param] = value.Value
+	// resolve the values
+	values, sources, err := resolveValues(ctx, om, om.CollectionSchemaManager().GetDefaultValues(), dir)
+	if err != nil {
+		return nil, err
+	}
+	values, sources, err = mergeBaseValues(ctx, om, om.Metadata().ValuesExtends, values, sources, dir, chain)
+	if err != nil {
+		return nil, err
 	}
 
 	vs := &valueSchema{
@@ -101,17 +137,173 @@ func GetValue(ctx context.Context, m *ValueMetadata, dir Directories) (*valueSch
 			Catalog:    om.Metadata().Catalog,
 			Variant:    om.Metadata().Variant,
 			Collection: om.FullyQualifiedName(),
+			Extends:    om.Metadata().ValuesExtends,
 		},
-		Spec: values,
+		Spec:    values,
+		Sources: sources,
 	}
 
 	return vs, nil
 }
 
+// resolveValues resolves the value of each parameter of the collection schema by falling back from the value
+// in current to the default in the collection schema and then to the default of the parameter
+// schema. A nullable parameter that was set to null stays null. Defaults of parameter schemas are copied into the collection schema when it is saved, so a collection
+// default that matches the parameter schema default is attributed to the parameter schema. Likewise, a value
+// that matches the default it would otherwise resolve to is attributed to the default.
+func resolveValues(ctx context.Context, om schemamanager.SchemaManager, current schemamanager.ParamValues, dir Directories) (valueSpec, valueSources, apperrors.Error) {
+	csJson, err := om.ToJson(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	m := om.Metadata()
+	loaders := getSchemaLoaders(ctx, m, WithDirectories(dir))
+	namespaces := make(map[string]struct{})
+	if !m.Namespace.IsNil() {
+		namespaces[m.Namespace.String()] = struct{}{}
+	}
+
+	values := make(valueSpec)
+	sources := make(valueSources)
+	var rErr apperrors.Error
+	gjson.GetBytes(csJson, "spec.parameters").ForEach(func(name, p gjson.Result) bool {
+		param := name.String()
+		var collectionDefault, parameterDefault types.NullableAny
+		if d := p.Get("default"); d.Exists() {
+			if err := collectionDefault.UnmarshalJSON([]byte(d.Raw)); err != nil {
+				rErr = ErrCatalogError.Msg("invalid default for parameter " + param)
+				return false
+			}
+		}
+		if schemaName := p.Get("schema").String(); schemaName != "" {
+			ps, _, err := loadParameterSchema(ctx, loaders, m, namespaces, param, schemaName)
+			if err != nil {
+				rErr = err
+				return false
+			}
+			if pm := ps.ParameterSchemaManager(); pm != nil && pm.Default() != nil {
+				parameterDefault, _ = types.NullableAnyFrom(pm.Default())
+			}
+		}
+
+		value := current[param].Value
+		switch {
+		case value.IsNil() && p.Get("nullable").Bool() && (!collectionDefault.IsNil() || !parameterDefault.IsNil()):
+			// a nullable parameter without a value despite having a default was set to null explicitly
+			values[param] = types.NilAny()
+			sources[param] = ValueSourceExplicit
+		case !value.IsNil() && !sameValue(value, collectionDefault) && !(collectionDefault.IsNil() && sameValue(value, parameterDefault)):
+			values[param] = value
+			sources[param] = ValueSourceExplicit
+		case !collectionDefault.IsNil() && !sameValue(collectionDefault, parameterDefault):
+			values[param] = collectionDefault
+			sources[param] = ValueSourceCollectionDefault
+		case !parameterDefault.IsNil():
+			values[param] = parameterDefault
+			sources[param] = ValueSourceParameterDefault
+		default:
+			values[param] = types.NilAny()
+		}
+		return true
+	})
+	if rErr != nil {
+		return nil, nil, rErr
+	}
+	return values, sources, nil
+}
+
+// mergeBaseValues merges the values set in base, the path of the collection schema that the values of the
+// collection schema om extend, into values resolved for om. A parameter of om that has no value of its own
+// takes the value set in base, or in the values base extends in turn, and the values it takes are validated
+// against om. Defaults are not taken from base, since om has its own. chain are the collection schemas whose
+// values extend those of om.
+func mergeBaseValues(ctx context.Context, om schemamanager.SchemaManager, base string, values valueSpec, sources valueSources, dir Directories, chain []string) (valueSpec, valueSources, apperrors.Error) {
+	if base == "" {
+		return values, sources, nil
+	}
+	m := om.Metadata()
+	self := path.Clean(om.FullyQualifiedName())
+	chain = append(slices.Clone(chain), self)
+	if slices.Contains(chain, base) {
+		return nil, nil, ErrCyclicValueExtends.Msg("values of " + chain[0] + " extend themselves through " + strings.Join(append(chain, base), " -> "))
+	}
+	if len(chain) > maxExtendsDepth {
+		return nil, nil, ErrInvalidValueExtends.Msg("values of " + chain[0] + " extend a chain of more than " + strconv.Itoa(maxExtendsDepth) + " values")
+	}
+	bv, err := getValue(ctx, &ValueMetadata{Catalog: m.Catalog, Variant: m.Variant, Collection: base}, dir, chain)
+	if err != nil {
+		if errors.Is(err, ErrCollectionSchemaNotFound) {
+			return nil, nil, ErrInvalidValueExtends.Msg("collection schema " + base + " extended by the values of " + self + " does not exist")
+		}
+		return nil, nil, err
+	}
+
+	inherited := make(map[string]types.NullableAny)
+	for param, source := range bv.Sources {
+		if source != ValueSourceExplicit && source != ValueSourceBase {
+			continue
+		}
+		if _, ok := values[param]; !ok || sources[param] == ValueSourceExplicit {
+			continue
+		}
+		inherited[param] = bv.Spec[param]
+	}
+	if len(inherited) == 0 {
+		return values, sources, nil
+	}
+	loaders := getSchemaLoaders(ctx, m, WithDirectories(dir))
+	if err := om.CollectionSchemaManager().ValidateValues(ctx, loaders, inherited); err != nil {
+		return nil, nil, ErrInvalidValueExtends.Msg("values of " + base + " are not valid for " + self + ": " + err.Error())
+	}
+	for param, value := range inherited {
+		values[param] = value
+		sources[param] = ValueSourceBase
+	}
+	return values, sources, nil
+}
+
+// sameValue reports whether two values hold the same json value regardless of its encoding
+func sameValue(a, b types.NullableAny) bool {
+	if a.IsNil() || b.IsNil() {
+		return a.IsNil() == b.IsNil()
+	}
+	return reflect.DeepEqual(a.Get(), b.Get())
+}
+
+// collectionSchemaNotFound returns the error for a value whose collection does not resolve to a collection
+// schema. With hierarchical schemas it also names the closest parent of the collection path that exists.
+func collectionSchemaNotFound(ctx context.Context, collectionsDir uuid.UUID, collection string) apperrors.Error {
+	msg := "no collection schema at " + collection
+	if !config.HierarchicalSchemas {
+		return ErrCollectionSchemaNotFound.Msg(msg)
+	}
+	var parents []string
+	for p := path.Dir(collection); p != "/" && p != "."; p = path.Dir(p) {
+		parents = append(parents, p)
+	}
+	existing, err := db.DB(ctx).PathsExist(ctx, types.CatalogObjectTypeCollectionSchema, collectionsDir, parents)
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Str("path", collection).Msg("failed to check if parent paths exist")
+		return ErrCollectionSchemaNotFound.Msg(msg)
+	}
+	// parents are ordered from the closest
+	for _, p := range parents {
+		if existing[p] {
+			if p == path.Dir(collection) {
+				return ErrCollectionSchemaNotFound.Msg(msg + "; parent " + p + " exists but has no collection schema named " + path.Base(collection))
+			}
+			return ErrCollectionSchemaNotFound.Msg(msg + "; closest existing parent is " + p)
+		}
+	}
+	return ErrCollectionSchemaNotFound.Msg(msg + "; none of its parents exist")
+}
+
 func SaveValue(ctx context.Context, valueJson []byte, m *ValueMetadata, opts ...ObjectStoreOption) apperrors.Error {
 	if len(valueJson) == 0 {
 		return validationerrors.ErrEmptySchema
 	}
+	ctx, span := tracing.Start(ctx, "catalogmanager.SaveValue")
+	defer span.End()
 
 	// get the options
 	options := &storeOptions{}
@@ -132,6 +324,9 @@ func SaveValue(ctx context.Context, valueJson []byte, m *ValueMetadata, opts ...
 	if err := v.Validate(); err != nil {
 		return validationerrors.ErrSchemaValidation.Msg(err.Error())
 	}
+	if v.Metadata.Extends != "" {
+		v.Metadata.Extends = path.Clean(v.Metadata.Extends)
+	}
 
 	var dir Directories
 
@@ -157,17 +352,25 @@ func SaveValue(ctx context.Context, valueJson []byte, m *ValueMetadata, opts ...
 		},
 		WithDirectories(dir))
 	if err != nil {
-		log.Ctx(ctx).Error().Err(err).Msg("failed to load object manager")
 		if errors.Is(err, ErrObjectNotFound) {
-			return ErrInvalidCollection.Msg("invalid collection " + v.Metadata.Collection)
+			return collectionSchemaNotFound(ctx, dir.CollectionsDir, v.Metadata.Collection)
 		}
+		log.Ctx(ctx).Error().Err(err).Msg("failed to load object manager")
 		return ErrCatalogError.Err(err)
 	}
 
 	oldHash := om.StorageRepresentation().GetHash()
+	sm := om.Metadata()
+	oldExtends := sm.ValuesExtends
+	// the base is kept unless the value names one; an empty or null extends detaches the values from their base
+	if gjson.GetBytes(valueJson, "metadata.extends").Exists() {
+		sm.ValuesExtends = v.Metadata.Extends
+	}
+	// the values are saved to the directory entry the collection schema was loaded from
+	storagePath := path.Clean(sm.GetStoragePath(types.CatalogObjectTypeCollectionSchema) + "/" + sm.Name)
 
 	// get object References
-	refs, err := getSchemaReferences(ctx, types.CatalogObjectTypeCollectionSchema, dir.CollectionsDir, v.Metadata.Collection)
+	refs, err := getSchemaReferences(ctx, types.CatalogObjectTypeCollectionSchema, dir.CollectionsDir, storagePath)
 	if err != nil {
 		log.Ctx(ctx).Error().Err(err).Msg("failed to get object references")
 		refs = schemamanager.SchemaReferences{}
@@ -182,21 +385,62 @@ func SaveValue(ctx context.Context, valueJson []byte, m *ValueMetadata, opts ...
 	if c == nil {
 		return validationerrors.ErrSchemaValidation.Msg("failed to load collection manager")
 	}
+	unknown := unknownValueKeys(v.Spec, c.ParameterNames())
+	if len(unknown) > 0 {
+		if !options.AllowUnknownValues {
+			return ErrUnknownValueKeys.Msg("unknown keys: " + strings.Join(unknown, ", "))
+		}
+		log.Ctx(ctx).Debug().Strs("keys", unknown).Msg("ignoring unknown keys in value")
+	}
+	changed := make(map[string]types.NullableAny)
 	for param, value := range v.Spec {
+		if slices.Contains(unknown, param) {
+			continue
+		}
 		v := c.GetValue(ctx, param)
+		if v.Sensitive {
+			// values saved here are stored in the collection schema, which is not encrypted
+			return ErrInvalidParameter.Msg("sensitive values can only be set on collections: " + param)
+		}
 		if v.Value.Equals(value) {
 			continue
 		}
-		if err := c.ValidateValue(ctx, loaders, param, value); err != nil {
+		changed[param] = value
+	}
+	if len(changed) > 0 {
+		params := make([]string, 0, len(refs))
+		for _, ref := range refs {
+			params = append(params, ref.Name)
+		}
+		schemaKey := validationSchemaKey(ctx, dir, oldHash, params)
+		if err := withValidationCache(c, schemaKey).ValidateValues(ctx, loaders, changed); err != nil {
 			return err
 		}
+	}
+	for param, value := range changed {
 		c.SetValue(ctx, param, value)
 	}
 
+	// the values the value takes from its base must be valid for the collection schema too
+	if sm.ValuesExtends != "" {
+		values, sources, err := resolveValues(ctx, om, c.GetDefaultValues(), dir)
+		if err != nil {
+			return err
+		}
+		if _, _, err := mergeBaseValues(ctx, om, sm.ValuesExtends, values, sources, dir, nil); err != nil {
+			return err
+		}
+	}
+
 	s := c.StorageRepresentation()
 	hash := s.GetHash()
 
 	if hash == oldHash {
+		// the base is kept with the directory entry, so it may change without the values changing
+		if sm.ValuesExtends != oldExtends {
+			_, err := saveEntryMetadata(ctx, types.CatalogObjectTypeCollectionSchema, dir.CollectionsDir, storagePath, &sm)
+			return err
+		}
 		return nil
 	}
 
@@ -211,18 +455,6 @@ func SaveValue(ctx context.Context, valueJson []byte, m *ValueMetadata, opts ...
 		Data:    data,
 		Hash:    hash,
 	}
-	// Save obj to the database
-	dberr := db.DB(ctx).CreateCatalogObject(ctx, &obj)
-	if dberr != nil {
-		if errors.Is(dberr, dberror.ErrAlreadyExists) {
-			log.Ctx(ctx).Debug().Str("hash", obj.Hash).Msg("catalog object already exists")
-			// in this case, we don't return. If we came here it means the object is not in the directory,
-			// so we'll keep chugging along and save the object to the directory
-		} else {
-			log.Ctx(ctx).Error().Err(dberr).Msg("failed to save catalog object")
-			return dberr
-		}
-	}
 	var refModel models.References
 	for _, ref := range refs {
 		refModel = append(refModel, models.Reference{
@@ -230,19 +462,42 @@ func SaveValue(ctx context.Context, valueJson []byte, m *ValueMetadata, opts ...
 		})
 	}
 
-	if err := db.DB(ctx).AddOrUpdateObjectByPath(
-		ctx, types.CatalogObjectTypeCollectionSchema,
-		dir.DirForType(types.CatalogObjectTypeCollectionSchema),
-		v.Metadata.Collection,
-		models.ObjectRef{
-			Hash:       obj.Hash,
-			References: refModel,
-		}); err != nil {
-		log.Ctx(ctx).Error().Err(err).Msg("failed to save object to directory")
-		return ErrCatalogError
-	}
+	// the object and the directory entry pointing to it are saved in one transaction
+	return db.WithTx(ctx, func(ctx context.Context) apperrors.Error {
+		dberr := db.DB(ctx).CreateCatalogObject(ctx, &obj)
+		if dberr != nil {
+			if errors.Is(dberr, dberror.ErrAlreadyExists) {
+				log.Ctx(ctx).Debug().Str("hash", obj.Hash).Msg("catalog object already exists")
+				// in this case, we don't return. If we came here it means the object is not in the directory,
+				// so we'll keep chugging along and save the object to the directory
+			} else {
+				log.Ctx(ctx).Error().Err(dberr).Msg("failed to save catalog object")
+				return dberr
+			}
+		}
 
-	return nil
+		if err := db.DB(ctx).AddOrUpdateObjectByPath(
+			ctx, types.CatalogObjectTypeCollectionSchema,
+			dir.DirForType(types.CatalogObjectTypeCollectionSchema),
+			storagePath,
+			schemaObjectRef(obj.Hash, refModel, &sm)); err != nil {
+			log.Ctx(ctx).Error().Err(err).Msg("failed to save object to directory")
+			return ErrCatalogError
+		}
+		return nil
+	})
+}
+
+// unknownValueKeys returns the sorted keys of spec that are not in params
+func unknownValueKeys(spec valueSpec, params []string) []string {
+	var unknown []string
+	for k := range spec {
+		if !slices.Contains(params, k) {
+			unknown = append(unknown, k)
+		}
+	}
+	sort.Strings(unknown)
+	return unknown
 }
 
 func canonicalizeValueMetadata(v valueSchema, m *ValueMetadata) apperrors.Error {
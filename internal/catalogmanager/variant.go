@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"errors"
 	"reflect"
+	"time"
 
 	"github.com/go-playground/validator/v10"
 	"github.com/google/uuid"
@@ -30,9 +31,11 @@ type variantSchema struct {
 }
 
 type variantMetadata struct {
-	Name        string `json:"name" validate:"required,resourceNameValidator"`
-	Catalog     string `json:"catalog" validate:"required,resourceNameValidator"`
-	Description string `json:"description"`
+	Name        string     `json:"name" validate:"required,resourceNameValidator"`
+	Catalog     string     `json:"catalog" validate:"required,resourceNameValidator"`
+	Description string     `json:"description"`
+	CreatedAt   *time.Time `json:"createdAt,omitempty"` // set by the server, ignored on input
+	UpdatedAt   *time.Time `json:"updatedAt,omitempty"` // set by the server, ignored on input
 }
 
 type variantManager struct {
@@ -210,6 +213,8 @@ func (vm *variantManager) ToJson(ctx context.Context) ([]byte, apperrors.Error)
 			Name:        vm.v.Name,
 			Catalog:     catalog.Name,
 			Description: vm.v.Description,
+			CreatedAt:   &vm.v.CreatedAt,
+			UpdatedAt:   &vm.v.UpdatedAt,
 		},
 	}
 
@@ -223,7 +228,26 @@ func (vm *variantManager) ToJson(ctx context.Context) ([]byte, apperrors.Error)
 }
 
 func DeleteVariant(ctx context.Context, catalogID, variantID uuid.UUID, name string) apperrors.Error {
-	err := db.DB(ctx).DeleteVariant(ctx, catalogID, variantID, name)
+	// the variant that a catalog is configured to default to cannot be deleted
+	c, err := db.DB(ctx).GetCatalog(ctx, catalogID, "")
+	if err != nil && !errors.Is(err, dberror.ErrNotFound) {
+		log.Ctx(ctx).Error().Err(err).Msg("failed to load catalog")
+		return err
+	}
+	if c != nil && c.GetInfo().DefaultVariant != "" {
+		v, err := db.DB(ctx).GetVariant(ctx, catalogID, variantID, name)
+		if err != nil {
+			if errors.Is(err, dberror.ErrNotFound) {
+				return ErrVariantNotFound
+			}
+			log.Ctx(ctx).Error().Err(err).Msg("failed to load variant")
+			return err
+		}
+		if v.Name == c.GetInfo().DefaultVariant {
+			return ErrUnableToDeleteDefaultVariant
+		}
+	}
+	err = db.DB(ctx).DeleteVariant(ctx, catalogID, variantID, name)
 	if err != nil {
 		if errors.Is(err, dberror.ErrNotFound) {
 			return ErrVariantNotFound
@@ -0,0 +1,168 @@
+package catalogmanager
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mugiliam/common/apperrors"
+	"github.com/mugiliam/hatchcatalogsrv/internal/db"
+	"github.com/mugiliam/hatchcatalogsrv/internal/db/dberror"
+	"github.com/mugiliam/hatchcatalogsrv/internal/db/models"
+	"github.com/rs/zerolog/log"
+)
+
+// VersionSummary describes a version of a variant
+type VersionSummary struct {
+	Version     int        `json:"version"`
+	Label       string     `json:"label,omitempty"`
+	Description string     `json:"description,omitempty"`
+	CreatedAt   *time.Time `json:"createdAt,omitempty"`
+}
+
+// VersionList is the response of a request to list the versions of a variant. Count is the number of
+// versions in the variant, whether or not the list is filtered to labeled versions.
+type VersionList struct {
+	Variant  string           `json:"variant"`
+	Count    int              `json:"count"`
+	Versions []VersionSummary `json:"versions"`
+}
+
+// ListVersions returns the versions of the variant identified by reqCtx, oldest first. With namedOnly, only
+// versions that have a label are listed.
+func ListVersions(ctx context.Context, reqCtx RequestContext, namedOnly bool) ([]byte, apperrors.Error) {
+	v, err := variantForRequest(ctx, reqCtx)
+	if err != nil {
+		return nil, err
+	}
+
+	var versions []models.Version
+	var dberr error
+	if namedOnly {
+		versions, dberr = db.ReadDB(ctx).GetNamedVersions(ctx, v.VariantID)
+	} else {
+		versions, dberr = db.ReadDB(ctx).ListVersions(ctx, v.VariantID)
+	}
+	if dberr != nil {
+		return nil, ErrCatalogError.Err(dberr)
+	}
+	count, dberr := db.ReadDB(ctx).CountVersionsInVariant(ctx, v.VariantID)
+	if dberr != nil {
+		return nil, ErrCatalogError.Err(dberr)
+	}
+
+	rsp := VersionList{
+		Variant:  v.Name,
+		Count:    count,
+		Versions: []VersionSummary{},
+	}
+	for i := range versions {
+		rsp.Versions = append(rsp.Versions, versionSummary(&versions[i]))
+	}
+	j, e := json.Marshal(rsp)
+	if e != nil {
+		log.Ctx(ctx).Error().Err(e).Msg("failed to marshal version list")
+		return nil, ErrCatalogError
+	}
+	return j, nil
+}
+
+// GetVersionByLabel returns the version labeled label in the variant identified by reqCtx
+func GetVersionByLabel(ctx context.Context, reqCtx RequestContext, label string) ([]byte, apperrors.Error) {
+	v, err := variantForRequest(ctx, reqCtx)
+	if err != nil {
+		return nil, err
+	}
+	version, dberr := db.ReadDB(ctx).GetVersionByLabel(ctx, label, v.VariantID)
+	if dberr != nil {
+		if errors.Is(dberr, dberror.ErrNotFound) {
+			return nil, ErrVersionNotFound.Msg("no version labeled " + label)
+		}
+		return nil, ErrCatalogError.Err(dberr)
+	}
+	j, e := json.Marshal(versionSummary(version))
+	if e != nil {
+		log.Ctx(ctx).Error().Err(e).Msg("failed to marshal version")
+		return nil, ErrCatalogError
+	}
+	return j, nil
+}
+
+// SetVersionLabel labels version versionNum of the variant identified by reqCtx. A label that is already on
+// another version is a conflict unless force is set, in which case the label is moved to versionNum.
+func SetVersionLabel(ctx context.Context, reqCtx RequestContext, versionNum int, label string, force bool) ([]byte, apperrors.Error) {
+	v, err := variantForRequest(ctx, reqCtx)
+	if err != nil {
+		return nil, err
+	}
+	if label == "" {
+		return nil, ErrInvalidVersionLabel.Msg("label cannot be empty")
+	}
+
+	// clearing the label and setting it are done together, so a failed move leaves the label where it was
+	err = db.WithTx(ctx, func(ctx context.Context) apperrors.Error {
+		if force {
+			if dberr := db.DB(ctx).ClearVersionLabel(ctx, label, v.VariantID); dberr != nil {
+				return ErrCatalogError.Err(dberr)
+			}
+		}
+		dberr := db.DB(ctx).SetVersionLabel(ctx, versionNum, v.VariantID, label)
+		switch {
+		case dberr == nil:
+			return nil
+		case errors.Is(dberr, dberror.ErrNotFound):
+			return ErrVersionNotFound
+		case errors.Is(dberr, dberror.ErrAlreadyExists):
+			return ErrVersionLabelExists.Msg("label " + label + " is on another version")
+		case errors.Is(dberr, dberror.ErrInvalidInput):
+			return ErrInvalidVersionLabel.Msg("labels may only contain letters, digits, '-' and '_'")
+		}
+		return ErrCatalogError.Err(dberr)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	version, dberr := db.DB(ctx).GetVersion(ctx, versionNum, v.VariantID)
+	if dberr != nil {
+		return nil, ErrCatalogError.Err(dberr)
+	}
+	j, e := json.Marshal(versionSummary(version))
+	if e != nil {
+		log.Ctx(ctx).Error().Err(e).Msg("failed to marshal version")
+		return nil, ErrCatalogError
+	}
+	return j, nil
+}
+
+func versionSummary(v *models.Version) VersionSummary {
+	s := VersionSummary{
+		Version:     v.VersionNum,
+		Label:       v.Label,
+		Description: v.Description,
+	}
+	if !v.CreatedAt.IsZero() {
+		s.CreatedAt = &v.CreatedAt
+	}
+	return s
+}
+
+// variantForRequest loads the variant named or identified in reqCtx
+func variantForRequest(ctx context.Context, reqCtx RequestContext) (*models.Variant, apperrors.Error) {
+	if reqCtx.CatalogID == uuid.Nil {
+		return nil, ErrInvalidCatalog
+	}
+	if reqCtx.VariantID == uuid.Nil && reqCtx.Variant == "" {
+		return nil, ErrInvalidVariant
+	}
+	v, err := db.ReadDB(ctx).GetVariant(ctx, reqCtx.CatalogID, reqCtx.VariantID, reqCtx.Variant)
+	if err != nil {
+		if errors.Is(err, dberror.ErrNotFound) {
+			return nil, ErrVariantNotFound
+		}
+		return nil, ErrCatalogError.Err(err)
+	}
+	return v, nil
+}
@@ -5,6 +5,8 @@ import (
 	"encoding/json"
 	"errors"
 	"reflect"
+	"strconv"
+	"time"
 
 	"github.com/go-playground/validator/v10"
 	"github.com/google/uuid"
@@ -30,11 +32,13 @@ type workspaceSchema struct {
 }
 
 type workspaceMetadata struct {
-	Catalog     string `json:"catalog" validate:"omitempty,resourceNameValidator"`
-	Variant     string `json:"variant" validate:"omitempty,resourceNameValidator"`
-	BaseVersion int    `json:"-"`
-	Description string `json:"description"`
-	Label       string `json:"label" validate:"omitempty,resourceNameValidator"`
+	Catalog     string     `json:"catalog" validate:"omitempty,resourceNameValidator"`
+	Variant     string     `json:"variant" validate:"omitempty,resourceNameValidator"`
+	BaseVersion int        `json:"-"`
+	Description string     `json:"description"`
+	Label       string     `json:"label" validate:"omitempty,resourceNameValidator"`
+	CreatedAt   *time.Time `json:"createdAt,omitempty"` // set by the server, ignored on input
+	UpdatedAt   *time.Time `json:"updatedAt,omitempty"` // set by the server, ignored on input
 }
 
 type workspaceManager struct {
@@ -268,6 +272,8 @@ func (wm *workspaceManager) ToJson(ctx context.Context) ([]byte, apperrors.Error
 			BaseVersion: wm.w.BaseVersion,
 			Description: wm.w.Description,
 			Label:       wm.w.Label,
+			CreatedAt:   &wm.w.CreatedAt,
+			UpdatedAt:   &wm.w.UpdatedAt,
 		},
 	}
 
@@ -292,6 +298,234 @@ func DeleteWorkspace(ctx context.Context, workspaceID uuid.UUID) apperrors.Error
 	return nil
 }
 
+// RebaseResult is the outcome of a workspace rebase. Conflicts is empty if the rebase was applied.
+type RebaseResult struct {
+	WorkspaceID uuid.UUID        `json:"workspaceId"`
+	BaseVersion int              `json:"baseVersion"`
+	Conflicts   []ObjectConflict `json:"conflicts,omitempty"`
+}
+
+// RebaseWorkspace replays the changes made in the workspace since its base version onto newBaseVersion.
+// An object changed both in the workspace and between the two versions is a conflict. If there are any
+// conflicts the workspace is left untouched and the result lists them; otherwise the workspace directories
+// and base version are updated in a single transaction.
+func RebaseWorkspace(ctx context.Context, workspaceID uuid.UUID, newBaseVersion int) (*RebaseResult, apperrors.Error) {
+	if workspaceID == uuid.Nil {
+		return nil, ErrInvalidWorkspace
+	}
+	if newBaseVersion < 1 {
+		return nil, ErrInvalidVersion.Msg("base version must be a positive version number")
+	}
+
+	var result *RebaseResult
+	err := db.WithTx(ctx, func(ctx context.Context) apperrors.Error {
+		w, err := getWorkspace(ctx, workspaceID)
+		if err != nil {
+			return err
+		}
+		result = &RebaseResult{
+			WorkspaceID: w.WorkspaceID,
+			BaseVersion: w.BaseVersion,
+		}
+		if w.BaseVersion == newBaseVersion {
+			return nil
+		}
+
+		oldBase, err := getDirectoriesForVersion(ctx, w.VariantID, w.BaseVersion)
+		if err != nil {
+			return err
+		}
+		newBase, err := getDirectoriesForVersion(ctx, w.VariantID, newBaseVersion)
+		if err != nil {
+			return err
+		}
+		wsDirs := workspaceDirectories(w)
+
+		rebased := make(map[types.CatalogObjectType]models.Directory, len(directoryTypes))
+		for _, t := range directoryTypes {
+			base, err := loadDirectory(ctx, t, oldBase.DirForType(t))
+			if err != nil {
+				return err
+			}
+			upstream, err := loadDirectory(ctx, t, newBase.DirForType(t))
+			if err != nil {
+				return err
+			}
+			ours, err := loadDirectory(ctx, t, wsDirs.DirForType(t))
+			if err != nil {
+				return err
+			}
+			conflicts := applyDirectoryChanges(t, upstream, diffDirectory(base, ours), diffDirectory(base, upstream))
+			result.Conflicts = append(result.Conflicts, conflicts...)
+			rebased[t] = upstream
+		}
+		if len(result.Conflicts) > 0 {
+			return nil
+		}
+
+		for _, t := range directoryTypes {
+			if err := storeDirectory(ctx, t, wsDirs.DirForType(t), rebased[t]); err != nil {
+				return err
+			}
+		}
+		w.BaseVersion = newBaseVersion
+		if err := db.DB(ctx).UpdateWorkspace(ctx, w); err != nil {
+			log.Ctx(ctx).Error().Err(err).Msg("failed to update workspace")
+			return ErrUnableToUpdateObject.Msg("failed to update workspace")
+		}
+		result.BaseVersion = newBaseVersion
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// MergeResult is the outcome of merging one workspace into another. Conflicts is empty if the merge was applied.
+type MergeResult struct {
+	TargetID  uuid.UUID        `json:"target"`
+	SourceID  uuid.UUID        `json:"source"`
+	Conflicts []ObjectConflict `json:"conflicts,omitempty"`
+}
+
+// MergeWorkspaces applies the objects added, modified and deleted in the source workspace since its base
+// version onto the target workspace. An object the target also changed to a different result is a conflict.
+// If there are any conflicts neither workspace is modified and the result lists them; otherwise the target
+// directories are updated in a single transaction. The source workspace is never modified.
+func MergeWorkspaces(ctx context.Context, targetID, sourceID uuid.UUID) (*MergeResult, apperrors.Error) {
+	if targetID == uuid.Nil || sourceID == uuid.Nil {
+		return nil, ErrInvalidWorkspace
+	}
+	if targetID == sourceID {
+		return nil, ErrInvalidWorkspace.Msg("cannot merge a workspace into itself")
+	}
+
+	result := &MergeResult{
+		TargetID: targetID,
+		SourceID: sourceID,
+	}
+	err := db.WithTx(ctx, func(ctx context.Context) apperrors.Error {
+		target, err := getWorkspace(ctx, targetID)
+		if err != nil {
+			return err
+		}
+		source, err := getWorkspace(ctx, sourceID)
+		if err != nil {
+			return err
+		}
+		if target.VariantID != source.VariantID {
+			return ErrInvalidWorkspace.Msg("workspaces belong to different variants")
+		}
+
+		sourceBase, err := getDirectoriesForVersion(ctx, source.VariantID, source.BaseVersion)
+		if err != nil {
+			return err
+		}
+		targetDirs := workspaceDirectories(target)
+		sourceDirs := workspaceDirectories(source)
+
+		merged := make(map[types.CatalogObjectType]models.Directory, len(directoryTypes))
+		for _, t := range directoryTypes {
+			base, err := loadDirectory(ctx, t, sourceBase.DirForType(t))
+			if err != nil {
+				return err
+			}
+			theirs, err := loadDirectory(ctx, t, targetDirs.DirForType(t))
+			if err != nil {
+				return err
+			}
+			ours, err := loadDirectory(ctx, t, sourceDirs.DirForType(t))
+			if err != nil {
+				return err
+			}
+			// the target's differences from the source's base, which include any changes in its own base
+			// version, are what the source changes must not conflict with
+			conflicts := applyDirectoryChanges(t, theirs, diffDirectory(base, ours), diffDirectory(base, theirs))
+			result.Conflicts = append(result.Conflicts, conflicts...)
+			merged[t] = theirs
+		}
+		if len(result.Conflicts) > 0 {
+			return nil
+		}
+
+		for _, t := range directoryTypes {
+			if err := storeDirectory(ctx, t, targetDirs.DirForType(t), merged[t]); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// resolveVersionRef returns the number of the version of the variant named by ref, which is either a version
+// number or a version label. An empty ref resolves to the latest version.
+func resolveVersionRef(ctx context.Context, variantID uuid.UUID, ref string) (int, apperrors.Error) {
+	var (
+		v   *models.Version
+		err error
+	)
+	if ref == "" {
+		v, err = db.DB(ctx).GetLatestVersion(ctx, variantID)
+	} else if num, convErr := strconv.Atoi(ref); convErr == nil {
+		v, err = db.DB(ctx).GetVersion(ctx, num, variantID)
+	} else {
+		v, err = db.DB(ctx).GetVersionByLabel(ctx, ref, variantID)
+	}
+	if err != nil {
+		if errors.Is(err, dberror.ErrNotFound) {
+			if ref == "" {
+				return 0, ErrVersionNotFound.Msg("variant has no versions")
+			}
+			return 0, ErrInvalidVersion.Msg("version " + ref + " does not exist")
+		}
+		log.Ctx(ctx).Error().Err(err).Str("version", ref).Msg("failed to load version")
+		return 0, ErrCatalogError.Msg("unable to load version")
+	}
+	return v.VersionNum, nil
+}
+
+// branchWorkspaceFromVersion replaces the directories of a new workspace with those of versionNum and makes it
+// the base version. Objects are shared by hash, so only the directories are copied.
+func branchWorkspaceFromVersion(ctx context.Context, w *models.Workspace, versionNum int) apperrors.Error {
+	base, err := getDirectoriesForVersion(ctx, w.VariantID, versionNum)
+	if err != nil {
+		return err
+	}
+	wsDirs := workspaceDirectories(w)
+	for _, t := range directoryTypes {
+		dir, err := loadDirectory(ctx, t, base.DirForType(t))
+		if err != nil {
+			return err
+		}
+		if err := storeDirectory(ctx, t, wsDirs.DirForType(t), dir); err != nil {
+			return err
+		}
+	}
+	w.BaseVersion = versionNum
+	if err := db.DB(ctx).UpdateWorkspace(ctx, w); err != nil {
+		log.Ctx(ctx).Error().Err(err).Msg("failed to update workspace")
+		return ErrUnableToUpdateObject.Msg("failed to update workspace")
+	}
+	return nil
+}
+
+func getWorkspace(ctx context.Context, workspaceID uuid.UUID) (*models.Workspace, apperrors.Error) {
+	w, err := db.DB(ctx).GetWorkspace(ctx, workspaceID)
+	if err != nil {
+		if errors.Is(err, dberror.ErrNotFound) {
+			return nil, ErrWorkspaceNotFound
+		}
+		log.Ctx(ctx).Error().Err(err).Msg("failed to load workspace")
+		return nil, ErrUnableToLoadObject.Msg("unable to load workspace")
+	}
+	return w, nil
+}
+
 type workspaceResource struct {
 	name RequestContext
 	vm   schemamanager.WorkspaceManager
@@ -318,7 +552,24 @@ func (wr *workspaceResource) Create(ctx context.Context, rsrcJson []byte) (strin
 	if err != nil {
 		return "", err
 	}
-	err = workspace.Save(ctx)
+	// the workspace is branched from the version in the fromVersion query parameter, or the latest version
+	err = db.WithTx(ctx, func(ctx context.Context) apperrors.Error {
+		versionNum, err := resolveVersionRef(ctx, workspace.VariantID(), wr.name.QueryParams.Get("fromVersion"))
+		if err != nil {
+			return err
+		}
+		if err := workspace.Save(ctx); err != nil {
+			return err
+		}
+		if workspace.BaseVersion() == versionNum {
+			return nil
+		}
+		w, err := getWorkspace(ctx, workspace.ID())
+		if err != nil {
+			return err
+		}
+		return branchWorkspaceFromVersion(ctx, w, versionNum)
+	})
 	if err != nil {
 		return "", err
 	}
@@ -406,16 +657,42 @@ func (wr *workspaceResource) Update(ctx context.Context, rsrcJson []byte) apperr
 		return ErrInvalidWorkspace
 	}
 
-	w.Description = ws.Metadata.Description
-	w.Label = ws.Metadata.Label
-
-	err = db.DB(ctx).UpdateWorkspace(ctx, w)
-	if err != nil {
-		log.Ctx(ctx).Error().Err(err).Msg("failed to update workspace")
-		return ErrUnableToUpdateObject.Msg("failed to update workspace")
+	// updated_at is bumped by the database on every update, so skip the update if nothing changed
+	if w.Description == ws.Metadata.Description && w.Label == ws.Metadata.Label {
+		return nil
 	}
 
-	return nil
+	return db.WithTx(ctx, func(ctx context.Context) apperrors.Error {
+		// a new label is set only if the label is still the one read above, so that a concurrent rename is
+		// reported distinctly from a label taken by another workspace
+		if ws.Metadata.Label != "" && ws.Metadata.Label != w.Label {
+			if err := db.DB(ctx).UpdateWorkspaceLabel(ctx, w.WorkspaceID, w.Label, ws.Metadata.Label); err != nil {
+				switch {
+				case errors.Is(err, dberror.ErrConcurrentModification):
+					return ErrConcurrentModification.Msg("workspace label was changed by another request")
+				case errors.Is(err, dberror.ErrAlreadyExists):
+					return ErrAlreadyExists.Msg("label already exists for another workspace")
+				case errors.Is(err, dberror.ErrNotFound):
+					return ErrWorkspaceNotFound
+				case errors.Is(err, dberror.ErrInvalidInput):
+					return ErrInvalidWorkspace.Msg("invalid label format")
+				}
+				log.Ctx(ctx).Error().Err(err).Msg("failed to update workspace label")
+				return ErrUnableToUpdateObject.Msg("failed to update workspace")
+			}
+			w.Label = ws.Metadata.Label
+		}
+		if w.Description == ws.Metadata.Description && w.Label == ws.Metadata.Label {
+			return nil
+		}
+		w.Description = ws.Metadata.Description
+		w.Label = ws.Metadata.Label
+		if err := db.DB(ctx).UpdateWorkspace(ctx, w); err != nil {
+			log.Ctx(ctx).Error().Err(err).Msg("failed to update workspace")
+			return ErrUnableToUpdateObject.Msg("failed to update workspace")
+		}
+		return nil
+	})
 }
 
 func NewWorkspaceResource(ctx context.Context, name RequestContext) (schemamanager.ResourceManager, apperrors.Error) {
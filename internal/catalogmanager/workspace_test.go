@@ -1,15 +1,19 @@
 package catalogmanager
 
 import (
+	"context"
+	"net/url"
 	"testing"
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgtype"
 	"github.com/mugiliam/hatchcatalogsrv/internal/common"
 	"github.com/mugiliam/hatchcatalogsrv/internal/db"
+	"github.com/mugiliam/hatchcatalogsrv/internal/db/dberror"
 	"github.com/mugiliam/hatchcatalogsrv/internal/db/models"
 	"github.com/mugiliam/hatchcatalogsrv/pkg/types"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestNewWorkspaceManager(t *testing.T) {
@@ -172,3 +176,335 @@ func TestNewWorkspaceManager(t *testing.T) {
 		})
 	}
 }
+
+func TestRebaseWorkspace(t *testing.T) {
+	ctx := newDb()
+	t.Cleanup(func() {
+		db.DB(ctx).Close(ctx)
+	})
+
+	tenantID := types.TenantId("TABCDE")
+	projectID := types.ProjectId("PDEFGH")
+	ctx = common.SetTenantIdInContext(ctx, tenantID)
+	ctx = common.SetProjectIdInContext(ctx, projectID)
+
+	var err error
+	err = db.DB(ctx).CreateTenant(ctx, tenantID)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		db.DB(ctx).DeleteTenant(ctx, tenantID)
+	})
+	err = db.DB(ctx).CreateProject(ctx, projectID)
+	require.NoError(t, err)
+
+	c := models.Catalog{
+		Name:      "rebase-catalog",
+		ProjectID: projectID,
+		Info:      pgtype.JSONB{Status: pgtype.Null},
+	}
+	err = db.DB(ctx).CreateCatalog(ctx, &c)
+	require.NoError(t, err)
+	variantID, err := db.DB(ctx).GetVariantIDFromName(ctx, c.CatalogID, types.DefaultVariant)
+	require.NoError(t, err)
+
+	v2 := models.Version{
+		Info:      pgtype.JSONB{Status: pgtype.Null},
+		VariantID: variantID,
+	}
+	require.NoError(t, db.DB(ctx).CreateVersion(ctx, &v2))
+
+	setDirs := func(dirs Directories, params, collectionSchemas models.Directory) {
+		setTestDirectories(t, ctx, dirs, params, collectionSchemas)
+	}
+	newWorkspace := func() *models.Workspace {
+		return newTestWorkspace(t, ctx, variantID)
+	}
+	wsDirs := workspaceDirectories
+	ref := testObjectRef
+
+	v1Dirs, err := getDirectoriesForVersion(ctx, variantID, 1)
+	require.NoError(t, err)
+	v2Dirs, err := getDirectoriesForVersion(ctx, variantID, v2.VersionNum)
+	require.NoError(t, err)
+
+	// base: /a, /b and /c, with a collection schema /s referencing x
+	setDirs(v1Dirs,
+		models.Directory{"/a": ref("a1"), "/b": ref("b1"), "/c": ref("c1")},
+		models.Directory{"/s": ref("s1", "x")})
+	// upstream modifies /b, deletes /c, adds /d and adds a reference to y in /s
+	setDirs(v2Dirs,
+		models.Directory{"/a": ref("a1"), "/b": ref("b2"), "/d": ref("d2")},
+		models.Directory{"/s": ref("s1", "x", "y")})
+
+	// the workspace modifies /a, adds /e and drops the reference to x in /s
+	ws := newWorkspace()
+	setDirs(wsDirs(ws),
+		models.Directory{"/a": ref("a3"), "/b": ref("b1"), "/c": ref("c1"), "/e": ref("e3")},
+		models.Directory{"/s": ref("s1")})
+
+	result, err := RebaseWorkspace(ctx, ws.WorkspaceID, v2.VersionNum)
+	require.NoError(t, err)
+	assert.Empty(t, result.Conflicts)
+	assert.Equal(t, v2.VersionNum, result.BaseVersion)
+
+	params, err := loadDirectory(ctx, types.CatalogObjectTypeParameterSchema, ws.ParametersDir)
+	require.NoError(t, err)
+	assert.Equal(t, models.Directory{"/a": ref("a3"), "/b": ref("b2"), "/d": ref("d2"), "/e": ref("e3")}, params)
+	collectionSchemas, err := loadDirectory(ctx, types.CatalogObjectTypeCollectionSchema, ws.CollectionsDir)
+	require.NoError(t, err)
+	assert.Equal(t, models.Directory{"/s": ref("s1", "y")}, collectionSchemas)
+	w, err := db.DB(ctx).GetWorkspace(ctx, ws.WorkspaceID)
+	require.NoError(t, err)
+	assert.Equal(t, v2.VersionNum, w.BaseVersion)
+
+	// a workspace that modifies /b and /c conflicts with upstream and is left untouched
+	ws = newWorkspace()
+	conflicting := models.Directory{"/a": ref("a1"), "/b": ref("b3"), "/c": ref("c3")}
+	setDirs(wsDirs(ws), conflicting, models.Directory{"/s": ref("s1", "x")})
+
+	result, err = RebaseWorkspace(ctx, ws.WorkspaceID, v2.VersionNum)
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.BaseVersion)
+	assert.Equal(t, []ObjectConflict{
+		{
+			Kind:   types.ParameterSchemaKind,
+			Path:   "/b",
+			Ours:   ObjectChange{Action: ChangeModified, Hash: "b3"},
+			Theirs: ObjectChange{Action: ChangeModified, Hash: "b2"},
+		},
+		{
+			Kind:   types.ParameterSchemaKind,
+			Path:   "/c",
+			Ours:   ObjectChange{Action: ChangeModified, Hash: "c3"},
+			Theirs: ObjectChange{Action: ChangeDeleted},
+		},
+	}, result.Conflicts)
+
+	params, err = loadDirectory(ctx, types.CatalogObjectTypeParameterSchema, ws.ParametersDir)
+	require.NoError(t, err)
+	assert.Equal(t, conflicting, params)
+	w, err = db.DB(ctx).GetWorkspace(ctx, ws.WorkspaceID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, w.BaseVersion)
+
+	// rebasing onto a version that does not exist fails
+	_, err = RebaseWorkspace(ctx, ws.WorkspaceID, v2.VersionNum+100)
+	assert.ErrorIs(t, err, ErrVersionNotFound)
+	_, err = RebaseWorkspace(ctx, uuid.New(), v2.VersionNum)
+	assert.ErrorIs(t, err, ErrWorkspaceNotFound)
+}
+
+func TestCreateWorkspaceFromVersion(t *testing.T) {
+	ctx := newDb()
+	t.Cleanup(func() {
+		db.DB(ctx).Close(ctx)
+	})
+
+	tenantID := types.TenantId("TABCDE")
+	projectID := types.ProjectId("PDEFGH")
+	ctx = common.SetTenantIdInContext(ctx, tenantID)
+	ctx = common.SetProjectIdInContext(ctx, projectID)
+
+	var err error
+	err = db.DB(ctx).CreateTenant(ctx, tenantID)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		db.DB(ctx).DeleteTenant(ctx, tenantID)
+	})
+	err = db.DB(ctx).CreateProject(ctx, projectID)
+	require.NoError(t, err)
+
+	c := models.Catalog{
+		Name:      "branch-catalog",
+		ProjectID: projectID,
+		Info:      pgtype.JSONB{Status: pgtype.Null},
+	}
+	err = db.DB(ctx).CreateCatalog(ctx, &c)
+	require.NoError(t, err)
+	variantID, err := db.DB(ctx).GetVariantIDFromName(ctx, c.CatalogID, types.DefaultVariant)
+	require.NoError(t, err)
+
+	v2 := models.Version{
+		Label:     "release",
+		Info:      pgtype.JSONB{Status: pgtype.Null},
+		VariantID: variantID,
+	}
+	require.NoError(t, db.DB(ctx).CreateVersion(ctx, &v2))
+
+	v1Dirs, err := getDirectoriesForVersion(ctx, variantID, 1)
+	require.NoError(t, err)
+	v2Dirs, err := getDirectoriesForVersion(ctx, variantID, v2.VersionNum)
+	require.NoError(t, err)
+	setTestDirectories(t, ctx, v1Dirs, models.Directory{"/a": testObjectRef("a1")}, models.Directory{})
+	setTestDirectories(t, ctx, v2Dirs, models.Directory{"/a": testObjectRef("a2"), "/b": testObjectRef("b2")}, models.Directory{})
+
+	create := func(label, fromVersion string) (*models.Workspace, error) {
+		q := url.Values{}
+		if fromVersion != "" {
+			q.Set("fromVersion", fromVersion)
+		}
+		rm, err := ResourceManagerForKind(ctx, types.WorkspaceKind, RequestContext{
+			Catalog:     c.Name,
+			Variant:     types.DefaultVariant,
+			QueryParams: q,
+		})
+		require.NoError(t, err)
+		rsrc := `{"version": "v1", "kind": "Workspace", "metadata": {"label": "` + label + `"}}`
+		if _, err := rm.Create(ctx, []byte(rsrc)); err != nil {
+			return nil, err
+		}
+		w, err := db.DB(ctx).GetWorkspaceByLabel(ctx, variantID, label)
+		require.NoError(t, err)
+		return w, nil
+	}
+	checkBranch := func(w *models.Workspace, versionNum int, params models.Directory) {
+		assert.Equal(t, versionNum, w.BaseVersion)
+		dir, err := loadDirectory(ctx, types.CatalogObjectTypeParameterSchema, w.ParametersDir)
+		require.NoError(t, err)
+		assert.Equal(t, params, dir)
+	}
+
+	// by number
+	w, err := create("from-one", "1")
+	require.NoError(t, err)
+	checkBranch(w, 1, models.Directory{"/a": testObjectRef("a1")})
+
+	// by label
+	w, err = create("from-release", "release")
+	require.NoError(t, err)
+	checkBranch(w, v2.VersionNum, models.Directory{"/a": testObjectRef("a2"), "/b": testObjectRef("b2")})
+
+	// the latest version by default
+	w, err = create("from-latest", "")
+	require.NoError(t, err)
+	checkBranch(w, v2.VersionNum, models.Directory{"/a": testObjectRef("a2"), "/b": testObjectRef("b2")})
+
+	// a version that does not exist is rejected and no workspace is created
+	_, err = create("from-missing", "100")
+	assert.ErrorIs(t, err, ErrInvalidVersion)
+	_, err = create("from-missing", "no-such-label")
+	assert.ErrorIs(t, err, ErrInvalidVersion)
+	_, err = db.DB(ctx).GetWorkspaceByLabel(ctx, variantID, "from-missing")
+	assert.ErrorIs(t, err, dberror.ErrNotFound)
+}
+
+func TestMergeWorkspaces(t *testing.T) {
+	ctx := newDb()
+	t.Cleanup(func() {
+		db.DB(ctx).Close(ctx)
+	})
+
+	tenantID := types.TenantId("TABCDE")
+	projectID := types.ProjectId("PDEFGH")
+	ctx = common.SetTenantIdInContext(ctx, tenantID)
+	ctx = common.SetProjectIdInContext(ctx, projectID)
+
+	var err error
+	err = db.DB(ctx).CreateTenant(ctx, tenantID)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		db.DB(ctx).DeleteTenant(ctx, tenantID)
+	})
+	err = db.DB(ctx).CreateProject(ctx, projectID)
+	require.NoError(t, err)
+
+	c := models.Catalog{
+		Name:      "merge-catalog",
+		ProjectID: projectID,
+		Info:      pgtype.JSONB{Status: pgtype.Null},
+	}
+	err = db.DB(ctx).CreateCatalog(ctx, &c)
+	require.NoError(t, err)
+	variantID, err := db.DB(ctx).GetVariantIDFromName(ctx, c.CatalogID, types.DefaultVariant)
+	require.NoError(t, err)
+
+	ref := testObjectRef
+	baseDirs, err := getDirectoriesForVersion(ctx, variantID, 1)
+	require.NoError(t, err)
+	setTestDirectories(t, ctx, baseDirs,
+		models.Directory{"/a": ref("a1"), "/b": ref("b1"), "/c": ref("c1")},
+		models.Directory{"/s": ref("s1", "x")})
+
+	// the target modifies /a and adds a reference to y in /s
+	target := newTestWorkspace(t, ctx, variantID)
+	setTestDirectories(t, ctx, workspaceDirectories(target),
+		models.Directory{"/a": ref("a2"), "/b": ref("b1"), "/c": ref("c1")},
+		models.Directory{"/s": ref("s1", "x", "y")})
+
+	// the source modifies /b, deletes /c, adds /d and drops the reference to x in /s
+	source := newTestWorkspace(t, ctx, variantID)
+	sourceParams := models.Directory{"/a": ref("a1"), "/b": ref("b3"), "/d": ref("d3")}
+	setTestDirectories(t, ctx, workspaceDirectories(source), sourceParams, models.Directory{"/s": ref("s1")})
+
+	result, err := MergeWorkspaces(ctx, target.WorkspaceID, source.WorkspaceID)
+	require.NoError(t, err)
+	assert.Empty(t, result.Conflicts)
+
+	params, err := loadDirectory(ctx, types.CatalogObjectTypeParameterSchema, target.ParametersDir)
+	require.NoError(t, err)
+	assert.Equal(t, models.Directory{"/a": ref("a2"), "/b": ref("b3"), "/d": ref("d3")}, params)
+	collectionSchemas, err := loadDirectory(ctx, types.CatalogObjectTypeCollectionSchema, target.CollectionsDir)
+	require.NoError(t, err)
+	assert.Equal(t, models.Directory{"/s": ref("s1", "y")}, collectionSchemas)
+
+	// the source is not modified
+	params, err = loadDirectory(ctx, types.CatalogObjectTypeParameterSchema, source.ParametersDir)
+	require.NoError(t, err)
+	assert.Equal(t, sourceParams, params)
+
+	// a second source that also changes /a conflicts with the target, which is left untouched
+	conflicting := newTestWorkspace(t, ctx, variantID)
+	setTestDirectories(t, ctx, workspaceDirectories(conflicting),
+		models.Directory{"/a": ref("a4"), "/b": ref("b1"), "/c": ref("c1")},
+		models.Directory{"/s": ref("s1", "x")})
+
+	result, err = MergeWorkspaces(ctx, target.WorkspaceID, conflicting.WorkspaceID)
+	require.NoError(t, err)
+	assert.Equal(t, []ObjectConflict{
+		{
+			Kind:   types.ParameterSchemaKind,
+			Path:   "/a",
+			Ours:   ObjectChange{Action: ChangeModified, Hash: "a4"},
+			Theirs: ObjectChange{Action: ChangeModified, Hash: "a2"},
+		},
+	}, result.Conflicts)
+	params, err = loadDirectory(ctx, types.CatalogObjectTypeParameterSchema, target.ParametersDir)
+	require.NoError(t, err)
+	assert.Equal(t, models.Directory{"/a": ref("a2"), "/b": ref("b3"), "/d": ref("d3")}, params)
+
+	_, err = MergeWorkspaces(ctx, target.WorkspaceID, target.WorkspaceID)
+	assert.ErrorIs(t, err, ErrInvalidWorkspace)
+	_, err = MergeWorkspaces(ctx, target.WorkspaceID, uuid.New())
+	assert.ErrorIs(t, err, ErrWorkspaceNotFound)
+}
+
+func setTestDirectories(t *testing.T, ctx context.Context, dirs Directories, params, collectionSchemas models.Directory) {
+	t.Helper()
+	dirMap := map[types.CatalogObjectType]models.Directory{
+		types.CatalogObjectTypeParameterSchema:   params,
+		types.CatalogObjectTypeCollectionSchema:  collectionSchemas,
+		types.CatalogObjectTypeCatalogCollection: {},
+	}
+	for typ, d := range dirMap {
+		require.NoError(t, storeDirectory(ctx, typ, dirs.DirForType(typ), d))
+	}
+}
+
+func newTestWorkspace(t *testing.T, ctx context.Context, variantID uuid.UUID) *models.Workspace {
+	t.Helper()
+	ws := &models.Workspace{
+		Info:        pgtype.JSONB{Status: pgtype.Null},
+		BaseVersion: 1,
+		VariantID:   variantID,
+	}
+	require.NoError(t, db.DB(ctx).CreateWorkspace(ctx, ws))
+	return ws
+}
+
+func testObjectRef(hash string, refs ...string) models.ObjectRef {
+	r := models.ObjectRef{Hash: hash, References: models.References{}}
+	for _, name := range refs {
+		r.References = append(r.References, models.Reference{Name: name})
+	}
+	return r
+}
@@ -0,0 +1,288 @@
+package catalogmanager
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"sort"
+	"strconv"
+
+	"github.com/google/uuid"
+	"github.com/mugiliam/common/apperrors"
+	"github.com/mugiliam/hatchcatalogsrv/internal/db"
+	"github.com/mugiliam/hatchcatalogsrv/internal/db/dberror"
+	"github.com/mugiliam/hatchcatalogsrv/internal/db/models"
+	"github.com/mugiliam/hatchcatalogsrv/pkg/types"
+	"github.com/rs/zerolog/log"
+)
+
+// directoryTypes are the object directories that make up a workspace or a version, in the order they are
+// diffed and written.
+var directoryTypes = []types.CatalogObjectType{
+	types.CatalogObjectTypeParameterSchema,
+	types.CatalogObjectTypeCollectionSchema,
+	types.CatalogObjectTypeCatalogCollection,
+}
+
+type ChangeAction string
+
+const (
+	ChangeAdded    ChangeAction = "added"
+	ChangeModified ChangeAction = "modified"
+	ChangeDeleted  ChangeAction = "deleted"
+)
+
+// ObjectChange describes how one side changed an object relative to the common base
+type ObjectChange struct {
+	Action ChangeAction `json:"action"`
+	Hash   string       `json:"hash,omitempty"`
+}
+
+// ObjectConflict is an object at Path that was changed differently on both sides of a rebase or merge.
+// Ours is the change being applied, Theirs is the change already present in the target.
+type ObjectConflict struct {
+	Kind   string       `json:"kind"`
+	Path   string       `json:"path"`
+	Ours   ObjectChange `json:"ours"`
+	Theirs ObjectChange `json:"theirs"`
+}
+
+// pathChange is the state of a directory entry before and after a change. A nil ref means the entry
+// does not exist on that side.
+type pathChange struct {
+	base   *models.ObjectRef
+	result *models.ObjectRef
+}
+
+func (c pathChange) action() ChangeAction {
+	switch {
+	case c.base == nil:
+		return ChangeAdded
+	case c.result == nil:
+		return ChangeDeleted
+	default:
+		return ChangeModified
+	}
+}
+
+func (c pathChange) summary() ObjectChange {
+	oc := ObjectChange{Action: c.action()}
+	if c.result != nil {
+		oc.Hash = c.result.Hash
+	}
+	return oc
+}
+
+// changesContent is false for changes that only touch the references of an object. Those are merged
+// rather than treated as conflicting.
+func (c pathChange) changesContent() bool {
+	return !sameContent(c.base, c.result)
+}
+
+type directoryChanges map[string]pathChange
+
+func sameContent(a, b *models.ObjectRef) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Hash == b.Hash && a.BaseSchema == b.BaseSchema &&
+		a.Description == b.Description && a.Deprecated == b.Deprecated && a.DeprecationMessage == b.DeprecationMessage &&
+		maps.Equal(a.Units, b.Units) && a.ValuesExtends == b.ValuesExtends
+}
+
+func sameReferences(a, b models.References) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for _, r := range a {
+		if !b.Contains(r.Name) {
+			return false
+		}
+	}
+	return true
+}
+
+// diffDirectory returns the entries of other that differ from base
+func diffDirectory(base, other models.Directory) directoryChanges {
+	changes := make(directoryChanges)
+	for p, b := range base {
+		b := b
+		o, ok := other[p]
+		if !ok {
+			changes[p] = pathChange{base: &b}
+			continue
+		}
+		if sameContent(&b, &o) && sameReferences(b.References, o.References) {
+			continue
+		}
+		changes[p] = pathChange{base: &b, result: &o}
+	}
+	for p, o := range other {
+		o := o
+		if _, ok := base[p]; !ok {
+			changes[p] = pathChange{result: &o}
+		}
+	}
+	return changes
+}
+
+// applyDirectoryChanges applies changes onto dir, which already contains concurrent. Both sets of changes
+// must be relative to the same base. A path whose content was changed on both sides to a different result,
+// or deleted on one side and changed on the other, is reported as a conflict and left as it is in dir.
+// Reference-only changes are merged with whatever is in dir.
+func applyDirectoryChanges(t types.CatalogObjectType, dir models.Directory, changes, concurrent directoryChanges) []ObjectConflict {
+	var conflicts []ObjectConflict
+	paths := make([]string, 0, len(changes))
+	for p := range changes {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+
+	for _, p := range paths {
+		c := changes[p]
+		if cc, ok := concurrent[p]; ok {
+			conflicting := false
+			switch {
+			case c.changesContent() && cc.changesContent():
+				conflicting = !sameContent(c.result, cc.result)
+			case c.result == nil || cc.result == nil:
+				// a deletion on one side against a reference change on the other
+				conflicting = c.result != cc.result
+			}
+			if conflicting {
+				conflicts = append(conflicts, ObjectConflict{
+					Kind:   types.Kind(t),
+					Path:   p,
+					Ours:   c.summary(),
+					Theirs: cc.summary(),
+				})
+				continue
+			}
+		}
+		if c.result == nil {
+			delete(dir, p)
+			continue
+		}
+		entry := *c.result
+		current, ok := dir[p]
+		if ok && !c.changesContent() {
+			// keep the content in dir, which may have been changed concurrently
+			entry = current
+		}
+		if ok {
+			var baseRefs models.References
+			if c.base != nil {
+				baseRefs = c.base.References
+			}
+			entry.References = mergeReferences(current.References, baseRefs, c.result.References)
+		}
+		dir[p] = entry
+	}
+	return conflicts
+}
+
+// mergeReferences applies the references added and removed between base and result onto current
+func mergeReferences(current, base, result models.References) models.References {
+	merged := make(models.References, 0, len(current)+len(result))
+	for _, r := range current {
+		if base.Contains(r.Name) && !result.Contains(r.Name) {
+			continue
+		}
+		merged = append(merged, r)
+	}
+	for _, r := range result {
+		if !base.Contains(r.Name) && !merged.Contains(r.Name) {
+			merged = append(merged, r)
+		}
+	}
+	return merged
+}
+
+func loadDirectory(ctx context.Context, t types.CatalogObjectType, id uuid.UUID) (models.Directory, apperrors.Error) {
+	b, err := db.ReadDB(ctx).GetDirectory(ctx, t, id)
+	if err != nil {
+		return nil, ErrCatalogError.Err(err)
+	}
+	dir, jsonErr := models.JSONToDirectory(b)
+	if jsonErr != nil {
+		log.Ctx(ctx).Error().Err(jsonErr).Msg("failed to unmarshal directory")
+		return nil, ErrCatalogError.Msg("unable to read directory")
+	}
+	if dir == nil {
+		dir = models.Directory{}
+	}
+	return dir, nil
+}
+
+func storeDirectory(ctx context.Context, t types.CatalogObjectType, id uuid.UUID, dir models.Directory) apperrors.Error {
+	b, jsonErr := models.DirectoryToJSON(dir)
+	if jsonErr != nil {
+		log.Ctx(ctx).Error().Err(jsonErr).Msg("failed to marshal directory")
+		return ErrCatalogError.Msg("unable to write directory")
+	}
+	if err := db.DB(ctx).SetDirectory(ctx, t, id, b); err != nil {
+		return ErrCatalogError.Err(err)
+	}
+	return nil
+}
+
+func getDirectoriesForVersion(ctx context.Context, variantID uuid.UUID, versionNum int) (Directories, apperrors.Error) {
+	var dir Directories
+	v, err := db.ReadDB(ctx).GetVersion(ctx, versionNum, variantID)
+	if err != nil {
+		if errors.Is(err, dberror.ErrNotFound) {
+			return dir, ErrVersionNotFound
+		}
+		return dir, ErrCatalogError.Err(err)
+	}
+	dir.ParametersDir = v.ParametersDir
+	dir.CollectionsDir = v.CollectionsDir
+	dir.ValuesDir = v.ValuesDir
+	dir.VariantID = variantID
+	return dir, nil
+}
+
+// getDirectoriesForRead returns the directories that a read of the object named by reqCtx is served from. With a
+// version query parameter, they are the directories of that committed version of the variant, so the object is
+// read as it was at that version. Otherwise they are the directories of the workspace or the variant.
+func getDirectoriesForRead(ctx context.Context, reqCtx RequestContext) (Directories, apperrors.Error) {
+	if reqCtx.WorkspaceID == uuid.Nil && reqCtx.VariantID == uuid.Nil {
+		return Directories{}, ErrInvalidVersionOrWorkspace
+	}
+	version := reqCtx.QueryParams.Get("version")
+	if version == "" {
+		if reqCtx.WorkspaceID != uuid.Nil {
+			return getDirectoriesForWorkspace(ctx, reqCtx.WorkspaceID)
+		}
+		return getDirectoriesForVariant(ctx, reqCtx.VariantID)
+	}
+	versionNum, convErr := strconv.Atoi(version)
+	if convErr != nil || versionNum < 1 {
+		return Directories{}, ErrInvalidVersion.Msg("version must be a version number")
+	}
+
+	// versions belong to the variant, so a version is read from the variant of the workspace
+	variantID := reqCtx.VariantID
+	if variantID == uuid.Nil && reqCtx.WorkspaceID != uuid.Nil {
+		wm, err := LoadWorkspaceManagerByID(ctx, reqCtx.WorkspaceID)
+		if err != nil {
+			return Directories{}, err
+		}
+		variantID = wm.VariantID()
+	}
+	dir, err := getDirectoriesForVersion(ctx, variantID, versionNum)
+	if errors.Is(err, ErrVersionNotFound) {
+		return dir, ErrInvalidVersion.Msg("version " + version + " does not exist")
+	}
+	return dir, err
+}
+
+func workspaceDirectories(w *models.Workspace) Directories {
+	return Directories{
+		ParametersDir:  w.ParametersDir,
+		CollectionsDir: w.CollectionsDir,
+		ValuesDir:      w.ValuesDir,
+		WorkspaceID:    w.WorkspaceID,
+		VariantID:      w.VariantID,
+	}
+}
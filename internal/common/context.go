@@ -44,6 +44,24 @@ func ProjectIdFromContext(ctx context.Context) types.ProjectId {
 	return ""
 }
 
+// ctxRequestIdKeyType represents the key type for the request ID in the context.
+type ctxRequestIdKeyType string
+
+const ctxRequestIdKey ctxRequestIdKeyType = "HatchCatalogRequestId"
+
+// SetRequestIdInContext sets the request ID in the provided context.
+func SetRequestIdInContext(ctx context.Context, requestId string) context.Context {
+	return context.WithValue(ctx, ctxRequestIdKey, requestId)
+}
+
+// RequestIdFromContext retrieves the request ID from the provided context.
+func RequestIdFromContext(ctx context.Context) string {
+	if requestId, ok := ctx.Value(ctxRequestIdKey).(string); ok {
+		return requestId
+	}
+	return ""
+}
+
 type ctxCatalogContextKeyType string
 
 const ctxCatalogContextKey ctxCatalogContextKeyType = "HatchCatalogContext"
@@ -4,53 +4,284 @@ import (
 	"fmt"
 	"os"
 	"strconv"
+	"sync"
 	"time"
 
 	"github.com/BurntSushi/toml"
 )
 
 type ConfigParam struct {
-	ServerPort               string `toml:"server_port"`
-	EndpointPort             string `toml:"endpoint_port"`
-	HandleCORS               bool   `toml:"handle_cors"`
-	ClientConfig             string `toml:"client_config"`
-	InternalCA               string `toml:"internal_ca"`
-	InternalServerCert       string `toml:"internal_server_cert"`
-	InternalServerPrivateKey string `toml:"internal_server_private_key"`
-	IDTokenValidity          int    `toml:"id_token_validity"`
-	APITokenValidity         string `toml:"api_token_validity"`
+	ServerPort               string          `toml:"server_port"`
+	EndpointPort             string          `toml:"endpoint_port"`
+	HandleCORS               bool            `toml:"handle_cors"`
+	ClientConfig             string          `toml:"client_config"`
+	InternalCA               string          `toml:"internal_ca"`
+	InternalServerCert       string          `toml:"internal_server_cert"`
+	InternalServerPrivateKey string          `toml:"internal_server_private_key"`
+	IDTokenValidity          int             `toml:"id_token_validity"`
+	APITokenValidity         string          `toml:"api_token_validity"`
+	WebhookURLs              []string        `toml:"webhook_urls"`
+	WebhookSecret            string          `toml:"webhook_secret"`
+	WebhookMaxRetries        *int            `toml:"webhook_max_retries"` // defaults to 3 when not set, 0 turns retries off
+	ShutdownTimeout          int             `toml:"shutdown_timeout"`    // seconds to drain in-flight requests on shutdown
+	RequestTimeout           int             `toml:"request_timeout"`     // seconds a request may run before it is cancelled
+	ExportTimeout            int             `toml:"export_timeout"`      // seconds a streamed response, such as an export, may run
+	RateLimit                RateLimitConfig `toml:"rate_limit"`
+	LogLevel                 string          `toml:"log_level"` // zerolog level name, e.g. "debug". Unchanged when empty
+	Tracing                  TracingConfig   `toml:"tracing"`
+	ReadReplicaDSN           string          `toml:"read_replica_dsn"` // read-only replica for GET requests. Reads use the primary when empty
+	BlobStore                BlobStoreConfig `toml:"blob_store"`
+	Quotas                   QuotaConfig     `toml:"quotas"`
+	VerifyCatalogObjects     bool            `toml:"verify_catalog_objects"` // compare a catalog object that is already stored with the one being written
+	MaxImportObjectSize      int64           `toml:"max_import_object_size"` // bytes of a single manifest in an import archive
+	MaxImportSize            int64           `toml:"max_import_size"`        // bytes of an import archive once decompressed
+	ImportConcurrency        int             `toml:"import_concurrency"`     // manifests of an import applied at the same time, defaults to DefaultImportConcurrency
+	ValidationCacheSize      *int            `toml:"validation_cache_size"`  // successful validations remembered, defaults to DefaultValidationCacheSize, 0 turns the cache off
+	AllowDirectWrites        bool            `toml:"allow_direct_writes"`    // allow ?direct=true writes to a variant that bypass workspaces
+	DefaultNamespace         string          `toml:"default_namespace"`      // name of the root namespace, defaults to types.DefaultNamespace. Read at startup only
+	SecretKey                string          `toml:"secret_key"`             // base64 AES-256 key that sensitive values are encrypted with. Sensitive parameters are rejected when empty. Read at startup only
+	AllowReveal              bool            `toml:"allow_reveal"`           // honour ?reveal=true on reads of collections, which returns sensitive values in plaintext
 }
 
-var cfg *ConfigParam
+// QuotaConfig configures the default limits on what a tenant may store. A limit of 0 is unlimited. Limits can
+// be overridden for a tenant in the tenant_quotas table.
+type QuotaConfig struct {
+	MaxCatalogsPerProject int   `toml:"max_catalogs_per_project"`
+	MaxObjectsPerVariant  int   `toml:"max_objects_per_variant"` // parameter schemas, collection schemas and collections
+	MaxBytesPerTenant     int64 `toml:"max_bytes_per_tenant"`    // stored size of the catalog objects of the tenant
+}
+
+// BlobStoreConfig configures where the data of large catalog objects is stored. With no type, or "postgres",
+// all objects are stored in the database.
+type BlobStoreConfig struct {
+	Type            string   `toml:"type"`             // "postgres" or "s3"
+	InlineThreshold int      `toml:"inline_threshold"` // objects up to this many bytes stay in the database, defaults to DefaultBlobInlineThreshold
+	S3              S3Config `toml:"s3"`
+}
+
+// S3Config configures an S3 compatible blob store
+type S3Config struct {
+	Endpoint        string `toml:"endpoint"` // e.g. "https://s3.us-east-1.amazonaws.com"
+	Region          string `toml:"region"`
+	Bucket          string `toml:"bucket"`
+	Prefix          string `toml:"prefix"` // prepended to the key of every object
+	AccessKeyID     string `toml:"access_key_id"`
+	SecretAccessKey string `toml:"secret_access_key"`
+}
+
+// DefaultBlobInlineThreshold is used when blob_store.inline_threshold is not configured
+const DefaultBlobInlineThreshold = 256 * 1024
+
+// TracingConfig configures OpenTelemetry tracing. Tracing is off when Endpoint is empty.
+type TracingConfig struct {
+	Endpoint    string  `toml:"endpoint"`     // OTLP/HTTP collector URL, e.g. "http://localhost:4318"
+	SampleRate  float64 `toml:"sample_rate"`  // fraction of traces sampled, defaults to 1
+	ServiceName string  `toml:"service_name"` // defaults to "hatchcatalogsrv"
+}
+
+// RateLimitConfig configures the per-tenant request rate limit. Rate limiting is off when RequestsPerSecond is 0.
+type RateLimitConfig struct {
+	RequestsPerSecond float64 `toml:"requests_per_second"`
+	Burst             int     `toml:"burst"`       // defaults to RequestsPerSecond rounded up
+	MaxTenants        int     `toml:"max_tenants"` // tenants tracked at once, defaults to DefaultRateLimitMaxTenants
+}
+
+// DefaultRateLimitMaxTenants is used when rate_limit.max_tenants is not configured
+const DefaultRateLimitMaxTenants = 10000
+
+// DefaultShutdownTimeout is used when shutdown_timeout is not configured
+const DefaultShutdownTimeout = 30 * time.Second
+
+// ShutdownTimeoutDuration returns the configured shutdown timeout or the default
+func (c *ConfigParam) ShutdownTimeoutDuration() time.Duration {
+	if c.ShutdownTimeout <= 0 {
+		return DefaultShutdownTimeout
+	}
+	return time.Duration(c.ShutdownTimeout) * time.Second
+}
+
+// DefaultRequestTimeout is used when request_timeout is not configured
+const DefaultRequestTimeout = 60 * time.Second
+
+// RequestTimeoutDuration returns the configured request timeout or the default
+func (c *ConfigParam) RequestTimeoutDuration() time.Duration {
+	if c.RequestTimeout <= 0 {
+		return DefaultRequestTimeout
+	}
+	return time.Duration(c.RequestTimeout) * time.Second
+}
+
+// DefaultExportTimeout is used when export_timeout is not configured
+const DefaultExportTimeout = 10 * time.Minute
+
+// ExportTimeoutDuration returns the configured timeout of streamed responses or the default
+func (c *ConfigParam) ExportTimeoutDuration() time.Duration {
+	if c.ExportTimeout <= 0 {
+		return DefaultExportTimeout
+	}
+	return time.Duration(c.ExportTimeout) * time.Second
+}
+
+// DefaultMaxImportObjectSize is used when max_import_object_size is not configured
+const DefaultMaxImportObjectSize = 4 * 1024 * 1024
+
+// DefaultMaxImportSize is used when max_import_size is not configured
+const DefaultMaxImportSize = 128 * 1024 * 1024
+
+// ImportLimits returns the configured limits on the size of a manifest in an import archive and of the
+// decompressed archive, or their defaults
+func (c *ConfigParam) ImportLimits() (objectSize, archiveSize int64) {
+	objectSize, archiveSize = c.MaxImportObjectSize, c.MaxImportSize
+	if objectSize <= 0 {
+		objectSize = DefaultMaxImportObjectSize
+	}
+	if archiveSize <= 0 {
+		archiveSize = DefaultMaxImportSize
+	}
+	return objectSize, archiveSize
+}
+
+// DefaultImportConcurrency is used when import_concurrency is not configured
+const DefaultImportConcurrency = 4
+
+// ImportWorkers returns the number of manifests of an import that are applied at the same time. Each takes a
+// database connection of its own.
+func (c *ConfigParam) ImportWorkers() int {
+	if c.ImportConcurrency <= 0 {
+		return DefaultImportConcurrency
+	}
+	return c.ImportConcurrency
+}
+
+// DefaultValidationCacheSize is used when validation_cache_size is not configured
+const DefaultValidationCacheSize = 10000
 
+// ValidationCacheEntries returns the number of successful validations of values to remember. 0 means that
+// validations are not cached.
+func (c *ConfigParam) ValidationCacheEntries() int {
+	if c.ValidationCacheSize == nil {
+		return DefaultValidationCacheSize
+	}
+	return max(*c.ValidationCacheSize, 0)
+}
+
+var (
+	cfgMu         sync.RWMutex
+	cfg           *ConfigParam
+	reloadHandler []func(*ConfigParam)
+)
+
+// Config returns the current config. The returned value is never modified, a reload replaces it.
 func Config() *ConfigParam {
+	cfgMu.RLock()
+	defer cfgMu.RUnlock()
 	return cfg
 }
 
+// OnReload registers f to be called with the new config after every successful ReloadConfig
+func OnReload(f func(*ConfigParam)) {
+	cfgMu.Lock()
+	defer cfgMu.Unlock()
+	reloadHandler = append(reloadHandler, f)
+}
+
 //TODO - Check all config value usage and assign defaults
 
 func LoadConfig(filename string) error {
+	cp, err := readConfig(filename)
+	if err != nil {
+		return err
+	}
+	// assign config to global cfg
+	cfgMu.Lock()
+	cfg = cp
+	cfgMu.Unlock()
+	return nil
+}
+
+// ReloadConfig re-reads the config file and replaces the current config with it. Settings that are only
+// used at startup keep their current value, and their names are returned so the caller can warn about them.
+// The current config is left in place if the file cannot be read.
+func ReloadConfig(filename string) ([]string, error) {
+	cp, err := readConfig(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	cfgMu.Lock()
+	ignored := keepImmutableSettings(cfg, cp)
+	cfg = cp
+	handlers := reloadHandler
+	cfgMu.Unlock()
+
+	for _, h := range handlers {
+		h(cp)
+	}
+	return ignored, nil
+}
+
+// keepImmutableSettings copies the settings that cannot change without a restart from prev to next, and returns
+// the names of those that were changed in next.
+func keepImmutableSettings(prev, next *ConfigParam) []string {
+	var ignored []string
+	if prev == nil {
+		return ignored
+	}
+	if next.ServerPort != prev.ServerPort {
+		ignored = append(ignored, "server_port")
+		next.ServerPort = prev.ServerPort
+	}
+	if next.EndpointPort != prev.EndpointPort {
+		ignored = append(ignored, "endpoint_port")
+		next.EndpointPort = prev.EndpointPort
+	}
+	if next.HandleCORS != prev.HandleCORS {
+		ignored = append(ignored, "handle_cors")
+		next.HandleCORS = prev.HandleCORS
+	}
+	if next.ReadReplicaDSN != prev.ReadReplicaDSN {
+		ignored = append(ignored, "read_replica_dsn")
+		next.ReadReplicaDSN = prev.ReadReplicaDSN
+	}
+	if next.BlobStore != prev.BlobStore {
+		ignored = append(ignored, "blob_store")
+		next.BlobStore = prev.BlobStore
+	}
+	if next.Tracing != prev.Tracing {
+		ignored = append(ignored, "tracing")
+		next.Tracing = prev.Tracing
+	}
+	if next.SecretKey != prev.SecretKey {
+		ignored = append(ignored, "secret_key")
+		next.SecretKey = prev.SecretKey
+	}
+	// the name of the root namespace is part of the storage path of its objects
+	if next.DefaultNamespace != prev.DefaultNamespace {
+		ignored = append(ignored, "default_namespace")
+		next.DefaultNamespace = prev.DefaultNamespace
+	}
+	return ignored
+}
+
+func readConfig(filename string) (*ConfigParam, error) {
 	if filename == "" {
-		cfg = &ConfigParam{
+		return &ConfigParam{
 			ServerPort:   "8194",
 			EndpointPort: "9002",
 			HandleCORS:   true,
-		}
-		return nil
+		}, nil
 	}
 	// Read the config file
 	content, err := os.ReadFile(filename)
 	if err != nil {
-		return fmt.Errorf("error reading config file: %v", err)
+		return nil, fmt.Errorf("error reading config file: %v", err)
 	}
 	// Parse the config file
 	var cp ConfigParam
 	if _, err := toml.Decode(string(content), &cp); err != nil {
-		return fmt.Errorf("error parsing config file: %v", err)
+		return nil, fmt.Errorf("error parsing config file: %v", err)
 	}
-	// assign config to global cfg
-	cfg = &cp
-	return nil
+	return &cp, nil
 }
 
 func ParseTokenDuration(input string) (time.Duration, error) {
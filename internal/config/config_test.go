@@ -0,0 +1,62 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReloadConfig(t *testing.T) {
+	prev := Config()
+	t.Cleanup(func() {
+		cfgMu.Lock()
+		cfg = prev
+		reloadHandler = nil
+		cfgMu.Unlock()
+	})
+
+	file := filepath.Join(t.TempDir(), "config.toml")
+	write := func(content string) {
+		require.NoError(t, os.WriteFile(file, []byte(content), 0o600))
+	}
+
+	write(`
+server_port = "8194"
+log_level = "info"
+default_namespace = "main"
+[rate_limit]
+requests_per_second = 10
+`)
+	require.NoError(t, LoadConfig(file))
+
+	var reloaded *ConfigParam
+	OnReload(func(c *ConfigParam) {
+		reloaded = c
+	})
+
+	write(`
+server_port = "9999"
+log_level = "debug"
+default_namespace = "other"
+[rate_limit]
+requests_per_second = 20
+`)
+	ignored, err := ReloadConfig(file)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"server_port", "default_namespace"}, ignored)
+	assert.Equal(t, "8194", Config().ServerPort)
+	assert.Equal(t, "main", Config().DefaultNamespace)
+	assert.Equal(t, "debug", Config().LogLevel)
+	assert.Equal(t, float64(20), Config().RateLimit.RequestsPerSecond)
+	assert.Same(t, Config(), reloaded)
+
+	// a config that cannot be parsed leaves the current one in place
+	current := Config()
+	write(`server_port = `)
+	_, err = ReloadConfig(file)
+	assert.Error(t, err)
+	assert.Same(t, current, Config())
+}
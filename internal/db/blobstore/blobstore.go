@@ -0,0 +1,83 @@
+// Package blobstore stores the data of large catalog objects outside the database. Catalog objects are addressed
+// by their hash, so a blob is written once and never changes. Objects below the inline threshold, and all objects
+// when no blob store is configured, are stored in the catalog_objects table.
+package blobstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/mugiliam/hatchcatalogsrv/internal/config"
+	"github.com/mugiliam/hatchcatalogsrv/pkg/types"
+)
+
+// BlobStore stores the data of catalog objects by tenant and hash
+type BlobStore interface {
+	// Put stores data under the hash. Storing the same hash again overwrites it with the same data.
+	Put(ctx context.Context, tenantID types.TenantId, hash string, data []byte) error
+	// Get returns the data stored under the hash, or ErrNotFound
+	Get(ctx context.Context, tenantID types.TenantId, hash string) ([]byte, error)
+	// Delete removes the data stored under the hash. Deleting a hash that is not stored is not an error.
+	Delete(ctx context.Context, tenantID types.TenantId, hash string) error
+	// List calls fn with the hash of each blob of the tenant and the time it was last stored, stopping at the
+	// first error fn returns
+	List(ctx context.Context, tenantID types.TenantId, fn func(hash string, stored time.Time) error) error
+}
+
+// ErrNotFound is returned by Get when there is no blob for the hash
+var ErrNotFound = errors.New("blob not found")
+
+var (
+	mu              sync.RWMutex
+	store           BlobStore
+	inlineThreshold = config.DefaultBlobInlineThreshold
+)
+
+// Init selects the blob store configured in c
+func Init(c config.BlobStoreConfig) error {
+	switch c.Type {
+	case "", "postgres":
+		Set(nil, c.InlineThreshold)
+	case "s3":
+		s, err := NewS3Store(c.S3)
+		if err != nil {
+			return err
+		}
+		Set(s, c.InlineThreshold)
+	default:
+		return fmt.Errorf("unknown blob store type %q", c.Type)
+	}
+	return nil
+}
+
+// Set makes s the blob store for objects larger than threshold bytes. With a nil s, all objects are stored in
+// the database. A threshold of 0 or less selects the default.
+func Set(s BlobStore, threshold int) {
+	if threshold <= 0 {
+		threshold = config.DefaultBlobInlineThreshold
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	store = s
+	inlineThreshold = threshold
+}
+
+// ForSize returns the blob store for an object of size bytes, or nil if the object is stored in the database
+func ForSize(size int) BlobStore {
+	mu.RLock()
+	defer mu.RUnlock()
+	if store == nil || size <= inlineThreshold {
+		return nil
+	}
+	return store
+}
+
+// Current returns the configured blob store, or nil if there is none
+func Current() BlobStore {
+	mu.RLock()
+	defer mu.RUnlock()
+	return store
+}
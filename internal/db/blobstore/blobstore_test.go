@@ -0,0 +1,135 @@
+package blobstore
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mugiliam/hatchcatalogsrv/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestForSize(t *testing.T) {
+	defer Set(nil, 0)
+
+	assert.Nil(t, ForSize(10<<20), "without a blob store everything stays in the database")
+
+	s := &s3Store{}
+	Set(s, 100)
+	assert.Nil(t, ForSize(100))
+	assert.Equal(t, BlobStore(s), ForSize(101))
+	assert.Equal(t, BlobStore(s), Current())
+
+	Set(s, 0)
+	assert.Nil(t, ForSize(config.DefaultBlobInlineThreshold))
+	assert.NotNil(t, ForSize(config.DefaultBlobInlineThreshold+1))
+
+	assert.Error(t, Init(config.BlobStoreConfig{Type: "ftp"}))
+	assert.Error(t, Init(config.BlobStoreConfig{Type: "s3"}), "s3 needs a bucket and credentials")
+}
+
+func TestS3Store(t *testing.T) {
+	var mu sync.Mutex
+	objects := make(map[string][]byte)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth := r.Header.Get("Authorization")
+		if !strings.HasPrefix(auth, "AWS4-HMAC-SHA256 Credential=AKID/20240102/us-test-1/s3/aws4_request, SignedHeaders=host;x-amz-content-sha256;x-amz-date, Signature=") ||
+			r.Header.Get("x-amz-date") != "20240102T030405Z" || r.Header.Get("x-amz-content-sha256") == "" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		switch r.Method {
+		case http.MethodPut:
+			b, _ := io.ReadAll(r.Body)
+			if sha256Hex(b) != r.Header.Get("x-amz-content-sha256") {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			objects[r.URL.Path] = b
+		case http.MethodGet:
+			if r.URL.Query().Get("list-type") == "2" {
+				// one key per page, to exercise the continuation token
+				prefix := r.URL.Query().Get("prefix")
+				var keys []string
+				for k := range objects {
+					if key := strings.TrimPrefix(k, r.URL.Path+"/"); strings.HasPrefix(key, prefix) {
+						keys = append(keys, key)
+					}
+				}
+				sort.Strings(keys)
+				start := 0
+				if token := r.URL.Query().Get("continuation-token"); token != "" {
+					start, _ = strconv.Atoi(token)
+				}
+				_, _ = io.WriteString(w, "<ListBucketResult>")
+				if start < len(keys) {
+					fmt.Fprintf(w, "<Contents><Key>%s</Key><LastModified>2024-01-02T03:04:05.000Z</LastModified></Contents>", keys[start])
+				}
+				if start+1 < len(keys) {
+					fmt.Fprintf(w, "<IsTruncated>true</IsTruncated><NextContinuationToken>%d</NextContinuationToken>", start+1)
+				}
+				_, _ = io.WriteString(w, "</ListBucketResult>")
+				return
+			}
+			b, ok := objects[r.URL.Path]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			_, _ = w.Write(b)
+		case http.MethodDelete:
+			delete(objects, r.URL.Path)
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	defer srv.Close()
+
+	bs, err := NewS3Store(config.S3Config{
+		Endpoint:        srv.URL,
+		Region:          "us-test-1",
+		Bucket:          "catalog",
+		Prefix:          "objects",
+		AccessKeyID:     "AKID",
+		SecretAccessKey: "secret",
+	})
+	require.NoError(t, err)
+	bs.(*s3Store).now = func() time.Time { return time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC) }
+
+	ctx := context.Background()
+	require.NoError(t, bs.Put(ctx, "TABCDE", "abc123", []byte("large object")))
+	assert.Contains(t, objects, "/catalog/objects/TABCDE/abc123")
+
+	data, err := bs.Get(ctx, "TABCDE", "abc123")
+	require.NoError(t, err)
+	assert.Equal(t, "large object", string(data))
+
+	// blobs are kept apart by tenant
+	_, err = bs.Get(ctx, "TOTHER", "abc123")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	require.NoError(t, bs.Put(ctx, "TABCDE", "def456", []byte("another object")))
+	require.NoError(t, bs.Put(ctx, "TOTHER", "fff000", []byte("other tenant")))
+	var hashes []string
+	require.NoError(t, bs.List(ctx, "TABCDE", func(hash string, stored time.Time) error {
+		assert.Equal(t, time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC), stored)
+		hashes = append(hashes, hash)
+		return nil
+	}))
+	assert.Equal(t, []string{"abc123", "def456"}, hashes)
+
+	require.NoError(t, bs.Delete(ctx, "TABCDE", "abc123"))
+	_, err = bs.Get(ctx, "TABCDE", "abc123")
+	assert.ErrorIs(t, err, ErrNotFound)
+	assert.NoError(t, bs.Delete(ctx, "TABCDE", "abc123"), "deleting a missing blob is not an error")
+}
@@ -0,0 +1,214 @@
+package blobstore
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/mugiliam/hatchcatalogsrv/internal/config"
+	"github.com/mugiliam/hatchcatalogsrv/pkg/types"
+)
+
+// s3Store is a BlobStore on an S3 compatible object store. Requests use path style addressing and are signed
+// with AWS Signature Version 4.
+type s3Store struct {
+	cfg      config.S3Config
+	endpoint *url.URL
+	client   *http.Client
+	now      func() time.Time
+}
+
+// NewS3Store returns a BlobStore that stores blobs in the configured S3 bucket
+func NewS3Store(c config.S3Config) (BlobStore, error) {
+	if c.Endpoint == "" || c.Bucket == "" || c.Region == "" {
+		return nil, errors.New("s3 blob store requires an endpoint, region and bucket")
+	}
+	if c.AccessKeyID == "" || c.SecretAccessKey == "" {
+		return nil, errors.New("s3 blob store requires credentials")
+	}
+	u, err := url.Parse(c.Endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid s3 endpoint: %w", err)
+	}
+	return &s3Store{
+		cfg:      c,
+		endpoint: u,
+		client:   &http.Client{Timeout: 30 * time.Second},
+		now:      time.Now,
+	}, nil
+}
+
+func (s *s3Store) Put(ctx context.Context, tenantID types.TenantId, hash string, data []byte) error {
+	rsp, err := s.do(ctx, http.MethodPut, tenantID, hash, data)
+	if err != nil {
+		return err
+	}
+	defer rsp.Body.Close()
+	if rsp.StatusCode != http.StatusOK {
+		return s3Error(rsp)
+	}
+	return nil
+}
+
+func (s *s3Store) Get(ctx context.Context, tenantID types.TenantId, hash string) ([]byte, error) {
+	rsp, err := s.do(ctx, http.MethodGet, tenantID, hash, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer rsp.Body.Close()
+	switch rsp.StatusCode {
+	case http.StatusOK:
+		return io.ReadAll(rsp.Body)
+	case http.StatusNotFound:
+		return nil, ErrNotFound
+	}
+	return nil, s3Error(rsp)
+}
+
+func (s *s3Store) Delete(ctx context.Context, tenantID types.TenantId, hash string) error {
+	rsp, err := s.do(ctx, http.MethodDelete, tenantID, hash, nil)
+	if err != nil {
+		return err
+	}
+	defer rsp.Body.Close()
+	if rsp.StatusCode != http.StatusNoContent && rsp.StatusCode != http.StatusOK && rsp.StatusCode != http.StatusNotFound {
+		return s3Error(rsp)
+	}
+	return nil
+}
+
+// listBucketResult is the part of a ListObjectsV2 response that List uses
+type listBucketResult struct {
+	Contents []struct {
+		Key          string    `xml:"Key"`
+		LastModified time.Time `xml:"LastModified"`
+	} `xml:"Contents"`
+	IsTruncated           bool   `xml:"IsTruncated"`
+	NextContinuationToken string `xml:"NextContinuationToken"`
+}
+
+func (s *s3Store) List(ctx context.Context, tenantID types.TenantId, fn func(hash string, stored time.Time) error) error {
+	prefix := strings.TrimPrefix(path.Join(s.cfg.Prefix, string(tenantID)), "/") + "/"
+	token := ""
+	for {
+		q := url.Values{"list-type": {"2"}, "prefix": {prefix}}
+		if token != "" {
+			q.Set("continuation-token", token)
+		}
+		u := *s.endpoint
+		u.Path = path.Join(u.Path, "/"+s.cfg.Bucket)
+		u.RawQuery = q.Encode()
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+		if err != nil {
+			return err
+		}
+		s.sign(req, nil)
+		rsp, err := s.client.Do(req)
+		if err != nil {
+			return err
+		}
+		if rsp.StatusCode != http.StatusOK {
+			err := s3Error(rsp)
+			rsp.Body.Close()
+			return err
+		}
+		var result listBucketResult
+		err = xml.NewDecoder(rsp.Body).Decode(&result)
+		rsp.Body.Close()
+		if err != nil {
+			return fmt.Errorf("invalid s3 list response: %w", err)
+		}
+		for _, c := range result.Contents {
+			if err := fn(strings.TrimPrefix(c.Key, prefix), c.LastModified); err != nil {
+				return err
+			}
+		}
+		if !result.IsTruncated || result.NextContinuationToken == "" {
+			return nil
+		}
+		token = result.NextContinuationToken
+	}
+}
+
+// objectPath returns the path of the blob for the hash, relative to the endpoint
+func (s *s3Store) objectPath(tenantID types.TenantId, hash string) string {
+	return "/" + path.Join(s.cfg.Bucket, s.cfg.Prefix, string(tenantID), hash)
+}
+
+func (s *s3Store) do(ctx context.Context, method string, tenantID types.TenantId, hash string, body []byte) (*http.Response, error) {
+	u := *s.endpoint
+	u.Path = path.Join(u.Path, s.objectPath(tenantID, hash))
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.ContentLength = int64(len(body))
+	s.sign(req, body)
+	return s.client.Do(req)
+}
+
+// sign adds the AWS Signature Version 4 authorization header to req
+func (s *s3Store) sign(req *http.Request, body []byte) {
+	t := s.now().UTC()
+	amzDate := t.Format("20060102T150405Z")
+	date := t.Format("20060102")
+	payloadHash := sha256Hex(body)
+
+	req.Header.Set("x-amz-date", amzDate)
+	req.Header.Set("x-amz-content-sha256", payloadHash)
+
+	const signedHeaders = "host;x-amz-content-sha256;x-amz-date"
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		"host:" + req.URL.Host + "\n" +
+			"x-amz-content-sha256:" + payloadHash + "\n" +
+			"x-amz-date:" + amzDate + "\n",
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+	scope := date + "/" + s.cfg.Region + "/s3/aws4_request"
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		amzDate,
+		scope,
+		sha256Hex([]byte(canonicalRequest)),
+	}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+s.cfg.SecretAccessKey), date)
+	key = hmacSHA256(key, s.cfg.Region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", "AWS4-HMAC-SHA256 Credential="+s.cfg.AccessKeyID+"/"+scope+
+		", SignedHeaders="+signedHeaders+", Signature="+signature)
+}
+
+func sha256Hex(b []byte) string {
+	h := sha256.Sum256(b)
+	return hex.EncodeToString(h[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+func s3Error(rsp *http.Response) error {
+	msg, _ := io.ReadAll(io.LimitReader(rsp.Body, 1024))
+	return fmt.Errorf("s3 request failed with status %d: %s", rsp.StatusCode, strings.TrimSpace(string(msg)))
+}
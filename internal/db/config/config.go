@@ -34,3 +34,10 @@ func HatchCatalogDsn() string {
 }
 
 const CompressCatalogObjects = config.CompressCatalogObjects
+
+// VerifyCatalogObjects reports whether the data of a catalog object that is already stored is compared with the
+// data of a write of the same hash
+func VerifyCatalogObjects() bool {
+	cfg := config.Config()
+	return cfg != nil && cfg.VerifyCatalogObjects
+}
@@ -2,9 +2,11 @@ package db
 
 import (
 	"context"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/mugiliam/common/apperrors"
+	"github.com/mugiliam/hatchcatalogsrv/internal/db/dberror"
 	"github.com/mugiliam/hatchcatalogsrv/internal/db/dbmanager"
 	"github.com/mugiliam/hatchcatalogsrv/internal/db/models"
 	"github.com/mugiliam/hatchcatalogsrv/internal/db/postgresql"
@@ -25,12 +27,19 @@ type MetadataManager interface {
 	CreateProject(ctx context.Context, projectID types.ProjectId) error
 	GetProject(ctx context.Context, projectID types.ProjectId) (*models.Project, error)
 	DeleteProject(ctx context.Context, projectID types.ProjectId) error
+	ListProjects(ctx context.Context, tenantID types.TenantId) ([]*models.Project, error)
+	UpdateProject(ctx context.Context, project *models.Project) error
+	GetTenantQuota(ctx context.Context) (*models.TenantQuota, apperrors.Error)
+	SetTenantQuota(ctx context.Context, q *models.TenantQuota) apperrors.Error
+	GetTenantObjectBytes(ctx context.Context) (int64, apperrors.Error)
 	// Catalog
 	CreateCatalog(ctx context.Context, catalog *models.Catalog) apperrors.Error
 	GetCatalogIDByName(ctx context.Context, catalogName string) (uuid.UUID, apperrors.Error)
 	GetCatalog(ctx context.Context, catalogID uuid.UUID, name string) (*models.Catalog, apperrors.Error)
 	UpdateCatalog(ctx context.Context, catalog *models.Catalog) apperrors.Error
+	RenameCatalog(ctx context.Context, catalogID uuid.UUID, newName string) apperrors.Error
 	DeleteCatalog(ctx context.Context, catalogID uuid.UUID, name string) apperrors.Error
+	CountCatalogsInProject(ctx context.Context) (int, apperrors.Error)
 
 	// Variant
 	CreateVariant(ctx context.Context, variant *models.Variant) apperrors.Error
@@ -38,16 +47,20 @@ type MetadataManager interface {
 	GetVariantIDFromName(ctx context.Context, catalogID uuid.UUID, name string) (uuid.UUID, apperrors.Error)
 	UpdateVariant(ctx context.Context, variantID uuid.UUID, name string, updatedVariant *models.Variant) apperrors.Error
 	DeleteVariant(ctx context.Context, catalogID uuid.UUID, variantID uuid.UUID, name string) apperrors.Error
+	GetVariantStats(ctx context.Context, variantID uuid.UUID) (*models.VariantStats, apperrors.Error)
 
 	// Version
 	CreateVersion(ctx context.Context, version *models.Version) error
 	GetVersion(ctx context.Context, versionNum int, variantID uuid.UUID) (*models.Version, error)
 	GetVersionByLabel(ctx context.Context, label string, variantID uuid.UUID) (*models.Version, error)
+	GetLatestVersion(ctx context.Context, variantID uuid.UUID) (*models.Version, error)
 	SetVersionLabel(ctx context.Context, versionNum int, variantID uuid.UUID, newLabel string) error
+	ClearVersionLabel(ctx context.Context, label string, variantID uuid.UUID) error
 	UpdateVersionDescription(ctx context.Context, versionNum int, variantID uuid.UUID, newDescription string) error
 	DeleteVersion(ctx context.Context, versionNum int, variantID uuid.UUID) error
 	CountVersionsInVariant(ctx context.Context, variantID uuid.UUID) (int, error)
 	GetNamedVersions(ctx context.Context, variantID uuid.UUID) ([]models.Version, error)
+	ListVersions(ctx context.Context, variantID uuid.UUID) ([]models.Version, error)
 
 	// Workspace
 	CreateWorkspace(ctx context.Context, workspace *models.Workspace) apperrors.Error
@@ -55,7 +68,7 @@ type MetadataManager interface {
 	DeleteWorkspaceByLabel(ctx context.Context, variantID uuid.UUID, label string) apperrors.Error
 	GetWorkspace(ctx context.Context, workspaceID uuid.UUID) (*models.Workspace, apperrors.Error)
 	GetWorkspaceByLabel(ctx context.Context, variantID uuid.UUID, label string) (*models.Workspace, apperrors.Error)
-	UpdateWorkspaceLabel(ctx context.Context, workspaceID uuid.UUID, newLabel string) apperrors.Error
+	UpdateWorkspaceLabel(ctx context.Context, workspaceID uuid.UUID, expectedLabel, newLabel string) apperrors.Error
 	UpdateWorkspace(ctx context.Context, workspace *models.Workspace) apperrors.Error
 	GetCatalogForWorkspace(ctx context.Context, workspaceID uuid.UUID) (models.Catalog, apperrors.Error)
 	CommitWorkspace(ctx context.Context, workspace *models.Workspace) apperrors.Error
@@ -66,13 +79,22 @@ type MetadataManager interface {
 	UpdateNamespace(ctx context.Context, ns *models.Namespace) apperrors.Error
 	DeleteNamespace(ctx context.Context, name string, variantID uuid.UUID) apperrors.Error
 	ListNamespacesByVariant(ctx context.Context, variantID uuid.UUID) ([]*models.Namespace, apperrors.Error)
+
+	// Idempotency keys
+	CreateIdempotencyKey(ctx context.Context, k *models.IdempotencyKey, ttl time.Duration) apperrors.Error
+	GetIdempotencyKey(ctx context.Context, key string) (*models.IdempotencyKey, apperrors.Error)
+	UpdateIdempotencyKey(ctx context.Context, k *models.IdempotencyKey) apperrors.Error
+	DeleteIdempotencyKey(ctx context.Context, key string) apperrors.Error
 }
 
 type ObjectManager interface {
 	// Catalog Object
 	CreateCatalogObject(ctx context.Context, obj *models.CatalogObject) apperrors.Error
 	GetCatalogObject(ctx context.Context, hash string) (*models.CatalogObject, apperrors.Error)
-	DeleteCatalogObject(ctx context.Context, t types.CatalogObjectType, hash string) apperrors.Error
+	GetCatalogObjects(ctx context.Context, hashes []string) (map[string]*models.CatalogObject, apperrors.Error)
+	DeleteCatalogObject(ctx context.Context, hash string) apperrors.Error
+	DeleteUnreferencedCatalogObjects(ctx context.Context, olderThan time.Duration, limit int) (int, apperrors.Error)
+	DeleteOrphanedBlobs(ctx context.Context, olderThan time.Duration) (int, apperrors.Error)
 
 	//Collections
 	UpsertCollection(ctx context.Context, wc *models.Collection, dir uuid.UUID) (err apperrors.Error)
@@ -81,6 +103,9 @@ type ObjectManager interface {
 	UpdateCollection(ctx context.Context, wc *models.Collection, dir uuid.UUID) apperrors.Error
 	DeleteCollection(ctx context.Context, path string, dir uuid.UUID) (string, apperrors.Error)
 	HasReferencesToCollectionSchema(ctx context.Context, collectionSchema string, dir uuid.UUID) (bool, apperrors.Error)
+	ListCollectionHistory(ctx context.Context, path string, dir uuid.UUID) ([]models.CollectionHistoryEntry, apperrors.Error)
+	ListCollectionsByPathPrefix(ctx context.Context, prefix string, dir uuid.UUID, limit, offset int) ([]models.Collection, apperrors.Error)
+	ForEachCollectionByPathPrefix(ctx context.Context, prefix string, dir uuid.UUID, fn func(c models.Collection) apperrors.Error) apperrors.Error
 
 	// Schema Directory
 	CreateSchemaDirectory(ctx context.Context, t types.CatalogObjectType, dir *models.SchemaDirectory) apperrors.Error
@@ -89,14 +114,18 @@ type ObjectManager interface {
 	GetSchemaDirectory(ctx context.Context, t types.CatalogObjectType, directoryID uuid.UUID) (*models.SchemaDirectory, apperrors.Error)
 	GetObjectRefByPath(ctx context.Context, t types.CatalogObjectType, directoryID uuid.UUID, path string) (*models.ObjectRef, apperrors.Error)
 	LoadObjectByPath(ctx context.Context, t types.CatalogObjectType, directoryID uuid.UUID, path string) (*models.CatalogObject, apperrors.Error)
+	LoadObjectsByPaths(ctx context.Context, t types.CatalogObjectType, directoryID uuid.UUID, paths []string) (map[string]*models.CatalogObject, apperrors.Error)
 	UpdateObjectHashForPath(ctx context.Context, t types.CatalogObjectType, directoryID uuid.UUID, path string, hash string) apperrors.Error
 	AddOrUpdateObjectByPath(ctx context.Context, t types.CatalogObjectType, directoryID uuid.UUID, path string, obj models.ObjectRef) apperrors.Error
 	AddReferencesToObject(ctx context.Context, t types.CatalogObjectType, directoryID uuid.UUID, path string, references models.References) apperrors.Error
+	AddReferencesToObjects(ctx context.Context, t types.CatalogObjectType, directoryID uuid.UUID, paths []string, references models.References) apperrors.Error
 	GetAllReferences(ctx context.Context, t types.CatalogObjectType, directoryID uuid.UUID, path string) (models.References, apperrors.Error)
 	DeleteReferenceFromObject(ctx context.Context, t types.CatalogObjectType, directoryID uuid.UUID, path string, reference string) apperrors.Error
+	DeleteReferencesFromObjects(ctx context.Context, t types.CatalogObjectType, directoryID uuid.UUID, paths []string, reference string) apperrors.Error
 	DeleteObjectByPath(ctx context.Context, t types.CatalogObjectType, directoryID uuid.UUID, path string) (types.Hash, apperrors.Error)
 	FindClosestObject(ctx context.Context, t types.CatalogObjectType, directoryID uuid.UUID, targetName, startPath string) (string, *models.ObjectRef, apperrors.Error)
 	PathExists(ctx context.Context, t types.CatalogObjectType, directoryID uuid.UUID, path string) (bool, apperrors.Error)
+	PathsExist(ctx context.Context, t types.CatalogObjectType, directoryID uuid.UUID, paths []string) (map[string]bool, apperrors.Error)
 	DeleteTree(ctx context.Context, directoryIds models.DirectoryIDs, path string) ([]string, apperrors.Error)
 	DeleteObjectWithReferences(ctx context.Context, t types.CatalogObjectType, dirIDs models.DirectoryIDs, delPath string, opts ...models.DirectoryObjectDeleteOptions) (string, apperrors.Error)
 }
@@ -109,6 +138,12 @@ type ConnectionManager interface {
 	DropScope(ctx context.Context, scope string) error
 	DropAllScopes(ctx context.Context) error
 
+	// Transactions. While a transaction is in progress, all queries on the connection are part of it.
+	BeginTx(ctx context.Context) apperrors.Error
+	InTx() bool
+	Commit(ctx context.Context) apperrors.Error
+	Rollback(ctx context.Context) apperrors.Error
+
 	// Close the connection to the database.
 	Close(ctx context.Context)
 }
@@ -131,6 +166,9 @@ var configuredScopes = []string{
 
 var pool dbmanager.ScopedDb
 
+// replicaPool is the pool of connections to the read replica, nil when no replica is configured
+var replicaPool dbmanager.ScopedDb
+
 func init() {
 	ctx := log.Logger.WithContext(context.Background())
 	pg := dbmanager.NewScopedDb(ctx, "postgresql", configuredScopes)
@@ -151,9 +189,51 @@ func Conn(ctx context.Context) dbmanager.ScopedConn {
 	return nil
 }
 
+// Ping checks that the database is reachable
+func Ping(ctx context.Context) error {
+	if pool == nil {
+		return dberror.ErrDatabase.Msg("database pool not initialized")
+	}
+	if err := pool.Ping(ctx); err != nil {
+		return dberror.ErrDatabase.Err(err)
+	}
+	return nil
+}
+
+// InitReadReplica opens a pool of connections to the read-only replica at dsn. Until it is called, or if dsn is
+// empty, ReadDB uses the primary.
+func InitReadReplica(ctx context.Context, dsn string) error {
+	if dsn == "" {
+		return nil
+	}
+	p, err := dbmanager.NewPostgresqlDbWithDsn(dsn, configuredScopes)
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Msg("unable to create read replica pool")
+		return dberror.ErrDatabase.Err(err)
+	}
+	replicaPool = p
+	return nil
+}
+
+// Close closes the database pools. It is called on server shutdown once in-flight requests have drained.
+func Close() error {
+	if replicaPool != nil {
+		if err := replicaPool.Close(); err != nil {
+			log.Error().Err(err).Msg("failed to close read replica pool")
+		}
+	}
+	if pool == nil {
+		return nil
+	}
+	return pool.Close()
+}
+
 type ctxDbKeyType string
 
-const ctxDbKey ctxDbKeyType = "HatchCatalogDb"
+const (
+	ctxDbKey     ctxDbKeyType = "HatchCatalogDb"
+	ctxReadDbKey ctxDbKeyType = "HatchCatalogReadDb"
+)
 
 func ConnCtx(ctx context.Context) context.Context {
 	conn := Conn(ctx)
@@ -178,3 +258,65 @@ func DB(ctx context.Context) DB_ {
 	log.Ctx(ctx).Error().Msg("unable to get db connection from context")
 	return nil
 }
+
+// ReadConnCtx adds a connection to the read replica to ctx, if one is configured. It should only be used for
+// requests that do not write, so that nothing they read can be behind what they wrote.
+func ReadConnCtx(ctx context.Context) context.Context {
+	if replicaPool == nil {
+		return ctx
+	}
+	conn, err := replicaPool.Conn(ctx)
+	if err != nil {
+		log.Ctx(ctx).Warn().Err(err).Msg("unable to get read replica connection, reading from primary")
+		return ctx
+	}
+	return context.WithValue(ctx, ctxReadDbKey, conn)
+}
+
+// CloseReadConn returns the read replica connection in ctx, if any, to the pool
+func CloseReadConn(ctx context.Context) {
+	if conn, ok := ctx.Value(ctxReadDbKey).(dbmanager.ScopedConn); ok {
+		conn.Close(ctx)
+	}
+}
+
+// ReadDB returns the database to use for queries that only read. It is the read replica when ctx has a
+// connection to one, and the primary otherwise or while a transaction is in progress on the primary.
+func ReadDB(ctx context.Context) DB_ {
+	conn, ok := ctx.Value(ctxReadDbKey).(dbmanager.ScopedConn)
+	if !ok {
+		return DB(ctx)
+	}
+	if d := DB(ctx); d != nil && d.InTx() {
+		return d
+	}
+	mm, om, cm := postgresql.NewHatchCatalogDb(conn)
+	return &hatchCatalogDb{
+		MetadataManager:   mm,
+		ObjectManager:     om,
+		ConnectionManager: cm,
+	}
+}
+
+// WithTx runs fn in a transaction on the connection in ctx. The transaction is committed if fn succeeds and
+// rolled back otherwise. If a transaction is already in progress, fn joins it and the caller that started it
+// decides the outcome.
+func WithTx(ctx context.Context, fn func(ctx context.Context) apperrors.Error) apperrors.Error {
+	d := DB(ctx)
+	if d == nil {
+		return dberror.ErrDatabase.Msg("no database connection")
+	}
+	if d.InTx() {
+		return fn(ctx)
+	}
+	if err := d.BeginTx(ctx); err != nil {
+		return err
+	}
+	if err := fn(ctx); err != nil {
+		if rbErr := d.Rollback(ctx); rbErr != nil {
+			log.Ctx(ctx).Error().Err(rbErr).Msg("failed to roll back transaction")
+		}
+		return err
+	}
+	return d.Commit(ctx)
+}
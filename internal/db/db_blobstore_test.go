@@ -0,0 +1,168 @@
+package db
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mugiliam/hatchcatalogsrv/internal/common"
+	"github.com/mugiliam/hatchcatalogsrv/internal/db/blobstore"
+	"github.com/mugiliam/hatchcatalogsrv/internal/db/dberror"
+	"github.com/mugiliam/hatchcatalogsrv/internal/db/models"
+	"github.com/mugiliam/hatchcatalogsrv/pkg/types"
+	"github.com/rs/zerolog/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memBlobStore is a BlobStore that keeps blobs in memory
+type memBlobStore struct {
+	mu     sync.Mutex
+	blobs  map[string][]byte
+	stored map[string]time.Time
+}
+
+func (m *memBlobStore) Put(ctx context.Context, tenantID types.TenantId, hash string, data []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.blobs[string(tenantID)+"/"+hash] = data
+	if m.stored == nil {
+		m.stored = make(map[string]time.Time)
+	}
+	m.stored[string(tenantID)+"/"+hash] = time.Now()
+	return nil
+}
+
+func (m *memBlobStore) Get(ctx context.Context, tenantID types.TenantId, hash string) ([]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	b, ok := m.blobs[string(tenantID)+"/"+hash]
+	if !ok {
+		return nil, blobstore.ErrNotFound
+	}
+	return b, nil
+}
+
+func (m *memBlobStore) Delete(ctx context.Context, tenantID types.TenantId, hash string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.blobs, string(tenantID)+"/"+hash)
+	return nil
+}
+
+func (m *memBlobStore) List(ctx context.Context, tenantID types.TenantId, fn func(hash string, stored time.Time) error) error {
+	m.mu.Lock()
+	var hashes []string
+	stored := make(map[string]time.Time)
+	for k := range m.blobs {
+		if hash, ok := strings.CutPrefix(k, string(tenantID)+"/"); ok {
+			hashes = append(hashes, hash)
+			stored[hash] = m.stored[k]
+		}
+	}
+	m.mu.Unlock()
+	for _, hash := range hashes {
+		if err := fn(hash, stored[hash]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestCatalogObjectsInBlobStore(t *testing.T) {
+	ctx := log.Logger.WithContext(context.Background())
+	ctx = newDb(ctx)
+	defer DB(ctx).Close(ctx)
+
+	tenantID := types.TenantId("TBLOBS")
+	ctx = common.SetTenantIdInContext(ctx, tenantID)
+	require.NoError(t, DB(ctx).CreateTenant(ctx, tenantID))
+	defer DB(ctx).DeleteTenant(ctx, tenantID)
+
+	store := &memBlobStore{blobs: make(map[string][]byte)}
+	blobstore.Set(store, 64)
+	defer blobstore.Set(nil, 0)
+
+	small := models.CatalogObject{
+		Hash:    strings.Repeat("a", 128),
+		Type:    types.CatalogObjectTypeParameterSchema,
+		Version: "v1",
+		Data:    []byte(`{"key": "value"}`),
+	}
+	large := models.CatalogObject{
+		Hash:    strings.Repeat("b", 128),
+		Type:    types.CatalogObjectTypeParameterSchema,
+		Version: "v1",
+		Data:    []byte(`{"key": "` + strings.Repeat("0123456789", 100) + `"}`),
+	}
+	require.NoError(t, DB(ctx).CreateCatalogObject(ctx, &small))
+	require.NoError(t, DB(ctx).CreateCatalogObject(ctx, &large))
+
+	// only the large object is in the blob store
+	assert.Len(t, store.blobs, 1)
+	assert.Contains(t, store.blobs, string(tenantID)+"/"+large.Hash)
+
+	for _, obj := range []models.CatalogObject{small, large} {
+		loaded, err := DB(ctx).GetCatalogObject(ctx, obj.Hash)
+		require.NoError(t, err)
+		assert.Equal(t, obj.Data, loaded.Data)
+	}
+	objs, err := DB(ctx).GetCatalogObjects(ctx, []string{small.Hash, large.Hash})
+	require.NoError(t, err)
+	assert.Equal(t, large.Data, objs[large.Hash].Data)
+
+	// deleting the object deletes its blob
+	require.NoError(t, DB(ctx).DeleteCatalogObject(ctx, large.Hash))
+	assert.Empty(t, store.blobs)
+	_, err = DB(ctx).GetCatalogObject(ctx, large.Hash)
+	assert.ErrorIs(t, err, dberror.ErrNotFound)
+	require.NoError(t, DB(ctx).DeleteCatalogObject(ctx, small.Hash))
+}
+
+func TestDeleteOrphanedBlobs(t *testing.T) {
+	ctx := log.Logger.WithContext(context.Background())
+	ctx = newDb(ctx)
+	defer DB(ctx).Close(ctx)
+
+	tenantID := types.TenantId("TBLOBGC")
+	ctx = common.SetTenantIdInContext(ctx, tenantID)
+	require.NoError(t, DB(ctx).CreateTenant(ctx, tenantID))
+	defer DB(ctx).DeleteTenant(ctx, tenantID)
+
+	store := &memBlobStore{blobs: make(map[string][]byte)}
+	blobstore.Set(store, 64)
+	defer blobstore.Set(nil, 0)
+
+	live := models.CatalogObject{
+		Hash:    strings.Repeat("c", 128),
+		Type:    types.CatalogObjectTypeParameterSchema,
+		Version: "v1",
+		Data:    []byte(`{"key": "` + strings.Repeat("0123456789", 100) + `"}`),
+	}
+	require.NoError(t, DB(ctx).CreateCatalogObject(ctx, &live))
+	defer DB(ctx).DeleteCatalogObject(ctx, live.Hash)
+
+	// a blob whose insert rolled back has no catalog object
+	orphan := strings.Repeat("d", 128)
+	require.NoError(t, store.Put(ctx, tenantID, orphan, []byte("orphaned object data")))
+
+	// young blobs are kept, since their object may not be committed yet
+	n, err := DB(ctx).DeleteOrphanedBlobs(ctx, time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, 0, n)
+	assert.Contains(t, store.blobs, string(tenantID)+"/"+orphan)
+
+	store.stored[string(tenantID)+"/"+orphan] = time.Now().Add(-2 * time.Hour)
+	store.stored[string(tenantID)+"/"+live.Hash] = time.Now().Add(-2 * time.Hour)
+	n, err = DB(ctx).DeleteOrphanedBlobs(ctx, time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+	assert.NotContains(t, store.blobs, string(tenantID)+"/"+orphan)
+	assert.Contains(t, store.blobs, string(tenantID)+"/"+live.Hash)
+
+	loaded, err := DB(ctx).GetCatalogObject(ctx, live.Hash)
+	require.NoError(t, err)
+	assert.Equal(t, live.Data, loaded.Data)
+}
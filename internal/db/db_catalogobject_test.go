@@ -0,0 +1,64 @@
+package db
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mugiliam/hatchcatalogsrv/internal/common"
+	"github.com/mugiliam/hatchcatalogsrv/internal/config"
+	"github.com/mugiliam/hatchcatalogsrv/internal/db/dberror"
+	"github.com/mugiliam/hatchcatalogsrv/internal/db/models"
+	"github.com/mugiliam/hatchcatalogsrv/pkg/types"
+	"github.com/rs/zerolog/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateCatalogObjectVerifiesStoredObject(t *testing.T) {
+	ctx := log.Logger.WithContext(context.Background())
+	ctx = newDb(ctx)
+	defer DB(ctx).Close(ctx)
+
+	tenantID := types.TenantId("TVERIF")
+	ctx = common.SetTenantIdInContext(ctx, tenantID)
+	require.NoError(t, DB(ctx).CreateTenant(ctx, tenantID))
+	defer DB(ctx).DeleteTenant(ctx, tenantID)
+
+	stored := models.CatalogObject{
+		Hash:    strings.Repeat("c", 128),
+		Type:    types.CatalogObjectTypeParameterSchema,
+		Version: "v1",
+		Data:    []byte(`{"key": "value"}`),
+	}
+	// a row whose data does not match its hash, as a collision or a corrupted write would leave
+	mismatched := stored
+	mismatched.Data = []byte(`{"key": "other value"}`)
+
+	require.NoError(t, DB(ctx).CreateCatalogObject(ctx, &stored))
+	defer DB(ctx).DeleteCatalogObject(ctx, stored.Hash)
+
+	// without verification, the same hash is taken to be the same object
+	err := DB(ctx).CreateCatalogObject(ctx, &mismatched)
+	assert.ErrorIs(t, err, dberror.ErrAlreadyExists)
+
+	file := filepath.Join(t.TempDir(), "config.toml")
+	require.NoError(t, os.WriteFile(file, []byte("verify_catalog_objects = true\n"), 0o600))
+	require.NoError(t, config.LoadConfig(file))
+	t.Cleanup(func() {
+		_ = config.LoadConfig("")
+	})
+
+	err = DB(ctx).CreateCatalogObject(ctx, &mismatched)
+	assert.ErrorIs(t, err, dberror.ErrObjectHashMismatch)
+	assert.NotErrorIs(t, err, dberror.ErrAlreadyExists)
+
+	// the stored object is left as it is, and writing it again is still benign
+	loaded, err := DB(ctx).GetCatalogObject(ctx, stored.Hash)
+	require.NoError(t, err)
+	assert.Equal(t, stored.Data, loaded.Data)
+	err = DB(ctx).CreateCatalogObject(ctx, &stored)
+	assert.ErrorIs(t, err, dberror.ErrAlreadyExists)
+}
@@ -288,11 +288,20 @@ func TestCommitWorkspace(t *testing.T) {
 	}
 	err = DB(ctx).CreateCatalogObject(ctx, &obj)
 	assert.NoError(t, err)
+	// batch load a mix of existing and missing hashes
+	objs, err := DB(ctx).GetCatalogObjects(ctx, []string{c.Hash, "missing_hash"})
+	assert.NoError(t, err)
+	if assert.Len(t, objs, 1) {
+		assert.Equal(t, obj.Data, objs[c.Hash].Data)
+		assert.Equal(t, obj.Type, objs[c.Hash].Type)
+	}
+	_, ok := objs["missing_hash"]
+	assert.False(t, ok)
 	// save collection in workspace
 	err = DB(ctx).UpsertCollection(ctx, c, workspace.ValuesDir)
 	assert.NoError(t, err)
 	// delete this catalog object
-	err = DB(ctx).DeleteCatalogObject(ctx, types.CatalogObjectTypeCatalogCollection, c.Hash)
+	err = DB(ctx).DeleteCatalogObject(ctx, c.Hash)
 	assert.NoError(t, err)
 	// get the collection object
 	_, err = DB(ctx).GetCatalogObject(ctx, c.Hash)
@@ -306,7 +315,7 @@ func TestCommitWorkspace(t *testing.T) {
 	assert.Equal(t, c.Hash, hash)
 
 	// delete this catalog object
-	err = DB(ctx).DeleteCatalogObject(ctx, types.CatalogObjectTypeCatalogCollection, c.Hash)
+	err = DB(ctx).DeleteCatalogObject(ctx, c.Hash)
 	assert.NoError(t, err)
 	// get the collection object
 	_, err = DB(ctx).GetCatalogObject(ctx, c.Hash)
@@ -318,7 +327,7 @@ func TestCommitWorkspace(t *testing.T) {
 	assert.Equal(t, c.Hash, hash)
 
 	// delete this catalog object
-	err = DB(ctx).DeleteCatalogObject(ctx, types.CatalogObjectTypeCatalogCollection, c.Hash)
+	err = DB(ctx).DeleteCatalogObject(ctx, c.Hash)
 	assert.NoError(t, err)
 	// get the collection object
 	_, err = DB(ctx).GetCatalogObject(ctx, c.Hash)
@@ -0,0 +1,73 @@
+package db
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/mugiliam/hatchcatalogsrv/internal/common"
+	"github.com/mugiliam/hatchcatalogsrv/internal/db/dberror"
+	"github.com/mugiliam/hatchcatalogsrv/internal/db/models"
+	"github.com/mugiliam/hatchcatalogsrv/internal/metrics"
+	"github.com/mugiliam/hatchcatalogsrv/pkg/types"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/rs/zerolog/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueriesAreRecordedInMetrics(t *testing.T) {
+	ctx := log.Logger.WithContext(context.Background())
+	ctx = newDb(ctx)
+	defer DB(ctx).Close(ctx)
+
+	reg := prometheus.NewRegistry()
+	prev := metrics.Default()
+	metrics.SetDefault(metrics.New(reg))
+	t.Cleanup(func() {
+		metrics.SetDefault(prev)
+	})
+
+	tenantID := types.TenantId("TMETRC")
+	ctx = common.SetTenantIdInContext(ctx, tenantID)
+	require.NoError(t, DB(ctx).CreateTenant(ctx, tenantID))
+	defer DB(ctx).DeleteTenant(ctx, tenantID)
+
+	obj := models.CatalogObject{
+		Hash:    strings.Repeat("d", 128),
+		Type:    types.CatalogObjectTypeParameterSchema,
+		Version: "v1",
+		Data:    []byte(`{"key": "value"}`),
+	}
+	require.NoError(t, DB(ctx).CreateCatalogObject(ctx, &obj))
+	defer DB(ctx).DeleteCatalogObject(ctx, obj.Hash)
+
+	// errors are counted where they happen, whether or not they reach a handler
+	_, err := DB(ctx).GetCatalogObject(ctx, strings.Repeat("e", 128))
+	assert.ErrorIs(t, err, dberror.ErrNotFound)
+
+	n, gatherErr := testutil.GatherAndCount(reg, "hatchcatalog_db_operations_total")
+	require.NoError(t, gatherErr)
+	assert.Positive(t, n)
+	n, gatherErr = testutil.GatherAndCount(reg, "hatchcatalog_db_operation_duration_seconds")
+	require.NoError(t, gatherErr)
+	assert.Positive(t, n)
+
+	mfs, gatherErr := reg.Gather()
+	require.NoError(t, gatherErr)
+	notFound := 0.0
+	for _, mf := range mfs {
+		if mf.GetName() != "hatchcatalog_db_errors_total" {
+			continue
+		}
+		for _, m := range mf.GetMetric() {
+			for _, l := range m.GetLabel() {
+				if l.GetName() == "type" && l.GetValue() == "not_found" {
+					notFound += m.GetCounter().GetValue()
+				}
+			}
+		}
+	}
+	assert.Equal(t, 1.0, notFound)
+}
@@ -0,0 +1,50 @@
+package db
+
+import (
+	"context"
+	"testing"
+
+	"github.com/mugiliam/common/apperrors"
+	"github.com/mugiliam/hatchcatalogsrv/internal/db/config"
+	"github.com/mugiliam/hatchcatalogsrv/internal/db/dberror"
+	"github.com/mugiliam/hatchcatalogsrv/pkg/types"
+	"github.com/rs/zerolog/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadDB(t *testing.T) {
+	ctx := log.Logger.WithContext(context.Background())
+	ctx = newDb(ctx)
+	defer DB(ctx).Close(ctx)
+
+	// without a replica, reads go to the primary
+	assert.Equal(t, ctx, ReadConnCtx(ctx))
+	tenantID := types.TenantId("TREPLC")
+	require.NoError(t, DB(ctx).CreateTenant(ctx, tenantID))
+	defer DB(ctx).DeleteTenant(ctx, tenantID)
+	_, err := ReadDB(ctx).GetTenant(ctx, tenantID)
+	assert.NoError(t, err)
+
+	// the primary stands in for the replica
+	require.NoError(t, InitReadReplica(ctx, config.HatchCatalogDsn()))
+	defer func() {
+		replicaPool.Close()
+		replicaPool = nil
+	}()
+	ctx = ReadConnCtx(ctx)
+	defer CloseReadConn(ctx)
+	_, err = ReadDB(ctx).GetTenant(ctx, tenantID)
+	assert.NoError(t, err)
+
+	// within a transaction, reads see its uncommitted writes
+	otherID := types.TenantId("TREPLD")
+	_ = WithTx(ctx, func(ctx context.Context) apperrors.Error {
+		require.NoError(t, DB(ctx).CreateTenant(ctx, otherID))
+		_, err := ReadDB(ctx).GetTenant(ctx, otherID)
+		assert.NoError(t, err)
+		return dberror.ErrDatabase.Msg("roll back")
+	})
+	_, err = ReadDB(ctx).GetTenant(ctx, otherID)
+	assert.ErrorIs(t, err, dberror.ErrNotFound)
+}
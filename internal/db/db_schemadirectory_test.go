@@ -2,6 +2,8 @@ package db
 
 import (
 	"context"
+	"encoding/json"
+	"strconv"
 	"strings"
 	"testing"
 
@@ -159,6 +161,14 @@ func TestSchemaDirectory(t *testing.T) {
 	assert.NoError(t, err)
 	assert.False(t, exists)
 
+	// Check several paths at once
+	existing, err := DB(ctx).PathsExist(ctx, types.CatalogObjectTypeParameterSchema, pd, []string{"/x/y2/z/a/b", "/non/existing/path", "/a1/b3"})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]bool{"/x/y2/z/a/b": true, "/a1/b3": true}, existing)
+	existing, err = DB(ctx).PathsExist(ctx, types.CatalogObjectTypeParameterSchema, pd, nil)
+	assert.NoError(t, err)
+	assert.Empty(t, existing)
+
 	// Update object by path
 	updateObj := models.ObjectRef{
 		Hash: "new_hash_value",
@@ -236,6 +246,30 @@ func TestSchemaDirectory(t *testing.T) {
 	assert.NoError(t, err)
 	assert.ElementsMatch(t, references, []models.Reference{{Name: "ref1"}})
 
+	// Add references to multiple objects in a batch, including a non-existing path
+	batchPaths := []string{"/a/b3/c/d/e/f", "/x/y2/z/a/b", "/non/existing/batch/path"}
+	err = DB(ctx).AddReferencesToObjects(ctx, types.CatalogObjectTypeParameterSchema, pd, batchPaths, []models.Reference{{Name: "ref1"}, {Name: "ref5"}})
+	assert.NoError(t, err)
+	references, err = DB(ctx).GetAllReferences(ctx, types.CatalogObjectTypeParameterSchema, pd, "/a/b3/c/d/e/f")
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, references, []models.Reference{{Name: "ref1"}, {Name: "ref5"}})
+	references, err = DB(ctx).GetAllReferences(ctx, types.CatalogObjectTypeParameterSchema, pd, "/x/y2/z/a/b")
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, references, []models.Reference{{Name: "ref1"}, {Name: "ref5"}})
+	exists, err = DB(ctx).PathExists(ctx, types.CatalogObjectTypeParameterSchema, pd, "/non/existing/batch/path")
+	assert.NoError(t, err)
+	assert.False(t, exists)
+
+	// Delete a reference from multiple objects in a batch
+	err = DB(ctx).DeleteReferencesFromObjects(ctx, types.CatalogObjectTypeParameterSchema, pd, batchPaths, "ref5")
+	assert.NoError(t, err)
+	references, err = DB(ctx).GetAllReferences(ctx, types.CatalogObjectTypeParameterSchema, pd, "/a/b3/c/d/e/f")
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, references, []models.Reference{{Name: "ref1"}})
+	references, err = DB(ctx).GetAllReferences(ctx, types.CatalogObjectTypeParameterSchema, pd, "/x/y2/z/a/b")
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, references, []models.Reference{{Name: "ref1"}})
+
 	// Delete object by path
 	hash, err := DB(ctx).DeleteObjectByPath(ctx, types.CatalogObjectTypeParameterSchema, pd, "/a/b3/c/d/e/f")
 	assert.NoError(t, err)
@@ -406,3 +440,58 @@ func TestSchemaDirectory(t *testing.T) {
 	}, "/col/x/y")
 	require.NoError(t, err)
 }
+
+// BenchmarkFindClosestObject looks up a parameter schema from deep in a directory in which many unrelated
+// paths end with the same name
+func BenchmarkFindClosestObject(b *testing.B) {
+	ctx := log.Logger.WithContext(context.Background())
+	ctx = newDb(ctx)
+	defer DB(ctx).Close(ctx)
+
+	tenantID := types.TenantId("TABCDE")
+	projectID := types.ProjectId("P12345")
+	ctx = common.SetTenantIdInContext(ctx, tenantID)
+	ctx = common.SetProjectIdInContext(ctx, projectID)
+
+	require.NoError(b, DB(ctx).CreateTenant(ctx, tenantID))
+	defer DB(ctx).DeleteTenant(ctx, tenantID)
+	require.NoError(b, DB(ctx).CreateProject(ctx, projectID))
+	defer DB(ctx).DeleteProject(ctx, projectID)
+
+	catalog := models.Catalog{
+		Name:        "test_catalog",
+		Description: "A test catalog",
+		Info:        pgtype.JSONB{Status: pgtype.Null},
+	}
+	require.NoError(b, DB(ctx).CreateCatalog(ctx, &catalog))
+	defer DB(ctx).DeleteCatalog(ctx, catalog.CatalogID, "")
+	variant, err := DB(ctx).GetVariant(ctx, catalog.CatalogID, uuid.Nil, types.DefaultVariant)
+	require.NoError(b, err)
+	workspace := models.Workspace{
+		VariantID:   variant.VariantID,
+		Info:        pgtype.JSONB{Status: pgtype.Null},
+		BaseVersion: 1,
+	}
+	require.NoError(b, DB(ctx).CreateWorkspace(ctx, &workspace))
+
+	// the schema is near the root, the lookup starts 32 levels below it, and 2000 other schemas share its name
+	dir := models.Directory{"/--root--/l0/param": {Hash: "closest"}}
+	for i := 0; i < 2000; i++ {
+		dir["/--root--/s"+strconv.Itoa(i)+"/t/param"] = models.ObjectRef{Hash: "other"}
+	}
+	startPath := "/--root--"
+	for i := 0; i < 32; i++ {
+		startPath += "/l" + strconv.Itoa(i)
+	}
+	dirJson, e := json.Marshal(dir)
+	require.NoError(b, e)
+	require.NoError(b, DB(ctx).SetDirectory(ctx, types.CatalogObjectTypeParameterSchema, workspace.ParametersDir, dirJson))
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		p, obj, err := DB(ctx).FindClosestObject(ctx, types.CatalogObjectTypeParameterSchema, workspace.ParametersDir, "param", startPath)
+		if err != nil || p != "/--root--/l0/param" || obj.Hash != "closest" {
+			b.Fatalf("unexpected result %q %v %v", p, obj, err)
+		}
+	}
+}
@@ -166,6 +166,59 @@ func TestDeleteProject(t *testing.T) {
 	assert.ErrorIs(t, err, dberror.ErrNotFound)
 }
 
+func TestListAndUpdateProjects(t *testing.T) {
+	// Initialize context with logger and database connection
+	ctx := log.Logger.WithContext(context.Background())
+	ctx = newDb(ctx)
+	defer DB(ctx).Close(ctx)
+
+	tenantID := types.TenantId("TABCDE")
+	otherTenantID := types.TenantId("TFGHIJ")
+
+	// Set the tenant ID in the context
+	ctx = common.SetTenantIdInContext(ctx, tenantID)
+
+	err := DB(ctx).CreateTenant(ctx, tenantID)
+	assert.NoError(t, err)
+	defer DB(ctx).DeleteTenant(ctx, tenantID)
+	err = DB(ctx).CreateTenant(ctx, otherTenantID)
+	assert.NoError(t, err)
+	defer DB(ctx).DeleteTenant(ctx, otherTenantID)
+
+	for _, p := range []types.ProjectId{"P2", "P1"} {
+		err = DB(ctx).CreateProject(ctx, p)
+		assert.NoError(t, err)
+		defer DB(ctx).DeleteProject(ctx, p)
+	}
+	otherCtx := common.SetTenantIdInContext(ctx, otherTenantID)
+	err = DB(otherCtx).CreateProject(otherCtx, "P3")
+	assert.NoError(t, err)
+
+	// only the projects of the tenant are listed, in order
+	projects, err := DB(ctx).ListProjects(ctx, tenantID)
+	assert.NoError(t, err)
+	if assert.Len(t, projects, 2) {
+		assert.Equal(t, types.ProjectId("P1"), projects[0].ProjectID)
+		assert.Equal(t, types.ProjectId("P2"), projects[1].ProjectID)
+		assert.Empty(t, projects[0].Description)
+	}
+
+	// update the description
+	err = DB(ctx).UpdateProject(ctx, &models.Project{ProjectID: "P1", Description: "first project"})
+	assert.NoError(t, err)
+	project, err := DB(ctx).GetProject(ctx, "P1")
+	assert.NoError(t, err)
+	assert.Equal(t, "first project", project.Description)
+
+	// a project of another tenant is not updated
+	err = DB(ctx).UpdateProject(ctx, &models.Project{ProjectID: "P3", Description: "other"})
+	assert.ErrorIs(t, err, dberror.ErrNotFound)
+
+	// a tenant is required
+	_, err = DB(ctx).ListProjects(ctx, "")
+	assert.Error(t, err)
+}
+
 func TestCreateCatalog(t *testing.T) {
 	// Initialize context with logger and database connection
 	ctx := log.Logger.WithContext(context.Background())
@@ -727,6 +727,13 @@ func TestGetVersionByLabel(t *testing.T) {
 	_, err = DB(ctx).GetVersionByLabel(ctx, "v1", invalidVariantID)
 	assert.Error(t, err)
 	assert.ErrorIs(t, err, dberror.ErrNotFound)
+
+	// Test case: The latest version is the one just added
+	latestVersion, err := DB(ctx).GetLatestVersion(ctx, variant.VariantID)
+	assert.NoError(t, err)
+	assert.Equal(t, version.VersionNum, latestVersion.VersionNum)
+	_, err = DB(ctx).GetLatestVersion(ctx, invalidVariantID)
+	assert.ErrorIs(t, err, dberror.ErrNotFound)
 }
 
 func TestConcurrentVersionCreate(t *testing.T) {
@@ -835,3 +842,74 @@ func TestConcurrentVersionCreate(t *testing.T) {
 		assert.Equal(t, expectedVersionNum, versions[i].VersionNum, "Version numbers should be sequential and without conflict")
 	}
 }
+
+func TestListVersions(t *testing.T) {
+	ctx := log.Logger.WithContext(context.Background())
+	ctx = newDb(ctx)
+	defer DB(ctx).Close(ctx)
+
+	tenantID := types.TenantId("TABCDE")
+	projectID := types.ProjectId("P12345")
+
+	ctx = common.SetTenantIdInContext(ctx, tenantID)
+	ctx = common.SetProjectIdInContext(ctx, projectID)
+
+	err := DB(ctx).CreateTenant(ctx, tenantID)
+	assert.NoError(t, err)
+	defer DB(ctx).DeleteTenant(ctx, tenantID)
+
+	err = DB(ctx).CreateProject(ctx, projectID)
+	assert.NoError(t, err)
+	defer DB(ctx).DeleteProject(ctx, projectID)
+
+	var info pgtype.JSONB
+	err = info.Set(`{"key": "value"}`)
+	assert.NoError(t, err)
+
+	catalog := models.Catalog{
+		Name:        "test_catalog",
+		Description: "A test catalog",
+		Info:        info,
+	}
+	err = DB(ctx).CreateCatalog(ctx, &catalog)
+	assert.NoError(t, err)
+	defer DB(ctx).DeleteCatalog(ctx, catalog.CatalogID, "")
+
+	variant := models.Variant{
+		Name:        "test_variant",
+		Description: "A test variant",
+		CatalogID:   catalog.CatalogID,
+		Info:        info,
+	}
+	err = DB(ctx).CreateVariant(ctx, &variant)
+	assert.NoError(t, err)
+	defer DB(ctx).DeleteVariant(ctx, catalog.CatalogID, variant.VariantID, "")
+
+	// a labeled and an unlabeled version after the "init" version
+	for _, label := range []string{"v2", ""} {
+		version := models.Version{
+			Label:     label,
+			Info:      info,
+			VariantID: variant.VariantID,
+		}
+		err = DB(ctx).CreateVersion(ctx, &version)
+		assert.NoError(t, err)
+	}
+
+	versions, err := DB(ctx).ListVersions(ctx, variant.VariantID)
+	assert.NoError(t, err)
+	if assert.Len(t, versions, 3) {
+		for i, version := range versions {
+			assert.Equal(t, i+1, version.VersionNum)
+			assert.False(t, version.CreatedAt.IsZero())
+		}
+		assert.Equal(t, "init", versions[0].Label)
+		assert.Equal(t, "v2", versions[1].Label)
+		assert.Equal(t, "", versions[2].Label)
+	}
+
+	// a variant without versions
+	versions, err = DB(ctx).ListVersions(ctx, uuid.New())
+	assert.NoError(t, err)
+	assert.Empty(t, versions)
+}
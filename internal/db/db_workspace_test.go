@@ -211,7 +211,20 @@ func TestDeleteWorkspace(t *testing.T) {
 	// Verify the workspace was created successfully
 	retrievedWorkspace, err := DB(ctx).GetWorkspace(ctx, workspace.WorkspaceID)
 	assert.NoError(t, err)
-	assert.NotNil(t, retrievedWorkspace)
+	require.NotNil(t, retrievedWorkspace)
+
+	// Add collections to the workspace
+	collectionPaths := []string{"/--root--/a/collection1", "/--root--/a/b/collection2"}
+	for _, p := range collectionPaths {
+		err = DB(ctx).UpsertCollection(ctx, &models.Collection{
+			Path:             p,
+			Hash:             "hash-" + p,
+			CollectionSchema: "/--root--/schema",
+		}, retrievedWorkspace.ValuesDir)
+		require.NoError(t, err)
+		_, err = DB(ctx).GetCollection(ctx, p, retrievedWorkspace.ValuesDir)
+		require.NoError(t, err)
+	}
 
 	// Delete the workspace
 	err = DB(ctx).DeleteWorkspace(ctx, workspace.WorkspaceID)
@@ -222,6 +235,41 @@ func TestDeleteWorkspace(t *testing.T) {
 	assert.Error(t, err)
 	assert.ErrorIs(t, err, dberror.ErrNotFound)
 
+	// Verify that the collections and directories of the workspace were deleted with it
+	for _, p := range collectionPaths {
+		_, err = DB(ctx).GetCollection(ctx, p, retrievedWorkspace.ValuesDir)
+		assert.ErrorIs(t, err, dberror.ErrNotFound)
+	}
+	for objType, dirID := range map[types.CatalogObjectType]uuid.UUID{
+		types.CatalogObjectTypeParameterSchema:   retrievedWorkspace.ParametersDir,
+		types.CatalogObjectTypeCollectionSchema:  retrievedWorkspace.CollectionsDir,
+		types.CatalogObjectTypeCatalogCollection: retrievedWorkspace.ValuesDir,
+	} {
+		_, err = DB(ctx).GetSchemaDirectory(ctx, objType, dirID)
+		assert.ErrorIs(t, err, dberror.ErrNotFound, string(objType))
+	}
+
+	// A workspace with the same label starts without collections
+	recreated := models.Workspace{
+		Label:       "workspace1",
+		Description: "A test workspace",
+		Info:        info,
+		BaseVersion: 1,
+		VariantID:   variant.VariantID,
+	}
+	err = DB(ctx).CreateWorkspace(ctx, &recreated)
+	require.NoError(t, err)
+	recreatedWorkspace, err := DB(ctx).GetWorkspace(ctx, recreated.WorkspaceID)
+	require.NoError(t, err)
+	for _, p := range collectionPaths {
+		_, err = DB(ctx).GetCollection(ctx, p, recreatedWorkspace.ValuesDir)
+		assert.ErrorIs(t, err, dberror.ErrNotFound)
+	}
+	err = DB(ctx).DeleteWorkspaceByLabel(ctx, variant.VariantID, "workspace1")
+	assert.NoError(t, err)
+	_, err = DB(ctx).GetSchemaDirectory(ctx, types.CatalogObjectTypeCatalogCollection, recreatedWorkspace.ValuesDir)
+	assert.ErrorIs(t, err, dberror.ErrNotFound)
+
 	// Test case: Attempt to delete a non-existent workspace (should fail gracefully)
 	err = DB(ctx).DeleteWorkspace(ctx, workspace.WorkspaceID) // Attempt to delete again
 	assert.NoError(t, err)                                    // Should not return an error, as it may be idempotent
@@ -395,7 +443,7 @@ func TestUpdateWorkspaceLabel(t *testing.T) {
 
 	// Update the workspace label
 	newLabel := "updated_label"
-	err = DB(ctx).UpdateWorkspaceLabel(ctx, workspace.WorkspaceID, newLabel)
+	err = DB(ctx).UpdateWorkspaceLabel(ctx, workspace.WorkspaceID, "original_label", newLabel)
 	assert.NoError(t, err)
 
 	// Verify that the label was updated successfully
@@ -403,9 +451,18 @@ func TestUpdateWorkspaceLabel(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, newLabel, updatedWorkspace.Label)
 
+	// Test case: The label was changed since it was read (should fail as a concurrent modification)
+	err = DB(ctx).UpdateWorkspaceLabel(ctx, workspace.WorkspaceID, "original_label", "stale_label")
+	assert.ErrorIs(t, err, dberror.ErrConcurrentModification)
+	err = DB(ctx).UpdateWorkspaceLabel(ctx, workspace.WorkspaceID, "", "stale_label")
+	assert.ErrorIs(t, err, dberror.ErrConcurrentModification)
+	updatedWorkspace, err = DB(ctx).GetWorkspace(ctx, workspace.WorkspaceID)
+	assert.NoError(t, err)
+	assert.Equal(t, newLabel, updatedWorkspace.Label)
+
 	// Test case: Attempt to update with an invalid label format (should fail)
 	invalidLabel := "invalid label with spaces"
-	err = DB(ctx).UpdateWorkspaceLabel(ctx, workspace.WorkspaceID, invalidLabel)
+	err = DB(ctx).UpdateWorkspaceLabel(ctx, workspace.WorkspaceID, newLabel, invalidLabel)
 	assert.Error(t, err)
 	assert.ErrorIs(t, err, dberror.ErrInvalidInput)
 
@@ -422,19 +479,19 @@ func TestUpdateWorkspaceLabel(t *testing.T) {
 	defer DB(ctx).DeleteWorkspace(ctx, duplicateWorkspace.WorkspaceID)
 
 	// Attempt to update the first workspace to use the duplicate label (should fail)
-	err = DB(ctx).UpdateWorkspaceLabel(ctx, workspace.WorkspaceID, "unique_label")
+	err = DB(ctx).UpdateWorkspaceLabel(ctx, workspace.WorkspaceID, newLabel, "unique_label")
 	assert.Error(t, err)
 	assert.ErrorIs(t, err, dberror.ErrAlreadyExists)
 
 	// Test case: Attempt to update a non-existent workspace (should fail)
 	nonExistentWorkspaceID := uuid.New()
-	err = DB(ctx).UpdateWorkspaceLabel(ctx, nonExistentWorkspaceID, "new_label")
+	err = DB(ctx).UpdateWorkspaceLabel(ctx, nonExistentWorkspaceID, "", "new_label")
 	assert.Error(t, err)
 	assert.ErrorIs(t, err, dberror.ErrNotFound)
 
 	// Test case: Missing tenant ID in context (should fail)
 	ctxWithoutTenant := common.SetTenantIdInContext(ctx, "")
-	err = DB(ctx).UpdateWorkspaceLabel(ctxWithoutTenant, workspace.WorkspaceID, "another_label")
+	err = DB(ctx).UpdateWorkspaceLabel(ctxWithoutTenant, workspace.WorkspaceID, newLabel, "another_label")
 	assert.Error(t, err)
 	assert.ErrorIs(t, err, dberror.ErrInvalidInput)
 }
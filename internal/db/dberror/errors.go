@@ -80,4 +80,6 @@ var (
 	ErrMissingTenantID           apperrors.Error = ErrInvalidInput.New("missing tenant ID").SetStatusCode(http.StatusBadRequest)
 	ErrMissingProjecID           apperrors.Error = ErrInvalidInput.New("missing project ID").SetStatusCode(http.StatusBadRequest)
 	ErrNoAncestorReferencesFound apperrors.Error = ErrDatabase.New("no ancestor references found").SetStatusCode(http.StatusBadRequest)
+	ErrConcurrentModification    apperrors.Error = ErrDatabase.New("modified concurrently").SetStatusCode(http.StatusPreconditionFailed)
+	ErrObjectHashMismatch        apperrors.Error = ErrDatabase.New("stored object does not match the object with the same hash").SetStatusCode(http.StatusInternalServerError)
 )
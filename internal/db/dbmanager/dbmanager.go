@@ -13,6 +13,10 @@ type ScopedDb interface {
 	Conn(ctx context.Context) (ScopedConn, error)
 	// Stats returns the number of connection requests and returns.
 	Stats() (requests, returns uint64)
+	// Ping checks that the database is reachable by running a trivial query on the pool.
+	Ping(ctx context.Context) error
+	// Close closes the pool. Connections in use are closed when they are returned.
+	Close() error
 }
 
 type ScopedConn interface {
@@ -28,6 +32,14 @@ type ScopedConn interface {
 	DropAllScopes(ctx context.Context) error
 	// Conn returns the underlying connection of the ScopedConn.
 	Conn() *sql.Conn
+	// BeginTx starts a transaction on the connection. Until it is committed or rolled back, Tx returns it.
+	BeginTx(ctx context.Context, opts *sql.TxOptions) error
+	// Tx returns the transaction in progress on the connection, or nil if there is none.
+	Tx() *sql.Tx
+	// CommitTx commits the transaction in progress on the connection.
+	CommitTx() error
+	// RollbackTx rolls back the transaction in progress on the connection.
+	RollbackTx() error
 	// Close drops all scopes and returns the connection back to the pool.
 	Close(ctx context.Context)
 }
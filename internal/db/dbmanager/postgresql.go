@@ -4,6 +4,7 @@ package dbmanager
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 
 	_ "github.com/jackc/pgx/v4/stdlib"
@@ -19,6 +20,7 @@ type postgresConn struct {
 	scopes           map[string]string
 	configuredScopes []string
 	pool             *postgresPool
+	tx               *sql.Tx
 }
 
 // PostgresPool represents a pool of PostgreSQL database connections.
@@ -33,8 +35,12 @@ type postgresPool struct {
 // It returns a pointer to the PostgresPool and an error, if any.
 func NewPostgresqlDb(configuredScopes []string) (ScopedDb, error) {
 	// Get the database connection string from the configuration.
-	dsn := config.HatchCatalogDsn()
+	return NewPostgresqlDbWithDsn(config.HatchCatalogDsn(), configuredScopes)
+}
 
+// NewPostgresqlDbWithDsn creates a connection pool to the PostgreSQL database at dsn. It is used to connect to
+// databases other than the primary, such as a read replica.
+func NewPostgresqlDbWithDsn(dsn string, configuredScopes []string) (ScopedDb, error) {
 	// Open a new database connection using the "pgx" driver.
 	sqlDB, err := sql.Open("pgx", dsn)
 	if err != nil {
@@ -114,8 +120,23 @@ func (p *postgresPool) Stats() (requests, returns uint64) {
 	return p.connRequests, p.connReturns
 }
 
+// Ping runs a lightweight query on a pooled connection to check that the database is reachable.
+func (p *postgresPool) Ping(ctx context.Context) error {
+	var one int
+	return p.db.QueryRowContext(ctx, "SELECT 1").Scan(&one)
+}
+
+// Close closes the underlying database pool.
+func (p *postgresPool) Close() error {
+	return p.db.Close()
+}
+
 // Close cleans up the scopes and returns the connection back to the pool.
 func (h *postgresConn) Close(ctx context.Context) {
+	if h.tx != nil {
+		log.Ctx(ctx).Warn().Msg("rolling back unfinished transaction")
+		h.RollbackTx()
+	}
 	h.DropAllScopes(ctx)
 	if h.cancel != nil {
 		h.cancel()
@@ -217,3 +238,51 @@ func (h *postgresConn) DropAllScopes(ctx context.Context) error {
 func (h *postgresConn) Conn() *sql.Conn {
 	return h.conn
 }
+
+// ErrTxInProgress is returned when a transaction is started on a connection that already has one
+var ErrTxInProgress = errors.New("transaction already in progress")
+
+// ErrNoTx is returned when committing or rolling back a connection without a transaction
+var ErrNoTx = errors.New("no transaction in progress")
+
+// BeginTx starts a transaction on the connection. Postgres does not support nested transactions,
+// so starting a second one before the first is finished is an error.
+func (h *postgresConn) BeginTx(ctx context.Context, opts *sql.TxOptions) error {
+	if h.conn == nil {
+		return errors.New("no connection")
+	}
+	if h.tx != nil {
+		return ErrTxInProgress
+	}
+	tx, err := h.conn.BeginTx(ctx, opts)
+	if err != nil {
+		return err
+	}
+	h.tx = tx
+	return nil
+}
+
+// Tx returns the transaction in progress on the connection, or nil if there is none.
+func (h *postgresConn) Tx() *sql.Tx {
+	return h.tx
+}
+
+// CommitTx commits the transaction in progress on the connection.
+func (h *postgresConn) CommitTx() error {
+	if h.tx == nil {
+		return ErrNoTx
+	}
+	tx := h.tx
+	h.tx = nil
+	return tx.Commit()
+}
+
+// RollbackTx rolls back the transaction in progress on the connection.
+func (h *postgresConn) RollbackTx() error {
+	if h.tx == nil {
+		return ErrNoTx
+	}
+	tx := h.tx
+	h.tx = nil
+	return tx.Rollback()
+}
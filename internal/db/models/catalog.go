@@ -1,20 +1,25 @@
 package models
 
 import (
+	"encoding/json"
+	"time"
+
 	"github.com/google/uuid"
 	"github.com/jackc/pgtype"
 	"github.com/mugiliam/hatchcatalogsrv/pkg/types"
 )
 
 /*
-   Column    |          Type           | Collation | Nullable |      Default
--------------+-------------------------+-----------+----------+--------------------
- catalog_id  | uuid                    |           | not null | uuid_generate_v4()
- name        | character varying(128)  |           | not null |
- description | character varying(1024) |           |          |
- info        | jsonb                   |           |          |
- project_id  | character varying(10)   |           | not null |
- tenant_id   | character varying(10)   |           | not null |
+   Column    |           Type           | Collation | Nullable |      Default
+-------------+--------------------------+-----------+----------+--------------------
+ catalog_id  | uuid                     |           | not null | uuid_generate_v4()
+ name        | character varying(128)   |           | not null |
+ description | character varying(1024)  |           |          |
+ info        | jsonb                    |           |          |
+ project_id  | character varying(10)    |           | not null |
+ tenant_id   | character varying(10)    |           | not null |
+ created_at  | timestamp with time zone |           |          | now()
+ updated_at  | timestamp with time zone |           |          | now()
 */
 
 // Catalog model definition
@@ -24,4 +29,37 @@ type Catalog struct {
 	Description string          `db:"description"`
 	Info        pgtype.JSONB    `db:"info"`
 	ProjectID   types.ProjectId `db:"project_id"`
+	CreatedAt   time.Time       `db:"created_at"`
+	UpdatedAt   time.Time       `db:"updated_at"`
+}
+
+// CatalogInfo is kept in the info column of a catalog
+type CatalogInfo struct {
+	DefaultVariant string `json:"defaultVariant,omitempty"` // the variant of requests that do not name one
+}
+
+// GetInfo returns the info of the catalog, or an empty CatalogInfo if it has none
+func (c *Catalog) GetInfo() CatalogInfo {
+	var info CatalogInfo
+	if c.Info.Status == pgtype.Present {
+		_ = json.Unmarshal(c.Info.Bytes, &info)
+	}
+	return info
+}
+
+// SetInfo sets the info of the catalog. An empty info is stored as null.
+func (c *Catalog) SetInfo(info CatalogInfo) error {
+	if info == (CatalogInfo{}) {
+		c.Info = pgtype.JSONB{Status: pgtype.Null}
+		return nil
+	}
+	return c.Info.Set(info)
+}
+
+// DefaultVariant returns the name of the variant that requests to the catalog use when they do not name one
+func (c *Catalog) DefaultVariant() string {
+	if v := c.GetInfo().DefaultVariant; v != "" {
+		return v
+	}
+	return types.DefaultVariant
 }
@@ -3,12 +3,14 @@ package models
 import "github.com/mugiliam/hatchcatalogsrv/pkg/types"
 
 /*
-     Column   |         Type          | Collation | Nullable | Default
---------------+-----------------------+-----------+----------+---------
-	hash      | character(128)        |           | not null |
-	type      | character varying(64) |           | not null |
-	tenant_id | character varying(10) |           | not null |
-	data      | bytea                 |           | not null |
+     Column   |           Type           | Collation | Nullable | Default
+--------------+--------------------------+-----------+----------+---------
+	hash      | character(128)           |           | not null |
+	type      | character varying(64)    |           | not null |
+	tenant_id | character varying(10)    |           | not null |
+	data      | bytea                    |           | not null |
+	size      | bigint                   |           | not null | 0
+	created_at| timestamp with time zone |           | not null | now()
 */
 
 type CatalogObject struct {
@@ -17,4 +19,5 @@ type CatalogObject struct {
 	Version  string                  `db:"version"`
 	TenantID types.TenantId          `db:"tenant_id"`
 	Data     []byte                  `db:"data"`
+	Size     int64                   `db:"size"` // size of the data before it is compressed or moved to the blob store
 }
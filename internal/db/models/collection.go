@@ -39,6 +39,7 @@ type Collection struct {
 	Path             string         `db:"path"`
 	Hash             string         `db:"hash"`
 	CollectionSchema string         `db:"collection_schema"`
+	SchemaHash       string         `db:"-"` // hash of the collection schema the values were validated against, kept in the directory entry
 	RepoID           uuid.UUID      `db:"repo_id"`
 	VariantID        uuid.UUID      `db:"variant_id"`
 	TenantID         types.TenantId `db:"tenant_id"`
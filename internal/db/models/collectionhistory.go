@@ -0,0 +1,33 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mugiliam/hatchcatalogsrv/pkg/types"
+)
+
+/*
+    Column    |           Type           | Collation | Nullable |                        Default
+--------------+--------------------------+-----------+----------+--------------------------------------------------------
+ history_id   | bigint                   |           | not null | nextval('collection_history_history_id_seq'::regclass)
+ directory_id | uuid                     |           | not null |
+ path         | text                     |           | not null |
+ hash         | character(128)           |           | not null |
+ tenant_id    | character varying(10)    |           | not null |
+ created_at   | timestamp with time zone |           | not null | now()
+Indexes:
+    "collection_history_pkey" PRIMARY KEY, btree (history_id)
+    "idx_collection_history_dir_path" btree (directory_id, path, tenant_id, history_id)
+Foreign-key constraints:
+    "collection_history_directory_id_tenant_id_fkey" FOREIGN KEY (directory_id, tenant_id) REFERENCES values_directory(directory_id, tenant_id) ON DELETE CASCADE
+*/
+
+// CollectionHistoryEntry is an append-only record of the hash a collection pointed to after an upsert
+type CollectionHistoryEntry struct {
+	DirectoryID uuid.UUID      `db:"directory_id" json:"-"`
+	Path        string         `db:"path" json:"-"`
+	Hash        string         `db:"hash" json:"hash"`
+	TenantID    types.TenantId `db:"tenant_id" json:"-"`
+	CreatedAt   time.Time      `db:"created_at" json:"createdAt"`
+}
@@ -0,0 +1,40 @@
+package models
+
+import (
+	"time"
+
+	"github.com/mugiliam/hatchcatalogsrv/pkg/types"
+)
+
+/*
+    Column    |           Type           | Collation | Nullable | Default
+--------------+--------------------------+-----------+----------+---------
+ key          | character varying(255)   |           | not null |
+ tenant_id    | character varying(10)    |           | not null |
+ project_id   | character varying(10)    |           | not null |
+ request_hash | character(64)            |           | not null |
+ status_code  | integer                  |           | not null |
+ location     | text                     |           |          |
+ response     | bytea                    |           |          |
+ created_at   | timestamp with time zone |           | not null | now()
+ expires_at   | timestamp with time zone |           | not null |
+Indexes:
+    "idempotency_keys_pkey" PRIMARY KEY, btree (key, tenant_id, project_id)
+    "idx_idempotency_keys_expires_at" btree (expires_at)
+Foreign-key constraints:
+    "idempotency_keys_project_id_tenant_id_fkey" FOREIGN KEY (project_id, tenant_id) REFERENCES projects(project_id, tenant_id) ON DELETE CASCADE
+*/
+
+// IdempotencyKey records the outcome of a request made with an Idempotency-Key header in a project. A StatusCode
+// of 0 marks a request that is still in progress.
+type IdempotencyKey struct {
+	Key         string          `db:"key"`
+	TenantID    types.TenantId  `db:"tenant_id"`
+	ProjectID   types.ProjectId `db:"project_id"`
+	RequestHash string          `db:"request_hash"`
+	StatusCode  int             `db:"status_code"`
+	Location    string          `db:"location"`
+	Response    []byte          `db:"response"`
+	CreatedAt   time.Time       `db:"created_at"`
+	ExpiresAt   time.Time       `db:"expires_at"`
+}
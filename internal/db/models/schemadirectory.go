@@ -45,6 +45,14 @@ type ObjectRef struct {
 	Hash       string     `json:"hash"`
 	References References `json:"references"`  // used for objects that reference other objects, e.g. schemas
 	BaseSchema string     `json:"base_schema"` // used for objects that are based on a schema, e.g. collections
+	// hash of the schema the values of a collection were validated against, if they were
+	BaseSchemaHash string `json:"base_schema_hash,omitempty"`
+	// metadata of a schema; kept here rather than in the catalog object so that it is not part of the hash
+	Description        string            `json:"description,omitempty"`
+	Deprecated         bool              `json:"deprecated,omitempty"`
+	DeprecationMessage string            `json:"deprecation_message,omitempty"`
+	Units              map[string]string `json:"units,omitempty"`
+	ValuesExtends      string            `json:"values_extends,omitempty"` // path of the collection schema whose values are the base of these
 }
 
 // we'll keep Reference as a struct for future extensibility at the cost of increased storage space
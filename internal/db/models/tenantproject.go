@@ -6,7 +6,20 @@ type Tenant struct {
 	TenantID types.TenantId
 }
 
+/*
+   Column    |          Type           | Collation | Nullable | Default
+-------------+-------------------------+-----------+----------+---------
+ project_id  | character varying(10)   |           | not null |
+ tenant_id   | character varying(10)   |           | not null |
+ description | character varying(1024) |           |          |
+Indexes:
+    "projects_pkey" PRIMARY KEY, btree (project_id, tenant_id)
+Foreign-key constraints:
+    "projects_tenant_id_fkey" FOREIGN KEY (tenant_id) REFERENCES tenants(tenant_id) ON DELETE CASCADE
+*/
+
 type Project struct {
-	ProjectID types.ProjectId
-	TenantID  types.TenantId
+	ProjectID   types.ProjectId
+	TenantID    types.TenantId
+	Description string
 }
@@ -0,0 +1,24 @@
+package models
+
+import "github.com/mugiliam/hatchcatalogsrv/pkg/types"
+
+/*
+          Column          |          Type         | Collation | Nullable | Default
+--------------------------+-----------------------+-----------+----------+---------
+ tenant_id                | character varying(10) |           | not null |
+ max_catalogs_per_project | integer               |           |          |
+ max_objects_per_variant  | integer               |           |          |
+ max_bytes_per_tenant     | bigint                |           |          |
+Indexes:
+    "tenant_quotas_pkey" PRIMARY KEY, btree (tenant_id)
+Foreign-key constraints:
+    "tenant_quotas_tenant_id_fkey" FOREIGN KEY (tenant_id) REFERENCES tenants(tenant_id) ON DELETE CASCADE
+*/
+
+// TenantQuota overrides the configured quotas for a tenant. A nil limit keeps the configured one.
+type TenantQuota struct {
+	TenantID              types.TenantId `db:"tenant_id"`
+	MaxCatalogsPerProject *int           `db:"max_catalogs_per_project"`
+	MaxObjectsPerVariant  *int           `db:"max_objects_per_variant"`
+	MaxBytesPerTenant     *int64         `db:"max_bytes_per_tenant"`
+}
@@ -1,19 +1,23 @@
 package models
 
 import (
+	"time"
+
 	"github.com/google/uuid"
 	"github.com/jackc/pgtype"
 )
 
 /*
-   Column    |          Type           | Collation | Nullable |      Default
--------------+-------------------------+-----------+----------+--------------------
- variant_id  | uuid                    |           | not null | uuid_generate_v4()
- name        | character varying(128)  |           | not null |
- description | character varying(1024) |           |          |
- info        | jsonb                   |           |          |
- catalog_id  | uuid                    |           | not null |
- tenant_id   | character varying(10)   |           | not null |
+   Column    |           Type           | Collation | Nullable |      Default
+-------------+--------------------------+-----------+----------+--------------------
+ variant_id  | uuid                     |           | not null | uuid_generate_v4()
+ name        | character varying(128)   |           | not null |
+ description | character varying(1024)  |           |          |
+ info        | jsonb                    |           |          |
+ catalog_id  | uuid                     |           | not null |
+ tenant_id   | character varying(10)    |           | not null |
+ created_at  | timestamp with time zone |           |          | now()
+ updated_at  | timestamp with time zone |           |          | now()
 */
 
 // Variant model definition
@@ -23,4 +27,18 @@ type Variant struct {
 	Description string       `db:"description"`
 	Info        pgtype.JSONB `db:"info"`
 	CatalogID   uuid.UUID    `db:"catalog_id"`
+	CreatedAt   time.Time    `db:"created_at"`
+	UpdatedAt   time.Time    `db:"updated_at"`
+}
+
+// VariantStats holds the number of objects in a variant. ObjectBytes is the size of the data of the distinct
+// catalog objects referenced by the variant's directories, before compression and wherever the data is stored.
+type VariantStats struct {
+	ParameterSchemas  int
+	CollectionSchemas int
+	Collections       int
+	Namespaces        int
+	Workspaces        int
+	Versions          int
+	ObjectBytes       int64
 }
@@ -0,0 +1,175 @@
+package postgresql
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/golang/snappy"
+	"github.com/mugiliam/common/apperrors"
+	"github.com/mugiliam/hatchcatalogsrv/internal/common"
+	"github.com/mugiliam/hatchcatalogsrv/internal/db/blobstore"
+	"github.com/mugiliam/hatchcatalogsrv/internal/db/config"
+	"github.com/mugiliam/hatchcatalogsrv/internal/db/dberror"
+	"github.com/mugiliam/hatchcatalogsrv/pkg/types"
+	"github.com/rs/zerolog/log"
+)
+
+// orphanedBlobBatchSize bounds the number of hashes looked up in catalog_objects at a time when collecting
+// orphaned blobs
+const orphanedBlobBatchSize = 500
+
+// blobMarker is stored in the data column of catalog objects whose data is in the blob store. The data of an
+// object is JSON or snappy compressed JSON, and neither starts with a NUL byte.
+var blobMarker = []byte("\x00blobstore")
+
+func isBlobMarker(data []byte) bool {
+	return bytes.Equal(data, blobMarker)
+}
+
+// encodeObjectData returns the value to store in the data column for the data of an object. The data is
+// compressed if configured, and put in the blob store if it is larger than the inline threshold.
+func encodeObjectData(ctx context.Context, tenantID types.TenantId, hash string, data []byte) ([]byte, apperrors.Error) {
+	dataZ := data
+	if config.CompressCatalogObjects {
+		dataZ = snappy.Encode(nil, data)
+		log.Ctx(ctx).Debug().Msgf("raw: %d, compressed: %d", len(data), len(dataZ))
+	} else {
+		log.Ctx(ctx).Debug().Msg("compression is disabled, using raw data")
+	}
+	bs := blobstore.ForSize(len(dataZ))
+	if bs == nil {
+		return dataZ, nil
+	}
+	if err := bs.Put(ctx, tenantID, hash, dataZ); err != nil {
+		log.Ctx(ctx).Error().Err(err).Str("hash", hash).Msg("failed to store object data in blob store")
+		return nil, dberror.ErrDatabase.Err(err)
+	}
+	return blobMarker, nil
+}
+
+// decodeObjectData returns the data of an object from the value of its data column, loading it from the blob
+// store if it is stored there
+func decodeObjectData(ctx context.Context, tenantID types.TenantId, hash string, stored []byte) ([]byte, apperrors.Error) {
+	data := stored
+	if isBlobMarker(stored) {
+		bs := blobstore.Current()
+		if bs == nil {
+			log.Ctx(ctx).Error().Str("hash", hash).Msg("object data is in the blob store, but no blob store is configured")
+			return nil, dberror.ErrDatabase.Msg("blob store not configured")
+		}
+		var err error
+		if data, err = bs.Get(ctx, tenantID, hash); err != nil {
+			log.Ctx(ctx).Error().Err(err).Str("hash", hash).Msg("failed to load object data from blob store")
+			return nil, dberror.ErrDatabase.Err(err)
+		}
+	}
+	if config.CompressCatalogObjects {
+		var err error
+		if data, err = snappy.Decode(nil, data); err != nil {
+			log.Ctx(ctx).Error().Err(err).Str("hash", hash).Msg("failed to uncompress catalog object data")
+			return nil, dberror.ErrDatabase.Err(err)
+		}
+	}
+	return data, nil
+}
+
+// deleteObjectBlob deletes the data of a deleted object from the blob store, if it was stored there. A failure
+// leaves an unreferenced blob behind, so it is logged rather than returned.
+func deleteObjectBlob(ctx context.Context, tenantID types.TenantId, hash string, stored []byte) {
+	if !isBlobMarker(stored) {
+		return
+	}
+	bs := blobstore.Current()
+	if bs == nil {
+		return
+	}
+	if err := bs.Delete(ctx, tenantID, hash); err != nil {
+		log.Ctx(ctx).Warn().Err(err).Str("hash", hash).Msg("failed to delete object data from blob store")
+	}
+}
+
+// DeleteOrphanedBlobs deletes the tenant's blobs that no catalog object refers to. The blob of an object is
+// stored before the object is inserted, so a blob is left behind when the insert's transaction rolls back.
+// Blobs stored within olderThan are kept, since the object of a young blob may not be committed yet; storing a
+// blob again resets its time. It returns the number of blobs deleted.
+func (om *objectManager) DeleteOrphanedBlobs(ctx context.Context, olderThan time.Duration) (int, apperrors.Error) {
+	tenantID := common.TenantIdFromContext(ctx)
+	if tenantID == "" {
+		return 0, dberror.ErrMissingTenantID
+	}
+	bs := blobstore.Current()
+	if bs == nil {
+		return 0, nil
+	}
+
+	cutoff := time.Now().Add(-olderThan)
+	deleted := 0
+	var batch []string
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		referenced, err := om.blobReferences(ctx, tenantID, batch)
+		if err != nil {
+			return err
+		}
+		for _, hash := range batch {
+			if referenced[hash] {
+				continue
+			}
+			if err := bs.Delete(ctx, tenantID, hash); err != nil {
+				return err
+			}
+			deleted++
+		}
+		batch = batch[:0]
+		return nil
+	}
+	err := bs.List(ctx, tenantID, func(hash string, stored time.Time) error {
+		if !stored.Before(cutoff) {
+			return nil
+		}
+		batch = append(batch, hash)
+		if len(batch) < orphanedBlobBatchSize {
+			return nil
+		}
+		return flush()
+	})
+	if err == nil {
+		err = flush()
+	}
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Int("deleted", deleted).Msg("failed to delete orphaned blobs")
+		return deleted, dberror.ErrDatabase.Err(err)
+	}
+	return deleted, nil
+}
+
+// blobReferences returns the hashes of the tenant's catalog objects whose data is in the blob store
+func (om *objectManager) blobReferences(ctx context.Context, tenantID types.TenantId, hashes []string) (map[string]bool, error) {
+	hashData, err := json.Marshal(hashes)
+	if err != nil {
+		return nil, err
+	}
+	query := `
+		SELECT hash
+		FROM catalog_objects
+		WHERE hash IN (SELECT jsonb_array_elements_text($1::jsonb)) AND tenant_id = $2 AND data = $3
+	`
+	rows, err := om.conn().QueryContext(ctx, query, hashData, tenantID, blobMarker)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	referenced := make(map[string]bool, len(hashes))
+	for rows.Next() {
+		var hash string
+		if err := rows.Scan(&hash); err != nil {
+			return nil, err
+		}
+		referenced[hash] = true
+	}
+	return referenced, rows.Err()
+}
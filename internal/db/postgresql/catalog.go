@@ -5,6 +5,7 @@ import (
 	"database/sql"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgconn"
 	"github.com/jackc/pgtype"
 	"github.com/mugiliam/common/apperrors"
 	"github.com/mugiliam/hatchcatalogsrv/internal/db/dberror"
@@ -27,7 +28,7 @@ func (mm *metadataManager) CreateCatalog(ctx context.Context, catalog *models.Ca
 	}
 
 	// create a transaction
-	tx, errdb := mm.conn().BeginTx(ctx, &sql.TxOptions{})
+	tx, errdb := beginTx(ctx, mm.c, &sql.TxOptions{})
 	if errdb != nil {
 		log.Ctx(ctx).Error().Err(errdb).Msg("failed to start transaction")
 		return dberror.ErrDatabase.Err(errdb)
@@ -44,14 +45,14 @@ func (mm *metadataManager) CreateCatalog(ctx context.Context, catalog *models.Ca
 		INSERT INTO catalogs (catalog_id, name, description, info, tenant_id, project_id)
 		VALUES ($1, $2, $3, $4, $5, $6)
 		ON CONFLICT (name, project_id, tenant_id) DO NOTHING
-		RETURNING catalog_id, name;
+		RETURNING catalog_id, name, created_at, updated_at;
 	`
 
 	// Execute the query directly using mm.conn().QueryRowContext
 	row := tx.QueryRowContext(ctx, query, catalogID, catalog.Name, catalog.Description, catalog.Info, tenantID, projectID)
 	var insertedCatalogID uuid.UUID
 	var insertedName string
-	errDb := row.Scan(&insertedCatalogID, &insertedName)
+	errDb := row.Scan(&insertedCatalogID, &insertedName, &catalog.CreatedAt, &catalog.UpdatedAt)
 	if errDb != nil {
 		tx.Rollback()
 		if errDb == sql.ErrNoRows {
@@ -122,11 +123,11 @@ func (mm *metadataManager) GetCatalog(ctx context.Context, catalogID uuid.UUID,
 
 	// Construct the query based on input
 	query := `
-        SELECT catalog_id, name, description, info, project_id
+        SELECT catalog_id, name, description, info, project_id, created_at, updated_at
         FROM catalogs
         WHERE tenant_id = $2 AND project_id = $3 AND `
 
-	var row *sql.Row
+	var row rowScanner
 	if catalogID != uuid.Nil {
 		query += "catalog_id = $1;"
 		row = mm.conn().QueryRowContext(ctx, query, catalogID, tenantID, projectID)
@@ -137,7 +138,8 @@ func (mm *metadataManager) GetCatalog(ctx context.Context, catalogID uuid.UUID,
 
 	// Scan the result into the catalog model
 	var catalog models.Catalog
-	errDb := row.Scan(&catalog.CatalogID, &catalog.Name, &catalog.Description, &catalog.Info, &catalog.ProjectID)
+	errDb := row.Scan(&catalog.CatalogID, &catalog.Name, &catalog.Description, &catalog.Info, &catalog.ProjectID,
+		&catalog.CreatedAt, &catalog.UpdatedAt)
 	if errDb != nil {
 		if errDb == sql.ErrNoRows {
 			log.Ctx(ctx).Info().Str("name", name).Str("catalog_id", catalogID.String()).Msg("catalog not found")
@@ -165,24 +167,27 @@ func (mm *metadataManager) UpdateCatalog(ctx context.Context, catalog *models.Ca
 		return dberror.ErrInvalidInput.Msg("catalogID or name must be provided")
 	}
 
-	// Construct the update query based on the provided input
+	// Construct the update query based on the provided input. updated_at is only bumped if the
+	// description or info actually changes, so saving the same catalog again is a no-op.
 	query := `
 		UPDATE catalogs
-		SET description = $4, info = $5
+		SET description = $4, info = $5,
+			updated_at = CASE WHEN description IS DISTINCT FROM $4 OR info IS DISTINCT FROM $5
+				THEN now() ELSE updated_at END
 		WHERE tenant_id = $2 AND project_id = $3 AND `
 
-	var row *sql.Row
+	var row rowScanner
 	if catalog.CatalogID != uuid.Nil {
-		query += "catalog_id = $1 RETURNING catalog_id, name;"
+		query += "catalog_id = $1 RETURNING catalog_id, name, created_at, updated_at;"
 		row = mm.conn().QueryRowContext(ctx, query, catalog.CatalogID, tenantID, projectID, catalog.Description, catalog.Info)
 	} else {
-		query += "name = $1 RETURNING catalog_id, name;"
+		query += "name = $1 RETURNING catalog_id, name, created_at, updated_at;"
 		row = mm.conn().QueryRowContext(ctx, query, catalog.Name, tenantID, projectID, catalog.Description, catalog.Info)
 	}
 
 	// Scan the updated values
 	var updatedCatalogID, updatedName string
-	errDb := row.Scan(&updatedCatalogID, &updatedName)
+	errDb := row.Scan(&updatedCatalogID, &updatedName, &catalog.CreatedAt, &catalog.UpdatedAt)
 	if errDb != nil {
 		if errDb == sql.ErrNoRows {
 			log.Ctx(ctx).Info().Str("name", catalog.Name).Str("catalog_id", catalog.CatalogID.String()).Msg("catalog not found for update")
@@ -195,6 +200,42 @@ func (mm *metadataManager) UpdateCatalog(ctx context.Context, catalog *models.Ca
 	return nil
 }
 
+// RenameCatalog sets the name of the catalog with catalogID to newName. Variants and the objects under them
+// refer to the catalog by its ID, so they are kept.
+func (mm *metadataManager) RenameCatalog(ctx context.Context, catalogID uuid.UUID, newName string) apperrors.Error {
+	tenantID, projectID, err := getTenantAndProjectFromContext(ctx)
+	if err != nil {
+		return err
+	}
+	if catalogID == uuid.Nil || newName == "" {
+		log.Ctx(ctx).Error().Msg("catalogID and new name must be provided")
+		return dberror.ErrInvalidInput.Msg("catalogID and new name must be provided")
+	}
+
+	query := `
+		UPDATE catalogs
+		SET name = $1, updated_at = now()
+		WHERE catalog_id = $2 AND tenant_id = $3 AND project_id = $4
+		RETURNING catalog_id;`
+
+	var renamedCatalogID uuid.UUID
+	errDb := mm.conn().QueryRowContext(ctx, query, newName, catalogID, tenantID, projectID).Scan(&renamedCatalogID)
+	if errDb != nil {
+		if errDb == sql.ErrNoRows {
+			log.Ctx(ctx).Info().Str("catalog_id", catalogID.String()).Msg("catalog not found for rename")
+			return dberror.ErrNotFound.Msg("catalog not found")
+		}
+		if pgErr, ok := errDb.(*pgconn.PgError); ok && pgErr.Code == "23505" {
+			log.Ctx(ctx).Info().Str("name", newName).Msg("catalog already exists")
+			return dberror.ErrAlreadyExists.Msg("catalog already exists")
+		}
+		log.Ctx(ctx).Error().Err(errDb).Str("name", newName).Str("catalog_id", catalogID.String()).Msg("failed to rename catalog")
+		return dberror.ErrDatabase.Err(errDb)
+	}
+
+	return nil
+}
+
 // DeleteCatalog deletes a catalog from the database.
 // If both catalogID and name are provided, catalogID takes precedence.
 func (mm *metadataManager) DeleteCatalog(ctx context.Context, catalogID uuid.UUID, name string) apperrors.Error {
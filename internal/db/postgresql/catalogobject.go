@@ -1,16 +1,18 @@
 package postgresql
 
 import (
+	"bytes"
 	"context"
 	"database/sql"
+	"encoding/json"
+	"errors"
+	"time"
 
-	"github.com/golang/snappy"
 	"github.com/mugiliam/common/apperrors"
 	"github.com/mugiliam/hatchcatalogsrv/internal/common"
 	"github.com/mugiliam/hatchcatalogsrv/internal/db/config"
 	"github.com/mugiliam/hatchcatalogsrv/internal/db/dberror"
 	"github.com/mugiliam/hatchcatalogsrv/internal/db/models"
-	"github.com/mugiliam/hatchcatalogsrv/pkg/types"
 	"github.com/rs/zerolog/log"
 )
 
@@ -32,41 +34,67 @@ func (om *objectManager) CreateCatalogObject(ctx context.Context, obj *models.Ca
 		return dberror.ErrInvalidInput.Msg("data cannot be nil")
 	}
 
-	// snappy compress the data
-	var dataZ []byte
-	if config.CompressCatalogObjects {
-		dataZ = snappy.Encode(nil, obj.Data)
-		log.Ctx(ctx).Debug().Msgf("raw: %d, compressed: %d", len(obj.Data), len(dataZ))
-	} else {
-		dataZ = obj.Data // No compression
-		log.Ctx(ctx).Debug().Msg("compression is disabled, using raw data")
+	// An object that is already stored is not written again, since the same hash means the same data. When
+	// configured, that is verified so that a hash collision or a corrupted earlier write is not hidden. This
+	// is done before writing, since a write would replace the data of an object in the blob store.
+	if config.VerifyCatalogObjects() {
+		stored, err := om.GetCatalogObject(ctx, obj.Hash)
+		if err == nil {
+			if stored.Type != obj.Type || !bytes.Equal(stored.Data, obj.Data) {
+				log.Ctx(ctx).Error().Str("hash", obj.Hash).Msg("stored catalog object does not match the object being written")
+				return dberror.ErrObjectHashMismatch
+			}
+			// the object is about to be referenced again, so it must not be collected
+			if err := om.touchCatalogObject(ctx, obj.Hash); err != nil {
+				return err
+			}
+			return dberror.ErrAlreadyExists.Msg("catalog object already exists")
+		}
+		if !errors.Is(err, dberror.ErrNotFound) {
+			return err
+		}
 	}
 
-	// Insert the catalog object into the database
-	query := `
-		INSERT INTO catalog_objects (hash, type, version, tenant_id, data)
-		VALUES ($1, $2, $3, $4, $5)
-		ON CONFLICT (hash, tenant_id) DO NOTHING;
-	`
-	result, err := om.conn().ExecContext(ctx, query, obj.Hash, obj.Type, obj.Version, tenantID, dataZ)
-	if err != nil {
-		return dberror.ErrDatabase.Err(err)
+	// compress the data and move it to the blob store if it is large
+	dataZ, apperr := encodeObjectData(ctx, tenantID, obj.Hash, obj.Data)
+	if apperr != nil {
+		return apperr
 	}
 
-	// Check if the row was inserted
-	rowsAffected, err := result.RowsAffected()
+	// Insert the catalog object into the database. An object that already exists is about to be referenced
+	// again, so its created_at is reset to restart the grace period of DeleteUnreferencedCatalogObjects. The
+	// update also locks the row, so a concurrent collection, which skips locked rows, cannot delete it.
+	query := `
+		INSERT INTO catalog_objects (hash, type, version, tenant_id, data, size)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (hash, tenant_id) DO UPDATE SET created_at = now()
+		RETURNING (xmax = 0) AS inserted;
+	`
+	var inserted bool
+	err := om.conn().QueryRowContext(ctx, query, obj.Hash, obj.Type, obj.Version, tenantID, dataZ, len(obj.Data)).Scan(&inserted)
 	if err != nil {
 		return dberror.ErrDatabase.Err(err)
 	}
 
-	// If no rows were affected, it means the object already exists
-	if rowsAffected == 0 {
+	// If the row was not inserted, the object already exists
+	if !inserted {
 		return dberror.ErrAlreadyExists.Msg("catalog object already exists")
 	}
 
 	return nil
 }
 
+// touchCatalogObject resets the created_at of the catalog object with the hash, which restarts its grace period
+// for DeleteUnreferencedCatalogObjects
+func (om *objectManager) touchCatalogObject(ctx context.Context, hash string) apperrors.Error {
+	tenantID := common.TenantIdFromContext(ctx)
+	query := `UPDATE catalog_objects SET created_at = now() WHERE hash = $1 AND tenant_id = $2;`
+	if _, err := om.conn().ExecContext(ctx, query, hash, tenantID); err != nil {
+		return dberror.ErrDatabase.Err(err)
+	}
+	return nil
+}
+
 func (om *objectManager) GetCatalogObject(ctx context.Context, hash string) (*models.CatalogObject, apperrors.Error) {
 	tenantID := common.TenantIdFromContext(ctx)
 	if tenantID == "" {
@@ -96,76 +124,183 @@ func (om *objectManager) GetCatalogObject(ctx context.Context, hash string) (*mo
 	}
 
 	// Uncompress the data
-	if config.CompressCatalogObjects {
-		obj.Data, err = snappy.Decode(nil, obj.Data)
-		if err != nil {
-			log.Ctx(ctx).Error().Err(err).Msg("failed to uncompress catalog object data")
-			return nil, dberror.ErrDatabase.Err(err)
-		}
+	data, apperr := decodeObjectData(ctx, tenantID, obj.Hash, obj.Data)
+	if apperr != nil {
+		return nil, apperr
 	}
+	obj.Data = data
 
 	return &obj, nil
 }
 
-func (om *objectManager) DeleteCatalogObject(ctx context.Context, t types.CatalogObjectType, hash string) apperrors.Error {
+// GetCatalogObjects loads the catalog objects for the given hashes in a single query. The result is
+// keyed by hash; hashes that do not exist are omitted from the map.
+func (om *objectManager) GetCatalogObjects(ctx context.Context, hashes []string) (map[string]*models.CatalogObject, apperrors.Error) {
 	tenantID := common.TenantIdFromContext(ctx)
 	if tenantID == "" {
-		return dberror.ErrMissingTenantID
+		return nil, dberror.ErrMissingTenantID
 	}
-	if hash == "" {
-		return dberror.ErrInvalidInput.Msg("hash cannot be empty")
+	objs := make(map[string]*models.CatalogObject, len(hashes))
+	if len(hashes) == 0 {
+		return objs, nil
 	}
 
-	var table string
-	switch t {
-	case types.CatalogObjectTypeParameterSchema:
-		table = "parameters_directory"
-	case types.CatalogObjectTypeCollectionSchema:
-		table = "collections_directory"
-	case types.CatalogObjectTypeCatalogCollection:
-		table = "values_directory"
-	default:
-		return dberror.ErrInvalidInput.Msg("invalid catalog object type")
+	hashData, err := json.Marshal(hashes)
+	if err != nil {
+		return nil, dberror.ErrDatabase.Err(err)
 	}
 
-	// look for references in this table for this has
 	query := `
-		SELECT 1
-		FROM ` + table + `
-		WHERE jsonb_path_query_array(directory, '$.*.hash') @> to_jsonb($1::text)
-		AND tenant_id = $2
-		LIMIT 1;
+		SELECT hash, type, version, tenant_id, data
+		FROM catalog_objects
+		WHERE hash IN (SELECT jsonb_array_elements_text($1::jsonb)) AND tenant_id = $2
 	`
-	var exists bool // we'll probably just hit the ErrNoRows case in case of false
-	dberr := om.conn().QueryRowContext(ctx, query, hash, tenantID).Scan(&exists)
-	if dberr != nil {
-		if dberr != sql.ErrNoRows {
-			return dberror.ErrDatabase.Err(dberr)
+	rows, err := om.conn().QueryContext(ctx, query, hashData, tenantID)
+	if err != nil {
+		return nil, dberror.ErrDatabase.Err(err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var obj models.CatalogObject
+		if err := rows.Scan(&obj.Hash, &obj.Type, &obj.Version, &obj.TenantID, &obj.Data); err != nil {
+			return nil, dberror.ErrDatabase.Err(err)
+		}
+		// Uncompress the data
+		data, apperr := decodeObjectData(ctx, tenantID, obj.Hash, obj.Data)
+		if apperr != nil {
+			return nil, apperr
 		}
+		obj.Data = data
+		objs[obj.Hash] = &obj
 	}
-	if exists {
-		// do nothing. There are other references to this object
-		return nil
+	if err := rows.Err(); err != nil {
+		return nil, dberror.ErrDatabase.Err(err)
 	}
-	query = `
-		DELETE FROM catalog_objects
-		WHERE hash = $1 AND tenant_id = $2
+
+	return objs, nil
+}
+
+// DeleteCatalogObject deletes the catalog object with the hash if no directory entry or collection history entry
+// refers to it, checking the references and deleting in one statement. An object that is still referenced is
+// kept and nil is returned; ErrNotFound is returned if there is no object with the hash.
+func (om *objectManager) DeleteCatalogObject(ctx context.Context, hash string) apperrors.Error {
+	tenantID := common.TenantIdFromContext(ctx)
+	if tenantID == "" {
+		return dberror.ErrMissingTenantID
+	}
+	if hash == "" {
+		return dberror.ErrInvalidInput.Msg("hash cannot be empty")
+	}
+
+	query := `
+		DELETE FROM catalog_objects co
+		WHERE co.hash = $1 AND co.tenant_id = $2
+		AND NOT EXISTS (
+			SELECT 1 FROM parameters_directory d
+			WHERE d.tenant_id = $2
+			AND jsonb_path_query_array(d.directory, '$.*.hash') @> to_jsonb($1::text)
+		)
+		AND NOT EXISTS (
+			SELECT 1 FROM collections_directory d
+			WHERE d.tenant_id = $2
+			AND jsonb_path_query_array(d.directory, '$.*.hash') @> to_jsonb($1::text)
+		)
+		AND NOT EXISTS (
+			SELECT 1 FROM values_directory d
+			WHERE d.tenant_id = $2
+			AND jsonb_path_query_array(d.directory, '$.*.hash') @> to_jsonb($1::text)
+		)
+		AND NOT EXISTS (
+			SELECT 1 FROM collection_history h
+			WHERE h.tenant_id = $2 AND h.hash = $1
+		)
+		RETURNING data
 	`
-	result, err := om.conn().ExecContext(ctx, query, hash, tenantID)
-	if err != nil {
+	var stored []byte
+	err := om.conn().QueryRowContext(ctx, query, hash, tenantID).Scan(&stored)
+	if err == nil {
+		deleteObjectBlob(ctx, tenantID, hash, stored)
+		return nil
+	}
+	if err != sql.ErrNoRows {
 		return dberror.ErrDatabase.Err(err)
 	}
 
-	// Check if the row was deleted
-	rowsAffected, err := result.RowsAffected()
-	if err != nil {
+	// nothing was deleted; tell an object that is still referenced from one that does not exist
+	var exists bool
+	query = `SELECT EXISTS (SELECT 1 FROM catalog_objects WHERE hash = $1 AND tenant_id = $2)`
+	if err := om.conn().QueryRowContext(ctx, query, hash, tenantID).Scan(&exists); err != nil {
 		return dberror.ErrDatabase.Err(err)
 	}
-
-	// If no rows were affected, it means the object does not exist
-	if rowsAffected == 0 {
+	if !exists {
 		return dberror.ErrNotFound.Msg("catalog object not found")
 	}
-
 	return nil
 }
+
+// DeleteUnreferencedCatalogObjects deletes up to limit catalog objects of the tenant that are not referenced by
+// any directory or collection history entry. Objects created within olderThan are skipped, since a writer may have
+// created them and not yet added the directory entry that references them. A writer that reuses an existing object
+// resets its created_at, so reused objects are skipped as well. It returns the number of
+// objects deleted.
+func (om *objectManager) DeleteUnreferencedCatalogObjects(ctx context.Context, olderThan time.Duration, limit int) (int, apperrors.Error) {
+	tenantID := common.TenantIdFromContext(ctx)
+	if tenantID == "" {
+		return 0, dberror.ErrMissingTenantID
+	}
+	if limit <= 0 {
+		return 0, dberror.ErrInvalidInput.Msg("limit must be positive")
+	}
+
+	query := `
+		DELETE FROM catalog_objects
+		WHERE tenant_id = $1 AND hash IN (
+			SELECT co.hash
+			FROM catalog_objects co
+			WHERE co.tenant_id = $1
+			AND co.created_at < now() - make_interval(secs => $2)
+			AND NOT EXISTS (
+				SELECT 1 FROM parameters_directory d
+				WHERE d.tenant_id = $1
+				AND jsonb_path_query_array(d.directory, '$.*.hash') @> to_jsonb(co.hash::text)
+			)
+			AND NOT EXISTS (
+				SELECT 1 FROM collections_directory d
+				WHERE d.tenant_id = $1
+				AND jsonb_path_query_array(d.directory, '$.*.hash') @> to_jsonb(co.hash::text)
+			)
+			AND NOT EXISTS (
+				SELECT 1 FROM values_directory d
+				WHERE d.tenant_id = $1
+				AND jsonb_path_query_array(d.directory, '$.*.hash') @> to_jsonb(co.hash::text)
+			)
+			AND NOT EXISTS (
+				SELECT 1 FROM collection_history h
+				WHERE h.tenant_id = $1 AND h.hash = co.hash
+			)
+			LIMIT $3
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING hash, data;
+	`
+	rows, err := om.conn().QueryContext(ctx, query, tenantID, olderThan.Seconds(), limit)
+	if err != nil {
+		return 0, dberror.ErrDatabase.Err(err)
+	}
+	defer rows.Close()
+	deleted := 0
+	for rows.Next() {
+		var hash string
+		var stored []byte
+		if err := rows.Scan(&hash, &stored); err != nil {
+			return deleted, dberror.ErrDatabase.Err(err)
+		}
+		deleteObjectBlob(ctx, tenantID, hash, stored)
+		deleted++
+	}
+	if err := rows.Err(); err != nil {
+		return deleted, dberror.ErrDatabase.Err(err)
+	}
+	return deleted, nil
+}
@@ -3,6 +3,7 @@ package postgresql
 import (
 	"context"
 	"database/sql"
+	"strings"
 
 	"github.com/google/uuid"
 	"github.com/mugiliam/common/apperrors"
@@ -10,6 +11,7 @@ import (
 	"github.com/mugiliam/hatchcatalogsrv/internal/db/dberror"
 	"github.com/mugiliam/hatchcatalogsrv/internal/db/models"
 	"github.com/mugiliam/hatchcatalogsrv/pkg/types"
+	"github.com/rs/zerolog/log"
 )
 
 // The Collections interface functions are a shim on top of schema directory.  This would allow for a different implementation
@@ -31,17 +33,75 @@ func (om *objectManager) UpsertCollection(ctx context.Context, c *models.Collect
 		dir,
 		c.Path,
 		models.ObjectRef{
-			Hash:       c.Hash,
-			BaseSchema: c.CollectionSchema,
+			Hash:           c.Hash,
+			BaseSchema:     c.CollectionSchema,
+			BaseSchemaHash: c.SchemaHash,
 		},
 	)
 	if err != nil {
 		return err
 	}
 
+	// history is informational; a failure to record it does not fail the upsert
+	if err := om.appendCollectionHistory(ctx, dir, c.Path, c.Hash); err != nil {
+		log.Ctx(ctx).Error().Err(err).Str("path", c.Path).Msg("failed to record collection history")
+	}
+
+	return nil
+}
+
+func (om *objectManager) appendCollectionHistory(ctx context.Context, dir uuid.UUID, path, hash string) apperrors.Error {
+	tenantID := common.TenantIdFromContext(ctx)
+	query := `
+		INSERT INTO collection_history (directory_id, path, hash, tenant_id)
+		VALUES ($1, $2, $3, $4)
+	`
+	if _, err := om.conn().ExecContext(ctx, query, dir, path, hash, tenantID); err != nil {
+		return dberror.ErrDatabase.Err(err)
+	}
 	return nil
 }
 
+// ListCollectionHistory returns the hashes the collection at path has pointed to in the directory, oldest first.
+// A collection that was never upserted in the directory has an empty history.
+func (om *objectManager) ListCollectionHistory(ctx context.Context, path string, dir uuid.UUID) ([]models.CollectionHistoryEntry, apperrors.Error) {
+	tenantID := common.TenantIdFromContext(ctx)
+	if tenantID == "" {
+		return nil, dberror.ErrMissingTenantID
+	}
+
+	query := `
+		SELECT hash, created_at
+		FROM collection_history
+		WHERE directory_id = $1 AND path = $2 AND tenant_id = $3
+		ORDER BY history_id ASC
+	`
+	rows, err := om.conn().QueryContext(ctx, query, dir, path, tenantID)
+	if err != nil {
+		return nil, dberror.ErrDatabase.Err(err)
+	}
+	defer rows.Close()
+
+	history := []models.CollectionHistoryEntry{}
+	for rows.Next() {
+		e := models.CollectionHistoryEntry{
+			DirectoryID: dir,
+			Path:        path,
+			TenantID:    tenantID,
+		}
+		if err := rows.Scan(&e.Hash, &e.CreatedAt); err != nil {
+			log.Ctx(ctx).Error().Err(err).Msg("failed to scan collection history row")
+			return nil, dberror.ErrDatabase.Err(err)
+		}
+		e.Hash = strings.TrimSpace(e.Hash)
+		history = append(history, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, dberror.ErrDatabase.Err(err)
+	}
+	return history, nil
+}
+
 func (om *objectManager) GetCollection(ctx context.Context, path string, dir uuid.UUID) (*models.Collection, apperrors.Error) {
 	tenantID := common.TenantIdFromContext(ctx)
 	if tenantID == "" {
@@ -57,6 +117,7 @@ func (om *objectManager) GetCollection(ctx context.Context, path string, dir uui
 		Path:             path,
 		Hash:             objRef.Hash,
 		CollectionSchema: objRef.BaseSchema,
+		SchemaHash:       objRef.BaseSchemaHash,
 	}, nil
 }
 
@@ -108,9 +169,100 @@ func (om *objectManager) DeleteCollection(ctx context.Context, path string, dir
 		return "", err
 	}
 
+	// the history goes with the collection, so the objects it refers to can be reclaimed
+	if deletedHash != "" {
+		if err := om.deleteCollectionHistory(ctx, dir, path); err != nil {
+			log.Ctx(ctx).Error().Err(err).Str("path", path).Msg("failed to delete collection history")
+		}
+	}
+
 	return string(deletedHash), nil
 }
 
+func (om *objectManager) deleteCollectionHistory(ctx context.Context, dir uuid.UUID, path string) apperrors.Error {
+	tenantID := common.TenantIdFromContext(ctx)
+	query := `
+		DELETE FROM collection_history
+		WHERE directory_id = $1 AND path = $2 AND tenant_id = $3
+	`
+	if _, err := om.conn().ExecContext(ctx, query, dir, path, tenantID); err != nil {
+		return dberror.ErrDatabase.Err(err)
+	}
+	return nil
+}
+
+// ListCollectionsByPathPrefix returns the collections in the directory whose path is prefix or lies under it,
+// ordered by path. The prefix is matched on path boundaries, so /app matches /app/x but not /application.
+// The first offset collections are skipped, and at most limit are returned, or all of them if limit is 0.
+// Since paths are unique, the order is the same across calls and pages do not overlap.
+func (om *objectManager) ListCollectionsByPathPrefix(ctx context.Context, prefix string, dir uuid.UUID, limit, offset int) ([]models.Collection, apperrors.Error) {
+	if limit < 0 || offset < 0 {
+		return nil, dberror.ErrInvalidInput.Msg("limit and offset must not be negative")
+	}
+	collections := []models.Collection{}
+	err := om.queryCollectionsByPathPrefix(ctx, prefix, dir, limit, offset, func(c models.Collection) apperrors.Error {
+		collections = append(collections, c)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return collections, nil
+}
+
+// ForEachCollectionByPathPrefix calls fn for each collection in the directory whose path is prefix or lies
+// under it, in path order, as the rows are read from the database, so the collections are never all held in
+// memory. It stops at the first error returned by fn and returns it.
+func (om *objectManager) ForEachCollectionByPathPrefix(ctx context.Context, prefix string, dir uuid.UUID, fn func(c models.Collection) apperrors.Error) apperrors.Error {
+	return om.queryCollectionsByPathPrefix(ctx, prefix, dir, 0, 0, fn)
+}
+
+func (om *objectManager) queryCollectionsByPathPrefix(ctx context.Context, prefix string, dir uuid.UUID, limit, offset int, fn func(c models.Collection) apperrors.Error) apperrors.Error {
+	tenantID := common.TenantIdFromContext(ctx)
+	if tenantID == "" {
+		return dberror.ErrMissingTenantID
+	}
+	prefix = strings.TrimSuffix(prefix, "/")
+	if !isValidPath(prefix) {
+		return dberror.ErrInvalidInput.Msg("invalid path prefix")
+	}
+	// LIMIT NULL is no limit
+	var maxRows any
+	if limit > 0 {
+		maxRows = limit
+	}
+
+	// starts_with is used rather than LIKE, since '_' is valid in a path and is a LIKE wildcard
+	query := `
+		SELECT key, value->>'hash', COALESCE(value->>'base_schema', '')
+		FROM values_directory, LATERAL jsonb_each(directory)
+		WHERE directory_id = $1 AND tenant_id = $2
+		AND (key = $3 OR starts_with(key, $3 || '/'))
+		ORDER BY key
+		LIMIT $4 OFFSET $5;
+	`
+	rows, err := om.conn().QueryContext(ctx, query, dir, tenantID, prefix, maxRows, offset)
+	if err != nil {
+		return dberror.ErrDatabase.Err(err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		c := models.Collection{TenantID: tenantID}
+		if err := rows.Scan(&c.Path, &c.Hash, &c.CollectionSchema); err != nil {
+			log.Ctx(ctx).Error().Err(err).Msg("failed to scan collection row")
+			return dberror.ErrDatabase.Err(err)
+		}
+		if err := fn(c); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return dberror.ErrDatabase.Err(err)
+	}
+	return nil
+}
+
 func (om *objectManager) HasReferencesToCollectionSchema(ctx context.Context, collectionSchema string, dir uuid.UUID) (bool, apperrors.Error) {
 	tenantID := common.TenantIdFromContext(ctx)
 	if tenantID == "" {
@@ -0,0 +1,112 @@
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/mugiliam/common/apperrors"
+	"github.com/mugiliam/hatchcatalogsrv/internal/db/dberror"
+	"github.com/mugiliam/hatchcatalogsrv/internal/db/models"
+)
+
+// CreateIdempotencyKey records k for the project until ttl elapses. An expired record with the same key is
+// replaced. It returns ErrAlreadyExists if an unexpired record with the same key exists.
+func (mm *metadataManager) CreateIdempotencyKey(ctx context.Context, k *models.IdempotencyKey, ttl time.Duration) apperrors.Error {
+	tenantID, projectID, err := getTenantAndProjectFromContext(ctx)
+	if err != nil {
+		return err
+	}
+	if k.Key == "" {
+		return dberror.ErrInvalidInput.Msg("idempotency key cannot be empty")
+	}
+	k.TenantID = tenantID
+	k.ProjectID = projectID
+
+	query := `
+		INSERT INTO idempotency_keys (key, tenant_id, project_id, request_hash, status_code, location, response, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, now() + make_interval(secs => $8))
+		ON CONFLICT (key, tenant_id, project_id) DO UPDATE
+		SET request_hash = EXCLUDED.request_hash,
+			status_code = EXCLUDED.status_code,
+			location = EXCLUDED.location,
+			response = EXCLUDED.response,
+			created_at = now(),
+			expires_at = EXCLUDED.expires_at
+		WHERE idempotency_keys.expires_at <= now()
+		RETURNING created_at, expires_at;
+	`
+	dbErr := mm.conn().QueryRowContext(ctx, query, k.Key, tenantID, projectID, k.RequestHash, k.StatusCode, k.Location, k.Response, ttl.Seconds()).
+		Scan(&k.CreatedAt, &k.ExpiresAt)
+	if dbErr != nil {
+		if dbErr == sql.ErrNoRows {
+			return dberror.ErrAlreadyExists.Msg("idempotency key already exists")
+		}
+		return dberror.ErrDatabase.Err(dbErr)
+	}
+	return nil
+}
+
+// GetIdempotencyKey returns the unexpired record for key in the project
+func (mm *metadataManager) GetIdempotencyKey(ctx context.Context, key string) (*models.IdempotencyKey, apperrors.Error) {
+	tenantID, projectID, err := getTenantAndProjectFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+		SELECT key, tenant_id, project_id, request_hash, status_code, COALESCE(location, ''), response, created_at, expires_at
+		FROM idempotency_keys
+		WHERE key = $1 AND tenant_id = $2 AND project_id = $3 AND expires_at > now();
+	`
+	k := &models.IdempotencyKey{}
+	dbErr := mm.conn().QueryRowContext(ctx, query, key, tenantID, projectID).
+		Scan(&k.Key, &k.TenantID, &k.ProjectID, &k.RequestHash, &k.StatusCode, &k.Location, &k.Response, &k.CreatedAt, &k.ExpiresAt)
+	if dbErr != nil {
+		if dbErr == sql.ErrNoRows {
+			return nil, dberror.ErrNotFound.Msg("idempotency key not found")
+		}
+		return nil, dberror.ErrDatabase.Err(dbErr)
+	}
+	return k, nil
+}
+
+// UpdateIdempotencyKey records the outcome in k of the request that holds the unexpired key k.Key in the project
+func (mm *metadataManager) UpdateIdempotencyKey(ctx context.Context, k *models.IdempotencyKey) apperrors.Error {
+	tenantID, projectID, err := getTenantAndProjectFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		UPDATE idempotency_keys
+		SET status_code = $4, location = $5, response = $6
+		WHERE key = $1 AND tenant_id = $2 AND project_id = $3 AND request_hash = $7 AND expires_at > now();
+	`
+	result, dbErr := mm.conn().ExecContext(ctx, query, k.Key, tenantID, projectID, k.StatusCode, k.Location, k.Response, k.RequestHash)
+	if dbErr != nil {
+		return dberror.ErrDatabase.Err(dbErr)
+	}
+	rowsAffected, dbErr := result.RowsAffected()
+	if dbErr != nil {
+		return dberror.ErrDatabase.Err(dbErr)
+	}
+	if rowsAffected == 0 {
+		return dberror.ErrNotFound.Msg("idempotency key not found")
+	}
+	return nil
+}
+
+// DeleteIdempotencyKey deletes the record for key in the project
+func (mm *metadataManager) DeleteIdempotencyKey(ctx context.Context, key string) apperrors.Error {
+	tenantID, projectID, err := getTenantAndProjectFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	query := `DELETE FROM idempotency_keys WHERE key = $1 AND tenant_id = $2 AND project_id = $3;`
+	if _, dbErr := mm.conn().ExecContext(ctx, query, key, tenantID, projectID); dbErr != nil {
+		return dberror.ErrDatabase.Err(dbErr)
+	}
+	return nil
+}
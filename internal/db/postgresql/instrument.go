@@ -0,0 +1,92 @@
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgconn"
+	"github.com/mugiliam/hatchcatalogsrv/internal/metrics"
+)
+
+// rowScanner is the result of a query that returns at most one row
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+// instrumentedQueryer records the count, latency and errors of the queries made through q in the database
+// metrics. Every query of the managers goes through it, so errors that the callers log and swallow are
+// counted as well.
+type instrumentedQueryer struct {
+	q queryer
+}
+
+func (iq instrumentedQueryer) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	start := time.Now()
+	result, err := iq.q.ExecContext(ctx, query, args...)
+	observeQuery("exec", start, err)
+	return result, err
+}
+
+func (iq instrumentedQueryer) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	start := time.Now()
+	rows, err := iq.q.QueryContext(ctx, query, args...)
+	observeQuery("query", start, err)
+	return rows, err
+}
+
+// QueryRowContext runs the query, which is recorded once its row is scanned, since that is when its error,
+// including sql.ErrNoRows, is known
+func (iq instrumentedQueryer) QueryRowContext(ctx context.Context, query string, args ...any) rowScanner {
+	start := time.Now()
+	return &instrumentedRow{row: iq.q.QueryRowContext(ctx, query, args...), start: start}
+}
+
+type instrumentedRow struct {
+	row   *sql.Row
+	start time.Time
+}
+
+func (r *instrumentedRow) Scan(dest ...any) error {
+	err := r.row.Scan(dest...)
+	observeQuery("query_row", r.start, err)
+	return err
+}
+
+// dbTx is a transaction whose queries are recorded in the database metrics
+type dbTx struct {
+	*sql.Tx
+}
+
+func (tx *dbTx) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	return instrumentedQueryer{q: tx.Tx}.ExecContext(ctx, query, args...)
+}
+
+func (tx *dbTx) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	return instrumentedQueryer{q: tx.Tx}.QueryContext(ctx, query, args...)
+}
+
+func (tx *dbTx) QueryRowContext(ctx context.Context, query string, args ...any) rowScanner {
+	return instrumentedQueryer{q: tx.Tx}.QueryRowContext(ctx, query, args...)
+}
+
+func observeQuery(op string, start time.Time, err error) {
+	metrics.ObserveDBOperation(op, time.Since(start), queryErrorType(err))
+}
+
+// queryErrorType classifies the error of a query for the database error counter. It returns "" if err is nil.
+func queryErrorType(err error) string {
+	var pgErr *pgconn.PgError
+	switch {
+	case err == nil:
+		return ""
+	case errors.Is(err, sql.ErrNoRows):
+		return "not_found"
+	case errors.As(err, &pgErr) && pgErr.Code == "23505":
+		return "already_exists"
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return "canceled"
+	}
+	return "database"
+}
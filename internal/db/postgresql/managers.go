@@ -4,16 +4,61 @@ import (
 	"context"
 	"database/sql"
 
+	"github.com/mugiliam/common/apperrors"
+	"github.com/mugiliam/hatchcatalogsrv/internal/db/dberror"
 	"github.com/mugiliam/hatchcatalogsrv/internal/db/dbmanager"
+	"github.com/rs/zerolog/log"
 )
 
+// queryer is implemented by both *sql.Conn and *sql.Tx
+type queryer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// connOrTx returns the transaction in progress on the connection so that queries made while it is open
+// are part of it, or the connection itself otherwise. Its queries are recorded in the database metrics.
+func connOrTx(c dbmanager.ScopedConn) instrumentedQueryer {
+	if tx := c.Tx(); tx != nil {
+		return instrumentedQueryer{q: tx}
+	}
+	return instrumentedQueryer{q: c.Conn()}
+}
+
+// beginTx starts a transaction local to a single call. It cannot be nested in a transaction started on the
+// connection with BeginTx.
+func beginTx(ctx context.Context, c dbmanager.ScopedConn, opts *sql.TxOptions) (*dbTx, error) {
+	if c.Tx() != nil {
+		return nil, dbmanager.ErrTxInProgress
+	}
+	tx, err := c.Conn().BeginTx(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	return &dbTx{Tx: tx}, nil
+}
+
+// joinOrBeginTx returns the transaction in progress on the connection, or starts one local to the call.
+// The caller commits or rolls back only a local transaction; a joined one is ended by whoever started it.
+func joinOrBeginTx(ctx context.Context, c dbmanager.ScopedConn, opts *sql.TxOptions) (tx *dbTx, local bool, err error) {
+	if tx := c.Tx(); tx != nil {
+		return &dbTx{Tx: tx}, false, nil
+	}
+	sqlTx, err := c.Conn().BeginTx(ctx, opts)
+	if err != nil {
+		return nil, true, err
+	}
+	return &dbTx{Tx: sqlTx}, true, nil
+}
+
 // Metadata Manager
 type metadataManager struct {
 	c dbmanager.ScopedConn
 }
 
-func (mm *metadataManager) conn() *sql.Conn {
-	return mm.c.Conn()
+func (mm *metadataManager) conn() instrumentedQueryer {
+	return connOrTx(mm.c)
 }
 
 func newMetadataManager(c dbmanager.ScopedConn) *metadataManager {
@@ -26,8 +71,8 @@ type objectManager struct {
 	m *metadataManager
 }
 
-func (om *objectManager) conn() *sql.Conn {
-	return om.c.Conn()
+func (om *objectManager) conn() instrumentedQueryer {
+	return connOrTx(om.c)
 }
 
 func newObjectManager(c dbmanager.ScopedConn) *objectManager {
@@ -66,3 +111,30 @@ func (cm *connectionManager) DropAllScopes(ctx context.Context) error {
 func (cm *connectionManager) Close(ctx context.Context) {
 	cm.c.Close(ctx)
 }
+
+func (cm *connectionManager) BeginTx(ctx context.Context) apperrors.Error {
+	if err := cm.c.BeginTx(ctx, &sql.TxOptions{}); err != nil {
+		return dberror.ErrDatabase.Err(err)
+	}
+	return nil
+}
+
+func (cm *connectionManager) InTx() bool {
+	return cm.c.Tx() != nil
+}
+
+func (cm *connectionManager) Commit(ctx context.Context) apperrors.Error {
+	if err := cm.c.CommitTx(); err != nil {
+		log.Ctx(ctx).Error().Err(err).Msg("failed to commit transaction")
+		return dberror.ErrDatabase.Err(err)
+	}
+	return nil
+}
+
+func (cm *connectionManager) Rollback(ctx context.Context) apperrors.Error {
+	if err := cm.c.RollbackTx(); err != nil {
+		log.Ctx(ctx).Error().Err(err).Msg("failed to rollback transaction")
+		return dberror.ErrDatabase.Err(err)
+	}
+	return nil
+}
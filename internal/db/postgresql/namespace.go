@@ -22,7 +22,7 @@ func (mm *metadataManager) CreateNamespace(ctx context.Context, ns *models.Names
 
 	ns.TenantID = tenantID
 
-	tx, errStd := mm.conn().BeginTx(ctx, nil)
+	tx, errStd := beginTx(ctx, mm.c, nil)
 	if errStd != nil {
 		log.Ctx(ctx).Error().Err(errStd).Msg("failed to begin transaction")
 		return dberror.ErrDatabase.Err(errStd)
@@ -49,9 +49,9 @@ func (mm *metadataManager) CreateNamespace(ctx context.Context, ns *models.Names
 	return nil
 }
 
-func (mm *metadataManager) createNamespaceWithTransaction(ctx context.Context, ns *models.Namespace, tx *sql.Tx) apperrors.Error {
+func (mm *metadataManager) createNamespaceWithTransaction(ctx context.Context, ns *models.Namespace, tx *dbTx) apperrors.Error {
 	if ns.Name == "" {
-		ns.Name = types.DefaultNamespace
+		ns.Name = types.RootNamespace()
 	}
 	// Treat empty string as NULL
 	description := sql.NullString{String: ns.Description, Valid: ns.Description != ""}
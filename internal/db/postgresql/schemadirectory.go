@@ -8,15 +8,15 @@ import (
 	"regexp"
 	"strings"
 
-	"github.com/golang/snappy"
 	"github.com/google/uuid"
 	"github.com/mugiliam/common/apperrors"
 	"github.com/mugiliam/hatchcatalogsrv/internal/common"
-	"github.com/mugiliam/hatchcatalogsrv/internal/db/config"
 	"github.com/mugiliam/hatchcatalogsrv/internal/db/dberror"
 	"github.com/mugiliam/hatchcatalogsrv/internal/db/models"
+	"github.com/mugiliam/hatchcatalogsrv/internal/tracing"
 	"github.com/mugiliam/hatchcatalogsrv/pkg/types"
 	"github.com/rs/zerolog/log"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 func (om *objectManager) CreateSchemaDirectory(ctx context.Context, t types.CatalogObjectType, dir *models.SchemaDirectory) apperrors.Error {
@@ -39,7 +39,7 @@ func (om *objectManager) CreateSchemaDirectory(ctx context.Context, t types.Cata
 
 	dir.TenantID = tenantID
 
-	tx, err := om.conn().BeginTx(ctx, &sql.TxOptions{})
+	tx, err := beginTx(ctx, om.c, &sql.TxOptions{})
 	if err != nil {
 		log.Ctx(ctx).Error().Err(err).Msg("failed to start transaction")
 		return dberror.ErrDatabase.Err(err)
@@ -111,7 +111,7 @@ func (om *objectManager) GetDirectory(ctx context.Context, t types.CatalogObject
 	return dir, nil
 }
 
-func (om *objectManager) createSchemaDirectoryWithTransaction(ctx context.Context, t types.CatalogObjectType, dir *models.SchemaDirectory, tx *sql.Tx) apperrors.Error {
+func (om *objectManager) createSchemaDirectoryWithTransaction(ctx context.Context, t types.CatalogObjectType, dir *models.SchemaDirectory, tx *dbTx) apperrors.Error {
 	tableName := getSchemaDirectoryTableName(t)
 	if tableName == "" {
 		return dberror.ErrInvalidInput.Msg("invalid catalog object type")
@@ -264,19 +264,78 @@ func (om *objectManager) LoadObjectByPath(ctx context.Context, t types.CatalogOb
 		TenantID: tenantID,
 	}
 
-	catalogObj.Data = data
 	// Decompress the data
-	if config.CompressCatalogObjects {
-		catalogObj.Data, err = snappy.Decode(nil, data)
-		if err != nil {
-			return nil, dberror.ErrDatabase.Err(err)
-		}
+	var apperr apperrors.Error
+	if catalogObj.Data, apperr = decodeObjectData(ctx, tenantID, hash, data); apperr != nil {
+		return nil, apperr
 	}
 
 	return catalogObj, nil
 }
 
+// LoadObjectsByPaths loads the catalog objects at the given paths of the directory in a single query. The
+// result is keyed by path; paths that are not in the directory are omitted from the map.
+func (om *objectManager) LoadObjectsByPaths(ctx context.Context, t types.CatalogObjectType, directoryID uuid.UUID, paths []string) (map[string]*models.CatalogObject, apperrors.Error) {
+	tenantID := common.TenantIdFromContext(ctx)
+	if tenantID == "" {
+		return nil, dberror.ErrMissingTenantID
+	}
+	tableName := getSchemaDirectoryTableName(t)
+	if tableName == "" {
+		return nil, dberror.ErrInvalidInput.Msg("invalid catalog object type")
+	}
+
+	objs := make(map[string]*models.CatalogObject, len(paths))
+	if len(paths) == 0 {
+		return objs, nil
+	}
+	pathData, err := json.Marshal(paths)
+	if err != nil {
+		return nil, dberror.ErrDatabase.Err(err)
+	}
+
+	query := `
+		SELECT p.path, co.hash, co.type, co.version, co.tenant_id, co.data
+		FROM ` + tableName + ` d
+		CROSS JOIN jsonb_array_elements_text($1::jsonb) AS p(path)
+		JOIN catalog_objects co
+		ON co.hash = d.directory -> p.path ->> 'hash' AND co.tenant_id = d.tenant_id
+		WHERE d.directory_id = $2 AND d.tenant_id = $3;`
+
+	rows, err := om.conn().QueryContext(ctx, query, pathData, directoryID, tenantID)
+	if err != nil {
+		return nil, dberror.ErrDatabase.Err(err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var p string
+		var obj models.CatalogObject
+		if err := rows.Scan(&p, &obj.Hash, &obj.Type, &obj.Version, &obj.TenantID, &obj.Data); err != nil {
+			return nil, dberror.ErrDatabase.Err(err)
+		}
+		// Uncompress the data
+		data, apperr := decodeObjectData(ctx, tenantID, obj.Hash, obj.Data)
+		if apperr != nil {
+			return nil, apperr
+		}
+		obj.Data = data
+		objs[p] = &obj
+	}
+	if err := rows.Err(); err != nil {
+		return nil, dberror.ErrDatabase.Err(err)
+	}
+	return objs, nil
+}
+
+// AddOrUpdateObjectByPath sets the entry at path in the directory to obj. The entry is set by a single update of
+// the directory row, which locks it, so concurrent writes to the directory are applied one after the other
+// rather than lost, and concurrent writes of the same entry all succeed.
 func (om *objectManager) AddOrUpdateObjectByPath(ctx context.Context, t types.CatalogObjectType, directoryID uuid.UUID, path string, obj models.ObjectRef) apperrors.Error {
+	ctx, span := tracing.Start(ctx, "db.AddOrUpdateObjectByPath",
+		attribute.String("kind", string(t)),
+		attribute.String("path", path),
+	)
+	defer span.End()
 	tenantID := common.TenantIdFromContext(ctx)
 	if tenantID == "" {
 		return dberror.ErrMissingTenantID
@@ -393,6 +452,84 @@ func (om *objectManager) AddReferencesToObject(ctx context.Context, t types.Cata
 	return nil
 }
 
+// AddReferencesToObjects adds the same set of references to each of the objects at the given paths
+// in a single statement. Paths that do not exist in the directory are left untouched.
+func (om *objectManager) AddReferencesToObjects(ctx context.Context, t types.CatalogObjectType, directoryID uuid.UUID, paths []string, references models.References) apperrors.Error {
+	tenantID := common.TenantIdFromContext(ctx)
+	if tenantID == "" {
+		return dberror.ErrMissingTenantID
+	}
+	tableName := getSchemaDirectoryTableName(t)
+	if tableName == "" {
+		return dberror.ErrInvalidInput.Msg("invalid catalog object type")
+	}
+	if len(paths) == 0 || len(references) == 0 {
+		return nil
+	}
+
+	pathData, err := json.Marshal(paths)
+	if err != nil {
+		return dberror.ErrDatabase.Err(err)
+	}
+	referenceData, err := json.Marshal(references)
+	if err != nil {
+		return dberror.ErrDatabase.Err(err)
+	}
+
+	query := `
+		UPDATE ` + tableName + `
+		SET directory = directory || (
+			SELECT COALESCE(jsonb_object_agg(
+				p.path,
+				jsonb_set(
+					directory -> p.path,
+					ARRAY['references'],
+					(
+						SELECT COALESCE(jsonb_agg(DISTINCT x), '[]'::jsonb)
+						FROM (
+							SELECT x
+							FROM jsonb_array_elements(
+								CASE
+									WHEN jsonb_typeof(directory -> p.path -> 'references') = 'array' THEN
+										directory -> p.path -> 'references'
+									ELSE
+										'[]'::jsonb
+								END
+							) AS x
+							WHERE x->>'name' NOT IN (
+								SELECT value->>'name'
+								FROM jsonb_array_elements($2::jsonb) AS value
+							)
+							UNION ALL
+							SELECT value
+							FROM jsonb_array_elements($2::jsonb) AS value
+						) AS combined
+					),
+					true
+				)
+			), '{}'::jsonb)
+			FROM jsonb_array_elements_text($1::jsonb) AS p(path)
+			WHERE directory ? p.path
+		)
+		WHERE directory_id = $3 AND tenant_id = $4;`
+
+	result, err := om.conn().ExecContext(ctx, query, pathData, referenceData, directoryID, tenantID)
+	if err != nil {
+		return dberror.ErrDatabase.Err(err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return dberror.ErrDatabase.Err(err)
+	}
+
+	if rowsAffected == 0 {
+		return dberror.ErrNotFound.Msg("directory not found")
+	}
+
+	return nil
+}
+
 func (om *objectManager) GetAllReferences(ctx context.Context, t types.CatalogObjectType, directoryID uuid.UUID, path string) (models.References, apperrors.Error) {
 	tenantID := common.TenantIdFromContext(ctx)
 	if tenantID == "" {
@@ -480,6 +617,74 @@ func (om *objectManager) DeleteReferenceFromObject(ctx context.Context, t types.
 	return nil
 }
 
+// DeleteReferencesFromObjects removes the named reference from each of the objects at the given paths
+// in a single statement. Paths that do not exist in the directory are left untouched.
+func (om *objectManager) DeleteReferencesFromObjects(ctx context.Context, t types.CatalogObjectType, directoryID uuid.UUID, paths []string, refName string) apperrors.Error {
+	tenantID := common.TenantIdFromContext(ctx)
+	if tenantID == "" {
+		return dberror.ErrMissingTenantID
+	}
+	tableName := getSchemaDirectoryTableName(t)
+	if tableName == "" {
+		return dberror.ErrInvalidInput.Msg("invalid catalog object type")
+	}
+	if len(paths) == 0 {
+		return nil
+	}
+
+	pathData, err := json.Marshal(paths)
+	if err != nil {
+		return dberror.ErrDatabase.Err(err)
+	}
+
+	query := `
+		UPDATE ` + tableName + `
+		SET directory = directory || (
+			SELECT COALESCE(jsonb_object_agg(
+				p.path,
+				jsonb_set(
+					directory -> p.path,
+					ARRAY['references'],
+					COALESCE(
+						(
+							SELECT jsonb_agg(x)
+							FROM jsonb_array_elements(
+								CASE
+									WHEN jsonb_typeof(directory -> p.path -> 'references') = 'array' THEN
+										directory -> p.path -> 'references'
+									ELSE
+										'[]'::jsonb
+								END
+							) x
+							WHERE x->>'name' != $2
+						),
+						'[]'::jsonb
+					),
+					true
+				)
+			), '{}'::jsonb)
+			FROM jsonb_array_elements_text($1::jsonb) AS p(path)
+			WHERE directory ? p.path
+		)
+		WHERE directory_id = $3 AND tenant_id = $4;`
+
+	result, err := om.conn().ExecContext(ctx, query, pathData, refName, directoryID, tenantID)
+	if err != nil {
+		return dberror.ErrDatabase.Err(err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return dberror.ErrDatabase.Err(err)
+	}
+
+	if rowsAffected == 0 {
+		return dberror.ErrNotFound.Msg("directory not found")
+	}
+
+	return nil
+}
+
 func (om *objectManager) DeleteObjectByPath(ctx context.Context, t types.CatalogObjectType, directoryID uuid.UUID, path string) (types.Hash, apperrors.Error) {
 	var hash types.Hash = ""
 	tenantID := common.TenantIdFromContext(ctx)
@@ -572,7 +777,7 @@ func (om *objectManager) DeleteTree(ctx context.Context, directoryIds models.Dir
 		return nil, dberror.ErrInvalidInput.Msg("invalid directory ids")
 	}
 
-	tx, err := om.conn().BeginTx(ctx, &sql.TxOptions{
+	tx, err := beginTx(ctx, om.c, &sql.TxOptions{
 		Isolation: sql.LevelSerializable,
 	})
 	if err != nil {
@@ -759,7 +964,8 @@ func (om *objectManager) DeleteObjectWithReferences(ctx context.Context,
 		return "", dberror.ErrInvalidInput.Msg("invalid catalog object type")
 	}
 
-	tx, err := om.conn().BeginTx(ctx, &sql.TxOptions{
+	// join a transaction in progress so that the delete is part of a larger unit of work
+	tx, localTx, err := joinOrBeginTx(ctx, om.c, &sql.TxOptions{
 		Isolation: sql.LevelSerializable,
 	})
 	if err != nil {
@@ -769,15 +975,19 @@ func (om *objectManager) DeleteObjectWithReferences(ctx context.Context,
 	defer func() {
 		// recover from panic
 		if r := recover(); r != nil {
-			tx.Rollback()
+			if localTx {
+				tx.Rollback()
+			}
 			log.Ctx(ctx).Error().Err(r.(error)).Msg("panic in DeleteObjectWithReferences")
 			// raise the panic back
 			panic(r)
 		} else {
 			if errRet != nil {
 				objHash = ""
-				tx.Rollback()
-			} else {
+				if localTx {
+					tx.Rollback()
+				}
+			} else if localTx {
 				tx.Commit()
 			}
 		}
@@ -932,6 +1142,12 @@ func (om *objectManager) DeleteObjectWithReferences(ctx context.Context,
 // If no path with the specified targetName is found within or above startPath, the function returns an empty string and nil for the object.
 
 func (om *objectManager) FindClosestObject(ctx context.Context, t types.CatalogObjectType, directoryID uuid.UUID, targetName, startPath string) (string, *models.ObjectRef, apperrors.Error) {
+	ctx, span := tracing.Start(ctx, "db.FindClosestObject",
+		attribute.String("kind", string(t)),
+		attribute.String("name", targetName),
+		attribute.String("path", startPath),
+	)
+	defer span.End()
 	tenantID := common.TenantIdFromContext(ctx)
 	if tenantID == "" {
 		return "", nil, dberror.ErrMissingTenantID
@@ -941,67 +1157,50 @@ func (om *objectManager) FindClosestObject(ctx context.Context, t types.CatalogO
 		return "", nil, dberror.ErrInvalidInput.Msg("invalid catalog object type")
 	}
 
-	// Build LIKE pattern for paths ending with "/<targetName>"
-	likePattern := "%" + "/" + targetName
+	// the object can only be at one of the ancestors of startPath, so those paths are looked up directly and
+	// the deepest one present is returned
+	candidates, err := json.Marshal(closestObjectCandidates(startPath, targetName))
+	if err != nil {
+		return "", nil, dberror.ErrDatabase.Err(err)
+	}
 
-	// SQL to find paths ending in targetName, ordered by path length descending
 	query := `
-SELECT key AS path, value AS object
-FROM ` + tableName + `, LATERAL jsonb_each_text(directory)
+SELECT c.path, directory -> c.path AS object
+FROM ` + tableName + `, jsonb_array_elements_text($3::jsonb) WITH ORDINALITY AS c(path, depth)
 WHERE directory_id = $1 AND tenant_id = $2
-  AND key LIKE $3
-ORDER BY LENGTH(key) DESC;
+  AND directory ? c.path
+ORDER BY c.depth
+LIMIT 1;
 `
 
-	rows, err := om.conn().QueryContext(ctx, query, directoryID, tenantID, likePattern)
+	var closestPath string
+	var objectData []byte
+	err = om.conn().QueryRowContext(ctx, query, directoryID, tenantID, candidates).Scan(&closestPath, &objectData)
 	if err != nil {
+		if err == sql.ErrNoRows {
+			return "", nil, nil
+		}
 		return "", nil, dberror.ErrDatabase.Err(err)
 	}
-	defer rows.Close()
 
-	var closestPath string
 	var closestObject models.ObjectRef
-
-	for rows.Next() {
-		var delPath string
-		var objectData []byte
-
-		if err := rows.Scan(&delPath, &objectData); err != nil {
-			return "", nil, dberror.ErrDatabase.Err(err)
-		}
-
-		// Check if the path is equal to or a parent of startPath
-		if isParentPath(delPath, startPath, targetName) {
-			closestPath = delPath
-
-			if err := json.Unmarshal(objectData, &closestObject); err != nil {
-				return "", nil, dberror.ErrDatabase.Err(err)
-			}
-			break
-		}
-	}
-
-	// Error handling for row scan
-	if err := rows.Err(); err != nil {
+	if err := json.Unmarshal(objectData, &closestObject); err != nil {
 		return "", nil, dberror.ErrDatabase.Err(err)
 	}
-
-	if closestPath == "" {
-		return "", nil, nil
-	}
-
 	return closestPath, &closestObject, nil
 }
 
-// isParentPath checks if path is a parent of startPatom.
-func isParentPath(path, startPath, targetName string) bool {
-	parentPath := strings.TrimSuffix(path, "/"+targetName)
-	b := strings.HasPrefix(startPath, parentPath)
-	if b {
-		r := strings.TrimPrefix(startPath, parentPath)
-		return r == "" || strings.HasPrefix(r, "/")
+// closestObjectCandidates returns the paths at which an object named targetName would be a parent of or at
+// startPath, deepest first
+func closestObjectCandidates(startPath, targetName string) []string {
+	var candidates []string
+	for dir := path.Clean("/" + startPath); ; dir = path.Dir(dir) {
+		candidates = append(candidates, path.Join(dir, targetName))
+		if dir == "/" {
+			break
+		}
 	}
-	return false
+	return candidates
 }
 
 func (om *objectManager) PathExists(ctx context.Context, t types.CatalogObjectType, directoryID uuid.UUID, path string) (bool, apperrors.Error) {
@@ -1028,6 +1227,49 @@ func (om *objectManager) PathExists(ctx context.Context, t types.CatalogObjectTy
 	return exists, nil
 }
 
+// PathsExist checks the paths in a single query and returns the set of those that exist in the directory.
+func (om *objectManager) PathsExist(ctx context.Context, t types.CatalogObjectType, directoryID uuid.UUID, paths []string) (map[string]bool, apperrors.Error) {
+	tenantID := common.TenantIdFromContext(ctx)
+	if tenantID == "" {
+		return nil, dberror.ErrMissingTenantID
+	}
+	tableName := getSchemaDirectoryTableName(t)
+	if tableName == "" {
+		return nil, dberror.ErrInvalidInput.Msg("invalid catalog object type")
+	}
+
+	existing := make(map[string]bool)
+	if len(paths) == 0 {
+		return existing, nil
+	}
+	pathData, err := json.Marshal(paths)
+	if err != nil {
+		return nil, dberror.ErrDatabase.Err(err)
+	}
+
+	query := `
+		SELECT p.path
+		FROM ` + tableName + `, jsonb_array_elements_text($1::jsonb) AS p(path)
+		WHERE directory_id = $2 AND tenant_id = $3 AND directory ? p.path;`
+
+	rows, err := om.conn().QueryContext(ctx, query, pathData, directoryID, tenantID)
+	if err != nil {
+		return nil, dberror.ErrDatabase.Err(err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var p string
+		if err := rows.Scan(&p); err != nil {
+			return nil, dberror.ErrDatabase.Err(err)
+		}
+		existing[p] = true
+	}
+	if err := rows.Err(); err != nil {
+		return nil, dberror.ErrDatabase.Err(err)
+	}
+	return existing, nil
+}
+
 func getSchemaDirectoryTableName(t types.CatalogObjectType) string {
 	switch t {
 	case types.CatalogObjectTypeCollectionSchema:
@@ -143,7 +143,7 @@ func (mm *metadataManager) GetProject(ctx context.Context, projectID types.Proje
 	}
 
 	query := `
-		SELECT project_id, tenant_id
+		SELECT project_id, tenant_id, COALESCE(description, '')
 		FROM projects
 		WHERE project_id = $1 AND tenant_id = $2;
 	`
@@ -152,7 +152,7 @@ func (mm *metadataManager) GetProject(ctx context.Context, projectID types.Proje
 	row := mm.conn().QueryRowContext(ctx, query, string(projectID), string(tenantID))
 
 	var project models.Project
-	err := row.Scan(&project.ProjectID, &project.TenantID)
+	err := row.Scan(&project.ProjectID, &project.TenantID, &project.Description)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			log.Ctx(ctx).Info().
@@ -194,3 +194,74 @@ func (mm *metadataManager) DeleteProject(ctx context.Context, projectID types.Pr
 
 	return nil
 }
+
+// ListProjects retrieves the projects of a tenant ordered by project ID.
+func (mm *metadataManager) ListProjects(ctx context.Context, tenantID types.TenantId) ([]*models.Project, error) {
+	if tenantID == "" {
+		log.Ctx(ctx).Error().Msg("tenant ID is missing")
+		return nil, dberror.ErrInvalidInput.Msg("tenant ID is required")
+	}
+
+	query := `
+		SELECT project_id, tenant_id, COALESCE(description, '')
+		FROM projects
+		WHERE tenant_id = $1
+		ORDER BY project_id;
+	`
+	rows, err := mm.conn().QueryContext(ctx, query, string(tenantID))
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Str("tenant_id", string(tenantID)).Msg("failed to list projects")
+		return nil, dberror.ErrDatabase.Err(err)
+	}
+	defer rows.Close()
+
+	var projects []*models.Project
+	for rows.Next() {
+		var project models.Project
+		if err := rows.Scan(&project.ProjectID, &project.TenantID, &project.Description); err != nil {
+			return nil, dberror.ErrDatabase.Err(err)
+		}
+		projects = append(projects, &project)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, dberror.ErrDatabase.Err(err)
+	}
+	return projects, nil
+}
+
+// UpdateProject updates the description of a project of the tenant in the context.
+func (mm *metadataManager) UpdateProject(ctx context.Context, project *models.Project) error {
+	tenantID := common.TenantIdFromContext(ctx)
+
+	// Validate tenantID to ensure it is not empty
+	if tenantID == "" {
+		log.Ctx(ctx).Error().Msg("tenant ID is missing from context")
+		return dberror.ErrInvalidInput.Msg("tenant ID is required")
+	}
+	if project == nil {
+		return dberror.ErrInvalidInput.Msg("project is required")
+	}
+
+	query := `
+		UPDATE projects
+		SET description = $3
+		WHERE project_id = $1 AND tenant_id = $2;
+	`
+	result, err := mm.conn().ExecContext(ctx, query, string(project.ProjectID), string(tenantID), project.Description)
+	if err != nil {
+		log.Ctx(ctx).Error().
+			Err(err).
+			Str("project_id", string(project.ProjectID)).
+			Msg("failed to update project")
+		return dberror.ErrDatabase.Err(err)
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return dberror.ErrDatabase.Err(err)
+	}
+	if n == 0 {
+		return dberror.ErrNotFound.Msg("project not found")
+	}
+	project.TenantID = tenantID
+	return nil
+}
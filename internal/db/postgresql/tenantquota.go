@@ -0,0 +1,113 @@
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/mugiliam/common/apperrors"
+	"github.com/mugiliam/hatchcatalogsrv/internal/common"
+	"github.com/mugiliam/hatchcatalogsrv/internal/db/dberror"
+	"github.com/mugiliam/hatchcatalogsrv/internal/db/models"
+	"github.com/rs/zerolog/log"
+)
+
+// GetTenantQuota returns the quota overrides of the tenant in the context, or ErrNotFound if it has none
+func (mm *metadataManager) GetTenantQuota(ctx context.Context) (*models.TenantQuota, apperrors.Error) {
+	tenantID := common.TenantIdFromContext(ctx)
+	if tenantID == "" {
+		return nil, dberror.ErrMissingTenantID
+	}
+
+	query := `
+		SELECT max_catalogs_per_project, max_objects_per_variant, max_bytes_per_tenant
+		FROM tenant_quotas
+		WHERE tenant_id = $1;
+	`
+	q := models.TenantQuota{TenantID: tenantID}
+	var catalogs, objects, bytes sql.NullInt64
+	err := mm.conn().QueryRowContext(ctx, query, tenantID).Scan(&catalogs, &objects, &bytes)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, dberror.ErrNotFound.Msg("tenant quota not found")
+		}
+		log.Ctx(ctx).Error().Err(err).Msg("failed to get tenant quota")
+		return nil, dberror.ErrDatabase.Err(err)
+	}
+	if catalogs.Valid {
+		n := int(catalogs.Int64)
+		q.MaxCatalogsPerProject = &n
+	}
+	if objects.Valid {
+		n := int(objects.Int64)
+		q.MaxObjectsPerVariant = &n
+	}
+	if bytes.Valid {
+		q.MaxBytesPerTenant = &bytes.Int64
+	}
+	return &q, nil
+}
+
+// SetTenantQuota creates or replaces the quota overrides of the tenant in the context
+func (mm *metadataManager) SetTenantQuota(ctx context.Context, q *models.TenantQuota) apperrors.Error {
+	tenantID := common.TenantIdFromContext(ctx)
+	if tenantID == "" {
+		return dberror.ErrMissingTenantID
+	}
+
+	query := `
+		INSERT INTO tenant_quotas (tenant_id, max_catalogs_per_project, max_objects_per_variant, max_bytes_per_tenant)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (tenant_id) DO UPDATE
+		SET max_catalogs_per_project = EXCLUDED.max_catalogs_per_project,
+			max_objects_per_variant = EXCLUDED.max_objects_per_variant,
+			max_bytes_per_tenant = EXCLUDED.max_bytes_per_tenant;
+	`
+	_, err := mm.conn().ExecContext(ctx, query, tenantID, q.MaxCatalogsPerProject, q.MaxObjectsPerVariant, q.MaxBytesPerTenant)
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Msg("failed to set tenant quota")
+		return dberror.ErrDatabase.Err(err)
+	}
+	q.TenantID = tenantID
+	return nil
+}
+
+// CountCatalogsInProject returns the number of catalogs in the project in the context
+func (mm *metadataManager) CountCatalogsInProject(ctx context.Context) (int, apperrors.Error) {
+	tenantID, projectID, err := getTenantAndProjectFromContext(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	query := `
+		SELECT COUNT(*)
+		FROM catalogs
+		WHERE project_id = $1 AND tenant_id = $2;
+	`
+	var count int
+	if dberr := mm.conn().QueryRowContext(ctx, query, projectID, tenantID).Scan(&count); dberr != nil {
+		log.Ctx(ctx).Error().Err(dberr).Msg("failed to count catalogs")
+		return 0, dberror.ErrDatabase.Err(dberr)
+	}
+	return count, nil
+}
+
+// GetTenantObjectBytes returns the size of the data of all catalog objects of the tenant in the context. The size
+// is recorded when the object is written, since the data column of an object in the blob store only holds a marker.
+func (mm *metadataManager) GetTenantObjectBytes(ctx context.Context) (int64, apperrors.Error) {
+	tenantID := common.TenantIdFromContext(ctx)
+	if tenantID == "" {
+		return 0, dberror.ErrMissingTenantID
+	}
+
+	query := `
+		SELECT COALESCE(SUM(size), 0)
+		FROM catalog_objects
+		WHERE tenant_id = $1;
+	`
+	var n int64
+	if err := mm.conn().QueryRowContext(ctx, query, tenantID).Scan(&n); err != nil {
+		log.Ctx(ctx).Error().Err(err).Msg("failed to sum catalog object sizes")
+		return 0, dberror.ErrDatabase.Err(err)
+	}
+	return n, nil
+}
@@ -22,7 +22,7 @@ import (
 // the catalog ID is invalid, or there is a database error.
 func (mm *metadataManager) CreateVariant(ctx context.Context, variant *models.Variant) (err apperrors.Error) {
 	// Start a transaction
-	tx, errdb := mm.conn().BeginTx(ctx, &sql.TxOptions{})
+	tx, errdb := beginTx(ctx, mm.c, &sql.TxOptions{})
 	if errdb != nil {
 		log.Ctx(ctx).Error().Err(errdb).Msg("failed to start transaction")
 		return dberror.ErrDatabase.Err(errdb)
@@ -50,7 +50,7 @@ func (mm *metadataManager) CreateVariant(ctx context.Context, variant *models.Va
 	return nil
 }
 
-func (mm *metadataManager) createVariantWithTransaction(ctx context.Context, variant *models.Variant, tx *sql.Tx) apperrors.Error {
+func (mm *metadataManager) createVariantWithTransaction(ctx context.Context, variant *models.Variant, tx *dbTx) apperrors.Error {
 	tenantID := common.TenantIdFromContext(ctx)
 	if tenantID == "" {
 		return dberror.ErrMissingTenantID
@@ -64,14 +64,14 @@ func (mm *metadataManager) createVariantWithTransaction(ctx context.Context, var
 		INSERT INTO variants (variant_id, name, description, info, catalog_id, tenant_id)
 		VALUES ($1, $2, $3, $4, $5, $6)
 		ON CONFLICT (name, catalog_id, tenant_id) DO NOTHING
-		RETURNING variant_id, name;
+		RETURNING variant_id, name, created_at, updated_at;
 	`
 
 	// Execute variant insertion within the transaction
 	row := tx.QueryRowContext(ctx, queryVariant, variantID, variant.Name, variant.Description, variant.Info, variant.CatalogID, tenantID)
 	var insertedVariantID uuid.UUID
 	var insertedName string
-	err := row.Scan(&insertedVariantID, &insertedName)
+	err := row.Scan(&insertedVariantID, &insertedName, &variant.CreatedAt, &variant.UpdatedAt)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			log.Ctx(ctx).Info().Str("name", variant.Name).Str("variant_id", variant.VariantID.String()).Msg("variant already exists")
@@ -114,7 +114,7 @@ func (mm *metadataManager) createVariantWithTransaction(ctx context.Context, var
 
 	// Create a default namespace for the variant
 	namespace := models.Namespace{
-		Name:        types.DefaultNamespace,
+		Name:        types.RootNamespace(),
 		VariantID:   variant.VariantID,
 		TenantID:    tenantID,
 		Description: "Default namespace for the variant",
@@ -140,18 +140,18 @@ func (mm *metadataManager) GetVariant(ctx context.Context, catalogID uuid.UUID,
 	}
 
 	var query string
-	var row *sql.Row
+	var row rowScanner
 
 	if variantID != uuid.Nil {
 		query = `
-			SELECT variant_id, name, description, info, catalog_id
+			SELECT variant_id, name, description, info, catalog_id, created_at, updated_at
 			FROM variants
 			WHERE variant_id = $1 AND tenant_id = $2;
 		`
 		row = mm.conn().QueryRowContext(ctx, query, variantID, tenantID)
 	} else if name != "" {
 		query = `
-			SELECT variant_id, name, description, info, catalog_id
+			SELECT variant_id, name, description, info, catalog_id, created_at, updated_at
 			FROM variants
 			WHERE name = $1 AND catalog_id = $2 AND tenant_id = $3;
 		`
@@ -162,7 +162,8 @@ func (mm *metadataManager) GetVariant(ctx context.Context, catalogID uuid.UUID,
 	}
 
 	variant := &models.Variant{}
-	err := row.Scan(&variant.VariantID, &variant.Name, &variant.Description, &variant.Info, &variant.CatalogID)
+	err := row.Scan(&variant.VariantID, &variant.Name, &variant.Description, &variant.Info, &variant.CatalogID,
+		&variant.CreatedAt, &variant.UpdatedAt)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			log.Ctx(ctx).Info().Msg("variant not found")
@@ -213,22 +214,26 @@ func (mm *metadataManager) UpdateVariant(ctx context.Context, variantID uuid.UUI
 	}
 
 	var query string
-	var row *sql.Row
+	var row rowScanner
 
 	if variantID != uuid.Nil {
 		query = `
 			UPDATE variants
-			SET name = $1, description = $2, info = $3
+			SET name = $1, description = $2, info = $3,
+				updated_at = CASE WHEN name IS DISTINCT FROM $1 OR description IS DISTINCT FROM $2 OR info IS DISTINCT FROM $3
+					THEN now() ELSE updated_at END
 			WHERE variant_id = $4 AND catalog_id = $5 AND tenant_id = $6
-			RETURNING variant_id;
+			RETURNING variant_id, created_at, updated_at;
 		`
 		row = mm.conn().QueryRowContext(ctx, query, updatedVariant.Name, updatedVariant.Description, updatedVariant.Info, variantID, updatedVariant.CatalogID, tenantID)
 	} else if name != "" {
 		query = `
 			UPDATE variants
-			SET name = $1, description = $2, info = $3
+			SET name = $1, description = $2, info = $3,
+				updated_at = CASE WHEN name IS DISTINCT FROM $1 OR description IS DISTINCT FROM $2 OR info IS DISTINCT FROM $3
+					THEN now() ELSE updated_at END
 			WHERE name = $4 AND catalog_id = $5 AND tenant_id = $6
-			RETURNING variant_id;
+			RETURNING variant_id, created_at, updated_at;
 		`
 		row = mm.conn().QueryRowContext(ctx, query, updatedVariant.Name, updatedVariant.Description, updatedVariant.Info, name, updatedVariant.CatalogID, tenantID)
 	} else {
@@ -236,8 +241,9 @@ func (mm *metadataManager) UpdateVariant(ctx context.Context, variantID uuid.UUI
 		return dberror.ErrInvalidInput.Msg("either variant ID or name must be provided")
 	}
 
+	// updated_at is only bumped if something actually changes, so saving the same variant again is a no-op
 	var returnedVariantID uuid.UUID
-	err := row.Scan(&returnedVariantID)
+	err := row.Scan(&returnedVariantID, &updatedVariant.CreatedAt, &updatedVariant.UpdatedAt)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			log.Ctx(ctx).Info().Msg("variant not found or no changes made")
@@ -308,3 +314,55 @@ func (mm *metadataManager) DeleteVariant(ctx context.Context, catalogID, variant
 
 	return nil
 }
+
+// GetVariantStats counts the objects in a variant. Schemas and collections are counted in the directories
+// of the variant, i.e. those of its first version.
+func (mm *metadataManager) GetVariantStats(ctx context.Context, variantID uuid.UUID) (*models.VariantStats, apperrors.Error) {
+	tenantID := common.TenantIdFromContext(ctx)
+	if tenantID == "" {
+		return nil, dberror.ErrMissingTenantID
+	}
+
+	query := `
+		WITH v AS (
+			SELECT parameters_directory, collections_directory, values_directory
+			FROM versions
+			WHERE version_num = 1 AND variant_id = $1 AND tenant_id = $2
+		), p AS (
+			SELECT e.key, e.value
+			FROM parameters_directory d JOIN v ON d.directory_id = v.parameters_directory, LATERAL jsonb_each(d.directory) e
+			WHERE d.tenant_id = $2
+		), c AS (
+			SELECT e.key, e.value
+			FROM collections_directory d JOIN v ON d.directory_id = v.collections_directory, LATERAL jsonb_each(d.directory) e
+			WHERE d.tenant_id = $2
+		), vals AS (
+			SELECT e.key, e.value
+			FROM values_directory d JOIN v ON d.directory_id = v.values_directory, LATERAL jsonb_each(d.directory) e
+			WHERE d.tenant_id = $2
+		)
+		SELECT
+			(SELECT COUNT(*) FROM p),
+			(SELECT COUNT(*) FROM c),
+			(SELECT COUNT(*) FROM vals),
+			(SELECT COUNT(*) FROM namespaces WHERE variant_id = $1 AND tenant_id = $2),
+			(SELECT COUNT(*) FROM workspaces WHERE variant_id = $1 AND tenant_id = $2),
+			(SELECT COUNT(*) FROM versions WHERE variant_id = $1 AND tenant_id = $2),
+			(SELECT COALESCE(SUM(co.size), 0)
+			 FROM catalog_objects co
+			 WHERE co.tenant_id = $2 AND co.hash IN (
+				SELECT value->>'hash' FROM p
+				UNION SELECT value->>'hash' FROM c
+				UNION SELECT value->>'hash' FROM vals
+			 ));
+	`
+	var s models.VariantStats
+	err := mm.conn().QueryRowContext(ctx, query, variantID, tenantID).Scan(
+		&s.ParameterSchemas, &s.CollectionSchemas, &s.Collections,
+		&s.Namespaces, &s.Workspaces, &s.Versions, &s.ObjectBytes)
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Str("variant_id", variantID.String()).Msg("failed to count objects in variant")
+		return nil, dberror.ErrDatabase.Err(err)
+	}
+	return &s, nil
+}
@@ -20,7 +20,7 @@ func (mm *metadataManager) CreateVersion(ctx context.Context, version *models.Ve
 	}
 	version.TenantID = tenantID
 
-	tx, err := mm.conn().BeginTx(ctx, nil)
+	tx, err := beginTx(ctx, mm.c, nil)
 	if err != nil {
 		log.Ctx(ctx).Error().Err(err).Msg("failed to begin transaction")
 		return dberror.ErrDatabase.Err(err)
@@ -45,7 +45,7 @@ func (mm *metadataManager) CreateVersion(ctx context.Context, version *models.Ve
 	return nil
 }
 
-func (mm *metadataManager) createVersionWithTransaction(ctx context.Context, version *models.Version, tx *sql.Tx) apperrors.Error {
+func (mm *metadataManager) createVersionWithTransaction(ctx context.Context, version *models.Version, tx *dbTx) apperrors.Error {
 	label := sql.NullString{String: version.Label, Valid: version.Label != ""}
 	query := `
 		SELECT version_num, label, description, info, parameters_directory, collections_directory, values_directory, variant_id, tenant_id, created_at, updated_at
@@ -125,6 +125,36 @@ func (mm *metadataManager) GetVersion(ctx context.Context, versionNum int, varia
 	return version, nil
 }
 
+// GetLatestVersion returns the version of the variant with the highest version number.
+func (mm *metadataManager) GetLatestVersion(ctx context.Context, variantID uuid.UUID) (*models.Version, error) {
+	tenantID := common.TenantIdFromContext(ctx)
+	if tenantID == "" {
+		return nil, dberror.ErrMissingTenantID
+	}
+
+	query := `
+		SELECT version_num, label, description, info, parameters_directory, collections_directory, values_directory, variant_id, tenant_id
+		FROM versions
+		WHERE variant_id = $1 AND tenant_id = $2
+		ORDER BY version_num DESC
+		LIMIT 1;
+	`
+
+	row := mm.conn().QueryRowContext(ctx, query, variantID, tenantID)
+	version := &models.Version{}
+	err := row.Scan(&version.VersionNum, &version.Label, &version.Description, &version.Info, &version.ParametersDir, &version.CollectionsDir, &version.ValuesDir, &version.VariantID, &version.TenantID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			log.Ctx(ctx).Info().Str("variant_id", variantID.String()).Msg("no versions in variant")
+			return nil, dberror.ErrNotFound.Msg("version not found")
+		}
+		log.Ctx(ctx).Error().Err(err).Msg("failed to retrieve latest version")
+		return nil, dberror.ErrDatabase.Err(err)
+	}
+
+	return version, nil
+}
+
 func (mm *metadataManager) SetVersionLabel(ctx context.Context, versionNum int, variantID uuid.UUID, newLabel string) error {
 	tenantID := common.TenantIdFromContext(ctx)
 	if tenantID == "" {
@@ -168,6 +198,27 @@ func (mm *metadataManager) SetVersionLabel(ctx context.Context, versionNum int,
 	return nil
 }
 
+// ClearVersionLabel removes label from whichever version of the variant has it. It is not an error if no
+// version has the label.
+func (mm *metadataManager) ClearVersionLabel(ctx context.Context, label string, variantID uuid.UUID) error {
+	tenantID := common.TenantIdFromContext(ctx)
+	if tenantID == "" {
+		return dberror.ErrMissingTenantID
+	}
+
+	query := `
+		UPDATE versions
+		SET label = NULL
+		WHERE label = $1 AND variant_id = $2 AND tenant_id = $3;
+	`
+
+	if _, err := mm.conn().ExecContext(ctx, query, label, variantID, tenantID); err != nil {
+		log.Ctx(ctx).Error().Err(err).Msg("failed to clear version label")
+		return dberror.ErrDatabase.Err(err)
+	}
+	return nil
+}
+
 func (mm *metadataManager) UpdateVersionDescription(ctx context.Context, versionNum int, variantID uuid.UUID, newDescription string) error {
 	tenantID := common.TenantIdFromContext(ctx)
 	if tenantID == "" {
@@ -256,9 +307,10 @@ func (mm *metadataManager) GetNamedVersions(ctx context.Context, variantID uuid.
 	}
 
 	query := `
-		SELECT version_num, label, description
+		SELECT version_num, label, COALESCE(description, '')
 		FROM versions
-		WHERE variant_id = $1 AND tenant_id = $2 AND label IS NOT NULL;
+		WHERE variant_id = $1 AND tenant_id = $2 AND label IS NOT NULL
+		ORDER BY version_num;
 	`
 
 	rows, err := mm.conn().QueryContext(ctx, query, variantID, tenantID)
@@ -287,6 +339,47 @@ func (mm *metadataManager) GetNamedVersions(ctx context.Context, variantID uuid.
 	return namedVersions, nil
 }
 
+// ListVersions returns all the versions of a variant, labeled or not, in the order they were created
+func (mm *metadataManager) ListVersions(ctx context.Context, variantID uuid.UUID) ([]models.Version, error) {
+	tenantID := common.TenantIdFromContext(ctx)
+	if tenantID == "" {
+		return nil, dberror.ErrMissingTenantID
+	}
+
+	query := `
+		SELECT version_num, COALESCE(label, ''), COALESCE(description, ''), created_at
+		FROM versions
+		WHERE variant_id = $1 AND tenant_id = $2
+		ORDER BY version_num;
+	`
+
+	rows, err := mm.conn().QueryContext(ctx, query, variantID, tenantID)
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Msg("failed to retrieve versions")
+		return nil, dberror.ErrDatabase.Err(err)
+	}
+	defer rows.Close()
+
+	var versions []models.Version
+	for rows.Next() {
+		var version models.Version
+		if err := rows.Scan(&version.VersionNum, &version.Label, &version.Description, &version.CreatedAt); err != nil {
+			log.Ctx(ctx).Error().Err(err).Msg("failed to scan version row")
+			return nil, dberror.ErrDatabase.Err(err)
+		}
+		version.VariantID = variantID
+		version.TenantID = tenantID
+		versions = append(versions, version)
+	}
+
+	if err = rows.Err(); err != nil {
+		log.Ctx(ctx).Error().Err(err).Msg("error after scanning versions")
+		return nil, dberror.ErrDatabase.Err(err)
+	}
+
+	return versions, nil
+}
+
 func (mm *metadataManager) GetVersionByLabel(ctx context.Context, label string, variantID uuid.UUID) (*models.Version, error) {
 	tenantID := common.TenantIdFromContext(ctx)
 	if tenantID == "" {
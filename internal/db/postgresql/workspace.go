@@ -3,6 +3,7 @@ package postgresql
 import (
 	"context"
 	"database/sql"
+	"strconv"
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgconn"
@@ -119,10 +120,7 @@ func (mm *metadataManager) DeleteWorkspace(ctx context.Context, workspaceID uuid
 		return dberror.ErrMissingTenantID
 	}
 
-	query := `
-		DELETE FROM workspaces
-		WHERE workspace_id = $1 AND tenant_id = $2;
-	`
+	query := deleteWorkspaceQuery(`workspace_id = $1 AND tenant_id = $2`)
 
 	result, err := mm.conn().ExecContext(ctx, query, workspaceID, tenantID)
 	if err != nil {
@@ -143,6 +141,31 @@ func (mm *metadataManager) DeleteWorkspace(ctx context.Context, workspaceID uuid
 	return nil
 }
 
+// deleteWorkspaceQuery returns a statement that deletes the workspaces matching cond together with the
+// parameters, collections and values directories they point to, so the collections of a workspace never
+// outlive it. The directories also cascade from the workspace, but deleting them by the ids on the workspace
+// covers directories that were not created with the workspace. Directories that belong to a version are never
+// deleted. The statement affects one row per deleted workspace.
+func deleteWorkspaceQuery(cond string) string {
+	return `
+		WITH deleted AS (
+			DELETE FROM workspaces
+			WHERE ` + cond + `
+			RETURNING parameters_directory, collections_directory, values_directory, tenant_id
+		), deleted_parameters AS (
+			DELETE FROM parameters_directory d USING deleted
+			WHERE d.directory_id = deleted.parameters_directory AND d.tenant_id = deleted.tenant_id AND d.version_num IS NULL
+		), deleted_collections AS (
+			DELETE FROM collections_directory d USING deleted
+			WHERE d.directory_id = deleted.collections_directory AND d.tenant_id = deleted.tenant_id AND d.version_num IS NULL
+		), deleted_values AS (
+			DELETE FROM values_directory d USING deleted
+			WHERE d.directory_id = deleted.values_directory AND d.tenant_id = deleted.tenant_id AND d.version_num IS NULL
+		)
+		SELECT 1 FROM deleted;
+	`
+}
+
 func (mm *metadataManager) DeleteWorkspaceByLabel(ctx context.Context, variantID uuid.UUID, label string) apperrors.Error {
 	tenantID := common.TenantIdFromContext(ctx)
 	if tenantID == "" {
@@ -155,10 +178,7 @@ func (mm *metadataManager) DeleteWorkspaceByLabel(ctx context.Context, variantID
 		return dberror.ErrInvalidInput.Msg("label cannot be empty")
 	}
 
-	query := `
-		DELETE FROM workspaces
-		WHERE label = $1 AND variant_id = $2 AND tenant_id = $3;
-	`
+	query := deleteWorkspaceQuery(`label = $1 AND variant_id = $2 AND tenant_id = $3`)
 
 	result, err := mm.conn().ExecContext(ctx, query, label, variantID, tenantID)
 	if err != nil {
@@ -300,7 +320,10 @@ func (mm *metadataManager) GetWorkspaceByLabel(ctx context.Context, variantID uu
 	return workspace, nil
 }
 
-func (mm *metadataManager) UpdateWorkspaceLabel(ctx context.Context, workspaceID uuid.UUID, newLabel string) apperrors.Error {
+// UpdateWorkspaceLabel sets the label of the workspace to newLabel if its current label is expectedLabel. An empty
+// expectedLabel matches a workspace without a label. Returns ErrConcurrentModification if the label is no longer
+// expectedLabel, and ErrAlreadyExists if another workspace of the variant has newLabel.
+func (mm *metadataManager) UpdateWorkspaceLabel(ctx context.Context, workspaceID uuid.UUID, expectedLabel, newLabel string) apperrors.Error {
 	tenantID := common.TenantIdFromContext(ctx)
 	if tenantID == "" {
 		return dberror.ErrMissingTenantID
@@ -310,21 +333,21 @@ func (mm *metadataManager) UpdateWorkspaceLabel(ctx context.Context, workspaceID
 		log.Ctx(ctx).Error().Msg("new label cannot be empty")
 		return dberror.ErrInvalidInput.Msg("label cannot be empty")
 	}
+	expected := sql.NullString{String: expectedLabel, Valid: expectedLabel != ""}
 
 	query := `
 		UPDATE workspaces
 		SET label = $1, updated_at = NOW()
-		WHERE workspace_id = $2 AND tenant_id = $3
+		WHERE workspace_id = $2 AND tenant_id = $3 AND label IS NOT DISTINCT FROM $4
 		RETURNING workspace_id;
 	`
 
-	row := mm.conn().QueryRowContext(ctx, query, newLabel, workspaceID, tenantID)
+	row := mm.conn().QueryRowContext(ctx, query, newLabel, workspaceID, tenantID, expected)
 	var returnedWorkspaceID uuid.UUID
 	err := row.Scan(&returnedWorkspaceID)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			log.Ctx(ctx).Info().Str("workspace_id", workspaceID.String()).Str("tenant_id", string(tenantID)).Msg("workspace not found")
-			return dberror.ErrNotFound.Msg("workspace not found")
+			return mm.workspaceLabelMismatch(ctx, workspaceID, expectedLabel)
 		}
 		if pgErr, ok := err.(*pgconn.PgError); ok {
 			if pgErr.Code == "23505" && pgErr.ConstraintName == "workspaces_label_variant_id_tenant_id_key" {
@@ -343,6 +366,29 @@ func (mm *metadataManager) UpdateWorkspaceLabel(ctx context.Context, workspaceID
 	return nil
 }
 
+// workspaceLabelMismatch returns the error for a label update that matched no rows: the workspace either does
+// not exist or no longer has the expected label.
+func (mm *metadataManager) workspaceLabelMismatch(ctx context.Context, workspaceID uuid.UUID, expectedLabel string) apperrors.Error {
+	tenantID := common.TenantIdFromContext(ctx)
+	query := `
+		SELECT label
+		FROM workspaces
+		WHERE workspace_id = $1 AND tenant_id = $2;
+	`
+	var current sql.NullString
+	err := mm.conn().QueryRowContext(ctx, query, workspaceID, tenantID).Scan(&current)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			log.Ctx(ctx).Info().Str("workspace_id", workspaceID.String()).Str("tenant_id", string(tenantID)).Msg("workspace not found")
+			return dberror.ErrNotFound.Msg("workspace not found")
+		}
+		log.Ctx(ctx).Error().Err(err).Msg("failed to retrieve workspace label")
+		return dberror.ErrDatabase.Err(err)
+	}
+	log.Ctx(ctx).Info().Str("workspace_id", workspaceID.String()).Str("expected", expectedLabel).Str("current", current.String).Msg("workspace label was modified concurrently")
+	return dberror.ErrConcurrentModification.Msg("workspace label is no longer " + strconv.Quote(expectedLabel))
+}
+
 func (mm *metadataManager) GetCatalogForWorkspace(ctx context.Context, workspaceID uuid.UUID) (models.Catalog, apperrors.Error) {
 	// get the variant ID and then from variant fetch the catalog id and then the catalog
 	tenantID := common.TenantIdFromContext(ctx)
@@ -441,7 +487,7 @@ func (mm *metadataManager) CommitWorkspace(ctx context.Context, workspace *model
 		}
 
 		label := sql.NullString{String: workspace.Label, Valid: workspace.Label != ""}
-		tx, errdb := mm.conn().BeginTx(ctx, &sql.TxOptions{})
+		tx, errdb := beginTx(ctx, mm.c, &sql.TxOptions{})
 		if errdb != nil {
 			log.Ctx(ctx).Error().Err(errdb).Msg("failed to start transaction")
 			return dberror.ErrDatabase.Err(errdb)
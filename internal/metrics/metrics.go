@@ -0,0 +1,156 @@
+// Package metrics exposes Prometheus metrics for the catalog server.
+package metrics
+
+import (
+	"net/http"
+	"runtime"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	chimw "github.com/go-chi/chi/v5/middleware"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "hatchcatalog"
+
+// Version is the server version reported by the build info gauge. Override at build time with
+// -ldflags "-X github.com/mugiliam/hatchcatalogsrv/internal/metrics.Version=<version>".
+var Version = "1.0.0"
+
+// Metrics holds the collectors registered with a registry
+type Metrics struct {
+	registry        *prometheus.Registry
+	requestsTotal   *prometheus.CounterVec
+	requestDuration *prometheus.HistogramVec
+	dbOperations    *prometheus.CounterVec
+	dbDuration      *prometheus.HistogramVec
+	dbErrorsTotal   *prometheus.CounterVec
+	buildInfo       *prometheus.GaugeVec
+}
+
+// New creates the collectors and registers them with reg. Passing a fresh registry lets
+// tests assert on counters without interference from other tests.
+func New(reg *prometheus.Registry) *Metrics {
+	m := &Metrics{
+		registry: reg,
+		requestsTotal: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "http_requests_total",
+			Help:      "Number of HTTP requests by route, method and status.",
+		}, []string{"route", "method", "status"}),
+		requestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "HTTP request latency by route, method and status.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"route", "method", "status"}),
+		dbOperations: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "db_operations_total",
+			Help:      "Number of database queries by operation.",
+		}, []string{"op"}),
+		dbDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "db_operation_duration_seconds",
+			Help:      "Database query latency by operation.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"op"}),
+		dbErrorsTotal: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "db_errors_total",
+			Help:      "Number of database errors by operation and type.",
+		}, []string{"op", "type"}),
+		buildInfo: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "build_info",
+			Help:      "Build information of the running server.",
+		}, []string{"version", "goversion"}),
+	}
+	reg.MustRegister(
+		m.requestsTotal,
+		m.requestDuration,
+		m.dbOperations,
+		m.dbDuration,
+		m.dbErrorsTotal,
+		m.buildInfo,
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+	m.buildInfo.WithLabelValues(Version, runtime.Version()).Set(1)
+	return m
+}
+
+// Registry returns the registry the collectors are registered with
+func (m *Metrics) Registry() *prometheus.Registry {
+	return m.registry
+}
+
+// Handler serves the metrics in the Prometheus exposition format
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+// Middleware records the count and latency of every request. The route label is the matched
+// chi route pattern so that path parameters do not blow up the label cardinality.
+func (m *Metrics) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		ww := chimw.NewWrapResponseWriter(w, r.ProtoMajor)
+		next.ServeHTTP(ww, r)
+
+		route := "unmatched"
+		if rctx := chi.RouteContext(r.Context()); rctx != nil {
+			if p := rctx.RoutePattern(); p != "" {
+				route = p
+			}
+		}
+		status := ww.Status()
+		if status == 0 {
+			status = http.StatusOK
+		}
+		labels := []string{route, r.Method, strconv.Itoa(status)}
+		m.requestsTotal.WithLabelValues(labels...).Inc()
+		m.requestDuration.WithLabelValues(labels...).Observe(time.Since(start).Seconds())
+	})
+}
+
+// ObserveDBOperation records a database query of the operation op that took d. errType classifies the error
+// of the query, such as "not_found" or "already_exists", and is empty if the query succeeded.
+func (m *Metrics) ObserveDBOperation(op string, d time.Duration, errType string) {
+	m.dbOperations.WithLabelValues(op).Inc()
+	m.dbDuration.WithLabelValues(op).Observe(d.Seconds())
+	if errType != "" {
+		m.dbErrorsTotal.WithLabelValues(op, errType).Inc()
+	}
+}
+
+var (
+	defaultMetrics *Metrics
+	defaultMu      sync.Mutex
+)
+
+// Default returns the process wide metrics, creating them on a new registry on first use
+func Default() *Metrics {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	if defaultMetrics == nil {
+		defaultMetrics = New(prometheus.NewRegistry())
+	}
+	return defaultMetrics
+}
+
+// SetDefault replaces the process wide metrics
+func SetDefault(m *Metrics) {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	defaultMetrics = m
+}
+
+// ObserveDBOperation records a database query against the process wide metrics
+func ObserveDBOperation(op string, d time.Duration, errType string) {
+	Default().ObserveDBOperation(op, d, errType)
+}
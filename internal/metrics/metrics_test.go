@@ -0,0 +1,61 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMiddleware(t *testing.T) {
+	m := New(prometheus.NewRegistry())
+	r := chi.NewRouter()
+	r.Use(m.Middleware)
+	r.Get("/catalogs/{catalogName}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+	r.Get("/ok", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	})
+
+	for _, p := range []string{"/catalogs/a", "/catalogs/b", "/ok"} {
+		req := httptest.NewRequest(http.MethodGet, p, nil)
+		r.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	assert.Equal(t, 2.0, testutil.ToFloat64(m.requestsTotal.WithLabelValues("/catalogs/{catalogName}", "GET", "404")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.requestsTotal.WithLabelValues("/ok", "GET", "200")))
+	assert.Equal(t, 2, testutil.CollectAndCount(m.requestDuration))
+}
+
+func TestObserveDBOperation(t *testing.T) {
+	m := New(prometheus.NewRegistry())
+	m.ObserveDBOperation("query_row", time.Millisecond, "")
+	m.ObserveDBOperation("query_row", time.Millisecond, "not_found")
+	m.ObserveDBOperation("exec", time.Millisecond, "already_exists")
+	m.ObserveDBOperation("exec", time.Millisecond, "")
+
+	assert.Equal(t, 2.0, testutil.ToFloat64(m.dbOperations.WithLabelValues("query_row")))
+	assert.Equal(t, 2.0, testutil.ToFloat64(m.dbOperations.WithLabelValues("exec")))
+	assert.Equal(t, 2, testutil.CollectAndCount(m.dbDuration))
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.dbErrorsTotal.WithLabelValues("query_row", "not_found")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.dbErrorsTotal.WithLabelValues("exec", "already_exists")))
+	assert.Equal(t, 2, testutil.CollectAndCount(m.dbErrorsTotal))
+}
+
+func TestHandler(t *testing.T) {
+	m := New(prometheus.NewRegistry())
+	rr := httptest.NewRecorder()
+	m.Handler().ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	require.Equal(t, http.StatusOK, rr.Code)
+	body := rr.Body.String()
+	assert.True(t, strings.Contains(body, `hatchcatalog_build_info{goversion=`))
+	assert.True(t, strings.Contains(body, `version="`+Version+`"`))
+}
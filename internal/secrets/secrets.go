@@ -0,0 +1,95 @@
+// Package secrets encrypts the values of sensitive parameters, so that they are not stored in plaintext. Values
+// are encrypted with AES-256-GCM under the key in the secret_key setting. Sensitive parameters can only be
+// declared while a key is configured.
+package secrets
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"strings"
+
+	"github.com/mugiliam/hatchcatalogsrv/internal/config"
+)
+
+// prefix marks the format of an encrypted value, so that the format can change without losing older values
+const prefix = "v1:"
+
+var (
+	ErrNotConfigured = errors.New("no secret key is configured")
+	ErrInvalidKey    = errors.New("secret key must be a base64 encoded 32 byte key")
+	ErrInvalidValue  = errors.New("value is not an encrypted value")
+)
+
+// Enabled reports whether sensitive values can be encrypted, which is when a secret key is configured
+func Enabled() bool {
+	return config.Config().SecretKey != ""
+}
+
+func key() ([]byte, error) {
+	encoded := config.Config().SecretKey
+	if encoded == "" {
+		return nil, ErrNotConfigured
+	}
+	k, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil || len(k) != 32 {
+		return nil, ErrInvalidKey
+	}
+	return k, nil
+}
+
+func newAEAD(k []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(k)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// Encrypt encrypts plaintext. The nonce is derived from the key and the plaintext, so a value always encrypts
+// to the same string: a collection that is saved again unchanged keeps its hash. The only thing this reveals
+// is whether two encrypted values are equal.
+func Encrypt(plaintext []byte) (string, error) {
+	k, err := key()
+	if err != nil {
+		return "", err
+	}
+	aead, err := newAEAD(k)
+	if err != nil {
+		return "", err
+	}
+	mac := hmac.New(sha256.New, k)
+	mac.Write(plaintext)
+	nonce := mac.Sum(nil)[:aead.NonceSize()]
+	sealed := aead.Seal(nonce, nonce, plaintext, nil)
+	return prefix + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt returns the plaintext of a value returned by Encrypt
+func Decrypt(value string) ([]byte, error) {
+	k, err := key()
+	if err != nil {
+		return nil, err
+	}
+	aead, err := newAEAD(k)
+	if err != nil {
+		return nil, err
+	}
+	encoded, ok := strings.CutPrefix(value, prefix)
+	if !ok {
+		return nil, ErrInvalidValue
+	}
+	sealed, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil || len(sealed) < aead.NonceSize() {
+		return nil, ErrInvalidValue
+	}
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	plaintext, err := aead.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return nil, ErrInvalidValue
+	}
+	return plaintext, nil
+}
@@ -0,0 +1,73 @@
+package secrets
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mugiliam/hatchcatalogsrv/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setSecretKey(t *testing.T, key string) {
+	file := filepath.Join(t.TempDir(), "config.toml")
+	require.NoError(t, os.WriteFile(file, []byte("secret_key = \""+key+"\"\n"), 0o600))
+	require.NoError(t, config.LoadConfig(file))
+	t.Cleanup(func() {
+		_ = config.LoadConfig("")
+	})
+}
+
+func newKey(t *testing.T) string {
+	k := make([]byte, 32)
+	_, err := rand.Read(k)
+	require.NoError(t, err)
+	return base64.StdEncoding.EncodeToString(k)
+}
+
+func TestEncrypt(t *testing.T) {
+	assert.False(t, Enabled())
+	_, err := Encrypt([]byte(`"secret"`))
+	assert.ErrorIs(t, err, ErrNotConfigured)
+
+	setSecretKey(t, newKey(t))
+	assert.True(t, Enabled())
+
+	plaintext := []byte(`"hunter2"`)
+	enc, err := Encrypt(plaintext)
+	require.NoError(t, err)
+	assert.False(t, bytes.Contains([]byte(enc), []byte("hunter2")))
+
+	// the same value always encrypts the same way, and different values differently
+	again, err := Encrypt(plaintext)
+	require.NoError(t, err)
+	assert.Equal(t, enc, again)
+	other, err := Encrypt([]byte(`"hunter3"`))
+	require.NoError(t, err)
+	assert.NotEqual(t, enc, other)
+
+	dec, err := Decrypt(enc)
+	require.NoError(t, err)
+	assert.Equal(t, plaintext, dec)
+
+	// values that were not encrypted, or were tampered with, are rejected
+	_, err = Decrypt(`"hunter2"`)
+	assert.ErrorIs(t, err, ErrInvalidValue)
+	tampered := []byte(enc)
+	tampered[len(tampered)-2] ^= 1
+	_, err = Decrypt(string(tampered))
+	assert.ErrorIs(t, err, ErrInvalidValue)
+
+	// and so are values encrypted with another key
+	setSecretKey(t, newKey(t))
+	_, err = Decrypt(enc)
+	assert.ErrorIs(t, err, ErrInvalidValue)
+
+	setSecretKey(t, "too short")
+	_, err = Encrypt(plaintext)
+	assert.ErrorIs(t, err, ErrInvalidKey)
+}
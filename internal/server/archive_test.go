@@ -0,0 +1,496 @@
+package server
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/mugiliam/hatchcatalogsrv/internal/catalogmanager"
+	"github.com/mugiliam/hatchcatalogsrv/internal/config"
+	"github.com/mugiliam/hatchcatalogsrv/internal/db"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
+	"sigs.k8s.io/yaml"
+)
+
+func TestExportCatalog(t *testing.T) {
+	ctx := newDb()
+	t.Cleanup(func() {
+		db.DB(ctx).Close(ctx)
+	})
+	testContextP := createTestObjects(t, ctx)
+	testContext := *testContextP
+
+	// export the workspace
+	httpReq, _ := http.NewRequest("GET", "/catalogs/valid-catalog/export", nil)
+	response := executeTestRequest(t, httpReq, nil, testContext)
+	if !assert.Equal(t, http.StatusOK, response.Code) {
+		t.Logf("Response: %v", response.Body.String())
+		t.FailNow()
+	}
+	assert.Equal(t, "application/gzip", response.Header().Get("Content-Type"))
+	assert.Equal(t, `attachment; filename="valid-catalog-valid-variant.tar.gz"`, response.Header().Get("Content-Disposition"))
+
+	gr, err := gzip.NewReader(response.Body)
+	require.NoError(t, err)
+	tr := tar.NewReader(gr)
+	manifests := make(map[string][]byte)
+	var order []string
+	var trailer []byte
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		require.NoError(t, err)
+		b, err := io.ReadAll(tr)
+		require.NoError(t, err)
+		require.Nil(t, trailer, "entry %s after the trailer", hdr.Name)
+		if hdr.Name == catalogmanager.ExportTrailerName {
+			trailer = b
+			continue
+		}
+		j, err := yaml.YAMLToJSON(b)
+		require.NoError(t, err)
+		manifests[hdr.Name] = j
+		order = append(order, hdr.Name)
+	}
+
+	param, ok := manifests["valid-namespace/parameterschemas/integer-param-schema.yaml"]
+	if assert.True(t, ok, "entries: %v", order) {
+		assert.Equal(t, "ParameterSchema", gjson.GetBytes(param, "kind").String())
+		assert.Equal(t, "integer-param-schema", gjson.GetBytes(param, "metadata.name").String())
+		assert.Equal(t, "valid-catalog", gjson.GetBytes(param, "metadata.catalog").String())
+		assert.Equal(t, "valid-namespace", gjson.GetBytes(param, "metadata.namespace").String())
+		assert.Equal(t, "10", gjson.GetBytes(param, "spec.validation.maxValue").String())
+	}
+	collection, ok := manifests["valid-namespace/collectionschemas/valid.yaml"]
+	if assert.True(t, ok, "entries: %v", order) {
+		assert.Equal(t, "CollectionSchema", gjson.GetBytes(collection, "kind").String())
+		assert.Equal(t, "valid", gjson.GetBytes(collection, "metadata.name").String())
+		assert.True(t, gjson.GetBytes(collection, "spec.parameters.maxRetries").Exists())
+	}
+	// parameter schemas are exported before collection schemas
+	if assert.Len(t, order, 2) {
+		assert.Equal(t, "valid-namespace/parameterschemas/integer-param-schema.yaml", order[0])
+	}
+	// the archive ends with a trailer that counts the manifests
+	if assert.NotNil(t, trailer) {
+		assert.Equal(t, int64(2), gjson.GetBytes(trailer, "objects").Int())
+	}
+
+	// export of a non-existing catalog
+	httpReq, _ = http.NewRequest("GET", "/catalogs/invalid-catalog/export", nil)
+	response = executeTestRequest(t, httpReq, nil, testContext)
+	assert.NotEqual(t, http.StatusOK, response.Code)
+}
+
+func TestImportCatalog(t *testing.T) {
+	ctx := newDb()
+	t.Cleanup(func() {
+		db.DB(ctx).Close(ctx)
+	})
+	testContextP := createTestObjects(t, ctx)
+	testContext := *testContextP
+
+	// export the workspace and import it back into a fresh workspace
+	httpReq, _ := http.NewRequest("GET", "/catalogs/valid-catalog/export", nil)
+	response := executeTestRequest(t, httpReq, nil, testContext)
+	require.Equal(t, http.StatusOK, response.Code)
+	archive := response.Body.Bytes()
+
+	httpReq, _ = http.NewRequest("POST", "/catalogs/valid-catalog/import", bytes.NewReader(archive))
+	response = executeTestRequest(t, httpReq, nil, testContext)
+	if !assert.Equal(t, http.StatusCreated, response.Code) {
+		t.Logf("Response: %v", response.Body.String())
+		t.FailNow()
+	}
+	rspJson := response.Body.Bytes()
+	assert.False(t, gjson.GetBytes(rspJson, "rolledBack").Bool())
+	assert.False(t, gjson.GetBytes(rspJson, "committed").Bool())
+	assert.NotEmpty(t, gjson.GetBytes(rspJson, "workspace").String())
+	assert.Equal(t, "/workspaces/"+gjson.GetBytes(rspJson, "workspace").String(), response.Header().Get("Location"))
+	results := gjson.GetBytes(rspJson, "results").Array()
+	if assert.Len(t, results, 2) {
+		assert.Equal(t, "ParameterSchema", results[0].Get("kind").String())
+		assert.Equal(t, "CollectionSchema", results[1].Get("kind").String())
+		for _, r := range results {
+			assert.Empty(t, r.Get("error").String())
+		}
+	}
+
+	// an archive with a failing manifest is rolled back
+	var buf bytes.Buffer
+	gw := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gw)
+	files := map[string]string{
+		"collections/broken.yaml": `
+version: v1
+kind: Collection
+metadata:
+  name: broken
+  path: /
+spec:
+  schema: does-not-exist
+`,
+		"parameterschemas/param.yaml": `
+version: v1
+kind: ParameterSchema
+metadata:
+  name: another-param-schema
+  catalog: some-other-catalog
+spec:
+  dataType: Integer
+  default: 5
+`,
+	}
+	for name, content := range files {
+		require.NoError(t, tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(content))}))
+		_, err := tw.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.Close())
+	require.NoError(t, gw.Close())
+
+	httpReq, _ = http.NewRequest("POST", "/catalogs/valid-catalog/import", &buf)
+	response = executeTestRequest(t, httpReq, nil, testContext)
+	if !assert.Equal(t, http.StatusUnprocessableEntity, response.Code) {
+		t.Logf("Response: %v", response.Body.String())
+		t.FailNow()
+	}
+	rspJson = response.Body.Bytes()
+	assert.True(t, gjson.GetBytes(rspJson, "rolledBack").Bool())
+	assert.Empty(t, gjson.GetBytes(rspJson, "workspace").String())
+	results = gjson.GetBytes(rspJson, "results").Array()
+	if assert.Len(t, results, 2) {
+		// the parameter is applied first, with the catalog taken from the url
+		assert.Equal(t, "parameterschemas/param.yaml", results[0].Get("file").String())
+		assert.Empty(t, results[0].Get("error").String())
+		assert.Equal(t, "collections/broken.yaml", results[1].Get("file").String())
+		assert.NotEmpty(t, results[1].Get("error").String())
+	}
+
+	// a request that is not a gzip archive is rejected
+	httpReq, _ = http.NewRequest("POST", "/catalogs/valid-catalog/import", strings.NewReader("not an archive"))
+	response = executeTestRequest(t, httpReq, nil, testContext)
+	assert.Equal(t, http.StatusBadRequest, response.Code)
+}
+
+func TestImportSizeLimits(t *testing.T) {
+	ctx := newDb()
+	t.Cleanup(func() {
+		db.DB(ctx).Close(ctx)
+	})
+	testContextP := createTestObjects(t, ctx)
+	testContext := *testContextP
+
+	file := filepath.Join(t.TempDir(), "config.toml")
+	require.NoError(t, os.WriteFile(file, []byte("max_import_object_size = 512\nmax_import_size = 8192\n"), 0o600))
+	require.NoError(t, config.LoadConfig(file))
+	t.Cleanup(func() {
+		_ = config.LoadConfig("")
+	})
+
+	archive := func(files map[string]string) *bytes.Buffer {
+		var buf bytes.Buffer
+		gw := gzip.NewWriter(&buf)
+		tw := tar.NewWriter(gw)
+		for name, content := range files {
+			require.NoError(t, tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(content))}))
+			_, err := tw.Write([]byte(content))
+			require.NoError(t, err)
+		}
+		require.NoError(t, tw.Close())
+		require.NoError(t, gw.Close())
+		return &buf
+	}
+	param := `{"version": "v1", "kind": "ParameterSchema", "metadata": {"name": "small-param", "path": "/"}, "spec": {"dataType": "Integer"}}`
+
+	// a manifest over the limit is reported and not read
+	large := `{"version": "v1", "kind": "ParameterSchema", "metadata": {"name": "large-param", "path": "/", "description": "` +
+		strings.Repeat("x", 1024) + `"}, "spec": {"dataType": "Integer"}}`
+	httpReq, _ := http.NewRequest("POST", "/catalogs/valid-catalog/import", archive(map[string]string{
+		"parameterschemas/small.json": param,
+		"parameterschemas/large.json": large,
+	}))
+	response := executeTestRequest(t, httpReq, nil, testContext)
+	if !assert.Equal(t, http.StatusUnprocessableEntity, response.Code) {
+		t.Logf("Response: %v", response.Body.String())
+		t.FailNow()
+	}
+	for _, r := range gjson.GetBytes(response.Body.Bytes(), "results").Array() {
+		if r.Get("file").String() == "parameterschemas/large.json" {
+			assert.Contains(t, r.Get("error").String(), "larger than 512 bytes")
+		}
+	}
+
+	// an archive over the limit is rejected
+	files := make(map[string]string)
+	for i := 0; i < 32; i++ {
+		files["parameterschemas/param-"+strconv.Itoa(i)+".json"] = param
+	}
+	httpReq, _ = http.NewRequest("POST", "/catalogs/valid-catalog/import", archive(files))
+	response = executeTestRequest(t, httpReq, nil, testContext)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, response.Code)
+}
+
+func TestImportConcurrency(t *testing.T) {
+	ctx := newDb()
+	t.Cleanup(func() {
+		db.DB(ctx).Close(ctx)
+	})
+	testContextP := createTestObjects(t, ctx)
+	testContext := *testContextP
+
+	file := filepath.Join(t.TempDir(), "config.toml")
+	require.NoError(t, os.WriteFile(file, []byte("import_concurrency = 4\n"), 0o600))
+	require.NoError(t, config.LoadConfig(file))
+	t.Cleanup(func() {
+		_ = config.LoadConfig("")
+	})
+
+	type entry struct{ name, content string }
+	archive := func(entries []entry) *bytes.Buffer {
+		var buf bytes.Buffer
+		gw := gzip.NewWriter(&buf)
+		tw := tar.NewWriter(gw)
+		for _, e := range entries {
+			require.NoError(t, tw.WriteHeader(&tar.Header{Name: e.name, Mode: 0644, Size: int64(len(e.content))}))
+			_, err := tw.Write([]byte(e.content))
+			require.NoError(t, err)
+		}
+		require.NoError(t, tw.Close())
+		require.NoError(t, gw.Close())
+		return &buf
+	}
+	manifests := func(badParam int) []entry {
+		var entries []entry
+		// collections are listed first; they are applied after the schemas they depend on
+		for i := 0; i < 4; i++ {
+			entries = append(entries, entry{
+				"collections/c-" + strconv.Itoa(i) + ".json",
+				`{"version": "v1", "kind": "Collection", "metadata": {"name": "c-` + strconv.Itoa(i) + `", "path": "/"}, "spec": {"schema": "imported-schema", "values": {"p0": ` + strconv.Itoa(i) + `}}}`,
+			})
+		}
+		entries = append(entries, entry{
+			"collectionschemas/imported-schema.json",
+			`{"version": "v1", "kind": "CollectionSchema", "metadata": {"name": "imported-schema", "path": "/"}, "spec": {"parameters": {"p0": {"schema": "import-param-0"}, "p1": {"schema": "import-param-1"}}}}`,
+		})
+		for i := 0; i < 12; i++ {
+			dataType := "Integer"
+			if i == badParam {
+				dataType = "NoSuchType"
+			}
+			entries = append(entries, entry{
+				"parameterschemas/import-param-" + strconv.Itoa(i) + ".json",
+				`{"version": "v1", "kind": "ParameterSchema", "metadata": {"name": "import-param-` + strconv.Itoa(i) + `", "path": "/"}, "spec": {"dataType": "` + dataType + `"}}`,
+			})
+		}
+		return entries
+	}
+	files := func(rsp []byte) []string {
+		var f []string
+		for _, r := range gjson.GetBytes(rsp, "results").Array() {
+			f = append(f, r.Get("file").String())
+		}
+		return f
+	}
+
+	// all manifests are applied, and results are reported in dependency order and then archive order
+	httpReq, _ := http.NewRequest("POST", "/catalogs/valid-catalog/import", archive(manifests(-1)))
+	response := executeTestRequest(t, httpReq, nil, testContext)
+	if !assert.Equal(t, http.StatusCreated, response.Code) {
+		t.Logf("Response: %v", response.Body.String())
+		t.FailNow()
+	}
+	var want []string
+	for i := 0; i < 12; i++ {
+		want = append(want, "parameterschemas/import-param-"+strconv.Itoa(i)+".json")
+	}
+	want = append(want, "collectionschemas/imported-schema.json")
+	for i := 0; i < 4; i++ {
+		want = append(want, "collections/c-"+strconv.Itoa(i)+".json")
+	}
+	assert.Equal(t, want, files(response.Body.Bytes()))
+	for _, r := range gjson.GetBytes(response.Body.Bytes(), "results").Array() {
+		assert.Empty(t, r.Get("error").String(), r.Get("file").String())
+		assert.NotEmpty(t, r.Get("location").String(), r.Get("file").String())
+	}
+
+	// a failure stops the import before the next stage and rolls it back
+	httpReq, _ = http.NewRequest("POST", "/catalogs/valid-catalog/import", archive(manifests(5)))
+	response = executeTestRequest(t, httpReq, nil, testContext)
+	if !assert.Equal(t, http.StatusUnprocessableEntity, response.Code) {
+		t.Logf("Response: %v", response.Body.String())
+		t.FailNow()
+	}
+	assert.True(t, gjson.GetBytes(response.Body.Bytes(), "rolledBack").Bool())
+	// every manifest has a result, and those of the later stages were not applied
+	assert.Equal(t, want, files(response.Body.Bytes()))
+	for _, r := range gjson.GetBytes(response.Body.Bytes(), "results").Array() {
+		switch r.Get("file").String() {
+		case "parameterschemas/import-param-5.json":
+			assert.NotEmpty(t, r.Get("error").String())
+		case "collectionschemas/imported-schema.json":
+			assert.Contains(t, r.Get("error").String(), "not applied")
+			assert.Empty(t, r.Get("location").String())
+		}
+	}
+
+	// with continueOnError, every manifest is applied and only the bad one fails
+	httpReq, _ = http.NewRequest("POST", "/catalogs/valid-catalog/import?continueOnError=true", archive(manifests(5)))
+	response = executeTestRequest(t, httpReq, nil, testContext)
+	if !assert.Equal(t, http.StatusCreated, response.Code) {
+		t.Logf("Response: %v", response.Body.String())
+		t.FailNow()
+	}
+	assert.Equal(t, want, files(response.Body.Bytes()))
+	for _, r := range gjson.GetBytes(response.Body.Bytes(), "results").Array() {
+		if r.Get("file").String() == "parameterschemas/import-param-5.json" {
+			assert.NotEmpty(t, r.Get("error").String())
+		} else {
+			assert.Empty(t, r.Get("error").String(), r.Get("file").String())
+		}
+	}
+}
+
+func TestReplaceNamespaceContents(t *testing.T) {
+	ctx := newDb()
+	t.Cleanup(func() {
+		db.DB(ctx).Close(ctx)
+	})
+	testContextP := createTestObjects(t, ctx)
+	testContext := *testContextP
+	// the contents are committed to the variant, so they are read without the workspace
+	variantContext := testContext
+	variantContext.CatalogContext.WorkspaceLabel = ""
+
+	archive := func(files map[string]string) *bytes.Buffer {
+		var buf bytes.Buffer
+		gw := gzip.NewWriter(&buf)
+		tw := tar.NewWriter(gw)
+		for name, content := range files {
+			require.NoError(t, tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(content))}))
+			_, err := tw.Write([]byte(content))
+			require.NoError(t, err)
+		}
+		require.NoError(t, tw.Close())
+		require.NoError(t, gw.Close())
+		return &buf
+	}
+	param := func(name string, def int) string {
+		return `{"version": "v1", "kind": "ParameterSchema", "metadata": {"name": "` + name + `", "path": "/"}, "spec": {"dataType": "Integer", "default": ` + strconv.Itoa(def) + `}}`
+	}
+	schema := `{"version": "v1", "kind": "CollectionSchema", "metadata": {"name": "ns-schema", "path": "/"}, "spec": {"parameters": {"maxRetries": {"schema": "ns-param"}}}}`
+	replace := func(query string, files map[string]string) (int, []byte) {
+		httpReq, _ := http.NewRequest("PUT", "/namespaces/valid-namespace/contents"+query, archive(files))
+		response := executeTestRequest(t, httpReq, nil, testContext)
+		return response.Code, response.Body.Bytes()
+	}
+	changes := func(rsp []byte) []string {
+		c := []string{}
+		for _, ch := range gjson.GetBytes(rsp, "changes").Array() {
+			c = append(c, ch.Get("action").String()+" "+ch.Get("kind").String()+" "+ch.Get("path").String())
+		}
+		return c
+	}
+	defaultOf := func(name string) string {
+		httpReq, _ := http.NewRequest("GET", "/parameterschemas/"+name, nil)
+		response := executeTestRequest(t, httpReq, nil, variantContext)
+		if response.Code != http.StatusOK {
+			return strconv.Itoa(response.Code)
+		}
+		return gjson.GetBytes(response.Body.Bytes(), "spec.default").String()
+	}
+
+	// objects that are not in the namespace are created, in dependency order
+	code, rsp := replace("", map[string]string{
+		"collectionschemas/ns-schema.json": schema,
+		"parameterschemas/ns-param.json":   param("ns-param", 5),
+		"parameterschemas/extra.json":      param("extra-param", 1),
+	})
+	if !assert.Equal(t, http.StatusOK, code) {
+		t.Logf("Response: %s", rsp)
+		t.FailNow()
+	}
+	assert.Equal(t, "valid-namespace", gjson.GetBytes(rsp, "namespace").String())
+	assert.False(t, gjson.GetBytes(rsp, "rolledBack").Bool())
+	assert.Equal(t, []string{
+		"added ParameterSchema /extra-param",
+		"added ParameterSchema /ns-param",
+		"added CollectionSchema /ns-schema",
+	}, changes(rsp))
+	assert.Equal(t, "5", defaultOf("ns-param"))
+
+	// applying the same contents again changes nothing
+	code, rsp = replace("", map[string]string{
+		"collectionschemas/ns-schema.json": schema,
+		"parameterschemas/ns-param.json":   param("ns-param", 5),
+		"parameterschemas/extra.json":      param("extra-param", 1),
+	})
+	assert.Equal(t, http.StatusOK, code)
+	assert.Empty(t, changes(rsp))
+
+	// without pruning, objects that are not in the archive are kept
+	code, rsp = replace("?prune=false", map[string]string{
+		"collectionschemas/ns-schema.json": schema,
+		"parameterschemas/ns-param.json":   param("ns-param", 7),
+	})
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, []string{"modified ParameterSchema /ns-param"}, changes(rsp))
+	assert.Equal(t, "7", defaultOf("ns-param"))
+	assert.Equal(t, "1", defaultOf("extra-param"))
+
+	// by default, they are deleted
+	code, rsp = replace("", map[string]string{
+		"collectionschemas/ns-schema.json": schema,
+		"parameterschemas/ns-param.json":   param("ns-param", 7),
+	})
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, []string{"deleted ParameterSchema /extra-param"}, changes(rsp))
+	assert.Equal(t, strconv.Itoa(http.StatusNotFound), defaultOf("extra-param"))
+
+	// a manifest that cannot be applied leaves the namespace unchanged
+	code, rsp = replace("", map[string]string{
+		"collectionschemas/ns-schema.json": schema,
+		"parameterschemas/ns-param.json":   param("ns-param", 9),
+		"collections/broken.json":          `{"version": "v1", "kind": "Collection", "metadata": {"name": "broken", "path": "/"}, "spec": {"schema": "does-not-exist"}}`,
+	})
+	if !assert.Equal(t, http.StatusUnprocessableEntity, code) {
+		t.Logf("Response: %s", rsp)
+		t.FailNow()
+	}
+	assert.True(t, gjson.GetBytes(rsp, "rolledBack").Bool())
+	assert.Empty(t, changes(rsp))
+	results := gjson.GetBytes(rsp, "results").Array()
+	if assert.Len(t, results, 1) {
+		assert.Equal(t, "collections/broken.json", results[0].Get("file").String())
+		assert.NotEmpty(t, results[0].Get("error").String())
+	}
+	assert.Equal(t, "7", defaultOf("ns-param"))
+
+	// two manifests for the same object are rejected
+	code, rsp = replace("", map[string]string{
+		"parameterschemas/a.json": param("ns-param", 1),
+		"parameterschemas/b.json": param("ns-param", 2),
+	})
+	assert.Equal(t, http.StatusUnprocessableEntity, code)
+	assert.True(t, gjson.GetBytes(rsp, "rolledBack").Bool())
+	assert.Equal(t, "7", defaultOf("ns-param"))
+
+	// the namespace must exist
+	httpReq, _ := http.NewRequest("PUT", "/namespaces/no-such-namespace/contents", archive(map[string]string{
+		"parameterschemas/ns-param.json": param("ns-param", 5),
+	}))
+	response := executeTestRequest(t, httpReq, nil, testContext)
+	assert.Equal(t, http.StatusNotFound, response.Code)
+}
@@ -0,0 +1,107 @@
+package server
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/mugiliam/hatchcatalogsrv/internal/db"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
+	"sigs.k8s.io/yaml"
+)
+
+func TestBatchDelete(t *testing.T) {
+	ctx := newDb()
+	t.Cleanup(func() {
+		db.DB(ctx).Close(ctx)
+	})
+	testContextP := createTestObjects(t, ctx)
+	testContext := *testContextP
+
+	reqYaml := `
+		version: v1
+		kind: Collection
+		metadata:
+			name: my-collection
+			path: /
+		spec:
+			schema: valid
+	`
+	replaceTabsWithSpaces(&reqYaml)
+	reqJson, err := yaml.YAMLToJSON([]byte(reqYaml))
+	require.NoError(t, err)
+	httpReq, _ := http.NewRequest("POST", "/collections", nil)
+	setRequestBodyAndHeader(t, httpReq, string(reqJson))
+	response := executeTestRequest(t, httpReq, nil, testContext)
+	if !assert.Equal(t, http.StatusCreated, response.Code) {
+		t.Logf("Response: %v", response.Body.String())
+		t.FailNow()
+	}
+
+	batchDelete := func(url, body string) []gjson.Result {
+		httpReq, _ := http.NewRequest("POST", url, nil)
+		setRequestBodyAndHeader(t, httpReq, body)
+		response := executeTestRequest(t, httpReq, nil, testContext)
+		if !assert.Equal(t, http.StatusOK, response.Code) {
+			t.Logf("Response: %v", response.Body.String())
+			t.FailNow()
+		}
+		return gjson.Get(response.Body.String(), "items").Array()
+	}
+	exists := func(url string) bool {
+		httpReq, _ := http.NewRequest("GET", url, nil)
+		return executeTestRequest(t, httpReq, nil, testContext).Code == http.StatusOK
+	}
+
+	// a parameter schema referenced by a collection schema outside of the batch is blocked
+	items := batchDelete("/parameterschemas:batchDelete", `{"items": [{"name": "integer-param-schema", "path": "/"}]}`)
+	if assert.Len(t, items, 1) {
+		assert.Equal(t, "blocked", items[0].Get("status").String())
+		assert.Contains(t, items[0].Get("reason").String(), "/valid")
+	}
+	assert.True(t, exists("/parameterschemas/integer-param-schema"))
+
+	// so is a collection schema that a collection is based on
+	items = batchDelete("/collectionschemas:batchDelete", `{"items": [{"name": "valid", "path": "/"}]}`)
+	if assert.Len(t, items, 1) {
+		assert.Equal(t, "blocked", items[0].Get("status").String())
+		assert.Contains(t, items[0].Get("reason").String(), "my-collection")
+	}
+
+	// listed together, they can be deleted in dependency order. A dry run only reports it.
+	body := `{
+		"items": [
+			{"name": "integer-param-schema", "path": "/", "kind": "ParameterSchema"},
+			{"name": "valid", "path": "/"},
+			{"name": "missing", "path": "/"},
+			{"name": "my-collection", "path": "/", "kind": "Collection"}
+		]
+	}`
+	items = batchDelete("/collectionschemas:batchDelete?dryRun=true", body)
+	if assert.Len(t, items, 4) {
+		assert.Equal(t, "wouldDelete", items[0].Get("status").String())
+		assert.Equal(t, "wouldDelete", items[1].Get("status").String())
+		assert.Equal(t, "CollectionSchema", items[1].Get("kind").String())
+		assert.Equal(t, "notFound", items[2].Get("status").String())
+		assert.Equal(t, "wouldDelete", items[3].Get("status").String())
+	}
+	assert.True(t, exists("/collectionschemas/valid"))
+	assert.True(t, exists("/parameterschemas/integer-param-schema"))
+
+	items = batchDelete("/collectionschemas:batchDelete", body)
+	if assert.Len(t, items, 4) {
+		assert.Equal(t, "deleted", items[0].Get("status").String())
+		assert.Equal(t, "deleted", items[1].Get("status").String())
+		assert.Equal(t, "notFound", items[2].Get("status").String())
+		assert.Equal(t, "deleted", items[3].Get("status").String())
+	}
+	assert.False(t, exists("/collectionschemas/valid"))
+	assert.False(t, exists("/parameterschemas/integer-param-schema"))
+
+	// unsupported kinds fail the request
+	httpReq, _ = http.NewRequest("POST", "/collections:batchDelete", nil)
+	setRequestBodyAndHeader(t, httpReq, `{"items": [{"name": "valid-catalog", "kind": "Catalog"}]}`)
+	response = executeTestRequest(t, httpReq, nil, testContext)
+	assert.Equal(t, http.StatusBadRequest, response.Code)
+}
@@ -0,0 +1,81 @@
+package server
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/mugiliam/hatchcatalogsrv/internal/db"
+	"github.com/stretchr/testify/assert"
+	"github.com/tidwall/gjson"
+)
+
+func TestBatchGetSchemas(t *testing.T) {
+	ctx := newDb()
+	t.Cleanup(func() {
+		db.DB(ctx).Close(ctx)
+	})
+	testContextP := createTestObjects(t, ctx)
+	testContext := *testContextP
+
+	httpReq, _ := http.NewRequest("GET", "/collectionschemas/valid", nil)
+	response := executeTestRequest(t, httpReq, nil, testContext)
+	assert.Equal(t, http.StatusOK, response.Code)
+	expected := response.Body.String()
+
+	// hits and misses are returned in the order requested
+	httpReq, _ = http.NewRequest("POST", "/collectionschemas:batchGet", nil)
+	setRequestBodyAndHeader(t, httpReq, `{
+		"items": [
+			{"name": "missing", "path": "/"},
+			{"name": "valid", "path": "/"},
+			{"name": "valid", "path": "/", "namespace": "other-namespace"},
+			{"name": "valid", "path": "/a/b"}
+		]
+	}`)
+	response = executeTestRequest(t, httpReq, nil, testContext)
+	if !assert.Equal(t, http.StatusOK, response.Code) {
+		t.Logf("Response: %v", response.Body.String())
+		t.FailNow()
+	}
+	items := gjson.Get(response.Body.String(), "items").Array()
+	if assert.Len(t, items, 4) {
+		assert.Equal(t, "missing", items[0].Get("name").String())
+		assert.False(t, items[0].Get("found").Bool())
+		assert.False(t, items[0].Get("object").Exists())
+
+		assert.True(t, items[1].Get("found").Bool())
+		assert.Equal(t, "valid-namespace", items[1].Get("namespace").String())
+		assert.JSONEq(t, expected, items[1].Get("object").Raw)
+
+		assert.False(t, items[2].Get("found").Bool())
+		assert.Equal(t, "other-namespace", items[2].Get("namespace").String())
+
+		assert.False(t, items[3].Get("found").Bool())
+		assert.Equal(t, "/a/b", items[3].Get("path").String())
+	}
+
+	// parameter schemas
+	httpReq, _ = http.NewRequest("POST", "/parameterschemas:batchGet", nil)
+	setRequestBodyAndHeader(t, httpReq, `{"items": [{"name": "integer-param-schema", "path": "/"}, {"name": "valid", "path": "/"}]}`)
+	response = executeTestRequest(t, httpReq, nil, testContext)
+	assert.Equal(t, http.StatusOK, response.Code)
+	items = gjson.Get(response.Body.String(), "items").Array()
+	if assert.Len(t, items, 2) {
+		assert.True(t, items[0].Get("found").Bool())
+		assert.Equal(t, "ParameterSchema", items[0].Get("object.kind").String())
+		assert.False(t, items[1].Get("found").Bool())
+	}
+
+	// an empty batch returns no items
+	httpReq, _ = http.NewRequest("POST", "/collectionschemas:batchGet", nil)
+	setRequestBodyAndHeader(t, httpReq, `{"items": []}`)
+	response = executeTestRequest(t, httpReq, nil, testContext)
+	assert.Equal(t, http.StatusOK, response.Code)
+	assert.JSONEq(t, `{"items": []}`, response.Body.String())
+
+	// invalid names fail the request
+	httpReq, _ = http.NewRequest("POST", "/collectionschemas:batchGet", nil)
+	setRequestBodyAndHeader(t, httpReq, `{"items": [{"name": "not a valid name", "path": "/"}]}`)
+	response = executeTestRequest(t, httpReq, nil, testContext)
+	assert.Equal(t, http.StatusBadRequest, response.Code)
+}
@@ -0,0 +1,69 @@
+package server
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/mugiliam/hatchcatalogsrv/internal/db"
+	"github.com/stretchr/testify/assert"
+	"github.com/tidwall/gjson"
+)
+
+func TestRenameCatalog(t *testing.T) {
+	ctx := newDb()
+	t.Cleanup(func() {
+		db.DB(ctx).Close(ctx)
+	})
+	testContextP := createTestObjects(t, ctx)
+	testContext := *testContextP
+
+	do := func(method, target, body string, tc TestContext) (int, []byte) {
+		httpReq, _ := http.NewRequest(method, target, nil)
+		if body != "" {
+			setRequestBodyAndHeader(t, httpReq, body)
+		}
+		response := executeTestRequest(t, httpReq, nil, tc)
+		return response.Code, response.Body.Bytes()
+	}
+
+	code, body := do("POST", "/catalogs", `{"version": "v1", "kind": "Catalog", "metadata": {"name": "other-catalog"}}`, testContext)
+	if !assert.Equal(t, http.StatusCreated, code) {
+		t.Logf("Response: %s", body)
+		t.FailNow()
+	}
+
+	// a name that is taken by another catalog is a conflict
+	code, _ = do("POST", "/catalogs/valid-catalog/rename", `{"newName": "other-catalog"}`, testContext)
+	assert.Equal(t, http.StatusConflict, code)
+	code, _ = do("POST", "/catalogs/valid-catalog/rename", `{"newName": "not a name"}`, testContext)
+	assert.Equal(t, http.StatusBadRequest, code)
+	code, _ = do("POST", "/catalogs/no-such-catalog/rename", `{"newName": "renamed-catalog"}`, testContext)
+	assert.Equal(t, http.StatusNotFound, code)
+
+	code, body = do("POST", "/catalogs/valid-catalog/rename", `{"newName": "renamed-catalog"}`, testContext)
+	if !assert.Equal(t, http.StatusOK, code) {
+		t.Logf("Response: %s", body)
+		t.FailNow()
+	}
+	assert.Equal(t, "renamed-catalog", gjson.GetBytes(body, "metadata.name").String())
+	renamed := testContext
+	renamed.CatalogContext.Catalog = "renamed-catalog"
+	code, _ = do("GET", "/catalogs/valid-catalog", "", renamed)
+	assert.Equal(t, http.StatusNotFound, code)
+	code, _ = do("GET", "/catalogs/renamed-catalog", "", renamed)
+	assert.Equal(t, http.StatusOK, code)
+
+	// the children of the catalog are found under the new name
+	code, body = do("GET", "/variants/valid-variant", "", renamed)
+	assert.Equal(t, http.StatusOK, code, string(body))
+	code, body = do("GET", "/namespaces/valid-namespace", "", renamed)
+	assert.Equal(t, http.StatusOK, code, string(body))
+	code, body = do("GET", "/parameterschemas/integer-param-schema", "", renamed)
+	if assert.Equal(t, http.StatusOK, code, string(body)) {
+		assert.Equal(t, "renamed-catalog", gjson.GetBytes(body, "metadata.catalog").String())
+	}
+	code, body = do("GET", "/collectionschemas/valid", "", renamed)
+	assert.Equal(t, http.StatusOK, code, string(body))
+	code, _ = do("GET", "/collectionschemas/valid", "", testContext)
+	assert.NotEqual(t, http.StatusOK, code)
+}
@@ -0,0 +1,77 @@
+package server
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/mugiliam/hatchcatalogsrv/internal/db"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
+	"sigs.k8s.io/yaml"
+)
+
+func TestCatalogStats(t *testing.T) {
+	ctx := newDb()
+	t.Cleanup(func() {
+		db.DB(ctx).Close(ctx)
+	})
+	testContextP := createTestObjects(t, ctx)
+	testContext := *testContextP
+
+	get := func(target string, expectedStatus int) []byte {
+		httpReq, _ := http.NewRequest("GET", target, nil)
+		response := executeTestRequest(t, httpReq, nil, testContext)
+		if !assert.Equal(t, expectedStatus, response.Code) {
+			t.Logf("Response: %v", response.Body.String())
+			t.FailNow()
+		}
+		return response.Body.Bytes()
+	}
+
+	before := get("/catalogs/valid-catalog/stats?v=valid-variant", http.StatusOK)
+	assert.Equal(t, "valid-catalog", gjson.GetBytes(before, "catalog").String())
+	assert.Equal(t, "valid-variant", gjson.GetBytes(before, "variant").String())
+	assert.Equal(t, int64(1), gjson.GetBytes(before, "namespaces").Int())
+	assert.Equal(t, int64(1), gjson.GetBytes(before, "workspaces").Int())
+	assert.Equal(t, int64(1), gjson.GetBytes(before, "versions").Int())
+
+	// a parameter schema saved to the variant rather than the workspace is counted
+	variantContext := testContext
+	variantContext.CatalogContext.WorkspaceLabel = ""
+	reqYaml := `
+		version: v1
+		kind: ParameterSchema
+		metadata:
+			name: stats-param-schema
+			catalog: valid-catalog
+			variant: valid-variant
+			path: /
+		spec:
+			dataType: Integer
+			default: 5
+	`
+	replaceTabsWithSpaces(&reqYaml)
+	reqJson, err := yaml.YAMLToJSON([]byte(reqYaml))
+	require.NoError(t, err)
+	httpReq, _ := http.NewRequest("POST", "/parameterschemas", nil)
+	setRequestBodyAndHeader(t, httpReq, string(reqJson))
+	response := executeTestRequest(t, httpReq, nil, variantContext)
+	if !assert.Equal(t, http.StatusCreated, response.Code) {
+		t.Logf("Response: %v", response.Body.String())
+		t.FailNow()
+	}
+
+	after := get("/catalogs/valid-catalog/stats?v=valid-variant", http.StatusOK)
+	assert.Equal(t, gjson.GetBytes(before, "parameterSchemas").Int()+1, gjson.GetBytes(after, "parameterSchemas").Int())
+	assert.Equal(t, gjson.GetBytes(before, "collectionSchemas").Int(), gjson.GetBytes(after, "collectionSchemas").Int())
+	assert.Equal(t, gjson.GetBytes(before, "collections").Int(), gjson.GetBytes(after, "collections").Int())
+	assert.Greater(t, gjson.GetBytes(after, "objectBytes").Int(), gjson.GetBytes(before, "objectBytes").Int())
+
+	// the variant defaults to the one in the catalog context
+	assert.Equal(t, gjson.GetBytes(after, "parameterSchemas").Int(),
+		gjson.GetBytes(get("/catalogs/valid-catalog/stats", http.StatusOK), "parameterSchemas").Int())
+
+	get("/catalogs/no-such-catalog/stats?v=valid-variant", http.StatusNotFound)
+	get("/catalogs/valid-catalog/stats?variant=no-such-variant", http.StatusNotFound)
+}
@@ -0,0 +1,85 @@
+package server
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/mugiliam/hatchcatalogsrv/internal/common"
+	"github.com/mugiliam/hatchcatalogsrv/internal/db"
+	"github.com/mugiliam/hatchcatalogsrv/internal/db/dberror"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+	"sigs.k8s.io/yaml"
+)
+
+func TestDeleteCollectionReclaimsObject(t *testing.T) {
+	ctx := newDb()
+	t.Cleanup(func() {
+		db.DB(ctx).Close(ctx)
+	})
+	testContextP := createTestObjects(t, ctx)
+	testContext := *testContextP
+
+	ctx = common.SetTenantIdInContext(ctx, testContext.TenantId)
+	ctx = common.SetProjectIdInContext(ctx, testContext.ProjectId)
+
+	reqYaml := `
+		version: v1
+		kind: Collection
+		metadata:
+			name: first
+			path: /reclaim
+		spec:
+			schema: valid
+			values:
+				maxRetries: 6
+	`
+	replaceTabsWithSpaces(&reqYaml)
+	reqJson, err := yaml.YAMLToJSON([]byte(reqYaml))
+	require.NoError(t, err)
+
+	// create collections and return the hash of their values
+	create := func(name string, maxRetries int) string {
+		j, _ := sjson.SetBytes(reqJson, "metadata.name", name)
+		j, _ = sjson.SetBytes(j, "spec.values.maxRetries", maxRetries)
+		httpReq, _ := http.NewRequest("POST", "/collections", nil)
+		setRequestBodyAndHeader(t, httpReq, string(j))
+		response := executeTestRequest(t, httpReq, nil, testContext)
+		if !assert.Equal(t, http.StatusCreated, response.Code) {
+			t.Logf("Response: %v", response.Body.String())
+			t.FailNow()
+		}
+		httpReq, _ = http.NewRequest("GET", "/collections/reclaim/"+name+"?history=true", nil)
+		response = executeTestRequest(t, httpReq, nil, testContext)
+		require.Equal(t, http.StatusOK, response.Code)
+		history := gjson.GetBytes(response.Body.Bytes(), "history").Array()
+		require.Len(t, history, 1)
+		return history[0].Get("hash").String()
+	}
+	remove := func(name string) {
+		httpReq, _ := http.NewRequest("DELETE", "/collections/reclaim/"+name, nil)
+		response := executeTestRequest(t, httpReq, nil, testContext)
+		require.Equal(t, http.StatusNoContent, response.Code)
+	}
+
+	// collections with the same values share an object
+	shared := create("first", 6)
+	assert.Equal(t, shared, create("second", 6))
+	unique := create("third", 7)
+	assert.NotEqual(t, shared, unique)
+
+	// the shared object survives while a collection refers to it
+	remove("first")
+	_, err = db.DB(ctx).GetCatalogObject(ctx, shared)
+	assert.NoError(t, err)
+
+	// deleting the last collection that refers to an object removes it
+	remove("second")
+	_, err = db.DB(ctx).GetCatalogObject(ctx, shared)
+	assert.ErrorIs(t, err, dberror.ErrNotFound)
+	remove("third")
+	_, err = db.DB(ctx).GetCatalogObject(ctx, unique)
+	assert.ErrorIs(t, err, dberror.ErrNotFound)
+}
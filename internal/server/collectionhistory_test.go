@@ -0,0 +1,90 @@
+package server
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/mugiliam/hatchcatalogsrv/internal/db"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+	"sigs.k8s.io/yaml"
+)
+
+func TestCollectionHistory(t *testing.T) {
+	ctx := newDb()
+	t.Cleanup(func() {
+		db.DB(ctx).Close(ctx)
+	})
+	testContextP := createTestObjects(t, ctx)
+	testContext := *testContextP
+
+	reqYaml := `
+		version: v1
+		kind: Collection
+		metadata:
+			name: my-collection
+			path: /some/path
+		spec:
+			schema: valid
+			values:
+				maxRetries: 3
+	`
+	replaceTabsWithSpaces(&reqYaml)
+	reqJson, err := yaml.YAMLToJSON([]byte(reqYaml))
+	require.NoError(t, err)
+	httpReq, _ := http.NewRequest("POST", "/collections", nil)
+	setRequestBodyAndHeader(t, httpReq, string(reqJson))
+	response := executeTestRequest(t, httpReq, nil, testContext)
+	if !assert.Equal(t, http.StatusCreated, response.Code) {
+		t.Logf("Response: %v", response.Body.String())
+		t.FailNow()
+	}
+
+	// update the values
+	reqJson, _ = sjson.SetBytes(reqJson, "spec.values.maxRetries", 5)
+	httpReq, _ = http.NewRequest("PUT", "/collections/some/path/my-collection", nil)
+	setRequestBodyAndHeader(t, httpReq, string(reqJson))
+	response = executeTestRequest(t, httpReq, nil, testContext)
+	if !assert.Equal(t, http.StatusOK, response.Code) {
+		t.Logf("Response: %v", response.Body.String())
+		t.FailNow()
+	}
+
+	// the history lists both states, oldest first
+	httpReq, _ = http.NewRequest("GET", "/collections/some/path/my-collection?history=true", nil)
+	response = executeTestRequest(t, httpReq, nil, testContext)
+	if !assert.Equal(t, http.StatusOK, response.Code) {
+		t.Logf("Response: %v", response.Body.String())
+		t.FailNow()
+	}
+	rsp := response.Body.Bytes()
+	assert.Equal(t, "/some/path/my-collection", gjson.GetBytes(rsp, "path").String())
+	history := gjson.GetBytes(rsp, "history").Array()
+	require.Len(t, history, 2)
+	assert.NotEqual(t, history[0].Get("hash").String(), history[1].Get("hash").String())
+	assert.NotEmpty(t, history[0].Get("createdAt").String())
+
+	// the latest entry is the current value of the collection
+	httpReq, _ = http.NewRequest("GET", "/collections/some/path/my-collection", nil)
+	response = executeTestRequest(t, httpReq, nil, testContext)
+	require.Equal(t, http.StatusOK, response.Code)
+	assert.Equal(t, int64(5), gjson.GetBytes(response.Body.Bytes(), "spec.values.maxRetries").Int())
+
+	// history of a missing collection
+	httpReq, _ = http.NewRequest("GET", "/collections/some/path/missing?history=true", nil)
+	response = executeTestRequest(t, httpReq, nil, testContext)
+	assert.Equal(t, http.StatusNotFound, response.Code)
+
+	// a collection named history is read like any other
+	reqJson, _ = sjson.SetBytes(reqJson, "metadata.name", "history")
+	httpReq, _ = http.NewRequest("POST", "/collections", nil)
+	setRequestBodyAndHeader(t, httpReq, string(reqJson))
+	response = executeTestRequest(t, httpReq, nil, testContext)
+	require.Equal(t, http.StatusCreated, response.Code, response.Body.String())
+	httpReq, _ = http.NewRequest("GET", "/collections/some/path/history", nil)
+	response = executeTestRequest(t, httpReq, nil, testContext)
+	require.Equal(t, http.StatusOK, response.Code, response.Body.String())
+	assert.Equal(t, "history", gjson.GetBytes(response.Body.Bytes(), "metadata.name").String())
+}
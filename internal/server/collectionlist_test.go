@@ -0,0 +1,205 @@
+package server
+
+import (
+	"bufio"
+	"net/http"
+	"strconv"
+	"testing"
+
+	"github.com/mugiliam/hatchcatalogsrv/internal/db"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+	"sigs.k8s.io/yaml"
+)
+
+func TestListCollectionsByPathPrefix(t *testing.T) {
+	ctx := newDb()
+	t.Cleanup(func() {
+		db.DB(ctx).Close(ctx)
+	})
+	testContextP := createTestObjects(t, ctx)
+	testContext := *testContextP
+
+	reqYaml := `
+		version: v1
+		kind: Collection
+		metadata:
+			name: my-collection
+			path: /
+		spec:
+			schema: valid
+	`
+	replaceTabsWithSpaces(&reqYaml)
+	reqJson, err := yaml.YAMLToJSON([]byte(reqYaml))
+	require.NoError(t, err)
+	for _, p := range []string{"/app", "/app/nested", "/application", "/other"} {
+		reqJson, _ = sjson.SetBytes(reqJson, "metadata.path", p)
+		httpReq, _ := http.NewRequest("POST", "/collections", nil)
+		setRequestBodyAndHeader(t, httpReq, string(reqJson))
+		response := executeTestRequest(t, httpReq, nil, testContext)
+		if !assert.Equal(t, http.StatusCreated, response.Code) {
+			t.Logf("Response: %v", response.Body.String())
+			t.FailNow()
+		}
+	}
+
+	listPaths := func(query string) []string {
+		httpReq, _ := http.NewRequest("GET", "/collections"+query, nil)
+		response := executeTestRequest(t, httpReq, nil, testContext)
+		if !assert.Equal(t, http.StatusOK, response.Code) {
+			t.Logf("Response: %v", response.Body.String())
+			t.FailNow()
+		}
+		var paths []string
+		for _, c := range gjson.GetBytes(response.Body.Bytes(), "collections").Array() {
+			assert.Equal(t, "valid", c.Get("schema").String())
+			paths = append(paths, c.Get("path").String())
+		}
+		return paths
+	}
+
+	// the prefix is matched on path boundaries
+	assert.Equal(t, []string{"/app/my-collection", "/app/nested/my-collection"}, listPaths("?pathPrefix=/app"))
+	assert.Equal(t, []string{"/app/nested/my-collection"}, listPaths("?pathPrefix=/app/nested/"))
+	assert.Equal(t, []string{"/application/my-collection"}, listPaths("?pathPrefix=/application"))
+	assert.Empty(t, listPaths("?pathPrefix=/ap"))
+
+	// without a prefix all collections are listed in path order
+	assert.Equal(t, []string{
+		"/app/my-collection",
+		"/app/nested/my-collection",
+		"/application/my-collection",
+		"/other/my-collection",
+	}, listPaths(""))
+
+	// an invalid prefix
+	httpReq, _ := http.NewRequest("GET", "/collections?pathPrefix=/bad%20path", nil)
+	response := executeTestRequest(t, httpReq, nil, testContext)
+	assert.Equal(t, http.StatusBadRequest, response.Code)
+}
+
+func TestListCollectionsPaged(t *testing.T) {
+	ctx := newDb()
+	t.Cleanup(func() {
+		db.DB(ctx).Close(ctx)
+	})
+	testContextP := createTestObjects(t, ctx)
+	testContext := *testContextP
+
+	// created out of order, so that the list is not in the order of creation
+	for _, p := range []string{"/d", "/b", "/e", "/a", "/c"} {
+		httpReq, _ := http.NewRequest("POST", "/collections", nil)
+		setRequestBodyAndHeader(t, httpReq, `{"version": "v1", "kind": "Collection", "metadata": {"name": "paged", "path": "`+p+`"}, "spec": {"schema": "valid"}}`)
+		response := executeTestRequest(t, httpReq, nil, testContext)
+		require.Equal(t, http.StatusCreated, response.Code, response.Body.String())
+	}
+
+	list := func(query string) []string {
+		httpReq, _ := http.NewRequest("GET", "/collections"+query, nil)
+		response := executeTestRequest(t, httpReq, nil, testContext)
+		require.Equal(t, http.StatusOK, response.Code, response.Body.String())
+		paths := []string{}
+		for _, c := range gjson.GetBytes(response.Body.Bytes(), "collections").Array() {
+			paths = append(paths, c.Get("path").String())
+		}
+		return paths
+	}
+
+	all := []string{"/a/paged", "/b/paged", "/c/paged", "/d/paged", "/e/paged"}
+	for i := 0; i < 3; i++ {
+		assert.Equal(t, all, list(""))
+	}
+
+	// pages follow each other without overlap
+	var paged []string
+	for offset := 0; offset < len(all); offset += 2 {
+		page := list("?limit=2&offset=" + strconv.Itoa(offset))
+		assert.LessOrEqual(t, len(page), 2)
+		paged = append(paged, page...)
+	}
+	assert.Equal(t, all, paged)
+	assert.Equal(t, all[3:], list("?offset=3"))
+	assert.Empty(t, list("?offset=10"))
+	assert.Equal(t, []string{"/b/paged"}, list("?pathPrefix=/b&limit=1"))
+
+	for _, query := range []string{"?limit=-1", "?offset=x"} {
+		httpReq, _ := http.NewRequest("GET", "/collections"+query, nil)
+		response := executeTestRequest(t, httpReq, nil, testContext)
+		assert.Equal(t, http.StatusBadRequest, response.Code, query)
+	}
+}
+
+func TestStreamCollections(t *testing.T) {
+	ctx := newDb()
+	t.Cleanup(func() {
+		db.DB(ctx).Close(ctx)
+	})
+	testContextP := createTestObjects(t, ctx)
+	testContext := *testContextP
+
+	for _, p := range []string{"/d", "/b", "/e", "/a", "/c"} {
+		httpReq, _ := http.NewRequest("POST", "/collections", nil)
+		setRequestBodyAndHeader(t, httpReq, `{"version": "v1", "kind": "Collection", "metadata": {"name": "streamed", "path": "`+p+`"}, "spec": {"schema": "valid"}}`)
+		response := executeTestRequest(t, httpReq, nil, testContext)
+		require.Equal(t, http.StatusCreated, response.Code, response.Body.String())
+	}
+
+	// stream reads the records one line at a time, and returns the paths of the collections and the trailer
+	stream := func(query string) ([]string, gjson.Result) {
+		httpReq, _ := http.NewRequest("GET", "/collections?format=ndjson"+query, nil)
+		response := executeTestRequest(t, httpReq, nil, testContext)
+		require.Equal(t, http.StatusOK, response.Code, response.Body.String())
+		assert.Equal(t, "application/x-ndjson", response.Header().Get("Content-Type"))
+		assert.True(t, response.Flushed)
+
+		paths := []string{}
+		var trailer gjson.Result
+		scanner := bufio.NewScanner(response.Body)
+		for scanner.Scan() {
+			line := scanner.Bytes()
+			require.True(t, gjson.ValidBytes(line), string(line))
+			require.False(t, trailer.Exists(), "record after the trailer: %s", line)
+			if r := gjson.GetBytes(line, "trailer"); r.Exists() {
+				trailer = r
+				continue
+			}
+			assert.Equal(t, "valid", gjson.GetBytes(line, "schema").String())
+			paths = append(paths, gjson.GetBytes(line, "path").String())
+		}
+		require.NoError(t, scanner.Err())
+		require.True(t, trailer.Exists(), "the stream has no trailer")
+		return paths, trailer
+	}
+
+	all := []string{"/a/streamed", "/b/streamed", "/c/streamed", "/d/streamed", "/e/streamed"}
+	paths, trailer := stream("")
+	assert.Equal(t, all, paths)
+	assert.Equal(t, int64(len(all)), trailer.Get("count").Int())
+	assert.False(t, trailer.Get("error").Exists())
+
+	// the same filters and paging as the list
+	paths, trailer = stream("&limit=2&offset=1")
+	assert.Equal(t, all[1:3], paths)
+	assert.Equal(t, int64(2), trailer.Get("count").Int())
+	paths, _ = stream("&pathPrefix=/c")
+	assert.Equal(t, []string{"/c/streamed"}, paths)
+	paths, trailer = stream("&offset=10")
+	assert.Empty(t, paths)
+	assert.Equal(t, int64(0), trailer.Get("count").Int())
+
+	// errors in the request are responses, not streams
+	for _, query := range []string{"&limit=-1", "&pathPrefix=/bad%20path"} {
+		httpReq, _ := http.NewRequest("GET", "/collections?format=ndjson"+query, nil)
+		response := executeTestRequest(t, httpReq, nil, testContext)
+		assert.Equal(t, http.StatusBadRequest, response.Code, query)
+		assert.NotEqual(t, "application/x-ndjson", response.Header().Get("Content-Type"))
+	}
+
+	// other formats get the list
+	httpReq, _ := http.NewRequest("GET", "/collections?format=json", nil)
+	response := executeTestRequest(t, httpReq, nil, testContext)
+	require.Equal(t, http.StatusOK, response.Code, response.Body.String())
+	assert.Len(t, gjson.GetBytes(response.Body.Bytes(), "collections").Array(), len(all))
+}
@@ -0,0 +1,105 @@
+package server
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/mugiliam/hatchcatalogsrv/internal/db"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
+	"sigs.k8s.io/yaml"
+)
+
+func TestPatchCollectionValues(t *testing.T) {
+	ctx := newDb()
+	t.Cleanup(func() {
+		db.DB(ctx).Close(ctx)
+	})
+	testContextP := createTestObjects(t, ctx)
+	testContext := *testContextP
+
+	reqYaml := `
+		version: v1
+		kind: Collection
+		metadata:
+			name: my-collection
+			path: /some/path
+		spec:
+			schema: valid
+			values:
+				maxRetries: 3
+				maxAttempts: 10
+	`
+	replaceTabsWithSpaces(&reqYaml)
+	reqJson, err := yaml.YAMLToJSON([]byte(reqYaml))
+	require.NoError(t, err)
+	httpReq, _ := http.NewRequest("POST", "/collections", nil)
+	setRequestBodyAndHeader(t, httpReq, string(reqJson))
+	response := executeTestRequest(t, httpReq, nil, testContext)
+	if !assert.Equal(t, http.StatusCreated, response.Code) {
+		t.Logf("Response: %v", response.Body.String())
+		t.FailNow()
+	}
+	loc := "/collections/some/path/my-collection"
+
+	getValues := func() []byte {
+		httpReq, _ := http.NewRequest("GET", loc, nil)
+		response := executeTestRequest(t, httpReq, nil, testContext)
+		require.Equal(t, http.StatusOK, response.Code)
+		return response.Body.Bytes()
+	}
+
+	// patch a single value; the others are preserved
+	httpReq, _ = http.NewRequest("PATCH", loc, nil)
+	setRequestBodyAndHeader(t, httpReq, `{"spec": {"values": {"maxRetries": 7}}}`)
+	response = executeTestRequest(t, httpReq, nil, testContext)
+	if !assert.Equal(t, http.StatusOK, response.Code) {
+		t.Logf("Response: %v", response.Body.String())
+		t.FailNow()
+	}
+	rsp := getValues()
+	assert.Equal(t, "7", gjson.GetBytes(rsp, "spec.values.maxRetries").String())
+	assert.Equal(t, "10", gjson.GetBytes(rsp, "spec.values.maxAttempts").String())
+
+	// every failing value is reported and nothing is stored
+	httpReq, _ = http.NewRequest("PATCH", loc, nil)
+	setRequestBodyAndHeader(t, httpReq, `{"spec": {"values": {"maxRetries": 50, "maxAttempts": 20, "maxDelay": 5}}}`)
+	response = executeTestRequest(t, httpReq, nil, testContext)
+	if !assert.Equal(t, http.StatusBadRequest, response.Code) {
+		t.Logf("Response: %v", response.Body.String())
+		t.FailNow()
+	}
+	body := response.Body.String()
+	assert.True(t, strings.Contains(body, "maxRetries"))
+	assert.True(t, strings.Contains(body, "maxAttempts"))
+	assert.False(t, strings.Contains(body, "maxDelay"))
+	rsp = getValues()
+	assert.Equal(t, "7", gjson.GetBytes(rsp, "spec.values.maxRetries").String())
+	assert.Equal(t, "1000", gjson.GetBytes(rsp, "spec.values.maxDelay").String())
+
+	// null resets a value to its default
+	httpReq, _ = http.NewRequest("PATCH", loc, nil)
+	setRequestBodyAndHeader(t, httpReq, `{"spec": {"values": {"maxAttempts": null}}}`)
+	response = executeTestRequest(t, httpReq, nil, testContext)
+	if !assert.Equal(t, http.StatusOK, response.Code) {
+		t.Logf("Response: %v", response.Body.String())
+		t.FailNow()
+	}
+	rsp = getValues()
+	assert.Equal(t, "8", gjson.GetBytes(rsp, "spec.values.maxAttempts").String())
+	assert.Equal(t, "7", gjson.GetBytes(rsp, "spec.values.maxRetries").String())
+
+	// only values can be patched
+	httpReq, _ = http.NewRequest("PATCH", loc, nil)
+	setRequestBodyAndHeader(t, httpReq, `{"spec": {"schema": "other"}}`)
+	response = executeTestRequest(t, httpReq, nil, testContext)
+	assert.Equal(t, http.StatusBadRequest, response.Code)
+
+	// patch a missing collection
+	httpReq, _ = http.NewRequest("PATCH", "/collections/some/path/missing", nil)
+	setRequestBodyAndHeader(t, httpReq, `{"spec": {"values": {"maxRetries": 7}}}`)
+	response = executeTestRequest(t, httpReq, nil, testContext)
+	assert.Equal(t, http.StatusNotFound, response.Code)
+}
@@ -0,0 +1,91 @@
+package server
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/mugiliam/hatchcatalogsrv/internal/db"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+	"sigs.k8s.io/yaml"
+)
+
+func TestGetResolvedCollection(t *testing.T) {
+	ctx := newDb()
+	t.Cleanup(func() {
+		db.DB(ctx).Close(ctx)
+	})
+	testContextP := createTestObjects(t, ctx)
+	testContext := *testContextP
+
+	reqYaml := `
+		version: v1
+		kind: Collection
+		metadata:
+			name: my-collection
+			path: /some/path
+		spec:
+			schema: valid
+			values:
+				maxRetries: 3
+	`
+	replaceTabsWithSpaces(&reqYaml)
+	reqJson, err := yaml.YAMLToJSON([]byte(reqYaml))
+	require.NoError(t, err)
+	httpReq, _ := http.NewRequest("POST", "/collections", nil)
+	setRequestBodyAndHeader(t, httpReq, string(reqJson))
+	response := executeTestRequest(t, httpReq, nil, testContext)
+	if !assert.Equal(t, http.StatusCreated, response.Code) {
+		t.Logf("Response: %v", response.Body.String())
+		t.FailNow()
+	}
+
+	httpReq, _ = http.NewRequest("GET", "/collections/some/path/my-collection?resolved=true", nil)
+	response = executeTestRequest(t, httpReq, nil, testContext)
+	if !assert.Equal(t, http.StatusOK, response.Code) {
+		t.Logf("Response: %v", response.Body.String())
+		t.FailNow()
+	}
+	rsp := response.Body.Bytes()
+	assert.Equal(t, "/some/path/my-collection", gjson.GetBytes(rsp, "path").String())
+	assert.Equal(t, "valid", gjson.GetBytes(rsp, "schema").String())
+
+	expected := []struct {
+		param  string
+		value  int64
+		source string
+	}{
+		{"maxRetries", 3, "explicit"},
+		{"maxDelay", 1000, "collectionDefault"},
+		{"maxAttempts", 8, "collectionDefault"},
+		{"maxValue", 10, "collectionDefault"},
+	}
+	for _, e := range expected {
+		assert.Equal(t, e.value, gjson.GetBytes(rsp, "values."+e.param).Int(), e.param)
+		assert.Equal(t, e.source, gjson.GetBytes(rsp, "sources."+e.param).String(), e.param)
+	}
+	// every parameter of the schema is resolved and typed
+	values := gjson.GetBytes(rsp, "values").Map()
+	assert.Len(t, values, 5)
+	for param := range values {
+		assert.Equal(t, "Integer", gjson.GetBytes(rsp, "dataTypes."+param).String(), param)
+	}
+
+	// a collection that does not exist
+	httpReq, _ = http.NewRequest("GET", "/collections/some/path/missing?resolved=true", nil)
+	response = executeTestRequest(t, httpReq, nil, testContext)
+	assert.Equal(t, http.StatusNotFound, response.Code)
+
+	// a collection named resolved is read like any other
+	reqJson, _ = sjson.SetBytes(reqJson, "metadata.name", "resolved")
+	httpReq, _ = http.NewRequest("POST", "/collections", nil)
+	setRequestBodyAndHeader(t, httpReq, string(reqJson))
+	response = executeTestRequest(t, httpReq, nil, testContext)
+	require.Equal(t, http.StatusCreated, response.Code, response.Body.String())
+	httpReq, _ = http.NewRequest("GET", "/collections/some/path/resolved", nil)
+	response = executeTestRequest(t, httpReq, nil, testContext)
+	require.Equal(t, http.StatusOK, response.Code, response.Body.String())
+	assert.Equal(t, "resolved", gjson.GetBytes(response.Body.Bytes(), "metadata.name").String())
+}
@@ -0,0 +1,60 @@
+package server
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/mugiliam/hatchcatalogsrv/internal/db"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCollectionValueKeys(t *testing.T) {
+	ctx := newDb()
+	t.Cleanup(func() {
+		db.DB(ctx).Close(ctx)
+	})
+	testContextP := createTestObjects(t, ctx)
+	testContext := *testContextP
+
+	post := func(url, body string) (int, string) {
+		httpReq, _ := http.NewRequest("POST", url, nil)
+		setRequestBodyAndHeader(t, httpReq, body)
+		response := executeTestRequest(t, httpReq, nil, testContext)
+		return response.Code, response.Body.String()
+	}
+
+	code, body := post("/collectionschemas", `{"version": "v1", "kind": "CollectionSchema", "metadata": {"name": "required-schema", "path": "/"},
+		"spec": {"parameters": {
+			"endpoint": {"dataType": "Integer", "required": true},
+			"timeout": {"dataType": "Integer", "required": true, "default": 30},
+			"retries": {"dataType": "Integer"}
+		}}}`)
+	require.Equal(t, http.StatusCreated, code, body)
+
+	collection := func(name, values string) string {
+		return `{"version": "v1", "kind": "Collection", "metadata": {"name": "` + name + `", "path": "/keys"}, "spec": {"schema": "required-schema", "values": ` + values + `}}`
+	}
+
+	// a key that is not a parameter of the schema is rejected
+	code, body = post("/collections", collection("unknown", `{"endpoint": 1, "retires": 3}`))
+	assert.Equal(t, http.StatusBadRequest, code)
+	assert.Contains(t, body, "retires")
+
+	// unless unknown keys are allowed, in which case they are ignored
+	code, body = post("/collections?allowUnknown=true", collection("unknown", `{"endpoint": 1, "retires": 3}`))
+	assert.Equal(t, http.StatusCreated, code, body)
+
+	// a required parameter must have a value, which a default provides
+	code, body = post("/collections", collection("missing", `{"retries": 3}`))
+	assert.Equal(t, http.StatusBadRequest, code)
+	assert.Contains(t, body, "endpoint")
+	assert.NotContains(t, body, "timeout")
+	code, body = post("/collections", collection("missing", `{"endpoint": 1}`))
+	assert.Equal(t, http.StatusCreated, code, body)
+
+	// and null is not a value unless the parameter is nullable
+	code, body = post("/collections", collection("nulled", `{"endpoint": 1, "timeout": null}`))
+	assert.Equal(t, http.StatusBadRequest, code)
+	assert.Contains(t, body, "timeout")
+}
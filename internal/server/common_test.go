@@ -54,7 +54,7 @@ func checkHeader(t *testing.T, h http.Header) {
 	expected := "application/json"
 	got := h.Get("Content-Type")
 	assert.Equal(t, expected, got, "Content-Type expected %s, got %s", expected, got)
-	assert.NotNil(t, h.Get("X-Request-ID"), "No Request Id")
+	assert.NotEmpty(t, h.Get("X-Request-ID"), "No Request Id")
 }
 
 func compareJson(t *testing.T, expected any, actual string) {
@@ -63,6 +63,14 @@ func compareJson(t *testing.T, expected any, actual string) {
 	assert.JSONEq(t, string(j), actual, "Expected: %v\n Got: %v\n", expected, actual)
 }
 
+// removeTimestamps removes the server set timestamps from the metadata of a decoded response
+func removeTimestamps(rsp map[string]any) {
+	if m, ok := rsp["metadata"].(map[string]any); ok {
+		delete(m, "createdAt")
+		delete(m, "updatedAt")
+	}
+}
+
 var _ = setRequestBodyAndHeader
 
 func setRequestBodyAndHeader(t *testing.T, req *http.Request, data interface{}) {
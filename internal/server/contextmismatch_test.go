@@ -0,0 +1,145 @@
+package server
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/mugiliam/hatchcatalogsrv/internal/db"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/sjson"
+)
+
+func TestRequestContextMismatch(t *testing.T) {
+	ctx := newDb()
+	t.Cleanup(func() {
+		db.DB(ctx).Close(ctx)
+	})
+	testContextP := createTestObjects(t, ctx)
+	testContext := *testContextP
+
+	tests := []struct {
+		method       string
+		url          string
+		kind         string
+		checkVariant bool
+	}{
+		{"POST", "/variants", "Variant", false},
+		{"PUT", "/variants/valid-variant", "Variant", false},
+		{"POST", "/namespaces", "Namespace", true},
+		{"PUT", "/namespaces/valid-namespace", "Namespace", true},
+		{"POST", "/workspaces", "Workspace", true},
+		{"PUT", "/workspaces/valid-workspace", "Workspace", true},
+		{"POST", "/parameterschemas", "ParameterSchema", true},
+		{"PUT", "/parameterschemas/integer-param-schema", "ParameterSchema", true},
+		{"POST", "/collectionschemas", "CollectionSchema", true},
+		{"PUT", "/collectionschemas/valid", "CollectionSchema", true},
+		{"POST", "/collections", "Collection", true},
+		{"PUT", "/collections/my-collection", "Collection", true},
+		{"PATCH", "/collections/my-collection", "Collection", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.url, func(t *testing.T) {
+			req := `{"version": "v1", "kind": "` + tt.kind + `", "metadata": {"name": "some-name", "path": "/"}}`
+
+			body, _ := sjson.Set(req, "metadata.catalog", "other-catalog")
+			httpReq, _ := http.NewRequest(tt.method, tt.url, nil)
+			setRequestBodyAndHeader(t, httpReq, body)
+			response := executeTestRequest(t, httpReq, nil, testContext)
+			assert.Equal(t, http.StatusBadRequest, response.Code)
+			assert.Contains(t, response.Body.String(), "other-catalog")
+			assert.Contains(t, response.Body.String(), "valid-catalog")
+
+			if !tt.checkVariant {
+				return
+			}
+			body, _ = sjson.Set(req, "metadata.catalog", "valid-catalog")
+			body, _ = sjson.Set(body, "metadata.variant", "other-variant")
+			httpReq, _ = http.NewRequest(tt.method, tt.url, nil)
+			setRequestBodyAndHeader(t, httpReq, body)
+			response = executeTestRequest(t, httpReq, nil, testContext)
+			assert.Equal(t, http.StatusBadRequest, response.Code)
+			assert.Contains(t, response.Body.String(), "other-variant")
+			assert.Contains(t, response.Body.String(), "valid-variant")
+		})
+	}
+}
+
+func TestWorkspaceOfAnotherVariant(t *testing.T) {
+	ctx := newDb()
+	t.Cleanup(func() {
+		db.DB(ctx).Close(ctx)
+	})
+	testContextP := createTestObjects(t, ctx)
+	testContext := *testContextP
+
+	do := func(method, target, body string, tc TestContext) (int, string, string) {
+		httpReq, _ := http.NewRequest(method, target, nil)
+		if body != "" {
+			setRequestBodyAndHeader(t, httpReq, body)
+		}
+		response := executeTestRequest(t, httpReq, nil, tc)
+		return response.Code, response.Body.String(), response.Header().Get("Location")
+	}
+	workspaceID := func(loc string) string {
+		return loc[strings.LastIndex(loc, "/")+1:]
+	}
+
+	// a workspace in another variant of the catalog
+	code, body, _ := do("POST", "/variants", `{"version": "v1", "kind": "Variant", "metadata": {"name": "other-variant"}}`, testContext)
+	require.Equal(t, http.StatusCreated, code, body)
+	other := testContext
+	other.CatalogContext.Variant = "other-variant"
+	other.CatalogContext.WorkspaceLabel = ""
+	code, body, loc := do("POST", "/workspaces", `{"version": "v1", "kind": "Workspace", "metadata": {"label": "other-workspace"}}`, other)
+	require.Equal(t, http.StatusCreated, code, body)
+	foreignID := workspaceID(loc)
+
+	code, body, loc = do("POST", "/workspaces", `{"version": "v1", "kind": "Workspace", "metadata": {"label": "own-workspace"}}`, testContext)
+	require.Equal(t, http.StatusCreated, code, body)
+	ownID := workspaceID(loc)
+
+	// the workspace is given by ID in the request of the valid variant
+	byID := testContext
+	byID.CatalogContext.WorkspaceLabel = ""
+	code, body, _ = do("GET", "/parameterschemas/integer-param-schema?workspace="+foreignID, "", byID)
+	assert.Equal(t, http.StatusNotFound, code)
+	assert.Contains(t, body, foreignID)
+	code, body, _ = do("POST", "/parameterschemas?workspace="+foreignID, `{"version": "v1", "kind": "ParameterSchema",
+		"metadata": {"name": "foreign", "path": "/"}, "spec": {"dataType": "Integer"}}`, byID)
+	assert.Equal(t, http.StatusNotFound, code, body)
+	code, body, _ = do("GET", "/collectionschemas/valid?workspace="+foreignID, "", byID)
+	assert.Equal(t, http.StatusNotFound, code, body)
+	code, body, _ = do("GET", "/collections/my-collection?workspace="+foreignID, "", byID)
+	assert.Equal(t, http.StatusNotFound, code, body)
+	code, body, _ = do("GET", "/collections/my-collection?history=true&workspace="+foreignID, "", byID)
+	assert.Equal(t, http.StatusNotFound, code, body)
+	code, body, _ = do("POST", "/collections:batchDelete?workspace="+foreignID, `{"items": [{"name": "my-collection", "path": "/"}]}`, byID)
+	assert.Equal(t, http.StatusNotFound, code, body)
+
+	// the workspace is given by ID in the path
+	code, body, _ = do("GET", "/workspaces/"+foreignID, "", byID)
+	assert.Equal(t, http.StatusNotFound, code, body)
+	code, body, _ = do("DELETE", "/workspaces/"+foreignID, "", byID)
+	assert.Equal(t, http.StatusNotFound, code, body)
+	code, body, _ = do("POST", "/workspaces/"+ownID+"/merge/"+foreignID, "", byID)
+	assert.Equal(t, http.StatusNotFound, code, body)
+
+	// the foreign workspace is still there
+	code, body, _ = do("GET", "/workspaces/"+foreignID, "", other)
+	assert.Equal(t, http.StatusOK, code, body)
+
+	// nothing was created in the foreign workspace
+	code, body, _ = do("GET", "/parameterschemas/foreign?workspace="+foreignID, "", other)
+	assert.Equal(t, http.StatusNotFound, code, body)
+
+	// a workspace of the variant can be given by ID
+	code, body, _ = do("POST", "/parameterschemas?workspace="+ownID, `{"version": "v1", "kind": "ParameterSchema",
+		"metadata": {"name": "own", "path": "/"}, "spec": {"dataType": "Integer"}}`, byID)
+	assert.Equal(t, http.StatusCreated, code, body)
+	code, body, _ = do("GET", "/parameterschemas/own?workspace="+ownID, "", byID)
+	assert.Equal(t, http.StatusOK, code, body)
+	code, body, _ = do("GET", "/workspaces/"+ownID, "", byID)
+	assert.Equal(t, http.StatusOK, code, body)
+}
@@ -0,0 +1,87 @@
+package server
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/mugiliam/hatchcatalogsrv/internal/db"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"sigs.k8s.io/yaml"
+)
+
+func TestCreateReturnsRepresentation(t *testing.T) {
+	ctx := newDb()
+	t.Cleanup(func() {
+		db.DB(ctx).Close(ctx)
+	})
+	testContextP := createTestObjects(t, ctx)
+	testContext := *testContextP
+
+	reqYaml := `
+		version: v1
+		kind: Collection
+		metadata:
+			name: returned
+			path: /representation
+		spec:
+			schema: valid
+			values:
+				maxRetries: 3
+	`
+	replaceTabsWithSpaces(&reqYaml)
+	reqJson, err := yaml.YAMLToJSON([]byte(reqYaml))
+	require.NoError(t, err)
+
+	get := func(loc string) string {
+		httpReq, _ := http.NewRequest("GET", loc, nil)
+		response := executeTestRequest(t, httpReq, nil, testContext)
+		require.Equal(t, http.StatusOK, response.Code)
+		return response.Body.String()
+	}
+
+	// the query parameter asks for the created collection
+	httpReq, _ := http.NewRequest("POST", "/collections?return=representation", nil)
+	setRequestBodyAndHeader(t, httpReq, string(reqJson))
+	response := executeTestRequest(t, httpReq, nil, testContext)
+	if !assert.Equal(t, http.StatusCreated, response.Code) {
+		t.Logf("Response: %v", response.Body.String())
+		t.FailNow()
+	}
+	loc := response.Header().Get("Location")
+	assert.Equal(t, "/collections/representation/returned", loc)
+	assert.JSONEq(t, get(loc), response.Body.String())
+
+	// so does the Prefer header
+	paramYaml := `
+		version: v1
+		kind: ParameterSchema
+		metadata:
+			name: returned-param
+			path: /
+		spec:
+			dataType: Integer
+			validation:
+				minValue: 1
+				maxValue: 10
+			default: 5
+	`
+	replaceTabsWithSpaces(&paramYaml)
+	paramJson, err := yaml.YAMLToJSON([]byte(paramYaml))
+	require.NoError(t, err)
+	httpReq, _ = http.NewRequest("POST", "/parameterschemas", nil)
+	setRequestBodyAndHeader(t, httpReq, string(paramJson))
+	httpReq.Header.Set("Prefer", "respond-async, return=representation")
+	response = executeTestRequest(t, httpReq, nil, testContext)
+	require.Equal(t, http.StatusCreated, response.Code, response.Body.String())
+	loc = response.Header().Get("Location")
+	assert.JSONEq(t, get(loc), response.Body.String())
+
+	// by default the body is empty
+	httpReq, _ = http.NewRequest("POST", "/collections", nil)
+	setRequestBodyAndHeader(t, httpReq, `{"version": "v1", "kind": "Collection", "metadata": {"name": "plain", "path": "/representation"}, "spec": {"schema": "valid"}}`)
+	response = executeTestRequest(t, httpReq, nil, testContext)
+	require.Equal(t, http.StatusCreated, response.Code, response.Body.String())
+	assert.Equal(t, "/collections/representation/plain", response.Header().Get("Location"))
+	assert.Empty(t, response.Body.String())
+}
@@ -0,0 +1,96 @@
+package server
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/mugiliam/hatchcatalogsrv/internal/db"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
+	"sigs.k8s.io/yaml"
+)
+
+func TestCatalogDefaultVariant(t *testing.T) {
+	ctx := newDb()
+	t.Cleanup(func() {
+		db.DB(ctx).Close(ctx)
+	})
+	testContextP := createTestObjects(t, ctx)
+	testContext := *testContextP
+	// requests that name neither a variant nor a workspace
+	testContext.CatalogContext.Variant = ""
+	testContext.CatalogContext.WorkspaceLabel = ""
+	testContext.CatalogContext.Namespace = ""
+
+	setDefaultVariant := func(variant string) int {
+		req := `
+			{
+				"version": "v1",
+				"kind": "Catalog",
+				"metadata": {
+					"name": "valid-catalog",
+					"description": "This is a valid catalog",
+					"defaultVariant": "` + variant + `"
+				}
+			}`
+		httpReq, _ := http.NewRequest("PUT", "/catalogs/valid-catalog", nil)
+		setRequestBodyAndHeader(t, httpReq, req)
+		return executeTestRequest(t, httpReq, nil, testContext).Code
+	}
+
+	// the variant must exist
+	assert.Equal(t, http.StatusNotFound, setDefaultVariant("missing-variant"))
+	require.Equal(t, http.StatusOK, setDefaultVariant("valid-variant"))
+
+	httpReq, _ := http.NewRequest("GET", "/catalogs/valid-catalog", nil)
+	response := executeTestRequest(t, httpReq, nil, testContext)
+	require.Equal(t, http.StatusOK, response.Code)
+	assert.Equal(t, "valid-variant", gjson.Get(response.Body.String(), "metadata.defaultVariant").String())
+
+	// objects are created in the default variant of the catalog
+	reqYaml := `
+		version: v1
+		kind: ParameterSchema
+		metadata:
+			name: default-variant-param
+			path: /
+		spec:
+			dataType: Integer
+			default: 5
+	`
+	replaceTabsWithSpaces(&reqYaml)
+	reqJson, err := yaml.YAMLToJSON([]byte(reqYaml))
+	require.NoError(t, err)
+	httpReq, _ = http.NewRequest("POST", "/parameterschemas", nil)
+	setRequestBodyAndHeader(t, httpReq, string(reqJson))
+	response = executeTestRequest(t, httpReq, nil, testContext)
+	require.Equal(t, http.StatusCreated, response.Code, response.Body.String())
+
+	httpReq, _ = http.NewRequest("GET", "/parameterschemas/default-variant-param", nil)
+	assert.Equal(t, http.StatusOK, executeTestRequest(t, httpReq, nil, testContext).Code)
+	httpReq, _ = http.NewRequest("GET", "/parameterschemas/default-variant-param?v=valid-variant", nil)
+	assert.Equal(t, http.StatusOK, executeTestRequest(t, httpReq, nil, testContext).Code)
+	httpReq, _ = http.NewRequest("GET", "/parameterschemas/default-variant-param?v=default", nil)
+	assert.Equal(t, http.StatusNotFound, executeTestRequest(t, httpReq, nil, testContext).Code)
+
+	// the default variant cannot be deleted
+	httpReq, _ = http.NewRequest("DELETE", "/variants/valid-variant", nil)
+	assert.Equal(t, http.StatusConflict, executeTestRequest(t, httpReq, nil, testContext).Code)
+
+	// leaving it out goes back to the default variant
+	req := `
+		{
+			"version": "v1",
+			"kind": "Catalog",
+			"metadata": {
+				"name": "valid-catalog",
+				"description": "This is a valid catalog"
+			}
+		}`
+	httpReq, _ = http.NewRequest("PUT", "/catalogs/valid-catalog", nil)
+	setRequestBodyAndHeader(t, httpReq, req)
+	require.Equal(t, http.StatusOK, executeTestRequest(t, httpReq, nil, testContext).Code)
+	httpReq, _ = http.NewRequest("GET", "/parameterschemas/default-variant-param", nil)
+	assert.Equal(t, http.StatusNotFound, executeTestRequest(t, httpReq, nil, testContext).Code)
+}
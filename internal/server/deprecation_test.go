@@ -0,0 +1,132 @@
+package server
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/mugiliam/hatchcatalogsrv/internal/db"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
+	"sigs.k8s.io/yaml"
+)
+
+func TestDeprecatedParameterSchema(t *testing.T) {
+	ctx := newDb()
+	t.Cleanup(func() {
+		db.DB(ctx).Close(ctx)
+	})
+	testContextP := createTestObjects(t, ctx)
+	testContext := *testContextP
+
+	send := func(method, target, reqYaml string, expectedStatus int) []byte {
+		replaceTabsWithSpaces(&reqYaml)
+		reqJson, err := yaml.YAMLToJSON([]byte(reqYaml))
+		require.NoError(t, err)
+		httpReq, _ := http.NewRequest(method, target, nil)
+		setRequestBodyAndHeader(t, httpReq, string(reqJson))
+		response := executeTestRequest(t, httpReq, nil, testContext)
+		if !assert.Equal(t, expectedStatus, response.Code) {
+			t.Logf("Response: %v", response.Body.String())
+			t.FailNow()
+		}
+		return response.Body.Bytes()
+	}
+
+	send("POST", "/parameterschemas", `
+		version: v1
+		kind: ParameterSchema
+		metadata:
+			name: old-param-schema
+			catalog: valid-catalog
+			description: an integer being phased out
+			deprecated: true
+			deprecationMessage: use integer-param-schema
+		spec:
+			dataType: Integer
+			validation:
+				minValue: 1
+				maxValue: 10
+			default: 5
+	`, http.StatusCreated)
+
+	// the flag is preserved on load
+	httpReq, _ := http.NewRequest("GET", "/parameterschemas/old-param-schema", nil)
+	response := executeTestRequest(t, httpReq, nil, testContext)
+	require.Equal(t, http.StatusOK, response.Code)
+	assert.True(t, gjson.GetBytes(response.Body.Bytes(), "metadata.deprecated").Bool())
+	assert.Equal(t, "use integer-param-schema", gjson.GetBytes(response.Body.Bytes(), "metadata.deprecationMessage").String())
+
+	collectionSchema := `
+		version: v1
+		kind: CollectionSchema
+		metadata:
+			name: uses-old
+			catalog: valid-catalog
+			description: refers to a deprecated parameter schema
+		spec:
+			parameters:
+				retries:
+					schema: old-param-schema
+				attempts:
+					schema: integer-param-schema
+	`
+	// the collection schema is saved with a warning naming the deprecated parameter
+	rsp := send("POST", "/collectionschemas", collectionSchema, http.StatusCreated)
+	warnings := gjson.GetBytes(rsp, "warnings").Array()
+	if assert.Len(t, warnings, 1) {
+		assert.Equal(t, "deprecated-parameter", warnings[0].Get("rule").String())
+		assert.Equal(t, "spec.parameters.retries.schema", warnings[0].Get("field").String())
+		assert.Contains(t, warnings[0].Get("message").String(), "retries")
+		assert.Contains(t, warnings[0].Get("message").String(), "use integer-param-schema")
+	}
+
+	// lint reports the same warning
+	rsp = send("POST", "/lint", collectionSchema, http.StatusOK)
+	warnings = gjson.GetBytes(rsp, "warnings").Array()
+	if assert.Len(t, warnings, 1) {
+		assert.Equal(t, "deprecated-parameter", warnings[0].Get("rule").String())
+	}
+
+	// a schema deprecated in its spec is deprecated the same way, and the spec is stored without the flag
+	send("POST", "/parameterschemas", `
+		version: v1
+		kind: ParameterSchema
+		metadata:
+			name: spec-deprecated-param-schema
+			catalog: valid-catalog
+			description: deprecated in the spec
+		spec:
+			dataType: Integer
+			default: 5
+			deprecated: true
+	`, http.StatusCreated)
+	httpReq, _ = http.NewRequest("GET", "/parameterschemas/spec-deprecated-param-schema", nil)
+	response = executeTestRequest(t, httpReq, nil, testContext)
+	require.Equal(t, http.StatusOK, response.Code)
+	assert.True(t, gjson.GetBytes(response.Body.Bytes(), "metadata.deprecated").Bool())
+	assert.False(t, gjson.GetBytes(response.Body.Bytes(), "spec.deprecated").Exists())
+
+	// lifting the deprecation only changes the flag, and the warning goes away
+	send("PUT", "/parameterschemas/old-param-schema", `
+		version: v1
+		kind: ParameterSchema
+		metadata:
+			name: old-param-schema
+			catalog: valid-catalog
+			description: an integer being phased out
+		spec:
+			dataType: Integer
+			validation:
+				minValue: 1
+				maxValue: 10
+			default: 5
+	`, http.StatusOK)
+	httpReq, _ = http.NewRequest("GET", "/parameterschemas/old-param-schema", nil)
+	response = executeTestRequest(t, httpReq, nil, testContext)
+	require.Equal(t, http.StatusOK, response.Code)
+	assert.False(t, gjson.GetBytes(response.Body.Bytes(), "metadata.deprecated").Exists())
+
+	rsp = send("PUT", "/collectionschemas/uses-old", collectionSchema, http.StatusOK)
+	assert.False(t, gjson.GetBytes(rsp, "warnings").Exists())
+}
@@ -0,0 +1,125 @@
+package server
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mugiliam/hatchcatalogsrv/internal/config"
+	"github.com/mugiliam/hatchcatalogsrv/internal/db"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
+	"sigs.k8s.io/yaml"
+)
+
+func TestDirectVariantWrites(t *testing.T) {
+	ctx := newDb()
+	t.Cleanup(func() {
+		db.DB(ctx).Close(ctx)
+	})
+	testContextP := createTestObjects(t, ctx)
+	testContext := *testContextP
+	require.NotEmpty(t, testContext.CatalogContext.WorkspaceLabel)
+	testContext.CatalogContext.Namespace = ""
+
+	send := func(method, target, body string) (int, string, string) {
+		httpReq, _ := http.NewRequest(method, target, nil)
+		if body != "" {
+			setRequestBodyAndHeader(t, httpReq, body)
+		}
+		response := executeTestRequest(t, httpReq, nil, testContext)
+		return response.Code, response.Header().Get("Location"), response.Body.String()
+	}
+
+	paramYaml := `
+		version: v1
+		kind: ParameterSchema
+		metadata:
+			name: direct-param
+			path: /
+		spec:
+			dataType: Integer
+			default: 5
+	`
+	replaceTabsWithSpaces(&paramYaml)
+	param, err := yaml.YAMLToJSON([]byte(paramYaml))
+	require.NoError(t, err)
+	schemaYaml := `
+		version: v1
+		kind: CollectionSchema
+		metadata:
+			name: direct-schema
+			path: /
+		spec:
+			parameters:
+				maxRetries:
+					schema: direct-param
+	`
+	replaceTabsWithSpaces(&schemaYaml)
+	schema, err := yaml.YAMLToJSON([]byte(schemaYaml))
+	require.NoError(t, err)
+	collectionYaml := `
+		version: v1
+		kind: Collection
+		metadata:
+			name: direct-collection
+			path: /
+		spec:
+			schema: direct-schema
+			values:
+				maxRetries: 3
+	`
+	replaceTabsWithSpaces(&collectionYaml)
+	collection, err := yaml.YAMLToJSON([]byte(collectionYaml))
+	require.NoError(t, err)
+
+	// direct writes are off by default, reads are not
+	code, _, _ := send("POST", "/parameterschemas?direct=true", string(param))
+	assert.Equal(t, http.StatusForbidden, code)
+	code, _, _ = send("GET", "/parameterschemas/direct-param?direct=true", "")
+	assert.Equal(t, http.StatusNotFound, code)
+
+	file := filepath.Join(t.TempDir(), "config.toml")
+	require.NoError(t, os.WriteFile(file, []byte("allow_direct_writes = true\n"), 0o600))
+	require.NoError(t, config.LoadConfig(file))
+	t.Cleanup(func() {
+		_ = config.LoadConfig("")
+	})
+
+	// a parameter schema is created in the variant rather than the workspace of the request
+	code, loc, body := send("POST", "/parameterschemas?direct=true", string(param))
+	require.Equal(t, http.StatusCreated, code, body)
+	assert.Equal(t, "/parameterschemas/direct-param?direct=true", loc)
+	code, _, _ = send("GET", loc, "")
+	assert.Equal(t, http.StatusOK, code)
+	code, _, _ = send("GET", "/parameterschemas/direct-param", "")
+	assert.Equal(t, http.StatusNotFound, code)
+
+	// a collection and its schema are created, read and deleted in the variant
+	code, schemaLoc, body := send("POST", "/collectionschemas?direct=true", string(schema))
+	require.Equal(t, http.StatusCreated, code, body)
+	code, collectionLoc, body := send("POST", "/collections?direct=true", string(collection))
+	require.Equal(t, http.StatusCreated, code, body)
+	assert.Equal(t, "/collections/direct-collection?direct=true", collectionLoc)
+	code, _, body = send("GET", collectionLoc, "")
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, int64(3), gjson.Get(body, "spec.values.maxRetries").Int())
+	code, _, _ = send("GET", "/collections/direct-collection", "")
+	assert.Equal(t, http.StatusNotFound, code)
+
+	code, _, _ = send("DELETE", collectionLoc, "")
+	assert.Equal(t, http.StatusNoContent, code)
+	code, _, _ = send("GET", collectionLoc, "")
+	assert.Equal(t, http.StatusNotFound, code)
+	code, _, _ = send("DELETE", schemaLoc, "")
+	assert.Equal(t, http.StatusNoContent, code)
+
+	code, _, _ = send("DELETE", loc, "")
+	assert.Equal(t, http.StatusNoContent, code)
+	code, _, _ = send("GET", loc, "")
+	assert.Equal(t, http.StatusNotFound, code)
+	code, _, _ = send("DELETE", loc, "")
+	assert.Equal(t, http.StatusNotFound, code)
+}
@@ -0,0 +1,96 @@
+package server
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/mugiliam/hatchcatalogsrv/internal/db"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/sjson"
+	"sigs.k8s.io/yaml"
+)
+
+func TestConditionalGet(t *testing.T) {
+	ctx := newDb()
+	t.Cleanup(func() {
+		db.DB(ctx).Close(ctx)
+	})
+	testContextP := createTestObjects(t, ctx)
+	testContext := *testContextP
+
+	reqYaml := `
+		version: v1
+		kind: Collection
+		metadata:
+			name: my-collection
+			path: /
+		spec:
+			schema: valid
+	`
+	replaceTabsWithSpaces(&reqYaml)
+	reqJson, err := yaml.YAMLToJSON([]byte(reqYaml))
+	require.NoError(t, err)
+	httpReq, _ := http.NewRequest("POST", "/collections", nil)
+	setRequestBodyAndHeader(t, httpReq, string(reqJson))
+	response := executeTestRequest(t, httpReq, nil, testContext)
+	require.Equal(t, http.StatusCreated, response.Code, response.Body.String())
+
+	get := func(loc, etag string) (int, string, string) {
+		httpReq, _ := http.NewRequest("GET", loc, nil)
+		if etag != "" {
+			httpReq.Header.Set("If-None-Match", etag)
+		}
+		response := executeTestRequest(t, httpReq, nil, testContext)
+		return response.Code, response.Header().Get("ETag"), response.Body.String()
+	}
+
+	for _, loc := range []string{"/parameterschemas/integer-param-schema", "/collectionschemas/valid", "/collections/my-collection"} {
+		code, etag, body := get(loc, "")
+		require.Equal(t, http.StatusOK, code, loc)
+		require.NotEmpty(t, etag, loc)
+
+		// the same object is not sent again
+		code, etag2, body2 := get(loc, etag)
+		assert.Equal(t, http.StatusNotModified, code, loc)
+		assert.Equal(t, etag, etag2, loc)
+		assert.Empty(t, body2, loc)
+
+		// a different tag gets the object
+		code, _, _ = get(loc, `W/"some-other-hash"`)
+		assert.Equal(t, http.StatusOK, code, loc)
+
+		// a change, even one outside of the hashed object, changes the tag
+		changed, _ := sjson.Set(body, "metadata.description", "a changed description")
+		if loc == "/collections/my-collection" {
+			changed, _ = sjson.Set(body, "spec.values.maxRetries", 7)
+		}
+		httpReq, _ := http.NewRequest("PUT", loc, nil)
+		setRequestBodyAndHeader(t, httpReq, changed)
+		response := executeTestRequest(t, httpReq, nil, testContext)
+		require.Equal(t, http.StatusOK, response.Code, response.Body.String())
+		code, etag3, _ := get(loc, etag)
+		assert.Equal(t, http.StatusOK, code, loc)
+		assert.NotEqual(t, etag, etag3, loc)
+	}
+
+	// the YAML document of an object has its own tag
+	code, etag, _ := get("/parameterschemas/integer-param-schema", "")
+	require.Equal(t, http.StatusOK, code)
+	httpReq, _ = http.NewRequest("GET", "/parameterschemas/integer-param-schema", nil)
+	httpReq.Header.Set("Accept", "application/yaml")
+	httpReq.Header.Set("If-None-Match", etag)
+	response = executeTestRequest(t, httpReq, nil, testContext)
+	assert.Equal(t, http.StatusOK, response.Code)
+	assert.Equal(t, "application/yaml", response.Header().Get("Content-Type"))
+	assert.Equal(t, "Accept", response.Header().Get("Vary"))
+	yamlETag := response.Header().Get("ETag")
+	assert.NotEqual(t, etag, yamlETag)
+	httpReq.Header.Set("If-None-Match", yamlETag)
+	response = executeTestRequest(t, httpReq, nil, testContext)
+	assert.Equal(t, http.StatusNotModified, response.Code)
+
+	// responses that are more than the stored object have no tag
+	_, etag, _ = get("/collectionschemas/valid?expand=parameters", "")
+	assert.Empty(t, etag)
+}
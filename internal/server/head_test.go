@@ -0,0 +1,64 @@
+package server
+
+import (
+	"net/http"
+	"strconv"
+	"testing"
+
+	"github.com/mugiliam/hatchcatalogsrv/internal/db"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"sigs.k8s.io/yaml"
+)
+
+func TestHeadRequests(t *testing.T) {
+	ctx := newDb()
+	t.Cleanup(func() {
+		db.DB(ctx).Close(ctx)
+	})
+	testContextP := createTestObjects(t, ctx)
+	testContext := *testContextP
+
+	reqYaml := `
+		version: v1
+		kind: Collection
+		metadata:
+			name: my-collection
+			path: /
+		spec:
+			schema: valid
+	`
+	replaceTabsWithSpaces(&reqYaml)
+	reqJson, err := yaml.YAMLToJSON([]byte(reqYaml))
+	require.NoError(t, err)
+	httpReq, _ := http.NewRequest("POST", "/collections", nil)
+	setRequestBodyAndHeader(t, httpReq, string(reqJson))
+	response := executeTestRequest(t, httpReq, nil, testContext)
+	require.Equal(t, http.StatusCreated, response.Code, response.Body.String())
+
+	for _, loc := range []string{"/parameterschemas/integer-param-schema", "/collectionschemas/valid", "/collections/my-collection"} {
+		httpReq, _ := http.NewRequest("GET", loc, nil)
+		get := executeTestRequest(t, httpReq, nil, testContext)
+		require.Equal(t, http.StatusOK, get.Code, loc)
+
+		httpReq, _ = http.NewRequest("HEAD", loc, nil)
+		head := executeTestRequest(t, httpReq, nil, testContext)
+		assert.Equal(t, http.StatusOK, head.Code, loc)
+		assert.Empty(t, head.Body.String(), loc)
+		assert.Equal(t, get.Header().Get("ETag"), head.Header().Get("ETag"), loc)
+		assert.Equal(t, strconv.Itoa(get.Body.Len()), head.Header().Get("Content-Length"), loc)
+
+		// a HEAD with a matching tag is not modified
+		httpReq, _ = http.NewRequest("HEAD", loc, nil)
+		httpReq.Header.Set("If-None-Match", head.Header().Get("ETag"))
+		head = executeTestRequest(t, httpReq, nil, testContext)
+		assert.Equal(t, http.StatusNotModified, head.Code, loc)
+	}
+
+	for _, loc := range []string{"/parameterschemas/missing-param", "/collectionschemas/missing", "/collections/missing-collection"} {
+		httpReq, _ := http.NewRequest("HEAD", loc, nil)
+		response := executeTestRequest(t, httpReq, nil, testContext)
+		assert.Equal(t, http.StatusNotFound, response.Code, loc)
+		assert.Empty(t, response.Body.String(), loc)
+	}
+}
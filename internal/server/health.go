@@ -0,0 +1,65 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/mugiliam/common/httpx"
+	"github.com/mugiliam/hatchcatalogsrv/internal/config"
+	"github.com/mugiliam/hatchcatalogsrv/internal/db"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	healthStatusOK          = "ok"
+	healthStatusUnavailable = "unavailable"
+)
+
+// time allowed for the database to respond to a readiness check
+const readinessTimeout = 2 * time.Second
+
+type healthRsp struct {
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks,omitempty"`
+}
+
+// pingDb is swapped in tests to simulate an unreachable database
+var pingDb = db.Ping
+
+// getHealth reports that the process is up. It does not touch any dependency.
+func (s *HatchCatalogServer) getHealth(w http.ResponseWriter, r *http.Request) {
+	httpx.SendJsonRsp(r.Context(), w, http.StatusOK, &healthRsp{Status: healthStatusOK})
+}
+
+// getReadiness reports whether the server can serve requests: the database must be reachable
+// and required configuration must be set.
+func (s *HatchCatalogServer) getReadiness(w http.ResponseWriter, r *http.Request) {
+	rsp := &healthRsp{
+		Status: healthStatusOK,
+		Checks: map[string]string{},
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), readinessTimeout)
+	defer cancel()
+	if err := pingDb(ctx); err != nil {
+		log.Ctx(r.Context()).Error().Err(err).Msg("readiness check: database unreachable")
+		rsp.Checks["database"] = "unreachable"
+		rsp.Status = healthStatusUnavailable
+	} else {
+		rsp.Checks["database"] = healthStatusOK
+	}
+
+	if cfg := config.Config(); cfg == nil || cfg.ServerPort == "" {
+		rsp.Checks["config"] = "server_port not set"
+		rsp.Status = healthStatusUnavailable
+	} else {
+		rsp.Checks["config"] = healthStatusOK
+	}
+
+	statusCode := http.StatusOK
+	if rsp.Status != healthStatusOK {
+		statusCode = http.StatusServiceUnavailable
+	}
+	httpx.SendJsonRsp(r.Context(), w, statusCode, rsp)
+}
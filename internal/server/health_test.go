@@ -0,0 +1,41 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/mugiliam/hatchcatalogsrv/internal/db"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHealth(t *testing.T) {
+	req, _ := http.NewRequest("GET", "/healthz", nil)
+	response := executeTestRequest(t, req, nil)
+	require.Equal(t, http.StatusOK, response.Code)
+	checkHeader(t, response.Result().Header)
+	compareJson(t, &healthRsp{Status: "ok"}, response.Body.String())
+}
+
+func TestReadiness(t *testing.T) {
+	req, _ := http.NewRequest("GET", "/readyz", nil)
+	response := executeTestRequest(t, req, nil)
+	require.Equal(t, http.StatusOK, response.Code)
+	compareJson(t, &healthRsp{
+		Status: "ok",
+		Checks: map[string]string{"database": "ok", "config": "ok"},
+	}, response.Body.String())
+
+	// simulate an unreachable database
+	pingDb = func(ctx context.Context) error { return errors.New("connection refused") }
+	defer func() { pingDb = db.Ping }()
+
+	req, _ = http.NewRequest("GET", "/readyz", nil)
+	response = executeTestRequest(t, req, nil)
+	require.Equal(t, http.StatusServiceUnavailable, response.Code)
+	compareJson(t, &healthRsp{
+		Status: "unavailable",
+		Checks: map[string]string{"database": "unreachable", "config": "ok"},
+	}, response.Body.String())
+}
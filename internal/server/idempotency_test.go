@@ -0,0 +1,94 @@
+package server
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mugiliam/hatchcatalogsrv/internal/apis"
+	"github.com/mugiliam/hatchcatalogsrv/internal/common"
+	"github.com/mugiliam/hatchcatalogsrv/internal/db"
+	"github.com/mugiliam/hatchcatalogsrv/internal/db/models"
+	"github.com/mugiliam/hatchcatalogsrv/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIdempotentCreate(t *testing.T) {
+	ctx := newDb()
+	t.Cleanup(func() {
+		db.DB(ctx).Close(ctx)
+	})
+	testContextP := createTestObjects(t, ctx)
+	testContext := *testContextP
+
+	req := `
+		{
+			"version": "v1",
+			"kind": "ParameterSchema",
+			"metadata": {
+				"name": "idempotent-param-schema",
+				"catalog": "valid-catalog",
+				"path": "/"
+			},
+			"spec": {
+				"dataType": "Integer",
+				"default": 5
+			}
+		}`
+	post := func(key, body string) (int, string) {
+		httpReq, _ := http.NewRequest("POST", "/parameterschemas", nil)
+		setRequestBodyAndHeader(t, httpReq, body)
+		if key != "" {
+			httpReq.Header.Set(apis.IdempotencyKeyHeader, key)
+		}
+		response := executeTestRequest(t, httpReq, nil, testContext)
+		return response.Code, response.Header().Get("Location")
+	}
+
+	code, loc := post("create-param-1", req)
+	if !assert.Equal(t, http.StatusCreated, code) {
+		t.FailNow()
+	}
+	assert.NotEmpty(t, loc)
+
+	// a retry with the same key gets the original response
+	code, retryLoc := post("create-param-1", req)
+	assert.Equal(t, http.StatusCreated, code)
+	assert.Equal(t, loc, retryLoc)
+
+	// without a key the retry is a conflict
+	code, _ = post("", req)
+	assert.Equal(t, http.StatusConflict, code)
+
+	// the same key with a different body is rejected
+	code, _ = post("create-param-1", `{"version": "v1", "kind": "ParameterSchema", "metadata": {"name": "other-param-schema", "catalog": "valid-catalog", "path": "/"}, "spec": {"dataType": "Integer"}}`)
+	assert.Equal(t, http.StatusUnprocessableEntity, code)
+
+	// a request that fails gives up its key, so the key can be used again
+	code, _ = post("create-param-2", req)
+	assert.Equal(t, http.StatusConflict, code)
+	code, _ = post("create-param-2", `{"version": "v1", "kind": "ParameterSchema", "metadata": {"name": "other-param-schema", "catalog": "valid-catalog", "path": "/"}, "spec": {"dataType": "Integer"}}`)
+	assert.Equal(t, http.StatusCreated, code)
+
+	// a key held by a request that has not completed is a conflict
+	projectCtx := common.SetProjectIdInContext(common.SetTenantIdInContext(ctx, testContext.TenantId), testContext.ProjectId)
+	err := db.DB(projectCtx).CreateIdempotencyKey(projectCtx, &models.IdempotencyKey{
+		Key:         "create-param-3",
+		RequestHash: strings.Repeat("0", 64),
+	}, time.Hour)
+	require.NoError(t, err)
+	code, _ = post("create-param-3", `{"version": "v1", "kind": "ParameterSchema", "metadata": {"name": "third-param-schema", "catalog": "valid-catalog", "path": "/"}, "spec": {"dataType": "Integer"}}`)
+	assert.Equal(t, http.StatusConflict, code)
+
+	// keys are scoped to the project, so another project of the tenant can use the same key for another request
+	otherProjectID := types.ProjectId("POTHER")
+	require.NoError(t, db.DB(projectCtx).CreateProject(projectCtx, otherProjectID))
+	otherCtx := common.SetProjectIdInContext(projectCtx, otherProjectID)
+	err = db.DB(otherCtx).CreateIdempotencyKey(otherCtx, &models.IdempotencyKey{
+		Key:         "create-param-1",
+		RequestHash: strings.Repeat("1", 64),
+	}, time.Hour)
+	assert.NoError(t, err)
+}
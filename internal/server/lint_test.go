@@ -0,0 +1,98 @@
+package server
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/mugiliam/hatchcatalogsrv/internal/db"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
+	"sigs.k8s.io/yaml"
+)
+
+func TestLintSchema(t *testing.T) {
+	ctx := newDb()
+	t.Cleanup(func() {
+		db.DB(ctx).Close(ctx)
+	})
+	testContextP := createTestObjects(t, ctx)
+	testContext := *testContextP
+
+	lint := func(reqYaml string) *gjson.Result {
+		replaceTabsWithSpaces(&reqYaml)
+		reqJson, err := yaml.YAMLToJSON([]byte(reqYaml))
+		require.NoError(t, err)
+		httpReq, _ := http.NewRequest("POST", "/lint", nil)
+		setRequestBodyAndHeader(t, httpReq, string(reqJson))
+		response := executeTestRequest(t, httpReq, nil, testContext)
+		if !assert.Equal(t, http.StatusOK, response.Code) {
+			t.Logf("Response: %v", response.Body.String())
+			t.FailNow()
+		}
+		r := gjson.GetBytes(response.Body.Bytes(), "warnings")
+		return &r
+	}
+	rules := func(warnings *gjson.Result) []string {
+		var r []string
+		for _, w := range warnings.Array() {
+			r = append(r, w.Get("rule").String())
+		}
+		return r
+	}
+
+	warnings := lint(`
+		version: v1
+		kind: CollectionSchema
+		metadata:
+			name: empty
+			catalog: valid-catalog
+	`)
+	assert.ElementsMatch(t, []string{"missing-description", "empty-collection-schema"}, rules(warnings))
+
+	// references are not resolved, so a schema that would not save can still be linted
+	warnings = lint(`
+		version: v1
+		kind: CollectionSchema
+		metadata:
+			name: unresolved
+			catalog: valid-catalog
+			description: refers to a missing parameter schema
+		spec:
+			parameters:
+				maxRetries:
+					schema: missing-param-schema
+	`)
+	assert.True(t, warnings.IsArray())
+	assert.Empty(t, warnings.Array())
+
+	warnings = lint(`
+		version: v1
+		kind: ParameterSchema
+		metadata:
+			name: boundary-param-schema
+			catalog: valid-catalog
+			description: default at the upper bound
+		spec:
+			dataType: Integer
+			validation:
+				minValue: 1
+				maxValue: 10
+			default: 10
+	`)
+	if assert.Len(t, warnings.Array(), 1) {
+		assert.Equal(t, "default-at-boundary", warnings.Array()[0].Get("rule").String())
+		assert.Equal(t, "spec.default", warnings.Array()[0].Get("field").String())
+	}
+
+	// invalid schemas are still rejected
+	httpReq, _ := http.NewRequest("POST", "/lint", nil)
+	setRequestBodyAndHeader(t, httpReq, `{"version":"v1","kind":"ParameterSchema","metadata":{"name":"bad","catalog":"valid-catalog"},"spec":{"dataType":"Integer","validation":{"minValue":1},"default":"hello"}}`)
+	response := executeTestRequest(t, httpReq, nil, testContext)
+	assert.Equal(t, http.StatusBadRequest, response.Code)
+
+	// linting does not save the schema
+	httpReq, _ = http.NewRequest("GET", "/collectionschemas/empty", nil)
+	response = executeTestRequest(t, httpReq, nil, testContext)
+	assert.Equal(t, http.StatusNotFound, response.Code)
+}
@@ -0,0 +1,49 @@
+package server
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/mugiliam/hatchcatalogsrv/internal/db"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMethodNotAllowed(t *testing.T) {
+	ctx := newDb()
+	t.Cleanup(func() {
+		db.DB(ctx).Close(ctx)
+	})
+	testContextP := createTestObjects(t, ctx)
+	testContext := *testContextP
+
+	tests := []struct {
+		method string
+		target string
+		allow  string
+	}{
+		{"PATCH", "/catalogs/valid-catalog", "GET, HEAD, PUT, DELETE"},
+		{"POST", "/catalogs/valid-catalog", "GET, HEAD, PUT, DELETE"},
+		{"PATCH", "/variants/valid-variant", "GET, HEAD, PUT, DELETE"},
+		{"DELETE", "/variants/valid-variant/versions", "GET, HEAD"},
+		{"GET", "/catalogs", "POST"},
+		{"PUT", "/collections", "GET, HEAD, POST"},
+		{"DELETE", "/catalogs/valid-catalog/export", "GET"},
+		{"HEAD", "/catalogs/valid-catalog/export", "GET"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.target, func(t *testing.T) {
+			httpReq, _ := http.NewRequest(tt.method, tt.target, nil)
+			response := executeTestRequest(t, httpReq, nil, testContext)
+			if !assert.Equal(t, http.StatusMethodNotAllowed, response.Code) {
+				t.Logf("Response: %v", response.Body.String())
+			}
+			assert.Equal(t, tt.allow, response.Header().Get("Allow"))
+		})
+	}
+
+	// the catalog is untouched and its supported methods still work
+	httpReq, _ := http.NewRequest("GET", "/catalogs/valid-catalog", nil)
+	response := executeTestRequest(t, httpReq, nil, testContext)
+	assert.Equal(t, http.StatusOK, response.Code)
+	assert.Empty(t, response.Header().Get("Allow"))
+}
@@ -5,6 +5,7 @@ import (
 
 	"github.com/go-chi/chi/v5"
 	"github.com/mugiliam/hatchcatalogsrv/internal/common"
+	"github.com/mugiliam/hatchcatalogsrv/internal/tracing"
 	"github.com/mugiliam/hatchcatalogsrv/pkg/types"
 )
 
@@ -18,12 +19,11 @@ func LoadContext(next http.Handler) http.Handler {
 		}
 		tenantId := chi.URLParam(r, "tenantId")
 		projectId := chi.URLParam(r, "projectId")
-		r = r.WithContext(
-			common.SetProjectIdInContext(
-				common.SetTenantIdInContext(r.Context(), types.TenantId(tenantId)),
-				types.ProjectId(projectId),
-			),
+		ctx = common.SetProjectIdInContext(
+			common.SetTenantIdInContext(ctx, types.TenantId(tenantId)),
+			types.ProjectId(projectId),
 		)
-		next.ServeHTTP(w, r)
+		tracing.Annotate(ctx)
+		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
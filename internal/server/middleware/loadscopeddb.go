@@ -10,6 +10,12 @@ func LoadScopedDB(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		ctx := db.ConnCtx(r.Context())
 		defer db.DB(ctx).Close(ctx)
+		// requests that only read may be served from the replica. Others read from the primary so that they
+		// see their own writes.
+		if r.Method == http.MethodGet || r.Method == http.MethodHead {
+			ctx = db.ReadConnCtx(ctx)
+			defer db.CloseReadConn(ctx)
+		}
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
@@ -0,0 +1,149 @@
+package middleware
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/mugiliam/common/httpx"
+	"github.com/mugiliam/hatchcatalogsrv/internal/common"
+	"github.com/mugiliam/hatchcatalogsrv/internal/config"
+	"github.com/mugiliam/hatchcatalogsrv/pkg/types"
+	"github.com/rs/zerolog/log"
+)
+
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// RateLimiter limits the request rate of each tenant with a token bucket. A bucket that has been idle long
+// enough to refill is the same as a new one, so such buckets are dropped when the number of tracked tenants
+// reaches its limit. This keeps memory bounded regardless of the number of tenants.
+type RateLimiter struct {
+	rate       float64
+	burst      float64
+	maxTenants int
+	now        func() time.Time
+
+	mu      sync.Mutex
+	buckets map[types.TenantId]*tokenBucket
+}
+
+// NewRateLimiter returns a rate limiter for c. now is the clock used to refill the buckets and defaults to
+// time.Now when nil.
+func NewRateLimiter(c config.RateLimitConfig, now func() time.Time) *RateLimiter {
+	if now == nil {
+		now = time.Now
+	}
+	l := &RateLimiter{
+		now:     now,
+		buckets: make(map[types.TenantId]*tokenBucket),
+	}
+	l.SetConfig(c)
+	return l
+}
+
+// SetConfig changes the rate limit to c. Buckets are kept, and hold at most the new burst from their next
+// request. A rate of 0 turns rate limiting off.
+func (l *RateLimiter) SetConfig(c config.RateLimitConfig) {
+	burst := c.Burst
+	if burst <= 0 {
+		burst = int(math.Ceil(c.RequestsPerSecond))
+	}
+	maxTenants := c.MaxTenants
+	if maxTenants <= 0 {
+		maxTenants = config.DefaultRateLimitMaxTenants
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.rate = c.RequestsPerSecond
+	l.burst = float64(burst)
+	l.maxTenants = maxTenants
+	if l.rate <= 0 {
+		clear(l.buckets)
+	}
+}
+
+// Allow takes a token from the tenant's bucket. If the bucket is empty, it returns false along with the time
+// until the next token is available.
+func (l *RateLimiter) Allow(tenant types.TenantId) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.rate <= 0 {
+		return true, 0
+	}
+	now := l.now()
+	b, ok := l.buckets[tenant]
+	if !ok {
+		if len(l.buckets) >= l.maxTenants {
+			l.evict(now)
+		}
+		b = &tokenBucket{tokens: l.burst, last: now}
+		l.buckets[tenant] = b
+	} else {
+		b.tokens = math.Min(l.burst, b.tokens+now.Sub(b.last).Seconds()*l.rate)
+		b.last = now
+	}
+
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0
+	}
+	return false, time.Duration((1 - b.tokens) / l.rate * float64(time.Second))
+}
+
+// evict drops the buckets that have refilled. If all of them are still in use, the least recently used
+// bucket is dropped to make room.
+func (l *RateLimiter) evict(now time.Time) {
+	refill := time.Duration(l.burst / l.rate * float64(time.Second))
+	var lru types.TenantId
+	var lruAt time.Time
+	for t, b := range l.buckets {
+		if now.Sub(b.last) >= refill {
+			delete(l.buckets, t)
+			continue
+		}
+		if lru == "" || b.last.Before(lruAt) {
+			lru, lruAt = t, b.last
+		}
+	}
+	if len(l.buckets) >= l.maxTenants {
+		delete(l.buckets, lru)
+	}
+}
+
+// tenants returns the number of tenants with a bucket
+func (l *RateLimiter) tenants() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return len(l.buckets)
+}
+
+// Middleware rejects requests of a tenant that exceeds its rate with 429 and a Retry-After header. It must run
+// after LoadContext so that the tenant is known, and before LoadScopedDB so that a rejected request doesn't take
+// a database connection. Requests without a tenant are passed through. Probes and metrics are served outside of
+// the resource routes, so they are never limited.
+func (l *RateLimiter) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tenant := common.TenantIdFromContext(r.Context())
+		if tenant == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if ok, wait := l.Allow(tenant); !ok {
+			log.Ctx(r.Context()).Debug().Str("tenant", string(tenant)).Dur("retry_after", wait).Msg("rate limit exceeded")
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+			(&httpx.Error{
+				StatusCode:  http.StatusTooManyRequests,
+				Description: "rate limit exceeded",
+			}).Send(w)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,140 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/mugiliam/hatchcatalogsrv/internal/common"
+	"github.com/mugiliam/hatchcatalogsrv/internal/config"
+	"github.com/mugiliam/hatchcatalogsrv/pkg/types"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeClock struct {
+	t time.Time
+}
+
+func (c *fakeClock) now() time.Time {
+	return c.t
+}
+
+func (c *fakeClock) advance(d time.Duration) {
+	c.t = c.t.Add(d)
+}
+
+func TestRateLimiterAllow(t *testing.T) {
+	clock := &fakeClock{t: time.Unix(0, 0)}
+	l := NewRateLimiter(config.RateLimitConfig{RequestsPerSecond: 2, Burst: 3}, clock.now)
+
+	// the burst is available up front
+	for i := 0; i < 3; i++ {
+		ok, _ := l.Allow("T1")
+		assert.True(t, ok)
+	}
+	ok, wait := l.Allow("T1")
+	assert.False(t, ok)
+	assert.Equal(t, 500*time.Millisecond, wait)
+
+	// other tenants have their own bucket
+	ok, _ = l.Allow("T2")
+	assert.True(t, ok)
+
+	// tokens refill at the configured rate
+	clock.advance(500 * time.Millisecond)
+	ok, _ = l.Allow("T1")
+	assert.True(t, ok)
+	ok, _ = l.Allow("T1")
+	assert.False(t, ok)
+
+	// the bucket never holds more than the burst
+	clock.advance(time.Hour)
+	for i := 0; i < 3; i++ {
+		ok, _ := l.Allow("T1")
+		assert.True(t, ok)
+	}
+	ok, _ = l.Allow("T1")
+	assert.False(t, ok)
+}
+
+func TestRateLimiterEvictsIdleTenants(t *testing.T) {
+	clock := &fakeClock{t: time.Unix(0, 0)}
+	l := NewRateLimiter(config.RateLimitConfig{RequestsPerSecond: 1, Burst: 2, MaxTenants: 2}, clock.now)
+
+	l.Allow("T1")
+	clock.advance(time.Second)
+	l.Allow("T2")
+	assert.Equal(t, 2, l.tenants())
+
+	// T1 has refilled and is dropped to make room
+	clock.advance(time.Second)
+	l.Allow("T3")
+	assert.Equal(t, 2, l.tenants())
+	assert.NotContains(t, l.buckets, types.TenantId("T1"))
+
+	// with every tenant active, the least recently used one is dropped
+	l.Allow("T4")
+	assert.Equal(t, 2, l.tenants())
+	assert.NotContains(t, l.buckets, types.TenantId("T2"))
+	assert.Contains(t, l.buckets, types.TenantId("T3"))
+}
+
+func TestRateLimiterSetConfig(t *testing.T) {
+	clock := &fakeClock{t: time.Unix(0, 0)}
+	l := NewRateLimiter(config.RateLimitConfig{}, clock.now)
+
+	// a rate of 0 lets everything through
+	for i := 0; i < 5; i++ {
+		ok, _ := l.Allow("T1")
+		assert.True(t, ok)
+	}
+
+	l.SetConfig(config.RateLimitConfig{RequestsPerSecond: 1, Burst: 1})
+	ok, _ := l.Allow("T1")
+	assert.True(t, ok)
+	ok, _ = l.Allow("T1")
+	assert.False(t, ok)
+
+	// a larger burst applies to existing buckets as they refill
+	l.SetConfig(config.RateLimitConfig{RequestsPerSecond: 1, Burst: 3})
+	clock.advance(time.Hour)
+	for i := 0; i < 3; i++ {
+		ok, _ := l.Allow("T1")
+		assert.True(t, ok)
+	}
+	ok, _ = l.Allow("T1")
+	assert.False(t, ok)
+
+	l.SetConfig(config.RateLimitConfig{})
+	ok, _ = l.Allow("T1")
+	assert.True(t, ok)
+	assert.Equal(t, 0, l.tenants())
+}
+
+func TestRateLimiterMiddleware(t *testing.T) {
+	clock := &fakeClock{t: time.Unix(0, 0)}
+	l := NewRateLimiter(config.RateLimitConfig{RequestsPerSecond: 0.5, Burst: 1}, clock.now)
+	h := l.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	send := func(path string, tenant types.TenantId) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req = req.WithContext(common.SetTenantIdInContext(req.Context(), tenant))
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+		return rr
+	}
+
+	assert.Equal(t, http.StatusOK, send("/catalogs/c1", "T1").Code)
+	rr := send("/catalogs/c1", "T1")
+	assert.Equal(t, http.StatusTooManyRequests, rr.Code)
+	assert.Equal(t, "2", rr.Header().Get("Retry-After"))
+
+	// requests without a tenant are passed through
+	assert.Equal(t, http.StatusOK, send("/catalogs/c1", "").Code)
+
+	clock.advance(2 * time.Second)
+	assert.Equal(t, http.StatusOK, send("/catalogs/c1", "T1").Code)
+}
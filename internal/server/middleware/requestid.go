@@ -0,0 +1,50 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/mugiliam/hatchcatalogsrv/internal/common"
+	"github.com/mugiliam/hatchcatalogsrv/pkg/api"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+// maxRequestIDLength bounds the size of a client supplied request ID
+const maxRequestIDLength = 128
+
+// RequestID reads the request ID from the X-Request-ID header, or generates one if it is missing or malformed.
+// The ID is echoed in the response header, stored in the context and added to the context logger, so every
+// log.Ctx(ctx) line for the request, including those from the db layer, carries a request_id field.
+func RequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestId := r.Header.Get(api.RequestIDHeader)
+		if !isValidRequestID(requestId) {
+			requestId = uuid.New().String()
+		}
+		w.Header().Set(api.RequestIDHeader, requestId)
+
+		ctx := r.Context()
+		logger := log.Ctx(ctx)
+		if logger.GetLevel() == zerolog.Disabled {
+			logger = &log.Logger
+		}
+		l := logger.With().Str("request_id", requestId).Logger()
+		ctx = l.WithContext(ctx)
+		ctx = common.SetRequestIdInContext(ctx, requestId)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// isValidRequestID accepts non-empty, bounded IDs made of printable, non-space ASCII characters
+func isValidRequestID(id string) bool {
+	if len(id) == 0 || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] <= ' ' || id[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
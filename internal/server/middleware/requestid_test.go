@@ -0,0 +1,60 @@
+package middleware
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/mugiliam/hatchcatalogsrv/internal/common"
+	"github.com/mugiliam/hatchcatalogsrv/pkg/api"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequestID(t *testing.T) {
+	var buf bytes.Buffer
+	var ctxId string
+	h := RequestID(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctxId = common.RequestIdFromContext(r.Context())
+		log.Ctx(r.Context()).Info().Msg("handled")
+	}))
+	serve := func(id string) *httptest.ResponseRecorder {
+		buf.Reset()
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		if id != "" {
+			req.Header.Set(api.RequestIDHeader, id)
+		}
+		logger := zerolog.New(&buf)
+		req = req.WithContext(logger.WithContext(req.Context()))
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w
+	}
+
+	// a client supplied ID is kept
+	w := serve("abc-123")
+	assert.Equal(t, "abc-123", w.Header().Get(api.RequestIDHeader))
+	assert.Equal(t, "abc-123", ctxId)
+	assert.Contains(t, buf.String(), `"request_id":"abc-123"`)
+
+	// a missing ID is generated
+	w = serve("")
+	id := w.Header().Get(api.RequestIDHeader)
+	_, err := uuid.Parse(id)
+	assert.NoError(t, err)
+	assert.Equal(t, id, ctxId)
+	assert.Contains(t, buf.String(), `"request_id":"`+id+`"`)
+
+	// malformed IDs are replaced
+	for _, bad := range []string{"has space", strings.Repeat("a", maxRequestIDLength+1)} {
+		w = serve(bad)
+		id = w.Header().Get(api.RequestIDHeader)
+		assert.NotEqual(t, bad, id)
+		_, err = uuid.Parse(id)
+		assert.NoError(t, err)
+	}
+}
@@ -146,6 +146,7 @@ func TestGetUpdateDeleteCatalog(t *testing.T) {
 	reqType := make(map[string]any)
 	err = json.Unmarshal([]byte(req), &reqType)
 	assert.NoError(t, err)
+	removeTimestamps(rspType)
 	assert.Equal(t, reqType, rspType)
 
 	// Create a New Request to get a non-existing catalog
@@ -194,6 +195,7 @@ func TestGetUpdateDeleteCatalog(t *testing.T) {
 	reqType = make(map[string]any)
 	err = json.Unmarshal([]byte(req), &reqType)
 	assert.NoError(t, err)
+	removeTimestamps(rspType)
 	assert.Equal(t, reqType, rspType)
 
 	// Delete the catalog
@@ -307,6 +309,7 @@ func TestVariantCrud(t *testing.T) {
 	reqType := make(map[string]any)
 	err = json.Unmarshal([]byte(req), &reqType)
 	assert.NoError(t, err)
+	removeTimestamps(rspType)
 	assert.Equal(t, reqType, rspType)
 
 	// Create a new variant on the /variants endpoint
@@ -345,6 +348,7 @@ func TestVariantCrud(t *testing.T) {
 	reqType = make(map[string]any)
 	err = json.Unmarshal([]byte(req), &reqType)
 	assert.NoError(t, err)
+	removeTimestamps(rspType)
 	assert.Equal(t, reqType, rspType)
 
 	// Create a new variant by updating the testcontext
@@ -397,6 +401,7 @@ func TestVariantCrud(t *testing.T) {
 	reqType = make(map[string]any)
 	err = json.Unmarshal([]byte(req), &reqType)
 	assert.NoError(t, err)
+	removeTimestamps(rspType)
 	assert.Equal(t, reqType, rspType)
 
 	// Delete the variant
@@ -661,6 +666,19 @@ func TestWorkspaceCrud(t *testing.T) {
 	}
 	checkHeader(t, response.Header())
 
+	// renaming to the label of another workspace is a conflict
+	httpReq, _ = http.NewRequest("POST", "/workspaces?c=valid-catalog&v=valid-variant", nil)
+	setRequestBodyAndHeader(t, httpReq, `{"version": "v1", "kind": "Workspace", "metadata": {"label": "other-workspace"}}`)
+	response = executeTestRequest(t, httpReq, nil, testContext)
+	if !assert.Equal(t, http.StatusCreated, response.Code) {
+		t.Logf("Response: %v", response.Body.String())
+		t.FailNow()
+	}
+	httpReq, _ = http.NewRequest("PUT", "/workspaces/valid-workspace", nil)
+	setRequestBodyAndHeader(t, httpReq, `{"version": "v1", "kind": "Workspace", "metadata": {"label": "other-workspace"}}`)
+	response = executeTestRequest(t, httpReq, nil, testContext)
+	assert.Equal(t, http.StatusConflict, response.Code)
+
 	// Delete the workspace
 	httpReq, _ = http.NewRequest("DELETE", loc, nil)
 	response = executeTestRequest(t, httpReq, nil, testContext)
@@ -1104,6 +1122,22 @@ func TestObjectCrud(t *testing.T) {
 	assert.Equal(t, gjson.GetBytes(rspJson, "metadata.namespace").String(), "valid-namespace")
 	assert.Equal(t, gjson.GetBytes(rspJson, "metadata.description").String(), "This is a new description")
 
+	// a namespace that does not exist is rejected rather than creating an orphaned object
+	for _, target := range []string{"/collectionschemas?n=no-such-namespace", "/collectionschemas"} {
+		nsJson, err := sjson.SetBytes(reqJson, "metadata.namespace", "no-such-namespace")
+		require.NoError(t, err)
+		httpReq, _ = http.NewRequest("POST", target, nil)
+		setRequestBodyAndHeader(t, httpReq, string(nsJson))
+		response = executeTestRequest(t, httpReq, nil, testContext)
+		if !assert.Equal(t, http.StatusNotFound, response.Code) {
+			t.Logf("Response: %v", response.Body.String())
+			t.FailNow()
+		}
+	}
+	httpReq, _ = http.NewRequest("GET", "/collectionschemas/valid?n=no-such-namespace", nil)
+	response = executeTestRequest(t, httpReq, nil, testContext)
+	assert.NotEqual(t, http.StatusOK, response.Code)
+
 	// create a valid parameter
 	reqYaml = `
 				version: v1
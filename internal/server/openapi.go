@@ -0,0 +1,339 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/mugiliam/hatchcatalogsrv/internal/apis"
+	"github.com/mugiliam/hatchcatalogsrv/pkg/types"
+)
+
+const openAPIVersion = "3.0.3"
+
+// openAPIDocument is an OpenAPI 3 description of the server. The paths are generated from the routes of the
+// router, so they stay in sync with the handlers, and are annotated with the hand-written summaries in
+// openAPIRoutes and schemas in openAPISchemas.
+type openAPIDocument struct {
+	OpenAPI    string                                  `json:"openapi"`
+	Info       openAPIInfo                             `json:"info"`
+	Paths      map[string]map[string]*openAPIOperation `json:"paths"`
+	Components openAPIComponents                       `json:"components"`
+}
+
+type openAPIInfo struct {
+	Title   string `json:"title"`
+	Version string `json:"version"`
+}
+
+type openAPIOperation struct {
+	OperationID string                     `json:"operationId"`
+	Summary     string                     `json:"summary"`
+	Tags        []string                   `json:"tags"`
+	Parameters  []openAPIParameter         `json:"parameters,omitempty"`
+	RequestBody *openAPIRequestBody        `json:"requestBody,omitempty"`
+	Responses   map[string]openAPIResponse `json:"responses"`
+}
+
+type openAPIParameter struct {
+	Name        string         `json:"name"`
+	In          string         `json:"in"`
+	Description string         `json:"description,omitempty"`
+	Required    bool           `json:"required,omitempty"`
+	Schema      map[string]any `json:"schema"`
+}
+
+type openAPIRequestBody struct {
+	Required bool                        `json:"required"`
+	Content  map[string]openAPIMediaType `json:"content"`
+}
+
+type openAPIMediaType struct {
+	Schema map[string]any `json:"schema"`
+}
+
+type openAPIResponse struct {
+	Description string                      `json:"description"`
+	Headers     map[string]any              `json:"headers,omitempty"`
+	Content     map[string]openAPIMediaType `json:"content,omitempty"`
+}
+
+type openAPIComponents struct {
+	Schemas map[string]any `json:"schemas"`
+}
+
+// openAPIRoute documents an operation. Request and Response name schemas in openAPISchemas; an operation
+// without a Response has no response body.
+type openAPIRoute struct {
+	Summary  string
+	Request  string
+	Response string
+	Status   int
+}
+
+// openAPIRoutes documents the operations of the resource paths by "METHOD path". The operations of the generic
+// object routes are documented by genericObjectRoute, and operations that are not listed get a summary made
+// from their method and path.
+var openAPIRoutes = map[string]openAPIRoute{
+	"GET /projects":                                            {Summary: "List the projects of the tenant", Response: "Object"},
+	"PUT /projects/{projectId}":                                {Summary: "Update a project", Request: "Object", Response: "Object"},
+	"POST /catalogs":                                           {Summary: "Create a catalog", Request: "Catalog", Status: http.StatusCreated},
+	"GET /catalogs/{catalogName}":                              {Summary: "Get a catalog", Response: "Catalog"},
+	"PUT /catalogs/{catalogName}":                              {Summary: "Update a catalog", Request: "Catalog"},
+	"DELETE /catalogs/{catalogName}":                           {Summary: "Delete a catalog", Status: http.StatusNoContent},
+	"POST /catalogs/{catalogName}/rename":                      {Summary: "Rename a catalog", Request: "Object", Response: "Object"},
+	"GET /catalogs/{catalogName}/stats":                        {Summary: "Count the objects of a catalog", Response: "Object"},
+	"POST /catalogs/{catalogName}/import":                      {Summary: "Import an archive of manifests into a workspace", Request: "Archive", Response: "Object", Status: http.StatusCreated},
+	"GET /catalogs/{catalogName}/export":                       {Summary: "Export a variant of a catalog as an archive of manifests", Response: "Archive"},
+	"POST /variants":                                           {Summary: "Create a variant", Request: "Variant", Status: http.StatusCreated},
+	"GET /variants/{variantName}":                              {Summary: "Get a variant", Response: "Variant"},
+	"PUT /variants/{variantName}":                              {Summary: "Update a variant", Request: "Variant"},
+	"DELETE /variants/{variantName}":                           {Summary: "Delete a variant", Status: http.StatusNoContent},
+	"GET /variants/{variantName}/versions":                     {Summary: "List the versions of a variant", Response: "VersionList"},
+	"GET /variants/{variantName}/versions/{versionRef}":        {Summary: "Get a version by number or label", Response: "Version"},
+	"PUT /variants/{variantName}/versions/{versionRef}/label":  {Summary: "Set the label of a version", Request: "Object", Response: "Version"},
+	"POST /workspaces":                                         {Summary: "Create a workspace", Request: "Workspace", Status: http.StatusCreated},
+	"GET /workspaces/{workspaceRef}":                           {Summary: "Get a workspace", Response: "Workspace"},
+	"PUT /workspaces/{workspaceRef}":                           {Summary: "Update a workspace", Request: "Workspace"},
+	"DELETE /workspaces/{workspaceRef}":                        {Summary: "Delete a workspace", Status: http.StatusNoContent},
+	"POST /workspaces/{workspaceRef}/rebase":                   {Summary: "Rebase a workspace on the latest version of its variant", Response: "Object"},
+	"POST /workspaces/{workspaceRef}/merge/{sourceRef}":        {Summary: "Merge a workspace into another", Response: "Object"},
+	"POST /namespaces":                                         {Summary: "Create a namespace", Request: "Namespace", Status: http.StatusCreated},
+	"GET /namespaces/{namespaceName}":                          {Summary: "Get a namespace", Response: "Namespace"},
+	"PUT /namespaces/{namespaceName}":                          {Summary: "Update a namespace", Request: "Namespace"},
+	"DELETE /namespaces/{namespaceName}":                       {Summary: "Delete a namespace", Status: http.StatusNoContent},
+	"PUT /namespaces/{namespaceName}/contents":                 {Summary: "Replace the contents of a namespace with an archive of manifests", Request: "Archive", Response: "Object"},
+	"GET /parameterschemas/{paramName}/resolve":                {Summary: "Resolve a parameter schema along its path", Response: "ParameterSchema"},
+	"GET /parameterschemas/{paramName}/default":                {Summary: "Get the default value of a parameter schema", Response: "Object"},
+	"POST /parameterschemas/{paramName}:checkCompat":           {Summary: "Check a change to a parameter schema against the collections that use it", Request: "ParameterSchema", Response: "Object"},
+	"GET /collectionschemas/{collectionSchemaName}/jsonschema": {Summary: "Get the JSON schema of the values of a collection schema", Response: "Object"},
+	"GET /collections":                                         {Summary: "List the collections under a path prefix, as NDJSON with format=ndjson", Response: "CollectionList"},
+	"POST /parameterschemas:batchGet":                          {Summary: "Get several parameter schemas", Request: "Object", Response: "Object", Status: http.StatusOK},
+	"POST /collectionschemas:batchGet":                         {Summary: "Get several collection schemas", Request: "Object", Response: "Object", Status: http.StatusOK},
+	"POST /parameterschemas:batchDelete":                       {Summary: "Delete several parameter schemas", Request: "Object", Response: "Object", Status: http.StatusOK},
+	"POST /collectionschemas:batchDelete":                      {Summary: "Delete several collection schemas", Request: "Object", Response: "Object", Status: http.StatusOK},
+	"POST /collections:batchDelete":                            {Summary: "Delete several collections", Request: "Object", Response: "Object", Status: http.StatusOK},
+	"POST /lint":                                               {Summary: "Validate a manifest without saving it", Request: "Resource", Response: "Object", Status: http.StatusOK},
+	"POST /admin/gc":                                           {Summary: "Collect the objects that are no longer referenced", Response: "Object", Status: http.StatusOK},
+	"POST /admin/migrate":                                      {Summary: "Migrate the objects of a variant", Response: "Object", Status: http.StatusOK},
+	"GET /admin/references:verify":                             {Summary: "Verify the references between schemas", Response: "Object"},
+	"POST /admin/references:repair":                            {Summary: "Repair the references between schemas", Response: "Object", Status: http.StatusOK},
+	"GET /healthz":                                             {Summary: "Report that the server is up", Response: "Object"},
+	"GET /readyz":                                              {Summary: "Report whether the server can serve requests", Response: "Object"},
+	"GET /version":                                             {Summary: "Get the version of the server", Response: "Object"},
+	"GET /metrics":                                             {Summary: "Get the metrics of the server in the Prometheus format"},
+	"GET /openapi.json":                                        {Summary: "Get this description of the server", Response: "Object"},
+}
+
+// genericObjectRoute documents an operation of the generic object routes on resource
+func genericObjectRoute(method, resource string) openAPIRoute {
+	kind := types.KindFromResourceName(resource)
+	switch method {
+	case http.MethodPost:
+		return openAPIRoute{Summary: "Create a " + kind, Request: kind, Status: http.StatusCreated}
+	case http.MethodGet:
+		return openAPIRoute{Summary: "Get a " + kind, Response: kind}
+	case http.MethodPut:
+		return openAPIRoute{Summary: "Update a " + kind, Request: kind}
+	case http.MethodPatch:
+		return openAPIRoute{Summary: "Patch a " + kind, Request: "Object", Response: kind}
+	case http.MethodDelete:
+		return openAPIRoute{Summary: "Delete a " + kind, Status: http.StatusNoContent}
+	}
+	return openAPIRoute{}
+}
+
+// genericObjectResources are the resources served by the generic object routes
+var genericObjectResources = []string{
+	types.ResourceNameParameterSchemas,
+	types.ResourceNameCollectionSchemas,
+	types.ResourceNameCollections,
+	types.ResourceNameAttributes,
+}
+
+// contextParameters are the query parameters that select the catalog, variant, workspace and namespace of a
+// request to a resource path
+var contextParameters = []openAPIParameter{
+	queryParameter("catalog", "name of the catalog, also c"),
+	queryParameter("catalog_id", "ID of the catalog, also c_id"),
+	queryParameter("variant", "name of the variant, also v"),
+	queryParameter("variant_id", "ID of the variant, also v_id"),
+	queryParameter("workspace", "label of the workspace, also w"),
+	queryParameter("workspace_id", "ID of the workspace, also w_id"),
+	queryParameter("namespace", "name of the namespace, also n"),
+}
+
+// serverPaths are the paths that describe the server rather than a catalog and take no context parameters
+var serverPaths = []string{"/metrics", "/healthz", "/readyz", "/version", "/openapi.json"}
+
+func queryParameter(name, description string) openAPIParameter {
+	return openAPIParameter{Name: name, In: "query", Description: description, Schema: map[string]any{"type": "string"}}
+}
+
+// routeParamRegexp matches the path parameters of a chi route pattern, with an optional regular expression
+var routeParamRegexp = regexp.MustCompile(`\{([^}:]+)(:[^}]*)?\}`)
+
+// newOpenAPIDocument describes the routes of router
+func newOpenAPIDocument(router chi.Routes) (*openAPIDocument, error) {
+	doc := &openAPIDocument{
+		OpenAPI:    openAPIVersion,
+		Info:       openAPIInfo{Title: "Hatch Catalog Server", Version: "1.0.0"},
+		Paths:      make(map[string]map[string]*openAPIOperation),
+		Components: openAPIComponents{Schemas: openAPISchemas},
+	}
+	walkFunc := func(method string, route string, handler http.Handler, middlewares ...func(http.Handler) http.Handler) error {
+		route = strings.ReplaceAll(route, "//", "/")
+		if method == http.MethodHead || method == http.MethodOptions || !apis.RouteAllowsMethod(route, method) {
+			return nil
+		}
+		for _, p := range openAPIPaths(method, route) {
+			doc.addOperation(method, p.path, p.route)
+		}
+		return nil
+	}
+	if err := chi.Walk(router, walkFunc); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+type openAPIPath struct {
+	path  string
+	route openAPIRoute
+}
+
+// openAPIPaths returns the paths of the document for a route of the router. The generic object routes are
+// expanded to a path for each resource they serve, and the trailing wildcard of a route is the objectPath
+// parameter.
+func openAPIPaths(method, route string) []openAPIPath {
+	if strings.HasPrefix(route, "/{objectType}") {
+		var paths []openAPIPath
+		for _, resource := range genericObjectResources {
+			if !types.IsValidResourceNameAndMethod(resource, method) {
+				continue
+			}
+			p := "/" + resource + strings.TrimPrefix(route, "/{objectType}")
+			paths = append(paths, openAPIPath{path: openAPIPathOf(p), route: genericObjectRoute(method, resource)})
+		}
+		return paths
+	}
+	p := openAPIPathOf(route)
+	r, ok := openAPIRoutes[method+" "+p]
+	if !ok {
+		r = openAPIRoute{Summary: method + " " + p, Response: "Object"}
+	}
+	return []openAPIPath{{path: p, route: r}}
+}
+
+// openAPIPathOf returns the OpenAPI path of a chi route pattern
+func openAPIPathOf(route string) string {
+	if strings.HasSuffix(route, "/*") {
+		route = strings.TrimSuffix(route, "*") + "{objectPath}"
+	}
+	return routeParamRegexp.ReplaceAllString(route, "{$1}")
+}
+
+func (doc *openAPIDocument) addOperation(method, path string, r openAPIRoute) {
+	op := &openAPIOperation{
+		OperationID: operationID(method, path),
+		Summary:     r.Summary,
+		Tags:        []string{pathTag(path)},
+		Responses:   map[string]openAPIResponse{"default": errorResponse()},
+	}
+	for _, m := range routeParamRegexp.FindAllStringSubmatch(path, -1) {
+		param := openAPIParameter{Name: m[1], In: "path", Required: true, Schema: map[string]any{"type": "string"}}
+		if m[1] == "objectPath" {
+			param.Description = "path of the object, ending in its name"
+		}
+		op.Parameters = append(op.Parameters, param)
+	}
+	if !isServerPath(path) {
+		op.Parameters = append(op.Parameters, contextParameters...)
+	}
+	if r.Request != "" {
+		op.RequestBody = &openAPIRequestBody{Required: true, Content: mediaTypes(r.Request)}
+	}
+
+	status := r.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	rsp := openAPIResponse{Description: http.StatusText(status)}
+	if r.Response != "" {
+		rsp.Content = mediaTypes(r.Response)
+	}
+	if status == http.StatusCreated {
+		rsp.Headers = map[string]any{
+			"Location": map[string]any{
+				"description": "path of the created object",
+				"schema":      map[string]any{"type": "string"},
+			},
+		}
+	}
+	op.Responses[strconv.Itoa(status)] = rsp
+
+	if doc.Paths[path] == nil {
+		doc.Paths[path] = make(map[string]*openAPIOperation)
+	}
+	doc.Paths[path][strings.ToLower(method)] = op
+}
+
+// mediaTypes returns the content of a request or response of the schema named name
+func mediaTypes(name string) map[string]openAPIMediaType {
+	mediaType := "application/json"
+	if name == "Archive" {
+		mediaType = "application/gzip"
+	}
+	return map[string]openAPIMediaType{
+		mediaType: {Schema: schemaRef(name)},
+	}
+}
+
+func errorResponse() openAPIResponse {
+	return openAPIResponse{Description: "the request failed", Content: mediaTypes("Error")}
+}
+
+func isServerPath(path string) bool {
+	return slices.Contains(serverPaths, path)
+}
+
+// pathTag groups the operations of a path by its first segment
+func pathTag(path string) string {
+	if isServerPath(path) {
+		return "server"
+	}
+	tag := strings.SplitN(strings.TrimPrefix(path, "/"), "/", 2)[0]
+	tag, _, _ = strings.Cut(tag, ":")
+	return tag
+}
+
+// operationID makes an identifier of an operation from its method and path, such as getCatalogsCatalogName
+func operationID(method, path string) string {
+	var b strings.Builder
+	b.WriteString(strings.ToLower(method))
+	for _, word := range strings.FieldsFunc(path, func(r rune) bool {
+		return !('a' <= r && r <= 'z' || 'A' <= r && r <= 'Z' || '0' <= r && r <= '9')
+	}) {
+		b.WriteString(strings.ToUpper(word[:1]) + word[1:])
+	}
+	return b.String()
+}
+
+// getOpenAPI serves the OpenAPI description of the server
+func (s *HatchCatalogServer) getOpenAPI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(s.openAPI)
+}
+
+func marshalOpenAPI(router chi.Routes) ([]byte, error) {
+	doc, err := newOpenAPIDocument(router)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(doc)
+}
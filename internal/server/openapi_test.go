@@ -0,0 +1,85 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/mugiliam/hatchcatalogsrv/internal/apis"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOpenAPI(t *testing.T) {
+	req, _ := http.NewRequest("GET", "/openapi.json", nil)
+	response := executeTestRequest(t, req, nil)
+	require.Equal(t, http.StatusOK, response.Code)
+	checkHeader(t, response.Result().Header)
+	var doc openAPIDocument
+	require.NoError(t, json.Unmarshal(response.Body.Bytes(), &doc))
+	assert.True(t, strings.HasPrefix(doc.OpenAPI, "3."))
+
+	op := func(method, path string) *openAPIOperation {
+		return doc.Paths[path][strings.ToLower(method)]
+	}
+	responseSchema := func(op *openAPIOperation, status string) string {
+		return op.Responses[status].Content["application/json"].Schema["$ref"].(string)
+	}
+	hasParameter := func(op *openAPIOperation, name, in string) bool {
+		for _, p := range op.Parameters {
+			if p.Name == name && p.In == in {
+				return true
+			}
+		}
+		return false
+	}
+
+	// resource paths with their parameters and schemas
+	getCatalog := op("GET", "/catalogs/{catalogName}")
+	require.NotNil(t, getCatalog)
+	assert.True(t, hasParameter(getCatalog, "catalogName", "path"))
+	assert.Equal(t, "#/components/schemas/Catalog", responseSchema(getCatalog, "200"))
+	require.NotNil(t, op("POST", "/catalogs"))
+	assert.Contains(t, op("POST", "/catalogs").Responses["201"].Headers, "Location")
+	require.NotNil(t, op("GET", "/collections"))
+	assert.True(t, hasParameter(op("GET", "/collections"), "namespace", "query"))
+	require.NotNil(t, op("GET", "/healthz"))
+	assert.False(t, hasParameter(op("GET", "/healthz"), "catalog", "query"))
+
+	// the generic object routes are described for each resource they serve
+	getParam := op("GET", "/parameterschemas/{objectPath}")
+	require.NotNil(t, getParam)
+	assert.True(t, hasParameter(getParam, "objectPath", "path"))
+	assert.Equal(t, "#/components/schemas/ParameterSchema", responseSchema(getParam, "200"))
+	assert.NotNil(t, op("POST", "/collections"))
+	assert.NotNil(t, op("PATCH", "/collections/{objectPath}"))
+	assert.Nil(t, op("PATCH", "/parameterschemas/{objectPath}"))
+	assert.NotContains(t, doc.Paths, "/{objectType}")
+
+	// methods that are answered with a 405 are not described
+	assert.Nil(t, op("GET", "/catalogs"))
+	assert.Nil(t, op("PATCH", "/catalogs/{catalogName}"))
+
+	// every route of the router is described
+	s, err := CreateNewServer()
+	require.NoError(t, err)
+	s.MountHandlers()
+	err = chi.Walk(s.Router, func(method string, route string, handler http.Handler, middlewares ...func(http.Handler) http.Handler) error {
+		route = strings.ReplaceAll(route, "//", "/")
+		if method == http.MethodHead || !apis.RouteAllowsMethod(route, method) || strings.HasPrefix(route, "/{objectType}") {
+			return nil
+		}
+		assert.NotNil(t, op(method, openAPIPathOf(route)), "%s %s is not described", method, route)
+		return nil
+	})
+	require.NoError(t, err)
+
+	// every schema that is referenced is a component
+	body := response.Body.String()
+	for _, r := range strings.Split(body, `"$ref":"#/components/schemas/`)[1:] {
+		name := r[:strings.Index(r, `"`)]
+		assert.Contains(t, doc.Components.Schemas, name)
+	}
+}
@@ -0,0 +1,159 @@
+package server
+
+import "github.com/mugiliam/hatchcatalogsrv/pkg/types"
+
+// openAPISchemas are the component schemas of the OpenAPI description. They are written by hand from the
+// manifests and responses of the catalog manager and describe their fields loosely: the server validates
+// requests itself and reports what is wrong with them.
+var openAPISchemas = map[string]any{
+	"Object": map[string]any{
+		"type":                 "object",
+		"additionalProperties": true,
+	},
+	"Archive": map[string]any{
+		"type":        "string",
+		"format":      "binary",
+		"description": "a gzip compressed tarball of manifests",
+	},
+	"Error": map[string]any{
+		"type":                 "object",
+		"description":          "the status code and description of the error",
+		"additionalProperties": true,
+	},
+	"Metadata": objectSchema(map[string]any{
+		"name":        stringSchema(),
+		"catalog":     stringSchema(),
+		"variant":     stringSchema(),
+		"namespace":   stringSchema(),
+		"path":        stringSchema(),
+		"description": stringSchema(),
+	}, "name"),
+	"Resource": manifest("", schemaRef("Object")),
+	"Catalog": manifest(types.CatalogKind, nil, objectSchema(map[string]any{
+		"name":           stringSchema(),
+		"description":    stringSchema(),
+		"defaultVariant": stringSchema(),
+		"createdAt":      timeSchema(),
+		"updatedAt":      timeSchema(),
+	}, "name")),
+	"Variant": manifest(types.VariantKind, nil, objectSchema(map[string]any{
+		"name":        stringSchema(),
+		"catalog":     stringSchema(),
+		"description": stringSchema(),
+		"createdAt":   timeSchema(),
+		"updatedAt":   timeSchema(),
+	}, "name", "catalog")),
+	"Namespace": manifest(types.NamespaceKind, nil, objectSchema(map[string]any{
+		"name":        stringSchema(),
+		"catalog":     stringSchema(),
+		"variant":     stringSchema(),
+		"description": stringSchema(),
+	}, "name")),
+	"Workspace": manifest(types.WorkspaceKind, nil, objectSchema(map[string]any{
+		"catalog":     stringSchema(),
+		"variant":     stringSchema(),
+		"label":       stringSchema(),
+		"description": stringSchema(),
+		"createdAt":   timeSchema(),
+	})),
+	"ParameterSchema": manifest(types.ParameterSchemaKind, objectSchema(map[string]any{
+		"extends":    withDescription(stringSchema(), "[namespace/]name of the parameter schema this one inherits from"),
+		"dataType":   stringSchema(),
+		"validation": schemaRef("Object"),
+		"default":    map[string]any{"nullable": true},
+	})),
+	"CollectionSchema": manifest(types.CollectionSchemaKind, objectSchema(map[string]any{
+		"parameters": map[string]any{
+			"type": "object",
+			"additionalProperties": objectSchema(map[string]any{
+				"schema":      withDescription(stringSchema(), "[namespace/]name of the parameter schema"),
+				"dataType":    stringSchema(),
+				"default":     map[string]any{"nullable": true},
+				"defaultExpr": stringSchema(),
+				"required":    map[string]any{"type": "boolean"},
+				"nullable":    map[string]any{"type": "boolean"},
+				"sensitive":   map[string]any{"type": "boolean"},
+				"annotations": map[string]any{"type": "object", "additionalProperties": stringSchema()},
+			}),
+		},
+	})),
+	"Collection": manifest(types.CollectionKind, objectSchema(map[string]any{
+		"schema": stringSchema(),
+		"values": schemaRef("Object"),
+	}, "schema")),
+	"Attribute": manifest(types.AttributeKind, schemaRef("Object")),
+	"CollectionList": objectSchema(map[string]any{
+		"namespace":  stringSchema(),
+		"pathPrefix": stringSchema(),
+		"limit":      map[string]any{"type": "integer"},
+		"offset":     map[string]any{"type": "integer"},
+		"collections": map[string]any{
+			"type": "array",
+			"items": objectSchema(map[string]any{
+				"path":   stringSchema(),
+				"schema": stringSchema(),
+			}),
+		},
+	}, "pathPrefix", "collections"),
+	"Version": objectSchema(map[string]any{
+		"version":     map[string]any{"type": "integer"},
+		"label":       stringSchema(),
+		"description": stringSchema(),
+		"createdAt":   timeSchema(),
+	}, "version"),
+	"VersionList": objectSchema(map[string]any{
+		"variant":  stringSchema(),
+		"count":    map[string]any{"type": "integer"},
+		"versions": map[string]any{"type": "array", "items": schemaRef("Version")},
+	}, "variant", "count", "versions"),
+}
+
+// manifest returns the schema of a manifest of kind with spec. The manifests of the kinds that have no spec
+// carry their fields in metadata, which replaces the common Metadata schema.
+func manifest(kind string, spec any, metadata ...any) map[string]any {
+	properties := map[string]any{
+		"version":  map[string]any{"type": "string", "enum": []string{types.VersionV1}},
+		"kind":     stringSchema(),
+		"metadata": schemaRef("Metadata"),
+	}
+	if kind != "" {
+		properties["kind"] = map[string]any{"type": "string", "enum": []string{kind}}
+	}
+	if len(metadata) > 0 {
+		properties["metadata"] = metadata[0]
+	}
+	required := []string{"version", "kind", "metadata"}
+	if spec != nil {
+		properties["spec"] = spec
+		required = append(required, "spec")
+	}
+	return objectSchema(properties, required...)
+}
+
+func objectSchema(properties map[string]any, required ...string) map[string]any {
+	o := map[string]any{
+		"type":       "object",
+		"properties": properties,
+	}
+	if len(required) > 0 {
+		o["required"] = required
+	}
+	return o
+}
+
+func schemaRef(name string) map[string]any {
+	return map[string]any{"$ref": "#/components/schemas/" + name}
+}
+
+func stringSchema() map[string]any {
+	return map[string]any{"type": "string"}
+}
+
+func timeSchema() map[string]any {
+	return map[string]any{"type": "string", "format": "date-time"}
+}
+
+func withDescription(schema map[string]any, description string) map[string]any {
+	schema["description"] = description
+	return schema
+}
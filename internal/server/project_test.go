@@ -0,0 +1,58 @@
+package server
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/mugiliam/hatchcatalogsrv/internal/db"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProjects(t *testing.T) {
+	ctx := newDb()
+	t.Cleanup(func() {
+		db.DB(ctx).Close(ctx)
+	})
+	testContextP := createTestObjects(t, ctx)
+	testContext := *testContextP
+
+	httpReq, _ := http.NewRequest("GET", "/projects", nil)
+	response := executeTestRequest(t, httpReq, nil, testContext)
+	if !assert.Equal(t, http.StatusOK, response.Code) {
+		t.Logf("Response: %v", response.Body.String())
+		t.FailNow()
+	}
+	checkHeader(t, response.Header())
+	compareJson(t, map[string]any{
+		"projects": []any{
+			map[string]any{"projectId": "PABCDE"},
+		},
+	}, response.Body.String())
+
+	// set the description
+	httpReq, _ = http.NewRequest("PUT", "/projects/PABCDE", nil)
+	setRequestBodyAndHeader(t, httpReq, `{"description": "the default project"}`)
+	response = executeTestRequest(t, httpReq, nil, testContext)
+	assert.Equal(t, http.StatusOK, response.Code)
+
+	httpReq, _ = http.NewRequest("GET", "/projects", nil)
+	response = executeTestRequest(t, httpReq, nil, testContext)
+	assert.Equal(t, http.StatusOK, response.Code)
+	compareJson(t, map[string]any{
+		"projects": []any{
+			map[string]any{"projectId": "PABCDE", "description": "the default project"},
+		},
+	}, response.Body.String())
+
+	// unknown project
+	httpReq, _ = http.NewRequest("PUT", "/projects/PMISSING", nil)
+	setRequestBodyAndHeader(t, httpReq, `{"description": "missing"}`)
+	response = executeTestRequest(t, httpReq, nil, testContext)
+	assert.Equal(t, http.StatusNotFound, response.Code)
+
+	// invalid body
+	httpReq, _ = http.NewRequest("PUT", "/projects/PABCDE", nil)
+	setRequestBodyAndHeader(t, httpReq, map[string]any{"description": 5})
+	response = executeTestRequest(t, httpReq, nil, testContext)
+	assert.Equal(t, http.StatusBadRequest, response.Code)
+}
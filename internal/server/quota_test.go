@@ -0,0 +1,130 @@
+package server
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/mugiliam/hatchcatalogsrv/internal/common"
+	"github.com/mugiliam/hatchcatalogsrv/internal/db"
+	"github.com/mugiliam/hatchcatalogsrv/internal/db/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/sjson"
+	"sigs.k8s.io/yaml"
+)
+
+func TestQuotas(t *testing.T) {
+	ctx := newDb()
+	t.Cleanup(func() {
+		db.DB(ctx).Close(ctx)
+	})
+	testContextP := createTestObjects(t, ctx)
+	testContext := *testContextP
+
+	ctx = common.SetTenantIdInContext(ctx, testContext.TenantId)
+	ctx = common.SetProjectIdInContext(ctx, testContext.ProjectId)
+
+	send := func(method, target, body string, tc TestContext, expectedStatus int) string {
+		httpReq, _ := http.NewRequest(method, target, nil)
+		if body != "" {
+			setRequestBodyAndHeader(t, httpReq, body)
+		}
+		response := executeTestRequest(t, httpReq, nil, tc)
+		if !assert.Equal(t, expectedStatus, response.Code) {
+			t.Logf("Response: %v", response.Body.String())
+			t.FailNow()
+		}
+		return response.Body.String()
+	}
+
+	t.Run("catalogs per project", func(t *testing.T) {
+		maxCatalogs := 2
+		require.NoError(t, db.DB(ctx).SetTenantQuota(ctx, &models.TenantQuota{MaxCatalogsPerProject: &maxCatalogs}))
+		t.Cleanup(func() {
+			_ = db.DB(ctx).SetTenantQuota(ctx, &models.TenantQuota{})
+		})
+
+		catalog := func(name string) string {
+			return `{"version": "v1", "kind": "Catalog", "metadata": {"name": "` + name + `"}}`
+		}
+		send("POST", "/catalogs", catalog("quota-catalog-1"), testContext, http.StatusCreated)
+		rsp := send("POST", "/catalogs", catalog("quota-catalog-2"), testContext, http.StatusForbidden)
+		assert.Contains(t, rsp, "max_catalogs_per_project")
+
+		send("DELETE", "/catalogs/quota-catalog-1", "", testContext, http.StatusNoContent)
+		send("POST", "/catalogs", catalog("quota-catalog-2"), testContext, http.StatusCreated)
+	})
+
+	t.Run("objects per variant", func(t *testing.T) {
+		cat, err := db.DB(ctx).GetCatalog(ctx, uuid.Nil, "valid-catalog")
+		require.NoError(t, err)
+		variant, err := db.DB(ctx).GetVariant(ctx, cat.CatalogID, uuid.Nil, "valid-variant")
+		require.NoError(t, err)
+		stats, err := db.DB(ctx).GetVariantStats(ctx, variant.VariantID)
+		require.NoError(t, err)
+		maxObjects := stats.ParameterSchemas + stats.CollectionSchemas + stats.Collections + 1
+		require.NoError(t, db.DB(ctx).SetTenantQuota(ctx, &models.TenantQuota{MaxObjectsPerVariant: &maxObjects}))
+		t.Cleanup(func() {
+			_ = db.DB(ctx).SetTenantQuota(ctx, &models.TenantQuota{})
+		})
+
+		// objects are saved to the variant rather than the workspace
+		variantContext := testContext
+		variantContext.CatalogContext.WorkspaceLabel = ""
+		reqYaml := `
+			version: v1
+			kind: ParameterSchema
+			metadata:
+				name: quota-param-1
+				catalog: valid-catalog
+				variant: valid-variant
+				path: /
+			spec:
+				dataType: Integer
+				default: 5
+		`
+		replaceTabsWithSpaces(&reqYaml)
+		param1, err := yaml.YAMLToJSON([]byte(reqYaml))
+		require.NoError(t, err)
+		param2, _ := sjson.SetBytes(param1, "metadata.name", "quota-param-2")
+
+		send("POST", "/parameterschemas", string(param1), variantContext, http.StatusCreated)
+		rsp := send("POST", "/parameterschemas", string(param2), variantContext, http.StatusForbidden)
+		assert.Contains(t, rsp, "max_objects_per_variant")
+
+		// updating an existing object does not add to the count
+		updated, _ := sjson.SetBytes(param1, "spec.default", 6)
+		send("PUT", "/parameterschemas/quota-param-1", string(updated), variantContext, http.StatusOK)
+
+		send("DELETE", "/parameterschemas/quota-param-1", "", variantContext, http.StatusNoContent)
+		send("POST", "/parameterschemas", string(param2), variantContext, http.StatusCreated)
+	})
+
+	t.Run("bytes per tenant", func(t *testing.T) {
+		used, err := db.DB(ctx).GetTenantObjectBytes(ctx)
+		require.NoError(t, err)
+		require.NoError(t, db.DB(ctx).SetTenantQuota(ctx, &models.TenantQuota{MaxBytesPerTenant: &used}))
+		t.Cleanup(func() {
+			_ = db.DB(ctx).SetTenantQuota(ctx, &models.TenantQuota{})
+		})
+
+		reqYaml := `
+			version: v1
+			kind: ParameterSchema
+			metadata:
+				name: quota-bytes-param
+				catalog: valid-catalog
+				variant: valid-variant
+				path: /
+			spec:
+				dataType: Integer
+				default: 7
+		`
+		replaceTabsWithSpaces(&reqYaml)
+		param, err := yaml.YAMLToJSON([]byte(reqYaml))
+		require.NoError(t, err)
+		rsp := send("POST", "/parameterschemas", string(param), testContext, http.StatusForbidden)
+		assert.Contains(t, rsp, "max_bytes_per_tenant")
+	})
+}
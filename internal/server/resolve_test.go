@@ -0,0 +1,227 @@
+package server
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/mugiliam/hatchcatalogsrv/internal/db"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
+	"sigs.k8s.io/yaml"
+)
+
+func TestResolveParameterSchema(t *testing.T) {
+	ctx := newDb()
+	t.Cleanup(func() {
+		db.DB(ctx).Close(ctx)
+	})
+	testContextP := createTestObjects(t, ctx)
+	testContext := *testContextP
+
+	// the parameter in the namespace applies at any collection path in the namespace
+	httpReq, _ := http.NewRequest("GET", "/parameterschemas/integer-param-schema/resolve?path=/valid/path", nil)
+	response := executeTestRequest(t, httpReq, nil, testContext)
+	if !assert.Equal(t, http.StatusOK, response.Code) {
+		t.Logf("Response: %v", response.Body.String())
+		t.FailNow()
+	}
+	rsp := response.Body.Bytes()
+	assert.Equal(t, "integer-param-schema", gjson.GetBytes(rsp, "name").String())
+	assert.Equal(t, "/valid/path", gjson.GetBytes(rsp, "path").String())
+	assert.Equal(t, "valid-namespace", gjson.GetBytes(rsp, "namespace").String())
+	assert.Equal(t, "/integer-param-schema", gjson.GetBytes(rsp, "resolved").String())
+	assert.Equal(t, int64(10), gjson.GetBytes(rsp, "validation.maxValue").Int())
+	assert.Equal(t, "ParameterSchema", gjson.GetBytes(rsp, "schema.kind").String())
+
+	// no such parameter
+	httpReq, _ = http.NewRequest("GET", "/parameterschemas/missing-param/resolve?path=/valid", nil)
+	response = executeTestRequest(t, httpReq, nil, testContext)
+	assert.Equal(t, http.StatusNotFound, response.Code)
+
+	// regular gets of parameter schemas are unaffected
+	httpReq, _ = http.NewRequest("GET", "/parameterschemas/integer-param-schema", nil)
+	response = executeTestRequest(t, httpReq, nil, testContext)
+	assert.Equal(t, http.StatusOK, response.Code)
+}
+
+func TestGetParameterSchemaDefault(t *testing.T) {
+	ctx := newDb()
+	t.Cleanup(func() {
+		db.DB(ctx).Close(ctx)
+	})
+	testContextP := createTestObjects(t, ctx)
+	testContext := *testContextP
+
+	httpReq, _ := http.NewRequest("GET", "/parameterschemas/integer-param-schema/default?path=/some/path", nil)
+	response := executeTestRequest(t, httpReq, nil, testContext)
+	if !assert.Equal(t, http.StatusOK, response.Code) {
+		t.Logf("Response: %v", response.Body.String())
+		t.FailNow()
+	}
+	rsp := response.Body.Bytes()
+	assert.Equal(t, "integer-param-schema", gjson.GetBytes(rsp, "name").String())
+	assert.Equal(t, "/integer-param-schema", gjson.GetBytes(rsp, "resolved").String())
+	assert.Equal(t, "Integer", gjson.GetBytes(rsp, "dataType").String())
+	assert.Equal(t, int64(5), gjson.GetBytes(rsp, "default").Int())
+
+	httpReq, _ = http.NewRequest("GET", "/parameterschemas/missing-param/default", nil)
+	response = executeTestRequest(t, httpReq, nil, testContext)
+	assert.Equal(t, http.StatusNotFound, response.Code)
+
+	// maxRetries has no default in the collection schema, so the parameter schema default applies
+	reqYaml := `
+		version: v1
+		kind: Collection
+		metadata:
+			name: my-collection
+			path: /some/path
+		spec:
+			schema: valid
+			values:
+				maxLength: 3
+	`
+	replaceTabsWithSpaces(&reqYaml)
+	reqJson, err := yaml.YAMLToJSON([]byte(reqYaml))
+	require.NoError(t, err)
+	httpReq, _ = http.NewRequest("POST", "/collections", nil)
+	setRequestBodyAndHeader(t, httpReq, string(reqJson))
+	response = executeTestRequest(t, httpReq, nil, testContext)
+	if !assert.Equal(t, http.StatusCreated, response.Code) {
+		t.Logf("Response: %v", response.Body.String())
+		t.FailNow()
+	}
+
+	httpReq, _ = http.NewRequest("GET", "/collections/some/path/my-collection", nil)
+	response = executeTestRequest(t, httpReq, nil, testContext)
+	if !assert.Equal(t, http.StatusOK, response.Code) {
+		t.Logf("Response: %v", response.Body.String())
+		t.FailNow()
+	}
+	collection := response.Body.Bytes()
+	assert.Equal(t, "parameterDefault", gjson.GetBytes(collection, "metadata.valueSources.maxRetries").String())
+	assert.Equal(t, "collectionDefault", gjson.GetBytes(collection, "metadata.valueSources.maxAttempts").String())
+	assert.Equal(t, "explicit", gjson.GetBytes(collection, "metadata.valueSources.maxLength").String())
+	assert.False(t, gjson.GetBytes(collection, "sources").Exists())
+
+	// the fetched collection can be applied again
+	httpReq, _ = http.NewRequest("PUT", "/collections/some/path/my-collection", nil)
+	setRequestBodyAndHeader(t, httpReq, string(collection))
+	response = executeTestRequest(t, httpReq, nil, testContext)
+	if !assert.Equal(t, http.StatusOK, response.Code) {
+		t.Logf("Response: %v", response.Body.String())
+	}
+}
+
+func TestGetCollectionSchemaExpanded(t *testing.T) {
+	ctx := newDb()
+	t.Cleanup(func() {
+		db.DB(ctx).Close(ctx)
+	})
+	testContextP := createTestObjects(t, ctx)
+	testContext := *testContextP
+
+	httpReq, _ := http.NewRequest("GET", "/collectionschemas/valid?expand=parameters", nil)
+	response := executeTestRequest(t, httpReq, nil, testContext)
+	if !assert.Equal(t, http.StatusOK, response.Code) {
+		t.Logf("Response: %v", response.Body.String())
+		t.FailNow()
+	}
+	rsp := response.Body.Bytes()
+	for _, p := range []string{"maxRetries", "maxAttempts", "maxLength"} {
+		assert.Equal(t, "/integer-param-schema", gjson.GetBytes(rsp, "resolved.parameters."+p+".schema").String(), p)
+		assert.Equal(t, "valid-namespace", gjson.GetBytes(rsp, "resolved.parameters."+p+".namespace").String(), p)
+		assert.Equal(t, "Integer", gjson.GetBytes(rsp, "resolved.parameters."+p+".dataType").String(), p)
+		assert.Equal(t, int64(10), gjson.GetBytes(rsp, "resolved.parameters."+p+".validation.maxValue").Int(), p)
+	}
+	// parameters with an inline data type are not resolved
+	assert.False(t, gjson.GetBytes(rsp, "resolved.parameters.maxDelay").Exists())
+	assert.False(t, gjson.GetBytes(rsp, "resolved.parameters.maxValue").Exists())
+
+	// the default response is unchanged
+	httpReq, _ = http.NewRequest("GET", "/collectionschemas/valid", nil)
+	response = executeTestRequest(t, httpReq, nil, testContext)
+	assert.Equal(t, http.StatusOK, response.Code)
+	assert.False(t, gjson.GetBytes(response.Body.Bytes(), "resolved").Exists())
+}
+
+func TestGetObjectAsYAML(t *testing.T) {
+	ctx := newDb()
+	t.Cleanup(func() {
+		db.DB(ctx).Close(ctx)
+	})
+	testContextP := createTestObjects(t, ctx)
+	testContext := *testContextP
+
+	for _, p := range []string{"/collectionschemas/valid", "/parameterschemas/integer-param-schema"} {
+		httpReq, _ := http.NewRequest("GET", p, nil)
+		response := executeTestRequest(t, httpReq, nil, testContext)
+		if !assert.Equal(t, http.StatusOK, response.Code, p) {
+			t.Logf("Response: %v", response.Body.String())
+			t.FailNow()
+		}
+		jsonRsp := response.Body.String()
+
+		httpReq, _ = http.NewRequest("GET", p, nil)
+		httpReq.Header.Set("Accept", "application/yaml")
+		response = executeTestRequest(t, httpReq, nil, testContext)
+		assert.Equal(t, http.StatusOK, response.Code, p)
+		assert.Equal(t, "application/yaml", response.Header().Get("Content-Type"), p)
+		// the manifest round trips to the same object
+		j, err := yaml.YAMLToJSON(response.Body.Bytes())
+		assert.NoError(t, err, p)
+		assert.JSONEq(t, jsonRsp, string(j), p)
+
+		// the output is stable across requests
+		httpReq, _ = http.NewRequest("GET", p, nil)
+		httpReq.Header.Set("Accept", "application/yaml")
+		again := executeTestRequest(t, httpReq, nil, testContext)
+		assert.Equal(t, response.Body.String(), again.Body.String(), p)
+	}
+
+	// errors are returned as json
+	httpReq, _ := http.NewRequest("GET", "/collectionschemas/missing", nil)
+	httpReq.Header.Set("Accept", "application/yaml")
+	response := executeTestRequest(t, httpReq, nil, testContext)
+	assert.Equal(t, http.StatusNotFound, response.Code)
+	assert.NotEqual(t, "application/yaml", response.Header().Get("Content-Type"))
+}
+
+func TestGetCollectionSchemaJSONSchema(t *testing.T) {
+	ctx := newDb()
+	t.Cleanup(func() {
+		db.DB(ctx).Close(ctx)
+	})
+	testContextP := createTestObjects(t, ctx)
+	testContext := *testContextP
+
+	httpReq, _ := http.NewRequest("GET", "/collectionschemas/valid/jsonschema", nil)
+	response := executeTestRequest(t, httpReq, nil, testContext)
+	if !assert.Equal(t, http.StatusOK, response.Code) {
+		t.Logf("Response: %v", response.Body.String())
+		t.FailNow()
+	}
+	rsp := response.Body.Bytes()
+	assert.Equal(t, "https://json-schema.org/draft/2020-12/schema", gjson.GetBytes(rsp, "$schema").String())
+	assert.Equal(t, "valid", gjson.GetBytes(rsp, "title").String())
+	assert.Equal(t, "object", gjson.GetBytes(rsp, "type").String())
+	assert.False(t, gjson.GetBytes(rsp, "additionalProperties").Bool())
+
+	// parameters bound to a parameter schema carry its validation
+	for _, p := range []string{"maxRetries", "maxAttempts", "maxLength"} {
+		assert.Equal(t, "integer", gjson.GetBytes(rsp, "properties."+p+".type").String(), p)
+		assert.Equal(t, int64(1), gjson.GetBytes(rsp, "properties."+p+".minimum").Int(), p)
+		assert.Equal(t, int64(10), gjson.GetBytes(rsp, "properties."+p+".maximum").Int(), p)
+	}
+	assert.Equal(t, int64(5), gjson.GetBytes(rsp, "properties.maxRetries.default").Int())
+	assert.Equal(t, int64(8), gjson.GetBytes(rsp, "properties.maxAttempts.default").Int())
+
+	// parameters with an inline data type have no validation
+	assert.Equal(t, "integer", gjson.GetBytes(rsp, "properties.maxDelay.type").String())
+	assert.False(t, gjson.GetBytes(rsp, "properties.maxDelay.maximum").Exists())
+	assert.Equal(t, int64(1000), gjson.GetBytes(rsp, "properties.maxDelay.default").Int())
+
+	httpReq, _ = http.NewRequest("GET", "/collectionschemas/missing/jsonschema", nil)
+	response = executeTestRequest(t, httpReq, nil, testContext)
+	assert.Equal(t, http.StatusNotFound, response.Code)
+}
@@ -0,0 +1,86 @@
+package server
+
+import (
+	"net/http"
+	"strconv"
+	"testing"
+
+	"github.com/mugiliam/hatchcatalogsrv/internal/db"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
+)
+
+func TestParameterSchemaCompat(t *testing.T) {
+	ctx := newDb()
+	t.Cleanup(func() {
+		db.DB(ctx).Close(ctx)
+	})
+	testContextP := createTestObjects(t, ctx)
+	testContext := *testContextP
+
+	candidate := func(name string, maxValue int) string {
+		return `{"version": "v1", "kind": "ParameterSchema", "metadata": {"name": "` + name + `", "path": "/"},
+			"spec": {"dataType": "Integer", "validation": {"minValue": 1, "maxValue": ` + strconv.Itoa(maxValue) + `}, "default": 5}}`
+	}
+	check := func(name, body string) (int, []byte) {
+		httpReq, _ := http.NewRequest("POST", "/parameterschemas/"+name+":checkCompat", nil)
+		setRequestBodyAndHeader(t, httpReq, body)
+		response := executeTestRequest(t, httpReq, nil, testContext)
+		return response.Code, response.Body.Bytes()
+	}
+
+	for _, c := range []struct {
+		name       string
+		maxRetries int
+	}{{"low", 2}, {"high", 9}} {
+		httpReq, _ := http.NewRequest("POST", "/collections", nil)
+		setRequestBodyAndHeader(t, httpReq, `{"version": "v1", "kind": "Collection", "metadata": {"name": "`+c.name+`", "path": "/compat"},
+			"spec": {"schema": "valid", "values": {"maxRetries": `+strconv.Itoa(c.maxRetries)+`}}}`)
+		response := executeTestRequest(t, httpReq, nil, testContext)
+		require.Equal(t, http.StatusCreated, response.Code, response.Body.String())
+	}
+
+	// the current schema is compatible with itself
+	code, body := check("integer-param-schema", candidate("integer-param-schema", 10))
+	require.Equal(t, http.StatusOK, code, string(body))
+	assert.True(t, gjson.GetBytes(body, "incompatible").IsArray())
+	assert.Empty(t, gjson.GetBytes(body, "incompatible").Array())
+
+	// lowering the maximum breaks the defaults of 8 in the collection schema and the value of 9 in one collection
+	code, body = check("integer-param-schema", candidate("integer-param-schema", 7))
+	require.Equal(t, http.StatusOK, code, string(body))
+	var schemas, collections []string
+	retries := map[string]bool{}
+	for _, v := range gjson.GetBytes(body, "incompatible").Array() {
+		switch v.Get("kind").String() {
+		case "CollectionSchema":
+			schemas = append(schemas, v.Get("path").String())
+		case "Collection":
+			collections = append(collections, v.Get("path").String())
+			if v.Get("parameter").String() == "maxRetries" {
+				retries[v.Get("path").String()] = true
+			}
+		}
+		assert.NotEmpty(t, v.Get("error").String())
+	}
+	require.Len(t, schemas, 1)
+	assert.Contains(t, schemas[0], "valid")
+	assert.NotEmpty(t, collections)
+	require.Len(t, retries, 1)
+	for p := range retries {
+		assert.Contains(t, p, "/compat/high")
+	}
+
+	// nothing is saved
+	httpReq, _ := http.NewRequest("GET", "/parameterschemas/integer-param-schema", nil)
+	response := executeTestRequest(t, httpReq, nil, testContext)
+	require.Equal(t, http.StatusOK, response.Code)
+	assert.Equal(t, int64(10), gjson.GetBytes(response.Body.Bytes(), "spec.validation.maxValue").Int())
+
+	// the candidate must be the named parameter schema, which must exist
+	code, _ = check("integer-param-schema", candidate("other-param-schema", 7))
+	assert.Equal(t, http.StatusBadRequest, code)
+	code, _ = check("other-param-schema", candidate("other-param-schema", 7))
+	assert.Equal(t, http.StatusNotFound, code)
+}
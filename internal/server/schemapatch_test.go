@@ -0,0 +1,88 @@
+package server
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/mugiliam/hatchcatalogsrv/internal/db"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
+)
+
+func TestJSONPatchCollectionSchema(t *testing.T) {
+	ctx := newDb()
+	t.Cleanup(func() {
+		db.DB(ctx).Close(ctx)
+	})
+	testContextP := createTestObjects(t, ctx)
+	testContext := *testContextP
+
+	loc := "/collectionschemas/valid"
+	patch := func(ops string) (int, string) {
+		httpReq, _ := http.NewRequest("PATCH", loc, nil)
+		setRequestBodyAndHeader(t, httpReq, ops)
+		httpReq.Header.Set("Content-Type", "application/json-patch+json")
+		response := executeTestRequest(t, httpReq, nil, testContext)
+		return response.Code, response.Body.String()
+	}
+	get := func() []byte {
+		httpReq, _ := http.NewRequest("GET", loc, nil)
+		response := executeTestRequest(t, httpReq, nil, testContext)
+		require.Equal(t, http.StatusOK, response.Code)
+		return response.Body.Bytes()
+	}
+
+	// add a parameter
+	code, body := patch(`[{"op": "add", "path": "/spec/parameters/maxBackoff", "value": {"dataType": "Integer", "default": 30}}]`)
+	require.Equal(t, http.StatusOK, code, body)
+	schema := get()
+	assert.Equal(t, "Integer", gjson.GetBytes(schema, "spec.parameters.maxBackoff.dataType").String())
+	assert.Equal(t, int64(30), gjson.GetBytes(schema, "spec.parameters.maxBackoff.default").Int())
+	assert.Equal(t, int64(1000), gjson.GetBytes(schema, "spec.parameters.maxDelay.default").Int())
+
+	// replace a value, guarded by a test of the current one
+	code, body = patch(`[
+		{"op": "test", "path": "/spec/parameters/maxDelay/default", "value": 1000},
+		{"op": "replace", "path": "/spec/parameters/maxDelay/default", "value": 2000}
+	]`)
+	require.Equal(t, http.StatusOK, code, body)
+	assert.Equal(t, int64(2000), gjson.GetBytes(get(), "spec.parameters.maxDelay.default").Int())
+
+	// remove a parameter
+	code, body = patch(`[{"op": "remove", "path": "/spec/parameters/maxBackoff"}]`)
+	require.Equal(t, http.StatusOK, code, body)
+	schema = get()
+	assert.False(t, gjson.GetBytes(schema, "spec.parameters.maxBackoff").Exists())
+	assert.True(t, gjson.GetBytes(schema, "spec.parameters.maxDelay").Exists())
+
+	// patches that cannot be applied conflict with the schema and change nothing
+	code, _ = patch(`[{"op": "remove", "path": "/spec/parameters/missing"}]`)
+	assert.Equal(t, http.StatusConflict, code)
+	code, _ = patch(`[
+		{"op": "replace", "path": "/spec/parameters/maxDelay/default", "value": 3000},
+		{"op": "test", "path": "/spec/parameters/maxDelay/default", "value": 1000}
+	]`)
+	assert.Equal(t, http.StatusConflict, code)
+	assert.Equal(t, int64(2000), gjson.GetBytes(get(), "spec.parameters.maxDelay.default").Int())
+
+	// a patch that produces an invalid schema is rejected
+	code, _ = patch(`[{"op": "replace", "path": "/spec/parameters/maxDelay/dataType", "value": "NoSuchType"}]`)
+	assert.Equal(t, http.StatusBadRequest, code)
+	code, _ = patch(`[{"op": "replace", "path": "/kind", "value": "ParameterSchema"}]`)
+	assert.Equal(t, http.StatusBadRequest, code)
+	assert.Equal(t, "Integer", gjson.GetBytes(get(), "spec.parameters.maxDelay.dataType").String())
+
+	// and so is a malformed patch
+	code, _ = patch(`{"op": "remove", "path": "/spec/parameters/maxDelay"}`)
+	assert.Equal(t, http.StatusBadRequest, code)
+	code, _ = patch(`[{"op": "rename", "path": "/spec/parameters/maxDelay"}]`)
+	assert.Equal(t, http.StatusBadRequest, code)
+
+	// collections take merge patches only
+	httpReq, _ := http.NewRequest("PATCH", "/collections/some-collection", nil)
+	setRequestBodyAndHeader(t, httpReq, `[]`)
+	httpReq.Header.Set("Content-Type", "application/json-patch+json")
+	response := executeTestRequest(t, httpReq, nil, testContext)
+	assert.Equal(t, http.StatusBadRequest, response.Code)
+}
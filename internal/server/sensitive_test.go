@@ -0,0 +1,116 @@
+package server
+
+import (
+	"bytes"
+	"encoding/base64"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mugiliam/hatchcatalogsrv/internal/catalogmanager"
+	"github.com/mugiliam/hatchcatalogsrv/internal/common"
+	"github.com/mugiliam/hatchcatalogsrv/internal/config"
+	"github.com/mugiliam/hatchcatalogsrv/internal/db"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
+)
+
+func TestSensitiveValues(t *testing.T) {
+	ctx := newDb()
+	t.Cleanup(func() {
+		db.DB(ctx).Close(ctx)
+	})
+	testContextP := createTestObjects(t, ctx)
+	testContext := *testContextP
+	ctx = common.SetTenantIdInContext(ctx, testContext.TenantId)
+	ctx = common.SetProjectIdInContext(ctx, testContext.ProjectId)
+
+	send := func(method, url, body string) (int, []byte) {
+		httpReq, _ := http.NewRequest(method, url, nil)
+		if body != "" {
+			setRequestBodyAndHeader(t, httpReq, body)
+		}
+		response := executeTestRequest(t, httpReq, nil, testContext)
+		return response.Code, response.Body.Bytes()
+	}
+	setConfig := func(content string) {
+		file := filepath.Join(t.TempDir(), "config.toml")
+		require.NoError(t, os.WriteFile(file, []byte(content), 0o600))
+		require.NoError(t, config.LoadConfig(file))
+	}
+	t.Cleanup(func() {
+		_ = config.LoadConfig("")
+	})
+
+	schema := `{"version": "v1", "kind": "CollectionSchema", "metadata": {"name": "secret-schema", "path": "/"},
+		"spec": {"parameters": {"pin": {"dataType": "Integer", "sensitive": true}, "port": {"dataType": "Integer"}}}}`
+	collection := `{"version": "v1", "kind": "Collection", "metadata": {"name": "db", "path": "/secrets"},
+		"spec": {"schema": "secret-schema", "values": {"pin": 987654321, "port": 8080}}}`
+
+	// sensitive parameters need a key to encrypt their values with
+	code, body := send("POST", "/collectionschemas", schema)
+	assert.Equal(t, http.StatusBadRequest, code, string(body))
+
+	key := base64.StdEncoding.EncodeToString([]byte("0123456789abcdef0123456789abcdef"))
+	setConfig(`secret_key = "` + key + `"`)
+
+	// and cannot have a default, which would be stored in the schema
+	code, body = send("POST", "/collectionschemas", `{"version": "v1", "kind": "CollectionSchema", "metadata": {"name": "default-schema", "path": "/"},
+		"spec": {"parameters": {"pin": {"dataType": "Integer", "sensitive": true, "default": 1234}}}}`)
+	assert.Equal(t, http.StatusBadRequest, code, string(body))
+
+	code, body = send("POST", "/collectionschemas", schema)
+	require.Equal(t, http.StatusCreated, code, string(body))
+	code, body = send("POST", "/collections", collection)
+	require.Equal(t, http.StatusCreated, code, string(body))
+
+	// values are validated before they are encrypted
+	code, _ = send("POST", "/collections", `{"version": "v1", "kind": "Collection", "metadata": {"name": "bad", "path": "/secrets"},
+		"spec": {"schema": "secret-schema", "values": {"pin": "not a number"}}}`)
+	assert.Equal(t, http.StatusBadRequest, code)
+
+	history := func() []gjson.Result {
+		code, body := send("GET", "/collections/secrets/db?history=true", "")
+		require.Equal(t, http.StatusOK, code, string(body))
+		return gjson.GetBytes(body, "history").Array()
+	}
+	h := history()
+	require.Len(t, h, 1)
+	hash := h[0].Get("hash").String()
+
+	// the stored object does not have the sensitive value in plaintext
+	obj, err := db.DB(ctx).GetCatalogObject(ctx, hash)
+	require.NoError(t, err)
+	assert.False(t, bytes.Contains(obj.Data, []byte("987654321")))
+	assert.True(t, bytes.Contains(obj.Data, []byte("8080")))
+
+	// saving the collection again unchanged keeps its object
+	code, body = send("PUT", "/collections/secrets/db", collection)
+	require.Equal(t, http.StatusOK, code, string(body))
+	h = history()
+	require.Len(t, h, 1)
+	assert.Equal(t, hash, h[0].Get("hash").String())
+
+	// reads redact the value
+	code, body = send("GET", "/collections/secrets/db", "")
+	require.Equal(t, http.StatusOK, code, string(body))
+	assert.Equal(t, catalogmanager.RedactedValue, gjson.GetBytes(body, "spec.values.pin").String())
+	assert.Equal(t, int64(8080), gjson.GetBytes(body, "spec.values.port").Int())
+	code, body = send("GET", "/collections/secrets/db?resolved=true", "")
+	require.Equal(t, http.StatusOK, code, string(body))
+	assert.Equal(t, catalogmanager.RedactedValue, gjson.GetBytes(body, "values.pin").String())
+	assert.NotContains(t, string(body), "987654321")
+
+	// unless they ask to reveal it, which must be allowed
+	code, _ = send("GET", "/collections/secrets/db?reveal=true", "")
+	assert.Equal(t, http.StatusForbidden, code)
+	setConfig(`secret_key = "` + key + `"` + "\nallow_reveal = true\n")
+	code, body = send("GET", "/collections/secrets/db?reveal=true", "")
+	require.Equal(t, http.StatusOK, code, string(body))
+	assert.Equal(t, int64(987654321), gjson.GetBytes(body, "spec.values.pin").Int())
+	code, body = send("GET", "/collections/secrets/db?resolved=true&reveal=true", "")
+	require.Equal(t, http.StatusOK, code, string(body))
+	assert.Equal(t, int64(987654321), gjson.GetBytes(body, "values.pin").Int())
+}
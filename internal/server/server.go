@@ -10,27 +10,44 @@ import (
 	"github.com/mugiliam/common/logtrace"
 	"github.com/mugiliam/hatchcatalogsrv/internal/apis"
 	"github.com/mugiliam/hatchcatalogsrv/internal/config"
+	"github.com/mugiliam/hatchcatalogsrv/internal/metrics"
 	"github.com/mugiliam/hatchcatalogsrv/internal/server/middleware"
+	"github.com/mugiliam/hatchcatalogsrv/internal/tracing"
 	"github.com/mugiliam/hatchcatalogsrv/pkg/api"
 	"github.com/rs/zerolog/log"
 )
 
 type HatchCatalogServer struct {
-	Router *chi.Mux
+	Router  *chi.Mux
+	Metrics *metrics.Metrics
+	openAPI []byte // the OpenAPI description of the routes, made once they are mounted
 }
 
 func CreateNewServer() (*HatchCatalogServer, error) {
 	s := &HatchCatalogServer{}
 	s.Router = chi.NewRouter()
+	s.Metrics = metrics.Default()
 	return s, nil
 }
 
 func (s *HatchCatalogServer) MountHandlers() {
 	s.Router.Use(hatchservicemiddleware.RequestLogger)
+	s.Router.Use(middleware.RequestID)
+	s.Router.Use(tracing.Middleware)
+	s.Router.Use(s.Metrics.Middleware)
 	if config.Config().HandleCORS {
 		s.Router.Use(s.HandleCORS)
 	}
+	s.Router.Method(http.MethodGet, "/metrics", s.Metrics.Handler())
+	s.Router.Get("/healthz", s.getHealth)
+	s.Router.Get("/readyz", s.getReadiness)
+	s.Router.Get("/openapi.json", s.getOpenAPI)
 	s.Router.Route("/", s.mountResourceHandlers)
+	openAPI, err := marshalOpenAPI(s.Router)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to describe the routes")
+	}
+	s.openAPI = openAPI
 	if logtrace.IsTraceEnabled() {
 		//print all the routes in the router by transversing the tree and printing the patterns
 		fmt.Println("Routes in tenant router")
@@ -45,9 +62,16 @@ func (s *HatchCatalogServer) MountHandlers() {
 }
 
 func (s *HatchCatalogServer) mountResourceHandlers(r chi.Router) {
+	// the rate limiter is always installed so that a config reload can turn it on or off. It runs before a
+	// database connection is taken, so that throttled requests don't hold one.
+	rl := middleware.NewRateLimiter(config.Config().RateLimit, nil)
+	config.OnReload(func(c *config.ConfigParam) {
+		rl.SetConfig(c.RateLimit)
+	})
 	r.Use(
-		middleware.LoadScopedDB, // Load the scoped db connection
 		middleware.LoadContext,  // Load the context variables
+		rl.Middleware,           // Limit the request rate of the tenant
+		middleware.LoadScopedDB, // Load the scoped db connection
 	)
 	apis.Router(r)
 	r.Get("/version", s.getVersion)
@@ -66,8 +90,9 @@ func (s *HatchCatalogServer) HandleCORS(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		// Set CORS headers
 		w.Header().Set("Access-Control-Allow-Origin", "http://localhost:8190")
-		w.Header().Set("Access-Control-Allow-Methods", "POST, GET, OPTIONS, PUT, DELETE")                                                       // Allowed methods
-		w.Header().Set("Access-Control-Allow-Headers", "Accept, Content-Type, Content-Length, Accept-Encoding, Authorization, X-Hatch-IDToken") // Allowed headers
+		w.Header().Set("Access-Control-Allow-Methods", "POST, GET, HEAD, OPTIONS, PUT, PATCH, DELETE")                                                                         // Allowed methods
+		w.Header().Set("Access-Control-Allow-Headers", "Accept, Content-Type, Content-Length, Accept-Encoding, Authorization, X-Hatch-IDToken, Idempotency-Key, X-Request-ID") // Allowed headers
+		w.Header().Set("Access-Control-Expose-Headers", "X-Request-ID")
 
 		// Check if the request method is OPTIONS
 		if r.Method == "OPTIONS" {
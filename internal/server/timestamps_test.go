@@ -0,0 +1,109 @@
+package server
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/mugiliam/hatchcatalogsrv/internal/db"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+	"sigs.k8s.io/yaml"
+)
+
+func TestTimestamps(t *testing.T) {
+	ctx := newDb()
+	t.Cleanup(func() {
+		db.DB(ctx).Close(ctx)
+	})
+	testContextP := createTestObjects(t, ctx)
+	testContext := *testContextP
+
+	get := func(loc string) gjson.Result {
+		httpReq, _ := http.NewRequest("GET", loc, nil)
+		response := executeTestRequest(t, httpReq, nil, testContext)
+		if !assert.Equal(t, http.StatusOK, response.Code) {
+			t.Logf("Response: %v", response.Body.String())
+			t.FailNow()
+		}
+		return gjson.ParseBytes(response.Body.Bytes())
+	}
+	put := func(loc string, body string) {
+		httpReq, _ := http.NewRequest("PUT", loc, nil)
+		setRequestBodyAndHeader(t, httpReq, body)
+		response := executeTestRequest(t, httpReq, nil, testContext)
+		if !assert.Equal(t, http.StatusOK, response.Code) {
+			t.Logf("Response: %v", response.Body.String())
+			t.FailNow()
+		}
+	}
+
+	// checkUpdates saves the object unchanged and then with a new description, and checks that only the
+	// second save advances updatedAt
+	checkUpdates := func(loc string) {
+		rsp := get(loc)
+		createdAt := rsp.Get("metadata.createdAt").Time()
+		updatedAt := rsp.Get("metadata.updatedAt").Time()
+		require.False(t, createdAt.IsZero(), loc)
+		require.False(t, updatedAt.IsZero(), loc)
+
+		time.Sleep(10 * time.Millisecond)
+		put(loc, rsp.Raw)
+		rsp = get(loc)
+		assert.True(t, updatedAt.Equal(rsp.Get("metadata.updatedAt").Time()), loc)
+
+		body, _ := sjson.Set(rsp.Raw, "metadata.description", "This description was changed")
+		put(loc, body)
+		rsp = get(loc)
+		assert.True(t, createdAt.Equal(rsp.Get("metadata.createdAt").Time()), loc)
+		assert.True(t, rsp.Get("metadata.updatedAt").Time().After(updatedAt), loc)
+	}
+	checkUpdates("/catalogs/valid-catalog")
+	checkUpdates("/variants/valid-variant")
+	checkUpdates("/workspaces/valid-workspace")
+
+	// collections
+	reqYaml := `
+		version: v1
+		kind: Collection
+		metadata:
+			name: my-collection
+			path: /some/path
+		spec:
+			schema: valid
+			values:
+				maxRetries: 3
+	`
+	replaceTabsWithSpaces(&reqYaml)
+	reqJson, err := yaml.YAMLToJSON([]byte(reqYaml))
+	require.NoError(t, err)
+	httpReq, _ := http.NewRequest("POST", "/collections", nil)
+	setRequestBodyAndHeader(t, httpReq, string(reqJson))
+	response := executeTestRequest(t, httpReq, nil, testContext)
+	if !assert.Equal(t, http.StatusCreated, response.Code) {
+		t.Logf("Response: %v", response.Body.String())
+		t.FailNow()
+	}
+
+	loc := "/collections/some/path/my-collection"
+	rsp := get(loc)
+	createdAt := rsp.Get("metadata.createdAt").Time()
+	updatedAt := rsp.Get("metadata.updatedAt").Time()
+	require.False(t, createdAt.IsZero())
+	assert.True(t, createdAt.Equal(updatedAt))
+
+	// saving the same values does not advance updatedAt
+	time.Sleep(10 * time.Millisecond)
+	put(loc, string(reqJson))
+	rsp = get(loc)
+	assert.True(t, updatedAt.Equal(rsp.Get("metadata.updatedAt").Time()))
+
+	// changing a value does
+	reqJson, _ = sjson.SetBytes(reqJson, "spec.values.maxRetries", 5)
+	put(loc, string(reqJson))
+	rsp = get(loc)
+	assert.True(t, createdAt.Equal(rsp.Get("metadata.createdAt").Time()))
+	assert.True(t, rsp.Get("metadata.updatedAt").Time().After(updatedAt))
+}
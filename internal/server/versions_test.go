@@ -0,0 +1,231 @@
+package server
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgtype"
+	"github.com/mugiliam/hatchcatalogsrv/internal/common"
+	"github.com/mugiliam/hatchcatalogsrv/internal/db"
+	"github.com/mugiliam/hatchcatalogsrv/internal/db/models"
+	"github.com/mugiliam/hatchcatalogsrv/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+func TestListVersions(t *testing.T) {
+	ctx := newDb()
+	t.Cleanup(func() {
+		db.DB(ctx).Close(ctx)
+	})
+	testContextP := createTestObjects(t, ctx)
+	testContext := *testContextP
+
+	ctx = common.SetTenantIdInContext(ctx, testContext.TenantId)
+	ctx = common.SetProjectIdInContext(ctx, testContext.ProjectId)
+	cat, err := db.DB(ctx).GetCatalog(ctx, uuid.Nil, "valid-catalog")
+	require.NoError(t, err)
+	variant, err := db.DB(ctx).GetVariant(ctx, cat.CatalogID, uuid.Nil, "valid-variant")
+	require.NoError(t, err)
+	// a labeled and an unlabeled version after the "init" version
+	for _, v := range []models.Version{
+		{Label: "release-1", Description: "first release"},
+		{},
+	} {
+		v.Info = pgtype.JSONB{Status: pgtype.Null}
+		v.VariantID = variant.VariantID
+		require.NoError(t, db.DB(ctx).CreateVersion(ctx, &v))
+	}
+
+	get := func(target string, expectedStatus int) []byte {
+		httpReq, _ := http.NewRequest("GET", target, nil)
+		response := executeTestRequest(t, httpReq, nil, testContext)
+		if !assert.Equal(t, expectedStatus, response.Code) {
+			t.Logf("Response: %v", response.Body.String())
+			t.FailNow()
+		}
+		return response.Body.Bytes()
+	}
+
+	rsp := get("/variants/valid-variant/versions", http.StatusOK)
+	assert.Equal(t, "valid-variant", gjson.GetBytes(rsp, "variant").String())
+	assert.Equal(t, int64(3), gjson.GetBytes(rsp, "count").Int())
+	versions := gjson.GetBytes(rsp, "versions").Array()
+	if assert.Len(t, versions, 3) {
+		for i, v := range versions {
+			assert.Equal(t, int64(i+1), v.Get("version").Int())
+			assert.True(t, v.Get("createdAt").Exists())
+		}
+		assert.Equal(t, "init", versions[0].Get("label").String())
+		assert.Equal(t, "release-1", versions[1].Get("label").String())
+		assert.Equal(t, "first release", versions[1].Get("description").String())
+		assert.False(t, versions[2].Get("label").Exists())
+	}
+
+	// only labeled versions are listed, but the count is still of all versions
+	rsp = get("/variants/valid-variant/versions?named=true", http.StatusOK)
+	assert.Equal(t, int64(3), gjson.GetBytes(rsp, "count").Int())
+	versions = gjson.GetBytes(rsp, "versions").Array()
+	if assert.Len(t, versions, 2) {
+		assert.Equal(t, "init", versions[0].Get("label").String())
+		assert.Equal(t, "release-1", versions[1].Get("label").String())
+	}
+	get("/variants/valid-variant/versions?named=maybe", http.StatusBadRequest)
+
+	// resolving a version by its label
+	rsp = get("/variants/valid-variant/versions/release-1", http.StatusOK)
+	assert.Equal(t, int64(2), gjson.GetBytes(rsp, "version").Int())
+	assert.Equal(t, "release-1", gjson.GetBytes(rsp, "label").String())
+	assert.Equal(t, "first release", gjson.GetBytes(rsp, "description").String())
+	get("/variants/valid-variant/versions/no-such-label", http.StatusNotFound)
+
+	// a variant that does not exist
+	get("/variants/no-such-variant/versions", http.StatusNotFound)
+}
+
+func TestSetVersionLabel(t *testing.T) {
+	ctx := newDb()
+	t.Cleanup(func() {
+		db.DB(ctx).Close(ctx)
+	})
+	testContextP := createTestObjects(t, ctx)
+	testContext := *testContextP
+
+	ctx = common.SetTenantIdInContext(ctx, testContext.TenantId)
+	ctx = common.SetProjectIdInContext(ctx, testContext.ProjectId)
+	cat, err := db.DB(ctx).GetCatalog(ctx, uuid.Nil, "valid-catalog")
+	require.NoError(t, err)
+	variant, err := db.DB(ctx).GetVariant(ctx, cat.CatalogID, uuid.Nil, "valid-variant")
+	require.NoError(t, err)
+	// two unlabeled versions after the "init" version
+	for i := 0; i < 2; i++ {
+		v := models.Version{Info: pgtype.JSONB{Status: pgtype.Null}, VariantID: variant.VariantID}
+		require.NoError(t, db.DB(ctx).CreateVersion(ctx, &v))
+	}
+
+	setLabel := func(target, label string, expectedStatus int) []byte {
+		httpReq, _ := http.NewRequest("PUT", target, nil)
+		setRequestBodyAndHeader(t, httpReq, `{"label":"`+label+`"}`)
+		response := executeTestRequest(t, httpReq, nil, testContext)
+		if !assert.Equal(t, expectedStatus, response.Code) {
+			t.Logf("Response: %v", response.Body.String())
+			t.FailNow()
+		}
+		return response.Body.Bytes()
+	}
+	labeledVersion := func(label string) int64 {
+		httpReq, _ := http.NewRequest("GET", "/variants/valid-variant/versions/"+label, nil)
+		response := executeTestRequest(t, httpReq, nil, testContext)
+		require.Equal(t, http.StatusOK, response.Code)
+		return gjson.GetBytes(response.Body.Bytes(), "version").Int()
+	}
+
+	rsp := setLabel("/variants/valid-variant/versions/2/label", "v2", http.StatusOK)
+	assert.Equal(t, int64(2), gjson.GetBytes(rsp, "version").Int())
+	assert.Equal(t, "v2", gjson.GetBytes(rsp, "label").String())
+	assert.Equal(t, int64(2), labeledVersion("v2"))
+
+	// the label is on another version
+	setLabel("/variants/valid-variant/versions/3/label", "v2", http.StatusConflict)
+	assert.Equal(t, int64(2), labeledVersion("v2"))
+
+	// force moves it
+	setLabel("/variants/valid-variant/versions/3/label?force=true", "v2", http.StatusOK)
+	assert.Equal(t, int64(3), labeledVersion("v2"))
+
+	// a failed move leaves the label where it was
+	setLabel("/variants/valid-variant/versions/99/label?force=true", "v2", http.StatusNotFound)
+	assert.Equal(t, int64(3), labeledVersion("v2"))
+
+	// labels that do not match the allowed format
+	setLabel("/variants/valid-variant/versions/2/label", "not a label!", http.StatusBadRequest)
+	setLabel("/variants/valid-variant/versions/2/label", "", http.StatusBadRequest)
+
+	// the version must be a number
+	setLabel("/variants/valid-variant/versions/latest/label", "v4", http.StatusBadRequest)
+}
+
+func TestGetObjectAtVersion(t *testing.T) {
+	ctx := newDb()
+	t.Cleanup(func() {
+		db.DB(ctx).Close(ctx)
+	})
+	testContextP := createTestObjects(t, ctx)
+	testContext := *testContextP
+
+	ctx = common.SetTenantIdInContext(ctx, testContext.TenantId)
+	ctx = common.SetProjectIdInContext(ctx, testContext.ProjectId)
+	cat, err := db.DB(ctx).GetCatalog(ctx, uuid.Nil, "valid-catalog")
+	require.NoError(t, err)
+	variant, err := db.DB(ctx).GetVariant(ctx, cat.CatalogID, uuid.Nil, "valid-variant")
+	require.NoError(t, err)
+
+	do := func(method, target, body string) (int, []byte) {
+		httpReq, _ := http.NewRequest(method, target, nil)
+		if body != "" {
+			setRequestBodyAndHeader(t, httpReq, body)
+		}
+		response := executeTestRequest(t, httpReq, nil, testContext)
+		return response.Code, response.Body.Bytes()
+	}
+	code, body := do("POST", "/collections", `{"version": "v1", "kind": "Collection", "metadata": {"name": "my-collection", "path": "/"},
+		"spec": {"schema": "valid", "values": {"maxRetries": 3}}}`)
+	require.Equal(t, http.StatusCreated, code, string(body))
+
+	// version 2 is a snapshot of the directories of the workspace, where the test objects are, as they are now
+	v := models.Version{Info: pgtype.JSONB{Status: pgtype.Null}, VariantID: variant.VariantID}
+	require.NoError(t, db.DB(ctx).CreateVersion(ctx, &v))
+	current, err := db.DB(ctx).GetWorkspaceByLabel(ctx, variant.VariantID, testContext.CatalogContext.WorkspaceLabel)
+	require.NoError(t, err)
+	snapshot, err := db.DB(ctx).GetVersion(ctx, v.VersionNum, variant.VariantID)
+	require.NoError(t, err)
+	for _, d := range []struct {
+		t        types.CatalogObjectType
+		from, to uuid.UUID
+	}{
+		{types.CatalogObjectTypeParameterSchema, current.ParametersDir, snapshot.ParametersDir},
+		{types.CatalogObjectTypeCollectionSchema, current.CollectionsDir, snapshot.CollectionsDir},
+		{types.CatalogObjectTypeCatalogCollection, current.ValuesDir, snapshot.ValuesDir},
+	} {
+		dir, err := db.DB(ctx).GetDirectory(ctx, d.t, d.from)
+		require.NoError(t, err)
+		require.NoError(t, db.DB(ctx).SetDirectory(ctx, d.t, d.to, dir))
+	}
+
+	// the workspace moves on
+	code, body = do("GET", "/collections/my-collection", "")
+	require.Equal(t, http.StatusOK, code, string(body))
+	changed, _ := sjson.SetBytes(body, "spec.values.maxRetries", 5)
+	code, body = do("PUT", "/collections/my-collection", string(changed))
+	require.Equal(t, http.StatusOK, code, string(body))
+	code, body = do("POST", "/collectionschemas", `{"version": "v1", "kind": "CollectionSchema", "metadata": {"name": "later", "path": "/"},
+		"spec": {"parameters": {"p": {"dataType": "Integer"}}}}`)
+	require.Equal(t, http.StatusCreated, code, string(body))
+
+	// objects are read as they were at the version
+	code, body = do("GET", "/collections/my-collection?version=2", "")
+	require.Equal(t, http.StatusOK, code, string(body))
+	assert.Equal(t, int64(3), gjson.GetBytes(body, "spec.values.maxRetries").Int())
+	code, body = do("GET", "/collections/my-collection", "")
+	require.Equal(t, http.StatusOK, code, string(body))
+	assert.Equal(t, int64(5), gjson.GetBytes(body, "spec.values.maxRetries").Int())
+	code, body = do("GET", "/collectionschemas/valid?version=2", "")
+	assert.Equal(t, http.StatusOK, code, string(body))
+	code, body = do("GET", "/parameterschemas/integer-param-schema?version=2", "")
+	assert.Equal(t, http.StatusOK, code, string(body))
+
+	// an object that did not exist at the version is not found
+	code, body = do("GET", "/collectionschemas/later", "")
+	assert.Equal(t, http.StatusOK, code, string(body))
+	code, _ = do("GET", "/collectionschemas/later?version=2", "")
+	assert.Equal(t, http.StatusNotFound, code)
+
+	// and a version that does not exist is a bad request
+	code, _ = do("GET", "/collectionschemas/valid?version=99", "")
+	assert.Equal(t, http.StatusBadRequest, code)
+	code, _ = do("GET", "/collectionschemas/valid?version=latest", "")
+	assert.Equal(t, http.StatusBadRequest, code)
+}
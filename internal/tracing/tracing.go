@@ -0,0 +1,150 @@
+// Package tracing provides optional OpenTelemetry spans for the catalog server. Tracing stays off unless an
+// exporter endpoint is configured; until then Start and Middleware only check a flag and add no overhead.
+package tracing
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+
+	"github.com/go-chi/chi/v5"
+	chimw "github.com/go-chi/chi/v5/middleware"
+	"github.com/mugiliam/hatchcatalogsrv/internal/common"
+	"github.com/mugiliam/hatchcatalogsrv/internal/config"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const instrumentationName = "github.com/mugiliam/hatchcatalogsrv"
+
+// DefaultServiceName is reported when tracing.service_name is not configured
+const DefaultServiceName = "hatchcatalogsrv"
+
+var (
+	enabled  atomic.Bool
+	tracer   trace.Tracer
+	noopSpan = trace.SpanFromContext(context.Background())
+)
+
+// Init sets up the exporter and tracer provider from the config. It does nothing if no endpoint is configured.
+// The returned function flushes pending spans and must be called before the process exits.
+func Init(ctx context.Context, c config.TracingConfig) (func(context.Context) error, error) {
+	if c.Endpoint == "" {
+		return func(context.Context) error { return nil }, nil
+	}
+	exp, err := otlptracehttp.New(ctx, otlptracehttp.WithEndpointURL(c.Endpoint))
+	if err != nil {
+		return nil, err
+	}
+	serviceName := c.ServiceName
+	if serviceName == "" {
+		serviceName = DefaultServiceName
+	}
+	sampleRate := c.SampleRate
+	if sampleRate <= 0 {
+		sampleRate = 1
+	}
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exp),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(sampleRate))),
+		sdktrace.WithResource(resource.NewSchemaless(attribute.String("service.name", serviceName))),
+	)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	setTracerProvider(tp)
+	return func(ctx context.Context) error {
+		enabled.Store(false)
+		return tp.Shutdown(ctx)
+	}, nil
+}
+
+// setTracerProvider turns tracing on with tp. Tests use it to install an in-memory exporter.
+func setTracerProvider(tp trace.TracerProvider) {
+	otel.SetTracerProvider(tp)
+	tracer = tp.Tracer(instrumentationName)
+	enabled.Store(true)
+}
+
+// Enabled reports whether spans are being recorded
+func Enabled() bool {
+	return enabled.Load()
+}
+
+// Start starts a child span of the span in ctx, tagged with the tenant, catalog and variant of the request.
+// When tracing is off it returns ctx unchanged and a span that does nothing.
+func Start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	if !enabled.Load() {
+		return ctx, noopSpan
+	}
+	attrs = append(attrs, contextAttributes(ctx)...)
+	return tracer.Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// Annotate adds the tenant, catalog and variant in ctx to the current span. The request span is started before
+// they are known, so the context loader calls this once the handler has resolved them.
+func Annotate(ctx context.Context) {
+	if !enabled.Load() {
+		return
+	}
+	trace.SpanFromContext(ctx).SetAttributes(contextAttributes(ctx)...)
+}
+
+// Middleware starts a server span for every request, continuing any trace propagated by the client
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !enabled.Load() {
+			next.ServeHTTP(w, r)
+			return
+		}
+		ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+		ctx, span := tracer.Start(ctx, r.Method+" "+r.URL.Path,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				attribute.String("http.method", r.Method),
+				attribute.String("http.target", r.URL.Path),
+			),
+		)
+		defer span.End()
+		if requestId := common.RequestIdFromContext(ctx); requestId != "" {
+			span.SetAttributes(attribute.String("request.id", requestId))
+		}
+
+		ww := chimw.NewWrapResponseWriter(w, r.ProtoMajor)
+		next.ServeHTTP(ww, r.WithContext(ctx))
+
+		// name the span after the matched route so that spans of the same endpoint group together
+		if rctx := chi.RouteContext(r.Context()); rctx != nil {
+			if pattern := rctx.RoutePattern(); pattern != "" {
+				span.SetName(r.Method + " " + pattern)
+				span.SetAttributes(attribute.String("http.route", pattern))
+			}
+		}
+		status := ww.Status()
+		if status == 0 {
+			status = http.StatusOK
+		}
+		span.SetAttributes(attribute.Int("http.status_code", status))
+		if status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(status))
+		}
+	})
+}
+
+func contextAttributes(ctx context.Context) []attribute.KeyValue {
+	var attrs []attribute.KeyValue
+	if tenantId := common.TenantIdFromContext(ctx); tenantId != "" {
+		attrs = append(attrs, attribute.String("tenant", string(tenantId)))
+	}
+	if catalog := common.GetCatalogFromContext(ctx); catalog != "" {
+		attrs = append(attrs, attribute.String("catalog", catalog))
+	}
+	if variant := common.GetVariantFromContext(ctx); variant != "" {
+		attrs = append(attrs, attribute.String("variant", variant))
+	}
+	return attrs
+}
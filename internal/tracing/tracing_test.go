@@ -0,0 +1,58 @@
+package tracing
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/mugiliam/hatchcatalogsrv/internal/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestStartDisabled(t *testing.T) {
+	ctx := context.Background()
+	spanCtx, span := Start(ctx, "op")
+	assert.Equal(t, ctx, spanCtx)
+	assert.False(t, span.IsRecording())
+	span.End()
+}
+
+func TestSpans(t *testing.T) {
+	exp := tracetest.NewInMemoryExporter()
+	setTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSyncer(exp)))
+	t.Cleanup(func() { enabled.Store(false) })
+
+	r := chi.NewRouter()
+	r.Use(Middleware)
+	r.Get("/catalogs/{catalogName}", func(w http.ResponseWriter, r *http.Request) {
+		ctx := common.SetTenantIdInContext(r.Context(), "TABCDE")
+		ctx = common.SetCatalogContext(ctx, &common.CatalogContext{Catalog: "my-catalog", Variant: "my-variant"})
+		_, span := Start(ctx, "catalogmanager.SaveSchema", attribute.String("name", "s1"))
+		span.End()
+		Annotate(ctx)
+		w.WriteHeader(http.StatusNotFound)
+	})
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/catalogs/my-catalog", nil))
+
+	spans := exp.GetSpans()
+	require.Len(t, spans, 2)
+	child, root := spans[0], spans[1]
+	assert.Equal(t, "catalogmanager.SaveSchema", child.Name)
+	assert.Equal(t, root.SpanContext.SpanID(), child.Parent.SpanID())
+	assert.Contains(t, child.Attributes, attribute.String("name", "s1"))
+	assert.Contains(t, child.Attributes, attribute.String("tenant", "TABCDE"))
+	assert.Contains(t, child.Attributes, attribute.String("catalog", "my-catalog"))
+	assert.Contains(t, child.Attributes, attribute.String("variant", "my-variant"))
+
+	assert.Equal(t, "GET /catalogs/{catalogName}", root.Name)
+	assert.Equal(t, trace.SpanKindServer, root.SpanKind)
+	assert.Contains(t, root.Attributes, attribute.String("tenant", "TABCDE"))
+	assert.Contains(t, root.Attributes, attribute.Int("http.status_code", http.StatusNotFound))
+}
@@ -0,0 +1,213 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/mugiliam/hatchcatalogsrv/internal/common"
+	"github.com/mugiliam/hatchcatalogsrv/internal/config"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+// SignatureHeader carries the hex encoded HMAC-SHA256 of the request body, keyed by the configured secret
+const SignatureHeader = "X-Hatch-Signature"
+
+const (
+	ActionCreate = "create"
+	ActionUpdate = "update"
+	ActionDelete = "delete"
+)
+
+const (
+	defaultMaxRetries = 3
+	queueSize         = 1024
+	requestTimeout    = 10 * time.Second
+)
+
+// Event is the payload posted to every configured webhook URL
+type Event struct {
+	Action     string    `json:"action"`
+	ObjectType string    `json:"objectType"`
+	Path       string    `json:"path"`
+	Tenant     string    `json:"tenant,omitempty"`
+	Catalog    string    `json:"catalog,omitempty"`
+	Variant    string    `json:"variant,omitempty"`
+	Workspace  string    `json:"workspace,omitempty"`
+	Hash       string    `json:"hash,omitempty"`
+	Timestamp  time.Time `json:"timestamp"`
+}
+
+type delivery struct {
+	body   []byte
+	logger zerolog.Logger
+}
+
+// Dispatcher delivers events asynchronously to a set of URLs. Every URL has its own queue and worker, so a
+// slow or failing URL does not hold up deliveries to the others.
+type Dispatcher struct {
+	urls       []string
+	secret     []byte
+	maxRetries int
+	backoff    time.Duration
+	client     *http.Client
+	queues     []chan delivery
+	mu         sync.RWMutex
+	closed     bool
+}
+
+// NewDispatcher creates a dispatcher and starts a delivery worker for each URL. Events are retried up to
+// maxRetries times with exponential backoff starting at backoff.
+func NewDispatcher(urls []string, secret string, maxRetries int, backoff time.Duration) *Dispatcher {
+	if maxRetries < 0 {
+		maxRetries = 0
+	}
+	d := &Dispatcher{
+		urls:       urls,
+		secret:     []byte(secret),
+		maxRetries: maxRetries,
+		backoff:    backoff,
+		client:     &http.Client{Timeout: requestTimeout},
+	}
+	for _, url := range urls {
+		q := make(chan delivery, queueSize)
+		d.queues = append(d.queues, q)
+		go d.run(url, q)
+	}
+	return d
+}
+
+// Notify queues the event for delivery to every URL. It never blocks; if the queue of a URL is full the
+// event is dropped for that URL.
+func (d *Dispatcher) Notify(ctx context.Context, e Event) {
+	if d == nil || len(d.urls) == 0 {
+		return
+	}
+	if e.Timestamp.IsZero() {
+		e.Timestamp = time.Now().UTC()
+	}
+	if e.Tenant == "" {
+		e.Tenant = string(common.TenantIdFromContext(ctx))
+	}
+	body, err := json.Marshal(e)
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Msg("failed to marshal webhook event")
+		return
+	}
+
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	if d.closed {
+		return
+	}
+	for i, q := range d.queues {
+		select {
+		case q <- delivery{body: body, logger: *log.Ctx(ctx)}:
+		default:
+			log.Ctx(ctx).Error().Str("url", d.urls[i]).Str("path", e.Path).Str("action", e.Action).Msg("webhook queue full, dropping event")
+		}
+	}
+}
+
+// Close stops the delivery workers once the queued events are delivered. Events notified after Close are
+// dropped.
+func (d *Dispatcher) Close() {
+	if d == nil {
+		return
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.closed {
+		return
+	}
+	d.closed = true
+	for _, q := range d.queues {
+		close(q)
+	}
+}
+
+// run delivers the events queued on q to url, one at a time
+func (d *Dispatcher) run(url string, q chan delivery) {
+	for dl := range q {
+		d.deliver(dl.logger, url, dl.body)
+	}
+}
+
+// Sign returns the hex encoded HMAC-SHA256 of body keyed by secret
+func Sign(secret, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+var (
+	dispatcherMu     sync.Mutex
+	dispatcher       *Dispatcher
+	dispatcherLoaded bool
+)
+
+func init() {
+	config.OnReload(reconfigure)
+}
+
+// Notify queues the event on the dispatcher configured from config.Config(). It is a no-op
+// if no webhook URLs are configured.
+func Notify(ctx context.Context, e Event) {
+	dispatcherMu.Lock()
+	defer dispatcherMu.Unlock()
+	if !dispatcherLoaded {
+		dispatcher = dispatcherFromConfig(config.Config())
+		dispatcherLoaded = true
+	}
+	dispatcher.Notify(ctx, e)
+}
+
+// reconfigure replaces the dispatcher if the webhook settings in cfg differ from the current ones. Events
+// already queued are delivered with the settings they were queued with.
+func reconfigure(cfg *config.ConfigParam) {
+	dispatcherMu.Lock()
+	defer dispatcherMu.Unlock()
+	if !dispatcherLoaded {
+		return // created from the current config on the first Notify
+	}
+	urls, secret, retries := webhookSettings(cfg)
+	if dispatcher.hasSettings(urls, secret, retries) {
+		return
+	}
+	dispatcher.Close()
+	dispatcher = dispatcherFromConfig(cfg)
+}
+
+func webhookSettings(cfg *config.ConfigParam) (urls []string, secret string, retries int) {
+	if cfg == nil {
+		return nil, "", 0
+	}
+	retries = defaultMaxRetries
+	if cfg.WebhookMaxRetries != nil {
+		retries = *cfg.WebhookMaxRetries
+	}
+	return cfg.WebhookURLs, cfg.WebhookSecret, retries
+}
+
+func dispatcherFromConfig(cfg *config.ConfigParam) *Dispatcher {
+	urls, secret, retries := webhookSettings(cfg)
+	if len(urls) == 0 {
+		return nil
+	}
+	return NewDispatcher(urls, secret, retries, time.Second)
+}
+
+func (d *Dispatcher) hasSettings(urls []string, secret string, maxRetries int) bool {
+	if d == nil {
+		return len(urls) == 0
+	}
+	return slices.Equal(d.urls, urls) && string(d.secret) == secret && d.maxRetries == maxRetries
+}
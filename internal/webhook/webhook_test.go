@@ -0,0 +1,113 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mugiliam/hatchcatalogsrv/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDispatcher(t *testing.T) {
+	var attempts atomic.Int32
+	received := make(chan Event, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		assert.Equal(t, "sha256="+Sign([]byte("secret"), body), r.Header.Get(SignatureHeader))
+		// fail the first attempt to exercise retries
+		if attempts.Add(1) == 1 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		var e Event
+		assert.NoError(t, json.Unmarshal(body, &e))
+		received <- e
+	}))
+	defer srv.Close()
+
+	d := NewDispatcher([]string{srv.URL}, "secret", 2, time.Millisecond)
+	d.Notify(context.Background(), Event{
+		Action:     ActionCreate,
+		ObjectType: "collection",
+		Path:       "/--root--/a/b",
+		Catalog:    "my-catalog",
+		Hash:       "abc",
+	})
+
+	select {
+	case e := <-received:
+		assert.Equal(t, ActionCreate, e.Action)
+		assert.Equal(t, "/--root--/a/b", e.Path)
+		assert.Equal(t, "abc", e.Hash)
+		assert.False(t, e.Timestamp.IsZero())
+	case <-time.After(5 * time.Second):
+		require.Fail(t, "webhook not delivered")
+	}
+	assert.Equal(t, int32(2), attempts.Load())
+}
+
+func TestDispatcherGivesUp(t *testing.T) {
+	var attempts atomic.Int32
+	done := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if attempts.Add(1) == 3 {
+			close(done)
+		}
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer srv.Close()
+
+	d := NewDispatcher([]string{srv.URL}, "", 2, time.Millisecond)
+	d.Notify(context.Background(), Event{Action: ActionDelete, Path: "/x"})
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		require.Fail(t, "retries not attempted")
+	}
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, int32(3), attempts.Load())
+}
+
+func TestDispatcherSlowURL(t *testing.T) {
+	release := make(chan struct{})
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer slow.Close()
+	defer close(release)
+	received := make(chan struct{}, 2)
+	fast := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received <- struct{}{}
+	}))
+	defer fast.Close()
+
+	// deliveries to the fast URL are not held up by the slow one
+	d := NewDispatcher([]string{slow.URL, fast.URL}, "", 0, time.Millisecond)
+	d.Notify(context.Background(), Event{Action: ActionCreate, Path: "/a"})
+	d.Notify(context.Background(), Event{Action: ActionCreate, Path: "/b"})
+	for i := 0; i < 2; i++ {
+		select {
+		case <-received:
+		case <-time.After(5 * time.Second):
+			require.Fail(t, "webhook not delivered")
+		}
+	}
+}
+
+func TestWebhookSettings(t *testing.T) {
+	_, _, retries := webhookSettings(&config.ConfigParam{})
+	assert.Equal(t, defaultMaxRetries, retries)
+
+	// retries can be turned off
+	zero := 0
+	_, _, retries = webhookSettings(&config.ConfigParam{WebhookMaxRetries: &zero})
+	assert.Equal(t, 0, retries)
+}
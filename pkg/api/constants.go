@@ -2,3 +2,13 @@ package api
 
 const Success = 1
 const Failure = 0
+
+// Headers the client sends to identify the tenant and project of a request. The server does not take the tenant
+// and project from them until requests are authenticated.
+const (
+	TenantIDHeader  = "X-Hatch-Tenant-ID"
+	ProjectIDHeader = "X-Hatch-Project-ID"
+)
+
+// RequestIDHeader carries the correlation ID of a request. It is generated by the server when the client does not supply one
+const RequestIDHeader = "X-Request-ID"
@@ -19,12 +19,20 @@ type SchemaStorageRepresentation struct {
 	Entropy     []byte                  `json:"entropy,omitempty"`
 }
 
-// Serialize converts the SchemaStorageRepresentation to a JSON byte array
+// Serialize converts the SchemaStorageRepresentation to a canonical JSON byte array. Object keys are sorted
+// at every level, including the parameter and value maps in the schema, so the output does not depend on
+// the order of keys in the source document or on map iteration order. Arrays keep their order since it is
+// significant (e.g. enumerations); parameters are always keyed by name and are therefore covered by the
+// key sort.
 func (s *SchemaStorageRepresentation) Serialize() ([]byte, apperrors.Error) {
 	j, err := json.Marshal(s)
 	if err != nil {
 		return nil, validationerrors.ErrSchemaSerialization
 	}
+	j, err = NormalizeJSON(j)
+	if err != nil {
+		return nil, validationerrors.ErrSchemaSerialization
+	}
 	return j, nil
 }
 
@@ -36,19 +44,14 @@ func (s *SchemaStorageRepresentation) SetEntropy(entropy []byte) {
 	s.Entropy = entropy
 }
 
-// GetHash returns the SHA-512 hash of the normalized SchemaStorageRepresentation
+// GetHash returns the SHA-512 hash of the canonical serialization, so 2 equivalent representations
+// yield the same hash
 func (s *SchemaStorageRepresentation) GetHash() string {
 	sz, err := s.Serialize()
 	if err != nil {
 		return ""
 	}
-	// Normalize the JSON, so 2 equivalent representations yield the same hash
-	nsz, e := NormalizeJSON(sz)
-	if e != nil {
-		return ""
-	}
-	hash := HexEncodedSHA512(nsz)
-	return hash
+	return HexEncodedSHA512(sz)
 }
 
 // Size returns the approximate size of the SchemaStorageRepresentation in bytes
@@ -0,0 +1,384 @@
+// Package client is a Go client for the catalog server HTTP API.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/mugiliam/hatchcatalogsrv/pkg/api"
+	"github.com/mugiliam/hatchcatalogsrv/pkg/types"
+	"github.com/tidwall/gjson"
+)
+
+// Scope selects the catalog, variant, namespace and workspace that requests operate on.
+// Empty fields are not sent.
+type Scope struct {
+	Catalog   string
+	Variant   string
+	Namespace string
+	Workspace string
+}
+
+// Client issues requests against a catalog server on behalf of a tenant and project
+type Client struct {
+	baseURL    *url.URL
+	httpClient *http.Client
+	tenantID   string
+	projectID  string
+	scope      Scope
+}
+
+type Option func(*Client)
+
+// WithHTTPClient sets the http.Client used for requests, for example to configure TLS or timeouts
+func WithHTTPClient(hc *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = hc
+	}
+}
+
+// WithScope sets the scope of the client
+func WithScope(s Scope) Option {
+	return func(c *Client) {
+		c.scope = s
+	}
+}
+
+// New returns a client for the server at baseURL
+func New(baseURL, tenantID, projectID string, opts ...Option) (*Client, error) {
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme == "" || u.Host == "" {
+		return nil, errors.New("base url must be absolute")
+	}
+	c := &Client{
+		baseURL:    u,
+		httpClient: http.DefaultClient,
+		tenantID:   tenantID,
+		projectID:  projectID,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c, nil
+}
+
+// In returns a copy of the client that operates on scope s
+func (c *Client) In(s Scope) *Client {
+	cc := *c
+	cc.scope = s
+	return &cc
+}
+
+// Scope returns the scope of the client
+func (c *Client) Scope() Scope {
+	return c.scope
+}
+
+// Catalogs
+
+func (c *Client) CreateCatalog(ctx context.Context, catalog any) (string, error) {
+	return c.create(ctx, types.ResourceNameCatalogs, catalog)
+}
+
+func (c *Client) GetCatalog(ctx context.Context, name string) (json.RawMessage, error) {
+	return c.get(ctx, resourcePath(types.ResourceNameCatalogs, name))
+}
+
+func (c *Client) UpdateCatalog(ctx context.Context, name string, catalog any) error {
+	return c.update(ctx, resourcePath(types.ResourceNameCatalogs, name), catalog)
+}
+
+func (c *Client) DeleteCatalog(ctx context.Context, name string) error {
+	return c.delete(ctx, resourcePath(types.ResourceNameCatalogs, name))
+}
+
+// Variants
+
+func (c *Client) CreateVariant(ctx context.Context, variant any) (string, error) {
+	return c.create(ctx, types.ResourceNameVariants, variant)
+}
+
+func (c *Client) GetVariant(ctx context.Context, name string) (json.RawMessage, error) {
+	return c.get(ctx, resourcePath(types.ResourceNameVariants, name))
+}
+
+func (c *Client) UpdateVariant(ctx context.Context, name string, variant any) error {
+	return c.update(ctx, resourcePath(types.ResourceNameVariants, name), variant)
+}
+
+func (c *Client) DeleteVariant(ctx context.Context, name string) error {
+	return c.delete(ctx, resourcePath(types.ResourceNameVariants, name))
+}
+
+// Namespaces
+
+func (c *Client) CreateNamespace(ctx context.Context, namespace any) (string, error) {
+	return c.create(ctx, types.ResourceNameNamespaces, namespace)
+}
+
+func (c *Client) GetNamespace(ctx context.Context, name string) (json.RawMessage, error) {
+	return c.get(ctx, resourcePath(types.ResourceNameNamespaces, name))
+}
+
+func (c *Client) UpdateNamespace(ctx context.Context, name string, namespace any) error {
+	return c.update(ctx, resourcePath(types.ResourceNameNamespaces, name), namespace)
+}
+
+func (c *Client) DeleteNamespace(ctx context.Context, name string) error {
+	return c.delete(ctx, resourcePath(types.ResourceNameNamespaces, name))
+}
+
+// Workspaces are identified by their label or id
+
+func (c *Client) CreateWorkspace(ctx context.Context, workspace any) (string, error) {
+	return c.create(ctx, types.ResourceNameWorkspaces, workspace)
+}
+
+func (c *Client) GetWorkspace(ctx context.Context, ref string) (json.RawMessage, error) {
+	return c.get(ctx, resourcePath(types.ResourceNameWorkspaces, ref))
+}
+
+func (c *Client) UpdateWorkspace(ctx context.Context, ref string, workspace any) error {
+	return c.update(ctx, resourcePath(types.ResourceNameWorkspaces, ref), workspace)
+}
+
+func (c *Client) DeleteWorkspace(ctx context.Context, ref string) error {
+	return c.delete(ctx, resourcePath(types.ResourceNameWorkspaces, ref))
+}
+
+// Parameter schemas, collection schemas and collections are identified by their path including the name.
+// Save creates the object, or updates it if it already exists.
+
+func (c *Client) SaveParameterSchema(ctx context.Context, schema any) (string, error) {
+	return c.save(ctx, types.ResourceNameParameterSchemas, schema)
+}
+
+func (c *Client) GetParameterSchema(ctx context.Context, objectPath string) (json.RawMessage, error) {
+	return c.get(ctx, resourcePath(types.ResourceNameParameterSchemas, objectPath))
+}
+
+func (c *Client) DeleteParameterSchema(ctx context.Context, objectPath string) error {
+	return c.delete(ctx, resourcePath(types.ResourceNameParameterSchemas, objectPath))
+}
+
+func (c *Client) SaveCollectionSchema(ctx context.Context, schema any) (string, error) {
+	return c.save(ctx, types.ResourceNameCollectionSchemas, schema)
+}
+
+func (c *Client) GetCollectionSchema(ctx context.Context, objectPath string) (json.RawMessage, error) {
+	return c.get(ctx, resourcePath(types.ResourceNameCollectionSchemas, objectPath))
+}
+
+func (c *Client) DeleteCollectionSchema(ctx context.Context, objectPath string) error {
+	return c.delete(ctx, resourcePath(types.ResourceNameCollectionSchemas, objectPath))
+}
+
+func (c *Client) SaveCollection(ctx context.Context, collection any) (string, error) {
+	return c.save(ctx, types.ResourceNameCollections, collection)
+}
+
+func (c *Client) GetCollection(ctx context.Context, objectPath string) (json.RawMessage, error) {
+	return c.get(ctx, resourcePath(types.ResourceNameCollections, objectPath))
+}
+
+// PatchCollectionValues updates only the given values of a collection. A nil value resets the parameter to its default.
+func (c *Client) PatchCollectionValues(ctx context.Context, objectPath string, values map[string]any) error {
+	patch := map[string]any{
+		"spec": map[string]any{
+			"values": values,
+		},
+	}
+	_, err := c.do(ctx, http.MethodPatch, resourcePath(types.ResourceNameCollections, objectPath), patch)
+	return err
+}
+
+func (c *Client) DeleteCollection(ctx context.Context, objectPath string) error {
+	return c.delete(ctx, resourcePath(types.ResourceNameCollections, objectPath))
+}
+
+// Apply creates or updates a resource of any kind, based on its kind and metadata
+func (c *Client) Apply(ctx context.Context, resource any) (string, error) {
+	body, err := marshal(resource)
+	if err != nil {
+		return "", err
+	}
+	kind := gjson.GetBytes(body, "kind").String()
+	switch kind {
+	case types.CatalogKind, types.VariantKind, types.NamespaceKind:
+		resourceName := resourceNameForKind(kind)
+		name := gjson.GetBytes(body, "metadata.name").String()
+		if name == "" {
+			return "", errors.New("missing metadata.name")
+		}
+		loc, err := c.create(ctx, resourceName, body)
+		if errors.Is(err, ErrConflict) {
+			return resourcePath(resourceName, name), c.update(ctx, resourcePath(resourceName, name), body)
+		}
+		return loc, err
+	case types.WorkspaceKind:
+		label := gjson.GetBytes(body, "metadata.label").String()
+		if label != "" {
+			if _, err := c.GetWorkspace(ctx, label); err == nil {
+				return resourcePath(types.ResourceNameWorkspaces, label), c.UpdateWorkspace(ctx, label, body)
+			} else if !errors.Is(err, ErrNotFound) {
+				return "", err
+			}
+		}
+		return c.CreateWorkspace(ctx, body)
+	case types.ParameterSchemaKind, types.CollectionSchemaKind, types.CollectionKind:
+		return c.save(ctx, resourceNameForKind(kind), body)
+	}
+	return "", errors.New("unsupported kind: " + kind)
+}
+
+func resourceNameForKind(kind string) string {
+	switch kind {
+	case types.CatalogKind:
+		return types.ResourceNameCatalogs
+	case types.VariantKind:
+		return types.ResourceNameVariants
+	case types.NamespaceKind:
+		return types.ResourceNameNamespaces
+	case types.WorkspaceKind:
+		return types.ResourceNameWorkspaces
+	}
+	return types.ResourceNameFromObjectType(types.CatalogObjectTypeFromKind(kind))
+}
+
+func resourcePath(resourceName, objectPath string) string {
+	return path.Clean("/" + resourceName + "/" + objectPath)
+}
+
+func (c *Client) create(ctx context.Context, resourceName string, resource any) (string, error) {
+	rsp, err := c.do(ctx, http.MethodPost, "/"+resourceName, resource)
+	if err != nil {
+		return "", err
+	}
+	return rsp.Header.Get("Location"), nil
+}
+
+func (c *Client) save(ctx context.Context, resourceName string, resource any) (string, error) {
+	body, err := marshal(resource)
+	if err != nil {
+		return "", err
+	}
+	loc, err := c.create(ctx, resourceName, body)
+	if !errors.Is(err, ErrConflict) {
+		return loc, err
+	}
+	objectPath := gjson.GetBytes(body, "metadata.path").String()
+	name := gjson.GetBytes(body, "metadata.name").String()
+	if name == "" {
+		return "", errors.New("missing metadata.name")
+	}
+	p := resourcePath(resourceName, objectPath+"/"+name)
+	return p, c.update(ctx, p, body)
+}
+
+func (c *Client) get(ctx context.Context, p string) (json.RawMessage, error) {
+	rsp, err := c.do(ctx, http.MethodGet, p, nil)
+	if err != nil {
+		return nil, err
+	}
+	return rsp.Body, nil
+}
+
+func (c *Client) update(ctx context.Context, p string, resource any) error {
+	_, err := c.do(ctx, http.MethodPut, p, resource)
+	return err
+}
+
+func (c *Client) delete(ctx context.Context, p string) error {
+	_, err := c.do(ctx, http.MethodDelete, p, nil)
+	return err
+}
+
+type response struct {
+	Header http.Header
+	Body   json.RawMessage
+}
+
+func (c *Client) do(ctx context.Context, method, p string, resource any) (*response, error) {
+	var body io.Reader
+	if resource != nil {
+		b, err := marshal(resource)
+		if err != nil {
+			return nil, err
+		}
+		body = bytes.NewReader(b)
+	}
+
+	u := c.baseURL.JoinPath(p)
+	u.RawQuery = c.query(u.Query()).Encode()
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), body)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set(api.TenantIDHeader, c.tenantID)
+	req.Header.Set(api.ProjectIDHeader, c.projectID)
+
+	rsp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer rsp.Body.Close()
+	b, err := io.ReadAll(rsp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if rsp.StatusCode < 200 || rsp.StatusCode > 299 {
+		return nil, &Error{StatusCode: rsp.StatusCode, Message: errorMessage(b)}
+	}
+	return &response{Header: rsp.Header, Body: b}, nil
+}
+
+func (c *Client) query(q url.Values) url.Values {
+	set := func(k, v string) {
+		if v != "" {
+			q.Set(k, v)
+		}
+	}
+	set("catalog", c.scope.Catalog)
+	set("variant", c.scope.Variant)
+	set("namespace", c.scope.Namespace)
+	set("workspace", c.scope.Workspace)
+	return q
+}
+
+func marshal(resource any) ([]byte, error) {
+	switch r := resource.(type) {
+	case []byte:
+		return r, nil
+	case json.RawMessage:
+		return r, nil
+	case string:
+		return []byte(r), nil
+	}
+	return json.Marshal(resource)
+}
+
+func errorMessage(b []byte) string {
+	if !gjson.ValidBytes(b) {
+		return strings.TrimSpace(string(b))
+	}
+	for _, k := range []string{"message", "error", "msg"} {
+		if r := gjson.GetBytes(b, k); r.Exists() {
+			return r.String()
+		}
+	}
+	return strings.TrimSpace(string(b))
+}
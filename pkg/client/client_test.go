@@ -0,0 +1,143 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/mugiliam/hatchcatalogsrv/internal/common"
+	"github.com/mugiliam/hatchcatalogsrv/internal/db"
+	"github.com/mugiliam/hatchcatalogsrv/internal/server"
+	"github.com/mugiliam/hatchcatalogsrv/pkg/api"
+	"github.com/mugiliam/hatchcatalogsrv/pkg/types"
+	"github.com/rs/zerolog/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
+)
+
+func TestErrorMapping(t *testing.T) {
+	assert.ErrorIs(t, &Error{StatusCode: http.StatusNotFound}, ErrNotFound)
+	assert.ErrorIs(t, &Error{StatusCode: http.StatusConflict}, ErrConflict)
+	assert.ErrorIs(t, &Error{StatusCode: http.StatusBadRequest}, ErrBadRequest)
+	assert.ErrorIs(t, &Error{StatusCode: http.StatusServiceUnavailable}, ErrServer)
+	assert.NotErrorIs(t, &Error{StatusCode: http.StatusNotFound}, ErrConflict)
+}
+
+func TestClientRoundTrip(t *testing.T) {
+	tenantID := types.TenantId("TCLIENT")
+	projectID := types.ProjectId("PCLIENT")
+
+	ctx := db.ConnCtx(log.Logger.WithContext(context.Background()))
+	t.Cleanup(func() {
+		db.DB(ctx).Close(ctx)
+	})
+	ctx = common.SetTenantIdInContext(ctx, tenantID)
+	ctx = common.SetProjectIdInContext(ctx, projectID)
+	require.NoError(t, db.DB(ctx).CreateTenant(ctx, tenantID))
+	t.Cleanup(func() {
+		_ = db.DB(ctx).DeleteTenant(ctx, tenantID)
+	})
+	require.NoError(t, db.DB(ctx).CreateProject(ctx, projectID))
+
+	s, err := server.CreateNewServer()
+	require.NoError(t, err)
+	s.MountHandlers()
+	// the server takes the tenant and project from the authenticated request, so the test stands in for
+	// authentication and takes them from the headers the client sends
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := common.SetTenantIdInContext(r.Context(), types.TenantId(r.Header.Get(api.TenantIDHeader)))
+		ctx = common.SetProjectIdInContext(ctx, types.ProjectId(r.Header.Get(api.ProjectIDHeader)))
+		ctx = common.SetCatalogContext(ctx, &common.CatalogContext{})
+		ctx = common.SetTestContext(ctx, true)
+		s.Router.ServeHTTP(w, r.WithContext(ctx))
+	}))
+	defer ts.Close()
+
+	c, err := New(ts.URL, string(tenantID), string(projectID), WithHTTPClient(ts.Client()))
+	require.NoError(t, err)
+	rctx := context.Background()
+
+	// catalogs and variants
+	catalog := map[string]any{
+		"version": "v1",
+		"kind":    "Catalog",
+		"metadata": map[string]any{
+			"name":        "client-catalog",
+			"description": "catalog created by the client",
+		},
+	}
+	_, err = c.CreateCatalog(rctx, catalog)
+	require.NoError(t, err)
+	_, err = c.CreateCatalog(rctx, catalog)
+	assert.ErrorIs(t, err, ErrConflict)
+
+	rsp, err := c.GetCatalog(rctx, "client-catalog")
+	require.NoError(t, err)
+	assert.Equal(t, "catalog created by the client", gjson.GetBytes(rsp, "metadata.description").String())
+
+	_, err = c.GetCatalog(rctx, "missing-catalog")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	c = c.In(Scope{Catalog: "client-catalog"})
+	_, err = c.CreateVariant(rctx, `{"version": "v1", "kind": "Variant", "metadata": {"name": "client-variant"}}`)
+	require.NoError(t, err)
+	c = c.In(Scope{Catalog: "client-catalog", Variant: "client-variant"})
+
+	// schemas and collections
+	_, err = c.Apply(rctx, `{
+		"version": "v1",
+		"kind": "ParameterSchema",
+		"metadata": {"name": "small-int", "path": "/"},
+		"spec": {"dataType": "Integer", "validation": {"minValue": 1, "maxValue": 10}, "default": 5}
+	}`)
+	require.NoError(t, err)
+	_, err = c.SaveCollectionSchema(rctx, `{
+		"version": "v1",
+		"kind": "CollectionSchema",
+		"metadata": {"name": "settings", "path": "/"},
+		"spec": {"parameters": {"retries": {"schema": "small-int"}}}
+	}`)
+	require.NoError(t, err)
+
+	collection := `{
+		"version": "v1",
+		"kind": "Collection",
+		"metadata": {"name": "service", "path": "/apps"},
+		"spec": {"schema": "settings", "values": {"retries": 3}}
+	}`
+	loc, err := c.SaveCollection(rctx, collection)
+	require.NoError(t, err)
+	assert.Contains(t, loc, "/collections/apps/service")
+
+	rsp, err = c.GetCollection(rctx, "/apps/service")
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), gjson.GetBytes(rsp, "spec.values.retries").Int())
+
+	// saving an existing collection updates it
+	_, err = c.Apply(rctx, `{
+		"version": "v1",
+		"kind": "Collection",
+		"metadata": {"name": "service", "path": "/apps"},
+		"spec": {"schema": "settings", "values": {"retries": 4}}
+	}`)
+	require.NoError(t, err)
+	rsp, err = c.GetCollection(rctx, "/apps/service")
+	require.NoError(t, err)
+	assert.Equal(t, int64(4), gjson.GetBytes(rsp, "spec.values.retries").Int())
+
+	require.NoError(t, c.PatchCollectionValues(rctx, "/apps/service", map[string]any{"retries": 6}))
+	rsp, err = c.GetCollection(rctx, "/apps/service")
+	require.NoError(t, err)
+	assert.Equal(t, int64(6), gjson.GetBytes(rsp, "spec.values.retries").Int())
+
+	err = c.PatchCollectionValues(rctx, "/apps/service", map[string]any{"retries": 60})
+	assert.ErrorIs(t, err, ErrBadRequest)
+
+	require.NoError(t, c.DeleteCollection(rctx, "/apps/service"))
+	_, err = c.GetCollection(rctx, "/apps/service")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	require.NoError(t, c.DeleteCatalog(rctx, "client-catalog"))
+}
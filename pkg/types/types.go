@@ -2,6 +2,7 @@ package types
 
 import (
 	"slices"
+	"sync/atomic"
 
 	"github.com/google/uuid"
 )
@@ -13,8 +14,40 @@ type Hash string
 
 const DefaultVariant = "default"
 const InitialVersionLabel = "init"
+
+// DefaultNamespace is the name of the root namespace unless a deployment configures another, see RootNamespace.
+// An object is in the root namespace when its namespace is absent, empty or the name of the root namespace; all
+// three are stored alike. A namespace named "default" has no special meaning: it is an ordinary namespace,
+// distinct from the root namespace.
 const DefaultNamespace = "--root--"
 
+var rootNamespace atomic.Pointer[string]
+
+// RootNamespace returns the name of the root namespace: the name set with SetRootNamespace, or DefaultNamespace
+func RootNamespace() string {
+	if ns := rootNamespace.Load(); ns != nil {
+		return *ns
+	}
+	return DefaultNamespace
+}
+
+// SetRootNamespace sets the name of the root namespace. An empty name restores DefaultNamespace. The name is part
+// of the storage path of every object in the root namespace, so it is set once at startup, before any object
+// is read or written.
+func SetRootNamespace(name string) {
+	if name == "" {
+		rootNamespace.Store(nil)
+		return
+	}
+	rootNamespace.Store(&name)
+}
+
+// IsRootNamespace reports whether the namespace ns refers to the root namespace. DefaultNamespace refers to it
+// as well when the root namespace is configured with another name, so requests that name it explicitly keep working.
+func IsRootNamespace(ns string) bool {
+	return ns == "" || ns == RootNamespace() || ns == DefaultNamespace
+}
+
 func (u CatalogId) String() string {
 	return uuid.UUID(u).String()
 }
@@ -96,7 +129,7 @@ func ResourceNameFromObjectType(t CatalogObjectType) string {
 }
 
 var validResourceNameAndMethod = map[string][]string{
-	ResourceNameCollections:       {"POST", "GET", "PUT", "DELETE"},
+	ResourceNameCollections:       {"POST", "GET", "PUT", "PATCH", "DELETE"},
 	ResourceNameParameterSchemas:  {"POST", "GET", "PUT", "DELETE"},
 	ResourceNameCollectionSchemas: {"POST", "GET", "PUT", "DELETE"},
 	ResourceNameAttributes:        {"GET", "POST", "DELETE"},
@@ -112,7 +145,8 @@ func IsValidResourceNameAndMethod(r string, m string) bool {
 }
 
 const (
-	VersionV1 = "v1"
+	VersionV1      = "v1"
+	VersionV1Beta1 = "v1beta1"
 )
 
 type CatalogObjectType string